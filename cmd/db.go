@@ -0,0 +1,366 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	homedir "github.com/mitchellh/go-homedir"
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/core/db"
+	"github.com/runatlantis/atlantis/server/core/locking"
+	"github.com/runatlantis/atlantis/server/core/redis"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// Flags for the db command. The database flags are the same as the server's
+// so the same environment variables can be used.
+const (
+	BackupDirFlag = "backup-dir"
+	FromFlag      = "from"
+	ToFlag        = "to"
+)
+
+// DBCmd is the db command. It exports, imports, migrates and backs up the
+// database Atlantis stores its locks and pull request statuses in.
+type DBCmd struct {
+	Viper *viper.Viper
+	// Out is where the command's output is written. If nil, it's os.Stdout.
+	Out io.Writer
+}
+
+// closableBackend is a database that must be closed once it's no longer used.
+type closableBackend interface {
+	locking.Backend
+	io.Closer
+}
+
+// Init returns the runnable cobra command.
+func (d *DBCmd) Init() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "db",
+		Short: "Export, import, migrate and back up the Atlantis database",
+		Long: `Export, import, migrate and back up the database Atlantis stores its locks,
+pull request statuses, command locks, API tokens and audit events in.
+
+BoltDB databases can only be opened by one process so Atlantis must be stopped
+before running these commands against one.`,
+	}
+
+	d.Viper.SetEnvPrefix("ATLANTIS")
+	d.Viper.SetEnvKeyReplacer(strings.NewReplacer("-", "_"))
+	d.Viper.AutomaticEnv()
+	d.Viper.SetTypeByDefaultValue(true)
+
+	flags := c.PersistentFlags()
+	flags.String(LockingDBType, DefaultLockingDBType, "The database type, either boltdb or redis.")
+	flags.String(DataDirFlag, DefaultDataDir, "Path to the directory Atlantis stores its BoltDB database in.")
+	flags.String(RedisHost, "", "The Redis hostname.")
+	flags.Int(RedisPort, DefaultRedisPort, "The Redis port.")
+	flags.String(RedisPassword, "", "The Redis password.")
+	flags.Int(RedisDB, DefaultRedisDB, "The Redis database.")
+	flags.Bool(RedisTLSEnabled, DefaultRedisTLSEnabled, "Connect to Redis over TLS.")
+	flags.Bool(RedisInsecureSkipVerify, DefaultRedisInsecureSkipVerify, "Skip verifying the Redis server's TLS certificate.")
+	for _, name := range []string{LockingDBType, DataDirFlag, RedisHost, RedisPort, RedisPassword, RedisDB, RedisTLSEnabled, RedisInsecureSkipVerify} {
+		d.Viper.BindPFlag(name, flags.Lookup(name)) // nolint: errcheck
+	}
+
+	c.AddCommand(d.exportCmd(), d.importCmd(), d.migrateCmd(), d.checkCmd(), d.backupCmd())
+	return c
+}
+
+func (d *DBCmd) exportCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "export [file]",
+		Short: "Write everything in the database to a JSON file, or to stdout if no file is given",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			backend, err := d.openBackend(d.Viper.GetString(LockingDBType))
+			if err != nil {
+				return err
+			}
+			defer backend.Close() // nolint: errcheck
+			dump, err := locking.Export(backend)
+			if err != nil {
+				return errors.Wrap(err, "exporting")
+			}
+			if len(args) == 0 || args[0] == "-" {
+				return writeDump(d.out(), dump)
+			}
+			if err := writeDumpFile(args[0], dump); err != nil {
+				return err
+			}
+			fmt.Fprintf(d.out(), "Exported %s to %s\n", dumpSummary(dump), args[0])
+			return nil
+		},
+	}
+}
+
+func (d *DBCmd) importCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "import <file>",
+		Short: "Restore a file written by export or backup into an empty database",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			dump, err := readDumpFile(args[0])
+			if err != nil {
+				return err
+			}
+			backend, err := d.openBackend(d.Viper.GetString(LockingDBType))
+			if err != nil {
+				return err
+			}
+			defer backend.Close() // nolint: errcheck
+			if err := importAndVerify(backend, dump); err != nil {
+				return err
+			}
+			fmt.Fprintf(d.out(), "Imported %s from %s\n", dumpSummary(dump), args[0])
+			return nil
+		},
+	}
+}
+
+func (d *DBCmd) migrateCmd() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "migrate --from <type> --to <type>",
+		Short: "Copy everything from one database type into another, empty, one",
+		Example: `  atlantis db migrate --from boltdb --to redis --data-dir /atlantis \
+    --redis-host redis.example.com --redis-password "$REDIS_PASSWORD"`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			cmd.SilenceUsage = true
+			from, to, err := d.openFromTo(cmd)
+			if err != nil {
+				return err
+			}
+			defer from.Close() // nolint: errcheck
+			defer to.Close()   // nolint: errcheck
+
+			dump, err := locking.Export(from)
+			if err != nil {
+				return errors.Wrap(err, "exporting")
+			}
+			if err := importAndVerify(to, dump); err != nil {
+				return err
+			}
+			fromType, _ := cmd.Flags().GetString(FromFlag)
+			toType, _ := cmd.Flags().GetString(ToFlag)
+			fmt.Fprintf(d.out(), "Migrated %s from %s to %s\n", dumpSummary(dump), fromType, toType)
+			return nil
+		},
+	}
+	addFromToFlags(c)
+	return c
+}
+
+func (d *DBCmd) checkCmd() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "check --from <type> --to <type>",
+		Short: "Check that two databases contain the same data, ex. after a migration",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			cmd.SilenceUsage = true
+			from, to, err := d.openFromTo(cmd)
+			if err != nil {
+				return err
+			}
+			defer from.Close() // nolint: errcheck
+			defer to.Close()   // nolint: errcheck
+
+			fromDump, err := locking.Export(from)
+			if err != nil {
+				return errors.Wrap(err, "exporting --from database")
+			}
+			toDump, err := locking.Export(to)
+			if err != nil {
+				return errors.Wrap(err, "exporting --to database")
+			}
+			if err := diffsErr(locking.CompareDumps(fromDump, toDump)); err != nil {
+				return err
+			}
+			fmt.Fprintf(d.out(), "Both databases contain %s\n", dumpSummary(fromDump))
+			return nil
+		},
+	}
+	addFromToFlags(c)
+	return c
+}
+
+func (d *DBCmd) backupCmd() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "backup",
+		Short: "Export the database to a timestamped file in the backup directory",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			cmd.SilenceUsage = true
+			dbType := d.Viper.GetString(LockingDBType)
+			dir, _ := cmd.Flags().GetString(BackupDirFlag)
+			if dir == "" {
+				dataDir, err := expandDataDir(d.Viper.GetString(DataDirFlag))
+				if err != nil {
+					return err
+				}
+				dir = filepath.Join(dataDir, "backups")
+			}
+			if err := os.MkdirAll(dir, 0700); err != nil {
+				return errors.Wrap(err, "creating backup directory")
+			}
+
+			backend, err := d.openBackend(dbType)
+			if err != nil {
+				return err
+			}
+			defer backend.Close() // nolint: errcheck
+			dump, err := locking.Export(backend)
+			if err != nil {
+				return errors.Wrap(err, "exporting")
+			}
+			path := filepath.Join(dir, fmt.Sprintf("atlantis-%s-%s.json", dbType, dump.CreatedAt.Format("20060102T150405Z")))
+			if err := writeDumpFile(path, dump); err != nil {
+				return err
+			}
+			// Read the backup back to make sure it can be restored.
+			written, err := readDumpFile(path)
+			if err != nil {
+				return err
+			}
+			if err := diffsErr(locking.CompareDumps(dump, written)); err != nil {
+				return errors.Wrapf(err, "verifying backup %s", path)
+			}
+			fmt.Fprintf(d.out(), "Backed up %s to %s\n", dumpSummary(dump), path)
+			return nil
+		},
+	}
+	c.Flags().String(BackupDirFlag, "", "Directory to write the backup to. Defaults to the backups directory in --data-dir.")
+	return c
+}
+
+func addFromToFlags(c *cobra.Command) {
+	c.Flags().String(FromFlag, "", "The database type to read from, either boltdb or redis.")
+	c.Flags().String(ToFlag, "", "The database type to write to, either boltdb or redis.")
+	c.MarkFlagRequired(FromFlag) // nolint: errcheck
+	c.MarkFlagRequired(ToFlag)   // nolint: errcheck
+}
+
+// openFromTo opens the databases of the --from and --to flags.
+func (d *DBCmd) openFromTo(cmd *cobra.Command) (closableBackend, closableBackend, error) {
+	fromType, _ := cmd.Flags().GetString(FromFlag)
+	toType, _ := cmd.Flags().GetString(ToFlag)
+	if fromType == toType {
+		return nil, nil, fmt.Errorf("--%s and --%s must be different database types", FromFlag, ToFlag)
+	}
+	from, err := d.openBackend(fromType)
+	if err != nil {
+		return nil, nil, err
+	}
+	to, err := d.openBackend(toType)
+	if err != nil {
+		from.Close() // nolint: errcheck
+		return nil, nil, err
+	}
+	return from, to, nil
+}
+
+// openBackend opens the database of type dbType configured by the flags.
+func (d *DBCmd) openBackend(dbType string) (closableBackend, error) {
+	switch dbType {
+	case "boltdb":
+		dataDir, err := expandDataDir(d.Viper.GetString(DataDirFlag))
+		if err != nil {
+			return nil, err
+		}
+		return db.New(dataDir)
+	case "redis":
+		if d.Viper.GetString(RedisHost) == "" {
+			return nil, fmt.Errorf("--%s must be set to use redis", RedisHost)
+		}
+		return redis.New(d.Viper.GetString(RedisHost), d.Viper.GetInt(RedisPort), d.Viper.GetString(RedisPassword),
+			d.Viper.GetBool(RedisTLSEnabled), d.Viper.GetBool(RedisInsecureSkipVerify), d.Viper.GetInt(RedisDB))
+	default:
+		return nil, fmt.Errorf("invalid database type %q, must be boltdb or redis", dbType)
+	}
+}
+
+func (d *DBCmd) out() io.Writer {
+	if d.Out == nil {
+		return os.Stdout
+	}
+	return d.Out
+}
+
+// importAndVerify imports dump into backend and then checks that backend
+// contains exactly what's in dump.
+func importAndVerify(backend locking.Backend, dump locking.Dump) error {
+	if err := locking.Import(backend, dump); err != nil {
+		return errors.Wrap(err, "importing")
+	}
+	imported, err := locking.Export(backend)
+	if err != nil {
+		return errors.Wrap(err, "verifying import")
+	}
+	return errors.Wrap(diffsErr(locking.CompareDumps(dump, imported)), "verifying import")
+}
+
+// diffsErr returns an error listing diffs, or nil if there are none.
+func diffsErr(diffs []string) error {
+	if len(diffs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("databases don't match:\n  %s", strings.Join(diffs, "\n  "))
+}
+
+func dumpSummary(dump locking.Dump) string {
+	return fmt.Sprintf("%d locks, %d pull statuses, %d command locks, %d API tokens and %d audit events",
+		len(dump.Locks), len(dump.PullStatuses), len(dump.CommandLocks), len(dump.APITokens), len(dump.AuditEvents))
+}
+
+func writeDump(w io.Writer, dump locking.Dump) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return errors.Wrap(enc.Encode(dump), "writing dump")
+}
+
+// writeDumpFile writes dump to path. It's only readable by the current user
+// since it contains hashes of API token secrets.
+func writeDumpFile(path string, dump locking.Dump) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return errors.Wrap(err, "creating dump file")
+	}
+	if err := writeDump(f, dump); err != nil {
+		f.Close() // nolint: errcheck
+		return err
+	}
+	return errors.Wrap(f.Close(), "writing dump file")
+}
+
+func readDumpFile(path string) (locking.Dump, error) {
+	var dump locking.Dump
+	contents, err := os.ReadFile(path) // nolint: gosec
+	if err != nil {
+		return dump, errors.Wrap(err, "reading dump file")
+	}
+	if err := json.Unmarshal(contents, &dump); err != nil {
+		return dump, errors.Wrapf(err, "parsing dump file %s", path)
+	}
+	return dump, nil
+}
+
+// expandDataDir converts ~ in dataDir to the home directory like the server
+// does.
+func expandDataDir(dataDir string) (string, error) {
+	if strings.HasPrefix(dataDir, "~/") {
+		expanded, err := homedir.Expand(dataDir)
+		if err != nil {
+			return "", errors.Wrap(err, "determining home directory")
+		}
+		return expanded, nil
+	}
+	return dataDir, nil
+}
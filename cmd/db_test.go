@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/spf13/viper"
+
+	"github.com/runatlantis/atlantis/server/core/db"
+	"github.com/runatlantis/atlantis/server/events/models"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+// runDBCmd runs atlantis db with args and returns its output.
+func runDBCmd(t *testing.T, args ...string) (string, error) {
+	out := &bytes.Buffer{}
+	c := (&DBCmd{Viper: viper.New(), Out: out}).Init()
+	c.SetArgs(args)
+	c.SetOut(&bytes.Buffer{})
+	c.SetErr(&bytes.Buffer{})
+	err := c.Execute()
+	return out.String(), err
+}
+
+func TestDBCmd(t *testing.T) {
+	dataDir := t.TempDir()
+	bolt, err := db.New(dataDir)
+	Ok(t, err)
+	acquired, _, err := bolt.TryLock(models.ProjectLock{
+		Project:   models.NewProject("owner/repo", "."),
+		Workspace: "default",
+		Time:      time.Now().Round(time.Second),
+	})
+	Ok(t, err)
+	Assert(t, acquired, "exp lock to be acquired")
+	Ok(t, bolt.AddAuditEvent(models.AuditEvent{Type: models.LockAuditEvent, Actor: "alice"}, 0))
+	Ok(t, bolt.Close())
+
+	mr := miniredis.RunT(t)
+	redisFlags := []string{"--data-dir", dataDir, "--redis-host", mr.Host(), "--redis-port", fmt.Sprint(mr.Server().Addr().Port)}
+	summary := "1 locks, 0 pull statuses, 0 command locks, 0 API tokens and 1 audit events"
+
+	t.Log("migrating copies everything and checks the copy")
+	out, err := runDBCmd(t, append([]string{"migrate", "--from", "boltdb", "--to", "redis"}, redisFlags...)...)
+	Ok(t, err)
+	Equals(t, fmt.Sprintf("Migrated %s from boltdb to redis\n", summary), out)
+
+	out, err = runDBCmd(t, append([]string{"check", "--from", "redis", "--to", "boltdb"}, redisFlags...)...)
+	Ok(t, err)
+	Equals(t, fmt.Sprintf("Both databases contain %s\n", summary), out)
+
+	t.Log("migrating into a database that isn't empty fails")
+	_, err = runDBCmd(t, append([]string{"migrate", "--from", "boltdb", "--to", "redis"}, redisFlags...)...)
+	ErrContains(t, "the database isn't empty", err)
+
+	_, err = runDBCmd(t, "migrate", "--from", "redis", "--to", "redis")
+	ErrEquals(t, "--from and --to must be different database types", err)
+
+	t.Log("exports can be imported")
+	export := filepath.Join(t.TempDir(), "export.json")
+	out, err = runDBCmd(t, append([]string{"export", export, "--locking-db-type", "redis"}, redisFlags...)...)
+	Ok(t, err)
+	Equals(t, fmt.Sprintf("Exported %s to %s\n", summary, export), out)
+	info, err := os.Stat(export)
+	Ok(t, err)
+	Equals(t, os.FileMode(0600), info.Mode().Perm())
+
+	importDir := t.TempDir()
+	out, err = runDBCmd(t, "import", export, "--data-dir", importDir)
+	Ok(t, err)
+	Equals(t, fmt.Sprintf("Imported %s from %s\n", summary, export), out)
+
+	t.Log("backups are written to the data dir by default")
+	out, err = runDBCmd(t, "backup", "--data-dir", importDir)
+	Ok(t, err)
+	backups, err := filepath.Glob(filepath.Join(importDir, "backups", "atlantis-boltdb-*.json"))
+	Ok(t, err)
+	Equals(t, 1, len(backups))
+	Equals(t, fmt.Sprintf("Backed up %s to %s\n", summary, backups[0]), out)
+}
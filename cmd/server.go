@@ -18,6 +18,7 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
 
 	homedir "github.com/mitchellh/go-homedir"
@@ -27,6 +28,8 @@ import (
 	"github.com/spf13/viper"
 
 	"github.com/runatlantis/atlantis/server"
+	"github.com/runatlantis/atlantis/server/core/runtime/sandbox"
+	"github.com/runatlantis/atlantis/server/events/models"
 	"github.com/runatlantis/atlantis/server/events/vcs/bitbucketcloud"
 	"github.com/runatlantis/atlantis/server/logging"
 )
@@ -50,9 +53,16 @@ const (
 	ADHostnameFlag                   = "azuredevops-hostname"
 	AllowCommandsFlag                = "allow-commands"
 	AllowForkPRsFlag                 = "allow-fork-prs"
+	ApplyTimeoutFlag                 = "apply-timeout"
 	AtlantisURLFlag                  = "atlantis-url"
+	AuditDBFlag                      = "audit-db"
+	AuditDBMaxEventsFlag             = "audit-db-max-events"
+	AuditLogFileFlag                 = "audit-log-file"
+	AuditWebhookURLFlag              = "audit-webhook-url"
 	AutoDiscoverModeFlag             = "autodiscover-mode"
 	AutomergeFlag                    = "automerge"
+	AutomergeChecksTimeoutFlag       = "automerge-checks-timeout"
+	AutomergeMethodFlag              = "automerge-method"
 	ParallelPlanFlag                 = "parallel-plan"
 	ParallelApplyFlag                = "parallel-apply"
 	AutoplanModules                  = "autoplan-modules"
@@ -70,7 +80,9 @@ const (
 	DisableApplyAllFlag              = "disable-apply-all"
 	DisableAutoplanFlag              = "disable-autoplan"
 	DisableAutoplanLabelFlag         = "disable-autoplan-label"
+	DisableDraftPRLockingFlag        = "disable-draft-pr-locking"
 	DisableMarkdownFoldingFlag       = "disable-markdown-folding"
+	DisableLockReaperFlag            = "disable-lock-reaper"
 	DisableRepoLockingFlag           = "disable-repo-locking"
 	DisableUnlockLabelFlag           = "disable-unlock-label"
 	DiscardApprovalOnPlanFlag        = "discard-approval-on-plan"
@@ -92,6 +104,7 @@ const (
 	GHOrganizationFlag               = "gh-org"
 	GHWebhookSecretFlag              = "gh-webhook-secret"               // nolint: gosec
 	GHAllowMergeableBypassApply      = "gh-allow-mergeable-bypass-apply" // nolint: gosec
+	GHMergeQueueVerifyProjectsFlag   = "gh-merge-queue-verify-projects"
 	GitlabHostnameFlag               = "gitlab-hostname"
 	GitlabTokenFlag                  = "gitlab-token"
 	GitlabUserFlag                   = "gitlab-user"
@@ -101,9 +114,12 @@ const (
 	HidePrevPlanComments             = "hide-prev-plan-comments"
 	QuietPolicyChecks                = "quiet-policy-checks"
 	LockingDBType                    = "locking-db-type"
+	LockReaperIntervalFlag           = "lock-reaper-interval"
 	LogLevelFlag                     = "log-level"
 	MarkdownTemplateOverridesDirFlag = "markdown-template-overrides-dir"
+	MaxConcurrentProjectsFlag        = "max-concurrent-projects"
 	ParallelPoolSize                 = "parallel-pool-size"
+	PlanTimeoutFlag                  = "plan-timeout"
 	StatsNamespace                   = "stats-namespace"
 	AllowDraftPRs                    = "allow-draft-prs"
 	PortFlag                         = "port"
@@ -116,6 +132,12 @@ const (
 	RepoConfigFlag                   = "repo-config"
 	RepoConfigJSONFlag               = "repo-config-json"
 	RepoAllowlistFlag                = "repo-allowlist"
+	SandboxFlag                      = "sandbox"
+	SandboxCgroupDirFlag             = "sandbox-cgroup-dir"
+	SandboxCPUTimeLimitFlag          = "sandbox-cpu-time-limit"
+	SandboxMaxProcessesFlag          = "sandbox-max-processes"
+	SandboxMemoryLimitFlag           = "sandbox-memory-limit"
+	SandboxUIDsFlag                  = "sandbox-uids"
 	SilenceNoProjectsFlag            = "silence-no-projects"
 	SilenceForkPRErrorsFlag          = "silence-fork-pr-errors"
 	SilenceVCSStatusNoPlans          = "silence-vcs-status-no-plans"
@@ -137,6 +159,16 @@ const (
 	WebBasicAuthFlag                 = "web-basic-auth"
 	WebUsernameFlag                  = "web-username"
 	WebPasswordFlag                  = "web-password"
+	WebOIDCClientIDFlag              = "web-oidc-client-id"
+	WebOIDCClientSecretFlag          = "web-oidc-client-secret"
+	WebOIDCGroupRolesFlag            = "web-oidc-group-roles"
+	WebOIDCGroupsClaimFlag           = "web-oidc-groups-claim"
+	WebOIDCIssuerURLFlag             = "web-oidc-issuer-url"
+	WebOIDCScopesFlag                = "web-oidc-scopes"
+	WebOIDCUsernameClaimFlag         = "web-oidc-username-claim"
+	WebSessionSecretFlag             = "web-session-secret"
+	WorkerRunnerSecretsFlag          = "worker-runner-secrets"
+	WorkerSecretFlag                 = "worker-secret"
 	WebsocketCheckOrigin             = "websocket-check-origin"
 
 	// NOTE: Must manually set these as defaults in the setDefaults function.
@@ -144,8 +176,10 @@ const (
 	DefaultADBasicPassword              = ""
 	DefaultADHostname                   = "dev.azure.com"
 	DefaultAutoDiscoverMode             = "auto"
+	DefaultAutomergeChecksTimeout       = 0
 	DefaultAutoplanFileList             = "**/*.tf,**/*.tfvars,**/*.tfvars.json,**/terragrunt.hcl,**/.terraform.lock.hcl"
 	DefaultAllowCommands                = "version,plan,apply,unlock,approve_policies"
+	DefaultAuditDBMaxEvents             = 100000
 	DefaultCheckoutStrategy             = CheckoutStrategyBranch
 	DefaultCheckoutDepth                = 0
 	DefaultBitbucketBaseURL             = bitbucketcloud.BaseURL
@@ -156,6 +190,7 @@ const (
 	DefaultGHHostname                   = "github.com"
 	DefaultGitlabHostname               = "gitlab.com"
 	DefaultLockingDBType                = "boltdb"
+	DefaultLockReaperInterval           = 10
 	DefaultLogLevel                     = "info"
 	DefaultParallelPoolSize             = 15
 	DefaultStatsNamespace               = "atlantis"
@@ -164,6 +199,7 @@ const (
 	DefaultRedisPort                    = 6379
 	DefaultRedisTLSEnabled              = false
 	DefaultRedisInsecureSkipVerify      = false
+	DefaultSandboxUIDs                  = "100000-100999"
 	DefaultTFDownloadURL                = "https://releases.hashicorp.com"
 	DefaultTFDownload                   = true
 	DefaultTFEHostname                  = "app.terraform.io"
@@ -171,6 +207,9 @@ const (
 	DefaultWebBasicAuth                 = false
 	DefaultWebUsername                  = "atlantis"
 	DefaultWebPassword                  = "atlantis"
+	DefaultWebOIDCGroupsClaim           = "groups"
+	DefaultWebOIDCScopes                = "openid,profile,email"
+	DefaultWebOIDCUsernameClaim         = "email"
 )
 
 var stringFlags = map[string]stringFlag{
@@ -203,6 +242,16 @@ var stringFlags = map[string]stringFlag{
 	AtlantisURLFlag: {
 		description: "URL that Atlantis can be reached at. Defaults to http://$(hostname):$port where $port is from --" + PortFlag + ". Supports a base path ex. https://example.com/basepath.",
 	},
+	AuditLogFileFlag: {
+		description: "Path to a file that audit events for privileged actions, ex. applies and deleted locks, are appended to as JSON lines.",
+	},
+	AuditWebhookURLFlag: {
+		description: "URL that audit events for privileged actions, ex. applies and deleted locks, are POSTed to as JSON.",
+	},
+	AutomergeMethodFlag: {
+		description: "Method used to merge pull requests when automerging. Accepts 'merge', 'squash' or 'rebase'." +
+			" If not set, the VCS host's default method is used.",
+	},
 	AutoDiscoverModeFlag: {
 		description: "Auto discover mode controls whether projects in a repo are discovered by Atlantis. Defaults to 'auto' which " +
 			"means projects will be discovered when no explicit projects are defined in repo config. Also supports 'enabled' (always " +
@@ -354,6 +403,14 @@ var stringFlags = map[string]stringFlag{
 	RedisHost: {
 		description: "The Redis Hostname for when using a Locking DB type of 'redis'.",
 	},
+	SandboxCgroupDirFlag: {
+		description: "cgroup v2 directory delegated to Atlantis that the cgroups of sandboxed jobs are created in, ex. /sys/fs/cgroup/atlantis." +
+			" If not set, sandbox memory and process limits are enforced for each process with rlimits instead of for the whole job.",
+	},
+	SandboxUIDsFlag: {
+		description:  "Range of UIDs sandboxed jobs run as, ex. 100000-100999. Each job that runs at the same time needs its own UID.",
+		defaultValue: DefaultSandboxUIDs,
+	},
 	RedisPassword: {
 		description: "The Redis Password for when using a Locking DB type of 'redis'.",
 	},
@@ -411,6 +468,49 @@ var stringFlags = map[string]stringFlag{
 		description:  "Password used for Web Basic Authentication on Atlantis HTTP Middleware",
 		defaultValue: DefaultWebPassword,
 	},
+	WebOIDCClientIDFlag: {
+		description: "Client ID of Atlantis' application with the OpenID Connect provider set by --" + WebOIDCIssuerURLFlag + ".",
+	},
+	WebOIDCClientSecretFlag: {
+		description: "Client secret of Atlantis' application with the OpenID Connect provider." +
+			" Can be omitted for public clients since Atlantis uses PKCE." +
+			" Should be specified via the ATLANTIS_WEB_OIDC_CLIENT_SECRET environment variable.",
+	},
+	WebOIDCGroupRolesFlag: {
+		description: "Comma-separated list of group=role pairs granting roles (viewer, operator or admin) to the groups in the ID token's groups claim," +
+			" ex. 'platform=admin,developers=operator'. If set, users must be in one of the groups to log in.",
+	},
+	WebOIDCGroupsClaimFlag: {
+		description:  "ID token claim listing the user's groups.",
+		defaultValue: DefaultWebOIDCGroupsClaim,
+	},
+	WebOIDCIssuerURLFlag: {
+		description: "Issuer URL of an OpenID Connect provider, ex. https://accounts.google.com. If set, users log in to the web UI with single sign-on." +
+			" Basic authentication, if enabled with --" + WebBasicAuthFlag + ", is still accepted.",
+	},
+	WebOIDCScopesFlag: {
+		description:  "Comma-separated list of scopes to request from the OpenID Connect provider.",
+		defaultValue: DefaultWebOIDCScopes,
+	},
+	WebOIDCUsernameClaimFlag: {
+		description:  "ID token claim used as the user's name. Falls back to the subject if the token doesn't have it.",
+		defaultValue: DefaultWebOIDCUsernameClaim,
+	},
+	WebSessionSecretFlag: {
+		description: "Secret used to sign web UI session cookies. If not set, a random secret is generated on startup so users are logged out when Atlantis restarts." +
+			" Must be the same on all Atlantis servers behind a load balancer." +
+			" Should be specified via the ATLANTIS_WEB_SESSION_SECRET environment variable.",
+	},
+	WorkerRunnerSecretsFlag: {
+		description: "Secrets remote workers authenticate with to pull the jobs of some runners only, formatted as {runner}:{secret},{runner}:{secret}." +
+			" Workers can only claim the jobs of the runners their secret is for." +
+			" Should be specified via the ATLANTIS_WORKER_RUNNER_SECRETS environment variable.",
+	},
+	WorkerSecretFlag: {
+		description: "Secret remote workers authenticate with to pull the jobs of projects that set a runner, whatever their runner." +
+			" Workers are disabled if neither this nor --" + WorkerRunnerSecretsFlag + " is set." +
+			" Should be specified via the ATLANTIS_WORKER_SECRET environment variable.",
+	},
 }
 
 var boolFlags = map[string]boolFlag{
@@ -418,6 +518,10 @@ var boolFlags = map[string]boolFlag{
 		description:  "Allow Atlantis to run on pull requests from forks. A security issue for public repos.",
 		defaultValue: false,
 	},
+	AuditDBFlag: {
+		description:  "Store audit events for privileged actions, ex. applies and deleted locks, in the locking database so that they can be queried from /api/audit.",
+		defaultValue: false,
+	},
 	AutoplanModules: {
 		description:  "Automatically plan projects that have a changed module from the local repository.",
 		defaultValue: false,
@@ -434,6 +538,14 @@ var boolFlags = map[string]boolFlag{
 		description:  "Disable atlantis auto planning feature",
 		defaultValue: false,
 	},
+	DisableDraftPRLockingFlag: {
+		description:  "Refuse to lock projects for draft pull requests. Plans on draft pull requests will fail until they're marked as ready for review.",
+		defaultValue: false,
+	},
+	DisableLockReaperFlag: {
+		description:  "Disable the job that releases expired locks and locks on closed pull requests.",
+		defaultValue: false,
+	},
 	DisableRepoLockingFlag: {
 		description: "Disable atlantis locking repos",
 	},
@@ -461,8 +573,12 @@ var boolFlags = map[string]boolFlag{
 		description:  "Feature flag to enable functionality to allow mergeable check to ignore apply required check",
 		defaultValue: false,
 	},
+	GHMergeQueueVerifyProjectsFlag: {
+		description:  "Fail the statuses of GitHub merge queue groups that modify files outside of the projects planned for their pull request.",
+		defaultValue: false,
+	},
 	AllowDraftPRs: {
-		description:  "Enable autoplan for Github Draft Pull Requests",
+		description:  "Enable autoplan for draft pull requests on GitHub, GitLab and Azure DevOps",
 		defaultValue: false,
 	},
 	HidePrevPlanComments: {
@@ -494,6 +610,11 @@ var boolFlags = map[string]boolFlag{
 		description:  "Controls whether the Redis client verifies the Redis server's certificate chain and host name. If true, accepts any certificate presented by the server and any host name in that certificate.",
 		defaultValue: DefaultRedisInsecureSkipVerify,
 	},
+	SandboxFlag: {
+		description: "Run terraform and run steps in a sandbox where they can only access their clone, as a dedicated user for each job." +
+			" Only supported on Linux. Atlantis must run as root.",
+		defaultValue: false,
+	},
 	SilenceNoProjectsFlag: {
 		description:  "Silences Atlants from responding to PRs when it finds no projects.",
 		defaultValue: false,
@@ -553,20 +674,66 @@ var boolFlags = map[string]boolFlag{
 	},
 }
 var intFlags = map[string]intFlag{
+	ApplyTimeoutFlag: {
+		description: "Number of minutes apply can run for projects that don't set apply_timeout before it's killed." +
+			" Defaults to 0 which means applies don't time out.",
+		defaultValue: 0,
+	},
+	AuditDBMaxEventsFlag: {
+		description: fmt.Sprintf("Used only if --%s is set. Number of audit events kept in the locking database. The oldest events are deleted once there are more."+
+			" Defaults to %d.", AuditDBFlag, DefaultAuditDBMaxEvents),
+		defaultValue: DefaultAuditDBMaxEvents,
+	},
+	AutomergeChecksTimeoutFlag: {
+		description: fmt.Sprintf("Used only if automerge is enabled. Number of minutes to wait for the pull request's required statuses and checks to pass before abandoning the merge."+
+			" Defaults to %d, which means merge without waiting.", DefaultAutomergeChecksTimeout),
+		defaultValue: DefaultAutomergeChecksTimeout,
+	},
 	CheckoutDepthFlag: {
 		description: fmt.Sprintf("Used only if --%s=%s.", CheckoutStrategyFlag, CheckoutStrategyMerge) +
 			" How many commits to include in each of base and feature branches when cloning repository." +
 			" If merge base is further behind than this number of commits from any of branches heads, full fetch will be performed.",
 		defaultValue: DefaultCheckoutDepth,
 	},
+	LockReaperIntervalFlag: {
+		description: fmt.Sprintf("Number of minutes between runs of the job that releases expired locks and locks on closed pull requests."+
+			" Defaults to %d.", DefaultLockReaperInterval),
+		defaultValue: DefaultLockReaperInterval,
+	},
+	MaxConcurrentProjectsFlag: {
+		description: "Max number of project commands, like plans and applies, that run at once across all pull requests." +
+			" Commands over the limit are queued, applies first, then plans, then autoplans." +
+			" Defaults to 0 which means there's no limit.",
+		defaultValue: 0,
+	},
 	ParallelPoolSize: {
 		description:  "Max size of the wait group that runs parallel plans and applies (if enabled).",
 		defaultValue: DefaultParallelPoolSize,
 	},
+	PlanTimeoutFlag: {
+		description: "Number of minutes plan can run for projects that don't set plan_timeout before it's killed." +
+			" Defaults to 0 which means plans don't time out.",
+		defaultValue: 0,
+	},
 	PortFlag: {
 		description:  "Port to bind to.",
 		defaultValue: DefaultPort,
 	},
+	SandboxCPUTimeLimitFlag: {
+		description: "Number of minutes of CPU time each process of a sandboxed job can use before it's killed." +
+			" Defaults to 0 which means no limit.",
+		defaultValue: 0,
+	},
+	SandboxMaxProcessesFlag: {
+		description: "Maximum number of processes a sandboxed job can run at the same time." +
+			" Defaults to 0 which means no limit.",
+		defaultValue: 0,
+	},
+	SandboxMemoryLimitFlag: {
+		description: "Maximum memory in MiB a sandboxed job can use before it's killed." +
+			" Defaults to 0 which means no limit.",
+		defaultValue: 0,
+	},
 	RedisDB: {
 		description:  "The Redis Database to use when using a Locking DB type of 'redis'.",
 		defaultValue: DefaultRedisDB,
@@ -786,6 +953,9 @@ func (s *ServerCmd) setDefaults(c *server.UserConfig) {
 	if c.AutoplanFileList == "" {
 		c.AutoplanFileList = DefaultAutoplanFileList
 	}
+	if c.AuditDBMaxEvents == 0 {
+		c.AuditDBMaxEvents = DefaultAuditDBMaxEvents
+	}
 	if c.CheckoutDepth <= 0 {
 		c.CheckoutDepth = DefaultCheckoutDepth
 	}
@@ -816,6 +986,9 @@ func (s *ServerCmd) setDefaults(c *server.UserConfig) {
 	if c.LockingDBType == "" {
 		c.LockingDBType = DefaultLockingDBType
 	}
+	if c.LockReaperInterval == 0 {
+		c.LockReaperInterval = DefaultLockReaperInterval
+	}
 	if c.LogLevel == "" {
 		c.LogLevel = DefaultLogLevel
 	}
@@ -831,6 +1004,9 @@ func (s *ServerCmd) setDefaults(c *server.UserConfig) {
 	if c.Port == 0 {
 		c.Port = DefaultPort
 	}
+	if c.SandboxUIDs == "" {
+		c.SandboxUIDs = DefaultSandboxUIDs
+	}
 	if c.RedisDB == 0 {
 		c.RedisDB = DefaultRedisDB
 	}
@@ -852,6 +1028,15 @@ func (s *ServerCmd) setDefaults(c *server.UserConfig) {
 	if c.WebPassword == "" {
 		c.WebPassword = DefaultWebPassword
 	}
+	if c.WebOIDCGroupsClaim == "" {
+		c.WebOIDCGroupsClaim = DefaultWebOIDCGroupsClaim
+	}
+	if c.WebOIDCScopes == "" {
+		c.WebOIDCScopes = DefaultWebOIDCScopes
+	}
+	if c.WebOIDCUsernameClaim == "" {
+		c.WebOIDCUsernameClaim = DefaultWebOIDCUsernameClaim
+	}
 	if c.AutoDiscoverModeFlag == "" {
 		c.AutoDiscoverModeFlag = DefaultAutoDiscoverMode
 	}
@@ -863,6 +1048,54 @@ func (s *ServerCmd) validate(userConfig server.UserConfig) error {
 		return fmt.Errorf("invalid log level: must be one of %v", ValidLogLevels)
 	}
 
+	switch models.MergeMethod(userConfig.AutomergeMethod) {
+	case "", models.MergeCommitMergeMethod, models.SquashMergeMethod, models.RebaseMergeMethod:
+	default:
+		return fmt.Errorf("invalid --%s: not one of %s, %s or %s", AutomergeMethodFlag,
+			models.MergeCommitMergeMethod, models.SquashMergeMethod, models.RebaseMergeMethod)
+	}
+	if userConfig.WebOIDCIssuerURL != "" && userConfig.WebOIDCClientID == "" {
+		return fmt.Errorf("--%s must be set if --%s is set", WebOIDCClientIDFlag, WebOIDCIssuerURLFlag)
+	}
+	if _, err := userConfig.ToOIDCGroupRoles(); err != nil {
+		return errors.Wrapf(err, "invalid --%s", WebOIDCGroupRolesFlag)
+	}
+	if userConfig.AuditDBMaxEvents < 0 {
+		return fmt.Errorf("--%s can't be negative", AuditDBMaxEventsFlag)
+	}
+	if userConfig.AutomergeChecksTimeout < 0 {
+		return fmt.Errorf("--%s can't be negative", AutomergeChecksTimeoutFlag)
+	}
+	if userConfig.LockReaperInterval < 0 {
+		return fmt.Errorf("--%s can't be negative", LockReaperIntervalFlag)
+	}
+	if userConfig.PlanTimeout < 0 {
+		return fmt.Errorf("--%s can't be negative", PlanTimeoutFlag)
+	}
+	if userConfig.ApplyTimeout < 0 {
+		return fmt.Errorf("--%s can't be negative", ApplyTimeoutFlag)
+	}
+	if userConfig.MaxConcurrentProjects < 0 {
+		return fmt.Errorf("--%s can't be negative", MaxConcurrentProjectsFlag)
+	}
+	if userConfig.SandboxCPUTimeLimit < 0 {
+		return fmt.Errorf("--%s can't be negative", SandboxCPUTimeLimitFlag)
+	}
+	if userConfig.SandboxMaxProcesses < 0 {
+		return fmt.Errorf("--%s can't be negative", SandboxMaxProcessesFlag)
+	}
+	if userConfig.SandboxMemoryLimit < 0 {
+		return fmt.Errorf("--%s can't be negative", SandboxMemoryLimitFlag)
+	}
+	if userConfig.Sandbox {
+		if runtime.GOOS != "linux" {
+			return fmt.Errorf("--%s is only supported on Linux", SandboxFlag)
+		}
+		if _, _, err := sandbox.ParseUIDRange(userConfig.SandboxUIDs); err != nil {
+			return errors.Wrapf(err, "invalid --%s", SandboxUIDsFlag)
+		}
+	}
+
 	checkoutStrategy := userConfig.CheckoutStrategy
 	if checkoutStrategy != CheckoutStrategyBranch && checkoutStrategy != CheckoutStrategyMerge {
 		return fmt.Errorf("invalid checkout strategy: not one of %s or %s",
@@ -915,6 +1148,16 @@ func (s *ServerCmd) validate(userConfig server.UserConfig) error {
 		return fmt.Errorf("--%s must have http:// or https://, got %q", BitbucketBaseURLFlag, userConfig.BitbucketBaseURL)
 	}
 
+	if userConfig.AuditWebhookURL != "" {
+		parsed, err := url.Parse(userConfig.AuditWebhookURL)
+		if err != nil {
+			return fmt.Errorf("error parsing --%s flag value %q: %s", AuditWebhookURLFlag, userConfig.AuditWebhookURL, err)
+		}
+		if parsed.Scheme != "http" && parsed.Scheme != "https" {
+			return fmt.Errorf("--%s must have http:// or https://, got %q", AuditWebhookURLFlag, userConfig.AuditWebhookURL)
+		}
+	}
+
 	if userConfig.RepoConfig != "" && userConfig.RepoConfigJSON != "" {
 		return fmt.Errorf("cannot use --%s and --%s at the same time", RepoConfigFlag, RepoConfigJSONFlag)
 	}
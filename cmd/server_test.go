@@ -63,8 +63,15 @@ var testFlags = map[string]interface{}{
 	AllowCommandsFlag:                "version,plan,apply,unlock,import,approve_policies",
 	AllowForkPRsFlag:                 true,
 	APISecretFlag:                    "",
+	AuditDBFlag:                      true,
+	AuditDBMaxEventsFlag:             500,
+	AuditLogFileFlag:                 "/audit.log",
+	AuditWebhookURLFlag:              "https://example.com/audit",
 	AutoDiscoverModeFlag:             "auto",
 	AutomergeFlag:                    true,
+	AutomergeChecksTimeoutFlag:       30,
+	AutomergeMethodFlag:              "squash",
+	ApplyTimeoutFlag:                 60,
 	AutoplanFileListFlag:             "**/*.tf,**/*.yml",
 	BitbucketBaseURLFlag:             "https://bitbucket-base-url.com",
 	BitbucketTokenFlag:               "bitbucket-token",
@@ -75,13 +82,16 @@ var testFlags = map[string]interface{}{
 	DataDirFlag:                      "/path",
 	DefaultTFVersionFlag:             "v0.11.0",
 	DisableApplyAllFlag:              true,
+	DisableDraftPRLockingFlag:        true,
 	DisableMarkdownFoldingFlag:       true,
+	DisableLockReaperFlag:            true,
 	DisableRepoLockingFlag:           true,
 	DiscardApprovalOnPlanFlag:        true,
 	EmojiReaction:                    "eyes",
 	ExecutableName:                   "atlantis",
 	FailOnPreWorkflowHookError:       false,
 	GHAllowMergeableBypassApply:      false,
+	GHMergeQueueVerifyProjectsFlag:   true,
 	GHHostnameFlag:                   "ghhostname",
 	GHTeamAllowlistFlag:              "",
 	GHTokenFlag:                      "token",
@@ -100,12 +110,15 @@ var testFlags = map[string]interface{}{
 	HidePrevPlanComments:             false,
 	IncludeGitUntrackedFiles:         false,
 	LockingDBType:                    "boltdb",
+	LockReaperIntervalFlag:           5,
 	LogLevelFlag:                     "debug",
 	MarkdownTemplateOverridesDirFlag: "/path2",
+	MaxConcurrentProjectsFlag:        4,
 	StatsNamespace:                   "atlantis",
 	AllowDraftPRs:                    true,
 	PortFlag:                         8181,
 	ParallelPoolSize:                 100,
+	PlanTimeoutFlag:                  30,
 	ParallelPlanFlag:                 true,
 	ParallelApplyFlag:                true,
 	QuietPolicyChecks:                false,
@@ -118,6 +131,12 @@ var testFlags = map[string]interface{}{
 	RepoAllowlistFlag:                "github.com/runatlantis/atlantis",
 	RepoConfigFlag:                   "",
 	RepoConfigJSONFlag:               "",
+	SandboxFlag:                      false,
+	SandboxCgroupDirFlag:             "/sys/fs/cgroup/atlantis",
+	SandboxCPUTimeLimitFlag:          30,
+	SandboxMaxProcessesFlag:          256,
+	SandboxMemoryLimitFlag:           2048,
+	SandboxUIDsFlag:                  "200000-200099",
 	SilenceNoProjectsFlag:            false,
 	SilenceForkPRErrorsFlag:          true,
 	SilenceAllowlistErrorsFlag:       true,
@@ -137,8 +156,18 @@ var testFlags = map[string]interface{}{
 	VCSStatusName:                    "my-status",
 	WebBasicAuthFlag:                 false,
 	WebPasswordFlag:                  "atlantis",
+	WebOIDCClientIDFlag:              "client-id",
+	WebOIDCClientSecretFlag:          "client-secret",
+	WebOIDCGroupRolesFlag:            "admins=admin",
+	WebOIDCGroupsClaimFlag:           "roles",
+	WebOIDCIssuerURLFlag:             "https://idp.example.com",
+	WebOIDCScopesFlag:                "openid,email",
+	WebOIDCUsernameClaimFlag:         "preferred_username",
+	WebSessionSecretFlag:             "session-secret",
 	WebUsernameFlag:                  "atlantis",
 	WebsocketCheckOrigin:             false,
+	WorkerRunnerSecretsFlag:          "prod:prod-secret",
+	WorkerSecretFlag:                 "worker-secret",
 	WriteGitCredsFlag:                true,
 	DisableAutoplanFlag:              true,
 	DisableAutoplanLabelFlag:         "no-auto-plan",
@@ -345,6 +374,39 @@ func TestExecute_ValidateCheckoutStrategy(t *testing.T) {
 	ErrEquals(t, "invalid checkout strategy: not one of branch or merge", err)
 }
 
+func TestExecute_ValidateAutomergeMethod(t *testing.T) {
+	c := setupWithDefaults(map[string]interface{}{
+		AutomergeMethodFlag: "invalid",
+	}, t)
+	err := c.Execute()
+	ErrEquals(t, "invalid --automerge-method: not one of merge, squash or rebase", err)
+}
+
+func TestExecute_AutomergeChecksTimeout(t *testing.T) {
+	t.Log("by default automerge doesn't wait for checks")
+	c := setupWithDefaults(map[string]interface{}{}, t)
+	Ok(t, c.Execute())
+	Equals(t, 0, passedConfig.AutomergeChecksTimeout)
+
+	c = setupWithDefaults(map[string]interface{}{
+		AutomergeChecksTimeoutFlag: -1,
+	}, t)
+	ErrEquals(t, "--automerge-checks-timeout can't be negative", c.Execute())
+}
+
+func TestExecute_MaxConcurrentProjects(t *testing.T) {
+	c := setupWithDefaults(map[string]interface{}{
+		MaxConcurrentProjectsFlag: 5,
+	}, t)
+	Ok(t, c.Execute())
+	Equals(t, 5, passedConfig.MaxConcurrentProjects)
+
+	c = setupWithDefaults(map[string]interface{}{
+		MaxConcurrentProjectsFlag: -1,
+	}, t)
+	ErrEquals(t, "--max-concurrent-projects can't be negative", c.Execute())
+}
+
 func TestExecute_ValidateSSLConfig(t *testing.T) {
 	expErr := "--ssl-key-file and --ssl-cert-file are both required for ssl"
 	cases := []struct {
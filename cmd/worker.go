@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+
+	"github.com/runatlantis/atlantis/server"
+	"github.com/runatlantis/atlantis/server/remote"
+)
+
+// Flags for the worker command. Flags it shares with the server, like
+// --data-dir, have the same names so the same environment variables can be
+// used.
+const (
+	CloneTTLFlag   = "clone-ttl"
+	RunnersFlag    = "runners"
+	WorkerNameFlag = "worker-name"
+)
+
+// WorkerCmd is the worker command. It runs the jobs of projects with a runner
+// label that an Atlantis server queues.
+type WorkerCmd struct {
+	WorkerCreator   WorkerCreator
+	Viper           *viper.Viper
+	AtlantisVersion string
+}
+
+// WorkerCreator creates workers.
+// It's an abstraction to help us test.
+type WorkerCreator interface {
+	NewWorker(config server.WorkerConfig) (WorkerRunner, error)
+}
+
+// WorkerRunner runs jobs until ctx is done.
+// It's an abstraction to help us test.
+type WorkerRunner interface {
+	Run(ctx context.Context) error
+}
+
+// DefaultWorkerCreator is the concrete implementation of WorkerCreator.
+type DefaultWorkerCreator struct{}
+
+// NewWorker returns the real Atlantis worker object.
+func (d *DefaultWorkerCreator) NewWorker(config server.WorkerConfig) (WorkerRunner, error) {
+	return server.NewWorker(config)
+}
+
+// Init returns the runnable cobra command.
+func (w *WorkerCmd) Init() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "worker",
+		Short: "Run the jobs of projects with a runner on this machine",
+		Long: `Pull the jobs of projects with a runner label from an Atlantis server and run
+them on this machine, ex. in a separate cloud account.
+
+Workers run the plans, applies and other commands of these projects in their
+own clone with their own credentials, and stream the output back to the server,
+which still takes locks and comments on pull requests.`,
+		Example: `  ATLANTIS_WORKER_SECRET=secret atlantis worker \
+    --atlantis-url https://atlantis.example.com --runners prod-account`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			cmd.SilenceUsage = true
+			return w.run()
+		},
+	}
+
+	w.Viper.SetEnvPrefix("ATLANTIS")
+	w.Viper.SetEnvKeyReplacer(strings.NewReplacer("-", "_"))
+	w.Viper.AutomaticEnv()
+	w.Viper.SetTypeByDefaultValue(true)
+
+	flags := c.Flags()
+	flags.String(AtlantisURLFlag, "", "URL of the Atlantis server to pull jobs from. Jobs are only queued on the server that received the pull request's webhook, so every server must be reachable.")
+	flags.String(WorkerSecretFlag, "", "Secret to authenticate to the Atlantis server with, the secret of the worker's runners in the server's --worker-runner-secrets or its --worker-secret. Should be specified via the ATLANTIS_WORKER_SECRET environment variable.")
+	flags.String(WorkerNameFlag, "", "Unique name of the worker. Must stay the same when the worker restarts since applies run on the worker that planned the project. Defaults to the hostname.")
+	flags.String(RunnersFlag, "", "Comma-separated runner labels of the projects to run jobs for, ex. prod-account.")
+	flags.String(DataDirFlag, DefaultDataDir, "Path to the directory the worker keeps its clones and binaries in.")
+	flags.String(CheckoutStrategyFlag, DefaultCheckoutStrategy, "How to check out pull requests, either branch or merge. Should be the same as the server's.")
+	flags.Int(CheckoutDepthFlag, DefaultCheckoutDepth, "Number of commits to fetch from the branch. 0 fetches the whole history.")
+	flags.String(CloneTTLFlag, remote.DefaultCloneTTL.String(), "How long to keep clones that no job used, ex. 72h. 0 keeps them forever.")
+	flags.String(DefaultTFVersionFlag, "", "Terraform version to default to when a project doesn't set one. Defaults to the terraform binary on the PATH.")
+	flags.Bool(TFDownloadFlag, DefaultTFDownload, "Allow downloading the Terraform versions projects set.")
+	flags.String(TFDownloadURLFlag, DefaultTFDownloadURL, "Base URL to download Terraform versions from.")
+	flags.Bool(UseTFPluginCache, true, "Share a Terraform plugin cache between jobs.")
+	flags.String(LogLevelFlag, DefaultLogLevel, "Log level. Either debug, info, warn, or error.")
+	flags.VisitAll(func(f *pflag.Flag) {
+		w.Viper.BindPFlag(f.Name, f) // nolint: errcheck
+	})
+	return c
+}
+
+func (w *WorkerCmd) run() error {
+	config, err := w.config()
+	if err != nil {
+		return err
+	}
+	worker, err := w.WorkerCreator.NewWorker(config)
+	if err != nil {
+		return errors.Wrap(err, "initializing worker")
+	}
+
+	// A job that's running when the worker is stopped is finished first.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return worker.Run(ctx)
+}
+
+// config returns the configuration of the worker set by its flags.
+func (w *WorkerCmd) config() (server.WorkerConfig, error) {
+	config := server.WorkerConfig{
+		AtlantisURL:          w.Viper.GetString(AtlantisURLFlag),
+		AtlantisVersion:      w.AtlantisVersion,
+		CheckoutDepth:        w.Viper.GetInt(CheckoutDepthFlag),
+		CheckoutStrategy:     w.Viper.GetString(CheckoutStrategyFlag),
+		DefaultTFVersion:     w.Viper.GetString(DefaultTFVersionFlag),
+		DefaultTFVersionFlag: DefaultTFVersionFlag,
+		LogLevel:             server.UserConfig{LogLevel: w.Viper.GetString(LogLevelFlag)}.ToLogLevel(),
+		Name:                 w.Viper.GetString(WorkerNameFlag),
+		TFDownload:           w.Viper.GetBool(TFDownloadFlag),
+		TFDownloadURL:        w.Viper.GetString(TFDownloadURLFlag),
+		UseTFPluginCache:     w.Viper.GetBool(UseTFPluginCache),
+		WorkerSecret:         w.Viper.GetString(WorkerSecretFlag),
+	}
+	if config.AtlantisURL == "" {
+		return config, fmt.Errorf("--%s must be set", AtlantisURLFlag)
+	}
+	if config.WorkerSecret == "" {
+		return config, fmt.Errorf("--%s must be set", WorkerSecretFlag)
+	}
+	for _, runner := range strings.Split(w.Viper.GetString(RunnersFlag), ",") {
+		if runner = strings.TrimSpace(runner); runner != "" {
+			config.Runners = append(config.Runners, runner)
+		}
+	}
+	if len(config.Runners) == 0 {
+		return config, fmt.Errorf("--%s must be set", RunnersFlag)
+	}
+	if config.CheckoutStrategy != CheckoutStrategyBranch && config.CheckoutStrategy != CheckoutStrategyMerge {
+		return config, fmt.Errorf("invalid --%s %q, must be %s or %s", CheckoutStrategyFlag, config.CheckoutStrategy, CheckoutStrategyBranch, CheckoutStrategyMerge)
+	}
+	if !isValidLogLevel(w.Viper.GetString(LogLevelFlag)) {
+		return config, fmt.Errorf("invalid --%s %q, must be one of %v", LogLevelFlag, w.Viper.GetString(LogLevelFlag), ValidLogLevels)
+	}
+	cloneTTL, err := time.ParseDuration(w.Viper.GetString(CloneTTLFlag))
+	if err != nil {
+		return config, errors.Wrapf(err, "invalid --%s", CloneTTLFlag)
+	}
+	config.CloneTTL = cloneTTL
+	if config.Name == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return config, errors.Wrapf(err, "--%s isn't set and getting the hostname", WorkerNameFlag)
+		}
+		config.Name = hostname
+	}
+	config.DataDir, err = expandDataDir(w.Viper.GetString(DataDirFlag))
+	if err != nil {
+		return config, err
+	}
+	return config, nil
+}
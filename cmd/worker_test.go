@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+
+	"github.com/runatlantis/atlantis/server"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+type workerCreatorMock struct {
+	config server.WorkerConfig
+}
+
+func (w *workerCreatorMock) NewWorker(config server.WorkerConfig) (WorkerRunner, error) {
+	w.config = config
+	return workerRunnerMock{}, nil
+}
+
+type workerRunnerMock struct{}
+
+func (workerRunnerMock) Run(_ context.Context) error {
+	return nil
+}
+
+// runWorkerCmd runs atlantis worker with args and returns the configuration
+// the worker was created with.
+func runWorkerCmd(t *testing.T, args ...string) (server.WorkerConfig, error) {
+	creator := &workerCreatorMock{}
+	c := (&WorkerCmd{WorkerCreator: creator, Viper: viper.New(), AtlantisVersion: "1.0.0"}).Init()
+	c.SetArgs(args)
+	c.SetOut(&bytes.Buffer{})
+	c.SetErr(&bytes.Buffer{})
+	err := c.Execute()
+	return creator.config, err
+}
+
+func TestWorkerCmd(t *testing.T) {
+	dataDir := t.TempDir()
+	config, err := runWorkerCmd(t,
+		"--atlantis-url", "https://atlantis.example.com",
+		"--worker-secret", "secret",
+		"--worker-name", "prod-1",
+		"--runners", "prod-account, staging-account",
+		"--data-dir", dataDir,
+		"--clone-ttl", "72h",
+	)
+	Ok(t, err)
+	Equals(t, server.WorkerConfig{
+		AtlantisURL:          "https://atlantis.example.com",
+		AtlantisVersion:      "1.0.0",
+		CheckoutStrategy:     CheckoutStrategyBranch,
+		CloneTTL:             72 * time.Hour,
+		DataDir:              dataDir,
+		DefaultTFVersionFlag: DefaultTFVersionFlag,
+		Name:                 "prod-1",
+		Runners:              []string{"prod-account", "staging-account"},
+		TFDownload:           true,
+		TFDownloadURL:        DefaultTFDownloadURL,
+		UseTFPluginCache:     true,
+		WorkerSecret:         "secret",
+	}, config)
+}
+
+func TestWorkerCmd_SecretFromEnv(t *testing.T) {
+	t.Setenv("ATLANTIS_WORKER_SECRET", "env-secret")
+	config, err := runWorkerCmd(t, "--atlantis-url", "https://atlantis.example.com", "--runners", "prod-account")
+	Ok(t, err)
+	Equals(t, "env-secret", config.WorkerSecret)
+	Assert(t, config.Name != "", "exp name to default to the hostname")
+}
+
+func TestWorkerCmd_Validation(t *testing.T) {
+	cases := []struct {
+		args   []string
+		expErr string
+	}{
+		{
+			[]string{"--worker-secret", "secret", "--runners", "prod"},
+			"--atlantis-url must be set",
+		},
+		{
+			[]string{"--atlantis-url", "https://atlantis", "--runners", "prod"},
+			"--worker-secret must be set",
+		},
+		{
+			[]string{"--atlantis-url", "https://atlantis", "--worker-secret", "secret", "--runners", " , "},
+			"--runners must be set",
+		},
+		{
+			[]string{"--atlantis-url", "https://atlantis", "--worker-secret", "secret", "--runners", "prod", "--checkout-strategy", "rebase"},
+			`invalid --checkout-strategy "rebase", must be branch or merge`,
+		},
+		{
+			[]string{"--atlantis-url", "https://atlantis", "--worker-secret", "secret", "--runners", "prod", "--clone-ttl", "week"},
+			"invalid --clone-ttl",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.expErr, func(t *testing.T) {
+			_, err := runWorkerCmd(t, c.args...)
+			ErrContains(t, c.expErr, err)
+		})
+	}
+}
@@ -60,8 +60,16 @@ func main() {
 	}
 	version := &cmd.VersionCmd{AtlantisVersion: atlantisVersion}
 	testdrive := &cmd.TestdriveCmd{}
+	db := &cmd.DBCmd{Viper: viper.New()}
+	worker := &cmd.WorkerCmd{
+		WorkerCreator:   &cmd.DefaultWorkerCreator{},
+		Viper:           viper.New(),
+		AtlantisVersion: atlantisVersion,
+	}
 	cmd.RootCmd.AddCommand(server.Init())
 	cmd.RootCmd.AddCommand(version.Init())
 	cmd.RootCmd.AddCommand(testdrive.Init())
+	cmd.RootCmd.AddCommand(db.Init())
+	cmd.RootCmd.AddCommand(worker.Init())
 	cmd.Execute()
 }
@@ -139,6 +139,8 @@ export default defineUserConfig({
                         'terraform-cloud',
                         'using-slack-hooks',
                         'stats',
+                        'database',
+                        'remote-workers',
                         'faq',
                     ]
                 },
@@ -0,0 +1,74 @@
+// Package audit records privileged actions, ex. applies and deleted locks,
+// so that it's possible to find out later who did what and when.
+package audit
+
+import (
+	"time"
+
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+)
+
+// Sink stores audit events, ex. in a file or the Atlantis database.
+type Sink interface {
+	Write(event models.AuditEvent) error
+}
+
+// Auditor records audit events in each of its sinks.
+//
+// A nil Auditor, or one without sinks, drops events so that callers don't need
+// to check whether auditing is enabled.
+type Auditor struct {
+	sinks  []Sink
+	logger logging.SimpleLogging
+	now    func() time.Time
+}
+
+// NewAuditor returns an Auditor recording events in sinks. Errors writing to
+// a sink are logged with logger.
+func NewAuditor(logger logging.SimpleLogging, sinks ...Sink) *Auditor {
+	return &Auditor{
+		sinks:  sinks,
+		logger: logger,
+		now:    time.Now,
+	}
+}
+
+// Enabled returns true if events are recorded anywhere.
+func (a *Auditor) Enabled() bool {
+	return a != nil && len(a.sinks) > 0
+}
+
+// Record writes event to every sink, setting its time if it isn't set.
+// Failing to write to a sink doesn't stop the action being audited so errors
+// are logged rather than returned.
+func (a *Auditor) Record(event models.AuditEvent) {
+	if !a.Enabled() {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = a.now().UTC()
+	}
+	for _, s := range a.sinks {
+		if err := s.Write(event); err != nil {
+			a.logger.Err("failed writing %s audit event: %s", event.Type, err)
+		}
+	}
+}
+
+// Outcome returns the outcome of an action that failed with err, or
+// succeeded if err is nil.
+func Outcome(err error) models.AuditOutcome {
+	if err != nil {
+		return models.AuditError
+	}
+	return models.AuditSuccess
+}
+
+// ErrorDetail returns err's message, or "" if err is nil.
+func ErrorDetail(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
@@ -0,0 +1,121 @@
+package audit_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/audit"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+	"github.com/stretchr/testify/assert"
+)
+
+type sliceSink struct {
+	events []models.AuditEvent
+	err    error
+}
+
+func (s *sliceSink) Write(event models.AuditEvent) error {
+	s.events = append(s.events, event)
+	return s.err
+}
+
+func TestAuditor_Record(t *testing.T) {
+	failing := &sliceSink{err: errors.New("full")}
+	sink := &sliceSink{}
+	auditor := audit.NewAuditor(logging.NewNoopLogger(t), failing, sink)
+	Assert(t, auditor.Enabled(), "exp auditor to be enabled")
+
+	auditor.Record(models.AuditEvent{Type: models.LockAuditEvent, Actor: "alice"})
+	Equals(t, 1, len(failing.events))
+	Equals(t, 1, len(sink.events))
+	Equals(t, "alice", sink.events[0].Actor)
+	Assert(t, !sink.events[0].Time.IsZero(), "exp time to be set")
+
+	t.Log("a time that's set is kept")
+	at := time.Date(2023, 3, 3, 0, 0, 0, 0, time.UTC)
+	auditor.Record(models.AuditEvent{Time: at})
+	Equals(t, at, sink.events[1].Time)
+}
+
+func TestAuditor_Disabled(t *testing.T) {
+	var auditor *audit.Auditor
+	Assert(t, !auditor.Enabled(), "exp nil auditor to be disabled")
+	auditor.Record(models.AuditEvent{})
+	Assert(t, !audit.NewAuditor(logging.NewNoopLogger(t)).Enabled(), "exp auditor without sinks to be disabled")
+}
+
+func TestFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	Ok(t, os.WriteFile(path, []byte("{}\n"), 0600))
+
+	sink, err := audit.NewFileSink(path)
+	Ok(t, err)
+	Ok(t, sink.Write(models.AuditEvent{Type: models.UnlockAuditEvent, Actor: "alice"}))
+	Ok(t, sink.Write(models.AuditEvent{Type: models.APICallAuditEvent, Actor: "bob"}))
+	Ok(t, sink.Close())
+
+	contents, err := os.ReadFile(path)
+	Ok(t, err)
+	lines := strings.Split(strings.TrimSpace(string(contents)), "\n")
+	Equals(t, 3, len(lines))
+	var event models.AuditEvent
+	Ok(t, json.Unmarshal([]byte(lines[2]), &event))
+	Equals(t, models.AuditEvent{Type: models.APICallAuditEvent, Actor: "bob"}, event)
+}
+
+func TestWebhookSink(t *testing.T) {
+	received := make(chan models.AuditEvent, 10)
+	unblock := make(chan struct{})
+	var slowPosted atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Equals(t, "application/json", r.Header.Get("Content-Type"))
+		var event models.AuditEvent
+		Ok(t, json.NewDecoder(r.Body).Decode(&event))
+		if event.Actor == "slow" {
+			<-unblock
+			slowPosted.Add(1)
+			return
+		}
+		if event.Actor == "failing" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		received <- event
+	}))
+	defer server.Close()
+
+	sink := audit.NewWebhookSink(server.URL, logging.NewNoopLogger(t))
+	stats := models.PlanSuccessStats{Add: 1}
+	event := models.AuditEvent{Type: models.CommandAuditEvent, Command: "plan", PlanStats: &stats}
+	Ok(t, sink.Write(event))
+	Equals(t, event, <-received)
+
+	t.Log("events are still posted after the webhook fails")
+	Ok(t, sink.Write(models.AuditEvent{Actor: "failing"}))
+	Ok(t, sink.Write(event))
+	Equals(t, event, <-received)
+
+	t.Log("events are dropped instead of waiting for a slow webhook once the queue is full")
+	var queued int64
+	var err error
+	for err == nil && queued < 2000 {
+		if err = sink.Write(models.AuditEvent{Actor: "slow"}); err == nil {
+			queued++
+		}
+	}
+	ErrEquals(t, "dropped event since 1000 events are waiting to be posted to the audit webhook", err)
+
+	t.Log("the queued events are posted once the webhook catches up")
+	close(unblock)
+	assert.Eventually(t, func() bool { return slowPosted.Load() == queued }, 10*time.Second, 10*time.Millisecond)
+}
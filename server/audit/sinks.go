@@ -0,0 +1,143 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+)
+
+// FileSink appends audit events to a file as JSON lines.
+type FileSink struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewFileSink returns a FileSink appending to the file at path, creating it
+// if it doesn't exist.
+func NewFileSink(path string) (*FileSink, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, errors.Wrapf(err, "opening audit log %q", path)
+	}
+	return &FileSink{file: file}, nil
+}
+
+// Write appends event to the file.
+func (f *FileSink) Write(event models.AuditEvent) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return errors.Wrap(err, "serializing")
+	}
+	line = append(line, '\n')
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_, err = f.file.Write(line)
+	return errors.Wrapf(err, "writing to %q", f.file.Name())
+}
+
+// Close closes the file.
+func (f *FileSink) Close() error {
+	return f.file.Close()
+}
+
+const (
+	// webhookTimeout is how long WebhookSink waits for the webhook to
+	// respond.
+	webhookTimeout = 10 * time.Second
+	// webhookQueueSize is how many events WebhookSink holds while the webhook
+	// is slow or down before it drops them.
+	webhookQueueSize = 1000
+)
+
+// WebhookSink POSTs each audit event as JSON to a URL. Events are posted in
+// the background, in the order they were written, so that a slow webhook
+// doesn't hold up the actions being audited.
+type WebhookSink struct {
+	URL    string
+	Client *http.Client
+	// Logger logs the events that couldn't be posted.
+	Logger logging.SimpleLogging
+	queue  chan models.AuditEvent
+}
+
+// NewWebhookSink returns a WebhookSink posting to url. Errors posting events
+// are logged with logger.
+func NewWebhookSink(url string, logger logging.SimpleLogging) *WebhookSink {
+	w := &WebhookSink{
+		URL:    url,
+		Client: &http.Client{Timeout: webhookTimeout},
+		Logger: logger,
+		queue:  make(chan models.AuditEvent, webhookQueueSize),
+	}
+	go w.run()
+	return w
+}
+
+// Write queues event to be posted. If the queue is full, event is dropped and
+// an error is returned.
+func (w *WebhookSink) Write(event models.AuditEvent) error {
+	select {
+	case w.queue <- event:
+		return nil
+	default:
+		return fmt.Errorf("dropped event since %d events are waiting to be posted to the audit webhook", webhookQueueSize)
+	}
+}
+
+// run posts the queued events.
+func (w *WebhookSink) run() {
+	for event := range w.queue {
+		if err := w.post(event); err != nil {
+			w.Logger.Err("failed writing %s audit event: %s", event.Type, err)
+		}
+	}
+}
+
+// post posts event to the webhook. Responses with a status other than 2xx are
+// errors.
+func (w *WebhookSink) post(event models.AuditEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return errors.Wrap(err, "serializing")
+	}
+	resp, err := w.Client.Post(w.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "posting to audit webhook")
+	}
+	defer resp.Body.Close() // nolint: errcheck
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("audit webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// DBBackend stores audit events in the Atlantis database. It's implemented
+// by locking.Backend.
+type DBBackend interface {
+	AddAuditEvent(event models.AuditEvent, maxEvents int) error
+	ListAuditEvents(query models.AuditQuery) ([]models.AuditEvent, error)
+}
+
+// DBSink stores audit events in the Atlantis database so that they can be
+// queried from the API.
+type DBSink struct {
+	Backend DBBackend
+	// MaxEvents is how many of the newest events are kept. If 0, all events
+	// are kept.
+	MaxEvents int
+}
+
+// Write stores event, deleting the oldest events if there are more than
+// MaxEvents.
+func (d *DBSink) Write(event models.AuditEvent) error {
+	return d.Backend.AddAuditEvent(event, d.MaxEvents)
+}
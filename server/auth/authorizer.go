@@ -0,0 +1,127 @@
+package auth
+
+import (
+	"fmt"
+
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+)
+
+// rank orders roles so that each role includes the permissions of the roles
+// ranked below it.
+var rank = map[Role]int{
+	ViewerRole:   1,
+	OperatorRole: 2,
+	AdminRole:    3,
+}
+
+// Includes returns true if r grants at least the permissions of other.
+func (r Role) Includes(other Role) bool {
+	return rank[r] >= rank[other] && rank[r] > 0
+}
+
+// ForbiddenError is returned when a user doesn't have the role required for
+// an action.
+type ForbiddenError struct {
+	Username string
+	Role     Role
+	// Scope is the repo ID the role is required on, or a description of the
+	// scope for actions that aren't on a single repo.
+	Scope string
+}
+
+func (e *ForbiddenError) Error() string {
+	username := e.Username
+	if username == "" {
+		username = "anonymous"
+	}
+	return fmt.Sprintf("user %q doesn't have the %s role on %s", username, e.Role, e.Scope)
+}
+
+// Authorizer decides whether users can perform actions in the web UI and API
+// based on the roles granted to them. Roles are granted by their groups
+// (see Identity.Roles) and by the role bindings in the server-side repo
+// config.
+//
+// A nil Authorizer, or one without any bindings or group roles, allows
+// everything so that Atlantis behaves as it did before roles existed.
+type Authorizer struct {
+	bindings []valid.RoleBinding
+	enabled  bool
+}
+
+// NewAuthorizer returns an Authorizer for bindings. groupRoles should be true
+// if single sign-on grants roles to groups, in which case roles are enforced
+// even if there are no bindings.
+func NewAuthorizer(bindings []valid.RoleBinding, groupRoles bool) *Authorizer {
+	return &Authorizer{
+		bindings: bindings,
+		enabled:  len(bindings) > 0 || groupRoles,
+	}
+}
+
+// Enabled returns true if roles are enforced.
+func (a *Authorizer) Enabled() bool {
+	return a != nil && a.enabled
+}
+
+// AuthorizeRepo returns a *ForbiddenError if id doesn't have at least role on
+// the repo with ID repoID.
+func (a *Authorizer) AuthorizeRepo(id Identity, repoID string, role Role) error {
+	if !a.Enabled() || a.highest(id, func(b valid.RoleBinding) bool { return b.IDMatches(repoID) }).Includes(role) {
+		return nil
+	}
+	return &ForbiddenError{Username: id.Username, Role: role, Scope: repoID}
+}
+
+// AuthorizeGlobal returns a *ForbiddenError if id doesn't have at least role
+// on all repos. It's used for actions that affect every repo, like the global
+// apply lock.
+func (a *Authorizer) AuthorizeGlobal(id Identity, role Role) error {
+	if !a.Enabled() || a.highest(id, valid.RoleBinding.AllRepos).Includes(role) {
+		return nil
+	}
+	return &ForbiddenError{Username: id.Username, Role: role, Scope: "all repos"}
+}
+
+// AuthorizeAny returns a *ForbiddenError if id doesn't have at least role on
+// any repo. It's used for pages that list data from many repos, which are
+// then filtered with CanView.
+func (a *Authorizer) AuthorizeAny(id Identity, role Role) error {
+	if !a.Enabled() || a.highest(id, func(valid.RoleBinding) bool { return true }).Includes(role) {
+		return nil
+	}
+	return &ForbiddenError{Username: id.Username, Role: role, Scope: "any repo"}
+}
+
+// HasRepoRole returns true if id has at least role on the repo with ID
+// repoID. Unlike AuthorizeRepo, it returns false if roles aren't enforced
+// since it's used for actions that are explicitly restricted to roles.
+func (a *Authorizer) HasRepoRole(id Identity, repoID string, role Role) bool {
+	return a != nil && a.highest(id, func(b valid.RoleBinding) bool { return b.IDMatches(repoID) }).Includes(role)
+}
+
+// CanView returns true if id can view the repo with ID repoID.
+func (a *Authorizer) CanView(id Identity, repoID string) bool {
+	return a.AuthorizeRepo(id, repoID, ViewerRole) == nil
+}
+
+// highest returns the highest role granted to id by its groups or by the
+// bindings that applies returns true for. It returns an empty role if id has
+// no role.
+func (a *Authorizer) highest(id Identity, applies func(valid.RoleBinding) bool) Role {
+	var highest Role
+	grant := func(r Role) {
+		if rank[r] > rank[highest] {
+			highest = r
+		}
+	}
+	for _, r := range id.Roles {
+		grant(r)
+	}
+	for _, b := range a.bindings {
+		if applies(b) && b.Grants(id.Username, id.Groups) {
+			grant(Role(b.Role))
+		}
+	}
+	return highest
+}
@@ -0,0 +1,88 @@
+package auth_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/auth"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestAuthorizer_AuthorizeRepo(t *testing.T) {
+	a := auth.NewAuthorizer([]valid.RoleBinding{
+		{Role: "viewer", Groups: []string{"developers"}},
+		{IDRegex: regexp.MustCompile("github.com/owner/.*"), Role: "operator", Users: []string{"alice"}},
+		{ID: "github.com/owner/infra", Role: "admin", Groups: []string{"platform"}},
+	}, false)
+
+	cases := []struct {
+		description string
+		id          auth.Identity
+		repoID      string
+		role        auth.Role
+		expAllowed  bool
+	}{
+		{"group on all repos", auth.Identity{Groups: []string{"developers"}}, "github.com/other/repo", auth.ViewerRole, true},
+		{"group below required role", auth.Identity{Groups: []string{"developers"}}, "github.com/other/repo", auth.OperatorRole, false},
+		{"user matching regex", auth.Identity{Username: "alice"}, "github.com/owner/repo", auth.OperatorRole, true},
+		{"higher role includes lower", auth.Identity{Username: "alice"}, "github.com/owner/repo", auth.ViewerRole, true},
+		{"user not matching regex", auth.Identity{Username: "alice"}, "github.com/other/repo", auth.ViewerRole, false},
+		{"group on exact repo", auth.Identity{Groups: []string{"platform"}}, "github.com/owner/infra", auth.AdminRole, true},
+		{"group on other repo", auth.Identity{Groups: []string{"platform"}}, "github.com/owner/repo", auth.ViewerRole, false},
+		{"roles from groups", auth.Identity{Roles: []auth.Role{auth.OperatorRole}}, "github.com/other/repo", auth.OperatorRole, true},
+		{"anonymous", auth.Identity{}, "github.com/owner/repo", auth.ViewerRole, false},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			err := a.AuthorizeRepo(c.id, c.repoID, c.role)
+			Equals(t, c.expAllowed, err == nil)
+		})
+	}
+
+	err := a.AuthorizeRepo(auth.Identity{Username: "bob"}, "github.com/owner/repo", auth.OperatorRole)
+	ErrEquals(t, `user "bob" doesn't have the operator role on github.com/owner/repo`, err)
+}
+
+func TestAuthorizer_AuthorizeGlobal(t *testing.T) {
+	a := auth.NewAuthorizer([]valid.RoleBinding{
+		{Role: "admin", Users: []string{"alice"}},
+		{IDRegex: regexp.MustCompile(".*"), Role: "admin", Users: []string{"bob"}},
+		{IDRegex: regexp.MustCompile("github.com/.*"), Role: "admin", Users: []string{"carol"}},
+	}, false)
+
+	Ok(t, a.AuthorizeGlobal(auth.Identity{Username: "alice"}, auth.AdminRole))
+	Ok(t, a.AuthorizeGlobal(auth.Identity{Username: "bob"}, auth.AdminRole))
+	Ok(t, a.AuthorizeGlobal(auth.Identity{Roles: []auth.Role{auth.AdminRole}}, auth.AdminRole))
+	ErrEquals(t, `user "carol" doesn't have the admin role on all repos`, a.AuthorizeGlobal(auth.Identity{Username: "carol"}, auth.AdminRole))
+	Ok(t, a.AuthorizeAny(auth.Identity{Username: "carol"}, auth.AdminRole))
+	ErrEquals(t, `user "anonymous" doesn't have the viewer role on any repo`, a.AuthorizeAny(auth.Identity{}, auth.ViewerRole))
+}
+
+func TestAuthorizer_Disabled(t *testing.T) {
+	var nilAuthorizer *auth.Authorizer
+	for _, a := range []*auth.Authorizer{nilAuthorizer, auth.NewAuthorizer(nil, false)} {
+		Assert(t, !a.Enabled(), "exp disabled")
+		Ok(t, a.AuthorizeRepo(auth.Identity{}, "github.com/owner/repo", auth.AdminRole))
+		Ok(t, a.AuthorizeGlobal(auth.Identity{}, auth.AdminRole))
+		Ok(t, a.AuthorizeAny(auth.Identity{}, auth.AdminRole))
+	}
+
+	t.Log("group roles enable roles even without bindings")
+	a := auth.NewAuthorizer(nil, true)
+	Assert(t, a.Enabled(), "exp enabled")
+	Assert(t, a.AuthorizeAny(auth.Identity{}, auth.ViewerRole) != nil, "exp anonymous users to be forbidden")
+}
+
+func TestAuthorizer_HasRepoRole(t *testing.T) {
+	a := auth.NewAuthorizer([]valid.RoleBinding{
+		{ID: "github.com/owner/infra", Role: "operator", Users: []string{"alice"}},
+	}, false)
+	Assert(t, a.HasRepoRole(auth.Identity{Username: "alice"}, "github.com/owner/infra", auth.ViewerRole), "exp alice to be a viewer")
+	Assert(t, !a.HasRepoRole(auth.Identity{Username: "alice"}, "github.com/owner/infra", auth.AdminRole), "exp alice not to be an admin")
+	Assert(t, !a.HasRepoRole(auth.Identity{Username: "alice"}, "github.com/owner/repo", auth.ViewerRole), "exp alice to have no role on other repos")
+
+	t.Log("no one has a role if roles aren't enforced")
+	var nilAuthorizer *auth.Authorizer
+	Assert(t, !nilAuthorizer.HasRepoRole(auth.Identity{Username: "alice"}, "github.com/owner/infra", auth.ViewerRole), "exp no role")
+}
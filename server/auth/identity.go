@@ -0,0 +1,67 @@
+// Package auth authenticates users of the Atlantis web UI.
+package auth
+
+import (
+	"context"
+	"fmt"
+)
+
+// Role is a set of permissions granted to a user of the web UI.
+type Role string
+
+const (
+	// ViewerRole can view locks, pull requests and jobs.
+	ViewerRole Role = "viewer"
+	// OperatorRole can also delete locks.
+	OperatorRole Role = "operator"
+	// AdminRole can also toggle the global apply lock.
+	AdminRole Role = "admin"
+)
+
+// ParseRole returns the role named s.
+func ParseRole(s string) (Role, error) {
+	switch r := Role(s); r {
+	case ViewerRole, OperatorRole, AdminRole:
+		return r, nil
+	}
+	return "", fmt.Errorf("invalid role %q, must be one of %q, %q or %q", s, ViewerRole, OperatorRole, AdminRole)
+}
+
+// Method is how a user authenticated.
+type Method string
+
+const (
+	// BasicAuthMethod is HTTP basic authentication with --web-username and
+	// --web-password.
+	BasicAuthMethod Method = "basic"
+	// OIDCMethod is OpenID Connect single sign-on.
+	OIDCMethod Method = "oidc"
+	// APISecretMethod is the X-Atlantis-Token header with --api-secret.
+	APISecretMethod Method = "api-secret"
+	// APITokenMethod is the X-Atlantis-Token header with a scoped API token.
+	APITokenMethod Method = "api-token"
+)
+
+// Identity is an authenticated user.
+type Identity struct {
+	Username string   `json:"username"`
+	Method   Method   `json:"method"`
+	Groups   []string `json:"groups,omitempty"`
+	// Roles are the roles granted to the user on all repos by their groups,
+	// in addition to those granted by role bindings.
+	Roles []Role `json:"roles,omitempty"`
+}
+
+type identityKey struct{}
+
+// WithIdentity returns a copy of ctx carrying id.
+func WithIdentity(ctx context.Context, id Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, id)
+}
+
+// IdentityFromContext returns the identity set by WithIdentity. ok is false
+// if the request wasn't authenticated.
+func IdentityFromContext(ctx context.Context) (id Identity, ok bool) {
+	id, ok = ctx.Value(identityKey{}).(Identity)
+	return id, ok
+}
@@ -0,0 +1,412 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/logging"
+	"golang.org/x/oauth2"
+)
+
+const (
+	// LoginPath is the route that starts an OIDC login.
+	LoginPath = "/login"
+	// CallbackPath is the route the OIDC provider redirects users back to.
+	CallbackPath = "/login/callback"
+	// LogoutPath is the route that ends a user's session.
+	LogoutPath = "/logout"
+)
+
+// OIDCConfig configures OpenID Connect single sign-on.
+type OIDCConfig struct {
+	// IssuerURL is the URL of the provider, ex. https://accounts.google.com.
+	// The provider's configuration is discovered from it.
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	// RedirectURL is the externally accessible URL of the callback route.
+	RedirectURL string
+	// Scopes are requested in addition to openid.
+	Scopes []string
+	// UsernameClaim is the ID token claim used as the username. The subject
+	// is used if the token doesn't have it.
+	UsernameClaim string
+	// GroupsClaim is the ID token claim listing the user's groups.
+	GroupsClaim string
+	// GroupRoles maps groups to the roles they grant. If it isn't empty, users
+	// must be in at least one of the groups to log in.
+	GroupRoles map[string]Role
+}
+
+// providerMetadata is the subset of the provider's discovery document that
+// we use.
+type providerMetadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// OIDCAuthenticator logs users into the web UI with an OpenID Connect
+// provider using the authorization code flow with PKCE.
+type OIDCAuthenticator struct {
+	config     OIDCConfig
+	metadata   providerMetadata
+	oauth2     oauth2.Config
+	sessions   *SessionManager
+	logger     logging.SimpleLogging
+	httpClient *http.Client
+	// basePath is the path Atlantis is accessible at externally. It never
+	// ends in a '/'.
+	basePath string
+
+	keysMutex sync.Mutex
+	// keys are the provider's signing keys by key ID.
+	keys map[string]interface{}
+}
+
+// NewOIDCAuthenticator discovers the configuration of the provider at
+// config.IssuerURL.
+func NewOIDCAuthenticator(config OIDCConfig, sessions *SessionManager, atlantisURL *url.URL, logger logging.SimpleLogging, httpClient *http.Client) (*OIDCAuthenticator, error) {
+	issuer := strings.TrimSuffix(config.IssuerURL, "/")
+	var metadata providerMetadata
+	if err := getJSON(context.Background(), httpClient, issuer+"/.well-known/openid-configuration", &metadata); err != nil {
+		return nil, errors.Wrapf(err, "discovering OIDC provider %s", issuer)
+	}
+	if strings.TrimSuffix(metadata.Issuer, "/") != issuer {
+		return nil, fmt.Errorf("OIDC provider issuer %q doesn't match %q", metadata.Issuer, issuer)
+	}
+	if metadata.AuthorizationEndpoint == "" || metadata.TokenEndpoint == "" || metadata.JWKSURI == "" {
+		return nil, fmt.Errorf("OIDC provider %s is missing an authorization, token or JWKS endpoint", issuer)
+	}
+
+	scopes := []string{"openid"}
+	for _, s := range config.Scopes {
+		if s != "openid" {
+			scopes = append(scopes, s)
+		}
+	}
+	return &OIDCAuthenticator{
+		config:   config,
+		metadata: metadata,
+		oauth2: oauth2.Config{
+			ClientID:     config.ClientID,
+			ClientSecret: config.ClientSecret,
+			RedirectURL:  config.RedirectURL,
+			Scopes:       scopes,
+			Endpoint: oauth2.Endpoint{
+				AuthURL:  metadata.AuthorizationEndpoint,
+				TokenURL: metadata.TokenEndpoint,
+			},
+		},
+		sessions:   sessions,
+		logger:     logger,
+		httpClient: httpClient,
+		basePath:   strings.TrimSuffix(atlantisURL.Path, "/"),
+		keys:       map[string]interface{}{},
+	}, nil
+}
+
+// Authenticate returns the identity of the user logged in to r's session.
+func (o *OIDCAuthenticator) Authenticate(r *http.Request) (Identity, bool) {
+	id, ok := o.sessions.Get(r)
+	if !ok || id.Method != OIDCMethod {
+		return Identity{}, false
+	}
+	return id, true
+}
+
+// RedirectToLogin redirects the user to the login route, returning them to
+// the page they requested once they've logged in.
+func (o *OIDCAuthenticator) RedirectToLogin(w http.ResponseWriter, r *http.Request) {
+	loginURL := o.basePath + LoginPath + "?return_to=" + url.QueryEscape(r.URL.RequestURI())
+	http.Redirect(w, r, loginURL, http.StatusFound)
+}
+
+// Login is the GET /login route. It redirects the user to the provider.
+func (o *OIDCAuthenticator) Login(w http.ResponseWriter, r *http.Request) {
+	returnTo := r.URL.Query().Get("return_to")
+	// Only redirect to our own pages after logging in.
+	if !strings.HasPrefix(returnTo, "/") || strings.HasPrefix(returnTo, "//") || strings.HasPrefix(returnTo, "/\\") {
+		returnTo = "/"
+	}
+	state := loginState{
+		State:        randomString(),
+		Nonce:        randomString(),
+		CodeVerifier: oauth2.GenerateVerifier(),
+		ReturnTo:     returnTo,
+		Expiry:       time.Now().Add(loginDuration),
+	}
+	if err := o.sessions.setLoginState(w, state); err != nil {
+		o.respond(w, logging.Error, http.StatusInternalServerError, "Unable to start login: %s", err)
+		return
+	}
+	authURL := o.oauth2.AuthCodeURL(state.State,
+		oauth2.S256ChallengeOption(state.CodeVerifier),
+		oauth2.SetAuthURLParam("nonce", state.Nonce))
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// Callback is the GET /login/callback route. The provider redirects users
+// here with an authorization code that we exchange for their ID token.
+func (o *OIDCAuthenticator) Callback(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if errCode := query.Get("error"); errCode != "" {
+		o.respond(w, logging.Warn, http.StatusUnauthorized, "Login failed: %s %s", errCode, query.Get("error_description"))
+		return
+	}
+	state, err := o.sessions.popLoginState(w, r)
+	if err != nil {
+		o.respond(w, logging.Warn, http.StatusBadRequest, "Login failed: %s. Try logging in again.", err)
+		return
+	}
+	if query.Get("state") != state.State {
+		o.respond(w, logging.Warn, http.StatusBadRequest, "Login failed: state did not match. Try logging in again.")
+		return
+	}
+
+	id, err := o.exchange(r.Context(), query.Get("code"), state)
+	if err != nil {
+		o.respond(w, logging.Warn, http.StatusUnauthorized, "Login failed: %s", err)
+		return
+	}
+	if len(o.config.GroupRoles) > 0 && len(id.Roles) == 0 {
+		o.respond(w, logging.Warn, http.StatusForbidden, "User %s isn't in any group that is allowed to use Atlantis", id.Username)
+		return
+	}
+	if err := o.sessions.Set(w, id); err != nil {
+		o.respond(w, logging.Error, http.StatusInternalServerError, "Unable to create session: %s", err)
+		return
+	}
+	o.logger.Info("user %s logged in with groups %v and roles %v", id.Username, id.Groups, id.Roles)
+	http.Redirect(w, r, o.basePath+state.ReturnTo, http.StatusFound)
+}
+
+// Logout is the GET /logout route. It ends the user's session.
+func (o *OIDCAuthenticator) Logout(w http.ResponseWriter, r *http.Request) {
+	o.sessions.Clear(w)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprintf(w, "You have been logged out. <a href=\"%s\">Log in again</a>.\n", o.basePath+LoginPath)
+}
+
+// exchange exchanges code for the user's ID token and returns their identity.
+func (o *OIDCAuthenticator) exchange(ctx context.Context, code string, state loginState) (Identity, error) {
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, o.httpClient)
+	token, err := o.oauth2.Exchange(ctx, code, oauth2.VerifierOption(state.CodeVerifier))
+	if err != nil {
+		return Identity{}, errors.Wrap(err, "exchanging authorization code")
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok || rawIDToken == "" {
+		return Identity{}, errors.New("provider did not return an ID token")
+	}
+	claims, err := o.verifyIDToken(ctx, rawIDToken, state.Nonce)
+	if err != nil {
+		return Identity{}, err
+	}
+	return o.identity(claims), nil
+}
+
+// verifyIDToken checks the ID token's signature, issuer, audience, expiry and
+// nonce and returns its claims.
+func (o *OIDCAuthenticator) verifyIDToken(ctx context.Context, rawIDToken string, nonce string) (jwt.MapClaims, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(rawIDToken, claims,
+		func(token *jwt.Token) (interface{}, error) {
+			kid, _ := token.Header["kid"].(string)
+			return o.signingKey(ctx, kid)
+		},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512"}),
+		jwt.WithIssuer(o.metadata.Issuer),
+		jwt.WithAudience(o.config.ClientID),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "verifying ID token")
+	}
+	if claims["nonce"] != nonce {
+		return nil, errors.New("verifying ID token: nonce did not match")
+	}
+	return claims, nil
+}
+
+// identity returns the identity described by an ID token's claims.
+func (o *OIDCAuthenticator) identity(claims jwt.MapClaims) Identity {
+	id := Identity{Method: OIDCMethod}
+	id.Username, _ = claims[o.config.UsernameClaim].(string)
+	if id.Username == "" {
+		id.Username, _ = claims["sub"].(string)
+	}
+
+	// Providers send a single group as a string.
+	switch groups := claims[o.config.GroupsClaim].(type) {
+	case string:
+		id.Groups = []string{groups}
+	case []interface{}:
+		for _, g := range groups {
+			if s, ok := g.(string); ok {
+				id.Groups = append(id.Groups, s)
+			}
+		}
+	}
+
+	roles := map[Role]bool{}
+	for _, g := range id.Groups {
+		if role, ok := o.config.GroupRoles[g]; ok && !roles[role] {
+			roles[role] = true
+			id.Roles = append(id.Roles, role)
+		}
+	}
+	sort.Slice(id.Roles, func(i, j int) bool { return id.Roles[i] < id.Roles[j] })
+	return id
+}
+
+// signingKey returns the provider's key with ID kid. The provider's keys are
+// fetched again if we don't know kid since providers rotate their keys.
+func (o *OIDCAuthenticator) signingKey(ctx context.Context, kid string) (interface{}, error) {
+	o.keysMutex.Lock()
+	defer o.keysMutex.Unlock()
+	if key := o.lookupKey(kid); key != nil {
+		return key, nil
+	}
+
+	var jwks struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := getJSON(ctx, o.httpClient, o.metadata.JWKSURI, &jwks); err != nil {
+		return nil, errors.Wrap(err, "fetching OIDC provider keys")
+	}
+	o.keys = map[string]interface{}{}
+	for _, k := range jwks.Keys {
+		key, err := k.publicKey()
+		if err != nil {
+			o.logger.Debug("ignoring OIDC provider key %q: %s", k.Kid, err)
+			continue
+		}
+		o.keys[k.Kid] = key
+	}
+	if key := o.lookupKey(kid); key != nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("OIDC provider has no key with id %q", kid)
+}
+
+// lookupKey returns the key with ID kid. If kid is empty and the provider has
+// a single key, that key is returned. o.keysMutex must be held.
+func (o *OIDCAuthenticator) lookupKey(kid string) interface{} {
+	if kid == "" && len(o.keys) == 1 {
+		for _, key := range o.keys {
+			return key
+		}
+	}
+	return o.keys[kid]
+}
+
+func (o *OIDCAuthenticator) respond(w http.ResponseWriter, lvl logging.LogLevel, responseCode int, format string, args ...interface{}) {
+	response := fmt.Sprintf(format, args...)
+	o.logger.Log(lvl, response)
+	w.WriteHeader(responseCode)
+	fmt.Fprintln(w, response)
+}
+
+// jsonWebKey is a public key from the provider's JWKS.
+type jsonWebKey struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	// N and E are set for RSA keys.
+	N string `json:"n"`
+	E string `json:"e"`
+	// Crv, X and Y are set for elliptic curve keys.
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (k jsonWebKey) publicKey() (interface{}, error) {
+	if k.Use != "" && k.Use != "sig" {
+		return nil, fmt.Errorf("key use is %q", k.Use)
+	}
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errors.Wrap(err, "decoding key")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+func getJSON(ctx context.Context, httpClient *http.Client, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() // nolint: errcheck
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s returned %d", url, resp.StatusCode)
+	}
+	return errors.Wrapf(json.NewDecoder(resp.Body).Decode(v), "decoding response from %s", url)
+}
+
+// randomString returns a random string for use as a state or nonce.
+func randomString() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
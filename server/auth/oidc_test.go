@@ -0,0 +1,209 @@
+package auth_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/auth"
+	"github.com/runatlantis/atlantis/server/auth/authtest"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestOIDCAuthenticator_Login(t *testing.T) {
+	provider := authtest.NewProvider(t)
+	atlantis := setupOIDC(t, provider, nil)
+	client := &http.Client{Jar: newJar(t)}
+
+	resp, err := client.Get(atlantis.URL + "/pulls?repo=owner/repo")
+	Ok(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	Equals(t, http.StatusOK, resp.StatusCode)
+	Equals(t, "/pulls?repo=owner/repo", resp.Request.URL.RequestURI())
+
+	var id auth.Identity
+	Ok(t, json.Unmarshal(body, &id))
+	Equals(t, auth.Identity{
+		Username: "user@example.com",
+		Method:   auth.OIDCMethod,
+		Groups:   []string{"developers"},
+	}, id)
+
+	t.Log("the session is kept")
+	resp, err = client.Get(atlantis.URL + "/")
+	Ok(t, err)
+	Equals(t, http.StatusOK, resp.StatusCode)
+	Equals(t, "/", resp.Request.URL.Path)
+
+	t.Log("logging out ends the session")
+	_, err = client.Get(atlantis.URL + auth.LogoutPath)
+	Ok(t, err)
+	client.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+	resp, err = client.Get(atlantis.URL + "/")
+	Ok(t, err)
+	Equals(t, http.StatusFound, resp.StatusCode)
+	Equals(t, auth.LoginPath+"?return_to=%2F", resp.Header.Get("Location"))
+}
+
+func TestOIDCAuthenticator_GroupRoles(t *testing.T) {
+	provider := authtest.NewProvider(t)
+	provider.User["groups"] = []string{"developers", "platform", "other"}
+	atlantis := setupOIDC(t, provider, map[string]auth.Role{
+		"platform":   auth.AdminRole,
+		"developers": auth.OperatorRole,
+	})
+	client := &http.Client{Jar: newJar(t)}
+
+	resp, err := client.Get(atlantis.URL + "/")
+	Ok(t, err)
+	Equals(t, http.StatusOK, resp.StatusCode)
+	var id auth.Identity
+	Ok(t, json.NewDecoder(resp.Body).Decode(&id))
+	Equals(t, []auth.Role{auth.AdminRole, auth.OperatorRole}, id.Roles)
+
+	t.Log("users without a role can't log in")
+	provider.User["groups"] = "other"
+	client = &http.Client{Jar: newJar(t)}
+	resp, err = client.Get(atlantis.URL + "/")
+	Ok(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	Equals(t, http.StatusForbidden, resp.StatusCode)
+	Assert(t, strings.Contains(string(body), "User user@example.com isn't in any group that is allowed to use Atlantis"), "got %q", string(body))
+}
+
+func TestOIDCAuthenticator_UsernameFallsBackToSubject(t *testing.T) {
+	provider := authtest.NewProvider(t)
+	delete(provider.User, "email")
+	atlantis := setupOIDC(t, provider, nil)
+	client := &http.Client{Jar: newJar(t)}
+
+	resp, err := client.Get(atlantis.URL + "/")
+	Ok(t, err)
+	var id auth.Identity
+	Ok(t, json.NewDecoder(resp.Body).Decode(&id))
+	Equals(t, "1234", id.Username)
+}
+
+func TestOIDCAuthenticator_InvalidSignature(t *testing.T) {
+	provider := authtest.NewProvider(t)
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	Ok(t, err)
+	provider.SigningKey = key
+	atlantis := setupOIDC(t, provider, nil)
+	client := &http.Client{Jar: newJar(t)}
+
+	resp, err := client.Get(atlantis.URL + "/")
+	Ok(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	Equals(t, http.StatusUnauthorized, resp.StatusCode)
+	Assert(t, strings.Contains(string(body), "verifying ID token"), "got %q", string(body))
+}
+
+func TestOIDCAuthenticator_Callback(t *testing.T) {
+	provider := authtest.NewProvider(t)
+	atlantis := setupOIDC(t, provider, nil)
+	client := &http.Client{
+		Jar:           newJar(t),
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+
+	t.Log("callbacks without a login are rejected")
+	resp, err := client.Get(atlantis.URL + auth.CallbackPath + "?code=code&state=state")
+	Ok(t, err)
+	Equals(t, http.StatusBadRequest, resp.StatusCode)
+
+	t.Log("the login redirects to the provider with a PKCE challenge")
+	resp, err = client.Get(atlantis.URL + auth.LoginPath + "?return_to=https://evil.example.com")
+	Ok(t, err)
+	Equals(t, http.StatusFound, resp.StatusCode)
+	authURL, err := url.Parse(resp.Header.Get("Location"))
+	Ok(t, err)
+	Equals(t, provider.URL()+"/authorize", authURL.Scheme+"://"+authURL.Host+authURL.Path)
+	Equals(t, "S256", authURL.Query().Get("code_challenge_method"))
+	Assert(t, authURL.Query().Get("code_challenge") != "", "exp code challenge")
+	Assert(t, authURL.Query().Get("nonce") != "", "exp nonce")
+	Equals(t, "openid profile", authURL.Query().Get("scope"))
+	Equals(t, atlantis.URL+auth.CallbackPath, authURL.Query().Get("redirect_uri"))
+
+	t.Log("the state must match")
+	resp, err = client.Get(atlantis.URL + auth.CallbackPath + "?code=code&state=wrong")
+	Ok(t, err)
+	Equals(t, http.StatusBadRequest, resp.StatusCode)
+
+	t.Log("errors from the provider are reported")
+	resp, err = client.Get(atlantis.URL + auth.CallbackPath + "?error=access_denied&error_description=denied")
+	Ok(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	Equals(t, http.StatusUnauthorized, resp.StatusCode)
+	Equals(t, "Login failed: access_denied denied\n", string(body))
+
+	t.Log("users can only be returned to Atlantis")
+	resp, err = client.Get(atlantis.URL + auth.LoginPath + "?return_to=//evil.example.com")
+	Ok(t, err)
+	resp, err = client.Get(resp.Header.Get("Location"))
+	Ok(t, err)
+	resp, err = client.Get(resp.Header.Get("Location"))
+	Ok(t, err)
+	Equals(t, http.StatusFound, resp.StatusCode)
+	Equals(t, "/", resp.Header.Get("Location"))
+}
+
+func TestNewOIDCAuthenticator_IssuerMismatch(t *testing.T) {
+	provider := authtest.NewProvider(t)
+	sessions, err := auth.NewSessionManager("secret", &url.URL{})
+	Ok(t, err)
+	_, err = auth.NewOIDCAuthenticator(auth.OIDCConfig{IssuerURL: provider.URL() + "/other"}, sessions, &url.URL{}, logging.NewNoopLogger(t), http.DefaultClient)
+	ErrContains(t, "discovering OIDC provider", err)
+}
+
+// setupOIDC starts an Atlantis stand-in with the single sign-on routes. Its
+// other routes respond with the logged in user's identity or redirect to the
+// login.
+func setupOIDC(t *testing.T, provider *authtest.Provider, groupRoles map[string]auth.Role) *httptest.Server {
+	mux := http.NewServeMux()
+	atlantis := httptest.NewServer(mux)
+	t.Cleanup(atlantis.Close)
+	atlantisURL, err := url.Parse(atlantis.URL)
+	Ok(t, err)
+
+	sessions, err := auth.NewSessionManager("secret", atlantisURL)
+	Ok(t, err)
+	authenticator, err := auth.NewOIDCAuthenticator(auth.OIDCConfig{
+		IssuerURL:     provider.URL(),
+		ClientID:      authtest.ClientID,
+		ClientSecret:  authtest.ClientSecret,
+		RedirectURL:   atlantis.URL + auth.CallbackPath,
+		Scopes:        []string{"openid", "profile"},
+		UsernameClaim: "email",
+		GroupsClaim:   "groups",
+		GroupRoles:    groupRoles,
+	}, sessions, atlantisURL, logging.NewNoopLogger(t), http.DefaultClient)
+	Ok(t, err)
+
+	mux.HandleFunc(auth.LoginPath, authenticator.Login)
+	mux.HandleFunc(auth.CallbackPath, authenticator.Callback)
+	mux.HandleFunc(auth.LogoutPath, authenticator.Logout)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		id, ok := authenticator.Authenticate(r)
+		if !ok {
+			authenticator.RedirectToLogin(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(id) // nolint: errcheck
+	})
+	return atlantis
+}
+
+func newJar(t *testing.T) http.CookieJar {
+	jar, err := cookiejar.New(nil)
+	Ok(t, err)
+	return jar
+}
@@ -0,0 +1,165 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// SessionCookieName is the name of the cookie holding a user's session.
+	SessionCookieName = "atlantis_session"
+	// loginCookieName is the name of the cookie holding the state of an
+	// in-progress login.
+	loginCookieName = "atlantis_login"
+	// SessionDuration is how long users stay logged in.
+	SessionDuration = 12 * time.Hour
+	// loginDuration is how long users have to log in with the provider.
+	loginDuration = 10 * time.Minute
+)
+
+// session is the content of the session cookie.
+type session struct {
+	Identity Identity  `json:"identity"`
+	Expiry   time.Time `json:"expiry"`
+}
+
+// loginState is the content of the login cookie. It's set when we redirect
+// the user to the provider and checked when they're redirected back.
+type loginState struct {
+	State        string    `json:"state"`
+	Nonce        string    `json:"nonce"`
+	CodeVerifier string    `json:"code_verifier"`
+	ReturnTo     string    `json:"return_to"`
+	Expiry       time.Time `json:"expiry"`
+}
+
+// SessionManager stores sessions in cookies signed with a secret key so that
+// they can't be forged and any Atlantis server sharing the key can read them.
+type SessionManager struct {
+	key []byte
+	// secure is true if cookies should only be sent over HTTPS.
+	secure bool
+	// path is the path cookies are scoped to.
+	path string
+}
+
+// NewSessionManager returns a SessionManager that signs cookies with secret.
+// If secret is empty a random key is used, so sessions won't survive a
+// restart or be shared between servers. atlantisURL is the URL Atlantis is
+// accessible from externally; cookies are scoped to its path and only sent
+// over HTTPS if its scheme is https.
+func NewSessionManager(secret string, atlantisURL *url.URL) (*SessionManager, error) {
+	key := []byte(secret)
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, errors.Wrap(err, "generating session key")
+		}
+	}
+	path := atlantisURL.Path
+	if path == "" {
+		path = "/"
+	}
+	return &SessionManager{key: key, secure: atlantisURL.Scheme == "https", path: path}, nil
+}
+
+// Get returns the identity of the logged in user. ok is false if there's no
+// valid session.
+func (s *SessionManager) Get(r *http.Request) (id Identity, ok bool) {
+	var sess session
+	if err := s.readCookie(r, SessionCookieName, &sess); err != nil || time.Now().After(sess.Expiry) {
+		return Identity{}, false
+	}
+	return sess.Identity, true
+}
+
+// Set logs in the user with identity id.
+func (s *SessionManager) Set(w http.ResponseWriter, id Identity) error {
+	expiry := time.Now().Add(SessionDuration)
+	return s.writeCookie(w, SessionCookieName, session{Identity: id, Expiry: expiry}, expiry)
+}
+
+// Clear logs out the user.
+func (s *SessionManager) Clear(w http.ResponseWriter) {
+	s.clearCookie(w, SessionCookieName)
+}
+
+func (s *SessionManager) setLoginState(w http.ResponseWriter, state loginState) error {
+	return s.writeCookie(w, loginCookieName, state, state.Expiry)
+}
+
+// popLoginState returns the state of the in-progress login and clears it so
+// it can't be reused.
+func (s *SessionManager) popLoginState(w http.ResponseWriter, r *http.Request) (loginState, error) {
+	var state loginState
+	if err := s.readCookie(r, loginCookieName, &state); err != nil {
+		return state, err
+	}
+	s.clearCookie(w, loginCookieName)
+	if time.Now().After(state.Expiry) {
+		return state, errors.New("login expired")
+	}
+	return state, nil
+}
+
+func (s *SessionManager) writeCookie(w http.ResponseWriter, name string, v interface{}, expiry time.Time) error {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return errors.Wrapf(err, "serializing %s cookie", name)
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Value:    encoded + "." + s.sign(name, encoded),
+		Path:     s.path,
+		Expires:  expiry,
+		HttpOnly: true,
+		Secure:   s.secure,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return nil
+}
+
+func (s *SessionManager) readCookie(r *http.Request, name string, v interface{}) error {
+	cookie, err := r.Cookie(name)
+	if err != nil {
+		return err
+	}
+	encoded, sig, found := strings.Cut(cookie.Value, ".")
+	if !found || !hmac.Equal([]byte(sig), []byte(s.sign(name, encoded))) {
+		return errors.Errorf("%s cookie has an invalid signature", name)
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return errors.Wrapf(err, "decoding %s cookie", name)
+	}
+	return errors.Wrapf(json.Unmarshal(payload, v), "deserializing %s cookie", name)
+}
+
+func (s *SessionManager) clearCookie(w http.ResponseWriter, name string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Path:     s.path,
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   s.secure,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// sign returns the signature of a cookie's value. The cookie's name is signed
+// too so that one kind of cookie can't be used as another.
+func (s *SessionManager) sign(name string, value string) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(name + "=" + value))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
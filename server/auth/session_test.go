@@ -0,0 +1,50 @@
+package auth_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/auth"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestSessionManager(t *testing.T) {
+	atlantisURL, _ := url.Parse("https://atlantis.example.com/basepath")
+	sessions, err := auth.NewSessionManager("secret", atlantisURL)
+	Ok(t, err)
+
+	id := auth.Identity{Username: "user", Method: auth.OIDCMethod, Groups: []string{"group"}, Roles: []auth.Role{auth.ViewerRole}}
+	w := httptest.NewRecorder()
+	Ok(t, sessions.Set(w, id))
+	cookies := w.Result().Cookies()
+	Equals(t, 1, len(cookies))
+	Equals(t, auth.SessionCookieName, cookies[0].Name)
+	Equals(t, "/basepath", cookies[0].Path)
+	Assert(t, cookies[0].Secure, "exp cookie to be secure")
+	Assert(t, cookies[0].HttpOnly, "exp cookie to be http only")
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.AddCookie(cookies[0])
+	got, ok := sessions.Get(req)
+	Assert(t, ok, "exp session")
+	Equals(t, id, got)
+
+	t.Log("sessions signed with another secret are rejected")
+	other, err := auth.NewSessionManager("other", atlantisURL)
+	Ok(t, err)
+	_, ok = other.Get(req)
+	Assert(t, !ok, "exp no session")
+
+	t.Log("tampered sessions are rejected")
+	req = httptest.NewRequest("GET", "/", nil)
+	req.AddCookie(&http.Cookie{Name: auth.SessionCookieName, Value: "e30." + cookies[0].Value[len(cookies[0].Value)-43:]})
+	_, ok = sessions.Get(req)
+	Assert(t, !ok, "exp no session")
+
+	t.Log("clearing the session expires the cookie")
+	w = httptest.NewRecorder()
+	sessions.Clear(w)
+	Equals(t, -1, w.Result().Cookies()[0].MaxAge)
+}
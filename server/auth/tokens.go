@@ -0,0 +1,138 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+)
+
+// apiTokenPrefix starts every API token's secret so that leaked tokens are
+// easy to recognize.
+const apiTokenPrefix = "atlantis_"
+
+// APITokenCommands are the commands API tokens can be allowed to run.
+var APITokenCommands = []command.Name{command.Plan, command.Apply, command.Unlock}
+
+// validTokenName matches the names tokens can have.
+var validTokenName = regexp.MustCompile(`^[a-zA-Z0-9_.-]+$`)
+
+// APITokenBackend stores API tokens. It's implemented by locking.Backend.
+type APITokenBackend interface {
+	CreateAPIToken(token models.APIToken) error
+	ListAPITokens() ([]models.APIToken, error)
+	DeleteAPIToken(name string) (*models.APIToken, error)
+}
+
+// APITokens mints, authenticates and revokes scoped API tokens.
+type APITokens struct {
+	backend APITokenBackend
+	now     func() time.Time
+}
+
+// NewAPITokens returns APITokens storing tokens in backend.
+func NewAPITokens(backend APITokenBackend) *APITokens {
+	return &APITokens{backend: backend, now: time.Now}
+}
+
+// Mint validates and stores a new token. It returns the token's secret,
+// which can't be retrieved later because only its hash is stored.
+// token.SecretHash and token.CreatedAt are set by Mint.
+func (a *APITokens) Mint(token models.APIToken) (string, models.APIToken, error) {
+	if !validTokenName.MatchString(token.Name) {
+		return "", token, fmt.Errorf("invalid token name %q, must only contain letters, numbers, '_', '.' and '-'", token.Name)
+	}
+	if err := token.CompileRepos(); err != nil {
+		return "", token, errors.Wrapf(err, "parsing repos regex %q", token.Repos)
+	}
+	if len(token.Commands) == 0 {
+		return "", token, errors.New("at least one command must be allowed")
+	}
+	for _, c := range token.Commands {
+		if !isAPITokenCommand(c) {
+			return "", token, fmt.Errorf("invalid command %q, must be one of %s", c, apiTokenCommandNames())
+		}
+	}
+	if !token.ExpiresAt.IsZero() && token.Expired(a.now()) {
+		return "", token, errors.New("expiry must be in the future")
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", token, errors.Wrap(err, "generating token")
+	}
+	secret := apiTokenPrefix + base64.RawURLEncoding.EncodeToString(b)
+	token.SecretHash = hashAPIToken(secret)
+	token.CreatedAt = a.now()
+	if err := a.backend.CreateAPIToken(token); err != nil {
+		return "", token, err
+	}
+	return secret, token, nil
+}
+
+// Authenticate returns the token whose secret is secret. It returns nil if
+// there's no such token or it has expired.
+func (a *APITokens) Authenticate(secret string) (*models.APIToken, error) {
+	if !strings.HasPrefix(secret, apiTokenPrefix) {
+		return nil, nil
+	}
+	tokens, err := a.backend.ListAPITokens()
+	if err != nil {
+		return nil, err
+	}
+	hash := hashAPIToken(secret)
+	for _, t := range tokens {
+		if subtle.ConstantTimeCompare([]byte(t.SecretHash), []byte(hash)) == 1 {
+			if t.Expired(a.now()) {
+				return nil, nil
+			}
+			if err := t.CompileRepos(); err != nil {
+				return nil, errors.Wrapf(err, "parsing repos regex of token %q", t.Name)
+			}
+			return &t, nil
+		}
+	}
+	return nil, nil
+}
+
+// List returns all tokens.
+func (a *APITokens) List() ([]models.APIToken, error) {
+	return a.backend.ListAPITokens()
+}
+
+// Revoke deletes the token named name. It returns nil if there was no such
+// token.
+func (a *APITokens) Revoke(name string) (*models.APIToken, error) {
+	return a.backend.DeleteAPIToken(name)
+}
+
+func hashAPIToken(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func isAPITokenCommand(name string) bool {
+	for _, c := range APITokenCommands {
+		if c.String() == name {
+			return true
+		}
+	}
+	return false
+}
+
+func apiTokenCommandNames() string {
+	var names []string
+	for _, c := range APITokenCommands {
+		names = append(names, fmt.Sprintf("%q", c.String()))
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,101 @@
+package auth_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/auth"
+	"github.com/runatlantis/atlantis/server/core/db"
+	"github.com/runatlantis/atlantis/server/events/models"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestAPITokens_MintAndAuthenticate(t *testing.T) {
+	boltDB, err := db.New(t.TempDir())
+	Ok(t, err)
+	tokens := auth.NewAPITokens(boltDB)
+
+	secret, token, err := tokens.Mint(models.APIToken{Name: "ci", Repos: "github.com/owner/.*", Commands: []string{"plan"}})
+	Ok(t, err)
+	Assert(t, strings.HasPrefix(secret, "atlantis_"), "exp prefixed secret, got %q", secret)
+	Assert(t, !strings.Contains(token.SecretHash, secret), "exp the secret to be hashed")
+	Assert(t, !token.CreatedAt.IsZero(), "exp created at to be set")
+
+	authenticated, err := tokens.Authenticate(secret)
+	Ok(t, err)
+	Equals(t, &token, authenticated)
+	Assert(t, authenticated.AllowsRepo("github.com/owner/repo"), "exp repo to be allowed")
+	Assert(t, !authenticated.AllowsRepo("github.com/other/repo"), "exp repo not to be allowed")
+	Assert(t, !authenticated.AllowsRepo("evil.com/github.com/owner/repo"), "exp repos to be matched from the start")
+	Assert(t, authenticated.AllowsCommand("plan"), "exp plan to be allowed")
+	Assert(t, !authenticated.AllowsCommand("apply"), "exp apply not to be allowed")
+
+	authenticated, err = tokens.Authenticate("atlantis_wrong")
+	Ok(t, err)
+	Assert(t, authenticated == nil, "exp wrong secret not to authenticate")
+
+	t.Log("names must be unique")
+	_, _, err = tokens.Mint(models.APIToken{Name: "ci", Commands: []string{"apply"}})
+	ErrContains(t, `token "ci" already exists`, err)
+
+	t.Log("revoked tokens don't authenticate")
+	revoked, err := tokens.Revoke("ci")
+	Ok(t, err)
+	Equals(t, "ci", revoked.Name)
+	authenticated, err = tokens.Authenticate(secret)
+	Ok(t, err)
+	Assert(t, authenticated == nil, "exp revoked token not to authenticate")
+}
+
+func TestAPIToken_AllowsRepo(t *testing.T) {
+	token := models.APIToken{Name: "ci", Repos: "github.com/org/app"}
+	Assert(t, !token.AllowsRepo("github.com/org/app"), "exp uncompiled token not to allow repos")
+	Ok(t, token.CompileRepos())
+	Assert(t, token.AllowsRepo("github.com/org/app"), "exp repo to be allowed")
+	Assert(t, !token.AllowsRepo("github.com/org/app-secrets"), "exp repos to be matched in full")
+
+	all := models.APIToken{Name: "ci"}
+	Assert(t, all.AllowsRepo("github.com/org/app"), "exp empty repos to allow all repos")
+}
+
+func TestAPITokens_Expiry(t *testing.T) {
+	boltDB, err := db.New(t.TempDir())
+	Ok(t, err)
+	tokens := auth.NewAPITokens(boltDB)
+
+	secret, _, err := tokens.Mint(models.APIToken{Name: "ci", Commands: []string{"plan"}, ExpiresAt: time.Now().Add(50 * time.Millisecond)})
+	Ok(t, err)
+	authenticated, err := tokens.Authenticate(secret)
+	Ok(t, err)
+	Assert(t, authenticated != nil, "exp token to authenticate before it expires")
+
+	time.Sleep(60 * time.Millisecond)
+	authenticated, err = tokens.Authenticate(secret)
+	Ok(t, err)
+	Assert(t, authenticated == nil, "exp expired token not to authenticate")
+}
+
+func TestAPITokens_MintInvalid(t *testing.T) {
+	boltDB, err := db.New(t.TempDir())
+	Ok(t, err)
+	tokens := auth.NewAPITokens(boltDB)
+
+	cases := []struct {
+		description string
+		token       models.APIToken
+		expErr      string
+	}{
+		{"invalid name", models.APIToken{Name: "my token", Commands: []string{"plan"}}, `invalid token name "my token"`},
+		{"invalid repos", models.APIToken{Name: "ci", Repos: "(", Commands: []string{"plan"}}, `parsing repos regex "("`},
+		{"no commands", models.APIToken{Name: "ci"}, "at least one command must be allowed"},
+		{"invalid command", models.APIToken{Name: "ci", Commands: []string{"import"}}, `invalid command "import", must be one of "plan", "apply", "unlock"`},
+		{"expired", models.APIToken{Name: "ci", Commands: []string{"plan"}, ExpiresAt: time.Now().Add(-time.Hour)}, "expiry must be in the future"},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			_, _, err := tokens.Mint(c.token)
+			ErrContains(t, c.expErr, err)
+		})
+	}
+}
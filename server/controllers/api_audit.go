@@ -0,0 +1,124 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/urfave/negroni/v3"
+)
+
+// defaultAuditLimit is how many audit events are returned if the limit query
+// parameter isn't set.
+const defaultAuditLimit = 100
+
+// APIAuditResponse is the response to GET /api/audit.
+type APIAuditResponse struct {
+	Events []models.AuditEvent `json:"events"`
+}
+
+// ListAuditEvents is the GET /api/audit route. It lists the audit events
+// matching the query parameters, newest first. Events are only stored for
+// querying if Atlantis was started with --audit-db.
+func (a *APIController) ListAuditEvents(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	caller, code, err := a.apiAuthenticate(r)
+	if err != nil {
+		a.apiReportError(w, code, err)
+		return
+	}
+	// Audit events are from every repo so scoped tokens can't read them.
+	if caller.token != nil {
+		a.apiReportError(w, http.StatusForbidden, fmt.Errorf("token %q can't read audit events, use the API secret", caller.token.Name))
+		return
+	}
+	if a.AuditDB == nil {
+		a.apiReportError(w, http.StatusBadRequest, fmt.Errorf("audit events aren't stored in the database, start Atlantis with --audit-db"))
+		return
+	}
+
+	query, err := parseAuditQuery(r)
+	if err != nil {
+		a.apiReportError(w, http.StatusBadRequest, err)
+		return
+	}
+	events, err := a.AuditDB.ListAuditEvents(query)
+	if err != nil {
+		a.apiReportError(w, http.StatusInternalServerError, fmt.Errorf("failed listing audit events: %w", err))
+		return
+	}
+	resp := APIAuditResponse{Events: []models.AuditEvent{}}
+	resp.Events = append(resp.Events, events...)
+	a.apiRespondJSON(w, http.StatusOK, resp)
+}
+
+// Audited wraps an API route so that an audit event is recorded for each
+// request to it.
+func (a *APIController) Audited(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !a.Auditor.Enabled() {
+			next(w, r)
+			return
+		}
+		// The caller is found before the request is handled since it may
+		// revoke the token it was made with.
+		var actor string
+		if caller, _, err := a.apiAuthenticate(r); err == nil {
+			actor = caller.identity.Username
+		}
+		path := r.URL.Path
+		if route := mux.CurrentRoute(r); route != nil {
+			if tmpl, err := route.GetPathTemplate(); err == nil {
+				path = tmpl
+			}
+		}
+
+		rw := negroni.NewResponseWriter(w)
+		next(rw, r)
+
+		outcome := models.AuditSuccess
+		if rw.Status() >= http.StatusInternalServerError {
+			outcome = models.AuditError
+		} else if rw.Status() >= http.StatusBadRequest {
+			outcome = models.AuditFailure
+		}
+		a.Auditor.Record(models.AuditEvent{
+			Type:    models.APICallAuditEvent,
+			Actor:   actor,
+			Repo:    r.URL.Query().Get("repo"),
+			Command: fmt.Sprintf("%s %s", r.Method, path),
+			Outcome: outcome,
+			Detail:  fmt.Sprintf("status %d", rw.Status()),
+		})
+	}
+}
+
+func parseAuditQuery(r *http.Request) (models.AuditQuery, error) {
+	q := r.URL.Query()
+	query := models.AuditQuery{
+		Type:    models.AuditEventType(q.Get("type")),
+		Actor:   q.Get("actor"),
+		Repo:    q.Get("repo"),
+		Command: q.Get("command"),
+		Outcome: models.AuditOutcome(q.Get("outcome")),
+	}
+	var err error
+	if query.Pull, err = parsePositiveIntQuery(r, "pull", 0); err != nil {
+		return query, err
+	}
+	if query.Limit, err = parsePositiveIntQuery(r, "limit", defaultAuditLimit); err != nil {
+		return query, err
+	}
+	for name, t := range map[string]*time.Time{"since": &query.Since, "until": &query.Until} {
+		raw := q.Get(name)
+		if raw == "" {
+			continue
+		}
+		if *t, err = time.Parse(time.RFC3339, raw); err != nil {
+			return query, fmt.Errorf("invalid %s query parameter %q, must be an RFC 3339 time like 2006-01-02T15:04:05Z", name, raw)
+		}
+	}
+	return query, nil
+}
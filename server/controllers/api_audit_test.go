@@ -0,0 +1,66 @@
+package controllers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/audit"
+	"github.com/runatlantis/atlantis/server/controllers"
+	"github.com/runatlantis/atlantis/server/events/models"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestAPIController_ListAuditEvents(t *testing.T) {
+	ac, _, _ := setupLocksAPI(t)
+
+	t.Log("events can't be queried unless they're stored in the database")
+	w := httptest.NewRecorder()
+	ac.ListAuditEvents(w, tokenRequest("GET", "/api/audit", atlantisToken, ""))
+	ResponseContains(t, w, http.StatusBadRequest, "start Atlantis with --audit-db")
+
+	ac.AuditDB = ac.Backend
+	ac.Auditor = audit.NewAuditor(ac.Logger, &audit.DBSink{Backend: ac.Backend})
+
+	t.Log("deleting a lock records the API call and the unlock")
+	w = httptest.NewRecorder()
+	ac.Audited(ac.DeleteLock)(w, lockRequest("DELETE", "owner/repo/a/default"))
+	Equals(t, http.StatusOK, w.Result().StatusCode)
+
+	w = httptest.NewRecorder()
+	ac.ListAuditEvents(w, tokenRequest("GET", "/api/audit", atlantisToken, ""))
+	Equals(t, http.StatusOK, w.Result().StatusCode)
+	var resp controllers.APIAuditResponse
+	Ok(t, json.NewDecoder(w.Result().Body).Decode(&resp))
+	Equals(t, 2, len(resp.Events))
+	apiCall, unlock := resp.Events[0], resp.Events[1]
+	Equals(t, models.APICallAuditEvent, apiCall.Type)
+	Equals(t, "api", apiCall.Actor)
+	Equals(t, models.AuditSuccess, apiCall.Outcome)
+	Equals(t, "status 200", apiCall.Detail)
+	Equals(t, models.UnlockAuditEvent, unlock.Type)
+	Equals(t, "api", unlock.Actor)
+	Equals(t, "owner/repo", unlock.Repo)
+	Equals(t, 1, unlock.Pull)
+	Equals(t, "a", unlock.Directory)
+	Equals(t, "default", unlock.Workspace)
+
+	t.Log("events can be filtered")
+	w = httptest.NewRecorder()
+	ac.ListAuditEvents(w, tokenRequest("GET", "/api/audit?type=unlock&repo=owner/repo", atlantisToken, ""))
+	resp = controllers.APIAuditResponse{}
+	Ok(t, json.NewDecoder(w.Result().Body).Decode(&resp))
+	Equals(t, []models.AuditEvent{unlock}, resp.Events)
+
+	w = httptest.NewRecorder()
+	ac.ListAuditEvents(w, tokenRequest("GET", "/api/audit?since=yesterday", atlantisToken, ""))
+	ResponseContains(t, w, http.StatusBadRequest, "invalid since query parameter")
+
+	t.Log("scoped tokens can't read events")
+	secret, _, err := ac.APITokens.Mint(models.APIToken{Name: "ci", Commands: []string{"plan"}})
+	Ok(t, err)
+	w = httptest.NewRecorder()
+	ac.ListAuditEvents(w, tokenRequest("GET", "/api/audit", secret, ""))
+	ResponseContains(t, w, http.StatusForbidden, "can't read audit events")
+}
@@ -8,11 +8,14 @@ import (
 	"strings"
 
 	"github.com/go-playground/validator/v10"
+	"github.com/runatlantis/atlantis/server/audit"
+	"github.com/runatlantis/atlantis/server/auth"
 	"github.com/runatlantis/atlantis/server/core/locking"
 	"github.com/runatlantis/atlantis/server/events"
 	"github.com/runatlantis/atlantis/server/events/command"
 	"github.com/runatlantis/atlantis/server/events/models"
 	"github.com/runatlantis/atlantis/server/events/vcs"
+	"github.com/runatlantis/atlantis/server/jobs"
 	"github.com/runatlantis/atlantis/server/logging"
 	tally "github.com/uber-go/tally/v4"
 )
@@ -20,13 +23,23 @@ import (
 const atlantisTokenHeader = "X-Atlantis-Token"
 
 type APIController struct {
-	APISecret                 []byte
+	APISecret []byte
+	APITokens *auth.APITokens
+	// AuditDB is where audit events are queried from. It's nil if audit
+	// events aren't stored in the database.
+	AuditDB                   audit.DBBackend
+	Auditor                   *audit.Auditor
+	Authorizer                *auth.Authorizer
+	Backend                   locking.Backend
+	DeleteLockCommand         events.DeleteLockCommand
+	JobURLGenerator           jobs.ProjectJobURLGenerator
 	Locker                    locking.Locker
 	Logger                    logging.SimpleLogging
 	Parser                    events.EventParsing
 	ProjectCommandBuilder     events.ProjectCommandBuilder
 	ProjectPlanCommandRunner  events.ProjectPlanCommandRunner
 	ProjectApplyCommandRunner events.ProjectApplyCommandRunner
+	ProjectCmdOutputHandler   jobs.ProjectCommandOutputHandler
 	RepoAllowlistChecker      *events.RepoAllowlistChecker
 	Scope                     tally.Scope
 	VCSClient                 vcs.Client
@@ -81,7 +94,7 @@ func (a *APIController) apiReportError(w http.ResponseWriter, code int, err erro
 func (a *APIController) Plan(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	request, ctx, code, err := a.apiParseAndValidate(r)
+	request, ctx, code, err := a.apiParseAndValidate(r, command.Plan)
 	if err != nil {
 		a.apiReportError(w, code, err)
 		return
@@ -109,7 +122,7 @@ func (a *APIController) Plan(w http.ResponseWriter, r *http.Request) {
 func (a *APIController) Apply(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	request, ctx, code, err := a.apiParseAndValidate(r)
+	request, ctx, code, err := a.apiParseAndValidate(r, command.Apply)
 	if err != nil {
 		a.apiReportError(w, code, err)
 		return
@@ -169,15 +182,12 @@ func (a *APIController) apiApply(request *APIRequest, ctx *command.Context) (*co
 	return &command.Result{ProjectResults: projectResults}, nil
 }
 
-func (a *APIController) apiParseAndValidate(r *http.Request) (*APIRequest, *command.Context, int, error) {
-	if len(a.APISecret) == 0 {
-		return nil, nil, http.StatusBadRequest, fmt.Errorf("ignoring request since API is disabled")
-	}
-
-	// Validate the secret token
-	secret := r.Header.Get(atlantisTokenHeader)
-	if secret != string(a.APISecret) {
-		return nil, nil, http.StatusUnauthorized, fmt.Errorf("header %s did not match expected secret", atlantisTokenHeader)
+// apiParseAndValidate parses a request to run cmd and checks that the caller
+// is allowed to run it on the request's repo.
+func (a *APIController) apiParseAndValidate(r *http.Request, cmd command.Name) (*APIRequest, *command.Context, int, error) {
+	caller, code, err := a.apiAuthenticate(r)
+	if err != nil {
+		return nil, nil, code, err
 	}
 
 	// Parse the JSON payload
@@ -211,6 +221,9 @@ func (a *APIController) apiParseAndValidate(r *http.Request) (*APIRequest, *comm
 	if !a.RepoAllowlistChecker.IsAllowlisted(baseRepo.FullName, baseRepo.VCSHost.Hostname) {
 		return nil, nil, http.StatusForbidden, fmt.Errorf("repo not allowlisted")
 	}
+	if err := caller.authorize(a.Authorizer, baseRepo.ID(), auth.OperatorRole, cmd); err != nil {
+		return nil, nil, http.StatusForbidden, err
+	}
 
 	return &request, &command.Context{
 		HeadRepo: baseRepo,
@@ -221,6 +234,7 @@ func (a *APIController) apiParseAndValidate(r *http.Request) (*APIRequest, *comm
 			HeadCommit: request.Ref,
 			BaseRepo:   baseRepo,
 		},
+		User:  caller.user(),
 		Scope: a.Scope,
 		Log:   a.Logger,
 	}, http.StatusOK, nil
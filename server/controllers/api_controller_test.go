@@ -8,7 +8,9 @@ import (
 	"testing"
 
 	. "github.com/petergtz/pegomock/v4"
+	"github.com/runatlantis/atlantis/server/auth"
 	"github.com/runatlantis/atlantis/server/controllers"
+	"github.com/runatlantis/atlantis/server/core/db"
 	. "github.com/runatlantis/atlantis/server/core/locking/mocks"
 	"github.com/runatlantis/atlantis/server/events"
 	"github.com/runatlantis/atlantis/server/events/command"
@@ -58,6 +60,36 @@ func TestAPIController_Apply(t *testing.T) {
 	projectCommandRunner.VerifyWasCalledOnce().Apply(Any[command.ProjectContext]())
 }
 
+func TestAPIController_PlanWithToken(t *testing.T) {
+	ac, projectCommandBuilder, _ := setup(t)
+	boltDB, err := db.New(t.TempDir())
+	Ok(t, err)
+	ac.APITokens = auth.NewAPITokens(boltDB)
+	secret, _, err := ac.APITokens.Mint(models.APIToken{Name: "ci", Commands: []string{"plan"}})
+	Ok(t, err)
+	body, _ := json.Marshal(controllers.APIRequest{
+		Repository: "Repo",
+		Ref:        "main",
+		Type:       "Gitlab",
+		Projects:   []string{"default"},
+	})
+
+	req, _ := http.NewRequest("POST", "", bytes.NewBuffer(body))
+	req.Header.Set(atlantisTokenHeader, secret)
+	w := httptest.NewRecorder()
+	ac.Plan(w, req)
+	ResponseContains(t, w, http.StatusOK, "")
+	ctx, _ := projectCommandBuilder.VerifyWasCalledOnce().BuildPlanCommands(Any[*command.Context](), Any[*events.CommentCommand]()).GetCapturedArguments()
+	Equals(t, "ci", ctx.User.Username)
+
+	t.Log("the token can't apply")
+	req, _ = http.NewRequest("POST", "", bytes.NewBuffer(body))
+	req.Header.Set(atlantisTokenHeader, secret)
+	w = httptest.NewRecorder()
+	ac.Apply(w, req)
+	ResponseContains(t, w, http.StatusForbidden, `token \"ci\" isn't allowed to run apply`)
+}
+
 func setup(t *testing.T) (controllers.APIController, *MockProjectCommandBuilder, *MockProjectCommandRunner) {
 	RegisterMockTestingT(t)
 	locker := NewMockLocker()
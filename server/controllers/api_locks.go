@@ -0,0 +1,433 @@
+package controllers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/runatlantis/atlantis/server/auth"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+)
+
+const (
+	// defaultLocksPerPage is the number of locks returned by GET /api/locks if
+	// per_page isn't set.
+	defaultLocksPerPage = 50
+	// maxLocksPerPage is the maximum per_page for GET /api/locks.
+	maxLocksPerPage = 500
+)
+
+// APILock is the JSON representation of a lock returned by the locks API.
+type APILock struct {
+	// ID identifies the lock in /api/locks/{id}. It's the lock's key encoded
+	// with unpadded URL-safe base64 because keys contain slashes.
+	ID          string    `json:"id"`
+	Key         string    `json:"key"`
+	Repo        string    `json:"repo"`
+	Path        string    `json:"path"`
+	Workspace   string    `json:"workspace"`
+	PullNum     int       `json:"pull_num"`
+	PullURL     string    `json:"pull_url"`
+	PullAuthor  string    `json:"pull_author"`
+	LockedBy    string    `json:"locked_by"`
+	LockedSince time.Time `json:"locked_since"`
+	// ExpiresAt is when the lock expires. It's omitted if the lock doesn't
+	// expire.
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// APIListLocksResponse is the response to GET /api/locks.
+type APIListLocksResponse struct {
+	Locks   []APILock `json:"locks"`
+	Total   int       `json:"total"`
+	Page    int       `json:"page"`
+	PerPage int       `json:"per_page"`
+}
+
+// APIDeleteLocksResponse is the response to DELETE /api/locks and
+// DELETE /api/locks/{id}.
+type APIDeleteLocksResponse struct {
+	Deleted []APILock `json:"deleted"`
+}
+
+// lockFilter filters locks by the query parameters of a locks API request.
+// Empty fields match any lock.
+type lockFilter struct {
+	repo      string
+	pullNum   int
+	path      string
+	workspace string
+}
+
+func (f lockFilter) matches(lock models.ProjectLock) bool {
+	return (f.repo == "" || lock.Project.RepoFullName == f.repo) &&
+		(f.pullNum == 0 || lock.Pull.Num == f.pullNum) &&
+		(f.path == "" || lock.Project.Path == f.path) &&
+		(f.workspace == "" || lock.Workspace == f.workspace)
+}
+
+// ListLocks is the GET /api/locks route. It returns the locks matching the
+// repo, pull, path and workspace query parameters a page at a time.
+func (a *APIController) ListLocks(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	caller, code, err := a.apiAuthenticate(r)
+	if err != nil {
+		a.apiReportError(w, code, err)
+		return
+	}
+
+	filter, err := parseLockFilter(r)
+	if err != nil {
+		a.apiReportError(w, http.StatusBadRequest, err)
+		return
+	}
+	page, err := parsePositiveIntQuery(r, "page", 1)
+	if err != nil {
+		a.apiReportError(w, http.StatusBadRequest, err)
+		return
+	}
+	perPage, err := parsePositiveIntQuery(r, "per_page", defaultLocksPerPage)
+	if err != nil {
+		a.apiReportError(w, http.StatusBadRequest, err)
+		return
+	}
+	if perPage > maxLocksPerPage {
+		perPage = maxLocksPerPage
+	}
+
+	locks, err := a.filterLocks(filter, func(lock models.ProjectLock) bool {
+		return caller.canView(a.Authorizer, lockRepoID(lock))
+	})
+	if err != nil {
+		a.apiReportError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	resp := APIListLocksResponse{
+		Locks:   []APILock{},
+		Total:   len(locks),
+		Page:    page,
+		PerPage: perPage,
+	}
+	if start := (page - 1) * perPage; start < len(locks) {
+		end := start + perPage
+		if end > len(locks) {
+			end = len(locks)
+		}
+		resp.Locks = locks[start:end]
+	}
+	a.apiRespondJSON(w, http.StatusOK, resp)
+}
+
+// GetLock is the GET /api/locks/{id} route.
+func (a *APIController) GetLock(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	caller, code, err := a.apiAuthenticate(r)
+	if err != nil {
+		a.apiReportError(w, code, err)
+		return
+	}
+
+	key, err := lockKeyFromRequest(r)
+	if err != nil {
+		a.apiReportError(w, http.StatusBadRequest, err)
+		return
+	}
+	lock, err := a.Locker.GetLock(key)
+	if err != nil {
+		a.apiReportError(w, http.StatusInternalServerError, fmt.Errorf("failed getting lock: %w", err))
+		return
+	}
+	if lock == nil {
+		a.apiReportError(w, http.StatusNotFound, fmt.Errorf("no lock found at id %q", mux.Vars(r)["id"]))
+		return
+	}
+	if !caller.canView(a.Authorizer, lockRepoID(*lock)) {
+		a.apiReportError(w, http.StatusNotFound, fmt.Errorf("no lock found at id %q", mux.Vars(r)["id"]))
+		return
+	}
+	if err := a.Authorizer.AuthorizeRepo(caller.identity, lockRepoID(*lock), auth.ViewerRole); err != nil {
+		a.apiReportError(w, http.StatusForbidden, err)
+		return
+	}
+	a.apiRespondJSON(w, http.StatusOK, newAPILock(key, *lock))
+}
+
+// DeleteLock is the DELETE /api/locks/{id} route. Like deleting a lock from
+// the UI, it discards the lock's plan and comments on its pull request.
+func (a *APIController) DeleteLock(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	caller, code, err := a.apiAuthenticate(r)
+	if err != nil {
+		a.apiReportError(w, code, err)
+		return
+	}
+
+	key, err := lockKeyFromRequest(r)
+	if err != nil {
+		a.apiReportError(w, http.StatusBadRequest, err)
+		return
+	}
+	if a.Authorizer.Enabled() || caller.token != nil {
+		lock, err := a.Locker.GetLock(key)
+		if err != nil {
+			a.apiReportError(w, http.StatusInternalServerError, fmt.Errorf("failed getting lock: %w", err))
+			return
+		}
+		if lock != nil {
+			if err := caller.authorize(a.Authorizer, lockRepoID(*lock), auth.OperatorRole, command.Unlock); err != nil {
+				a.apiReportError(w, http.StatusForbidden, err)
+				return
+			}
+		}
+	}
+	deleted, err := a.deleteLock(caller, key)
+	if err != nil {
+		a.apiReportError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if deleted == nil {
+		a.apiReportError(w, http.StatusNotFound, fmt.Errorf("no lock found at id %q", mux.Vars(r)["id"]))
+		return
+	}
+	a.apiRespondJSON(w, http.StatusOK, APIDeleteLocksResponse{Deleted: []APILock{*deleted}})
+}
+
+// DeleteLocks is the DELETE /api/locks route. It deletes all locks matching
+// the query parameters. The repo parameter is required so that a request
+// can't delete every lock by accident.
+func (a *APIController) DeleteLocks(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	caller, code, err := a.apiAuthenticate(r)
+	if err != nil {
+		a.apiReportError(w, code, err)
+		return
+	}
+
+	filter, err := parseLockFilter(r)
+	if err != nil {
+		a.apiReportError(w, http.StatusBadRequest, err)
+		return
+	}
+	if filter.repo == "" {
+		a.apiReportError(w, http.StatusBadRequest, fmt.Errorf("repo query parameter is required"))
+		return
+	}
+
+	// Nothing is deleted if any of the locks the user can see can't be
+	// deleted by them.
+	var forbidden error
+	locks, err := a.filterLocks(filter, func(lock models.ProjectLock) bool {
+		if !caller.canView(a.Authorizer, lockRepoID(lock)) {
+			return false
+		}
+		if err := caller.authorize(a.Authorizer, lockRepoID(lock), auth.OperatorRole, command.Unlock); err != nil && forbidden == nil {
+			forbidden = err
+		}
+		return true
+	})
+	if err != nil {
+		a.apiReportError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if forbidden != nil {
+		a.apiReportError(w, http.StatusForbidden, forbidden)
+		return
+	}
+	resp := APIDeleteLocksResponse{Deleted: []APILock{}}
+	for _, lock := range locks {
+		deleted, err := a.deleteLock(caller, lock.Key)
+		if err != nil {
+			a.apiReportError(w, http.StatusInternalServerError, err)
+			return
+		}
+		// The lock may have been deleted since we listed it.
+		if deleted != nil {
+			resp.Deleted = append(resp.Deleted, *deleted)
+		}
+	}
+	a.apiRespondJSON(w, http.StatusOK, resp)
+}
+
+// deleteLock deletes the lock at key on behalf of caller and discards its
+// plan. It returns nil if there was no lock at key.
+func (a *APIController) deleteLock(caller apiCaller, key string) (*APILock, error) {
+	lock, err := a.DeleteLockCommand.DeleteLock(key)
+	if err != nil {
+		return nil, fmt.Errorf("deleting lock %q failed with: %w", key, err)
+	}
+	if lock == nil {
+		return nil, nil
+	}
+	a.Logger.Info("deleted lock %q via the API", key)
+	a.Auditor.Record(newUnlockAuditEvent(caller.identity.Username, *lock))
+	discardLockedPlan(a.Logger, a.Backend, a.VCSClient, lock, "the Atlantis API")
+	deleted := newAPILock(key, *lock)
+	return &deleted, nil
+}
+
+// filterLocks returns the locks matching filter that include returns true
+// for, sorted by key.
+func (a *APIController) filterLocks(filter lockFilter, include func(models.ProjectLock) bool) ([]APILock, error) {
+	all, err := a.Locker.List()
+	if err != nil {
+		return nil, fmt.Errorf("failed listing locks: %w", err)
+	}
+	var locks []APILock
+	for key, lock := range all {
+		if filter.matches(lock) && include(lock) {
+			locks = append(locks, newAPILock(key, lock))
+		}
+	}
+	sort.Slice(locks, func(i, j int) bool { return locks[i].Key < locks[j].Key })
+	return locks, nil
+}
+
+// apiCaller is who made an API request.
+type apiCaller struct {
+	identity auth.Identity
+	// token is the scoped token the request was made with. It's nil for
+	// requests made with the API secret.
+	token *models.APIToken
+}
+
+// canView returns true if the caller can see the repo with ID repoID.
+func (c apiCaller) canView(authorizer *auth.Authorizer, repoID string) bool {
+	return (c.token == nil || c.token.AllowsRepo(repoID)) && authorizer.CanView(c.identity, repoID)
+}
+
+// authorize returns an error if the caller doesn't have role on the repo with
+// ID repoID or, for scoped tokens, isn't allowed to run cmd on it.
+func (c apiCaller) authorize(authorizer *auth.Authorizer, repoID string, role auth.Role, cmd command.Name) error {
+	if c.token != nil {
+		if !c.token.AllowsRepo(repoID) {
+			return fmt.Errorf("token %q isn't allowed to access %s", c.token.Name, repoID)
+		}
+		if !c.token.AllowsCommand(cmd.String()) {
+			return fmt.Errorf("token %q isn't allowed to run %s", c.token.Name, cmd)
+		}
+	}
+	return authorizer.AuthorizeRepo(c.identity, repoID, role)
+}
+
+// user is who commands run by the caller are recorded as being run by.
+// Commands run with the API secret aren't attributed to anyone.
+func (c apiCaller) user() models.User {
+	if c.token == nil {
+		return models.User{}
+	}
+	return models.User{Username: c.token.Name}
+}
+
+// apiSecretIdentity is the identity of requests made with the API secret.
+// The secret can plan and apply any repo so it's an admin.
+var apiSecretIdentity = auth.Identity{
+	Username: "api",
+	Method:   auth.APISecretMethod,
+	Roles:    []auth.Role{auth.AdminRole},
+}
+
+// apiAuthenticate checks that the API is enabled and that the request has
+// the API secret or a scoped API token. It returns who made the request. The
+// API is enabled if either the API secret or API tokens are configured.
+func (a *APIController) apiAuthenticate(r *http.Request) (apiCaller, int, error) {
+	if len(a.APISecret) == 0 && a.APITokens == nil {
+		return apiCaller{}, http.StatusBadRequest, fmt.Errorf("ignoring request since API is disabled")
+	}
+	secret := r.Header.Get(atlantisTokenHeader)
+	if len(a.APISecret) > 0 && secret == string(a.APISecret) {
+		return apiCaller{identity: apiSecretIdentity}, http.StatusOK, nil
+	}
+	if a.APITokens != nil {
+		token, err := a.APITokens.Authenticate(secret)
+		if err != nil {
+			return apiCaller{}, http.StatusInternalServerError, fmt.Errorf("authenticating token: %w", err)
+		}
+		if token != nil {
+			// Tokens are scoped by their repos and commands rather than by
+			// roles.
+			return apiCaller{
+				identity: auth.Identity{Username: token.Name, Method: auth.APITokenMethod, Roles: []auth.Role{auth.AdminRole}},
+				token:    token,
+			}, http.StatusOK, nil
+		}
+	}
+	return apiCaller{}, http.StatusUnauthorized, fmt.Errorf("header %s did not match expected secret", atlantisTokenHeader)
+}
+
+func (a *APIController) apiRespondJSON(w http.ResponseWriter, code int, v interface{}) {
+	response, err := json.Marshal(v)
+	if err != nil {
+		a.apiReportError(w, http.StatusInternalServerError, err)
+		return
+	}
+	a.respond(w, logging.Debug, code, "%s", response)
+}
+
+func newAPILock(key string, lock models.ProjectLock) APILock {
+	apiLock := APILock{
+		ID:          base64.RawURLEncoding.EncodeToString([]byte(key)),
+		Key:         key,
+		Repo:        lock.Project.RepoFullName,
+		Path:        lock.Project.Path,
+		Workspace:   lock.Workspace,
+		PullNum:     lock.Pull.Num,
+		PullURL:     lock.Pull.URL,
+		PullAuthor:  lock.Pull.Author,
+		LockedBy:    lock.User.Username,
+		LockedSince: lock.Time,
+	}
+	if !lock.ExpiresAt.IsZero() {
+		apiLock.ExpiresAt = &lock.ExpiresAt
+	}
+	return apiLock
+}
+
+// lockKeyFromRequest decodes the lock key from the id route variable.
+func lockKeyFromRequest(r *http.Request) (string, error) {
+	id := mux.Vars(r)["id"]
+	if id == "" {
+		return "", fmt.Errorf("no lock id in request")
+	}
+	key, err := base64.RawURLEncoding.DecodeString(id)
+	if err != nil {
+		return "", fmt.Errorf("invalid lock id %q: %w", id, err)
+	}
+	return string(key), nil
+}
+
+func parseLockFilter(r *http.Request) (lockFilter, error) {
+	query := r.URL.Query()
+	filter := lockFilter{
+		repo:      query.Get("repo"),
+		path:      query.Get("path"),
+		workspace: query.Get("workspace"),
+	}
+	pullNum, err := parsePositiveIntQuery(r, "pull", 0)
+	if err != nil {
+		return filter, err
+	}
+	filter.pullNum = pullNum
+	return filter, nil
+}
+
+// parsePositiveIntQuery parses the query parameter name as a positive integer,
+// returning def if it isn't set.
+func parsePositiveIntQuery(r *http.Request, name string, def int) (int, error) {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		return def, nil
+	}
+	i, err := strconv.Atoi(raw)
+	if err != nil || i < 1 {
+		return 0, fmt.Errorf("%s query parameter must be a positive integer, got %q", name, raw)
+	}
+	return i, nil
+}
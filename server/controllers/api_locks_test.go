@@ -0,0 +1,258 @@
+package controllers_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	. "github.com/petergtz/pegomock/v4"
+	"github.com/runatlantis/atlantis/server/auth"
+	"github.com/runatlantis/atlantis/server/controllers"
+	"github.com/runatlantis/atlantis/server/core/db"
+	"github.com/runatlantis/atlantis/server/core/locking"
+	"github.com/runatlantis/atlantis/server/events"
+	. "github.com/runatlantis/atlantis/server/events/mocks"
+	"github.com/runatlantis/atlantis/server/events/models"
+	. "github.com/runatlantis/atlantis/server/events/vcs/mocks"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestAPIController_ListLocks(t *testing.T) {
+	ac, _, _ := setupLocksAPI(t)
+
+	cases := []struct {
+		description string
+		query       string
+		expCode     int
+		expKeys     []string
+		expTotal    int
+	}{
+		{
+			"no filters",
+			"",
+			http.StatusOK,
+			[]string{"owner/other/./default", "owner/repo/a/default", "owner/repo/b/default", "owner/repo/b/staging"},
+			4,
+		},
+		{
+			"filter by repo",
+			"?repo=owner/repo",
+			http.StatusOK,
+			[]string{"owner/repo/a/default", "owner/repo/b/default", "owner/repo/b/staging"},
+			3,
+		},
+		{
+			"filter by pull",
+			"?repo=owner/repo&pull=2",
+			http.StatusOK,
+			[]string{"owner/repo/b/default", "owner/repo/b/staging"},
+			2,
+		},
+		{
+			"filter by path and workspace",
+			"?path=b&workspace=staging",
+			http.StatusOK,
+			[]string{"owner/repo/b/staging"},
+			1,
+		},
+		{
+			"paginated",
+			"?page=2&per_page=3",
+			http.StatusOK,
+			[]string{"owner/repo/b/staging"},
+			4,
+		},
+		{
+			"page past the end",
+			"?page=3&per_page=3",
+			http.StatusOK,
+			[]string{},
+			4,
+		},
+		{
+			"invalid pull",
+			"?pull=abc",
+			http.StatusBadRequest,
+			nil,
+			0,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/api/locks"+c.query, nil)
+			req.Header.Set(atlantisTokenHeader, atlantisToken)
+			w := httptest.NewRecorder()
+			ac.ListLocks(w, req)
+			Equals(t, c.expCode, w.Result().StatusCode)
+			if c.expCode != http.StatusOK {
+				return
+			}
+			var resp controllers.APIListLocksResponse
+			Ok(t, json.NewDecoder(w.Result().Body).Decode(&resp))
+			keys := []string{}
+			for _, l := range resp.Locks {
+				keys = append(keys, l.Key)
+			}
+			Equals(t, c.expKeys, keys)
+			Equals(t, c.expTotal, resp.Total)
+		})
+	}
+}
+
+func TestAPIController_LocksRequireToken(t *testing.T) {
+	ac, _, _ := setupLocksAPI(t)
+	req, _ := http.NewRequest("GET", "/api/locks", nil)
+	req.Header.Set(atlantisTokenHeader, "wrong")
+	w := httptest.NewRecorder()
+	ac.ListLocks(w, req)
+	ResponseContains(t, w, http.StatusUnauthorized, "header X-Atlantis-Token did not match expected secret")
+
+	t.Log("tokens work without the API secret, but an empty header isn't the API secret")
+	ac.APISecret = nil
+	req.Header.Set(atlantisTokenHeader, "")
+	w = httptest.NewRecorder()
+	ac.ListLocks(w, req)
+	ResponseContains(t, w, http.StatusUnauthorized, "header X-Atlantis-Token did not match expected secret")
+
+	ac.APITokens = nil
+	w = httptest.NewRecorder()
+	ac.ListLocks(w, req)
+	ResponseContains(t, w, http.StatusBadRequest, "ignoring request since API is disabled")
+}
+
+func TestAPIController_GetLock(t *testing.T) {
+	ac, _, _ := setupLocksAPI(t)
+
+	req := lockRequest("GET", "owner/other/./default")
+	w := httptest.NewRecorder()
+	ac.GetLock(w, req)
+	Equals(t, http.StatusOK, w.Result().StatusCode)
+	var lock controllers.APILock
+	Ok(t, json.NewDecoder(w.Result().Body).Decode(&lock))
+	Equals(t, "owner/other", lock.Repo)
+	Equals(t, ".", lock.Path)
+	Equals(t, "default", lock.Workspace)
+	Equals(t, 3, lock.PullNum)
+	Equals(t, "locker", lock.LockedBy)
+
+	req = lockRequest("GET", "owner/other/./staging")
+	w = httptest.NewRecorder()
+	ac.GetLock(w, req)
+	ResponseContains(t, w, http.StatusNotFound, "no lock found at id")
+
+	req = mux.SetURLVars(httptest.NewRequest("GET", "/api/locks/!", nil), map[string]string{"id": "!"})
+	req.Header.Set(atlantisTokenHeader, atlantisToken)
+	w = httptest.NewRecorder()
+	ac.GetLock(w, req)
+	ResponseContains(t, w, http.StatusBadRequest, "invalid lock id")
+}
+
+func TestAPIController_DeleteLock(t *testing.T) {
+	ac, vcsClient, workingDir := setupLocksAPI(t)
+
+	req := lockRequest("DELETE", "owner/repo/a/default")
+	w := httptest.NewRecorder()
+	ac.DeleteLock(w, req)
+	Equals(t, http.StatusOK, w.Result().StatusCode)
+	var resp controllers.APIDeleteLocksResponse
+	Ok(t, json.NewDecoder(w.Result().Body).Decode(&resp))
+	Equals(t, 1, len(resp.Deleted))
+	Equals(t, "owner/repo/a/default", resp.Deleted[0].Key)
+
+	workingDir.VerifyWasCalledOnce().DeletePlan(Any[models.Repo](), Any[models.PullRequest](), Eq("default"), Eq("a"), Eq(""))
+	vcsClient.VerifyWasCalledOnce().CreateComment(Any[models.Repo](), Eq(1),
+		Eq("**Warning**: The plan for dir: `a` workspace: `default` was **discarded** via the Atlantis API.\n\nTo `apply` this plan you must run `plan` again."), Eq(""))
+	lock, err := ac.Locker.GetLock("owner/repo/a/default")
+	Ok(t, err)
+	Assert(t, lock == nil, "expected lock to be deleted")
+
+	w = httptest.NewRecorder()
+	ac.DeleteLock(w, lockRequest("DELETE", "owner/repo/a/default"))
+	ResponseContains(t, w, http.StatusNotFound, "no lock found at id")
+}
+
+func TestAPIController_DeleteLocks(t *testing.T) {
+	ac, vcsClient, _ := setupLocksAPI(t)
+
+	t.Log("repo is required")
+	req, _ := http.NewRequest("DELETE", "/api/locks?pull=2", nil)
+	req.Header.Set(atlantisTokenHeader, atlantisToken)
+	w := httptest.NewRecorder()
+	ac.DeleteLocks(w, req)
+	ResponseContains(t, w, http.StatusBadRequest, "repo query parameter is required")
+
+	req, _ = http.NewRequest("DELETE", "/api/locks?repo=owner/repo&pull=2", nil)
+	req.Header.Set(atlantisTokenHeader, atlantisToken)
+	w = httptest.NewRecorder()
+	ac.DeleteLocks(w, req)
+	Equals(t, http.StatusOK, w.Result().StatusCode)
+	var resp controllers.APIDeleteLocksResponse
+	Ok(t, json.NewDecoder(w.Result().Body).Decode(&resp))
+	Equals(t, 2, len(resp.Deleted))
+	vcsClient.VerifyWasCalled(Times(2)).CreateComment(Any[models.Repo](), Eq(2), Any[string](), Eq(""))
+
+	locks, err := ac.Locker.List()
+	Ok(t, err)
+	Equals(t, 2, len(locks))
+}
+
+func lockRequest(method string, key string) *http.Request {
+	id := base64.RawURLEncoding.EncodeToString([]byte(key))
+	req := httptest.NewRequest(method, "/api/locks/"+id, nil)
+	req.Header.Set(atlantisTokenHeader, atlantisToken)
+	return mux.SetURLVars(req, map[string]string{"id": id})
+}
+
+// setupLocksAPI returns an APIController backed by a real database containing
+// four locks across two repos.
+func setupLocksAPI(t *testing.T) (controllers.APIController, *MockClient, *MockWorkingDir) {
+	RegisterMockTestingT(t)
+	logger := logging.NewNoopLogger(t)
+	boltDB, err := db.New(t.TempDir())
+	Ok(t, err)
+	locker := locking.NewClient(boltDB)
+	vcsClient := NewMockClient()
+	workingDir := NewMockWorkingDir()
+
+	repo := models.Repo{FullName: "owner/repo", Owner: "owner", Name: "repo"}
+	other := models.Repo{FullName: "owner/other", Owner: "owner", Name: "other"}
+	for _, l := range []struct {
+		repo      models.Repo
+		path      string
+		workspace string
+		pullNum   int
+	}{
+		{repo, "a", "default", 1},
+		{repo, "b", "default", 2},
+		{repo, "b", "staging", 2},
+		{other, ".", "default", 3},
+	} {
+		_, err := locker.TryLock(
+			models.Project{RepoFullName: l.repo.FullName, Path: l.path},
+			l.workspace,
+			models.PullRequest{Num: l.pullNum, BaseRepo: l.repo},
+			models.User{Username: "locker"},
+		)
+		Ok(t, err)
+	}
+
+	ac := controllers.APIController{
+		APISecret: []byte(atlantisToken),
+		APITokens: auth.NewAPITokens(boltDB),
+		Backend:   boltDB,
+		DeleteLockCommand: &events.DefaultDeleteLockCommand{
+			Locker:     locker,
+			Logger:     logger,
+			WorkingDir: workingDir,
+			Backend:    boltDB,
+		},
+		Locker:    locker,
+		Logger:    logger,
+		VCSClient: vcsClient,
+	}
+	return ac, vcsClient, workingDir
+}
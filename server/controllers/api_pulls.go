@@ -0,0 +1,24 @@
+package controllers
+
+import (
+	"net/http"
+)
+
+// ListPulls is the GET /api/pulls route. It returns the status of the open
+// pull requests matching the repo and status query parameters.
+func (a *APIController) ListPulls(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	caller, code, err := a.apiAuthenticate(r)
+	if err != nil {
+		a.apiReportError(w, code, err)
+		return
+	}
+
+	canView := func(repoID string) bool { return caller.canView(a.Authorizer, repoID) }
+	pulls, err := listPulls(a.Backend, a.ProjectCmdOutputHandler, a.JobURLGenerator, parsePullFilter(r), canView)
+	if err != nil {
+		a.apiReportError(w, http.StatusInternalServerError, err)
+		return
+	}
+	a.apiRespondJSON(w, http.StatusOK, APIListPullsResponse{Pulls: pulls})
+}
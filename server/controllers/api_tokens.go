@@ -0,0 +1,151 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/runatlantis/atlantis/server/events/models"
+)
+
+// APIToken is the JSON representation of a scoped API token returned by the
+// tokens API. The token's secret is only returned when it's minted.
+type APIToken struct {
+	Name      string     `json:"name"`
+	Repos     string     `json:"repos"`
+	Commands  []string   `json:"commands"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// APICreateTokenRequest is the body of POST /api/tokens.
+type APICreateTokenRequest struct {
+	Name string `json:"name"`
+	// Repos is a regex matching the IDs of the repos the token can access.
+	// If empty, the token can access all repos.
+	Repos    string   `json:"repos"`
+	Commands []string `json:"commands"`
+	// ExpiresIn is how long the token is valid for, ex. "720h". If empty,
+	// the token never expires.
+	ExpiresIn string `json:"expires_in"`
+}
+
+// APICreateTokenResponse is the response to POST /api/tokens.
+type APICreateTokenResponse struct {
+	APIToken
+	// Token is the secret to send in the X-Atlantis-Token header. It can't be
+	// retrieved again.
+	Token string `json:"token"`
+}
+
+// APIListTokensResponse is the response to GET /api/tokens.
+type APIListTokensResponse struct {
+	Tokens []APIToken `json:"tokens"`
+}
+
+// CreateToken is the POST /api/tokens route. It mints a scoped API token.
+func (a *APIController) CreateToken(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if code, err := a.apiAuthenticateAdmin(r); err != nil {
+		a.apiReportError(w, code, err)
+		return
+	}
+
+	var req APICreateTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		a.apiReportError(w, http.StatusBadRequest, fmt.Errorf("failed to parse request: %w", err))
+		return
+	}
+	token := models.APIToken{Name: req.Name, Repos: req.Repos, Commands: req.Commands}
+	if req.ExpiresIn != "" {
+		expiresIn, err := time.ParseDuration(req.ExpiresIn)
+		if err != nil {
+			a.apiReportError(w, http.StatusBadRequest, fmt.Errorf("invalid expires_in: %w", err))
+			return
+		}
+		token.ExpiresAt = time.Now().Add(expiresIn)
+	}
+	secret, token, err := a.APITokens.Mint(token)
+	if err != nil {
+		a.apiReportError(w, http.StatusBadRequest, err)
+		return
+	}
+	a.Logger.Info("minted API token %q", token.Name)
+	a.apiRespondJSON(w, http.StatusCreated, APICreateTokenResponse{APIToken: newAPIToken(token), Token: secret})
+}
+
+// ListTokens is the GET /api/tokens route. It lists the scoped API tokens
+// sorted by name.
+func (a *APIController) ListTokens(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if code, err := a.apiAuthenticateAdmin(r); err != nil {
+		a.apiReportError(w, code, err)
+		return
+	}
+
+	tokens, err := a.APITokens.List()
+	if err != nil {
+		a.apiReportError(w, http.StatusInternalServerError, fmt.Errorf("failed listing tokens: %w", err))
+		return
+	}
+	resp := APIListTokensResponse{Tokens: []APIToken{}}
+	for _, t := range tokens {
+		resp.Tokens = append(resp.Tokens, newAPIToken(t))
+	}
+	sort.Slice(resp.Tokens, func(i, j int) bool { return resp.Tokens[i].Name < resp.Tokens[j].Name })
+	a.apiRespondJSON(w, http.StatusOK, resp)
+}
+
+// DeleteToken is the DELETE /api/tokens/{name} route. It revokes a token.
+func (a *APIController) DeleteToken(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if code, err := a.apiAuthenticateAdmin(r); err != nil {
+		a.apiReportError(w, code, err)
+		return
+	}
+
+	name := mux.Vars(r)["name"]
+	deleted, err := a.APITokens.Revoke(name)
+	if err != nil {
+		a.apiReportError(w, http.StatusInternalServerError, fmt.Errorf("failed revoking token %q: %w", name, err))
+		return
+	}
+	if deleted == nil {
+		a.apiReportError(w, http.StatusNotFound, fmt.Errorf("no token named %q", name))
+		return
+	}
+	a.Logger.Info("revoked API token %q", name)
+	a.apiRespondJSON(w, http.StatusOK, newAPIToken(*deleted))
+}
+
+// apiAuthenticateAdmin checks that the request was made with the API secret.
+// Scoped tokens can't manage tokens.
+func (a *APIController) apiAuthenticateAdmin(r *http.Request) (int, error) {
+	caller, code, err := a.apiAuthenticate(r)
+	if err != nil {
+		return code, err
+	}
+	if caller.token != nil {
+		return http.StatusForbidden, fmt.Errorf("token %q can't manage tokens, use the API secret", caller.token.Name)
+	}
+	if a.APITokens == nil {
+		return http.StatusBadRequest, fmt.Errorf("API tokens aren't enabled")
+	}
+	return http.StatusOK, nil
+}
+
+func newAPIToken(t models.APIToken) APIToken {
+	token := APIToken{
+		Name:      t.Name,
+		Repos:     t.Repos,
+		Commands:  t.Commands,
+		CreatedAt: t.CreatedAt,
+	}
+	if !t.ExpiresAt.IsZero() {
+		token.ExpiresAt = &t.ExpiresAt
+	}
+	return token
+}
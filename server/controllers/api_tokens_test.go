@@ -0,0 +1,76 @@
+package controllers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/runatlantis/atlantis/server/controllers"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestAPIController_Tokens(t *testing.T) {
+	ac, _, _ := setupLocksAPI(t)
+
+	t.Log("tokens are minted with the API secret")
+	w := httptest.NewRecorder()
+	ac.CreateToken(w, tokenRequest("POST", "/api/tokens", atlantisToken, `{"name": "ci", "repos": ".*/owner/other", "commands": ["plan"], "expires_in": "1h"}`))
+	Equals(t, http.StatusCreated, w.Result().StatusCode)
+	var created controllers.APICreateTokenResponse
+	Ok(t, json.NewDecoder(w.Result().Body).Decode(&created))
+	Equals(t, "ci", created.Name)
+	Equals(t, []string{"plan"}, created.Commands)
+	Assert(t, created.ExpiresAt != nil, "exp expires_at to be set")
+	Assert(t, created.Token != "", "exp the secret to be returned")
+
+	w = httptest.NewRecorder()
+	ac.CreateToken(w, tokenRequest("POST", "/api/tokens", atlantisToken, `{"name": "bad", "commands": ["destroy"]}`))
+	ResponseContains(t, w, http.StatusBadRequest, `invalid command \"destroy\"`)
+
+	w = httptest.NewRecorder()
+	ac.ListTokens(w, tokenRequest("GET", "/api/tokens", atlantisToken, ""))
+	var list controllers.APIListTokensResponse
+	Ok(t, json.NewDecoder(w.Result().Body).Decode(&list))
+	Equals(t, 1, len(list.Tokens))
+	Equals(t, ".*/owner/other", list.Tokens[0].Repos)
+
+	t.Log("tokens only see their repos")
+	w = httptest.NewRecorder()
+	ac.ListLocks(w, tokenRequest("GET", "/api/locks", created.Token, ""))
+	var locks controllers.APIListLocksResponse
+	Ok(t, json.NewDecoder(w.Result().Body).Decode(&locks))
+	Equals(t, 1, locks.Total)
+	Equals(t, "owner/other", locks.Locks[0].Repo)
+
+	t.Log("tokens can only run their commands")
+	req := lockRequest("DELETE", "owner/other/./default")
+	req.Header.Set(atlantisTokenHeader, created.Token)
+	w = httptest.NewRecorder()
+	ac.DeleteLock(w, req)
+	ResponseContains(t, w, http.StatusForbidden, `token \"ci\" isn't allowed to run unlock`)
+
+	t.Log("tokens can't manage tokens")
+	w = httptest.NewRecorder()
+	ac.ListTokens(w, tokenRequest("GET", "/api/tokens", created.Token, ""))
+	ResponseContains(t, w, http.StatusForbidden, "can't manage tokens")
+
+	t.Log("revoked tokens stop working")
+	w = httptest.NewRecorder()
+	ac.DeleteToken(w, mux.SetURLVars(tokenRequest("DELETE", "/api/tokens/ci", atlantisToken, ""), map[string]string{"name": "ci"}))
+	Equals(t, http.StatusOK, w.Result().StatusCode)
+	w = httptest.NewRecorder()
+	ac.ListLocks(w, tokenRequest("GET", "/api/locks", created.Token, ""))
+	ResponseContains(t, w, http.StatusUnauthorized, "did not match expected secret")
+	w = httptest.NewRecorder()
+	ac.DeleteToken(w, mux.SetURLVars(tokenRequest("DELETE", "/api/tokens/ci", atlantisToken, ""), map[string]string{"name": "ci"}))
+	ResponseContains(t, w, http.StatusNotFound, `no token named \"ci\"`)
+}
+
+func tokenRequest(method string, path string, token string, body string) *http.Request {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set(atlantisTokenHeader, token)
+	return req
+}
@@ -0,0 +1,21 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/runatlantis/atlantis/server/auth"
+	"github.com/runatlantis/atlantis/server/events/models"
+)
+
+// requestIdentity returns the identity r was authenticated as. Requests that
+// weren't authenticated get an anonymous identity with no roles.
+func requestIdentity(r *http.Request) auth.Identity {
+	id, _ := auth.IdentityFromContext(r.Context())
+	return id
+}
+
+// lockRepoID returns the ID of the repo lock is on, which roles are checked
+// against.
+func lockRepoID(lock models.ProjectLock) string {
+	return lock.Pull.BaseRepo.ID()
+}
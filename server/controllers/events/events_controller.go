@@ -91,8 +91,28 @@ type VCSEventsController struct {
 	// Azure DevOps Team Project. If empty, no request validation is done.
 	AzureDevopsWebhookBasicPassword []byte
 	AzureDevopsRequestValidator     AzureDevopsRequestValidator
+	// MergeGroupStatusUpdater sets commit statuses for GitHub merge queues.
+	MergeGroupStatusUpdater *events.MergeGroupStatusUpdater
+	// GithubReviewCommenter reacts and replies to commands in GitHub pull
+	// request review comments.
+	GithubReviewCommenter vcs.GithubReviewCommenter
 }
 
+// commentKind is the kind of comment that a command was parsed from. It
+// decides how we react and reply to the comment.
+type commentKind int
+
+const (
+	// pullComment is a regular pull request comment.
+	pullComment commentKind = iota
+	// githubReview is the body of a GitHub pull request review. GitHub
+	// doesn't support reactions on reviews so we don't react to them.
+	githubReview
+	// githubReviewComment is a comment on a GitHub pull request's diff. We
+	// react to it and reply in its thread.
+	githubReviewComment
+)
+
 // Post handles POST webhook requests.
 func (e *VCSEventsController) Post(w http.ResponseWriter, r *http.Request) {
 	if r.Header.Get(githubHeader) != "" {
@@ -177,10 +197,21 @@ func (e *VCSEventsController) handleGithubPost(w http.ResponseWriter, r *http.Re
 		resp = e.HandleGithubCommentEvent(event, githubReqID, logger)
 		scope = scope.SubScope(fmt.Sprintf("comment_%s", *event.Action))
 		scope = vcs.SetGitScopeTags(scope, event.GetRepo().GetFullName(), event.GetIssue().GetNumber())
+	case *github.PullRequestReviewEvent:
+		resp = e.HandleGithubPullRequestReviewEvent(event, githubReqID, logger)
+		scope = scope.SubScope(fmt.Sprintf("review_%s", event.GetAction()))
+		scope = vcs.SetGitScopeTags(scope, event.GetRepo().GetFullName(), event.GetPullRequest().GetNumber())
+	case *github.PullRequestReviewCommentEvent:
+		resp = e.HandleGithubPullRequestReviewCommentEvent(event, githubReqID, logger)
+		scope = scope.SubScope(fmt.Sprintf("review_comment_%s", event.GetAction()))
+		scope = vcs.SetGitScopeTags(scope, event.GetRepo().GetFullName(), event.GetPullRequest().GetNumber())
 	case *github.PullRequestEvent:
 		resp = e.HandleGithubPullRequestEvent(logger, event, githubReqID)
 		scope = scope.SubScope(fmt.Sprintf("pr_%s", *event.Action))
 		scope = vcs.SetGitScopeTags(scope, event.GetRepo().GetFullName(), event.GetNumber())
+	case *github.MergeGroupEvent:
+		resp = e.HandleGithubMergeGroupEvent(logger, event, githubReqID)
+		scope = scope.SubScope(fmt.Sprintf("merge_group_%s", event.GetAction()))
 	default:
 		resp = HTTPResponse{
 			body: fmt.Sprintf("Ignoring unsupported event %s", githubReqID),
@@ -315,7 +346,61 @@ func (e *VCSEventsController) HandleGithubCommentEvent(event *github.IssueCommen
 
 	// We pass in nil for maybeHeadRepo because the head repo data isn't
 	// available in the GithubIssueComment event.
-	return e.handleCommentEvent(logger, baseRepo, nil, nil, user, pullNum, comment.GetBody(), comment.GetID(), models.Github)
+	return e.handleCommentEvent(logger, baseRepo, nil, nil, user, pullNum, comment.GetBody(), comment.GetID(), models.Github, pullComment)
+}
+
+// HandleGithubPullRequestReviewEvent handles pull request review events from
+// GitHub. Atlantis commands can come from the review's body. It's exported to
+// make testing easier.
+func (e *VCSEventsController) HandleGithubPullRequestReviewEvent(event *github.PullRequestReviewEvent, githubReqID string, logger logging.SimpleLogging) HTTPResponse {
+	if event.GetAction() != "submitted" {
+		return HTTPResponse{
+			body: fmt.Sprintf("Ignoring pull request review event since action was not submitted %s", githubReqID),
+		}
+	}
+
+	baseRepo, user, pullNum, err := e.Parser.ParseGithubPullRequestReviewEvent(event)
+	if err != nil {
+		wrapped := errors.Wrapf(err, "Failed parsing event: %s", githubReqID)
+		return HTTPResponse{
+			body: wrapped.Error(),
+			err: HTTPError{
+				code:       http.StatusBadRequest,
+				err:        wrapped,
+				isSilenced: false,
+			},
+		}
+	}
+
+	review := event.GetReview()
+	return e.handleCommentEvent(logger, baseRepo, nil, nil, user, pullNum, review.GetBody(), review.GetID(), models.Github, githubReview)
+}
+
+// HandleGithubPullRequestReviewCommentEvent handles pull request review
+// comment events from GitHub where Atlantis commands can come from. It's
+// exported to make testing easier.
+func (e *VCSEventsController) HandleGithubPullRequestReviewCommentEvent(event *github.PullRequestReviewCommentEvent, githubReqID string, logger logging.SimpleLogging) HTTPResponse {
+	if event.GetAction() != "created" {
+		return HTTPResponse{
+			body: fmt.Sprintf("Ignoring pull request review comment event since action was not created %s", githubReqID),
+		}
+	}
+
+	baseRepo, user, pullNum, err := e.Parser.ParseGithubPullRequestReviewCommentEvent(event)
+	if err != nil {
+		wrapped := errors.Wrapf(err, "Failed parsing event: %s", githubReqID)
+		return HTTPResponse{
+			body: wrapped.Error(),
+			err: HTTPError{
+				code:       http.StatusBadRequest,
+				err:        wrapped,
+				isSilenced: false,
+			},
+		}
+	}
+
+	comment := event.GetComment()
+	return e.handleCommentEvent(logger, baseRepo, nil, nil, user, pullNum, comment.GetBody(), comment.GetID(), models.Github, githubReviewComment)
 }
 
 // HandleBitbucketCloudCommentEvent handles comment events from Bitbucket.
@@ -325,7 +410,7 @@ func (e *VCSEventsController) HandleBitbucketCloudCommentEvent(w http.ResponseWr
 		e.respond(w, logging.Error, http.StatusBadRequest, "Error parsing pull data: %s %s=%s", err, bitbucketCloudRequestIDHeader, reqID)
 		return
 	}
-	resp := e.handleCommentEvent(e.Logger, baseRepo, &headRepo, &pull, user, pull.Num, comment, -1, models.BitbucketCloud)
+	resp := e.handleCommentEvent(e.Logger, baseRepo, &headRepo, &pull, user, pull.Num, comment, -1, models.BitbucketCloud, pullComment)
 
 	//TODO: move this to the outer most function similar to github
 	lvl := logging.Debug
@@ -346,7 +431,7 @@ func (e *VCSEventsController) HandleBitbucketServerCommentEvent(w http.ResponseW
 		e.respond(w, logging.Error, http.StatusBadRequest, "Error parsing pull data: %s %s=%s", err, bitbucketCloudRequestIDHeader, reqID)
 		return
 	}
-	resp := e.handleCommentEvent(e.Logger, baseRepo, &headRepo, &pull, user, pull.Num, comment, -1, models.BitbucketCloud)
+	resp := e.handleCommentEvent(e.Logger, baseRepo, &headRepo, &pull, user, pull.Num, comment, -1, models.BitbucketCloud, pullComment)
 
 	//TODO: move this to the outer most function similar to github
 	lvl := logging.Debug
@@ -425,6 +510,57 @@ func (e *VCSEventsController) HandleGithubPullRequestEvent(logger logging.Simple
 	return e.handlePullRequestEvent(logger, baseRepo, headRepo, pull, user, pullEventType)
 }
 
+// HandleGithubMergeGroupEvent reports the statuses of the pull request a
+// GitHub merge queue group was created for on the group's commit so that it
+// can be merged. It's exported to make testing easier.
+func (e *VCSEventsController) HandleGithubMergeGroupEvent(logger logging.SimpleLogging, event *github.MergeGroupEvent, githubReqID string) HTTPResponse {
+	if event.GetAction() != "checks_requested" {
+		return HTTPResponse{
+			body: fmt.Sprintf("Ignoring merge group event since action was not checks_requested %s", githubReqID),
+		}
+	}
+
+	baseRepo, pullNum, baseSHA, headSHA, err := e.Parser.ParseGithubMergeGroupEvent(event)
+	if err != nil {
+		wrapped := errors.Wrapf(err, "Error parsing merge group data: %s", githubReqID)
+		return HTTPResponse{
+			body: wrapped.Error(),
+			err: HTTPError{
+				code:       http.StatusBadRequest,
+				err:        wrapped,
+				isSilenced: false,
+			},
+		}
+	}
+	if !e.RepoAllowlistChecker.IsAllowlisted(baseRepo.FullName, baseRepo.VCSHost.Hostname) {
+		err := errors.Errorf("Merge group event from non-allowlisted repo \"%s/%s\"", baseRepo.VCSHost.Hostname, baseRepo.FullName)
+		return HTTPResponse{
+			body: err.Error(),
+			err: HTTPError{
+				code:       http.StatusForbidden,
+				err:        err,
+				isSilenced: true,
+			},
+		}
+	}
+
+	logger.Info("updating statuses of merge group %s for pull request %d", headSHA, pullNum)
+	if err := e.MergeGroupStatusUpdater.UpdateStatuses(logger, baseRepo, pullNum, baseSHA, headSHA); err != nil {
+		wrapped := errors.Wrapf(err, "Error updating merge group statuses: %s", githubReqID)
+		return HTTPResponse{
+			body: wrapped.Error(),
+			err: HTTPError{
+				code:       http.StatusInternalServerError,
+				err:        wrapped,
+				isSilenced: false,
+			},
+		}
+	}
+	return HTTPResponse{
+		body: "Merge group statuses updated successfully",
+	}
+}
+
 func (e *VCSEventsController) handlePullRequestEvent(logger logging.SimpleLogging, baseRepo models.Repo, headRepo models.Repo, pull models.PullRequest, user models.User, eventType models.PullRequestEventType) HTTPResponse {
 	if !e.RepoAllowlistChecker.IsAllowlisted(baseRepo.FullName, baseRepo.VCSHost.Hostname) {
 		// If the repo isn't allowlisted and we receive an opened pull request
@@ -479,6 +615,19 @@ func (e *VCSEventsController) handlePullRequestEvent(logger logging.SimpleLoggin
 		return HTTPResponse{
 			body: "Pull request cleaned successfully",
 		}
+	case models.MergedPullEvent:
+		// If the pull request was merged, we apply any projects that are
+		// configured to be applied after merge and then delete locks as we
+		// would for a closed pull request.
+		if !e.TestingMode {
+			go e.applyAndCleanUpMergedPull(logger, baseRepo, headRepo, pull, user)
+		} else {
+			// When testing we want to wait for everything to complete.
+			e.applyAndCleanUpMergedPull(logger, baseRepo, headRepo, pull, user)
+		}
+		return HTTPResponse{
+			body: "Processing...",
+		}
 	case models.OtherPullEvent:
 		// Else we ignore the event.
 		return HTTPResponse{
@@ -488,6 +637,18 @@ func (e *VCSEventsController) handlePullRequestEvent(logger logging.SimpleLoggin
 	return HTTPResponse{}
 }
 
+// applyAndCleanUpMergedPull runs the post-merge apply for pull and then cleans
+// it up. The apply must happen first since it relies on the locks and plan
+// statuses that the clean up deletes.
+func (e *VCSEventsController) applyAndCleanUpMergedPull(logger logging.SimpleLogging, baseRepo models.Repo, headRepo models.Repo, pull models.PullRequest, user models.User) {
+	e.CommandRunner.RunPostMergeCommand(baseRepo, headRepo, pull, user)
+	if err := e.PullCleaner.CleanUpPull(baseRepo, pull); err != nil {
+		logger.Err("unable to clean up merged pull request %d in repo %s: %s", pull.Num, baseRepo.FullName, err)
+		return
+	}
+	logger.Info("deleted locks and workspace for repo %s, pull %d", baseRepo.FullName, pull.Num)
+}
+
 func (e *VCSEventsController) handleGitlabPost(w http.ResponseWriter, r *http.Request) {
 	event, err := e.GitlabRequestParserValidator.ParseAndValidate(r, e.GitlabWebhookSecret)
 	if err != nil {
@@ -521,7 +682,7 @@ func (e *VCSEventsController) HandleGitlabCommentEvent(w http.ResponseWriter, ev
 		e.respond(w, logging.Error, http.StatusBadRequest, "Error parsing webhook: %s", err)
 		return
 	}
-	resp := e.handleCommentEvent(e.Logger, baseRepo, &headRepo, nil, user, event.MergeRequest.IID, event.ObjectAttributes.Note, int64(commentID), models.Gitlab)
+	resp := e.handleCommentEvent(e.Logger, baseRepo, &headRepo, nil, user, event.MergeRequest.IID, event.ObjectAttributes.Note, int64(commentID), models.Gitlab, pullComment)
 
 	//TODO: move this to the outer most function similar to github
 	lvl := logging.Debug
@@ -535,7 +696,7 @@ func (e *VCSEventsController) HandleGitlabCommentEvent(w http.ResponseWriter, ev
 	e.respond(w, lvl, code, msg)
 }
 
-func (e *VCSEventsController) handleCommentEvent(logger logging.SimpleLogging, baseRepo models.Repo, maybeHeadRepo *models.Repo, maybePull *models.PullRequest, user models.User, pullNum int, comment string, commentID int64, vcsHost models.VCSHostType) HTTPResponse {
+func (e *VCSEventsController) handleCommentEvent(logger logging.SimpleLogging, baseRepo models.Repo, maybeHeadRepo *models.Repo, maybePull *models.PullRequest, user models.User, pullNum int, comment string, commentID int64, vcsHost models.VCSHostType, kind commentKind) HTTPResponse {
 	parseResult := e.CommentParser.Parse(comment, vcsHost)
 	if parseResult.Ignore {
 		truncated := comment
@@ -568,7 +729,7 @@ func (e *VCSEventsController) handleCommentEvent(logger logging.SimpleLogging, b
 
 	// It's a comment we're gonna react to, so add a reaction.
 	if e.EmojiReaction != "" {
-		err := e.VCSClient.ReactToComment(baseRepo, pullNum, commentID, e.EmojiReaction)
+		err := e.reactToComment(baseRepo, pullNum, commentID, kind)
 		if err != nil {
 			logger.Warn("Failed to react to comment: %s", err)
 		}
@@ -579,7 +740,7 @@ func (e *VCSEventsController) handleCommentEvent(logger logging.SimpleLogging, b
 	// We do this here rather than earlier because we need access to the pull
 	// variable to comment back on the pull request.
 	if parseResult.CommentResponse != "" {
-		if err := e.VCSClient.CreateComment(baseRepo, pullNum, parseResult.CommentResponse, ""); err != nil {
+		if err := e.replyToComment(baseRepo, pullNum, commentID, kind, parseResult.CommentResponse); err != nil {
 			logger.Err("unable to comment on pull request: %s", err)
 		}
 		return HTTPResponse{
@@ -608,6 +769,29 @@ func (e *VCSEventsController) handleCommentEvent(logger logging.SimpleLogging, b
 	}
 }
 
+// reactToComment adds e.EmojiReaction to the comment that a command was
+// parsed from.
+func (e *VCSEventsController) reactToComment(baseRepo models.Repo, pullNum int, commentID int64, kind commentKind) error {
+	switch kind {
+	case githubReview:
+		return nil
+	case githubReviewComment:
+		return e.GithubReviewCommenter.ReactToReviewComment(baseRepo, commentID, e.EmojiReaction)
+	default:
+		return e.VCSClient.ReactToComment(baseRepo, pullNum, commentID, e.EmojiReaction)
+	}
+}
+
+// replyToComment responds to the comment that a command was parsed from.
+// Replies to review comments go in the review comment's thread, everything
+// else is commented on the pull request.
+func (e *VCSEventsController) replyToComment(baseRepo models.Repo, pullNum int, commentID int64, kind commentKind, reply string) error {
+	if kind == githubReviewComment {
+		return e.GithubReviewCommenter.ReplyToReviewComment(baseRepo, pullNum, commentID, reply)
+	}
+	return e.VCSClient.CreateComment(baseRepo, pullNum, reply, "")
+}
+
 // HandleGitlabMergeRequestEvent will delete any locks associated with the pull
 // request if the event is a merge request closed event. It's exported to make
 // testing easier.
@@ -672,7 +856,7 @@ func (e *VCSEventsController) HandleAzureDevopsPullRequestCommentedEvent(w http.
 		e.respond(w, logging.Error, http.StatusBadRequest, "Error parsing pull request repository field: %s; %s", err, azuredevopsReqID)
 		return
 	}
-	resp := e.handleCommentEvent(e.Logger, baseRepo, nil, nil, user, resource.PullRequest.GetPullRequestID(), string(strippedComment), -1, models.AzureDevops)
+	resp := e.handleCommentEvent(e.Logger, baseRepo, nil, nil, user, resource.PullRequest.GetPullRequestID(), string(strippedComment), -1, models.AzureDevops, pullComment)
 
 	//TODO: move this to the outer most function similar to github
 	lvl := logging.Debug
@@ -12,6 +12,7 @@ import (
 	"regexp"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/go-github/v57/github"
 	"github.com/hashicorp/go-version"
@@ -714,8 +715,9 @@ func TestGitHubWorkflow(t *testing.T) {
 			}
 
 			if c.ExpAutomerge {
-				// Verify that the merge API call was made.
-				vcsClient.VerifyWasCalledOnce().MergePull(Any[models.PullRequest](), Any[models.PullRequestOptions]())
+				// Verify that the merge API call was made. Automerge runs in the
+				// background so it may not have happened yet.
+				vcsClient.VerifyWasCalledEventually(Once(), 10*time.Second).MergePull(Any[models.PullRequest](), Any[models.PullRequestOptions]())
 			} else {
 				vcsClient.VerifyWasCalled(Never()).MergePull(Any[models.PullRequest](), Any[models.PullRequestOptions]())
 			}
@@ -1252,8 +1254,9 @@ func TestGitHubWorkflowWithPolicyCheck(t *testing.T) {
 			}
 
 			if c.ExpAutomerge {
-				// Verify that the merge API call was made.
-				vcsClient.VerifyWasCalledOnce().MergePull(Any[models.PullRequest](), Any[models.PullRequestOptions]())
+				// Verify that the merge API call was made. Automerge runs in the
+				// background so it may not have happened yet.
+				vcsClient.VerifyWasCalledEventually(Once(), 10*time.Second).MergePull(Any[models.PullRequest](), Any[models.PullRequestOptions]())
 			} else {
 				vcsClient.VerifyWasCalled(Never()).MergePull(Any[models.PullRequest](), Any[models.PullRequestOptions]())
 			}
@@ -1423,7 +1426,7 @@ func setupE2E(t *testing.T, repoDir string, opt setupOption) (events_controllers
 
 	Ok(t, err)
 
-	conftextExec := policy.NewConfTestExecutorWorkflow(logger, binDir, &NoopTFDownloader{})
+	conftextExec := policy.NewConfTestExecutorWorkflow(logger, binDir, &NoopTFDownloader{}, valid.EnvPolicy{})
 
 	// swapping out version cache to something that always returns local conftest
 	// binary
@@ -1551,6 +1554,7 @@ func setupE2E(t *testing.T, repoDir string, opt setupOption) (events_controllers
 		e2eVCSClient,
 		silenceNoProjects,
 		disableUnlockLabel,
+		nil,
 	)
 
 	versionCommandRunner := events.NewVersionCommandRunner(
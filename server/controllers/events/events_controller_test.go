@@ -30,6 +30,7 @@ import (
 	. "github.com/petergtz/pegomock/v4"
 	events_controllers "github.com/runatlantis/atlantis/server/controllers/events"
 	"github.com/runatlantis/atlantis/server/controllers/events/mocks"
+	"github.com/runatlantis/atlantis/server/core/db"
 	"github.com/runatlantis/atlantis/server/events"
 	"github.com/runatlantis/atlantis/server/events/command"
 	emocks "github.com/runatlantis/atlantis/server/events/mocks"
@@ -400,6 +401,84 @@ func TestPost_GithubCommentReaction(t *testing.T) {
 	vcsClient.VerifyWasCalledOnce().ReactToComment(baseRepo, 1, 1, "eyes")
 }
 
+func TestPost_GithubPullRequestReview(t *testing.T) {
+	t.Log("when the event is a submitted github review with a valid command we call the command handler")
+	e, v, _, _, p, cr, _, vcsClient, cp := setup(t)
+	req, _ := http.NewRequest("GET", "", bytes.NewBuffer(nil))
+	req.Header.Set(githubHeader, "pull_request_review")
+	testComment := "atlantis apply"
+	event := fmt.Sprintf(`{"action": "submitted", "review": {"body": "%v", "id": 1}}`, testComment)
+	When(v.Validate(req, secret)).ThenReturn([]byte(event), nil)
+	baseRepo := models.Repo{}
+	user := models.User{Username: "reviewer"}
+	cmd := events.CommentCommand{Name: command.Apply}
+	When(p.ParseGithubPullRequestReviewEvent(Any[*github.PullRequestReviewEvent]())).ThenReturn(baseRepo, user, 1, nil)
+	When(cp.Parse(testComment, models.Github)).ThenReturn(events.CommentParseResult{Command: &cmd})
+	w := httptest.NewRecorder()
+	e.Post(w, req)
+	ResponseContains(t, w, http.StatusOK, "Processing...")
+
+	cr.VerifyWasCalledOnce().RunCommentCommand(baseRepo, nil, nil, user, 1, &cmd)
+	// GitHub doesn't support reactions on reviews.
+	vcsClient.VerifyWasCalled(Never()).ReactToComment(Any[models.Repo](), Any[int](), Any[int64](), Any[string]())
+}
+
+func TestPost_GithubPullRequestReviewNotSubmitted(t *testing.T) {
+	e, v, _, _, p, _, _, _, _ := setup(t)
+	req, _ := http.NewRequest("GET", "", bytes.NewBuffer(nil))
+	req.Header.Set(githubHeader, "pull_request_review")
+	event := `{"action": "edited", "review": {"body": "atlantis apply"}}`
+	When(v.Validate(req, secret)).ThenReturn([]byte(event), nil)
+	w := httptest.NewRecorder()
+	e.Post(w, req)
+	ResponseContains(t, w, http.StatusOK, "Ignoring pull request review event since action was not submitted")
+	p.VerifyWasCalled(Never()).ParseGithubPullRequestReviewEvent(Any[*github.PullRequestReviewEvent]())
+}
+
+func TestPost_GithubPullRequestReviewComment(t *testing.T) {
+	t.Log("when the event is a github review comment with a valid command we react to it and call the command handler")
+	e, v, _, _, p, cr, _, vcsClient, cp := setup(t)
+	reviewCommenter := vcsmocks.NewMockGithubReviewCommenter()
+	e.GithubReviewCommenter = reviewCommenter
+	req, _ := http.NewRequest("GET", "", bytes.NewBuffer(nil))
+	req.Header.Set(githubHeader, "pull_request_review_comment")
+	testComment := "atlantis plan"
+	event := fmt.Sprintf(`{"action": "created", "comment": {"body": "%v", "id": 2}}`, testComment)
+	When(v.Validate(req, secret)).ThenReturn([]byte(event), nil)
+	baseRepo := models.Repo{}
+	user := models.User{}
+	cmd := events.CommentCommand{Name: command.Plan}
+	When(p.ParseGithubPullRequestReviewCommentEvent(Any[*github.PullRequestReviewCommentEvent]())).ThenReturn(baseRepo, user, 1, nil)
+	When(cp.Parse(testComment, models.Github)).ThenReturn(events.CommentParseResult{Command: &cmd})
+	w := httptest.NewRecorder()
+	e.Post(w, req)
+	ResponseContains(t, w, http.StatusOK, "Processing...")
+
+	cr.VerifyWasCalledOnce().RunCommentCommand(baseRepo, nil, nil, user, 1, &cmd)
+	reviewCommenter.VerifyWasCalledOnce().ReactToReviewComment(baseRepo, int64(2), "eyes")
+	vcsClient.VerifyWasCalled(Never()).ReactToComment(Any[models.Repo](), Any[int](), Any[int64](), Any[string]())
+}
+
+func TestPost_GithubPullRequestReviewCommentResponse(t *testing.T) {
+	t.Log("when a github review comment warrants a comment response we reply in its thread")
+	e, v, _, _, p, _, _, vcsClient, cp := setup(t)
+	reviewCommenter := vcsmocks.NewMockGithubReviewCommenter()
+	e.GithubReviewCommenter = reviewCommenter
+	req, _ := http.NewRequest("GET", "", bytes.NewBuffer(nil))
+	req.Header.Set(githubHeader, "pull_request_review_comment")
+	event := `{"action": "created", "comment": {"body": "atlantis help", "id": 2}}`
+	When(v.Validate(req, secret)).ThenReturn([]byte(event), nil)
+	baseRepo := models.Repo{}
+	When(p.ParseGithubPullRequestReviewCommentEvent(Any[*github.PullRequestReviewCommentEvent]())).ThenReturn(baseRepo, models.User{}, 1, nil)
+	When(cp.Parse("atlantis help", models.Github)).ThenReturn(events.CommentParseResult{CommentResponse: "a comment"})
+	w := httptest.NewRecorder()
+	e.Post(w, req)
+	ResponseContains(t, w, http.StatusOK, "Commenting back on pull request")
+
+	reviewCommenter.VerifyWasCalledOnce().ReplyToReviewComment(baseRepo, 1, int64(2), "a comment")
+	vcsClient.VerifyWasCalled(Never()).CreateComment(Any[models.Repo](), Any[int](), Any[string](), Any[string]())
+}
+
 func TestPost_GilabCommentReaction(t *testing.T) {
 	t.Log("when the event is a gitlab comment with a valid command we call the ReactToComment handler")
 	e, _, gl, _, _, _, _, vcsClient, cp := setup(t)
@@ -803,16 +882,20 @@ func TestPost_GitlabMergeRequestSuccess(t *testing.T) {
 // Test Bitbucket server pull closed events.
 func TestPost_BBServerPullClosed(t *testing.T) {
 	cases := []struct {
-		header string
+		header  string
+		expBody string
 	}{
 		{
 			"pr:deleted",
+			"Pull request cleaned successfully",
 		},
 		{
 			"pr:merged",
+			"Processing...",
 		},
 		{
 			"pr:declined",
+			"Pull request cleaned successfully",
 		},
 	}
 
@@ -820,12 +903,15 @@ func TestPost_BBServerPullClosed(t *testing.T) {
 		t.Run(c.header, func(t *testing.T) {
 			RegisterMockTestingT(t)
 			pullCleaner := emocks.NewMockPullCleaner()
+			cmdRunner := emocks.NewMockCommandRunner()
 			allowlist, err := events.NewRepoAllowlistChecker("*")
 			Ok(t, err)
 			logger := logging.NewNoopLogger(t)
 			scope, _, _ := metrics.NewLoggingScope(logger, "null")
 			ec := &events_controllers.VCSEventsController{
-				PullCleaner: pullCleaner,
+				TestingMode:   true,
+				PullCleaner:   pullCleaner,
+				CommandRunner: cmdRunner,
 				Parser: &events.EventParser{
 					BitbucketUser:      "bb-user",
 					BitbucketToken:     "bb-token",
@@ -854,7 +940,7 @@ func TestPost_BBServerPullClosed(t *testing.T) {
 			ec.Post(w, req)
 
 			// Make our assertions.
-			ResponseContains(t, w, 200, "Pull request cleaned successfully")
+			ResponseContains(t, w, 200, c.expBody)
 
 			expRepo := models.Repo{
 				FullName:          "project/repository",
@@ -877,6 +963,11 @@ func TestPost_BBServerPullClosed(t *testing.T) {
 				State:      models.OpenPullState,
 				BaseRepo:   expRepo,
 			})
+			if c.header == "pr:merged" {
+				cmdRunner.VerifyWasCalledOnce().RunPostMergeCommand(Any[models.Repo](), Any[models.Repo](), Any[models.PullRequest](), Any[models.User]())
+			} else {
+				cmdRunner.VerifyWasCalled(Never()).RunPostMergeCommand(Any[models.Repo](), Any[models.Repo](), Any[models.PullRequest](), Any[models.User]())
+			}
 		})
 	}
 }
@@ -942,6 +1033,57 @@ func TestPost_PullOpenedOrUpdated(t *testing.T) {
 	}
 }
 
+func TestPost_GithubMergeGroup(t *testing.T) {
+	cases := map[string]struct {
+		action     string
+		expCode    int
+		expBody    string
+		expUpdated bool
+	}{
+		"checks requested": {
+			action:     "checks_requested",
+			expCode:    http.StatusOK,
+			expBody:    "Merge group statuses updated successfully",
+			expUpdated: true,
+		},
+		"destroyed": {
+			action:  "destroyed",
+			expCode: http.StatusOK,
+			expBody: "Ignoring merge group event since action was not checks_requested",
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			e, v, _, _, p, _, _, _, _ := setup(t)
+			boltDB, err := db.New(t.TempDir())
+			Ok(t, err)
+			statusUpdater := emocks.NewMockCommitStatusUpdater()
+			e.MergeGroupStatusUpdater = &events.MergeGroupStatusUpdater{
+				Backend:             boltDB,
+				CommitStatusUpdater: statusUpdater,
+			}
+			req, _ := http.NewRequest("GET", "", bytes.NewBuffer(nil))
+			req.Header.Set(githubHeader, "merge_group")
+			event := fmt.Sprintf(`{"action": %q}`, c.action)
+			When(v.Validate(req, secret)).ThenReturn([]byte(event), nil)
+			repo := models.Repo{FullName: "owner/repo"}
+			When(p.ParseGithubMergeGroupEvent(Any[*github.MergeGroupEvent]())).ThenReturn(repo, 1, "basesha", "headsha", nil)
+
+			w := httptest.NewRecorder()
+			e.Post(w, req)
+			ResponseContains(t, w, c.expCode, c.expBody)
+
+			expPull := models.PullRequest{BaseRepo: repo, Num: 1, HeadCommit: "headsha"}
+			if c.expUpdated {
+				statusUpdater.VerifyWasCalledOnce().UpdateCombinedCount(repo, expPull, models.SuccessCommitStatus, command.Plan, 0, 0)
+				statusUpdater.VerifyWasCalledOnce().UpdateCombinedCount(repo, expPull, models.SuccessCommitStatus, command.Apply, 0, 0)
+			} else {
+				p.VerifyWasCalled(Never()).ParseGithubMergeGroupEvent(Any[*github.MergeGroupEvent]())
+			}
+		})
+	}
+}
+
 func setup(t *testing.T) (events_controllers.VCSEventsController, *mocks.MockGithubRequestValidator, *mocks.MockGitlabRequestParserValidator, *mocks.MockAzureDevopsRequestValidator, *emocks.MockEventParsing, *emocks.MockCommandRunner, *emocks.MockPullCleaner, *vcsmocks.MockClient, *emocks.MockCommentParsing) {
 	RegisterMockTestingT(t)
 	v := mocks.NewMockGithubRequestValidator()
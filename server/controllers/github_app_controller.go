@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/url"
 
+	"github.com/runatlantis/atlantis/server/auth"
 	"github.com/runatlantis/atlantis/server/controllers/templates"
 	"github.com/runatlantis/atlantis/server/events/vcs"
 	"github.com/runatlantis/atlantis/server/logging"
@@ -18,6 +19,7 @@ type GithubAppController struct {
 	GithubSetupComplete bool
 	GithubHostname      string
 	GithubOrg           string
+	Authorizer          *auth.Authorizer
 }
 
 type githubWebhook struct {
@@ -42,6 +44,10 @@ type githubAppRequest struct {
 // A code query parameter is exchanged for this app's ID, key, and webhook_secret
 // Implements https://developer.github.com/apps/building-github-apps/creating-github-apps-from-a-manifest/#implementing-the-github-app-manifest-flow
 func (g *GithubAppController) ExchangeCode(w http.ResponseWriter, r *http.Request) {
+	if err := g.Authorizer.AuthorizeGlobal(requestIdentity(r), auth.AdminRole); err != nil {
+		g.respond(w, logging.Warn, http.StatusForbidden, "Forbidden: %s", err)
+		return
+	}
 
 	if g.GithubSetupComplete {
 		g.respond(w, logging.Error, http.StatusBadRequest, "Atlantis already has GitHub credentials")
@@ -85,7 +91,11 @@ func (g *GithubAppController) ExchangeCode(w http.ResponseWriter, r *http.Reques
 }
 
 // New redirects the user to create a new GitHub app
-func (g *GithubAppController) New(w http.ResponseWriter, _ *http.Request) {
+func (g *GithubAppController) New(w http.ResponseWriter, r *http.Request) {
+	if err := g.Authorizer.AuthorizeGlobal(requestIdentity(r), auth.AdminRole); err != nil {
+		g.respond(w, logging.Warn, http.StatusForbidden, "Forbidden: %s", err)
+		return
+	}
 
 	if g.GithubSetupComplete {
 		g.respond(w, logging.Error, http.StatusBadRequest, "Atlantis already has GitHub credentials")
@@ -6,9 +6,11 @@ import (
 	"net/url"
 
 	"github.com/gorilla/mux"
+	"github.com/runatlantis/atlantis/server/auth"
 	"github.com/runatlantis/atlantis/server/controllers/templates"
 	"github.com/runatlantis/atlantis/server/controllers/websocket"
 	"github.com/runatlantis/atlantis/server/core/locking"
+	"github.com/runatlantis/atlantis/server/jobs"
 	"github.com/runatlantis/atlantis/server/logging"
 	"github.com/runatlantis/atlantis/server/metrics"
 	tally "github.com/uber-go/tally/v4"
@@ -35,6 +37,21 @@ type JobsController struct {
 	WsMux                    *websocket.Multiplexor
 	KeyGenerator             JobIDKeyGenerator
 	StatsScope               tally.Scope
+	// OutputHandler is used to look up the repo of each job.
+	OutputHandler jobs.ProjectCommandOutputHandler
+	// Authorizer checks that users can view the repo of the job.
+	Authorizer *auth.Authorizer
+}
+
+// authorize checks that the user can view the job with id jobID. Jobs whose
+// repo isn't known yet, ex. because they haven't output anything, require
+// the viewer role on all repos.
+func (j *JobsController) authorize(r *http.Request, jobID string) error {
+	id := requestIdentity(r)
+	if repoID, ok := j.OutputHandler.JobRepoID(jobID); ok {
+		return j.Authorizer.AuthorizeRepo(id, repoID, auth.ViewerRole)
+	}
+	return j.Authorizer.AuthorizeGlobal(id, auth.ViewerRole)
 }
 
 func (j *JobsController) getProjectJobs(w http.ResponseWriter, r *http.Request) error {
@@ -44,6 +61,10 @@ func (j *JobsController) getProjectJobs(w http.ResponseWriter, r *http.Request)
 		j.respond(w, logging.Error, http.StatusBadRequest, err.Error())
 		return err
 	}
+	if err := j.authorize(r, jobID); err != nil {
+		j.respond(w, logging.Warn, http.StatusForbidden, "Forbidden: %s", err)
+		return nil
+	}
 
 	viewData := templates.ProjectJobData{
 		AtlantisVersion: j.AtlantisVersion,
@@ -64,7 +85,16 @@ func (j *JobsController) GetProjectJobs(w http.ResponseWriter, r *http.Request)
 }
 
 func (j *JobsController) getProjectJobsWS(w http.ResponseWriter, r *http.Request) error {
-	err := j.WsMux.Handle(w, r)
+	jobID, err := j.KeyGenerator.Generate(r)
+	if err != nil {
+		j.respond(w, logging.Error, http.StatusBadRequest, err.Error())
+		return err
+	}
+	if err := j.authorize(r, jobID); err != nil {
+		j.respond(w, logging.Warn, http.StatusForbidden, "Forbidden: %s", err)
+		return nil
+	}
+	err = j.WsMux.Handle(w, r)
 
 	if err != nil {
 		j.respond(w, logging.Error, http.StatusInternalServerError, err.Error())
@@ -0,0 +1,56 @@
+package controllers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gorilla/mux"
+	. "github.com/petergtz/pegomock/v4"
+	"github.com/runatlantis/atlantis/server/auth"
+	"github.com/runatlantis/atlantis/server/controllers"
+	tMocks "github.com/runatlantis/atlantis/server/controllers/templates/mocks"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/jobs/mocks"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+	tally "github.com/uber-go/tally/v4"
+)
+
+func TestJobsController_Authorization(t *testing.T) {
+	RegisterMockTestingT(t)
+	outputHandler := mocks.NewMockProjectCommandOutputHandler()
+	When(outputHandler.JobRepoID("repo-job")).ThenReturn("github.com/owner/repo", true)
+	When(outputHandler.JobRepoID("other-job")).ThenReturn("github.com/owner/other", true)
+	When(outputHandler.JobRepoID("unknown-job")).ThenReturn("", false)
+	atlantisURL, _ := url.Parse("https://atlantis.example.com")
+	c := &controllers.JobsController{
+		AtlantisURL:         atlantisURL,
+		Logger:              logging.NewNoopLogger(t),
+		ProjectJobsTemplate: tMocks.NewMockTemplateWriter(),
+		KeyGenerator:        controllers.JobIDKeyGenerator{},
+		StatsScope:          tally.NoopScope,
+		OutputHandler:       outputHandler,
+		Authorizer: auth.NewAuthorizer([]valid.RoleBinding{
+			{ID: "github.com/owner/repo", Role: "viewer", Users: []string{"viewer"}},
+			{Role: "viewer", Users: []string{"admin"}},
+		}, false),
+	}
+	get := func(user string, jobID string) int {
+		req, _ := http.NewRequest("GET", "/jobs/"+jobID, nil)
+		req = mux.SetURLVars(req, map[string]string{"job-id": jobID})
+		req = req.WithContext(auth.WithIdentity(req.Context(), auth.Identity{Username: user}))
+		w := httptest.NewRecorder()
+		c.GetProjectJobs(w, req)
+		return w.Code
+	}
+
+	t.Log("viewers can only view jobs of their repos")
+	Equals(t, http.StatusOK, get("viewer", "repo-job"))
+	Equals(t, http.StatusForbidden, get("viewer", "other-job"))
+
+	t.Log("jobs whose repo isn't known need the viewer role on all repos")
+	Equals(t, http.StatusForbidden, get("viewer", "unknown-job"))
+	Equals(t, http.StatusOK, get("admin", "unknown-job"))
+}
@@ -8,8 +8,11 @@ import (
 	"github.com/runatlantis/atlantis/server/controllers/templates"
 
 	"github.com/gorilla/mux"
+	"github.com/runatlantis/atlantis/server/audit"
+	"github.com/runatlantis/atlantis/server/auth"
 	"github.com/runatlantis/atlantis/server/core/locking"
 	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/command"
 	"github.com/runatlantis/atlantis/server/events/models"
 	"github.com/runatlantis/atlantis/server/events/vcs"
 	"github.com/runatlantis/atlantis/server/logging"
@@ -28,12 +31,24 @@ type LocksController struct {
 	WorkingDirLocker   events.WorkingDirLocker
 	Backend            locking.Backend
 	DeleteLockCommand  events.DeleteLockCommand
+	Authorizer         *auth.Authorizer
+	Auditor            *audit.Auditor
 }
 
 // LockApply handles creating a global apply lock.
 // If Lock already exists it will be a no-op
-func (l *LocksController) LockApply(w http.ResponseWriter, _ *http.Request) {
+func (l *LocksController) LockApply(w http.ResponseWriter, r *http.Request) {
+	if err := l.Authorizer.AuthorizeGlobal(requestIdentity(r), auth.AdminRole); err != nil {
+		l.respond(w, logging.Warn, http.StatusForbidden, "Forbidden: %s", err)
+		return
+	}
 	lock, err := l.ApplyLocker.LockApply()
+	l.Auditor.Record(models.AuditEvent{
+		Type:    models.ApplyLockAuditEvent,
+		Actor:   requestIdentity(r).Username,
+		Outcome: audit.Outcome(err),
+		Detail:  audit.ErrorDetail(err),
+	})
 	if err != nil {
 		l.respond(w, logging.Error, http.StatusInternalServerError, "creating apply lock failed with: %s", err)
 		return
@@ -44,8 +59,18 @@ func (l *LocksController) LockApply(w http.ResponseWriter, _ *http.Request) {
 
 // UnlockApply handles releasing a global apply lock.
 // If Lock doesn't exists it will be a no-op
-func (l *LocksController) UnlockApply(w http.ResponseWriter, _ *http.Request) {
+func (l *LocksController) UnlockApply(w http.ResponseWriter, r *http.Request) {
+	if err := l.Authorizer.AuthorizeGlobal(requestIdentity(r), auth.AdminRole); err != nil {
+		l.respond(w, logging.Warn, http.StatusForbidden, "Forbidden: %s", err)
+		return
+	}
 	err := l.ApplyLocker.UnlockApply()
+	l.Auditor.Record(models.AuditEvent{
+		Type:    models.ApplyUnlockAuditEvent,
+		Actor:   requestIdentity(r).Username,
+		Outcome: audit.Outcome(err),
+		Detail:  audit.ErrorDetail(err),
+	})
 	if err != nil {
 		l.respond(w, logging.Error, http.StatusInternalServerError, "deleting apply lock failed with: %s", err)
 		return
@@ -76,6 +101,10 @@ func (l *LocksController) GetLock(w http.ResponseWriter, r *http.Request) {
 		l.respond(w, logging.Info, http.StatusNotFound, "No lock found at id %q", idUnencoded)
 		return
 	}
+	if err := l.Authorizer.AuthorizeRepo(requestIdentity(r), lockRepoID(*lock), auth.ViewerRole); err != nil {
+		l.respond(w, logging.Warn, http.StatusForbidden, "Forbidden: %s", err)
+		return
+	}
 
 	owner, repo := models.SplitRepoFullName(lock.Project.RepoFullName)
 	viewData := templates.LockDetailData{
@@ -111,6 +140,20 @@ func (l *LocksController) DeleteLock(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if l.Authorizer.Enabled() {
+		lock, err := l.Locker.GetLock(idUnencoded)
+		if err != nil {
+			l.respond(w, logging.Error, http.StatusInternalServerError, "Failed getting lock: %s", err)
+			return
+		}
+		if lock != nil {
+			if err := l.Authorizer.AuthorizeRepo(requestIdentity(r), lockRepoID(*lock), auth.OperatorRole); err != nil {
+				l.respond(w, logging.Warn, http.StatusForbidden, "Forbidden: %s", err)
+				return
+			}
+		}
+	}
+
 	lock, err := l.DeleteLockCommand.DeleteLock(idUnencoded)
 	if err != nil {
 		l.respond(w, logging.Error, http.StatusInternalServerError, "deleting lock failed with: %s", err)
@@ -121,25 +164,47 @@ func (l *LocksController) DeleteLock(w http.ResponseWriter, r *http.Request) {
 		l.respond(w, logging.Info, http.StatusNotFound, "No lock found at id %q", idUnencoded)
 		return
 	}
+	l.Auditor.Record(newUnlockAuditEvent(requestIdentity(r).Username, *lock))
+
+	discardLockedPlan(l.Logger, l.Backend, l.VCSClient, lock, "the Atlantis UI")
+	l.respond(w, logging.Info, http.StatusOK, "Deleted lock id %q", id)
+}
 
+// discardLockedPlan marks the plan of a deleted lock as discarded and comments
+// back on the lock's pull request. deletedVia describes where the lock was
+// deleted from, ex. "the Atlantis UI".
+func discardLockedPlan(logger logging.SimpleLogging, backend locking.Backend, vcsClient vcs.Client, lock *models.ProjectLock, deletedVia string) {
 	// NOTE: Because BaseRepo was added to the PullRequest model later, previous
 	// installations of Atlantis will have locks in their DB that do not have
 	// this field on PullRequest. We skip commenting in this case.
-	if lock.Pull.BaseRepo != (models.Repo{}) {
-		if err := l.Backend.UpdateProjectStatus(lock.Pull, lock.Workspace, lock.Project.Path, models.DiscardedPlanStatus); err != nil {
-			l.Logger.Err("unable to update project status: %s", err)
-		}
+	if lock.Pull.BaseRepo == (models.Repo{}) {
+		logger.Debug("skipping commenting on pull request and deleting workspace because BaseRepo field is empty")
+		return
+	}
+	if err := backend.UpdateProjectStatus(lock.Pull, lock.Workspace, lock.Project.Path, models.DiscardedPlanStatus); err != nil {
+		logger.Err("unable to update project status: %s", err)
+	}
 
-		// Once the lock has been deleted, comment back on the pull request.
-		comment := fmt.Sprintf("**Warning**: The plan for dir: `%s` workspace: `%s` was **discarded** via the Atlantis UI.\n\n"+
-			"To `apply` this plan you must run `plan` again.", lock.Project.Path, lock.Workspace)
-		if err = l.VCSClient.CreateComment(lock.Pull.BaseRepo, lock.Pull.Num, comment, ""); err != nil {
-			l.Logger.Warn("failed commenting on pull request: %s", err)
-		}
-	} else {
-		l.Logger.Debug("skipping commenting on pull request and deleting workspace because BaseRepo field is empty")
+	// Once the lock has been deleted, comment back on the pull request.
+	comment := fmt.Sprintf("**Warning**: The plan for dir: `%s` workspace: `%s` was **discarded** via %s.\n\n"+
+		"To `apply` this plan you must run `plan` again.", lock.Project.Path, lock.Workspace, deletedVia)
+	if err := vcsClient.CreateComment(lock.Pull.BaseRepo, lock.Pull.Num, comment, ""); err != nil {
+		logger.Warn("failed commenting on pull request: %s", err)
+	}
+}
+
+// newUnlockAuditEvent returns the audit event for actor deleting lock.
+func newUnlockAuditEvent(actor string, lock models.ProjectLock) models.AuditEvent {
+	return models.AuditEvent{
+		Type:      models.UnlockAuditEvent,
+		Actor:     actor,
+		Repo:      lock.Project.RepoFullName,
+		Pull:      lock.Pull.Num,
+		Directory: lock.Project.Path,
+		Workspace: lock.Workspace,
+		Command:   command.Unlock.String(),
+		Outcome:   models.AuditSuccess,
 	}
-	l.respond(w, logging.Info, http.StatusOK, "Deleted lock id %q", id)
 }
 
 // respond is a helper function to respond and log the response. lvl is the log
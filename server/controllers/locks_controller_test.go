@@ -10,9 +10,11 @@ import (
 	"testing"
 	"time"
 
+	"github.com/runatlantis/atlantis/server/auth"
 	"github.com/runatlantis/atlantis/server/controllers"
 	"github.com/runatlantis/atlantis/server/controllers/templates"
 	tMocks "github.com/runatlantis/atlantis/server/controllers/templates/mocks"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
 	"github.com/runatlantis/atlantis/server/core/db"
 	"github.com/runatlantis/atlantis/server/core/locking"
 
@@ -330,6 +332,7 @@ func TestDeleteLock_UpdateProjectStatus(t *testing.T) {
 			Workspace:  workspaceName,
 			RepoRelDir: projectPath,
 			Status:     models.DiscardedPlanStatus,
+			PlanDigest: (&models.PlanSuccess{TerraformOutput: "tf-output"}).ChangesDigest(),
 		},
 	}, status.Projects)
 }
@@ -405,3 +408,57 @@ func TestDeleteLock_CommentSuccess(t *testing.T) {
 		"**Warning**: The plan for dir: `path` workspace: `workspace` was **discarded** via the Atlantis UI.\n\n"+
 			"To `apply` this plan you must run `plan` again.", "")
 }
+
+func TestLocksController_Roles(t *testing.T) {
+	RegisterMockTestingT(t)
+	repo := models.Repo{FullName: "owner/repo", VCSHost: models.VCSHost{Hostname: "github.com"}}
+	l := mocks.NewMockLocker()
+	When(l.GetLock("id")).ThenReturn(&models.ProjectLock{
+		Project: models.Project{RepoFullName: "owner/repo", Path: "path"},
+		Pull:    models.PullRequest{BaseRepo: repo},
+	}, nil)
+	dlc := mocks2.NewMockDeleteLockCommand()
+	lc := controllers.LocksController{
+		Logger:             logging.NewNoopLogger(t),
+		Locker:             l,
+		ApplyLocker:        mocks.NewMockApplyLocker(),
+		LockDetailTemplate: tMocks.NewMockTemplateWriter(),
+		AtlantisURL:        &url.URL{},
+		DeleteLockCommand:  dlc,
+		Authorizer: auth.NewAuthorizer([]valid.RoleBinding{
+			{ID: "github.com/owner/repo", Role: "viewer", Users: []string{"viewer"}},
+			{ID: "github.com/owner/repo", Role: "operator", Users: []string{"operator"}},
+		}, false),
+	}
+	request := func(user string) *http.Request {
+		req, _ := http.NewRequest("GET", "", bytes.NewBuffer(nil))
+		req = mux.SetURLVars(req, map[string]string{"id": "id"})
+		return req.WithContext(auth.WithIdentity(req.Context(), auth.Identity{Username: user}))
+	}
+
+	t.Log("viewers can view the lock but not delete it")
+	w := httptest.NewRecorder()
+	lc.GetLock(w, request("viewer"))
+	ResponseContains(t, w, http.StatusOK, "")
+	w = httptest.NewRecorder()
+	lc.DeleteLock(w, request("viewer"))
+	ResponseContains(t, w, http.StatusForbidden, `Forbidden: user "viewer" doesn't have the operator role on github.com/owner/repo`)
+	dlc.VerifyWasCalled(Never()).DeleteLock(Any[string]())
+
+	t.Log("other users can't view the lock")
+	w = httptest.NewRecorder()
+	lc.GetLock(w, request("other"))
+	ResponseContains(t, w, http.StatusForbidden, `Forbidden: user "other" doesn't have the viewer role on github.com/owner/repo`)
+
+	t.Log("operators can delete the lock but not toggle the apply lock")
+	When(dlc.DeleteLock("id")).ThenReturn(nil, nil)
+	w = httptest.NewRecorder()
+	lc.DeleteLock(w, request("operator"))
+	ResponseContains(t, w, http.StatusNotFound, "No lock found")
+	w = httptest.NewRecorder()
+	lc.LockApply(w, request("operator"))
+	ResponseContains(t, w, http.StatusForbidden, `Forbidden: user "operator" doesn't have the admin role on all repos`)
+	w = httptest.NewRecorder()
+	lc.UnlockApply(w, request("operator"))
+	ResponseContains(t, w, http.StatusForbidden, `Forbidden: user "operator" doesn't have the admin role on all repos`)
+}
@@ -0,0 +1,303 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/auth"
+	"github.com/runatlantis/atlantis/server/controllers/templates"
+	"github.com/runatlantis/atlantis/server/core/locking"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/jobs"
+	"github.com/runatlantis/atlantis/server/logging"
+)
+
+// APIPull is the JSON representation of a pull request's status returned by
+// the pulls API.
+type APIPull struct {
+	Repo        string       `json:"repo"`
+	PullNum     int          `json:"pull_num"`
+	PullURL     string       `json:"pull_url"`
+	Author      string       `json:"author"`
+	HeadCommit  string       `json:"head_commit"`
+	LastCommand string       `json:"last_command"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+	Projects    []APIProject `json:"projects"`
+}
+
+// APIProject is the status of one of a pull request's projects.
+type APIProject struct {
+	ProjectName  string               `json:"project_name"`
+	Path         string               `json:"path"`
+	Workspace    string               `json:"workspace"`
+	Status       string               `json:"status"`
+	PolicyStatus []APIPolicySetStatus `json:"policy_status"`
+	Jobs         []APIJob             `json:"jobs"`
+}
+
+// APIPolicySetStatus is the status of a policy set for a project.
+type APIPolicySetStatus struct {
+	PolicySet string `json:"policy_set"`
+	Passed    bool   `json:"passed"`
+	Approvals int    `json:"approvals"`
+}
+
+// APIJob links to the logs of a job that ran for a project.
+type APIJob struct {
+	ID   string    `json:"id"`
+	URL  string    `json:"url"`
+	Time time.Time `json:"time"`
+}
+
+// APIListPullsResponse is the response to GET /api/pulls.
+type APIListPullsResponse struct {
+	Pulls []APIPull `json:"pulls"`
+}
+
+// PullsController renders the pull request dashboard.
+type PullsController struct {
+	AtlantisVersion         string
+	AtlantisURL             *url.URL
+	Backend                 locking.Backend
+	Logger                  logging.SimpleLogging
+	PullsTemplate           templates.TemplateWriter
+	ProjectCmdOutputHandler jobs.ProjectCommandOutputHandler
+	JobURLGenerator         jobs.ProjectJobURLGenerator
+	Authorizer              *auth.Authorizer
+}
+
+// GetPulls is the GET /pulls route. It lists the open pull requests Atlantis
+// has run on, filtered by the repo and status query parameters.
+func (p *PullsController) GetPulls(w http.ResponseWriter, r *http.Request) {
+	id := requestIdentity(r)
+	if err := p.Authorizer.AuthorizeAny(id, auth.ViewerRole); err != nil {
+		p.respond(w, logging.Warn, http.StatusForbidden, "Forbidden: %s", err)
+		return
+	}
+	filter := parsePullFilter(r)
+	canView := func(repoID string) bool { return p.Authorizer.CanView(id, repoID) }
+	pulls, err := listPulls(p.Backend, p.ProjectCmdOutputHandler, p.JobURLGenerator, pullFilter{}, canView)
+	if err != nil {
+		p.respond(w, logging.Error, http.StatusServiceUnavailable, "Could not retrieve pull requests: %s", err)
+		return
+	}
+
+	// The filter options are built from every pull request so that filtering
+	// doesn't hide the other options.
+	repos := map[string]bool{}
+	data := templates.PullsData{
+		AtlantisVersion: p.AtlantisVersion,
+		CleanedBasePath: p.AtlantisURL.Path,
+		Repo:            filter.repo,
+		Status:          filter.status,
+		Statuses:        pullStatusNames,
+	}
+	for _, pull := range pulls {
+		if !repos[pull.Repo] {
+			repos[pull.Repo] = true
+			data.Repos = append(data.Repos, pull.Repo)
+		}
+		if !filter.matches(pull) {
+			continue
+		}
+		data.Pulls = append(data.Pulls, newPullIndexData(pull))
+	}
+	sort.Strings(data.Repos)
+
+	if err := p.PullsTemplate.Execute(w, data); err != nil {
+		p.Logger.Err(err.Error())
+	}
+}
+
+func (p *PullsController) respond(w http.ResponseWriter, lvl logging.LogLevel, responseCode int, format string, args ...interface{}) {
+	response := fmt.Sprintf(format, args...)
+	p.Logger.Log(lvl, response)
+	w.WriteHeader(responseCode)
+	fmt.Fprintln(w, response)
+}
+
+// pullStatusNames are the project statuses that pull requests can be
+// filtered by.
+var pullStatusNames = []string{
+	models.PlannedPlanStatus.String(),
+	models.PlannedNoChangesPlanStatus.String(),
+	models.ErroredPlanStatus.String(),
+	models.AppliedPlanStatus.String(),
+	models.ErroredApplyStatus.String(),
+	models.DiscardedPlanStatus.String(),
+	models.PassedPolicyCheckStatus.String(),
+	models.ErroredPolicyCheckStatus.String(),
+}
+
+// pullFilter filters pull requests by the query parameters of a request.
+// Empty fields match any pull request.
+type pullFilter struct {
+	repo string
+	// status matches pull requests with at least one project in that status.
+	status string
+}
+
+func parsePullFilter(r *http.Request) pullFilter {
+	query := r.URL.Query()
+	return pullFilter{
+		repo:   query.Get("repo"),
+		status: query.Get("status"),
+	}
+}
+
+func (f pullFilter) matches(pull APIPull) bool {
+	if f.repo != "" && pull.Repo != f.repo {
+		return false
+	}
+	if f.status == "" {
+		return true
+	}
+	for _, project := range pull.Projects {
+		if project.Status == f.status {
+			return true
+		}
+	}
+	return false
+}
+
+// jobKey identifies the jobs of a project in a pull request.
+type jobKey struct {
+	repo        string
+	pullNum     int
+	path        string
+	workspace   string
+	projectName string
+}
+
+// listPulls returns the status of the open pull requests matching filter
+// along with links to their projects' jobs, most recently updated first.
+// Pull requests on repos that canView returns false for are left out.
+func listPulls(backend locking.Backend, outputHandler jobs.ProjectCommandOutputHandler, jobURLGenerator jobs.ProjectJobURLGenerator, filter pullFilter, canView func(repoID string) bool) ([]APIPull, error) {
+	statuses, err := backend.ListPullStatuses()
+	if err != nil {
+		return nil, fmt.Errorf("failed listing pull statuses: %w", err)
+	}
+
+	// Jobs are only kept in memory so older jobs won't be linked.
+	projectJobs := map[jobKey][]APIJob{}
+	for _, mapping := range outputHandler.GetPullToJobMapping() {
+		key := jobKey{
+			repo:        mapping.Pull.RepoFullName,
+			pullNum:     mapping.Pull.PullNum,
+			path:        mapping.Pull.Path,
+			workspace:   mapping.Pull.Workspace,
+			projectName: mapping.Pull.ProjectName,
+		}
+		for _, info := range mapping.JobIDInfos {
+			jobURL, err := jobURLGenerator.GenerateProjectJobURL(command.ProjectContext{JobID: info.JobID})
+			if err != nil {
+				return nil, err
+			}
+			projectJobs[key] = append(projectJobs[key], APIJob{ID: info.JobID, URL: jobURL, Time: info.Time})
+		}
+	}
+	for _, j := range projectJobs {
+		sort.Slice(j, func(x, y int) bool { return j[x].Time.After(j[y].Time) })
+	}
+
+	pulls := []APIPull{}
+	for _, s := range statuses {
+		if s.Pull.State != models.OpenPullState || !canView(s.Pull.BaseRepo.ID()) {
+			continue
+		}
+		pull := newAPIPull(s, projectJobs)
+		if filter.matches(pull) {
+			pulls = append(pulls, pull)
+		}
+	}
+	sort.SliceStable(pulls, func(i, j int) bool {
+		if !pulls[i].UpdatedAt.Equal(pulls[j].UpdatedAt) {
+			return pulls[i].UpdatedAt.After(pulls[j].UpdatedAt)
+		}
+		if pulls[i].Repo != pulls[j].Repo {
+			return pulls[i].Repo < pulls[j].Repo
+		}
+		return pulls[i].PullNum < pulls[j].PullNum
+	})
+	return pulls, nil
+}
+
+func newAPIPull(s models.PullStatus, projectJobs map[jobKey][]APIJob) APIPull {
+	pull := APIPull{
+		Repo:        s.Pull.BaseRepo.FullName,
+		PullNum:     s.Pull.Num,
+		PullURL:     s.Pull.URL,
+		Author:      s.Pull.Author,
+		HeadCommit:  s.Pull.HeadCommit,
+		LastCommand: s.LastCommand,
+		CreatedAt:   s.CreatedAt,
+		UpdatedAt:   s.UpdatedAt,
+		Projects:    []APIProject{},
+	}
+	for _, p := range s.Projects {
+		project := APIProject{
+			ProjectName:  p.ProjectName,
+			Path:         p.RepoRelDir,
+			Workspace:    p.Workspace,
+			Status:       p.Status.String(),
+			PolicyStatus: []APIPolicySetStatus{},
+			Jobs:         projectJobs[jobKey{pull.Repo, pull.PullNum, p.RepoRelDir, p.Workspace, p.ProjectName}],
+		}
+		if project.Jobs == nil {
+			project.Jobs = []APIJob{}
+		}
+		for _, ps := range p.PolicyStatus {
+			project.PolicyStatus = append(project.PolicyStatus, APIPolicySetStatus{
+				PolicySet: ps.PolicySetName,
+				Passed:    ps.Passed,
+				Approvals: ps.Approvals,
+			})
+		}
+		pull.Projects = append(pull.Projects, project)
+	}
+	return pull
+}
+
+func newPullIndexData(pull APIPull) templates.PullIndexData {
+	data := templates.PullIndexData{
+		RepoFullName: pull.Repo,
+		PullNum:      pull.PullNum,
+		PullURL:      pull.PullURL,
+		Author:       pull.Author,
+		LastCommand:  pull.LastCommand,
+	}
+	// Statuses recorded by older versions of Atlantis don't have timestamps.
+	if !pull.CreatedAt.IsZero() {
+		data.CreatedFormatted = pull.CreatedAt.Format("02-01-2006 15:04:05")
+	}
+	if !pull.UpdatedAt.IsZero() {
+		data.UpdatedFormatted = pull.UpdatedAt.Format("02-01-2006 15:04:05")
+	}
+	for _, p := range pull.Projects {
+		project := templates.PullProjectData{
+			ProjectName: p.ProjectName,
+			Path:        p.Path,
+			Workspace:   p.Workspace,
+			Status:      p.Status,
+		}
+		for _, ps := range p.PolicyStatus {
+			project.PolicyStatus = append(project.PolicyStatus, templates.PullPolicySetData{
+				Name:   ps.PolicySet,
+				Passed: ps.Passed,
+			})
+		}
+		for _, j := range p.Jobs {
+			project.Jobs = append(project.Jobs, templates.PullJobData{
+				URL:           j.URL,
+				TimeFormatted: j.Time.Format("02-01-2006 15:04:05"),
+			})
+		}
+		data.Projects = append(data.Projects, project)
+	}
+	return data
+}
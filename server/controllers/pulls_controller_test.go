@@ -0,0 +1,214 @@
+package controllers_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	. "github.com/petergtz/pegomock/v4"
+	"github.com/runatlantis/atlantis/server/auth"
+	"github.com/runatlantis/atlantis/server/controllers"
+	"github.com/runatlantis/atlantis/server/controllers/templates"
+	tMocks "github.com/runatlantis/atlantis/server/controllers/templates/mocks"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/core/db"
+	lockmocks "github.com/runatlantis/atlantis/server/core/locking/mocks"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/jobs"
+	jobmocks "github.com/runatlantis/atlantis/server/jobs/mocks"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestPullsController_GetPulls(t *testing.T) {
+	pc, _ := setupPulls(t)
+	tmpl := tMocks.NewMockTemplateWriter()
+	pc.PullsTemplate = tmpl
+
+	req, _ := http.NewRequest("GET", "/pulls?status=plan_errored", nil)
+	w := httptest.NewRecorder()
+	pc.GetPulls(w, req)
+	Equals(t, http.StatusOK, w.Result().StatusCode)
+
+	_, data := tmpl.VerifyWasCalledOnce().Execute(Any[*httptest.ResponseRecorder](), Any[interface{}]()).GetCapturedArguments()
+	pd := data.(templates.PullsData)
+	Equals(t, []string{"owner/other", "owner/repo"}, pd.Repos)
+	Equals(t, "plan_errored", pd.Status)
+	Equals(t, 1, len(pd.Pulls))
+	Equals(t, "owner/other", pd.Pulls[0].RepoFullName)
+	Equals(t, "plan", pd.Pulls[0].LastCommand)
+}
+
+func TestPullsController_GetPullsErr(t *testing.T) {
+	pc, _ := setupPulls(t)
+	backend := lockmocks.NewMockBackend()
+	When(backend.ListPullStatuses()).ThenReturn(nil, errors.New("err"))
+	pc.Backend = backend
+
+	req, _ := http.NewRequest("GET", "/pulls", nil)
+	w := httptest.NewRecorder()
+	pc.GetPulls(w, req)
+	ResponseContains(t, w, http.StatusServiceUnavailable, "Could not retrieve pull requests: failed listing pull statuses: err")
+}
+
+func TestPullsController_GetPullsRoles(t *testing.T) {
+	pc, _ := setupPulls(t)
+	tmpl := tMocks.NewMockTemplateWriter()
+	pc.PullsTemplate = tmpl
+	pc.Authorizer = auth.NewAuthorizer([]valid.RoleBinding{
+		{IDRegex: regexp.MustCompile("owner/other$"), Role: "viewer", Users: []string{"alice"}},
+	}, false)
+
+	req, _ := http.NewRequest("GET", "/pulls", nil)
+	w := httptest.NewRecorder()
+	pc.GetPulls(w, req.WithContext(auth.WithIdentity(req.Context(), auth.Identity{Username: "alice"})))
+	Equals(t, http.StatusOK, w.Result().StatusCode)
+	_, data := tmpl.VerifyWasCalledOnce().Execute(Any[*httptest.ResponseRecorder](), Any[interface{}]()).GetCapturedArguments()
+	pd := data.(templates.PullsData)
+	Equals(t, []string{"owner/other"}, pd.Repos)
+	Equals(t, 1, len(pd.Pulls))
+
+	t.Log("users without a role on any repo are forbidden")
+	w = httptest.NewRecorder()
+	pc.GetPulls(w, req.WithContext(auth.WithIdentity(req.Context(), auth.Identity{Username: "bob"})))
+	ResponseContains(t, w, http.StatusForbidden, `Forbidden: user "bob" doesn't have the viewer role on any repo`)
+}
+
+func TestAPIController_ListPulls(t *testing.T) {
+	_, ac := setupPulls(t)
+
+	cases := []struct {
+		description string
+		query       string
+		expPulls    []int
+	}{
+		{"no filters", "", []int{2, 1, 3}},
+		{"filter by repo", "?repo=owner/repo", []int{2, 1}},
+		{"filter by status", "?status=applied", []int{1}},
+		{"filter by repo and status", "?repo=owner/repo&status=plan_errored", []int{}},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/api/pulls"+c.query, nil)
+			req.Header.Set(atlantisTokenHeader, atlantisToken)
+			w := httptest.NewRecorder()
+			ac.ListPulls(w, req)
+			Equals(t, http.StatusOK, w.Result().StatusCode)
+			var resp controllers.APIListPullsResponse
+			Ok(t, json.NewDecoder(w.Result().Body).Decode(&resp))
+			nums := []int{}
+			for _, p := range resp.Pulls {
+				nums = append(nums, p.PullNum)
+			}
+			Equals(t, c.expPulls, nums)
+		})
+	}
+
+	t.Log("projects include their status, policies and jobs")
+	req, _ := http.NewRequest("GET", "/api/pulls?repo=owner/repo&status=applied", nil)
+	req.Header.Set(atlantisTokenHeader, atlantisToken)
+	w := httptest.NewRecorder()
+	ac.ListPulls(w, req)
+	var resp controllers.APIListPullsResponse
+	Ok(t, json.NewDecoder(w.Result().Body).Decode(&resp))
+	pull := resp.Pulls[0]
+	Equals(t, "author", pull.Author)
+	Equals(t, "apply", pull.LastCommand)
+	Assert(t, !pull.UpdatedAt.IsZero(), "exp updated_at to be set")
+	Equals(t, []controllers.APIProject{
+		{
+			ProjectName:  "",
+			Path:         ".",
+			Workspace:    "default",
+			Status:       "applied",
+			PolicyStatus: []controllers.APIPolicySetStatus{{PolicySet: "policy", Passed: true}},
+			Jobs: []controllers.APIJob{
+				{ID: "job2", URL: "https://atlantis/jobs/job2", Time: time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC)},
+				{ID: "job1", URL: "https://atlantis/jobs/job1", Time: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)},
+			},
+		},
+	}, pull.Projects)
+
+	ac.APISecret = nil
+	w = httptest.NewRecorder()
+	ac.ListPulls(w, req)
+	ResponseContains(t, w, http.StatusBadRequest, "ignoring request since API is disabled")
+}
+
+// setupPulls returns a PullsController and an APIController backed by a real
+// database containing the statuses of three pull requests across two repos.
+func setupPulls(t *testing.T) (controllers.PullsController, controllers.APIController) {
+	RegisterMockTestingT(t)
+	logger := logging.NewNoopLogger(t)
+	boltDB, err := db.New(t.TempDir())
+	Ok(t, err)
+
+	repo := models.Repo{FullName: "owner/repo", Owner: "owner", Name: "repo"}
+	other := models.Repo{FullName: "owner/other", Owner: "owner", Name: "other"}
+	for _, p := range []struct {
+		pull    models.PullRequest
+		results []command.ProjectResult
+	}{
+		{
+			models.PullRequest{Num: 3, BaseRepo: other, State: models.OpenPullState},
+			[]command.ProjectResult{{Command: command.Plan, RepoRelDir: ".", Workspace: "default", Error: errors.New("err")}},
+		},
+		{
+			models.PullRequest{Num: 1, BaseRepo: repo, Author: "author", State: models.OpenPullState},
+			[]command.ProjectResult{{
+				Command:            command.Apply,
+				RepoRelDir:         ".",
+				Workspace:          "default",
+				ApplySuccess:       "success",
+				PolicyCheckResults: &models.PolicyCheckResults{PolicySetResults: []models.PolicySetResult{{PolicySetName: "policy", Passed: true}}},
+			}},
+		},
+		{
+			models.PullRequest{Num: 2, BaseRepo: repo, State: models.OpenPullState},
+			[]command.ProjectResult{{Command: command.Plan, RepoRelDir: "dir", Workspace: "default", PlanSuccess: &models.PlanSuccess{}}},
+		},
+	} {
+		_, err := boltDB.UpdatePullWithResults(p.pull, p.results)
+		Ok(t, err)
+		// Pull requests are listed most recently updated first.
+		time.Sleep(time.Millisecond)
+	}
+
+	outputHandler := jobmocks.NewMockProjectCommandOutputHandler()
+	When(outputHandler.GetPullToJobMapping()).ThenReturn([]jobs.PullInfoWithJobIDs{
+		{
+			Pull: jobs.PullInfo{PullNum: 1, Repo: "repo", RepoFullName: "owner/repo", Path: ".", Workspace: "default"},
+			JobIDInfos: []jobs.JobIDInfo{
+				{JobID: "job1", Time: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)},
+				{JobID: "job2", Time: time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC)},
+			},
+		},
+	})
+	jobURLGenerator := jobmocks.NewMockProjectJobURLGenerator()
+	When(jobURLGenerator.GenerateProjectJobURL(Any[command.ProjectContext]())).Then(func(params []Param) ReturnValues {
+		return ReturnValues{"https://atlantis/jobs/" + params[0].(command.ProjectContext).JobID, nil}
+	})
+
+	pc := controllers.PullsController{
+		AtlantisURL:             &url.URL{},
+		Backend:                 boltDB,
+		Logger:                  logger,
+		PullsTemplate:           templates.PullsTemplate,
+		ProjectCmdOutputHandler: outputHandler,
+		JobURLGenerator:         jobURLGenerator,
+	}
+	ac := controllers.APIController{
+		APISecret:               []byte(atlantisToken),
+		Backend:                 boltDB,
+		JobURLGenerator:         jobURLGenerator,
+		Logger:                  logger,
+		ProjectCmdOutputHandler: outputHandler,
+	}
+	return pc, ac
+}
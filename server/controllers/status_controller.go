@@ -4,9 +4,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/models"
 	"github.com/runatlantis/atlantis/server/logging"
+	"github.com/runatlantis/atlantis/server/scheduled"
 )
 
 // StatusController handles the status of Atlantis.
@@ -14,22 +17,46 @@ type StatusController struct {
 	Logger          logging.SimpleLogging
 	Drainer         *events.Drainer
 	AtlantisVersion string
+	// LeaderElector, if set, is used to report the leader and the other
+	// replicas sharing the database.
+	LeaderElector *scheduled.LeaderElector
 }
 
 type StatusResponse struct {
 	ShuttingDown    bool   `json:"shutting_down"`
 	InProgressOps   int    `json:"in_progress_operations"`
 	AtlantisVersion string `json:"version"`
+	// ReplicaID identifies the replica that responded.
+	ReplicaID string `json:"replica_id,omitempty"`
+	// Leader is true if the replica that responded is the leader.
+	Leader   bool         `json:"leader"`
+	LeaderID string       `json:"leader_id,omitempty"`
+	Peers    []StatusPeer `json:"peers,omitempty"`
+	Error    string       `json:"error,omitempty"`
+}
+
+// StatusPeer is a replica sharing the database.
+type StatusPeer struct {
+	ID            string    `json:"id"`
+	Hostname      string    `json:"hostname"`
+	Version       string    `json:"version"`
+	StartedAt     time.Time `json:"started_at"`
+	ShuttingDown  bool      `json:"shutting_down"`
+	InProgressOps int       `json:"in_progress_operations"`
 }
 
 // Get is the GET /status route.
 func (d *StatusController) Get(w http.ResponseWriter, _ *http.Request) {
 	status := d.Drainer.GetStatus()
-	data, err := json.MarshalIndent(&StatusResponse{
+	resp := &StatusResponse{
 		ShuttingDown:    status.ShuttingDown,
 		InProgressOps:   status.InProgressOps,
 		AtlantisVersion: d.AtlantisVersion,
-	}, "", "  ")
+	}
+	if d.LeaderElector != nil {
+		d.addCluster(resp)
+	}
+	data, err := json.MarshalIndent(resp, "", "  ")
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		fmt.Fprintf(w, "Error creating status json response: %s", err)
@@ -38,3 +65,36 @@ func (d *StatusController) Get(w http.ResponseWriter, _ *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	w.Write(data) // nolint: errcheck
 }
+
+// addCluster adds the leader and the peers of this replica to resp.
+func (d *StatusController) addCluster(resp *StatusResponse) {
+	resp.ReplicaID = d.LeaderElector.Replica().ID
+	resp.Leader = d.LeaderElector.IsLeader()
+	leader, replicas, err := d.LeaderElector.Cluster()
+	if err != nil {
+		// The status is still useful without the cluster.
+		d.Logger.Warn("failed listing replicas: %s", err)
+		resp.Error = fmt.Sprintf("failed listing replicas: %s", err)
+		return
+	}
+	if leader != nil {
+		resp.LeaderID = leader.ID
+	}
+	for _, r := range replicas {
+		if r.ID == resp.ReplicaID {
+			continue
+		}
+		resp.Peers = append(resp.Peers, newStatusPeer(r))
+	}
+}
+
+func newStatusPeer(r models.Replica) StatusPeer {
+	return StatusPeer{
+		ID:            r.ID,
+		Hostname:      r.Hostname,
+		Version:       r.Version,
+		StartedAt:     r.StartedAt,
+		ShuttingDown:  r.ShuttingDown,
+		InProgressOps: r.InProgressOps,
+	}
+}
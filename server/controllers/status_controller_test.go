@@ -7,10 +7,14 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/runatlantis/atlantis/server/controllers"
+	"github.com/runatlantis/atlantis/server/core/db"
 	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/models"
 	"github.com/runatlantis/atlantis/server/logging"
+	"github.com/runatlantis/atlantis/server/scheduled"
 	. "github.com/runatlantis/atlantis/testing"
 )
 
@@ -83,3 +87,43 @@ func TestStatusController_Shutdown(t *testing.T) {
 	Equals(t, true, result.ShuttingDown)
 	Equals(t, 0, result.InProgressOps)
 }
+
+func TestStatusController_Cluster(t *testing.T) {
+	logger := logging.NewNoopLogger(t)
+	backend, err := db.New(t.TempDir())
+	Ok(t, err)
+	defer backend.Close() // nolint: errcheck
+	newElector := func(id string) *scheduled.LeaderElector {
+		return &scheduled.LeaderElector{
+			Backend: backend,
+			Replica: func() models.Replica { return models.Replica{ID: id, Hostname: id, Version: "1.0.0"} },
+			TTL:     time.Minute,
+			Logger:  logger,
+		}
+	}
+	leader, follower := newElector("a"), newElector("b")
+	leader.Run()
+	follower.Run()
+
+	r, _ := http.NewRequest("GET", "/status", bytes.NewBuffer(nil))
+	w := httptest.NewRecorder()
+	d := &controllers.StatusController{
+		Logger:          logger,
+		Drainer:         &events.Drainer{},
+		AtlantisVersion: "1.0.0",
+		LeaderElector:   follower,
+	}
+	d.Get(w, r)
+
+	var result controllers.StatusResponse
+	body, err := io.ReadAll(w.Result().Body)
+	Ok(t, err)
+	Equals(t, 200, w.Result().StatusCode)
+	Ok(t, json.Unmarshal(body, &result))
+	Equals(t, "b", result.ReplicaID)
+	Equals(t, false, result.Leader)
+	Equals(t, "a", result.LeaderID)
+	Equals(t, 1, len(result.Peers))
+	Equals(t, "a", result.Peers[0].ID)
+	Equals(t, "1.0.0", result.Peers[0].Version)
+}
@@ -159,7 +159,7 @@ var IndexTemplate = template.Must(template.New("index.html.tmpl").Parse(`
   <br>
   <br>
   <section>
-    <p class="title-heading small"><strong>Jobs</strong></p>
+    <p class="title-heading small"><strong>Jobs</strong> <a href="{{ .CleanedBasePath }}/pulls">(view pull requests)</a></p>
     {{ if .PullToJobMapping }}
     <div class="pulls-grid">
     <div class="lock-header">
@@ -411,6 +411,144 @@ v{{ .AtlantisVersion }}
 </html>
 `))
 
+// PullsData holds the data for rendering the pull request dashboard.
+type PullsData struct {
+	Pulls []PullIndexData
+	// Repos and Statuses are the options for filtering pull requests.
+	Repos    []string
+	Statuses []string
+	// Repo and Status are the current filters. Empty strings mean no filter.
+	Repo            string
+	Status          string
+	AtlantisVersion string
+	// CleanedBasePath is the path Atlantis is accessible at externally. If
+	// not using a path-based proxy, this will be an empty string. Never ends
+	// in a '/' (hence "cleaned").
+	CleanedBasePath string
+}
+
+// PullIndexData holds the data for a pull request on the pull request
+// dashboard.
+type PullIndexData struct {
+	RepoFullName     string
+	PullNum          int
+	PullURL          string
+	Author           string
+	LastCommand      string
+	CreatedFormatted string
+	UpdatedFormatted string
+	Projects         []PullProjectData
+}
+
+// PullProjectData holds the status of a project of a pull request.
+type PullProjectData struct {
+	ProjectName  string
+	Path         string
+	Workspace    string
+	Status       string
+	PolicyStatus []PullPolicySetData
+	Jobs         []PullJobData
+}
+
+// PullPolicySetData holds the status of a policy set of a project.
+type PullPolicySetData struct {
+	Name   string
+	Passed bool
+}
+
+// PullJobData links to a project's job.
+type PullJobData struct {
+	URL           string
+	TimeFormatted string
+}
+
+var PullsTemplate = template.Must(template.New("pulls.html.tmpl").Parse(`
+<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>atlantis</title>
+  <meta name="description" content="">
+  <meta name="author" content="">
+  <meta name="viewport" content="width=device-width, initial-scale=1">
+  <link rel="stylesheet" href="{{ .CleanedBasePath }}/static/css/normalize.css">
+  <link rel="stylesheet" href="{{ .CleanedBasePath }}/static/css/skeleton.css">
+  <link rel="stylesheet" href="{{ .CleanedBasePath }}/static/css/custom.css">
+  <link rel="icon" type="image/png" href="{{ .CleanedBasePath }}/static/images/atlantis-icon.png">
+</head>
+<body>
+<div class="container">
+  <section class="header">
+    <a title="atlantis" href="{{ .CleanedBasePath }}/"><img class="hero" src="{{ .CleanedBasePath }}/static/images/atlantis-icon_512.png"/></a>
+    <p class="title-heading">atlantis</p>
+  </section>
+  <section>
+    <p class="title-heading small"><strong>Pull Requests</strong></p>
+    {{ $repo := .Repo }}
+    {{ $status := .Status }}
+    <form method="GET" action="{{ .CleanedBasePath }}/pulls">
+      <select name="repo">
+        <option value="">All repositories</option>
+        {{ range .Repos }}
+        <option value="{{ . }}"{{ if eq . $repo }} selected{{ end }}>{{ . }}</option>
+        {{ end }}
+      </select>
+      <select name="status">
+        <option value="">All statuses</option>
+        {{ range .Statuses }}
+        <option value="{{ . }}"{{ if eq . $status }} selected{{ end }}>{{ . }}</option>
+        {{ end }}
+      </select>
+      <input class="button-primary" type="submit" value="Filter">
+    </form>
+    {{ if .Pulls }}
+    <div class="pulls-dashboard-grid">
+    <div class="lock-header">
+      <span>Pull Request</span>
+      <span>Project</span>
+      <span>Workspace</span>
+      <span>Status</span>
+      <span>Policies</span>
+      <span>Last Command</span>
+      <span>Updated</span>
+      <span>Jobs</span>
+    </div>
+    {{ range .Pulls }}
+      {{ $pull := . }}
+      {{ range .Projects }}
+      <div class="pulls-row">
+      <span class="pulls-element"><a href="{{ $pull.PullURL }}" target="_blank">{{ $pull.RepoFullName }} #{{ $pull.PullNum }}</a><br>{{ $pull.Author }}</span>
+      <span class="pulls-element">{{ if .ProjectName }}{{ .ProjectName }}<br>{{ end }}<code>{{ .Path }}</code></span>
+      <span class="pulls-element"><code>{{ .Workspace }}</code></span>
+      <span class="pulls-element"><code>{{ .Status }}</code></span>
+      <span class="pulls-element">
+      {{ range .PolicyStatus }}
+        <div>{{ .Name }}: {{ if .Passed }}passed{{ else }}failed{{ end }}</div>
+      {{ end }}
+      </span>
+      <span class="pulls-element">{{ $pull.LastCommand }}</span>
+      <span class="pulls-element" title="First run {{ $pull.CreatedFormatted }}">{{ $pull.UpdatedFormatted }}</span>
+      <span class="pulls-element">
+      {{ range .Jobs }}
+        <div><a href="{{ .URL }}" target="_blank">{{ .TimeFormatted }}</a></div>
+      {{ end }}
+      </span>
+      </div>
+      {{ end }}
+    {{ end }}
+    </div>
+    {{ else }}
+    <p class="placeholder">No pull requests found.</p>
+    {{ end }}
+  </section>
+</div>
+<footer>
+v{{ .AtlantisVersion }}
+</footer>
+</body>
+</html>
+`))
+
 // ProjectJobData holds the data needed to stream the current PR information
 type ProjectJobData struct {
 	AtlantisVersion string
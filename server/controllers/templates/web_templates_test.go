@@ -47,6 +47,38 @@ func TestLockTemplate(t *testing.T) {
 	Ok(t, err)
 }
 
+func TestPullsTemplate(t *testing.T) {
+	err := PullsTemplate.Execute(io.Discard, PullsData{
+		Pulls: []PullIndexData{
+			{
+				RepoFullName:     "repo full name",
+				PullNum:          1,
+				PullURL:          "https://example.com",
+				Author:           "author",
+				LastCommand:      "plan",
+				CreatedFormatted: "02-01-2006 15:04:05",
+				UpdatedFormatted: "02-01-2006 15:04:05",
+				Projects: []PullProjectData{
+					{
+						ProjectName:  "project",
+						Path:         "path",
+						Workspace:    "workspace",
+						Status:       "planned",
+						PolicyStatus: []PullPolicySetData{{Name: "policy", Passed: true}},
+						Jobs:         []PullJobData{{URL: "https://example.com/jobs/1", TimeFormatted: "02-01-2006 15:04:05"}},
+					},
+				},
+			},
+		},
+		Repos:           []string{"repo full name"},
+		Statuses:        []string{"planned"},
+		Repo:            "repo full name",
+		AtlantisVersion: "v0.0.0",
+		CleanedBasePath: "/path",
+	})
+	Ok(t, err)
+}
+
 func TestProjectJobsTemplate(t *testing.T) {
 	err := ProjectJobsTemplate.Execute(io.Discard, ProjectJobData{
 		AtlantisVersion: "v0.0.0",
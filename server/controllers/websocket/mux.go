@@ -1,6 +1,7 @@
 package websocket
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 
@@ -17,7 +18,9 @@ type PartitionKeyGenerator interface {
 // PartitionRegistry is the registry holding each partition
 // and is responsible for registering/deregistering new buffers
 type PartitionRegistry interface {
-	Register(key string, buffer chan string)
+	// Register streams the partition into buffer until ctx is done or the
+	// buffer is deregistered.
+	Register(ctx context.Context, key string, buffer chan string)
 	Deregister(key string, buffer chan string)
 	IsKeyExists(key string) bool
 }
@@ -73,7 +76,9 @@ func (m *Multiplexor) Handle(w http.ResponseWriter, r *http.Request) error {
 	buffer := make(chan string, 1000)
 
 	// spinning up a goroutine for this since we are attempting to block on the read side.
-	go m.registry.Register(key, buffer)
+	// The request's context is done once this handler returns, so streaming
+	// stops even if the buffer is deregistered before Register starts.
+	go m.registry.Register(r.Context(), key, buffer)
 	defer m.registry.Deregister(key, buffer)
 
 	return errors.Wrapf(m.writer.Write(w, r, buffer), "writing to ws %s", key)
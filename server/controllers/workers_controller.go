@@ -0,0 +1,184 @@
+package controllers
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/runatlantis/atlantis/server/logging"
+	"github.com/runatlantis/atlantis/server/remote"
+)
+
+// defaultClaimWait is how long claim requests wait for a job by default.
+const defaultClaimWait = 30 * time.Second
+
+// WorkersController handles the requests of remote workers, which pull
+// project jobs from the Atlantis server.
+type WorkersController struct {
+	Queue *remote.Queue
+	// Secret is the secret workers authenticate with to run the jobs of any
+	// runner.
+	Secret []byte
+	// RunnerSecrets are the secrets workers authenticate with to run the jobs
+	// of some runners only, by runner label. If neither Secret nor
+	// RunnerSecrets are set, workers aren't enabled.
+	RunnerSecrets   map[string][]string
+	AtlantisVersion string
+	Logger          logging.SimpleLogging
+	// ClaimWait is how long claim requests wait for a job before responding
+	// with no content. If 0, defaultClaimWait is used.
+	ClaimWait time.Duration
+}
+
+// Claim handles a worker's request for a job. It waits for a job for the
+// worker's runners and responds with no content if there's none.
+func (c *WorkersController) Claim(w http.ResponseWriter, r *http.Request) {
+	var req remote.ClaimRequest
+	if code, err := c.parse(r, &req); err != nil {
+		c.respondError(w, code, err)
+		return
+	}
+	if req.Worker == "" {
+		c.respondError(w, http.StatusBadRequest, errors.New("worker name is required"))
+		return
+	}
+	// Workers declare their own runners so they can only claim the jobs of
+	// the runners their secret is for.
+	for _, runner := range req.Runners {
+		if !c.secretAllowsRunner(r.Header.Get(remote.SecretHeader), runner) {
+			c.respondError(w, http.StatusForbidden, fmt.Errorf("worker secret isn't allowed to run jobs for runner %q", runner))
+			return
+		}
+	}
+	if req.Version != c.AtlantisVersion {
+		c.respondError(w, http.StatusConflict, fmt.Errorf("worker version %q doesn't match server version %q", req.Version, c.AtlantisVersion))
+		return
+	}
+
+	wait := c.ClaimWait
+	if wait == 0 {
+		wait = defaultClaimWait
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), wait)
+	defer cancel()
+	job := c.Queue.Claim(ctx, req)
+	if job == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	c.respondJSON(w, http.StatusOK, job)
+}
+
+// Output handles the output a worker sends for a job it's running.
+func (c *WorkersController) Output(w http.ResponseWriter, r *http.Request) {
+	var req remote.OutputRequest
+	if code, err := c.parse(r, &req); err != nil {
+		c.respondError(w, code, err)
+		return
+	}
+	jobID := mux.Vars(r)["id"]
+	if code, err := c.authorizeJob(r, jobID); err != nil {
+		c.respondError(w, code, err)
+		return
+	}
+	if err := c.Queue.SendOutput(req.Worker, jobID, req.Lines); err != nil {
+		c.respondError(w, http.StatusNotFound, err)
+		return
+	}
+	c.respondJSON(w, http.StatusOK, struct{}{})
+}
+
+// Result handles the result a worker reports for a job it ran.
+func (c *WorkersController) Result(w http.ResponseWriter, r *http.Request) {
+	var req remote.ResultRequest
+	if code, err := c.parse(r, &req); err != nil {
+		c.respondError(w, code, err)
+		return
+	}
+	jobID := mux.Vars(r)["id"]
+	if code, err := c.authorizeJob(r, jobID); err != nil {
+		c.respondError(w, code, err)
+		return
+	}
+	if err := c.Queue.Complete(req.Worker, jobID, req.Result); err != nil {
+		c.respondError(w, http.StatusNotFound, err)
+		return
+	}
+	c.respondJSON(w, http.StatusOK, struct{}{})
+}
+
+// parse authenticates r and decodes its body into v.
+func (c *WorkersController) parse(r *http.Request, v interface{}) (int, error) {
+	if (len(c.Secret) == 0 && len(c.RunnerSecrets) == 0) || c.Queue == nil {
+		return http.StatusBadRequest, errors.New("ignoring request since workers are disabled")
+	}
+	if !c.validSecret(r.Header.Get(remote.SecretHeader)) {
+		return http.StatusUnauthorized, fmt.Errorf("header %s did not match expected secret", remote.SecretHeader)
+	}
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		return http.StatusBadRequest, fmt.Errorf("failed to parse request: %w", err)
+	}
+	return http.StatusOK, nil
+}
+
+// authorizeJob checks that the secret of r is allowed to run the job with id
+// jobID. Worker names aren't secret so they can't be trusted on their own.
+func (c *WorkersController) authorizeJob(r *http.Request, jobID string) (int, error) {
+	runner, err := c.Queue.JobRunner(jobID)
+	if err != nil {
+		return http.StatusNotFound, err
+	}
+	if !c.secretAllowsRunner(r.Header.Get(remote.SecretHeader), runner) {
+		return http.StatusForbidden, fmt.Errorf("worker secret isn't allowed to run jobs for runner %q", runner)
+	}
+	return http.StatusOK, nil
+}
+
+// validSecret returns whether secret is Secret or the secret of a runner.
+func (c *WorkersController) validSecret(secret string) bool {
+	valid := secretEquals(secret, c.Secret)
+	for runner := range c.RunnerSecrets {
+		valid = c.secretAllowsRunner(secret, runner) || valid
+	}
+	return valid
+}
+
+// secretAllowsRunner returns whether workers with secret can run the jobs of
+// runner.
+func (c *WorkersController) secretAllowsRunner(secret string, runner string) bool {
+	allowed := secretEquals(secret, c.Secret)
+	for _, runnerSecret := range c.RunnerSecrets[runner] {
+		allowed = secretEquals(secret, []byte(runnerSecret)) || allowed
+	}
+	return allowed
+}
+
+// secretEquals compares secret to expected in constant time. An empty
+// expected secret never matches.
+func secretEquals(secret string, expected []byte) bool {
+	return len(expected) > 0 && subtle.ConstantTimeCompare([]byte(secret), expected) == 1
+}
+
+func (c *WorkersController) respondJSON(w http.ResponseWriter, code int, v interface{}) {
+	response, err := json.Marshal(v)
+	if err != nil {
+		c.respondError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(response) // nolint: errcheck
+}
+
+func (c *WorkersController) respondError(w http.ResponseWriter, code int, err error) {
+	c.Logger.Warn("worker request failed: %s", err)
+	response, _ := json.Marshal(map[string]string{"error": err.Error()})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(response) // nolint: errcheck
+}
@@ -0,0 +1,170 @@
+package controllers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/runatlantis/atlantis/server/controllers"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/jobs/mocks"
+	"github.com/runatlantis/atlantis/server/logging"
+	"github.com/runatlantis/atlantis/server/remote"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func workersRequest(t *testing.T, route string, secret string, body interface{}) *http.Request {
+	encoded, err := json.Marshal(body)
+	Ok(t, err)
+	req, _ := http.NewRequest("POST", route, bytes.NewReader(encoded))
+	req.Header.Set(remote.SecretHeader, secret)
+	return req
+}
+
+func TestWorkersController(t *testing.T) {
+	q := remote.NewQueue(mocks.NewMockProjectCommandOutputHandler())
+	c := &controllers.WorkersController{
+		Queue:           q,
+		Secret:          []byte("secret"),
+		AtlantisVersion: "1.0.0",
+		Logger:          logging.NewNoopLogger(t),
+		ClaimWait:       50 * time.Millisecond,
+	}
+	router := mux.NewRouter()
+	router.HandleFunc(remote.ClaimRoute, c.Claim).Methods("POST")
+	router.HandleFunc(remote.ResultRoute, c.Result).Methods("POST")
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	claim := remote.ClaimRequest{Worker: "prod-1", Runners: []string{"prod"}, Version: "1.0.0"}
+
+	t.Log("requests must have the secret")
+	w := serve(workersRequest(t, remote.ClaimRoute, "wrong", claim))
+	ResponseContains(t, w, http.StatusUnauthorized, "did not match expected secret")
+
+	t.Log("workers must run the server's version")
+	w = serve(workersRequest(t, remote.ClaimRoute, "secret", remote.ClaimRequest{Worker: "prod-1", Version: "0.9.0"}))
+	ResponseContains(t, w, http.StatusConflict, `worker version \"0.9.0\" doesn't match server version \"1.0.0\"`)
+
+	t.Log("claims respond with no content if there's no job")
+	w = serve(workersRequest(t, remote.ClaimRoute, "secret", claim))
+	Equals(t, http.StatusNoContent, w.Code)
+
+	t.Log("claims respond with a job for the worker's runners")
+	ctx := command.ProjectContext{
+		Log:        logging.NewNoopLogger(t),
+		Pull:       models.PullRequest{Num: 1, BaseRepo: models.Repo{FullName: "owner/repo"}},
+		RepoRelDir: ".",
+		Workspace:  "default",
+		Runner:     "prod",
+	}
+	done := make(chan remote.Result, 1)
+	go func() {
+		res, err := q.Run(ctx, remote.Job{Type: remote.VersionJob, CommandName: "version", Context: ctx})
+		Ok(t, err)
+		done <- res
+	}()
+	var job remote.Job
+	for job.ID == "" {
+		w = serve(workersRequest(t, remote.ClaimRoute, "secret", claim))
+		if w.Code == http.StatusOK {
+			Ok(t, json.Unmarshal(w.Body.Bytes(), &job))
+		}
+	}
+	Equals(t, remote.VersionJob, job.Type)
+	Equals(t, "prod", job.Context.Runner)
+
+	t.Log("only the worker running a job can report its result")
+	result := remote.ResultRequest{Worker: "prod-2", Result: remote.Result{Error: "failed"}}
+	w = serve(workersRequest(t, "/api/workers/jobs/"+job.ID+"/result", "secret", result))
+	ResponseContains(t, w, http.StatusNotFound, "unknown job")
+	result.Worker = "prod-1"
+	w = serve(workersRequest(t, "/api/workers/jobs/"+job.ID+"/result", "secret", result))
+	Equals(t, http.StatusOK, w.Code)
+	Equals(t, "failed", (<-done).Error)
+}
+
+func TestWorkersController_Disabled(t *testing.T) {
+	c := &controllers.WorkersController{Logger: logging.NewNoopLogger(t)}
+	w := httptest.NewRecorder()
+	c.Claim(w, workersRequest(t, remote.ClaimRoute, "", remote.ClaimRequest{Worker: "prod-1"}))
+	ResponseContains(t, w, http.StatusBadRequest, "workers are disabled")
+}
+
+func TestWorkersController_RunnerSecrets(t *testing.T) {
+	q := remote.NewQueue(mocks.NewMockProjectCommandOutputHandler())
+	c := &controllers.WorkersController{
+		Queue: q,
+		RunnerSecrets: map[string][]string{
+			"prod":    {"prod-secret"},
+			"staging": {"staging-secret"},
+		},
+		AtlantisVersion: "1.0.0",
+		Logger:          logging.NewNoopLogger(t),
+		ClaimWait:       50 * time.Millisecond,
+	}
+	claim := func(secret string, runners ...string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c.Claim(w, workersRequest(t, remote.ClaimRoute, secret, remote.ClaimRequest{Worker: "worker-1", Runners: runners, Version: "1.0.0"}))
+		return w
+	}
+
+	t.Log("workers can claim the jobs of the runners their secret is for")
+	Equals(t, http.StatusNoContent, claim("prod-secret", "prod").Code)
+	Equals(t, http.StatusNoContent, claim("staging-secret", "staging").Code)
+
+	t.Log("workers can't claim the jobs of other runners")
+	ResponseContains(t, claim("staging-secret", "staging", "prod"), http.StatusForbidden, `worker secret isn't allowed to run jobs for runner \"prod\"`)
+	ResponseContains(t, claim("staging-secret", "unknown"), http.StatusForbidden, `worker secret isn't allowed to run jobs for runner \"unknown\"`)
+	ResponseContains(t, claim("wrong", "prod"), http.StatusUnauthorized, "did not match expected secret")
+
+	t.Log("workers can only send the output and result of the jobs of the runners their secret is for")
+	ctx := command.ProjectContext{
+		Log:        logging.NewNoopLogger(t),
+		Pull:       models.PullRequest{Num: 1, BaseRepo: models.Repo{FullName: "owner/repo"}},
+		RepoRelDir: ".",
+		Workspace:  "default",
+		Runner:     "prod",
+	}
+	done := make(chan remote.Result, 1)
+	go func() {
+		res, err := q.Run(ctx, remote.Job{Type: remote.VersionJob, CommandName: "version", Context: ctx})
+		Ok(t, err)
+		done <- res
+	}()
+	var job remote.Job
+	for job.ID == "" {
+		w := claim("prod-secret", "prod")
+		if w.Code == http.StatusOK {
+			Ok(t, json.Unmarshal(w.Body.Bytes(), &job))
+		}
+	}
+	router := mux.NewRouter()
+	router.HandleFunc(remote.OutputRoute, c.Output).Methods("POST")
+	router.HandleFunc(remote.ResultRoute, c.Result).Methods("POST")
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	output := remote.OutputRequest{Worker: "worker-1", Lines: []string{"line"}}
+	w := serve(workersRequest(t, "/api/workers/jobs/"+job.ID+"/output", "staging-secret", output))
+	ResponseContains(t, w, http.StatusForbidden, `worker secret isn't allowed to run jobs for runner \"prod\"`)
+	result := remote.ResultRequest{Worker: "worker-1", Result: remote.Result{Error: "failed"}}
+	w = serve(workersRequest(t, "/api/workers/jobs/"+job.ID+"/result", "staging-secret", result))
+	ResponseContains(t, w, http.StatusForbidden, `worker secret isn't allowed to run jobs for runner \"prod\"`)
+	w = serve(workersRequest(t, "/api/workers/jobs/"+job.ID+"/result", "prod-secret", result))
+	Equals(t, http.StatusOK, w.Code)
+	Equals(t, "failed", (<-done).Error)
+
+	t.Log("the shared secret can claim the jobs of any runner")
+	c.Secret = []byte("secret")
+	Equals(t, http.StatusNoContent, claim("secret", "staging", "prod").Code)
+}
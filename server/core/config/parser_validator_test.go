@@ -7,6 +7,7 @@ import (
 	"regexp"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/go-version"
 	"github.com/runatlantis/atlantis/server/core/config"
@@ -340,6 +341,57 @@ workflows:
 				},
 			},
 		},
+		{
+			description: "workflow extending another workflow with a step template",
+			input: `
+version: 3
+projects:
+- dir: "."
+  workflow: staging
+step_templates:
+  scan:
+  - run: tfsec .
+workflows:
+  base:
+    plan:
+      steps: [init, plan]
+  staging:
+    extends: base
+    plan:
+      merge: append
+      steps:
+      - template: scan
+`,
+			exp: valid.RepoCfg{
+				Version: 3,
+				Projects: []valid.Project{
+					{
+						Dir:          ".",
+						Workspace:    "default",
+						WorkflowName: String("staging"),
+						Autoplan: valid.Autoplan{
+							WhenModified: raw.DefaultAutoPlanWhenModified,
+							Enabled:      true,
+						},
+					},
+				},
+				Workflows: map[string]valid.Workflow{
+					"base": defaultWorkflow("base"),
+					"staging": {
+						Name: "staging",
+						Plan: valid.Stage{Steps: []valid.Step{
+							{StepName: "init"},
+							{StepName: "plan"},
+							{StepName: "run", RunCommand: "tfsec ."},
+						}},
+						Apply:       valid.DefaultApplyStage,
+						PolicyCheck: valid.DefaultPolicyCheckStage,
+						Import:      valid.DefaultImportStage,
+						StateRm:     valid.DefaultStateRmStage,
+					},
+				},
+			},
+		},
 		{
 			description: "project fields set except autoplan",
 			input: `
@@ -1234,6 +1286,8 @@ func TestParseGlobalCfg(t *testing.T) {
 	}
 
 	conftestVersion, _ := version.NewVersion("v1.0.0")
+	lockTTL := 2*time.Hour + 30*time.Minute
+	maxConcurrentProjects := 2
 
 	cases := map[string]struct {
 		input  string
@@ -1276,17 +1330,101 @@ func TestParseGlobalCfg(t *testing.T) {
   repo_config_file: ../../etc/passwd`,
 			expErr: "repos: (0: (repo_config_file: must not contains parent directory path like '../'.).).",
 		},
+		"invalid lock_ttl": {
+			input: `repos:
+- id: /.*/
+  lock_ttl: 1day`,
+			expErr: "repos: (0: (lock_ttl: time: unknown unit \"day\" in duration \"1day\".).).",
+		},
+		"negative lock_ttl": {
+			input: `repos:
+- id: /.*/
+  lock_ttl: -1h`,
+			expErr: "repos: (0: (lock_ttl: must be greater than 0.).).",
+		},
+		"invalid max_concurrent_projects": {
+			input: `repos:
+- id: /.*/
+  max_concurrent_projects: 0`,
+			expErr: "repos: (0: (max_concurrent_projects: must be at least 1.).).",
+		},
+		"invalid runner": {
+			input: `repos:
+- id: /.*/
+  runner: prod account`,
+			expErr: "repos: (0: (runner: \"prod account\" is not a valid runner, it can only contain letters, numbers, '-', '_' and '.'.).).",
+		},
+		"invalid env_policy pattern": {
+			input: `env_policy:
+  deny: ["AWS_[*"]`,
+			expErr: "env_policy: (deny: parsing: AWS_[*: syntax error in pattern.).",
+		},
 		"workflow doesn't exist": {
 			input: `repos:
 - id: /.*/
   workflow: notdefined`,
 			expErr: "workflow \"notdefined\" is not defined",
 		},
+		"extended workflow doesn't exist": {
+			input: `workflows:
+  staging:
+    extends: notdefined`,
+			expErr: "workflow \"staging\" extends workflow \"notdefined\" which is not defined",
+		},
+		"workflows extend each other": {
+			input: `workflows:
+  a:
+    extends: b
+  b:
+    extends: c
+  c:
+    extends: a`,
+			expErr: "workflows extend each other in a cycle: a -> b -> c -> a",
+		},
+		"step template doesn't exist": {
+			input: `workflows:
+  staging:
+    plan:
+      steps:
+      - template: notdefined`,
+			expErr: "step template \"notdefined\" is not defined",
+		},
+		"step templates include each other": {
+			input: `step_templates:
+  a:
+  - template: b
+  b:
+  - run: echo b
+  - template: a`,
+			expErr: "step templates include each other in a cycle: a -> b -> a",
+		},
+		"custom command defined twice": {
+			input: `custom_commands:
+- name: validate
+  stage: validate
+- name: validate
+  stage: plan`,
+			expErr: "custom command \"validate\" is defined more than once",
+		},
+		"invalid custom command": {
+			input: `custom_commands:
+- name: apply
+  stage: validate`,
+			expErr: "custom_commands: (0: (name: \"apply\" is a built-in command.).).",
+		},
+		"invalid stage merge": {
+			input: `workflows:
+  staging:
+    plan:
+      merge: replace
+      steps: [init]`,
+			expErr: "workflows: (staging: (plan: (merge: must be one of \"override\", \"append\" or \"prepend\".).).).",
+		},
 		"invalid allowed_override": {
 			input: `repos:
 - id: /.*/
   allowed_overrides: [invalid]`,
-			expErr: "repos: (0: (allowed_overrides: \"invalid\" is not a valid override, only \"plan_requirements\", \"apply_requirements\", \"import_requirements\", \"workflow\", \"delete_source_branch_on_merge\", \"repo_locking\", \"policy_check\", and \"custom_policy_check\" are supported.).).",
+			expErr: "repos: (0: (allowed_overrides: \"invalid\" is not a valid override, only \"plan_requirements\", \"apply_requirements\", \"import_requirements\", \"workflow\", \"delete_source_branch_on_merge\", \"repo_locking\", \"policy_check\", \"custom_policy_check\", \"apply_mode\", and \"runner\" are supported.).).",
 		},
 		"invalid plan_requirement": {
 			input: `repos:
@@ -1500,6 +1638,265 @@ repos:
 				},
 			},
 		},
+		"roles": {
+			input: `
+roles:
+- role: viewer
+  groups: [developers]
+- id: github.com/owner/repo
+  role: operator
+  users: [alice]
+`,
+			exp: valid.GlobalCfg{
+				Repos: defaultCfg.Repos,
+				Workflows: map[string]valid.Workflow{
+					"default": defaultCfg.Workflows["default"],
+				},
+				Roles: []valid.RoleBinding{
+					{Role: "viewer", Groups: []string{"developers"}},
+					{ID: "github.com/owner/repo", Role: "operator", Users: []string{"alice"}},
+				},
+			},
+		},
+		"invalid role": {
+			input: `
+roles:
+- role: owner
+  users: [alice]
+`,
+			expErr: `roles: (0: (role: invalid role "owner", must be one of "viewer", "operator" or "admin".).).`,
+		},
+		"lock_ttl": {
+			input: `
+repos:
+- id: github.com/owner/repo
+  lock_ttl: 2h30m
+`,
+			exp: valid.GlobalCfg{
+				Repos: []valid.Repo{
+					defaultCfg.Repos[0],
+					{
+						ID:      "github.com/owner/repo",
+						LockTTL: &lockTTL,
+					},
+				},
+				Workflows: map[string]valid.Workflow{
+					"default": defaultCfg.Workflows["default"],
+				},
+			},
+		},
+		"max_concurrent_projects": {
+			input: `
+repos:
+- id: github.com/owner/repo
+  max_concurrent_projects: 2
+`,
+			exp: valid.GlobalCfg{
+				Repos: []valid.Repo{
+					defaultCfg.Repos[0],
+					{
+						ID:                    "github.com/owner/repo",
+						MaxConcurrentProjects: &maxConcurrentProjects,
+					},
+				},
+				Workflows: map[string]valid.Workflow{
+					"default": defaultCfg.Workflows["default"],
+				},
+			},
+		},
+		"runner": {
+			input: `
+repos:
+- id: github.com/owner/repo
+  runner: prod-account
+`,
+			exp: valid.GlobalCfg{
+				Repos: []valid.Repo{
+					defaultCfg.Repos[0],
+					{
+						ID:     "github.com/owner/repo",
+						Runner: String("prod-account"),
+					},
+				},
+				Workflows: map[string]valid.Workflow{
+					"default": defaultCfg.Workflows["default"],
+				},
+			},
+		},
+		"env_policy": {
+			input: `
+env_policy:
+  allow: [AWS_*, HOME, PATH]
+  deny: [AWS_SECRET_ACCESS_KEY]
+`,
+			exp: valid.GlobalCfg{
+				Repos: defaultCfg.Repos,
+				Workflows: map[string]valid.Workflow{
+					"default": defaultCfg.Workflows["default"],
+				},
+				EnvPolicy: valid.EnvPolicy{
+					Allow: []string{"AWS_*", "HOME", "PATH"},
+					Deny:  []string{"AWS_SECRET_ACCESS_KEY"},
+				},
+			},
+		},
+		"workflows with extends and step templates": {
+			input: `
+step_templates:
+  scan:
+  - run: tfsec .
+  notify:
+  - template: scan
+  - run: ./notify.sh
+workflows:
+  base:
+    plan:
+      steps:
+      - init
+      - plan:
+          extra_args: [-lock=false]
+    apply:
+      merge: prepend
+      steps:
+      - run: ./pre-apply.sh
+  staging:
+    extends: base
+    plan:
+      merge: append
+      steps:
+      - template: notify
+  prod:
+    extends: staging
+    plan:
+      steps: [init, plan]
+`,
+			exp: valid.GlobalCfg{
+				Repos: defaultCfg.Repos,
+				Workflows: map[string]valid.Workflow{
+					"default": defaultCfg.Workflows["default"],
+					"base": {
+						Name: "base",
+						Plan: valid.Stage{Steps: []valid.Step{
+							{StepName: "init"},
+							{StepName: "plan", ExtraArgs: []string{"-lock=false"}},
+						}},
+						Apply: valid.Stage{Steps: []valid.Step{
+							{StepName: "run", RunCommand: "./pre-apply.sh"},
+							{StepName: "apply"},
+						}},
+						PolicyCheck: valid.DefaultPolicyCheckStage,
+						Import:      valid.DefaultImportStage,
+						StateRm:     valid.DefaultStateRmStage,
+					},
+					"staging": {
+						Name: "staging",
+						Plan: valid.Stage{Steps: []valid.Step{
+							{StepName: "init"},
+							{StepName: "plan", ExtraArgs: []string{"-lock=false"}},
+							{StepName: "run", RunCommand: "tfsec ."},
+							{StepName: "run", RunCommand: "./notify.sh"},
+						}},
+						Apply: valid.Stage{Steps: []valid.Step{
+							{StepName: "run", RunCommand: "./pre-apply.sh"},
+							{StepName: "apply"},
+						}},
+						PolicyCheck: valid.DefaultPolicyCheckStage,
+						Import:      valid.DefaultImportStage,
+						StateRm:     valid.DefaultStateRmStage,
+					},
+					"prod": {
+						Name: "prod",
+						Plan: valid.Stage{Steps: []valid.Step{
+							{StepName: "init"},
+							{StepName: "plan"},
+						}},
+						Apply: valid.Stage{Steps: []valid.Step{
+							{StepName: "run", RunCommand: "./pre-apply.sh"},
+							{StepName: "apply"},
+						}},
+						PolicyCheck: valid.DefaultPolicyCheckStage,
+						Import:      valid.DefaultImportStage,
+						StateRm:     valid.DefaultStateRmStage,
+					},
+				},
+			},
+		},
+		"custom commands and stages": {
+			input: `
+workflows:
+  base:
+    stages:
+      validate:
+        steps: [init]
+  staging:
+    extends: base
+    stages:
+      validate:
+        merge: append
+        steps:
+        - run: terraform validate
+      fmt-check:
+        steps:
+        - run: terraform fmt -check
+custom_commands:
+- name: validate
+  description: Runs terraform validate.
+  stage: validate
+  requirements: [mergeable]
+  roles: [operator]
+- name: fmt-check
+  stage: fmt-check
+  require_lock: true
+`,
+			exp: valid.GlobalCfg{
+				Repos: defaultCfg.Repos,
+				Workflows: map[string]valid.Workflow{
+					"default": defaultCfg.Workflows["default"],
+					"base": {
+						Name:        "base",
+						Plan:        valid.DefaultPlanStage,
+						Apply:       valid.DefaultApplyStage,
+						PolicyCheck: valid.DefaultPolicyCheckStage,
+						Import:      valid.DefaultImportStage,
+						StateRm:     valid.DefaultStateRmStage,
+						Stages: map[string]valid.Stage{
+							"validate": {Steps: []valid.Step{{StepName: "init"}}},
+						},
+					},
+					"staging": {
+						Name:        "staging",
+						Plan:        valid.DefaultPlanStage,
+						Apply:       valid.DefaultApplyStage,
+						PolicyCheck: valid.DefaultPolicyCheckStage,
+						Import:      valid.DefaultImportStage,
+						StateRm:     valid.DefaultStateRmStage,
+						Stages: map[string]valid.Stage{
+							"validate": {Steps: []valid.Step{
+								{StepName: "init"},
+								{StepName: "run", RunCommand: "terraform validate"},
+							}},
+							"fmt-check": {Steps: []valid.Step{
+								{StepName: "run", RunCommand: "terraform fmt -check"},
+							}},
+						},
+					},
+				},
+				CustomCommands: []valid.CustomCommand{
+					{
+						Name:         "validate",
+						Description:  "Runs terraform validate.",
+						Stage:        "validate",
+						Requirements: []string{"mergeable"},
+						Roles:        []string{"operator"},
+					},
+					{
+						Name:        "fmt-check",
+						Stage:       "fmt-check",
+						RequireLock: true,
+					},
+				},
+			},
+		},
 		"referencing default workflow": {
 			input: `
 repos:
@@ -0,0 +1,84 @@
+package raw
+
+import (
+	"fmt"
+	"regexp"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/runatlantis/atlantis/server/auth"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/events/command"
+)
+
+// customCommandNameRegex matches the names of custom commands and of the
+// custom stages of workflows.
+var customCommandNameRegex = regexp.MustCompile(`^[a-z][a-z0-9_-]*$`)
+
+// CustomCommand is the raw schema for a comment command, ex. atlantis
+// validate, that runs a stage of the project's workflow.
+type CustomCommand struct {
+	Name        string `yaml:"name" json:"name"`
+	Description string `yaml:"description" json:"description"`
+	// Stage is the name of the workflow stage to run. It's either one of the
+	// built-in stages, ex. plan, or one of the workflow's custom stages.
+	Stage        string   `yaml:"stage" json:"stage"`
+	Requirements []string `yaml:"requirements" json:"requirements"`
+	RequireLock  bool     `yaml:"require_lock" json:"require_lock"`
+	// Roles are the roles that can run the command. If empty, anyone who can
+	// comment on the pull request can.
+	Roles []string `yaml:"roles" json:"roles"`
+}
+
+func (c CustomCommand) Validate() error {
+	nameValid := func(value interface{}) error {
+		name := value.(string)
+		if !customCommandNameRegex.MatchString(name) {
+			return fmt.Errorf("%q must start with a lowercase letter and only contain lowercase letters, digits, dashes and underscores", name)
+		}
+		if name == "help" || name == command.PolicyCheck.String() {
+			return fmt.Errorf("%q is a built-in command", name)
+		}
+		for _, builtIn := range command.AllCommentCommands {
+			if name == builtIn.String() {
+				return fmt.Errorf("%q is a built-in command", name)
+			}
+		}
+		return nil
+	}
+
+	reqsValid := func(value interface{}) error {
+		for _, r := range value.([]string) {
+			if r != ApprovedRequirement && r != MergeableRequirement && r != UnDivergedRequirement {
+				return fmt.Errorf("%q is not a valid requirement, only %q, %q and %q are supported", r, ApprovedRequirement, MergeableRequirement, UnDivergedRequirement)
+			}
+		}
+		return nil
+	}
+
+	rolesValid := func(value interface{}) error {
+		for _, r := range value.([]string) {
+			if _, err := auth.ParseRole(r); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return validation.ValidateStruct(&c,
+		validation.Field(&c.Name, validation.Required, validation.By(nameValid)),
+		validation.Field(&c.Stage, validation.Required),
+		validation.Field(&c.Requirements, validation.By(reqsValid)),
+		validation.Field(&c.Roles, validation.By(rolesValid)),
+	)
+}
+
+func (c CustomCommand) ToValid() valid.CustomCommand {
+	return valid.CustomCommand{
+		Name:         c.Name,
+		Description:  c.Description,
+		Stage:        c.Stage,
+		Requirements: c.Requirements,
+		RequireLock:  c.RequireLock,
+		Roles:        c.Roles,
+	}
+}
@@ -0,0 +1,114 @@
+package raw_test
+
+import (
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/core/config/raw"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	. "github.com/runatlantis/atlantis/testing"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestCustomCommand_Unmarshal(t *testing.T) {
+	var c raw.CustomCommand
+	Ok(t, yaml.UnmarshalStrict([]byte(`
+name: validate
+description: Runs terraform validate.
+stage: validate
+requirements: [approved]
+require_lock: true
+roles: [operator]
+`), &c))
+	Equals(t, raw.CustomCommand{
+		Name:         "validate",
+		Description:  "Runs terraform validate.",
+		Stage:        "validate",
+		Requirements: []string{"approved"},
+		RequireLock:  true,
+		Roles:        []string{"operator"},
+	}, c)
+}
+
+func TestCustomCommand_Validate(t *testing.T) {
+	cases := []struct {
+		description string
+		input       raw.CustomCommand
+		expErr      string
+	}{
+		{
+			description: "minimal",
+			input:       raw.CustomCommand{Name: "validate", Stage: "validate"},
+		},
+		{
+			description: "all fields",
+			input: raw.CustomCommand{
+				Name:         "fmt-check",
+				Stage:        "plan",
+				Requirements: []string{"approved", "mergeable", "undiverged"},
+				RequireLock:  true,
+				Roles:        []string{"viewer", "admin"},
+			},
+		},
+		{
+			description: "missing name",
+			input:       raw.CustomCommand{Stage: "validate"},
+			expErr:      "name: cannot be blank.",
+		},
+		{
+			description: "invalid name",
+			input:       raw.CustomCommand{Name: "Validate", Stage: "validate"},
+			expErr:      `name: "Validate" must start with a lowercase letter and only contain lowercase letters, digits, dashes and underscores.`,
+		},
+		{
+			description: "built-in name",
+			input:       raw.CustomCommand{Name: "plan", Stage: "validate"},
+			expErr:      `name: "plan" is a built-in command.`,
+		},
+		{
+			description: "help",
+			input:       raw.CustomCommand{Name: "help", Stage: "validate"},
+			expErr:      `name: "help" is a built-in command.`,
+		},
+		{
+			description: "missing stage",
+			input:       raw.CustomCommand{Name: "validate"},
+			expErr:      "stage: cannot be blank.",
+		},
+		{
+			description: "invalid requirement",
+			input:       raw.CustomCommand{Name: "validate", Stage: "validate", Requirements: []string{"policies_passed"}},
+			expErr:      `requirements: "policies_passed" is not a valid requirement, only "approved", "mergeable" and "undiverged" are supported.`,
+		},
+		{
+			description: "invalid role",
+			input:       raw.CustomCommand{Name: "validate", Stage: "validate", Roles: []string{"owner"}},
+			expErr:      `roles: invalid role "owner", must be one of "viewer", "operator" or "admin".`,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			err := c.input.Validate()
+			if c.expErr == "" {
+				Ok(t, err)
+				return
+			}
+			ErrEquals(t, c.expErr, err)
+		})
+	}
+}
+
+func TestCustomCommand_ToValid(t *testing.T) {
+	Equals(t, valid.CustomCommand{
+		Name:         "validate",
+		Stage:        "validate",
+		Requirements: []string{"approved"},
+		RequireLock:  true,
+		Roles:        []string{"operator"},
+	}, raw.CustomCommand{
+		Name:         "validate",
+		Stage:        "validate",
+		Requirements: []string{"approved"},
+		RequireLock:  true,
+		Roles:        []string{"operator"},
+	}.ToValid())
+}
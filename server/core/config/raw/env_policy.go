@@ -0,0 +1,41 @@
+package raw
+
+import (
+	"path"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+)
+
+// EnvPolicy is the raw schema for the policy deciding which of the Atlantis
+// process's environment variables are inherited by the commands it runs.
+type EnvPolicy struct {
+	Allow []string `yaml:"allow" json:"allow"`
+	Deny  []string `yaml:"deny" json:"deny"`
+}
+
+func (p EnvPolicy) Validate() error {
+	patternsValid := func(value interface{}) error {
+		for _, pattern := range value.([]string) {
+			if pattern == "" {
+				return errors.New("patterns cannot be empty")
+			}
+			if _, err := path.Match(pattern, ""); err != nil {
+				return errors.Wrapf(err, "parsing: %s", pattern)
+			}
+		}
+		return nil
+	}
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.Allow, validation.By(patternsValid)),
+		validation.Field(&p.Deny, validation.By(patternsValid)),
+	)
+}
+
+func (p EnvPolicy) ToValid() valid.EnvPolicy {
+	return valid.EnvPolicy{
+		Allow: p.Allow,
+		Deny:  p.Deny,
+	}
+}
@@ -0,0 +1,65 @@
+package raw_test
+
+import (
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/core/config/raw"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	. "github.com/runatlantis/atlantis/testing"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestEnvPolicy_Unmarshal(t *testing.T) {
+	var p raw.EnvPolicy
+	Ok(t, yaml.UnmarshalStrict([]byte(`
+allow: [AWS_*, HOME, PATH]
+deny: [AWS_SECRET_ACCESS_KEY]
+`), &p))
+	Equals(t, raw.EnvPolicy{
+		Allow: []string{"AWS_*", "HOME", "PATH"},
+		Deny:  []string{"AWS_SECRET_ACCESS_KEY"},
+	}, p)
+}
+
+func TestEnvPolicy_Validate(t *testing.T) {
+	cases := []struct {
+		description string
+		input       raw.EnvPolicy
+		expErr      string
+	}{
+		{
+			description: "empty",
+		},
+		{
+			description: "patterns",
+			input:       raw.EnvPolicy{Allow: []string{"AWS_*", "HOME"}, Deny: []string{"AWS_SECRET_?CCESS_KEY"}},
+		},
+		{
+			description: "invalid pattern",
+			input:       raw.EnvPolicy{Deny: []string{"AWS_[*"}},
+			expErr:      "deny: parsing: AWS_[*: syntax error in pattern.",
+		},
+		{
+			description: "empty pattern",
+			input:       raw.EnvPolicy{Allow: []string{""}},
+			expErr:      "allow: patterns cannot be empty.",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			err := c.input.Validate()
+			if c.expErr == "" {
+				Ok(t, err)
+				return
+			}
+			ErrEquals(t, c.expErr, err)
+		})
+	}
+}
+
+func TestEnvPolicy_ToValid(t *testing.T) {
+	Equals(t, valid.EnvPolicy{
+		Allow: []string{"AWS_*"},
+		Deny:  []string{"AWS_SECRET_ACCESS_KEY"},
+	}, raw.EnvPolicy{Allow: []string{"AWS_*"}, Deny: []string{"AWS_SECRET_ACCESS_KEY"}}.ToValid())
+}
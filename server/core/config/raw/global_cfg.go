@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+	"time"
 
 	validation "github.com/go-ozzo/ozzo-validation"
 	"github.com/pkg/errors"
@@ -12,10 +13,14 @@ import (
 
 // GlobalCfg is the raw schema for server-side repo config.
 type GlobalCfg struct {
-	Repos      []Repo              `yaml:"repos" json:"repos"`
-	Workflows  map[string]Workflow `yaml:"workflows" json:"workflows"`
-	PolicySets PolicySets          `yaml:"policies" json:"policies"`
-	Metrics    Metrics             `yaml:"metrics" json:"metrics"`
+	Repos          []Repo                  `yaml:"repos" json:"repos"`
+	Workflows      map[string]Workflow     `yaml:"workflows" json:"workflows"`
+	StepTemplates  map[string]StepTemplate `yaml:"step_templates" json:"step_templates"`
+	PolicySets     PolicySets              `yaml:"policies" json:"policies"`
+	Metrics        Metrics                 `yaml:"metrics" json:"metrics"`
+	Roles          []RoleBinding           `yaml:"roles" json:"roles"`
+	EnvPolicy      EnvPolicy               `yaml:"env_policy" json:"env_policy"`
+	CustomCommands []CustomCommand         `yaml:"custom_commands" json:"custom_commands"`
 }
 
 // Repo is the raw schema for repos in the server-side repo config.
@@ -37,17 +42,35 @@ type Repo struct {
 	PolicyCheck               *bool          `yaml:"policy_check,omitempty" json:"policy_check,omitempty"`
 	CustomPolicyCheck         *bool          `yaml:"custom_policy_check,omitempty" json:"custom_policy_check,omitempty"`
 	AutoDiscover              *AutoDiscover  `yaml:"autodiscover,omitempty" json:"autodiscover,omitempty"`
+	ApplyMode                 *string        `yaml:"apply_mode,omitempty" json:"apply_mode,omitempty"`
+	LockTTL                   *string        `yaml:"lock_ttl,omitempty" json:"lock_ttl,omitempty"`
+	Runner                    *string        `yaml:"runner,omitempty" json:"runner,omitempty"`
+	MaxConcurrentProjects     *int           `yaml:"max_concurrent_projects,omitempty" json:"max_concurrent_projects,omitempty"`
 }
 
 func (g GlobalCfg) Validate() error {
 	err := validation.ValidateStruct(&g,
 		validation.Field(&g.Repos),
 		validation.Field(&g.Workflows),
+		validation.Field(&g.StepTemplates),
 		validation.Field(&g.Metrics),
+		validation.Field(&g.Roles),
+		validation.Field(&g.EnvPolicy),
+		validation.Field(&g.CustomCommands),
 	)
 	if err != nil {
 		return err
 	}
+	customCommands := make(map[string]bool)
+	for _, c := range g.CustomCommands {
+		if customCommands[c.Name] {
+			return fmt.Errorf("custom command %q is defined more than once", c.Name)
+		}
+		customCommands[c.Name] = true
+	}
+	if _, err := resolveWorkflows(g.Workflows, g.StepTemplates); err != nil {
+		return err
+	}
 
 	// Check that all workflows referenced by repos are actually defined.
 	for _, repo := range g.Repos {
@@ -112,8 +135,9 @@ func (g GlobalCfg) ToValid(defaultCfg valid.GlobalCfg) valid.GlobalCfg {
 	}
 	globalImportReqs := defaultCfg.Repos[0].ImportRequirements
 
-	for k, v := range g.Workflows {
-		validatedWorkflow := v.ToValid(k)
+	// Safe to ignore the error because we test it in Validate().
+	resolvedWorkflows, _ := resolveWorkflows(g.Workflows, g.StepTemplates)
+	for k, validatedWorkflow := range resolvedWorkflows {
 		workflows[k] = validatedWorkflow
 		if k == valid.DefaultWorkflowName {
 			// Handle the special case where they're redefining the default
@@ -135,11 +159,24 @@ func (g GlobalCfg) ToValid(defaultCfg valid.GlobalCfg) valid.GlobalCfg {
 	}
 	repos = append(defaultCfg.Repos, repos...)
 
+	var roles []valid.RoleBinding
+	for _, b := range g.Roles {
+		roles = append(roles, b.ToValid())
+	}
+
+	var customCommands []valid.CustomCommand
+	for _, c := range g.CustomCommands {
+		customCommands = append(customCommands, c.ToValid())
+	}
+
 	return valid.GlobalCfg{
-		Repos:      repos,
-		Workflows:  workflows,
-		PolicySets: g.PolicySets.ToValid(),
-		Metrics:    g.Metrics.ToValid(),
+		Repos:          repos,
+		Workflows:      workflows,
+		PolicySets:     g.PolicySets.ToValid(),
+		Metrics:        g.Metrics.ToValid(),
+		Roles:          roles,
+		EnvPolicy:      g.EnvPolicy.ToValid(),
+		CustomCommands: customCommands,
 	}
 }
 
@@ -194,8 +231,8 @@ func (r Repo) Validate() error {
 	overridesValid := func(value interface{}) error {
 		overrides := value.([]string)
 		for _, o := range overrides {
-			if o != valid.PlanRequirementsKey && o != valid.ApplyRequirementsKey && o != valid.ImportRequirementsKey && o != valid.WorkflowKey && o != valid.DeleteSourceBranchOnMergeKey && o != valid.RepoLockingKey && o != valid.PolicyCheckKey && o != valid.CustomPolicyCheckKey {
-				return fmt.Errorf("%q is not a valid override, only %q, %q, %q, %q, %q, %q, %q, and %q are supported", o, valid.PlanRequirementsKey, valid.ApplyRequirementsKey, valid.ImportRequirementsKey, valid.WorkflowKey, valid.DeleteSourceBranchOnMergeKey, valid.RepoLockingKey, valid.PolicyCheckKey, valid.CustomPolicyCheckKey)
+			if o != valid.PlanRequirementsKey && o != valid.ApplyRequirementsKey && o != valid.ImportRequirementsKey && o != valid.WorkflowKey && o != valid.DeleteSourceBranchOnMergeKey && o != valid.RepoLockingKey && o != valid.PolicyCheckKey && o != valid.CustomPolicyCheckKey && o != valid.ApplyModeKey && o != valid.RunnerKey {
+				return fmt.Errorf("%q is not a valid override, only %q, %q, %q, %q, %q, %q, %q, %q, %q, and %q are supported", o, valid.PlanRequirementsKey, valid.ApplyRequirementsKey, valid.ImportRequirementsKey, valid.WorkflowKey, valid.DeleteSourceBranchOnMergeKey, valid.RepoLockingKey, valid.PolicyCheckKey, valid.CustomPolicyCheckKey, valid.ApplyModeKey, valid.RunnerKey)
 			}
 		}
 		return nil
@@ -220,6 +257,14 @@ func (r Repo) Validate() error {
 		return nil
 	}
 
+	maxConcurrentProjectsValid := func(value interface{}) error {
+		max := value.(*int)
+		if max != nil && *max < 1 {
+			return errors.New("must be at least 1")
+		}
+		return nil
+	}
+
 	return validation.ValidateStruct(&r,
 		validation.Field(&r.ID, validation.Required, validation.By(idValid)),
 		validation.Field(&r.Branch, validation.By(branchValid)),
@@ -231,6 +276,10 @@ func (r Repo) Validate() error {
 		validation.Field(&r.Workflow, validation.By(workflowExists)),
 		validation.Field(&r.DeleteSourceBranchOnMerge, validation.By(deleteSourceBranchOnMergeValid)),
 		validation.Field(&r.AutoDiscover, validation.By(autoDiscoverValid)),
+		validation.Field(&r.ApplyMode, validation.By(validApplyMode)),
+		validation.Field(&r.LockTTL, validation.By(DurationValidator)),
+		validation.Field(&r.Runner, validation.By(validRunner)),
+		validation.Field(&r.MaxConcurrentProjects, validation.By(maxConcurrentProjectsValid)),
 	)
 }
 
@@ -330,6 +379,19 @@ OuterGlobalImportReqs:
 		autoDiscover = r.AutoDiscover.ToValid()
 	}
 
+	var applyMode *valid.ApplyMode
+	if r.ApplyMode != nil {
+		mode := valid.ApplyMode(*r.ApplyMode)
+		applyMode = &mode
+	}
+
+	var lockTTL *time.Duration
+	if r.LockTTL != nil {
+		// Safe to ignore the error because we test it in Validate().
+		ttl, _ := time.ParseDuration(*r.LockTTL)
+		lockTTL = &ttl
+	}
+
 	return valid.Repo{
 		ID:                        id,
 		IDRegex:                   idRegex,
@@ -349,5 +411,9 @@ OuterGlobalImportReqs:
 		PolicyCheck:               r.PolicyCheck,
 		CustomPolicyCheck:         r.CustomPolicyCheck,
 		AutoDiscover:              autoDiscover,
+		ApplyMode:                 applyMode,
+		LockTTL:                   lockTTL,
+		Runner:                    r.Runner,
+		MaxConcurrentProjects:     r.MaxConcurrentProjects,
 	}
 }
@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"regexp"
 	"strings"
+	"time"
 
 	validation "github.com/go-ozzo/ozzo-validation"
 	version "github.com/hashicorp/go-version"
@@ -37,6 +38,10 @@ type Project struct {
 	ExecutionOrderGroup       *int      `yaml:"execution_order_group,omitempty"`
 	PolicyCheck               *bool     `yaml:"policy_check,omitempty"`
 	CustomPolicyCheck         *bool     `yaml:"custom_policy_check,omitempty"`
+	ApplyMode                 *string   `yaml:"apply_mode,omitempty"`
+	PlanTimeout               *string   `yaml:"plan_timeout,omitempty"`
+	ApplyTimeout              *string   `yaml:"apply_timeout,omitempty"`
+	Runner                    *string   `yaml:"runner,omitempty"`
 }
 
 func (p Project) Validate() error {
@@ -88,6 +93,10 @@ func (p Project) Validate() error {
 		validation.Field(&p.DependsOn, validation.By(DependsOn)),
 		validation.Field(&p.Name, validation.By(validName)),
 		validation.Field(&p.Branch, validation.By(branchValid)),
+		validation.Field(&p.ApplyMode, validation.By(validApplyMode)),
+		validation.Field(&p.PlanTimeout, validation.By(DurationValidator)),
+		validation.Field(&p.ApplyTimeout, validation.By(DurationValidator)),
+		validation.Field(&p.Runner, validation.By(validRunner)),
 	)
 }
 
@@ -151,6 +160,21 @@ func (p Project) ToValid() valid.Project {
 		v.CustomPolicyCheck = p.CustomPolicyCheck
 	}
 
+	if p.ApplyMode != nil {
+		applyMode := valid.ApplyMode(*p.ApplyMode)
+		v.ApplyMode = &applyMode
+	}
+
+	// Safe to ignore the errors because we test them in Validate().
+	if p.PlanTimeout != nil {
+		v.PlanTimeout, _ = time.ParseDuration(*p.PlanTimeout)
+	}
+	if p.ApplyTimeout != nil {
+		v.ApplyTimeout, _ = time.ParseDuration(*p.ApplyTimeout)
+	}
+
+	v.Runner = p.Runner
+
 	return v
 }
 
@@ -192,3 +216,30 @@ func validImportReq(value interface{}) error {
 	}
 	return nil
 }
+
+func validApplyMode(value interface{}) error {
+	strPtr := value.(*string)
+	if strPtr == nil {
+		return nil
+	}
+	mode := valid.ApplyMode(*strPtr)
+	if mode != valid.BeforeMergeApplyMode && mode != valid.AfterMergeApplyMode {
+		return fmt.Errorf("%q is not a valid apply_mode, only %q and %q are supported", *strPtr, valid.BeforeMergeApplyMode, valid.AfterMergeApplyMode)
+	}
+	return nil
+}
+
+// runnerRegex matches the names of runners, which are also passed to
+// workers on the command line.
+var runnerRegex = regexp.MustCompile(`^[a-zA-Z0-9_.-]+$`)
+
+func validRunner(value interface{}) error {
+	strPtr := value.(*string)
+	if strPtr == nil {
+		return nil
+	}
+	if !runnerRegex.MatchString(*strPtr) {
+		return fmt.Errorf("%q is not a valid runner, it can only contain letters, numbers, '-', '_' and '.'", *strPtr)
+	}
+	return nil
+}
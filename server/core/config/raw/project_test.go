@@ -2,6 +2,7 @@ package raw_test
 
 import (
 	"testing"
+	"time"
 
 	validation "github.com/go-ozzo/ozzo-validation"
 	version "github.com/hashicorp/go-version"
@@ -316,6 +317,30 @@ func TestProject_Validate(t *testing.T) {
 			},
 			expErr: `name: "namewith\\" is not allowed: must contain only URL safe characters.`,
 		},
+		{
+			description: "timeouts",
+			input: raw.Project{
+				Dir:          String("."),
+				PlanTimeout:  String("30m"),
+				ApplyTimeout: String("2h"),
+			},
+		},
+		{
+			description: "invalid plan_timeout",
+			input: raw.Project{
+				Dir:         String("."),
+				PlanTimeout: String("30"),
+			},
+			expErr: `plan_timeout: time: missing unit in duration "30".`,
+		},
+		{
+			description: "negative apply_timeout",
+			input: raw.Project{
+				Dir:          String("."),
+				ApplyTimeout: String("-2h"),
+			},
+			expErr: "apply_timeout: must be greater than 0.",
+		},
 	}
 	validation.ErrorTag = "yaml"
 	for _, c := range cases {
@@ -370,6 +395,8 @@ func TestProject_ToValid(t *testing.T) {
 				ApplyRequirements:   []string{"approved"},
 				Name:                String("myname"),
 				ExecutionOrderGroup: Int(10),
+				PlanTimeout:         String("30m"),
+				ApplyTimeout:        String("2h"),
 			},
 			exp: valid.Project{
 				Dir:              ".",
@@ -383,6 +410,8 @@ func TestProject_ToValid(t *testing.T) {
 				ApplyRequirements:   []string{"approved"},
 				Name:                String("myname"),
 				ExecutionOrderGroup: 10,
+				PlanTimeout:         30 * time.Minute,
+				ApplyTimeout:        2 * time.Hour,
 			},
 		},
 		{
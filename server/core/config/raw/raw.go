@@ -4,6 +4,8 @@
 package raw
 
 import (
+	"time"
+
 	version "github.com/hashicorp/go-version"
 	"github.com/pkg/errors"
 )
@@ -18,3 +20,20 @@ func VersionValidator(value interface{}) error {
 	_, err := version.NewVersion(*strPtr)
 	return errors.Wrapf(err, "version %q could not be parsed", *strPtr)
 }
+
+// DurationValidator validates that a duration like 1h30m is greater than 0.
+// Function implements ozzo-validation::Rule.Validate interface.
+func DurationValidator(value interface{}) error {
+	strPtr := value.(*string)
+	if strPtr == nil {
+		return nil
+	}
+	d, err := time.ParseDuration(*strPtr)
+	if err != nil {
+		return err
+	}
+	if d <= 0 {
+		return errors.New("must be greater than 0")
+	}
+	return nil
+}
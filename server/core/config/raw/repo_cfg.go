@@ -15,18 +15,19 @@ const DefaultAbortOnExcecutionOrderFail = false
 
 // RepoCfg is the raw schema for repo-level atlantis.yaml config.
 type RepoCfg struct {
-	Version                    *int                `yaml:"version,omitempty"`
-	Projects                   []Project           `yaml:"projects,omitempty"`
-	Workflows                  map[string]Workflow `yaml:"workflows,omitempty"`
-	PolicySets                 PolicySets          `yaml:"policies,omitempty"`
-	AutoDiscover               *AutoDiscover       `yaml:"autodiscover,omitempty"`
-	Automerge                  *bool               `yaml:"automerge,omitempty"`
-	ParallelApply              *bool               `yaml:"parallel_apply,omitempty"`
-	ParallelPlan               *bool               `yaml:"parallel_plan,omitempty"`
-	DeleteSourceBranchOnMerge  *bool               `yaml:"delete_source_branch_on_merge,omitempty"`
-	EmojiReaction              *string             `yaml:"emoji_reaction,omitempty"`
-	AllowedRegexpPrefixes      []string            `yaml:"allowed_regexp_prefixes,omitempty"`
-	AbortOnExcecutionOrderFail *bool               `yaml:"abort_on_execution_order_fail,omitempty"`
+	Version                    *int                    `yaml:"version,omitempty"`
+	Projects                   []Project               `yaml:"projects,omitempty"`
+	Workflows                  map[string]Workflow     `yaml:"workflows,omitempty"`
+	StepTemplates              map[string]StepTemplate `yaml:"step_templates,omitempty"`
+	PolicySets                 PolicySets              `yaml:"policies,omitempty"`
+	AutoDiscover               *AutoDiscover           `yaml:"autodiscover,omitempty"`
+	Automerge                  *bool                   `yaml:"automerge,omitempty"`
+	ParallelApply              *bool                   `yaml:"parallel_apply,omitempty"`
+	ParallelPlan               *bool                   `yaml:"parallel_plan,omitempty"`
+	DeleteSourceBranchOnMerge  *bool                   `yaml:"delete_source_branch_on_merge,omitempty"`
+	EmojiReaction              *string                 `yaml:"emoji_reaction,omitempty"`
+	AllowedRegexpPrefixes      []string                `yaml:"allowed_regexp_prefixes,omitempty"`
+	AbortOnExcecutionOrderFail *bool                   `yaml:"abort_on_execution_order_fail,omitempty"`
 }
 
 func (r RepoCfg) Validate() error {
@@ -40,18 +41,22 @@ func (r RepoCfg) Validate() error {
 		}
 		return nil
 	}
-	return validation.ValidateStruct(&r,
+	err := validation.ValidateStruct(&r,
 		validation.Field(&r.Version, validation.By(equals2)),
 		validation.Field(&r.Projects),
 		validation.Field(&r.Workflows),
+		validation.Field(&r.StepTemplates),
 	)
+	if err != nil {
+		return err
+	}
+	_, err = resolveWorkflows(r.Workflows, r.StepTemplates)
+	return err
 }
 
 func (r RepoCfg) ToValid() valid.RepoCfg {
-	validWorkflows := make(map[string]valid.Workflow)
-	for k, v := range r.Workflows {
-		validWorkflows[k] = v.ToValid(k)
-	}
+	// Safe to ignore the error because we test it in Validate().
+	validWorkflows, _ := resolveWorkflows(r.Workflows, r.StepTemplates)
 
 	var validProjects []valid.Project
 	for _, p := range r.Projects {
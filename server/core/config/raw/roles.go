@@ -0,0 +1,75 @@
+package raw
+
+import (
+	"regexp"
+	"strings"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/auth"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+)
+
+// RoleBinding is the raw schema for granting a web UI role to users and
+// groups in the server-side repo config.
+type RoleBinding struct {
+	// ID is the repo ID, or a regex surrounded by slashes, that the role is
+	// granted on. If empty, the role is granted on all repos.
+	ID     string   `yaml:"id" json:"id"`
+	Role   string   `yaml:"role" json:"role"`
+	Users  []string `yaml:"users" json:"users"`
+	Groups []string `yaml:"groups" json:"groups"`
+}
+
+// HasRegexID returns true if b is configured with a regex id instead of an
+// exact match id.
+func (b RoleBinding) HasRegexID() bool {
+	return strings.HasPrefix(b.ID, "/") && strings.HasSuffix(b.ID, "/")
+}
+
+func (b RoleBinding) Validate() error {
+	idValid := func(value interface{}) error {
+		id := value.(string)
+		if !b.HasRegexID() {
+			return nil
+		}
+		_, err := regexp.Compile(id[1 : len(id)-1])
+		return errors.Wrapf(err, "parsing: %s", id)
+	}
+
+	roleValid := func(value interface{}) error {
+		_, err := auth.ParseRole(value.(string))
+		return err
+	}
+
+	subjectsValid := func(value interface{}) error {
+		if len(b.Users) == 0 && len(b.Groups) == 0 {
+			return errors.New("at least one of users or groups must be set")
+		}
+		return nil
+	}
+
+	return validation.ValidateStruct(&b,
+		validation.Field(&b.ID, validation.By(idValid)),
+		validation.Field(&b.Role, validation.Required, validation.By(roleValid)),
+		validation.Field(&b.Users, validation.By(subjectsValid)),
+	)
+}
+
+func (b RoleBinding) ToValid() valid.RoleBinding {
+	var id string
+	var idRegex *regexp.Regexp
+	if b.HasRegexID() {
+		// Safe to use MustCompile because we test it in Validate().
+		idRegex = regexp.MustCompile(b.ID[1 : len(b.ID)-1])
+	} else {
+		id = b.ID
+	}
+	return valid.RoleBinding{
+		ID:      id,
+		IDRegex: idRegex,
+		Role:    b.Role,
+		Users:   b.Users,
+		Groups:  b.Groups,
+	}
+}
@@ -0,0 +1,88 @@
+package raw_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/core/config/raw"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	. "github.com/runatlantis/atlantis/testing"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestRoleBinding_Unmarshal(t *testing.T) {
+	var b raw.RoleBinding
+	Ok(t, yaml.UnmarshalStrict([]byte(`
+id: /github.com/owner/.*/
+role: operator
+users: [alice]
+groups: [platform]
+`), &b))
+	Equals(t, raw.RoleBinding{
+		ID:     "/github.com/owner/.*/",
+		Role:   "operator",
+		Users:  []string{"alice"},
+		Groups: []string{"platform"},
+	}, b)
+}
+
+func TestRoleBinding_Validate(t *testing.T) {
+	cases := []struct {
+		description string
+		input       raw.RoleBinding
+		expErr      string
+	}{
+		{
+			description: "all repos",
+			input:       raw.RoleBinding{Role: "viewer", Groups: []string{"developers"}},
+		},
+		{
+			description: "exact id",
+			input:       raw.RoleBinding{ID: "github.com/owner/repo", Role: "admin", Users: []string{"alice"}},
+		},
+		{
+			description: "invalid id regex",
+			input:       raw.RoleBinding{ID: "/?/", Role: "admin", Users: []string{"alice"}},
+			expErr:      "id: parsing: /?/: error parsing regexp: missing argument to repetition operator: `?`.",
+		},
+		{
+			description: "missing role",
+			input:       raw.RoleBinding{Users: []string{"alice"}},
+			expErr:      "role: cannot be blank.",
+		},
+		{
+			description: "invalid role",
+			input:       raw.RoleBinding{Role: "owner", Users: []string{"alice"}},
+			expErr:      `role: invalid role "owner", must be one of "viewer", "operator" or "admin".`,
+		},
+		{
+			description: "no users or groups",
+			input:       raw.RoleBinding{Role: "viewer"},
+			expErr:      "users: at least one of users or groups must be set.",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			err := c.input.Validate()
+			if c.expErr == "" {
+				Ok(t, err)
+				return
+			}
+			ErrEquals(t, c.expErr, err)
+		})
+	}
+}
+
+func TestRoleBinding_ToValid(t *testing.T) {
+	Equals(t, valid.RoleBinding{
+		ID:     "github.com/owner/repo",
+		Role:   "viewer",
+		Groups: []string{"developers"},
+	}, raw.RoleBinding{ID: "github.com/owner/repo", Role: "viewer", Groups: []string{"developers"}}.ToValid())
+
+	Equals(t, valid.RoleBinding{
+		IDRegex: regexp.MustCompile("github.com/owner/.*"),
+		Role:    "admin",
+		Users:   []string{"alice"},
+	}, raw.RoleBinding{ID: "/github.com/owner/.*/", Role: "admin", Users: []string{"alice"}}.ToValid())
+}
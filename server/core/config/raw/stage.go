@@ -1,16 +1,32 @@
 package raw
 
 import (
+	"fmt"
+
 	validation "github.com/go-ozzo/ozzo-validation"
 	"github.com/runatlantis/atlantis/server/core/config/valid"
 )
 
+// Ways the steps of a stage are merged with the steps of the same stage in
+// the workflow it extends.
+const (
+	OverrideStageMerge = "override"
+	AppendStageMerge   = "append"
+	PrependStageMerge  = "prepend"
+)
+
 type Stage struct {
-	Steps []Step `yaml:"steps,omitempty" json:"steps,omitempty"`
+	// Merge is how Steps are merged with the steps of the same stage in the
+	// workflow that's extended, or the default stage if no workflow is. It
+	// defaults to OverrideStageMerge.
+	Merge *string `yaml:"merge,omitempty" json:"merge,omitempty"`
+	Steps []Step  `yaml:"steps,omitempty" json:"steps,omitempty"`
 }
 
 func (s Stage) Validate() error {
 	return validation.ValidateStruct(&s,
+		validation.Field(&s.Merge, validation.In(OverrideStageMerge, AppendStageMerge, PrependStageMerge).Error(
+			fmt.Sprintf("must be one of %q, %q or %q", OverrideStageMerge, AppendStageMerge, PrependStageMerge))),
 		validation.Field(&s.Steps),
 	)
 }
@@ -24,3 +40,20 @@ func (s Stage) ToValid() valid.Stage {
 		Steps: validSteps,
 	}
 }
+
+// merge returns steps merged with the steps of base, the same stage in the
+// workflow that's extended.
+func (s Stage) merge(base valid.Stage, steps []valid.Step) valid.Stage {
+	var merged []valid.Step
+	switch {
+	case s.Merge != nil && *s.Merge == AppendStageMerge:
+		merged = append(merged, base.Steps...)
+		merged = append(merged, steps...)
+	case s.Merge != nil && *s.Merge == PrependStageMerge:
+		merged = append(merged, steps...)
+		merged = append(merged, base.Steps...)
+	default:
+		merged = steps
+	}
+	return valid.Stage{Steps: merged}
+}
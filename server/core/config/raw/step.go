@@ -4,8 +4,10 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"regexp"
 	"sort"
 	"strings"
+	"time"
 
 	validation "github.com/go-ozzo/ozzo-validation"
 	"github.com/runatlantis/atlantis/server/core/config/valid"
@@ -17,6 +19,9 @@ const (
 	CommandArgKey       = "command"
 	ValueArgKey         = "value"
 	OutputArgKey        = "output"
+	TimeoutArgKey       = "timeout"
+	RetryArgKey         = "retry"
+	WhenArgKey          = "when"
 	RunStepName         = "run"
 	PlanStepName        = "plan"
 	ShowStepName        = "show"
@@ -27,6 +32,7 @@ const (
 	MultiEnvStepName    = "multienv"
 	ImportStepName      = "import"
 	StateRmStepName     = "state_rm"
+	TemplateStepName    = "template"
 )
 
 // Step represents a single action/command to perform. In YAML, it can be set as
@@ -51,6 +57,15 @@ const (
 // 4. A map for a custom run command:
 //   - run: my custom command
 //
+// or for a reference to a step template, which is replaced by the template's
+// steps when the workflow is resolved:
+//   - template: my-template
+//
+// Steps in forms #2 and #3 can also set options that apply to all steps:
+//   - plan:
+//     extra_args: [-var-file=staging.tfvars]
+//     timeout: 30m
+//
 // Here we parse step in the most generic fashion possible. See fields for more
 // details.
 type Step struct {
@@ -63,6 +78,123 @@ type Step struct {
 	Map map[string]map[string][]string
 	// StringVal will be set in case #4 above.
 	StringVal map[string]string
+	// Options will be set in cases #2 and #3 above if the step sets any of
+	// the options that apply to all steps.
+	Options *StepOptions
+}
+
+// StepOptions are the options that apply to all steps.
+type StepOptions struct {
+	// Timeout is how long the step can run before it's killed.
+	Timeout *string `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+	// Retry is how the step is re-run if it fails.
+	Retry *StepRetry `yaml:"retry,omitempty" json:"retry,omitempty"`
+	// When is an expression that must be true for the step to run.
+	When *string `yaml:"when,omitempty" json:"when,omitempty"`
+}
+
+// stepOptionKeys are the keys of StepOptions.
+var stepOptionKeys = []string{TimeoutArgKey, RetryArgKey, WhenArgKey}
+
+func (o StepOptions) Validate() error {
+	return validation.ValidateStruct(&o,
+		validation.Field(&o.Timeout, validation.By(DurationValidator)),
+		validation.Field(&o.Retry),
+		validation.Field(&o.When, validation.By(stepConditionValidator)),
+	)
+}
+
+// StepRetry is the retry policy of a step, ex.
+//
+//	retry:
+//	  attempts: 3
+//	  delay: 10s
+//	  backoff: exponential
+//	  on_output_regex: "429 Too Many Requests"
+type StepRetry struct {
+	// Attempts is how many times the step is run at most, including the
+	// first run.
+	Attempts int `yaml:"attempts" json:"attempts"`
+	// Delay is how long to wait before the second attempt.
+	Delay *string `yaml:"delay,omitempty" json:"delay,omitempty"`
+	// Backoff is either constant or exponential. If exponential, the delay
+	// doubles after each attempt.
+	Backoff *string `yaml:"backoff,omitempty" json:"backoff,omitempty"`
+	// OnOutputRegex limits retries to failures whose output matches it.
+	OnOutputRegex *string `yaml:"on_output_regex,omitempty" json:"on_output_regex,omitempty"`
+}
+
+func (r StepRetry) Validate() error {
+	return validation.ValidateStruct(&r,
+		validation.Field(&r.Attempts, validation.Required.Error("must be set"), validation.Min(1), validation.Max(valid.MaxStepRetryAttempts)),
+		validation.Field(&r.Delay, validation.By(DurationValidator), validation.By(retryDelayValidator)),
+		validation.Field(&r.Backoff, validation.In(valid.ConstantBackoff, valid.ExponentialBackoff).Error(
+			fmt.Sprintf("must be %q or %q", valid.ConstantBackoff, valid.ExponentialBackoff))),
+		validation.Field(&r.OnOutputRegex, validation.By(regexValidator)),
+	)
+}
+
+// ToValid returns the valid representation of the retry policy.
+func (r StepRetry) ToValid() *valid.StepRetry {
+	v := &valid.StepRetry{
+		Attempts: r.Attempts,
+		Backoff:  valid.ConstantBackoff,
+	}
+	// Safe to ignore the errors because we test them in Validate().
+	if r.Delay != nil {
+		v.Delay, _ = time.ParseDuration(*r.Delay)
+	}
+	if r.Backoff != nil {
+		v.Backoff = *r.Backoff
+	}
+	if r.OnOutputRegex != nil {
+		v.OnOutputRegex, _ = regexp.Compile(*r.OnOutputRegex)
+	}
+	return v
+}
+
+func retryDelayValidator(value interface{}) error {
+	strPtr := value.(*string)
+	if strPtr == nil {
+		return nil
+	}
+	// Safe to ignore the error because DurationValidator checks it first.
+	if d, _ := time.ParseDuration(*strPtr); d > valid.MaxStepRetryDelay {
+		return fmt.Errorf("must be at most %s", valid.MaxStepRetryDelay)
+	}
+	return nil
+}
+
+func stepConditionValidator(value interface{}) error {
+	strPtr := value.(*string)
+	if strPtr == nil {
+		return nil
+	}
+	_, err := valid.ParseStepCondition(*strPtr)
+	return err
+}
+
+func regexValidator(value interface{}) error {
+	strPtr := value.(*string)
+	if strPtr == nil {
+		return nil
+	}
+	_, err := regexp.Compile(*strPtr)
+	return err
+}
+
+// applyTo sets the options on step.
+func (o StepOptions) applyTo(step *valid.Step) {
+	if o.Timeout != nil {
+		// Safe to ignore the error because we test it in Validate().
+		step.Timeout, _ = time.ParseDuration(*o.Timeout)
+	}
+	if o.Retry != nil {
+		step.Retry = o.Retry.ToValid()
+	}
+	if o.When != nil {
+		step.When = *o.When
+	}
 }
 
 func (s *Step) UnmarshalYAML(unmarshal func(interface{}) error) error {
@@ -238,13 +370,18 @@ func (s Step) Validate() error {
 				len(keys), strings.Join(keys, ","))
 		}
 		for stepName := range elem {
-			if stepName != RunStepName && stepName != MultiEnvStepName {
+			if stepName != RunStepName && stepName != MultiEnvStepName && stepName != TemplateStepName {
 				return fmt.Errorf("%q is not a valid step type", stepName)
 			}
 		}
 		return nil
 	}
 
+	if s.Options != nil {
+		if err := s.Options.Validate(); err != nil {
+			return err
+		}
+	}
 	if s.Key != nil {
 		return validation.Validate(s.Key, validation.By(validStep))
 	}
@@ -260,7 +397,22 @@ func (s Step) Validate() error {
 	return errors.New("step element is empty")
 }
 
+// templateName returns the name of the step template the step refers to, if
+// it refers to one.
+func (s Step) templateName() (string, bool) {
+	name, ok := s.StringVal[TemplateStepName]
+	return name, ok
+}
+
 func (s Step) ToValid() valid.Step {
+	step := s.toValid()
+	if s.Options != nil {
+		s.Options.applyTo(&step)
+	}
+	return step
+}
+
+func (s Step) toValid() valid.Step {
 	// This will trigger in case #1 (see Step docs).
 	if s.Key != nil {
 		return valid.Step{
@@ -323,6 +475,32 @@ func (s Step) ToValid() valid.Step {
 // It takes a parameter unmarshal that is a function that tries to unmarshal
 // the current element into a given object.
 func (s *Step) unmarshalGeneric(unmarshal func(interface{}) error) error {
+	// Options can be set on steps of any of the map forms, ex.
+	//   plan:
+	//     extra_args: [a, b]
+	//     timeout: 10m
+	// so we take them out and then unmarshal the rest of the step.
+	var withOptions map[string]map[string]interface{}
+	if err := unmarshal(&withOptions); err == nil && len(withOptions) == 1 {
+		for stepName, args := range withOptions {
+			options := make(map[string]interface{})
+			for _, k := range stepOptionKeys {
+				if v, ok := args[k]; ok {
+					options[k] = v
+					delete(args, k)
+				}
+			}
+			if len(options) == 0 {
+				break
+			}
+			if err := unmarshalJSONRoundTrip(options, &s.Options); err != nil {
+				return err
+			}
+			return s.unmarshalGeneric(func(i interface{}) error {
+				return unmarshalJSONRoundTrip(map[string]interface{}{stepName: args}, i)
+			})
+		}
+	}
 
 	// First try to unmarshal as a single string, ex.
 	// steps:
@@ -375,6 +553,32 @@ func (s *Step) unmarshalGeneric(unmarshal func(interface{}) error) error {
 }
 
 func (s Step) marshalGeneric() (interface{}, error) {
+	if s.Options != nil && (len(s.Map) != 0 || len(s.EnvOrRun) != 0) {
+		// Put the options back alongside the step's args.
+		withOptions := make(map[string]map[string]interface{})
+		for stepName, args := range s.Map {
+			withOptions[stepName] = make(map[string]interface{})
+			for k, v := range args {
+				withOptions[stepName][k] = v
+			}
+		}
+		for stepName, args := range s.EnvOrRun {
+			withOptions[stepName] = make(map[string]interface{})
+			for k, v := range args {
+				withOptions[stepName][k] = v
+			}
+		}
+		var options map[string]interface{}
+		if err := unmarshalJSONRoundTrip(s.Options, &options); err != nil {
+			return nil, err
+		}
+		for stepName := range withOptions {
+			for k, v := range options {
+				withOptions[stepName][k] = v
+			}
+		}
+		return withOptions, nil
+	}
 	if len(s.StringVal) != 0 {
 		return s.StringVal, nil
 	} else if len(s.Map) != 0 {
@@ -389,3 +593,41 @@ func (s Step) marshalGeneric() (interface{}, error) {
 	// unexpected behavior.
 	return nil, nil
 }
+
+// unmarshalJSONRoundTrip unmarshals in, which was unmarshalled from YAML or
+// JSON, into out by marshalling it to JSON.
+func unmarshalJSONRoundTrip(in interface{}, out interface{}) error {
+	bytes, err := json.Marshal(jsonCompatible(in))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(bytes, out)
+}
+
+// jsonCompatible converts the map[interface{}]interface{} values that YAML
+// unmarshals maps into to map[string]interface{} so they can be marshalled
+// to JSON.
+func jsonCompatible(in interface{}) interface{} {
+	switch v := in.(type) {
+	case map[interface{}]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, val := range v {
+			out[fmt.Sprint(k)] = jsonCompatible(val)
+		}
+		return out
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, val := range v {
+			out[k] = jsonCompatible(val)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, val := range v {
+			out[i] = jsonCompatible(val)
+		}
+		return out
+	default:
+		return v
+	}
+}
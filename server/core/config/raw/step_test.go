@@ -1,7 +1,10 @@
 package raw_test
 
 import (
+	"encoding/json"
+	"regexp"
 	"testing"
+	"time"
 
 	"github.com/runatlantis/atlantis/server/core/config/raw"
 	"github.com/runatlantis/atlantis/server/core/config/valid"
@@ -141,6 +144,86 @@ key: value`,
 			},
 		},
 
+		// Options
+		{
+			description: "extra_args style with timeout",
+			input: `
+plan:
+  extra_args: [-lock=false]
+  timeout: 10m`,
+			exp: raw.Step{
+				Map: MapType{
+					"plan": {
+						"extra_args": {"-lock=false"},
+					},
+				},
+				Options: &raw.StepOptions{Timeout: String("10m")},
+			},
+		},
+		{
+			description: "built-in step with only timeout",
+			input: `
+apply:
+  timeout: 1h`,
+			exp: raw.Step{
+				Map: MapType{
+					"apply": {},
+				},
+				Options: &raw.StepOptions{Timeout: String("1h")},
+			},
+		},
+		{
+			description: "run step with timeout",
+			input: `
+run:
+  command: sleep 1
+  timeout: 1m`,
+			exp: raw.Step{
+				EnvOrRun: EnvOrRunType{
+					"run": {
+						"command": "sleep 1",
+					},
+				},
+				Options: &raw.StepOptions{Timeout: String("1m")},
+			},
+		},
+		{
+			description: "init step with retry",
+			input: `
+init:
+  retry:
+    attempts: 3
+    delay: 10s
+    backoff: exponential
+    on_output_regex: "429"`,
+			exp: raw.Step{
+				Map: MapType{
+					"init": {},
+				},
+				Options: &raw.StepOptions{Retry: &raw.StepRetry{
+					Attempts:      3,
+					Delay:         String("10s"),
+					Backoff:       String("exponential"),
+					OnOutputRegex: String("429"),
+				}},
+			},
+		},
+		{
+			description: "run step with when",
+			input: `
+run:
+  command: ./notify.sh
+  when: workspace == "prod"`,
+			exp: raw.Step{
+				EnvOrRun: EnvOrRunType{
+					"run": {
+						"command": "./notify.sh",
+					},
+				},
+				Options: &raw.StepOptions{When: String(`workspace == "prod"`)},
+			},
+		},
+
 		// Errors
 		{
 			description: "extra args style no slice strings",
@@ -386,6 +469,115 @@ func TestStep_Validate(t *testing.T) {
 			},
 			expErr: "env steps only support one of the \"value\" or \"command\" keys, found both",
 		},
+		{
+			description: "step with timeout",
+			input: raw.Step{
+				Map: MapType{
+					"plan": {},
+				},
+				Options: &raw.StepOptions{Timeout: String("30m")},
+			},
+		},
+		{
+			description: "invalid timeout",
+			input: raw.Step{
+				Map: MapType{
+					"plan": {},
+				},
+				Options: &raw.StepOptions{Timeout: String("30")},
+			},
+			expErr: "timeout: time: missing unit in duration \"30\".",
+		},
+		{
+			description: "negative timeout",
+			input: raw.Step{
+				EnvOrRun: EnvOrRunType{
+					"run": {
+						"command": "sleep 1",
+					},
+				},
+				Options: &raw.StepOptions{Timeout: String("-1m")},
+			},
+			expErr: "timeout: must be greater than 0.",
+		},
+		{
+			description: "step with retry",
+			input: raw.Step{
+				Map: MapType{
+					"init": {},
+				},
+				Options: &raw.StepOptions{Retry: &raw.StepRetry{Attempts: 3, Delay: String("10s"), Backoff: String("constant")}},
+			},
+		},
+		{
+			description: "retry without attempts",
+			input: raw.Step{
+				Map: MapType{
+					"init": {},
+				},
+				Options: &raw.StepOptions{Retry: &raw.StepRetry{Delay: String("10s")}},
+			},
+			expErr: "retry: (attempts: must be set.).",
+		},
+		{
+			description: "retry with invalid backoff",
+			input: raw.Step{
+				Map: MapType{
+					"init": {},
+				},
+				Options: &raw.StepOptions{Retry: &raw.StepRetry{Attempts: 3, Backoff: String("linear")}},
+			},
+			expErr: "retry: (backoff: must be \"constant\" or \"exponential\".).",
+		},
+		{
+			description: "retry with invalid regex",
+			input: raw.Step{
+				Map: MapType{
+					"init": {},
+				},
+				Options: &raw.StepOptions{Retry: &raw.StepRetry{Attempts: 3, OnOutputRegex: String("(")}},
+			},
+			expErr: "retry: (on_output_regex: error parsing regexp: missing closing ): `(`.).",
+		},
+		{
+			description: "retry with too many attempts",
+			input: raw.Step{
+				Map: MapType{
+					"init": {},
+				},
+				Options: &raw.StepOptions{Retry: &raw.StepRetry{Attempts: 11}},
+			},
+			expErr: "retry: (attempts: must be no greater than 10.).",
+		},
+		{
+			description: "retry with too long a delay",
+			input: raw.Step{
+				Map: MapType{
+					"init": {},
+				},
+				Options: &raw.StepOptions{Retry: &raw.StepRetry{Attempts: 3, Delay: String("11m")}},
+			},
+			expErr: "retry: (delay: must be at most 10m0s.).",
+		},
+		{
+			description: "step with when",
+			input: raw.Step{
+				Map: MapType{
+					"apply": {},
+				},
+				Options: &raw.StepOptions{When: String(`contains(pull_labels, "deploy")`)},
+			},
+		},
+		{
+			description: "when with unknown variable",
+			input: raw.Step{
+				Map: MapType{
+					"apply": {},
+				},
+				Options: &raw.StepOptions{When: String(`env == "prod"`)},
+			},
+			expErr: "when: unknown variable \"env\", must be one of workspace, project_name, repo_rel_dir, base_branch, head_branch, comment_args, pull_labels, steps.",
+		},
 		{
 			// For atlantis.yaml v2, this wouldn't parse, but now there should
 			// be no error.
@@ -574,6 +766,58 @@ func TestStep_ToValid(t *testing.T) {
 				Output:     "hide",
 			},
 		},
+		{
+			description: "run step with timeout",
+			input: raw.Step{
+				EnvOrRun: EnvOrRunType{
+					"run": {
+						"command": "my 'run command'",
+					},
+				},
+				Options: &raw.StepOptions{Timeout: String("1h30m")},
+			},
+			exp: valid.Step{
+				StepName:   "run",
+				RunCommand: "my 'run command'",
+				Output:     "show",
+				Timeout:    90 * time.Minute,
+			},
+		},
+		{
+			description: "init step with retry",
+			input: raw.Step{
+				Map: MapType{
+					"init": {},
+				},
+				Options: &raw.StepOptions{Retry: &raw.StepRetry{Attempts: 3, Delay: String("10s"), OnOutputRegex: String("429")}},
+			},
+			exp: valid.Step{
+				StepName: "init",
+				Retry: &valid.StepRetry{
+					Attempts:      3,
+					Delay:         10 * time.Second,
+					Backoff:       valid.ConstantBackoff,
+					OnOutputRegex: regexp.MustCompile("429"),
+				},
+			},
+		},
+		{
+			description: "run step with when",
+			input: raw.Step{
+				EnvOrRun: EnvOrRunType{
+					"run": {
+						"command": "./notify.sh",
+					},
+				},
+				Options: &raw.StepOptions{When: String(`workspace == "prod"`)},
+			},
+			exp: valid.Step{
+				StepName:   "run",
+				RunCommand: "./notify.sh",
+				Output:     "show",
+				When:       `workspace == "prod"`,
+			},
+		},
 	}
 	for _, c := range cases {
 		t.Run(c.description, func(t *testing.T) {
@@ -582,5 +826,29 @@ func TestStep_ToValid(t *testing.T) {
 	}
 }
 
+func TestStep_MarshalOptions(t *testing.T) {
+	step := raw.Step{
+		Map: MapType{
+			"plan": {
+				"extra_args": {"-lock=false"},
+			},
+		},
+		Options: &raw.StepOptions{Timeout: String("10m")},
+	}
+	out, err := yaml.Marshal(step)
+	Ok(t, err)
+	Equals(t, "plan:\n  extra_args:\n  - -lock=false\n  timeout: 10m\n", string(out))
+
+	var got raw.Step
+	Ok(t, yaml.UnmarshalStrict(out, &got))
+	Equals(t, step, got)
+
+	jsonOut, err := json.Marshal(&step)
+	Ok(t, err)
+	got = raw.Step{}
+	Ok(t, json.Unmarshal(jsonOut, &got))
+	Equals(t, step, got)
+}
+
 type MapType map[string]map[string][]string
 type EnvOrRunType map[string]map[string]string
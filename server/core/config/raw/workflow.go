@@ -1,25 +1,46 @@
 package raw
 
 import (
+	"fmt"
+	"sort"
+	"strings"
+
 	validation "github.com/go-ozzo/ozzo-validation"
 	"github.com/runatlantis/atlantis/server/core/config/valid"
 )
 
 type Workflow struct {
-	Apply       *Stage `yaml:"apply,omitempty" json:"apply,omitempty"`
-	Plan        *Stage `yaml:"plan,omitempty" json:"plan,omitempty"`
-	PolicyCheck *Stage `yaml:"policy_check,omitempty" json:"policy_check,omitempty"`
-	Import      *Stage `yaml:"import,omitempty" json:"import,omitempty"`
-	StateRm     *Stage `yaml:"state_rm,omitempty" json:"state_rm,omitempty"`
+	// Extends is the name of the workflow whose stages this workflow
+	// inherits. See Stage.Merge for how they're merged.
+	Extends     *string `yaml:"extends,omitempty" json:"extends,omitempty"`
+	Apply       *Stage  `yaml:"apply,omitempty" json:"apply,omitempty"`
+	Plan        *Stage  `yaml:"plan,omitempty" json:"plan,omitempty"`
+	PolicyCheck *Stage  `yaml:"policy_check,omitempty" json:"policy_check,omitempty"`
+	Import      *Stage  `yaml:"import,omitempty" json:"import,omitempty"`
+	StateRm     *Stage  `yaml:"state_rm,omitempty" json:"state_rm,omitempty"`
+	// Stages are custom stages that are run by custom commands.
+	Stages map[string]Stage `yaml:"stages,omitempty" json:"stages,omitempty"`
 }
 
 func (w Workflow) Validate() error {
+	stageNamesValid := func(value interface{}) error {
+		for name := range value.(map[string]Stage) {
+			if !customCommandNameRegex.MatchString(name) {
+				return fmt.Errorf("%q must start with a lowercase letter and only contain lowercase letters, digits, dashes and underscores", name)
+			}
+			if _, builtIn := (valid.Workflow{}).Stage(name); builtIn {
+				return fmt.Errorf("%q is a built-in stage, configure it with the %s key instead", name, name)
+			}
+		}
+		return nil
+	}
 	return validation.ValidateStruct(&w,
 		validation.Field(&w.Apply),
 		validation.Field(&w.Plan),
 		validation.Field(&w.PolicyCheck),
 		validation.Field(&w.Import),
 		validation.Field(&w.StateRm),
+		validation.Field(&w.Stages, validation.By(stageNamesValid)),
 	)
 }
 
@@ -41,6 +62,185 @@ func (w Workflow) ToValid(name string) valid.Workflow {
 	v.PolicyCheck = w.toValidStage(w.PolicyCheck, valid.DefaultPolicyCheckStage)
 	v.Import = w.toValidStage(w.Import, valid.DefaultImportStage)
 	v.StateRm = w.toValidStage(w.StateRm, valid.DefaultStateRmStage)
+	for name, stage := range w.Stages {
+		if v.Stages == nil {
+			v.Stages = make(map[string]valid.Stage)
+		}
+		v.Stages[name] = stage.ToValid()
+	}
 
 	return v
 }
+
+// StepTemplate is a named list of steps that stages can include with a
+// template step, ex.
+//
+//	step_templates:
+//	  security-scan:
+//	  - run: tfsec .
+//	workflows:
+//	  default:
+//	    plan:
+//	      steps: [init, plan, template: security-scan]
+type StepTemplate []Step
+
+func (t StepTemplate) Validate() error {
+	return validation.Validate([]Step(t))
+}
+
+// workflowResolver resolves the workflows of a config file into valid
+// workflows by merging in the workflows they extend and replacing template
+// steps with the steps of their templates.
+type workflowResolver struct {
+	workflows map[string]Workflow
+	templates map[string]StepTemplate
+	resolved  map[string]valid.Workflow
+	// resolving are the workflows being resolved, used to detect cycles.
+	resolving []string
+}
+
+// resolveWorkflows returns the resolved valid workflows. It returns an error
+// if a workflow extends a workflow that isn't defined, if a step refers to a
+// step template that isn't defined, or if there's a cycle.
+func resolveWorkflows(workflows map[string]Workflow, templates map[string]StepTemplate) (map[string]valid.Workflow, error) {
+	r := &workflowResolver{
+		workflows: workflows,
+		templates: templates,
+		resolved:  make(map[string]valid.Workflow),
+	}
+	// Sort so errors are deterministic.
+	var names []string
+	for name := range workflows {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var templateNames []string
+	for name := range templates {
+		templateNames = append(templateNames, name)
+	}
+	sort.Strings(templateNames)
+	for _, name := range templateNames {
+		// Expand the templates that aren't used too so that their errors
+		// aren't only found when they're first used.
+		if _, err := r.expandTemplates([]Step{{StringVal: map[string]string{TemplateStepName: name}}}, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	resolved := make(map[string]valid.Workflow)
+	for _, name := range names {
+		w, err := r.resolve(name)
+		if err != nil {
+			return nil, err
+		}
+		resolved[name] = w
+	}
+	return resolved, nil
+}
+
+func (r *workflowResolver) resolve(name string) (valid.Workflow, error) {
+	if w, ok := r.resolved[name]; ok {
+		return w, nil
+	}
+	for i, n := range r.resolving {
+		if n == name {
+			cycle := append(append([]string{}, r.resolving[i:]...), name)
+			return valid.Workflow{}, fmt.Errorf("workflows extend each other in a cycle: %s", strings.Join(cycle, " -> "))
+		}
+	}
+	r.resolving = append(r.resolving, name)
+	defer func() { r.resolving = r.resolving[:len(r.resolving)-1] }()
+
+	w := r.workflows[name]
+	// If the workflow doesn't extend another workflow, its stages are merged
+	// with the default stages.
+	parent := Workflow{}.ToValid(name)
+	if w.Extends != nil {
+		if _, ok := r.workflows[*w.Extends]; !ok && *w.Extends != valid.DefaultWorkflowName {
+			return valid.Workflow{}, fmt.Errorf("workflow %q extends workflow %q which is not defined", name, *w.Extends)
+		}
+		var err error
+		if parent, err = r.resolve(*w.Extends); err != nil {
+			return valid.Workflow{}, err
+		}
+	}
+
+	resolved := valid.Workflow{Name: name}
+	stages := []struct {
+		raw    *Stage
+		parent valid.Stage
+		valid  *valid.Stage
+	}{
+		{w.Apply, parent.Apply, &resolved.Apply},
+		{w.Plan, parent.Plan, &resolved.Plan},
+		{w.PolicyCheck, parent.PolicyCheck, &resolved.PolicyCheck},
+		{w.Import, parent.Import, &resolved.Import},
+		{w.StateRm, parent.StateRm, &resolved.StateRm},
+	}
+	for _, stage := range stages {
+		if stage.raw == nil || stage.raw.Steps == nil {
+			*stage.valid = stage.parent
+			continue
+		}
+		steps, err := r.expandTemplates(stage.raw.Steps, nil)
+		if err != nil {
+			return valid.Workflow{}, err
+		}
+		*stage.valid = stage.raw.merge(stage.parent, Stage{Steps: steps}.ToValid().Steps)
+	}
+
+	// Custom stages are merged with the parent's custom stage of the same
+	// name, if it has one.
+	for stageName, stage := range parent.Stages {
+		if resolved.Stages == nil {
+			resolved.Stages = make(map[string]valid.Stage)
+		}
+		resolved.Stages[stageName] = stage
+	}
+	for stageName, stage := range w.Stages {
+		if resolved.Stages == nil {
+			resolved.Stages = make(map[string]valid.Stage)
+		}
+		if stage.Steps == nil {
+			resolved.Stages[stageName] = parent.Stages[stageName]
+			continue
+		}
+		steps, err := r.expandTemplates(stage.Steps, nil)
+		if err != nil {
+			return valid.Workflow{}, err
+		}
+		resolved.Stages[stageName] = stage.merge(parent.Stages[stageName], Stage{Steps: steps}.ToValid().Steps)
+	}
+	r.resolved[name] = resolved
+	return resolved, nil
+}
+
+// expandTemplates returns steps with template steps replaced by the steps of
+// their templates. expanding are the templates being expanded, used to
+// detect cycles.
+func (r *workflowResolver) expandTemplates(steps []Step, expanding []string) ([]Step, error) {
+	expanded := []Step{}
+	for _, step := range steps {
+		name, ok := step.templateName()
+		if !ok {
+			expanded = append(expanded, step)
+			continue
+		}
+		for i, n := range expanding {
+			if n == name {
+				cycle := append(append([]string{}, expanding[i:]...), name)
+				return nil, fmt.Errorf("step templates include each other in a cycle: %s", strings.Join(cycle, " -> "))
+			}
+		}
+		template, ok := r.templates[name]
+		if !ok {
+			return nil, fmt.Errorf("step template %q is not defined", name)
+		}
+		templateSteps, err := r.expandTemplates(template, append(append([]string{}, expanding...), name))
+		if err != nil {
+			return nil, err
+		}
+		expanded = append(expanded, templateSteps...)
+	}
+	return expanded, nil
+}
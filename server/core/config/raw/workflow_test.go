@@ -133,6 +133,13 @@ func TestWorkflow_Validate(t *testing.T) {
 
 	// Unset keys should validate.
 	Ok(t, (raw.Workflow{}).Validate())
+
+	// Custom stages can't be named after built-in stages.
+	w = raw.Workflow{Stages: map[string]raw.Stage{"plan": {}}}
+	ErrEquals(t, "stages: \"plan\" is a built-in stage, configure it with the plan key instead.", w.Validate())
+	w = raw.Workflow{Stages: map[string]raw.Stage{"Validate": {}}}
+	ErrEquals(t, "stages: \"Validate\" must start with a lowercase letter and only contain lowercase letters, digits, dashes and underscores.", w.Validate())
+	Ok(t, raw.Workflow{Stages: map[string]raw.Stage{"fmt-check": {}}}.Validate())
 }
 
 func TestWorkflow_ToValid(t *testing.T) {
@@ -190,6 +197,15 @@ func TestWorkflow_ToValid(t *testing.T) {
 						},
 					},
 				},
+				Stages: map[string]raw.Stage{
+					"validate": {
+						Steps: []raw.Step{
+							{
+								Key: String("init"),
+							},
+						},
+					},
+				},
 			},
 			exp: valid.Workflow{
 				Apply: valid.Stage{
@@ -227,6 +243,15 @@ func TestWorkflow_ToValid(t *testing.T) {
 						},
 					},
 				},
+				Stages: map[string]valid.Stage{
+					"validate": {
+						Steps: []valid.Step{
+							{
+								StepName: "init",
+							},
+						},
+					},
+				},
 			},
 		},
 	}
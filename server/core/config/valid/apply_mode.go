@@ -0,0 +1,13 @@
+package valid
+
+// ApplyMode enum
+type ApplyMode string
+
+const (
+	// BeforeMergeApplyMode applies plans from the pull request before it is
+	// merged. This is the default.
+	BeforeMergeApplyMode ApplyMode = "before_merge"
+	// AfterMergeApplyMode rejects apply on the pull request and instead
+	// applies the reviewed plans once the pull request has been merged.
+	AfterMergeApplyMode ApplyMode = "after_merge"
+)
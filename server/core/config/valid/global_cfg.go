@@ -2,8 +2,10 @@ package valid
 
 import (
 	"fmt"
+	"path"
 	"regexp"
 	"strings"
+	"time"
 
 	version "github.com/hashicorp/go-version"
 	"github.com/runatlantis/atlantis/server/logging"
@@ -26,6 +28,8 @@ const RepoLockingKey = "repo_locking"
 const PolicyCheckKey = "policy_check"
 const CustomPolicyCheckKey = "custom_policy_check"
 const AutoDiscoverKey = "autodiscover"
+const ApplyModeKey = "apply_mode"
+const RunnerKey = "runner"
 
 // DefaultAtlantisFile is the default name of the config file for each repo.
 const DefaultAtlantisFile = "atlantis.yaml"
@@ -39,10 +43,89 @@ var NonOverrideableApplyReqs = []string{PoliciesPassedCommandReq}
 
 // GlobalCfg is the final parsed version of server-side repo config.
 type GlobalCfg struct {
-	Repos      []Repo
-	Workflows  map[string]Workflow
-	PolicySets PolicySets
-	Metrics    Metrics
+	Repos          []Repo
+	Workflows      map[string]Workflow
+	PolicySets     PolicySets
+	Metrics        Metrics
+	Roles          []RoleBinding
+	EnvPolicy      EnvPolicy
+	CustomCommands []CustomCommand
+}
+
+// CustomCommand is a comment command, ex. atlantis validate, that runs a
+// stage of the workflow of each project.
+type CustomCommand struct {
+	Name        string
+	Description string
+	// Stage is the name of the workflow stage to run, see Workflow.Stage.
+	Stage string
+	// Requirements must be satisfied before the stage runs, like
+	// ApplyRequirements.
+	Requirements []string
+	// RequireLock is true if the project must be locked while the stage runs.
+	RequireLock bool
+	// Roles are the roles, any of which can run the command. If empty,
+	// anyone who can comment can.
+	Roles []string
+}
+
+// CustomCommand returns the custom command named name.
+func (g GlobalCfg) CustomCommand(name string) (CustomCommand, bool) {
+	for _, c := range g.CustomCommands {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return CustomCommand{}, false
+}
+
+// RoleBinding grants a web UI role to users and groups on the repos matching
+// its ID.
+type RoleBinding struct {
+	// ID is the exact repo ID this binding applies to. If both ID and IDRegex
+	// are empty it applies to all repos.
+	ID      string
+	IDRegex *regexp.Regexp
+	// Role is one of viewer, operator or admin.
+	Role   string
+	Users  []string
+	Groups []string
+}
+
+// AllRepos returns true if b applies to every repo.
+func (b RoleBinding) AllRepos() bool {
+	return b.ID == "" && (b.IDRegex == nil || b.IDRegex.String() == ".*")
+}
+
+// IDMatches returns true if b applies to the repo with ID repoID.
+func (b RoleBinding) IDMatches(repoID string) bool {
+	if b.ID != "" {
+		return b.ID == repoID
+	}
+	if b.IDRegex != nil {
+		return b.IDRegex.MatchString(repoID)
+	}
+	return true
+}
+
+// Grants returns true if b grants its role to the user named username or to
+// any of groups.
+func (b RoleBinding) Grants(username string, groups []string) bool {
+	if username != "" {
+		for _, u := range b.Users {
+			if u == username {
+				return true
+			}
+		}
+	}
+	for _, g := range groups {
+		for _, bg := range b.Groups {
+			if g == bg {
+				return true
+			}
+		}
+	}
+	return false
 }
 
 type Metrics struct {
@@ -59,6 +142,59 @@ type Prometheus struct {
 	Endpoint string
 }
 
+// atlantisEnvPrefix is the prefix of the environment variables Atlantis is
+// configured with, which include its secrets like ATLANTIS_GH_TOKEN.
+// Commands run by Atlantis never inherit them unless they're allowed by name.
+const atlantisEnvPrefix = "ATLANTIS_"
+
+// EnvPolicy decides which of the Atlantis process's environment variables
+// are inherited by the commands Atlantis runs: run, env and multienv steps,
+// workflow hooks, terraform, conftest and git. Variables Atlantis sets itself, like
+// WORKSPACE, aren't affected.
+type EnvPolicy struct {
+	// Allow are the patterns of the variables that are inherited. If empty,
+	// all variables but Atlantis's own are inherited unless they match Deny.
+	Allow []string
+	// Deny are the patterns of the variables that are never inherited.
+	Deny []string
+}
+
+// Inherits returns true if commands inherit the variable named name.
+func (p EnvPolicy) Inherits(name string) bool {
+	if strings.HasPrefix(name, atlantisEnvPrefix) {
+		// They must be allowed by name, not by a pattern like ATLANTIS_*.
+		return utils.SlicesContains(p.Allow, name) && !matchesEnvPattern(p.Deny, name)
+	}
+	if len(p.Allow) > 0 && !matchesEnvPattern(p.Allow, name) {
+		return false
+	}
+	return !matchesEnvPattern(p.Deny, name)
+}
+
+// Filter returns the variables in environ, which are in key=value form like
+// os.Environ(), that commands inherit.
+func (p EnvPolicy) Filter(environ []string) []string {
+	filtered := make([]string, 0, len(environ))
+	for _, kv := range environ {
+		name, _, _ := strings.Cut(kv, "=")
+		if p.Inherits(name) {
+			filtered = append(filtered, kv)
+		}
+	}
+	return filtered
+}
+
+// matchesEnvPattern returns true if name matches any of patterns. Patterns
+// are matched with path.Match so AWS_* matches AWS_REGION.
+func matchesEnvPattern(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
 // Repo is the final parsed version of server-side repo config.
 type Repo struct {
 	// ID is the exact match id of this config.
@@ -83,6 +219,16 @@ type Repo struct {
 	PolicyCheck               *bool
 	CustomPolicyCheck         *bool
 	AutoDiscover              *AutoDiscover
+	ApplyMode                 *ApplyMode
+	// LockTTL is how long locks on the repo last before the lock reaper
+	// releases them. If nil, locks don't expire.
+	LockTTL *time.Duration
+	// Runner is the runner label of the workers that run the repo's
+	// projects. If nil, they run on the Atlantis server.
+	Runner *string
+	// MaxConcurrentProjects is how many of the repo's projects can run at
+	// once across all pull requests. If nil, there's no limit.
+	MaxConcurrentProjects *int
 }
 
 type MergedProjectCfg struct {
@@ -105,6 +251,10 @@ type MergedProjectCfg struct {
 	RepoLocking               bool
 	PolicyCheck               bool
 	CustomPolicyCheck         bool
+	ApplyMode                 ApplyMode
+	PlanTimeout               time.Duration
+	ApplyTimeout              time.Duration
+	Runner                    string
 }
 
 // WorkflowHook is a map of custom run commands to run before or after workflows.
@@ -271,7 +421,8 @@ func (r Repo) IDString() string {
 // final config. It assumes that all configs have been validated.
 func (g GlobalCfg) MergeProjectCfg(log logging.SimpleLogging, repoID string, proj Project, rCfg RepoCfg) MergedProjectCfg {
 	log.Debug("MergeProjectCfg started")
-	planReqs, applyReqs, importReqs, workflow, allowedOverrides, allowCustomWorkflows, deleteSourceBranchOnMerge, repoLocking, policyCheck, customPolicyCheck, _ := g.getMatchingCfg(log, repoID)
+	planReqs, applyReqs, importReqs, workflow, allowedOverrides, allowCustomWorkflows, deleteSourceBranchOnMerge, repoLocking, policyCheck, customPolicyCheck, _, applyMode := g.getMatchingCfg(log, repoID)
+	runner := g.runner(repoID)
 
 	// If repos are allowed to override certain keys then override them.
 	for _, key := range allowedOverrides {
@@ -347,6 +498,16 @@ func (g GlobalCfg) MergeProjectCfg(log logging.SimpleLogging, repoID string, pro
 				log.Debug("overriding server-defined %s with repo settings: [%t]", CustomPolicyCheckKey, *proj.CustomPolicyCheck)
 				customPolicyCheck = *proj.CustomPolicyCheck
 			}
+		case ApplyModeKey:
+			if proj.ApplyMode != nil {
+				log.Debug("overriding server-defined %s with repo settings: [%s]", ApplyModeKey, *proj.ApplyMode)
+				applyMode = *proj.ApplyMode
+			}
+		case RunnerKey:
+			if proj.Runner != nil {
+				log.Debug("overriding server-defined %s with repo settings: [%s]", RunnerKey, *proj.Runner)
+				runner = *proj.Runner
+			}
 		}
 		log.Debug("MergeProjectCfg completed")
 	}
@@ -372,6 +533,10 @@ func (g GlobalCfg) MergeProjectCfg(log logging.SimpleLogging, repoID string, pro
 		RepoLocking:               repoLocking,
 		PolicyCheck:               policyCheck,
 		CustomPolicyCheck:         customPolicyCheck,
+		ApplyMode:                 applyMode,
+		PlanTimeout:               proj.PlanTimeout,
+		ApplyTimeout:              proj.ApplyTimeout,
+		Runner:                    runner,
 	}
 }
 
@@ -379,7 +544,7 @@ func (g GlobalCfg) MergeProjectCfg(log logging.SimpleLogging, repoID string, pro
 // repo with id repoID. It is used when there is no repo config.
 func (g GlobalCfg) DefaultProjCfg(log logging.SimpleLogging, repoID string, repoRelDir string, workspace string) MergedProjectCfg {
 	log.Debug("building config based on server-side config")
-	planReqs, applyReqs, importReqs, workflow, _, _, deleteSourceBranchOnMerge, repoLocking, policyCheck, customPolicyCheck, _ := g.getMatchingCfg(log, repoID)
+	planReqs, applyReqs, importReqs, workflow, _, _, deleteSourceBranchOnMerge, repoLocking, policyCheck, customPolicyCheck, _, applyMode := g.getMatchingCfg(log, repoID)
 	return MergedProjectCfg{
 		PlanRequirements:          planReqs,
 		ApplyRequirements:         applyReqs,
@@ -395,9 +560,19 @@ func (g GlobalCfg) DefaultProjCfg(log logging.SimpleLogging, repoID string, repo
 		RepoLocking:               repoLocking,
 		PolicyCheck:               policyCheck,
 		CustomPolicyCheck:         customPolicyCheck,
+		ApplyMode:                 applyMode,
+		Runner:                    g.runner(repoID),
 	}
 }
 
+// AfterMergeApplyModePossible returns true if projects in the repo with id
+// repoID can be configured with apply_mode: after_merge, either because the
+// server-side config sets it or because the repo is allowed to override it.
+func (g GlobalCfg) AfterMergeApplyModePossible(log logging.SimpleLogging, repoID string) bool {
+	_, _, _, _, allowedOverrides, _, _, _, _, _, _, applyMode := g.getMatchingCfg(log, repoID)
+	return applyMode == AfterMergeApplyMode || utils.SlicesContains(allowedOverrides, ApplyModeKey)
+}
+
 // RepoAutoDiscoverCfg returns the AutoDiscover config from the global config
 // for the repo with id repoID. If no matching repo is found or there is no
 // AutoDiscover config then this function returns nil.
@@ -409,6 +584,45 @@ func (g GlobalCfg) RepoAutoDiscoverCfg(repoID string) *AutoDiscover {
 	return nil
 }
 
+// LockTTL returns how long locks on the repo with id repoID last for, or 0 if
+// they don't expire. Like other settings, the last matching repo that sets
+// lock_ttl wins.
+func (g GlobalCfg) LockTTL(repoID string) time.Duration {
+	for i := len(g.Repos) - 1; i >= 0; i-- {
+		repo := g.Repos[i]
+		if repo.LockTTL != nil && repo.IDMatches(repoID) {
+			return *repo.LockTTL
+		}
+	}
+	return 0
+}
+
+// MaxConcurrentProjects returns how many projects of the repo with id repoID
+// can run at once, or 0 if there's no limit. The last matching repo that sets
+// max_concurrent_projects wins.
+func (g GlobalCfg) MaxConcurrentProjects(repoID string) int {
+	for i := len(g.Repos) - 1; i >= 0; i-- {
+		repo := g.Repos[i]
+		if repo.MaxConcurrentProjects != nil && repo.IDMatches(repoID) {
+			return *repo.MaxConcurrentProjects
+		}
+	}
+	return 0
+}
+
+// runner returns the runner label of the workers that run the projects of
+// the repo with id repoID, or "" if they run on the Atlantis server. The last
+// matching repo that sets runner wins.
+func (g GlobalCfg) runner(repoID string) string {
+	for i := len(g.Repos) - 1; i >= 0; i-- {
+		repo := g.Repos[i]
+		if repo.Runner != nil && repo.IDMatches(repoID) {
+			return *repo.Runner
+		}
+	}
+	return ""
+}
+
 // ValidateRepoCfg validates that rCfg for repo with id repoID is valid based
 // on our global config.
 func (g GlobalCfg) ValidateRepoCfg(rCfg RepoCfg, repoID string) error {
@@ -453,6 +667,12 @@ func (g GlobalCfg) ValidateRepoCfg(rCfg RepoCfg, repoID string) error {
 		if p.CustomPolicyCheck != nil && !utils.SlicesContains(allowedOverrides, CustomPolicyCheckKey) {
 			return fmt.Errorf("repo config not allowed to set '%s' key: server-side config needs '%s: [%s]'", CustomPolicyCheckKey, AllowedOverridesKey, CustomPolicyCheckKey)
 		}
+		if p.ApplyMode != nil && !utils.SlicesContains(allowedOverrides, ApplyModeKey) {
+			return fmt.Errorf("repo config not allowed to set '%s' key: server-side config needs '%s: [%s]'", ApplyModeKey, AllowedOverridesKey, ApplyModeKey)
+		}
+		if p.Runner != nil && !utils.SlicesContains(allowedOverrides, RunnerKey) {
+			return fmt.Errorf("repo config not allowed to set '%s' key: server-side config needs '%s: [%s]'", RunnerKey, AllowedOverridesKey, RunnerKey)
+		}
 	}
 
 	// Check custom workflows.
@@ -511,7 +731,7 @@ func (g GlobalCfg) ValidateRepoCfg(rCfg RepoCfg, repoID string) error {
 }
 
 // getMatchingCfg returns the key settings for repoID.
-func (g GlobalCfg) getMatchingCfg(log logging.SimpleLogging, repoID string) (planReqs []string, applyReqs []string, importReqs []string, workflow Workflow, allowedOverrides []string, allowCustomWorkflows bool, deleteSourceBranchOnMerge bool, repoLocking bool, policyCheck bool, customPolicyCheck bool, autoDiscover AutoDiscover) {
+func (g GlobalCfg) getMatchingCfg(log logging.SimpleLogging, repoID string) (planReqs []string, applyReqs []string, importReqs []string, workflow Workflow, allowedOverrides []string, allowCustomWorkflows bool, deleteSourceBranchOnMerge bool, repoLocking bool, policyCheck bool, customPolicyCheck bool, autoDiscover AutoDiscover, applyMode ApplyMode) {
 	toLog := make(map[string]string)
 	traceF := func(repoIdx int, repoID string, key string, val interface{}) string {
 		from := "default server config"
@@ -526,6 +746,8 @@ func (g GlobalCfg) getMatchingCfg(log logging.SimpleLogging, repoID string) (pla
 			valStr = fmt.Sprintf("[%s]", strings.Join(v, ","))
 		case bool:
 			valStr = fmt.Sprintf("%t", v)
+		case ApplyMode:
+			valStr = fmt.Sprintf("%q", v)
 		default:
 			valStr = "this is a bug"
 		}
@@ -536,7 +758,7 @@ func (g GlobalCfg) getMatchingCfg(log logging.SimpleLogging, repoID string) (pla
 	// Can't use raw.DefaultAutoDiscoverMode() because of an import cycle. Should refactor to avoid that.
 	autoDiscover = AutoDiscover{Mode: AutoDiscoverAutoMode}
 
-	for _, key := range []string{PlanRequirementsKey, ApplyRequirementsKey, ImportRequirementsKey, WorkflowKey, AllowedOverridesKey, AllowCustomWorkflowsKey, DeleteSourceBranchOnMergeKey, RepoLockingKey, PolicyCheckKey, CustomPolicyCheckKey} {
+	for _, key := range []string{PlanRequirementsKey, ApplyRequirementsKey, ImportRequirementsKey, WorkflowKey, AllowedOverridesKey, AllowCustomWorkflowsKey, DeleteSourceBranchOnMergeKey, RepoLockingKey, PolicyCheckKey, CustomPolicyCheckKey, ApplyModeKey} {
 		for i, repo := range g.Repos {
 			if repo.IDMatches(repoID) {
 				switch key {
@@ -595,6 +817,11 @@ func (g GlobalCfg) getMatchingCfg(log logging.SimpleLogging, repoID string) (pla
 						toLog[AutoDiscoverKey] = traceF(i, repo.IDString(), AutoDiscoverKey, repo.AutoDiscover.Mode)
 						autoDiscover = *repo.AutoDiscover
 					}
+				case ApplyModeKey:
+					if repo.ApplyMode != nil {
+						toLog[ApplyModeKey] = traceF(i, repo.IDString(), ApplyModeKey, *repo.ApplyMode)
+						applyMode = *repo.ApplyMode
+					}
 				}
 			}
 		}
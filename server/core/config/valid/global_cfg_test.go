@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"regexp"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/go-version"
 	"github.com/mohae/deepcopy"
@@ -652,6 +653,7 @@ policies:
 
 func TestGlobalCfg_MergeProjectCfg(t *testing.T) {
 	var emptyPolicySets valid.PolicySets
+	afterMergeApplyMode := valid.AfterMergeApplyMode
 
 	defaultWorkflow := valid.Workflow{
 		Name:        "default",
@@ -895,6 +897,131 @@ repos:
 				CustomPolicyCheck:  false,
 			},
 		},
+		"server-side apply_mode is used": {
+			gCfg: `
+repos:
+- id: /.*/
+  apply_mode: after_merge
+`,
+			repoID: "github.com/owner/repo",
+			proj: valid.Project{
+				Dir:                ".",
+				Workspace:          "default",
+				PlanRequirements:   []string{},
+				ApplyRequirements:  []string{},
+				ImportRequirements: []string{},
+			},
+			repoWorkflows: nil,
+			exp: valid.MergedProjectCfg{
+				PlanRequirements:   []string{},
+				ApplyRequirements:  []string{},
+				ImportRequirements: []string{},
+				Workflow:           defaultWorkflow,
+				RepoRelDir:         ".",
+				Workspace:          "default",
+				Name:               "",
+				AutoplanEnabled:    false,
+				PolicySets:         emptyPolicySets,
+				RepoLocking:        true,
+				CustomPolicyCheck:  false,
+				ApplyMode:          valid.AfterMergeApplyMode,
+			},
+		},
+		"repo-side apply_mode wins out if allowed": {
+			gCfg: `
+repos:
+- id: /.*/
+  apply_mode: before_merge
+  allowed_overrides: [apply_mode]
+`,
+			repoID: "github.com/owner/repo",
+			proj: valid.Project{
+				Dir:                ".",
+				Workspace:          "default",
+				PlanRequirements:   []string{},
+				ApplyRequirements:  []string{},
+				ImportRequirements: []string{},
+				ApplyMode:          &afterMergeApplyMode,
+			},
+			repoWorkflows: nil,
+			exp: valid.MergedProjectCfg{
+				PlanRequirements:   []string{},
+				ApplyRequirements:  []string{},
+				ImportRequirements: []string{},
+				Workflow:           defaultWorkflow,
+				RepoRelDir:         ".",
+				Workspace:          "default",
+				Name:               "",
+				AutoplanEnabled:    false,
+				PolicySets:         emptyPolicySets,
+				RepoLocking:        true,
+				CustomPolicyCheck:  false,
+				ApplyMode:          valid.AfterMergeApplyMode,
+			},
+		},
+		"server-side runner is used": {
+			gCfg: `
+repos:
+- id: /.*/
+  runner: prod-account
+`,
+			repoID: "github.com/owner/repo",
+			proj: valid.Project{
+				Dir:                ".",
+				Workspace:          "default",
+				PlanRequirements:   []string{},
+				ApplyRequirements:  []string{},
+				ImportRequirements: []string{},
+				Runner:             String("staging-account"),
+			},
+			repoWorkflows: nil,
+			exp: valid.MergedProjectCfg{
+				PlanRequirements:   []string{},
+				ApplyRequirements:  []string{},
+				ImportRequirements: []string{},
+				Workflow:           defaultWorkflow,
+				RepoRelDir:         ".",
+				Workspace:          "default",
+				Name:               "",
+				AutoplanEnabled:    false,
+				PolicySets:         emptyPolicySets,
+				RepoLocking:        true,
+				CustomPolicyCheck:  false,
+				Runner:             "prod-account",
+			},
+		},
+		"repo-side runner wins out if allowed": {
+			gCfg: `
+repos:
+- id: /.*/
+  runner: prod-account
+  allowed_overrides: [runner]
+`,
+			repoID: "github.com/owner/repo",
+			proj: valid.Project{
+				Dir:                ".",
+				Workspace:          "default",
+				PlanRequirements:   []string{},
+				ApplyRequirements:  []string{},
+				ImportRequirements: []string{},
+				Runner:             String("staging-account"),
+			},
+			repoWorkflows: nil,
+			exp: valid.MergedProjectCfg{
+				PlanRequirements:   []string{},
+				ApplyRequirements:  []string{},
+				ImportRequirements: []string{},
+				Workflow:           defaultWorkflow,
+				RepoRelDir:         ".",
+				Workspace:          "default",
+				Name:               "",
+				AutoplanEnabled:    false,
+				PolicySets:         emptyPolicySets,
+				RepoLocking:        true,
+				CustomPolicyCheck:  false,
+				Runner:             "staging-account",
+			},
+		},
 		"last server-side match wins": {
 			gCfg: `
 repos:
@@ -1118,6 +1245,93 @@ func TestGlobalCfg_MatchingRepo(t *testing.T) {
 	}
 }
 
+func TestGlobalCfg_LockTTL(t *testing.T) {
+	hour, day := time.Hour, 24*time.Hour
+	gCfg := valid.GlobalCfg{
+		Repos: []valid.Repo{
+			{IDRegex: regexp.MustCompile(".*"), LockTTL: &day},
+			{ID: "github.com/owner/repo", LockTTL: &hour},
+			{ID: "github.com/owner/other"},
+		},
+	}
+	Equals(t, hour, gCfg.LockTTL("github.com/owner/repo"))
+	Equals(t, day, gCfg.LockTTL("github.com/owner/other"))
+	Equals(t, time.Duration(0), valid.GlobalCfg{}.LockTTL("github.com/owner/repo"))
+}
+
+func TestGlobalCfg_MaxConcurrentProjects(t *testing.T) {
+	one, five := 1, 5
+	gCfg := valid.GlobalCfg{
+		Repos: []valid.Repo{
+			{IDRegex: regexp.MustCompile(".*"), MaxConcurrentProjects: &five},
+			{ID: "github.com/owner/repo", MaxConcurrentProjects: &one},
+			{ID: "github.com/owner/other"},
+		},
+	}
+	Equals(t, 1, gCfg.MaxConcurrentProjects("github.com/owner/repo"))
+	Equals(t, 5, gCfg.MaxConcurrentProjects("github.com/owner/other"))
+	Equals(t, 0, valid.GlobalCfg{}.MaxConcurrentProjects("github.com/owner/repo"))
+}
+
+func TestEnvPolicy_Filter(t *testing.T) {
+	environ := []string{
+		"AWS_REGION=us-east-1",
+		"AWS_SECRET_ACCESS_KEY=secret",
+		"ATLANTIS_GH_TOKEN=token",
+		"ATLANTIS_REDIS_PASSWORD=password",
+		"ATLANTIS_PORT=4141",
+		"HOME=/home/atlantis",
+		"WEIRD=a=b",
+	}
+	cases := []struct {
+		description string
+		policy      valid.EnvPolicy
+		exp         []string
+	}{
+		{
+			description: "atlantis variables are stripped by default",
+			exp: []string{
+				"AWS_REGION=us-east-1",
+				"AWS_SECRET_ACCESS_KEY=secret",
+				"HOME=/home/atlantis",
+				"WEIRD=a=b",
+			},
+		},
+		{
+			description: "deny",
+			policy:      valid.EnvPolicy{Deny: []string{"AWS_SECRET_*", "WEIRD"}},
+			exp: []string{
+				"AWS_REGION=us-east-1",
+				"HOME=/home/atlantis",
+			},
+		},
+		{
+			description: "allow",
+			policy:      valid.EnvPolicy{Allow: []string{"AWS_*", "ATLANTIS_*", "HOME"}},
+			exp: []string{
+				"AWS_REGION=us-east-1",
+				"AWS_SECRET_ACCESS_KEY=secret",
+				"HOME=/home/atlantis",
+			},
+		},
+		{
+			description: "allow and deny",
+			policy:      valid.EnvPolicy{Allow: []string{"AWS_*"}, Deny: []string{"AWS_SECRET_ACCESS_KEY"}},
+			exp:         []string{"AWS_REGION=us-east-1"},
+		},
+		{
+			description: "atlantis variables allowed by name",
+			policy:      valid.EnvPolicy{Deny: []string{"AWS_*"}, Allow: []string{"ATLANTIS_GH_TOKEN", "ATLANTIS_PORT", "HOME"}},
+			exp:         []string{"ATLANTIS_GH_TOKEN=token", "ATLANTIS_PORT=4141", "HOME=/home/atlantis"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			Equals(t, c.exp, c.policy.Filter(environ))
+		})
+	}
+}
+
 func TestGlobalCfg_PolicyCheckOverride(t *testing.T) {
 	var emptyPolicySets valid.PolicySets
 
@@ -7,6 +7,7 @@ import (
 	"log"
 	"regexp"
 	"strings"
+	"time"
 
 	version "github.com/hashicorp/go-version"
 )
@@ -157,6 +158,14 @@ type Project struct {
 	ExecutionOrderGroup       int
 	PolicyCheck               *bool
 	CustomPolicyCheck         *bool
+	ApplyMode                 *ApplyMode
+	// PlanTimeout and ApplyTimeout are how long plan and apply can run. If 0,
+	// the server defaults are used.
+	PlanTimeout  time.Duration
+	ApplyTimeout time.Duration
+	// Runner is the runner label of the workers that run the project. It
+	// overrides the server-side config.
+	Runner *string
 }
 
 // GetName returns the name of the project or an empty string if there is no
@@ -199,6 +208,66 @@ type Step struct {
 	EnvVarName string
 	// EnvVarValue is the value to set EnvVarName to.
 	EnvVarValue string
+	// Timeout is how long the step can run before it's killed. If 0, it can
+	// run until the project's plan or apply timeout.
+	Timeout time.Duration
+	// Retry is how the step is re-run if it fails. If nil, it isn't.
+	Retry *StepRetry
+	// When is an expression that must be true for the step to run, see
+	// ParseStepCondition. If empty, the step always runs.
+	When string
+}
+
+const (
+	ConstantBackoff    = "constant"
+	ExponentialBackoff = "exponential"
+)
+
+const (
+	// MaxStepRetryAttempts is the most attempts a step's retry policy can
+	// set.
+	MaxStepRetryAttempts = 10
+	// MaxStepRetryDelay is the longest delay before a step's attempt. Delays
+	// are capped to it when they back off exponentially.
+	MaxStepRetryDelay = 10 * time.Minute
+)
+
+// StepRetry is the retry policy of a step.
+type StepRetry struct {
+	// Attempts is how many times the step is run at most, including the
+	// first run.
+	Attempts int
+	// Delay is how long to wait before the second attempt.
+	Delay time.Duration
+	// Backoff is ConstantBackoff or ExponentialBackoff.
+	Backoff string
+	// OnOutputRegex, if set, limits retries to failures whose output
+	// matches it.
+	OnOutputRegex *regexp.Regexp
+}
+
+// ShouldRetry returns true if a step that failed on attempt (starting at 1)
+// with output should be run again.
+func (r *StepRetry) ShouldRetry(attempt int, output string) bool {
+	if r == nil || attempt >= r.Attempts {
+		return false
+	}
+	return r.OnOutputRegex == nil || r.OnOutputRegex.MatchString(output)
+}
+
+// DelayBefore returns how long to wait before attempt (starting at 2). It's
+// at most MaxStepRetryDelay.
+func (r *StepRetry) DelayBefore(attempt int) time.Duration {
+	delay := r.Delay
+	if r.Backoff == ExponentialBackoff {
+		for i := 2; i < attempt && delay < MaxStepRetryDelay; i++ {
+			delay *= 2
+		}
+	}
+	if delay > MaxStepRetryDelay {
+		return MaxStepRetryDelay
+	}
+	return delay
 }
 
 type Workflow struct {
@@ -208,4 +277,26 @@ type Workflow struct {
 	PolicyCheck Stage
 	Import      Stage
 	StateRm     Stage
+	// Stages are the custom stages of the workflow, which are run by custom
+	// commands.
+	Stages map[string]Stage
+}
+
+// Stage returns the stage of w named name. It's either one of the built-in
+// stages or one of w's custom stages.
+func (w Workflow) Stage(name string) (Stage, bool) {
+	switch name {
+	case "plan":
+		return w.Plan, true
+	case "apply":
+		return w.Apply, true
+	case "policy_check":
+		return w.PolicyCheck, true
+	case "import":
+		return w.Import, true
+	case "state_rm":
+		return w.StateRm, true
+	}
+	stage, ok := w.Stages[name]
+	return stage, ok
 }
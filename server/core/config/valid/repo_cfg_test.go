@@ -323,3 +323,22 @@ func TestConfig_AutoDiscoverEnabled(t *testing.T) {
 		})
 	}
 }
+
+func TestWorkflow_Stage(t *testing.T) {
+	validate := valid.Stage{Steps: []valid.Step{{StepName: "init"}}}
+	w := valid.Workflow{
+		Plan:   valid.DefaultPlanStage,
+		Stages: map[string]valid.Stage{"validate": validate},
+	}
+
+	stage, ok := w.Stage("plan")
+	Assert(t, ok, "exp plan stage")
+	Equals(t, valid.DefaultPlanStage, stage)
+
+	stage, ok = w.Stage("validate")
+	Assert(t, ok, "exp validate stage")
+	Equals(t, validate, stage)
+
+	_, ok = w.Stage("fmt-check")
+	Assert(t, !ok, "exp no fmt-check stage")
+}
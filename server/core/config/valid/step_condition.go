@@ -0,0 +1,213 @@
+package valid
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+	"github.com/zclconf/go-cty/cty/function"
+	"github.com/zclconf/go-cty/cty/function/stdlib"
+)
+
+// Step conditions are HCL expressions, ex. workspace == "prod", that decide
+// if a step runs. They can only read the variables below and call the
+// functions in stepConditionFunctions, none of which have side effects.
+const (
+	WorkspaceConditionVar   = "workspace"
+	ProjectNameConditionVar = "project_name"
+	RepoRelDirConditionVar  = "repo_rel_dir"
+	BaseBranchConditionVar  = "base_branch"
+	HeadBranchConditionVar  = "head_branch"
+	CommentArgsConditionVar = "comment_args"
+	PullLabelsConditionVar  = "pull_labels"
+	StepsConditionVar       = "steps"
+)
+
+// Outcomes of the steps that ran before a step.
+const (
+	StepSucceeded = "success"
+	StepSkipped   = "skipped"
+)
+
+var stepConditionVars = []string{
+	WorkspaceConditionVar,
+	ProjectNameConditionVar,
+	RepoRelDirConditionVar,
+	BaseBranchConditionVar,
+	HeadBranchConditionVar,
+	CommentArgsConditionVar,
+	PullLabelsConditionVar,
+	StepsConditionVar,
+}
+
+var stepConditionFunctions = map[string]function.Function{
+	"contains":    stdlib.ContainsFunc,
+	"endswith":    stringPredicateFunc(strings.HasSuffix),
+	"join":        stdlib.JoinFunc,
+	"length":      stdlib.LengthFunc,
+	"lower":       stdlib.LowerFunc,
+	"split":       stdlib.SplitFunc,
+	"startswith":  stringPredicateFunc(strings.HasPrefix),
+	"strcontains": stringPredicateFunc(strings.Contains),
+	"trimspace":   stdlib.TrimSpaceFunc,
+	"upper":       stdlib.UpperFunc,
+}
+
+// stepOutcomeType is the type of each element of the steps variable.
+var stepOutcomeType = cty.Object(map[string]cty.Type{
+	"name":    cty.String,
+	"outcome": cty.String,
+	"output":  cty.String,
+})
+
+// StepOutcome is the outcome of a step that ran, or was skipped, before the
+// step whose condition is being evaluated.
+type StepOutcome struct {
+	Name string
+	// Outcome is StepSucceeded or StepSkipped.
+	Outcome string
+	Output  string
+}
+
+// StepConditionContext is what a step condition can read.
+type StepConditionContext struct {
+	Workspace   string
+	ProjectName string
+	RepoRelDir  string
+	BaseBranch  string
+	HeadBranch  string
+	CommentArgs []string
+	// PullLabels returns the labels of the pull request. It's only called
+	// if the condition uses them since it calls the VCS host.
+	PullLabels func() ([]string, error)
+	Steps      []StepOutcome
+}
+
+// ParseStepCondition parses the condition of a step and checks that it only
+// uses the variables and functions that are available.
+func ParseStepCondition(src string) (hcl.Expression, error) {
+	expr, diags := hclsyntax.ParseExpression([]byte(src), "when", hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	for _, traversal := range expr.Variables() {
+		name := traversal.RootName()
+		if !stringInSlice(name, stepConditionVars) {
+			return nil, fmt.Errorf("unknown variable %q, must be one of %s", name, strings.Join(stepConditionVars, ", "))
+		}
+	}
+	var funcErr error
+	hclsyntax.VisitAll(expr.(hclsyntax.Node), func(node hclsyntax.Node) hcl.Diagnostics { // nolint: errcheck
+		if call, ok := node.(*hclsyntax.FunctionCallExpr); ok && funcErr == nil {
+			if _, ok := stepConditionFunctions[call.Name]; !ok {
+				funcErr = fmt.Errorf("unknown function %q, must be one of %s", call.Name, strings.Join(stepConditionFunctionNames(), ", "))
+			}
+		}
+		return nil
+	})
+	if funcErr != nil {
+		return nil, funcErr
+	}
+	return expr, nil
+}
+
+// Eval returns whether the step condition src is true.
+func (c StepConditionContext) Eval(src string) (bool, error) {
+	expr, err := ParseStepCondition(src)
+	if err != nil {
+		return false, err
+	}
+
+	vars := map[string]cty.Value{
+		WorkspaceConditionVar:   cty.StringVal(c.Workspace),
+		ProjectNameConditionVar: cty.StringVal(c.ProjectName),
+		RepoRelDirConditionVar:  cty.StringVal(c.RepoRelDir),
+		BaseBranchConditionVar:  cty.StringVal(c.BaseBranch),
+		HeadBranchConditionVar:  cty.StringVal(c.HeadBranch),
+		CommentArgsConditionVar: stringList(c.CommentArgs),
+		PullLabelsConditionVar:  cty.ListValEmpty(cty.String),
+		StepsConditionVar:       cty.ListValEmpty(stepOutcomeType),
+	}
+	for _, traversal := range expr.Variables() {
+		if traversal.RootName() == PullLabelsConditionVar && c.PullLabels != nil {
+			labels, err := c.PullLabels()
+			if err != nil {
+				return false, fmt.Errorf("getting pull request labels: %w", err)
+			}
+			vars[PullLabelsConditionVar] = stringList(labels)
+			break
+		}
+	}
+	if len(c.Steps) > 0 {
+		var steps []cty.Value
+		for _, s := range c.Steps {
+			steps = append(steps, cty.ObjectVal(map[string]cty.Value{
+				"name":    cty.StringVal(s.Name),
+				"outcome": cty.StringVal(s.Outcome),
+				"output":  cty.StringVal(s.Output),
+			}))
+		}
+		vars[StepsConditionVar] = cty.ListVal(steps)
+	}
+
+	val, diags := expr.Value(&hcl.EvalContext{
+		Variables: vars,
+		Functions: stepConditionFunctions,
+	})
+	if diags.HasErrors() {
+		return false, diags
+	}
+	val, err = convert.Convert(val, cty.Bool)
+	if err != nil || val.IsNull() {
+		return false, fmt.Errorf("%q must be true or false", src)
+	}
+	return val.True(), nil
+}
+
+// stringPredicateFunc returns a function that calls f on its two string
+// arguments.
+func stringPredicateFunc(f func(s string, substr string) bool) function.Function {
+	return function.New(&function.Spec{
+		Params: []function.Parameter{
+			{Name: "str", Type: cty.String},
+			{Name: "substr", Type: cty.String},
+		},
+		Type: function.StaticReturnType(cty.Bool),
+		Impl: func(args []cty.Value, _ cty.Type) (cty.Value, error) {
+			return cty.BoolVal(f(args[0].AsString(), args[1].AsString())), nil
+		},
+	})
+}
+
+func stepConditionFunctionNames() []string {
+	var names []string
+	for name := range stepConditionFunctions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func stringList(strs []string) cty.Value {
+	if len(strs) == 0 {
+		return cty.ListValEmpty(cty.String)
+	}
+	var vals []cty.Value
+	for _, s := range strs {
+		vals = append(vals, cty.StringVal(s))
+	}
+	return cty.ListVal(vals)
+}
+
+func stringInSlice(s string, slice []string) bool {
+	for _, e := range slice {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+package valid_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestStepConditionContext_Eval(t *testing.T) {
+	ctx := valid.StepConditionContext{
+		Workspace:   "prod",
+		ProjectName: "network",
+		RepoRelDir:  "infra/network",
+		BaseBranch:  "main",
+		HeadBranch:  "feature",
+		CommentArgs: []string{"-target=module.vpc"},
+		PullLabels: func() ([]string, error) {
+			return []string{"deploy"}, nil
+		},
+		Steps: []valid.StepOutcome{
+			{Name: "init", Outcome: valid.StepSucceeded},
+			{Name: "run", Outcome: valid.StepSucceeded, Output: "No changes."},
+			{Name: "run", Outcome: valid.StepSkipped},
+		},
+	}
+	cases := []struct {
+		expr   string
+		exp    bool
+		expErr string
+	}{
+		{expr: `workspace == "prod"`, exp: true},
+		{expr: `workspace != "prod" || project_name == "dns"`, exp: false},
+		{expr: `startswith(repo_rel_dir, "infra/") && base_branch == "main"`, exp: true},
+		{expr: `head_branch == "main"`, exp: false},
+		{expr: `contains(comment_args, "-target=module.vpc")`, exp: true},
+		{expr: `contains(pull_labels, "deploy")`, exp: true},
+		{expr: `length(comment_args) == 0`, exp: false},
+		{expr: `steps[0].outcome == "success" && strcontains(steps[1].output, "No changes")`, exp: true},
+		{expr: `steps[2].outcome == "skipped"`, exp: true},
+		{expr: `"true"`, exp: true},
+		{expr: `env == "prod"`, expErr: `unknown variable "env", must be one of workspace, project_name, repo_rel_dir, base_branch, head_branch, comment_args, pull_labels, steps`},
+		{expr: `file("/etc/passwd") != ""`, expErr: `unknown function "file", must be one of contains, endswith, join, length, lower, split, startswith, strcontains, trimspace, upper`},
+		{expr: `workspace ==`, expErr: "when:1,13-13: Missing expression; Expected the start of an expression, but found the end of the file."},
+		{expr: `workspace`, expErr: `"workspace" must be true or false`},
+	}
+	for _, c := range cases {
+		t.Run(c.expr, func(t *testing.T) {
+			run, err := ctx.Eval(c.expr)
+			if c.expErr != "" {
+				ErrEquals(t, c.expErr, err)
+				return
+			}
+			Ok(t, err)
+			Equals(t, c.exp, run)
+		})
+	}
+}
+
+func TestStepConditionContext_Eval_PullLabels(t *testing.T) {
+	called := false
+	ctx := valid.StepConditionContext{
+		Workspace: "default",
+		PullLabels: func() ([]string, error) {
+			called = true
+			return nil, errors.New("rate limited")
+		},
+	}
+
+	t.Log("labels are only fetched if the condition uses them")
+	run, err := ctx.Eval(`workspace == "default"`)
+	Ok(t, err)
+	Assert(t, run, "exp condition to be true")
+	Assert(t, !called, "exp labels not to be fetched")
+
+	_, err = ctx.Eval(`contains(pull_labels, "deploy")`)
+	ErrEquals(t, "getting pull request labels: rate limited", err)
+}
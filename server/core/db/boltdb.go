@@ -3,6 +3,7 @@ package db
 
 import (
 	"bytes"
+	"encoding/binary"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -22,12 +23,18 @@ type BoltDB struct {
 	locksBucketName       []byte
 	pullsBucketName       []byte
 	globalLocksBucketName []byte
+	apiTokensBucketName   []byte
+	auditBucketName       []byte
+	leasesBucketName      []byte
 }
 
 const (
 	locksBucketName       = "runLocks"
 	pullsBucketName       = "pulls"
 	globalLocksBucketName = "globalLocks"
+	apiTokensBucketName   = "apiTokens"
+	auditBucketName       = "audit"
+	leasesBucketName      = "leases"
 	pullKeySeparator      = "::"
 )
 
@@ -56,6 +63,15 @@ func New(dataDir string) (*BoltDB, error) {
 		if _, err = tx.CreateBucketIfNotExists([]byte(globalLocksBucketName)); err != nil {
 			return errors.Wrapf(err, "creating bucket %q", globalLocksBucketName)
 		}
+		if _, err = tx.CreateBucketIfNotExists([]byte(apiTokensBucketName)); err != nil {
+			return errors.Wrapf(err, "creating bucket %q", apiTokensBucketName)
+		}
+		if _, err = tx.CreateBucketIfNotExists([]byte(auditBucketName)); err != nil {
+			return errors.Wrapf(err, "creating bucket %q", auditBucketName)
+		}
+		if _, err = tx.CreateBucketIfNotExists([]byte(leasesBucketName)); err != nil {
+			return errors.Wrapf(err, "creating bucket %q", leasesBucketName)
+		}
 		return nil
 	})
 	if err != nil {
@@ -67,6 +83,9 @@ func New(dataDir string) (*BoltDB, error) {
 		locksBucketName:       []byte(locksBucketName),
 		pullsBucketName:       []byte(pullsBucketName),
 		globalLocksBucketName: []byte(globalLocksBucketName),
+		apiTokensBucketName:   []byte(apiTokensBucketName),
+		auditBucketName:       []byte(auditBucketName),
+		leasesBucketName:      []byte(leasesBucketName),
 	}, nil
 }
 
@@ -77,9 +96,17 @@ func NewWithDB(db *bolt.DB, bucket string, globalBucket string) (*BoltDB, error)
 		locksBucketName:       []byte(bucket),
 		pullsBucketName:       []byte(pullsBucketName),
 		globalLocksBucketName: []byte(globalBucket),
+		apiTokensBucketName:   []byte(apiTokensBucketName),
+		auditBucketName:       []byte(auditBucketName),
+		leasesBucketName:      []byte(leasesBucketName),
 	}, nil
 }
 
+// Close closes the database, releasing its file lock.
+func (b *BoltDB) Close() error {
+	return b.db.Close()
+}
+
 // TryLock attempts to create a new lock. If the lock is
 // acquired, it will return true and the lock returned will be newLock.
 // If the lock is not acquired, it will return false and the current
@@ -107,6 +134,14 @@ func (b *BoltDB) TryLock(newLock models.ProjectLock) (bool, models.ProjectLock,
 			return errors.Wrap(err, "failed to deserialize current lock")
 		}
 		lockAcquired = false
+		// If the same pull request is taking the lock again, renew it. Time
+		// is moved too so that ExpiresAt - Time stays the lock's TTL.
+		if currLock.Pull.Num == newLock.Pull.Num && !currLock.ExpiresAt.Equal(newLock.ExpiresAt) {
+			currLock.Time = newLock.Time
+			currLock.ExpiresAt = newLock.ExpiresAt
+			currLockSerialized, _ = json.Marshal(currLock)
+			return bucket.Put([]byte(key), currLockSerialized)
+		}
 		return nil
 	})
 
@@ -319,6 +354,7 @@ func (b *BoltDB) UpdatePullWithResults(pull models.PullRequest, newResults []com
 		return models.PullStatus{}, err
 	}
 
+	now := time.Now()
 	var newStatus models.PullStatus
 	err = b.db.Update(func(tx *bolt.Tx) error {
 		bucket := tx.Bucket(b.pullsBucketName)
@@ -335,8 +371,14 @@ func (b *BoltDB) UpdatePullWithResults(pull models.PullRequest, newResults []com
 				statuses = append(statuses, b.projectResultToProject(r))
 			}
 			newStatus = models.PullStatus{
-				Pull:     pull,
-				Projects: statuses,
+				Pull:      pull,
+				Projects:  statuses,
+				CreatedAt: now,
+			}
+			// Keep when we first saw the pull request even though its
+			// projects are reset by the new commit.
+			if currStatus != nil && !currStatus.CreatedAt.IsZero() {
+				newStatus.CreatedAt = currStatus.CreatedAt
 			}
 		} else {
 			// If there's an existing pull at the right commit then we have to
@@ -357,6 +399,9 @@ func (b *BoltDB) UpdatePullWithResults(pull models.PullRequest, newResults []com
 						res.ProjectName == proj.ProjectName {
 
 						proj.Status = res.PlanStatus()
+						if digest := res.PlanDigest(); digest != "" {
+							proj.PlanDigest = digest
+						}
 
 						// Updating only policy sets which are included in results; keeping the rest.
 						if len(proj.PolicyStatus) > 0 {
@@ -384,6 +429,11 @@ func (b *BoltDB) UpdatePullWithResults(pull models.PullRequest, newResults []com
 			}
 		}
 
+		if len(newResults) > 0 {
+			newStatus.LastCommand = newResults[0].Command.String()
+		}
+		newStatus.UpdatedAt = now
+
 		// Now, we overwrite the key with our new status.
 		return b.writePullToBucket(bucket, key, newStatus)
 	})
@@ -407,6 +457,230 @@ func (b *BoltDB) GetPullStatus(pull models.PullRequest) (*models.PullStatus, err
 	return s, errors.Wrap(err, "DB transaction failed")
 }
 
+// ListPullStatuses returns the statuses of all pull requests. Statuses are
+// deleted when their pull request is closed so these are the open pull
+// requests that Atlantis has run on.
+func (b *BoltDB) ListPullStatuses() ([]models.PullStatus, error) {
+	var statuses []models.PullStatus
+	err := b.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(b.pullsBucketName)
+		return bucket.ForEach(func(k, v []byte) error {
+			var s models.PullStatus
+			if err := json.Unmarshal(v, &s); err != nil {
+				return errors.Wrapf(err, "deserializing pull status at key %q", string(k))
+			}
+			statuses = append(statuses, s)
+			return nil
+		})
+	})
+	return statuses, errors.Wrap(err, "DB transaction failed")
+}
+
+// CreateAPIToken stores token. It returns an error if a token with the same
+// name already exists.
+func (b *BoltDB) CreateAPIToken(token models.APIToken) error {
+	serialized, err := json.Marshal(token)
+	if err != nil {
+		return errors.Wrap(err, "serializing")
+	}
+	err = b.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists(b.apiTokensBucketName)
+		if err != nil {
+			return err
+		}
+		if bucket.Get([]byte(token.Name)) != nil {
+			return fmt.Errorf("token %q already exists", token.Name)
+		}
+		return bucket.Put([]byte(token.Name), serialized)
+	})
+	return errors.Wrap(err, "DB transaction failed")
+}
+
+// ListAPITokens returns all API tokens.
+func (b *BoltDB) ListAPITokens() ([]models.APIToken, error) {
+	var tokens []models.APIToken
+	err := b.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(b.apiTokensBucketName)
+		if bucket == nil {
+			return nil
+		}
+		return bucket.ForEach(func(k, v []byte) error {
+			var t models.APIToken
+			if err := json.Unmarshal(v, &t); err != nil {
+				return errors.Wrapf(err, "deserializing API token at key %q", string(k))
+			}
+			tokens = append(tokens, t)
+			return nil
+		})
+	})
+	return tokens, errors.Wrap(err, "DB transaction failed")
+}
+
+// DeleteAPIToken deletes the API token named name. It returns nil if there
+// was no such token.
+func (b *BoltDB) DeleteAPIToken(name string) (*models.APIToken, error) {
+	var deleted *models.APIToken
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(b.apiTokensBucketName)
+		if bucket == nil {
+			return nil
+		}
+		v := bucket.Get([]byte(name))
+		if v == nil {
+			return nil
+		}
+		var t models.APIToken
+		if err := json.Unmarshal(v, &t); err != nil {
+			return errors.Wrapf(err, "deserializing API token at key %q", name)
+		}
+		deleted = &t
+		return bucket.Delete([]byte(name))
+	})
+	return deleted, errors.Wrap(err, "DB transaction failed")
+}
+
+// AddAuditEvent stores event. Events are keyed by a sequence number so that
+// they're stored in the order they were added, and the oldest events are
+// deleted once there are more than maxEvents.
+func (b *BoltDB) AddAuditEvent(event models.AuditEvent, maxEvents int) error {
+	serialized, err := json.Marshal(event)
+	if err != nil {
+		return errors.Wrap(err, "serializing")
+	}
+	err = b.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists(b.auditBucketName)
+		if err != nil {
+			return err
+		}
+		seq, err := bucket.NextSequence()
+		if err != nil {
+			return err
+		}
+		key := make([]byte, 8)
+		binary.BigEndian.PutUint64(key, seq)
+		if err := bucket.Put(key, serialized); err != nil {
+			return err
+		}
+		if maxEvents <= 0 || seq <= uint64(maxEvents) {
+			return nil
+		}
+		// Sequence numbers only grow so the events to delete come first.
+		c := bucket.Cursor()
+		for k, _ := c.First(); k != nil && binary.BigEndian.Uint64(k) <= seq-uint64(maxEvents); k, _ = c.First() {
+			if err := c.Delete(); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return errors.Wrap(err, "DB transaction failed")
+}
+
+// ListAuditEvents returns the audit events matching query, newest first.
+func (b *BoltDB) ListAuditEvents(query models.AuditQuery) ([]models.AuditEvent, error) {
+	var events []models.AuditEvent
+	err := b.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(b.auditBucketName)
+		if bucket == nil {
+			return nil
+		}
+		c := bucket.Cursor()
+		for k, v := c.Last(); k != nil; k, v = c.Prev() {
+			var e models.AuditEvent
+			if err := json.Unmarshal(v, &e); err != nil {
+				return errors.Wrapf(err, "deserializing audit event at key %x", k)
+			}
+			if !query.Matches(e) {
+				continue
+			}
+			events = append(events, e)
+			if query.Limit > 0 && len(events) == query.Limit {
+				break
+			}
+		}
+		return nil
+	})
+	return events, errors.Wrap(err, "DB transaction failed")
+}
+
+// AcquireLease stores lease if there's no lease with its name, the lease has
+// expired or it's held by the same replica. It returns the current lease and
+// whether it was acquired.
+func (b *BoltDB) AcquireLease(lease models.Lease) (models.Lease, bool, error) {
+	curr := lease
+	acquired := false
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists(b.leasesBucketName)
+		if err != nil {
+			return err
+		}
+		if serialized := bucket.Get([]byte(lease.Name)); serialized != nil {
+			var existing models.Lease
+			if err := json.Unmarshal(serialized, &existing); err != nil {
+				return errors.Wrapf(err, "deserializing lease %q", lease.Name)
+			}
+			if existing.Holder.ID != lease.Holder.ID && !existing.Expired(time.Now()) {
+				curr = existing
+				return nil
+			}
+		}
+		serialized, err := json.Marshal(lease)
+		if err != nil {
+			return errors.Wrap(err, "serializing")
+		}
+		acquired = true
+		return bucket.Put([]byte(lease.Name), serialized)
+	})
+	return curr, acquired, errors.Wrap(err, "DB transaction failed")
+}
+
+// ReleaseLease deletes the lease named name if it's held by the replica with
+// id holderID.
+func (b *BoltDB) ReleaseLease(name string, holderID string) error {
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists(b.leasesBucketName)
+		if err != nil {
+			return err
+		}
+		serialized := bucket.Get([]byte(name))
+		if serialized == nil {
+			return nil
+		}
+		var existing models.Lease
+		if err := json.Unmarshal(serialized, &existing); err != nil {
+			return errors.Wrapf(err, "deserializing lease %q", name)
+		}
+		if existing.Holder.ID != holderID {
+			return nil
+		}
+		return bucket.Delete([]byte(name))
+	})
+	return errors.Wrap(err, "DB transaction failed")
+}
+
+// ListLeases returns all leases that haven't expired.
+func (b *BoltDB) ListLeases() ([]models.Lease, error) {
+	var leases []models.Lease
+	now := time.Now()
+	err := b.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(b.leasesBucketName)
+		if bucket == nil {
+			return nil
+		}
+		return bucket.ForEach(func(k, v []byte) error {
+			var lease models.Lease
+			if err := json.Unmarshal(v, &lease); err != nil {
+				return errors.Wrapf(err, "deserializing lease %q", k)
+			}
+			if !lease.Expired(now) {
+				leases = append(leases, lease)
+			}
+			return nil
+		})
+	})
+	return leases, errors.Wrap(err, "DB transaction failed")
+}
+
 // DeletePullStatus deletes the status for pull.
 func (b *BoltDB) DeletePullStatus(pull models.PullRequest) error {
 	key, err := b.pullKey(pull)
@@ -420,6 +694,81 @@ func (b *BoltDB) DeletePullStatus(pull models.PullRequest) error {
 	return errors.Wrap(err, "DB transaction failed")
 }
 
+// Restore stores everything in one transaction, so if storing anything fails
+// nothing is stored. Audit events must be ordered oldest first.
+func (b *BoltDB) Restore(locks []models.ProjectLock, pullStatuses []models.PullStatus, commandLocks []command.Lock, tokens []models.APIToken, auditEvents []models.AuditEvent) error {
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		locksBucket := tx.Bucket(b.locksBucketName)
+		for _, lock := range locks {
+			serialized, err := json.Marshal(lock)
+			if err != nil {
+				return errors.Wrap(err, "serializing")
+			}
+			if err := locksBucket.Put([]byte(b.lockKey(lock.Project, lock.Workspace)), serialized); err != nil {
+				return err
+			}
+		}
+
+		pullsBucket := tx.Bucket(b.pullsBucketName)
+		for _, status := range pullStatuses {
+			key, err := b.pullKey(status.Pull)
+			if err != nil {
+				return err
+			}
+			if err := b.writePullToBucket(pullsBucket, key, status); err != nil {
+				return err
+			}
+		}
+
+		globalLocksBucket := tx.Bucket(b.globalLocksBucketName)
+		for _, lock := range commandLocks {
+			serialized, err := json.Marshal(lock)
+			if err != nil {
+				return errors.Wrap(err, "serializing")
+			}
+			if err := globalLocksBucket.Put([]byte(b.commandLockKey(lock.CommandName)), serialized); err != nil {
+				return err
+			}
+		}
+
+		tokensBucket, err := tx.CreateBucketIfNotExists(b.apiTokensBucketName)
+		if err != nil {
+			return err
+		}
+		for _, token := range tokens {
+			serialized, err := json.Marshal(token)
+			if err != nil {
+				return errors.Wrap(err, "serializing")
+			}
+			if err := tokensBucket.Put([]byte(token.Name), serialized); err != nil {
+				return err
+			}
+		}
+
+		auditBucket, err := tx.CreateBucketIfNotExists(b.auditBucketName)
+		if err != nil {
+			return err
+		}
+		for _, event := range auditEvents {
+			serialized, err := json.Marshal(event)
+			if err != nil {
+				return errors.Wrap(err, "serializing")
+			}
+			seq, err := auditBucket.NextSequence()
+			if err != nil {
+				return err
+			}
+			key := make([]byte, 8)
+			binary.BigEndian.PutUint64(key, seq)
+			if err := auditBucket.Put(key, serialized); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return errors.Wrap(err, "DB transaction failed")
+}
+
 // UpdateProjectStatus updates project status.
 func (b *BoltDB) UpdateProjectStatus(pull models.PullRequest, workspace string, repoRelDir string, newStatus models.ProjectPlanStatus) error {
 	key, err := b.pullKey(pull)
@@ -447,6 +796,7 @@ func (b *BoltDB) UpdateProjectStatus(pull models.PullRequest, workspace string,
 				break
 			}
 		}
+		currStatus.UpdatedAt = time.Now()
 		return b.writePullToBucket(bucket, key, currStatus)
 	})
 	return errors.Wrap(err, "DB transaction failed")
@@ -502,5 +852,6 @@ func (b *BoltDB) projectResultToProject(p command.ProjectResult) models.ProjectS
 		ProjectName:  p.ProjectName,
 		PolicyStatus: p.PolicyStatus(),
 		Status:       p.PlanStatus(),
+		PlanDigest:   p.PlanDigest(),
 	}
 }
@@ -15,6 +15,7 @@ package db_test
 
 import (
 	"os"
+	"sort"
 	"testing"
 	"time"
 
@@ -245,6 +246,37 @@ func TestLockingExistingLock(t *testing.T) {
 	}
 }
 
+func TestLockingRenewsLockOfSamePull(t *testing.T) {
+	db, b := newTestDB()
+	defer cleanupDB(db)
+	expiringLock := lock
+	expiringLock.ExpiresAt = time.Now().Add(time.Hour)
+	_, _, err := b.TryLock(expiringLock)
+	Ok(t, err)
+
+	t.Log("taking the lock again before it expires should renew it")
+	renewed := expiringLock
+	renewed.Time = expiringLock.Time.Add(time.Hour)
+	renewed.ExpiresAt = expiringLock.ExpiresAt.Add(time.Hour)
+	acquired, currLock, err := b.TryLock(renewed)
+	Ok(t, err)
+	Equals(t, false, acquired)
+	Assert(t, currLock.ExpiresAt.Equal(renewed.ExpiresAt), "exp lock to expire at %s, got %s", renewed.ExpiresAt, currLock.ExpiresAt)
+	stored, err := b.GetLock(project, workspace)
+	Ok(t, err)
+	Assert(t, stored.ExpiresAt.Equal(renewed.ExpiresAt), "exp stored lock to expire at %s, got %s", renewed.ExpiresAt, stored.ExpiresAt)
+	Assert(t, stored.Time.Equal(renewed.Time), "exp stored lock to be taken at %s, got %s", renewed.Time, stored.Time)
+
+	t.Log("another pull request should not renew it")
+	other := renewed
+	other.Pull.Num = pullNum + 1
+	other.ExpiresAt = renewed.ExpiresAt.Add(time.Hour)
+	acquired, currLock, err = b.TryLock(other)
+	Ok(t, err)
+	Equals(t, false, acquired)
+	Assert(t, currLock.ExpiresAt.Equal(renewed.ExpiresAt), "exp lock to expire at %s, got %s", renewed.ExpiresAt, currLock.ExpiresAt)
+}
+
 func TestUnlockingNoLocks(t *testing.T) {
 	t.Log("unlocking with no locks should succeed")
 	db, b := newTestDB()
@@ -523,6 +555,64 @@ func TestPullStatus_UpdateDeleteGet(t *testing.T) {
 	Assert(t, maybeStatus == nil, "exp nil")
 }
 
+// Test that we list the status of every pull request, that we record the last
+// command and that a new commit keeps when we first saw the pull request.
+func TestPullStatus_List(t *testing.T) {
+	b := newTestDB2(t)
+
+	statuses, err := b.ListPullStatuses()
+	Ok(t, err)
+	Equals(t, 0, len(statuses))
+
+	// Locks must not be listed as pull statuses.
+	_, _, err = b.TryLock(lock)
+	Ok(t, err)
+
+	repo := models.Repo{
+		FullName: "runatlantis/atlantis",
+		VCSHost: models.VCSHost{
+			Hostname: "github.com",
+			Type:     models.Github,
+		},
+	}
+	pull1 := models.PullRequest{Num: 1, HeadCommit: "sha", BaseRepo: repo, State: models.OpenPullState}
+	pull2 := models.PullRequest{Num: 2, HeadCommit: "sha", BaseRepo: repo, State: models.OpenPullState}
+	first, err := b.UpdatePullWithResults(pull1, []command.ProjectResult{
+		{Command: command.Plan, RepoRelDir: ".", Workspace: "default", PlanSuccess: &models.PlanSuccess{}},
+	})
+	Ok(t, err)
+	Equals(t, "plan", first.LastCommand)
+	Assert(t, !first.CreatedAt.IsZero(), "exp CreatedAt to be set")
+	Equals(t, first.CreatedAt, first.UpdatedAt)
+	_, err = b.UpdatePullWithResults(pull2, []command.ProjectResult{
+		{Command: command.Plan, RepoRelDir: ".", Workspace: "default", Error: errors.New("err")},
+	})
+	Ok(t, err)
+
+	pull1.HeadCommit = "newsha"
+	updated, err := b.UpdatePullWithResults(pull1, []command.ProjectResult{
+		{Command: command.Apply, RepoRelDir: ".", Workspace: "default", ApplySuccess: "success"},
+	})
+	Ok(t, err)
+	Equals(t, "apply", updated.LastCommand)
+	Assert(t, first.CreatedAt.Equal(updated.CreatedAt), "exp CreatedAt to be kept")
+	Assert(t, !updated.UpdatedAt.Before(first.UpdatedAt), "exp UpdatedAt to be updated")
+
+	statuses, err = b.ListPullStatuses()
+	Ok(t, err)
+	Equals(t, 2, len(statuses))
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Pull.Num < statuses[j].Pull.Num })
+	Equals(t, "newsha", statuses[0].Pull.HeadCommit)
+	Equals(t, models.AppliedPlanStatus, statuses[0].Projects[0].Status)
+	Equals(t, "apply", statuses[0].LastCommand)
+	Equals(t, models.ErroredPlanStatus, statuses[1].Projects[0].Status)
+
+	Ok(t, b.DeletePullStatus(pull2))
+	statuses, err = b.ListPullStatuses()
+	Ok(t, err)
+	Equals(t, 1, len(statuses))
+}
+
 // Test we can create a status, update a specific project's status within that
 // pull status, and when we getCommandLock all the project statuses, that specific project
 // should be updated.
@@ -751,6 +841,7 @@ func TestPullStatus_UpdateMerge_Apply(t *testing.T) {
 				RepoRelDir: "staythesame",
 				Workspace:  "default",
 				Status:     models.PlannedPlanStatus,
+				PlanDigest: (&models.PlanSuccess{TerraformOutput: "tf out"}).ChangesDigest(),
 			},
 			{
 				RepoRelDir: "newresult",
@@ -876,6 +967,117 @@ func TestPullStatus_UpdateMerge_ApprovePolicies(t *testing.T) {
 }
 
 // newTestDB returns a TestDB using a temporary path.
+func TestAPITokens(t *testing.T) {
+	b := newTestDB2(t)
+
+	tokens, err := b.ListAPITokens()
+	Ok(t, err)
+	Equals(t, 0, len(tokens))
+
+	token := models.APIToken{Name: "ci", Repos: "github.com/owner/.*", Commands: []string{"plan"}, SecretHash: "hash"}
+	Ok(t, b.CreateAPIToken(token))
+	ErrContains(t, `token "ci" already exists`, b.CreateAPIToken(token))
+
+	tokens, err = b.ListAPITokens()
+	Ok(t, err)
+	Equals(t, []models.APIToken{token}, tokens)
+
+	deleted, err := b.DeleteAPIToken("ci")
+	Ok(t, err)
+	Equals(t, &token, deleted)
+	deleted, err = b.DeleteAPIToken("ci")
+	Ok(t, err)
+	Assert(t, deleted == nil, "exp no token to be deleted")
+}
+
+func TestLeases(t *testing.T) {
+	b := newTestDB2(t)
+
+	alice := models.Lease{Name: "leader", Holder: models.Replica{ID: "alice"}, ExpiresAt: time.Now().Add(time.Hour)}
+	bob := models.Lease{Name: "leader", Holder: models.Replica{ID: "bob"}, ExpiresAt: time.Now().Add(time.Hour)}
+
+	curr, acquired, err := b.AcquireLease(alice)
+	Ok(t, err)
+	Assert(t, acquired, "exp lease to be acquired")
+	Equals(t, "alice", curr.Holder.ID)
+
+	t.Log("a lease held by another replica can't be acquired")
+	curr, acquired, err = b.AcquireLease(bob)
+	Ok(t, err)
+	Assert(t, !acquired, "exp lease not to be acquired")
+	Equals(t, "alice", curr.Holder.ID)
+
+	t.Log("the holder can renew its lease")
+	alice.RenewedAt = time.Now()
+	_, acquired, err = b.AcquireLease(alice)
+	Ok(t, err)
+	Assert(t, acquired, "exp lease to be renewed")
+
+	t.Log("only the holder can release its lease")
+	Ok(t, b.ReleaseLease("leader", "bob"))
+	leases, err := b.ListLeases()
+	Ok(t, err)
+	Equals(t, 1, len(leases))
+	Ok(t, b.ReleaseLease("leader", "alice"))
+	leases, err = b.ListLeases()
+	Ok(t, err)
+	Equals(t, 0, len(leases))
+
+	t.Log("an expired lease can be taken over")
+	alice.ExpiresAt = time.Now().Add(-time.Second)
+	_, acquired, err = b.AcquireLease(alice)
+	Ok(t, err)
+	Assert(t, acquired, "exp lease to be acquired")
+	curr, acquired, err = b.AcquireLease(bob)
+	Ok(t, err)
+	Assert(t, acquired, "exp expired lease to be taken over")
+	Equals(t, "bob", curr.Holder.ID)
+}
+
+func TestAuditEvents(t *testing.T) {
+	b := newTestDB2(t)
+
+	events, err := b.ListAuditEvents(models.AuditQuery{})
+	Ok(t, err)
+	Equals(t, 0, len(events))
+
+	// Add more events than are read in one page.
+	start := time.Date(2023, 3, 3, 0, 0, 0, 0, time.UTC)
+	var all []models.AuditEvent
+	for i := 0; i < 150; i++ {
+		e := models.AuditEvent{
+			Time:    start.Add(time.Duration(i) * time.Minute),
+			Type:    models.CommandAuditEvent,
+			Actor:   "alice",
+			Repo:    "owner/repo",
+			Pull:    i%2 + 1,
+			Command: "plan",
+			Outcome: models.AuditSuccess,
+		}
+		Ok(t, b.AddAuditEvent(e, 0))
+		all = append([]models.AuditEvent{e}, all...)
+	}
+
+	events, err = b.ListAuditEvents(models.AuditQuery{})
+	Ok(t, err)
+	Equals(t, all, events)
+
+	events, err = b.ListAuditEvents(models.AuditQuery{Pull: 2, Limit: 3})
+	Ok(t, err)
+	Equals(t, []models.AuditEvent{all[0], all[2], all[4]}, events)
+
+	events, err = b.ListAuditEvents(models.AuditQuery{Since: start.Add(148 * time.Minute)})
+	Ok(t, err)
+	Equals(t, all[:2], events)
+
+	t.Log("only the newest events are kept")
+	e := models.AuditEvent{Time: start.Add(150 * time.Minute), Type: models.LockAuditEvent, Actor: "bob"}
+	Ok(t, b.AddAuditEvent(e, 100))
+	events, err = b.ListAuditEvents(models.AuditQuery{})
+	Ok(t, err)
+	Equals(t, append([]models.AuditEvent{e}, all[:99]...), events)
+}
+
 func newTestDB() (*bolt.DB, *db.BoltDB) {
 	// Retrieve a temporary path.
 	f, err := os.CreateTemp("", "")
@@ -0,0 +1,218 @@
+package locking
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+)
+
+// DumpVersion is the version of the Dump format. It must be incremented
+// whenever the format changes in a way that older versions can't import.
+const DumpVersion = 1
+
+// dumpedCommandNames are the commands whose command locks are dumped. There's
+// no way to list command locks so every command is checked.
+var dumpedCommandNames = []command.Name{
+	command.Apply,
+	command.Plan,
+	command.Unlock,
+	command.PolicyCheck,
+	command.ApprovePolicies,
+	command.Autoplan,
+	command.Version,
+	command.Import,
+	command.State,
+}
+
+// Dump is everything stored in a Backend. It's used to back up a database and
+// to move from one database type to another.
+type Dump struct {
+	// Version is the version of the format, see DumpVersion.
+	Version      int                  `json:"version"`
+	CreatedAt    time.Time            `json:"created_at"`
+	Locks        []models.ProjectLock `json:"locks"`
+	PullStatuses []models.PullStatus  `json:"pull_statuses"`
+	CommandLocks []command.Lock       `json:"command_locks"`
+	APITokens    []models.APIToken    `json:"api_tokens"`
+	// AuditEvents are ordered newest first.
+	AuditEvents []models.AuditEvent `json:"audit_events"`
+}
+
+// Empty returns true if the dump has no data.
+func (d Dump) Empty() bool {
+	return len(d.Locks) == 0 && len(d.PullStatuses) == 0 && len(d.CommandLocks) == 0 &&
+		len(d.APITokens) == 0 && len(d.AuditEvents) == 0
+}
+
+// Export dumps everything stored in backend. Locks, pull statuses and API
+// tokens are sorted so that dumps of the same data are identical.
+func Export(backend Backend) (Dump, error) {
+	dump := Dump{
+		Version:   DumpVersion,
+		CreatedAt: time.Now().UTC(),
+	}
+	var err error
+	if dump.Locks, err = backend.List(); err != nil {
+		return dump, errors.Wrap(err, "listing locks")
+	}
+	sort.Slice(dump.Locks, func(i, j int) bool {
+		return lockKey(dump.Locks[i]) < lockKey(dump.Locks[j])
+	})
+
+	if dump.PullStatuses, err = backend.ListPullStatuses(); err != nil {
+		return dump, errors.Wrap(err, "listing pull statuses")
+	}
+	sort.Slice(dump.PullStatuses, func(i, j int) bool {
+		return pullStatusKey(dump.PullStatuses[i]) < pullStatusKey(dump.PullStatuses[j])
+	})
+
+	for _, name := range dumpedCommandNames {
+		lock, err := backend.CheckCommandLock(name)
+		if err != nil {
+			return dump, errors.Wrapf(err, "checking %s command lock", name)
+		}
+		if lock != nil && lock.IsLocked() {
+			dump.CommandLocks = append(dump.CommandLocks, *lock)
+		}
+	}
+
+	if dump.APITokens, err = backend.ListAPITokens(); err != nil {
+		return dump, errors.Wrap(err, "listing API tokens")
+	}
+	sort.Slice(dump.APITokens, func(i, j int) bool {
+		return dump.APITokens[i].Name < dump.APITokens[j].Name
+	})
+
+	if dump.AuditEvents, err = backend.ListAuditEvents(models.AuditQuery{}); err != nil {
+		return dump, errors.Wrap(err, "listing audit events")
+	}
+	return dump, nil
+}
+
+// Import restores dump into backend, which must be empty so that the data
+// isn't mixed with data that's already there. The dump is checked before
+// anything is stored and then stored all at once, so a failed import leaves
+// the database empty.
+func Import(backend Backend, dump Dump) error {
+	if dump.Version != DumpVersion {
+		return fmt.Errorf("dump is version %d but this version of Atlantis only supports version %d", dump.Version, DumpVersion)
+	}
+	if err := validateDump(dump); err != nil {
+		return err
+	}
+	existing, err := Export(backend)
+	if err != nil {
+		return errors.Wrap(err, "checking the database is empty")
+	}
+	if !existing.Empty() {
+		return errors.New("the database isn't empty, data can only be imported into an empty database")
+	}
+
+	// Events are stored oldest first so they're listed in the same order.
+	events := make([]models.AuditEvent, 0, len(dump.AuditEvents))
+	for i := len(dump.AuditEvents) - 1; i >= 0; i-- {
+		events = append(events, dump.AuditEvents[i])
+	}
+	return errors.Wrap(backend.Restore(dump.Locks, dump.PullStatuses, dump.CommandLocks, dump.APITokens, events), "restoring the database")
+}
+
+// validateDump returns an error if two items in dump would be stored under
+// the same key, since restoring them would silently drop one of them.
+func validateDump(dump Dump) error {
+	if err := checkUnique("lock", dump.Locks, lockKey); err != nil {
+		return err
+	}
+	if err := checkUnique("pull status", dump.PullStatuses, pullStatusKey); err != nil {
+		return err
+	}
+	if err := checkUnique("command lock", dump.CommandLocks, func(l command.Lock) string {
+		return l.CommandName.String()
+	}); err != nil {
+		return err
+	}
+	return checkUnique("API token", dump.APITokens, func(t models.APIToken) string {
+		return t.Name
+	})
+}
+
+func checkUnique[T any](kind string, items []T, key func(T) string) error {
+	seen := make(map[string]bool)
+	for _, item := range items {
+		k := key(item)
+		if seen[k] {
+			return fmt.Errorf("dump has more than one %s %q", kind, k)
+		}
+		seen[k] = true
+	}
+	return nil
+}
+
+// CompareDumps returns a description of each difference between the data in
+// a and b. It returns nil if they contain the same data.
+func CompareDumps(a Dump, b Dump) []string {
+	var diffs []string
+	diffs = append(diffs, compareItems("lock", a.Locks, b.Locks, lockKey)...)
+	diffs = append(diffs, compareItems("pull status", a.PullStatuses, b.PullStatuses, pullStatusKey)...)
+	diffs = append(diffs, compareItems("command lock", a.CommandLocks, b.CommandLocks, func(l command.Lock) string {
+		return l.CommandName.String()
+	})...)
+	diffs = append(diffs, compareItems("API token", a.APITokens, b.APITokens, func(t models.APIToken) string {
+		return t.Name
+	})...)
+	if len(a.AuditEvents) != len(b.AuditEvents) {
+		diffs = append(diffs, fmt.Sprintf("%d audit events != %d audit events", len(a.AuditEvents), len(b.AuditEvents)))
+	} else {
+		for i := range a.AuditEvents {
+			if !jsonEqual(a.AuditEvents[i], b.AuditEvents[i]) {
+				diffs = append(diffs, fmt.Sprintf("audit event %d differs", i))
+			}
+		}
+	}
+	return diffs
+}
+
+// compareItems compares the items in a and b with the same key. Items are
+// compared by their JSON encoding since that's how they're stored.
+func compareItems[T any](kind string, a []T, b []T, key func(T) string) []string {
+	bByKey := make(map[string]T)
+	for _, item := range b {
+		bByKey[key(item)] = item
+	}
+	var diffs []string
+	seen := make(map[string]bool)
+	for _, item := range a {
+		k := key(item)
+		seen[k] = true
+		other, ok := bByKey[k]
+		if !ok {
+			diffs = append(diffs, fmt.Sprintf("%s %q is missing from the second database", kind, k))
+		} else if !jsonEqual(item, other) {
+			diffs = append(diffs, fmt.Sprintf("%s %q differs", kind, k))
+		}
+	}
+	for _, item := range b {
+		if k := key(item); !seen[k] {
+			diffs = append(diffs, fmt.Sprintf("%s %q is missing from the first database", kind, k))
+		}
+	}
+	return diffs
+}
+
+func jsonEqual(a interface{}, b interface{}) bool {
+	aJSON, aErr := json.Marshal(a)
+	bJSON, bErr := json.Marshal(b)
+	return aErr == nil && bErr == nil && string(aJSON) == string(bJSON)
+}
+
+func lockKey(lock models.ProjectLock) string {
+	return fmt.Sprintf("%s/%s/%s", lock.Project.RepoFullName, lock.Project.Path, lock.Workspace)
+}
+
+func pullStatusKey(status models.PullStatus) string {
+	return fmt.Sprintf("%s/%s#%d", status.Pull.BaseRepo.VCSHost.Hostname, status.Pull.BaseRepo.FullName, status.Pull.Num)
+}
@@ -0,0 +1,147 @@
+package locking_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/runatlantis/atlantis/server/core/db"
+	"github.com/runatlantis/atlantis/server/core/locking"
+	"github.com/runatlantis/atlantis/server/core/redis"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+// populate stores one of everything in backend.
+func populate(t *testing.T, backend locking.Backend) {
+	repo := models.Repo{FullName: "owner/repo", VCSHost: models.VCSHost{Hostname: "github.com", Type: models.Github}}
+	pull := models.PullRequest{Num: 1, BaseRepo: repo, HeadBranch: "branch"}
+	now := time.Now().Round(time.Second)
+	for _, path := range []string{"a", "b"} {
+		acquired, _, err := backend.TryLock(models.ProjectLock{
+			Project:   models.NewProject("owner/repo", path),
+			Workspace: "default",
+			Pull:      pull,
+			User:      models.User{Username: "alice"},
+			Time:      now,
+			ExpiresAt: now.Add(time.Hour),
+		})
+		Ok(t, err)
+		Assert(t, acquired, "exp lock to be acquired")
+	}
+	_, err := backend.UpdatePullWithResults(pull, []command.ProjectResult{
+		{RepoRelDir: "a", Workspace: "default", PlanSuccess: &models.PlanSuccess{}},
+	})
+	Ok(t, err)
+	Ok(t, backend.UpdateProjectStatus(pull, "default", "a", models.DiscardedPlanStatus))
+	_, err = backend.LockCommand(command.Apply, now)
+	Ok(t, err)
+	Ok(t, backend.CreateAPIToken(models.APIToken{Name: "ci", Commands: []string{"plan"}, CreatedAt: now, SecretHash: "hash"}))
+	for _, actor := range []string{"alice", "bob", "carol"} {
+		Ok(t, backend.AddAuditEvent(models.AuditEvent{Time: now, Type: models.LockAuditEvent, Actor: actor}, 0))
+	}
+}
+
+func TestExportImport(t *testing.T) {
+	bolt, err := db.New(t.TempDir())
+	Ok(t, err)
+	defer bolt.Close() // nolint: errcheck
+	populate(t, bolt)
+
+	dump, err := locking.Export(bolt)
+	Ok(t, err)
+	Equals(t, locking.DumpVersion, dump.Version)
+	Equals(t, 2, len(dump.Locks))
+	Equals(t, 1, len(dump.PullStatuses))
+	Equals(t, models.DiscardedPlanStatus, dump.PullStatuses[0].Projects[0].Status)
+	Equals(t, 1, len(dump.CommandLocks))
+	Equals(t, 1, len(dump.APITokens))
+	Equals(t, 3, len(dump.AuditEvents))
+	Equals(t, "carol", dump.AuditEvents[0].Actor)
+
+	mr := miniredis.RunT(t)
+	rdb, err := redis.New(mr.Host(), mr.Server().Addr().Port, "", false, false, 0)
+	Ok(t, err)
+	defer rdb.Close() // nolint: errcheck
+	Ok(t, locking.Import(rdb, dump))
+
+	imported, err := locking.Export(rdb)
+	Ok(t, err)
+	Equals(t, 0, len(locking.CompareDumps(dump, imported)))
+	Equals(t, "carol", imported.AuditEvents[0].Actor)
+
+	t.Log("data can't be imported into a database that isn't empty")
+	ErrEquals(t, "the database isn't empty, data can only be imported into an empty database", locking.Import(rdb, dump))
+
+	t.Log("dumps of other versions can't be imported")
+	dump.Version = 2
+	ErrEquals(t, "dump is version 2 but this version of Atlantis only supports version 1", locking.Import(rdb, dump))
+}
+
+func TestImport_Failure(t *testing.T) {
+	bolt, err := db.New(t.TempDir())
+	Ok(t, err)
+	defer bolt.Close() // nolint: errcheck
+	populate(t, bolt)
+	dump, err := locking.Export(bolt)
+	Ok(t, err)
+
+	mr := miniredis.RunT(t)
+	rdb, err := redis.New(mr.Host(), mr.Server().Addr().Port, "", false, false, 0)
+	Ok(t, err)
+	defer rdb.Close() // nolint: errcheck
+
+	for name, backend := range map[string]locking.Backend{"boltdb": newBoltDB(t), "redis": rdb} {
+		t.Run(name, func(t *testing.T) {
+			t.Log("dumps with duplicate keys are rejected before anything is stored")
+			duplicate := dump
+			duplicate.APITokens = append(duplicate.APITokens, dump.APITokens[0])
+			ErrEquals(t, `dump has more than one API token "ci"`, locking.Import(backend, duplicate))
+			assertEmpty(t, backend)
+
+			t.Log("nothing is stored if storing any of the data fails")
+			bad := dump
+			bad.PullStatuses = append([]models.PullStatus{}, dump.PullStatuses...)
+			bad.PullStatuses[0].Pull.BaseRepo.VCSHost.Hostname = "bad::host"
+			Assert(t, locking.Import(backend, bad) != nil, "exp import to fail")
+			assertEmpty(t, backend)
+
+			Ok(t, locking.Import(backend, dump))
+		})
+	}
+}
+
+func newBoltDB(t *testing.T) *db.BoltDB {
+	bolt, err := db.New(t.TempDir())
+	Ok(t, err)
+	t.Cleanup(func() { bolt.Close() }) // nolint: errcheck
+	return bolt
+}
+
+func assertEmpty(t *testing.T, backend locking.Backend) {
+	t.Helper()
+	dump, err := locking.Export(backend)
+	Ok(t, err)
+	Assert(t, dump.Empty(), "exp database to be empty but got %+v", dump)
+}
+
+func TestCompareDumps(t *testing.T) {
+	lock := models.ProjectLock{Project: models.NewProject("owner/repo", "a"), Workspace: "default", User: models.User{Username: "alice"}}
+	otherLock := lock
+	otherLock.User.Username = "bob"
+	token := models.APIToken{Name: "ci"}
+
+	a := locking.Dump{Locks: []models.ProjectLock{lock}, APITokens: []models.APIToken{token}}
+	Equals(t, 0, len(locking.CompareDumps(a, a)))
+
+	b := locking.Dump{
+		Locks:       []models.ProjectLock{otherLock},
+		AuditEvents: []models.AuditEvent{{Actor: "alice"}},
+	}
+	Equals(t, []string{
+		`lock "owner/repo/a/default" differs`,
+		`API token "ci" is missing from the second database`,
+		"0 audit events != 1 audit events",
+	}, locking.CompareDumps(a, b))
+}
@@ -30,6 +30,10 @@ import (
 
 // Backend is an implementation of the locking API we require.
 type Backend interface {
+	// TryLock stores lock if its project and workspace aren't locked. If
+	// they're locked by the same pull request, the existing lock is renewed
+	// with lock's Time and ExpiresAt. It returns whether the lock was acquired
+	// and the current lock.
 	TryLock(lock models.ProjectLock) (bool, models.ProjectLock, error)
 	Unlock(project models.Project, workspace string) (*models.ProjectLock, error)
 	List() ([]models.ProjectLock, error)
@@ -37,12 +41,43 @@ type Backend interface {
 	UnlockByPull(repoFullName string, pullNum int) ([]models.ProjectLock, error)
 	UpdateProjectStatus(pull models.PullRequest, workspace string, repoRelDir string, newStatus models.ProjectPlanStatus) error
 	GetPullStatus(pull models.PullRequest) (*models.PullStatus, error)
+	ListPullStatuses() ([]models.PullStatus, error)
 	DeletePullStatus(pull models.PullRequest) error
 	UpdatePullWithResults(pull models.PullRequest, newResults []command.ProjectResult) (models.PullStatus, error)
 
 	LockCommand(cmdName command.Name, lockTime time.Time) (*command.Lock, error)
 	UnlockCommand(cmdName command.Name) error
 	CheckCommandLock(cmdName command.Name) (*command.Lock, error)
+
+	// CreateAPIToken stores token. It returns an error if a token with the
+	// same name already exists.
+	CreateAPIToken(token models.APIToken) error
+	ListAPITokens() ([]models.APIToken, error)
+	// DeleteAPIToken deletes the token named name. It returns nil if there
+	// was no such token.
+	DeleteAPIToken(name string) (*models.APIToken, error)
+
+	// AcquireLease stores lease if there's no lease with its name, the lease
+	// has expired or it's held by the same replica, which renews it. It
+	// returns the current lease and whether it was acquired.
+	AcquireLease(lease models.Lease) (models.Lease, bool, error)
+	// ReleaseLease deletes the lease named name if it's held by the replica
+	// with id holderID.
+	ReleaseLease(name string, holderID string) error
+	// ListLeases returns all leases that haven't expired.
+	ListLeases() ([]models.Lease, error)
+
+	// AddAuditEvent stores event. If maxEvents is positive, only the newest
+	// maxEvents events are kept.
+	AddAuditEvent(event models.AuditEvent, maxEvents int) error
+	// ListAuditEvents returns the audit events matching query, newest first.
+	ListAuditEvents(query models.AuditQuery) ([]models.AuditEvent, error)
+
+	// Restore stores locks, pull statuses, command locks, API tokens and audit
+	// events, which are ordered oldest first, as is and all at once so that
+	// either all of them are stored or none are. Existing data with the same
+	// keys is replaced. It's used to restore a database from a dump.
+	Restore(locks []models.ProjectLock, pullStatuses []models.PullStatus, commandLocks []command.Lock, tokens []models.APIToken, auditEvents []models.AuditEvent) error
 }
 
 // TryLockResponse results from an attempted lock.
@@ -58,6 +93,10 @@ type TryLockResponse struct {
 // Client is used to perform locking actions.
 type Client struct {
 	backend Backend
+	// lockTTL returns how long locks on the repo with ID repoID last for,
+	// counted from when they're last taken. Locks don't expire if it's nil or
+	// returns 0.
+	lockTTL func(repoID string) time.Duration
 }
 
 //go:generate pegomock generate --package mocks -o mocks/mock_locker.go Locker
@@ -77,6 +116,15 @@ func NewClient(backend Backend) *Client {
 	}
 }
 
+// NewClientWithLockTTL returns a new locking client whose locks expire after
+// the duration lockTTL returns for their repo.
+func NewClientWithLockTTL(backend Backend, lockTTL func(repoID string) time.Duration) *Client {
+	return &Client{
+		backend: backend,
+		lockTTL: lockTTL,
+	}
+}
+
 // keyRegex matches and captures {repoFullName}/{path}/{workspace} where path can have multiple /'s in it.
 var keyRegex = regexp.MustCompile(`^(.*?\/.*?)\/(.*)\/(.*)$`)
 
@@ -89,6 +137,11 @@ func (c *Client) TryLock(p models.Project, workspace string, pull models.PullReq
 		User:      user,
 		Pull:      pull,
 	}
+	if c.lockTTL != nil {
+		if ttl := c.lockTTL(pull.BaseRepo.ID()); ttl > 0 {
+			lock.ExpiresAt = lock.Time.Add(ttl)
+		}
+	}
 	lockAcquired, currLock, err := c.backend.TryLock(lock)
 	if err != nil {
 		return TryLockResponse{}, err
@@ -57,6 +57,37 @@ func TestTryLock_Success(t *testing.T) {
 	Equals(t, locking.TryLockResponse{LockAcquired: true, CurrLock: currLock, LockKey: "owner/repo/path/workspace"}, r)
 }
 
+func TestTryLock_LockTTL(t *testing.T) {
+	RegisterMockTestingT(t)
+	backend := mocks.NewMockBackend()
+	When(backend.TryLock(Any[models.ProjectLock]())).ThenReturn(true, models.ProjectLock{}, nil)
+	ttlPull := models.PullRequest{BaseRepo: models.Repo{FullName: "owner/repo", VCSHost: models.VCSHost{Hostname: "github.com"}}}
+	var gotRepoID string
+	l := locking.NewClientWithLockTTL(backend, func(repoID string) time.Duration {
+		gotRepoID = repoID
+		return time.Hour
+	})
+	_, err := l.TryLock(project, workspace, ttlPull, user)
+	Ok(t, err)
+	Equals(t, "github.com/owner/repo", gotRepoID)
+	lock := backend.VerifyWasCalledOnce().TryLock(Any[models.ProjectLock]()).GetCapturedArguments()
+	Equals(t, time.Hour, lock.ExpiresAt.Sub(lock.Time))
+	Assert(t, !lock.Expired(lock.Time), "exp lock not to be expired when it's created")
+	Assert(t, lock.Expired(lock.Time.Add(time.Hour)), "exp lock to be expired after its TTL")
+}
+
+func TestTryLock_NoLockTTL(t *testing.T) {
+	RegisterMockTestingT(t)
+	backend := mocks.NewMockBackend()
+	When(backend.TryLock(Any[models.ProjectLock]())).ThenReturn(true, models.ProjectLock{}, nil)
+	l := locking.NewClientWithLockTTL(backend, func(string) time.Duration { return 0 })
+	_, err := l.TryLock(project, workspace, pull, user)
+	Ok(t, err)
+	lock := backend.VerifyWasCalledOnce().TryLock(Any[models.ProjectLock]()).GetCapturedArguments()
+	Assert(t, lock.ExpiresAt.IsZero(), "exp lock without a TTL to never expire")
+	Assert(t, !lock.Expired(lock.Time.Add(24*365*time.Hour)), "exp lock without a TTL to never expire")
+}
+
 func TestUnlock_InvalidKey(t *testing.T) {
 	RegisterMockTestingT(t)
 	backend := mocks.NewMockBackend()
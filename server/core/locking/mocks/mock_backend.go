@@ -26,6 +26,44 @@ func NewMockBackend(options ...pegomock.Option) *MockBackend {
 func (mock *MockBackend) SetFailHandler(fh pegomock.FailHandler) { mock.fail = fh }
 func (mock *MockBackend) FailHandler() pegomock.FailHandler      { return mock.fail }
 
+func (mock *MockBackend) AcquireLease(lease models.Lease) (models.Lease, bool, error) {
+	if mock == nil {
+		panic("mock must not be nil. Use myMock := NewMockBackend().")
+	}
+	params := []pegomock.Param{lease}
+	result := pegomock.GetGenericMockFrom(mock).Invoke("AcquireLease", params, []reflect.Type{reflect.TypeOf((*models.Lease)(nil)).Elem(), reflect.TypeOf((*bool)(nil)).Elem(), reflect.TypeOf((*error)(nil)).Elem()})
+	var ret0 models.Lease
+	var ret1 bool
+	var ret2 error
+	if len(result) != 0 {
+		if result[0] != nil {
+			ret0 = result[0].(models.Lease)
+		}
+		if result[1] != nil {
+			ret1 = result[1].(bool)
+		}
+		if result[2] != nil {
+			ret2 = result[2].(error)
+		}
+	}
+	return ret0, ret1, ret2
+}
+
+func (mock *MockBackend) AddAuditEvent(event models.AuditEvent, maxEvents int) error {
+	if mock == nil {
+		panic("mock must not be nil. Use myMock := NewMockBackend().")
+	}
+	params := []pegomock.Param{event, maxEvents}
+	result := pegomock.GetGenericMockFrom(mock).Invoke("AddAuditEvent", params, []reflect.Type{reflect.TypeOf((*error)(nil)).Elem()})
+	var ret0 error
+	if len(result) != 0 {
+		if result[0] != nil {
+			ret0 = result[0].(error)
+		}
+	}
+	return ret0
+}
+
 func (mock *MockBackend) CheckCommandLock(cmdName command.Name) (*command.Lock, error) {
 	if mock == nil {
 		panic("mock must not be nil. Use myMock := NewMockBackend().")
@@ -45,6 +83,40 @@ func (mock *MockBackend) CheckCommandLock(cmdName command.Name) (*command.Lock,
 	return ret0, ret1
 }
 
+func (mock *MockBackend) CreateAPIToken(token models.APIToken) error {
+	if mock == nil {
+		panic("mock must not be nil. Use myMock := NewMockBackend().")
+	}
+	params := []pegomock.Param{token}
+	result := pegomock.GetGenericMockFrom(mock).Invoke("CreateAPIToken", params, []reflect.Type{reflect.TypeOf((*error)(nil)).Elem()})
+	var ret0 error
+	if len(result) != 0 {
+		if result[0] != nil {
+			ret0 = result[0].(error)
+		}
+	}
+	return ret0
+}
+
+func (mock *MockBackend) DeleteAPIToken(name string) (*models.APIToken, error) {
+	if mock == nil {
+		panic("mock must not be nil. Use myMock := NewMockBackend().")
+	}
+	params := []pegomock.Param{name}
+	result := pegomock.GetGenericMockFrom(mock).Invoke("DeleteAPIToken", params, []reflect.Type{reflect.TypeOf((**models.APIToken)(nil)).Elem(), reflect.TypeOf((*error)(nil)).Elem()})
+	var ret0 *models.APIToken
+	var ret1 error
+	if len(result) != 0 {
+		if result[0] != nil {
+			ret0 = result[0].(*models.APIToken)
+		}
+		if result[1] != nil {
+			ret1 = result[1].(error)
+		}
+	}
+	return ret0, ret1
+}
+
 func (mock *MockBackend) DeletePullStatus(pull models.PullRequest) error {
 	if mock == nil {
 		panic("mock must not be nil. Use myMock := NewMockBackend().")
@@ -117,6 +189,63 @@ func (mock *MockBackend) List() ([]models.ProjectLock, error) {
 	return ret0, ret1
 }
 
+func (mock *MockBackend) ListAPITokens() ([]models.APIToken, error) {
+	if mock == nil {
+		panic("mock must not be nil. Use myMock := NewMockBackend().")
+	}
+	params := []pegomock.Param{}
+	result := pegomock.GetGenericMockFrom(mock).Invoke("ListAPITokens", params, []reflect.Type{reflect.TypeOf((*[]models.APIToken)(nil)).Elem(), reflect.TypeOf((*error)(nil)).Elem()})
+	var ret0 []models.APIToken
+	var ret1 error
+	if len(result) != 0 {
+		if result[0] != nil {
+			ret0 = result[0].([]models.APIToken)
+		}
+		if result[1] != nil {
+			ret1 = result[1].(error)
+		}
+	}
+	return ret0, ret1
+}
+
+func (mock *MockBackend) ListAuditEvents(query models.AuditQuery) ([]models.AuditEvent, error) {
+	if mock == nil {
+		panic("mock must not be nil. Use myMock := NewMockBackend().")
+	}
+	params := []pegomock.Param{query}
+	result := pegomock.GetGenericMockFrom(mock).Invoke("ListAuditEvents", params, []reflect.Type{reflect.TypeOf((*[]models.AuditEvent)(nil)).Elem(), reflect.TypeOf((*error)(nil)).Elem()})
+	var ret0 []models.AuditEvent
+	var ret1 error
+	if len(result) != 0 {
+		if result[0] != nil {
+			ret0 = result[0].([]models.AuditEvent)
+		}
+		if result[1] != nil {
+			ret1 = result[1].(error)
+		}
+	}
+	return ret0, ret1
+}
+
+func (mock *MockBackend) ListLeases() ([]models.Lease, error) {
+	if mock == nil {
+		panic("mock must not be nil. Use myMock := NewMockBackend().")
+	}
+	params := []pegomock.Param{}
+	result := pegomock.GetGenericMockFrom(mock).Invoke("ListLeases", params, []reflect.Type{reflect.TypeOf((*[]models.Lease)(nil)).Elem(), reflect.TypeOf((*error)(nil)).Elem()})
+	var ret0 []models.Lease
+	var ret1 error
+	if len(result) != 0 {
+		if result[0] != nil {
+			ret0 = result[0].([]models.Lease)
+		}
+		if result[1] != nil {
+			ret1 = result[1].(error)
+		}
+	}
+	return ret0, ret1
+}
+
 func (mock *MockBackend) LockCommand(cmdName command.Name, lockTime time.Time) (*command.Lock, error) {
 	if mock == nil {
 		panic("mock must not be nil. Use myMock := NewMockBackend().")
@@ -136,6 +265,55 @@ func (mock *MockBackend) LockCommand(cmdName command.Name, lockTime time.Time) (
 	return ret0, ret1
 }
 
+func (mock *MockBackend) ListPullStatuses() ([]models.PullStatus, error) {
+	if mock == nil {
+		panic("mock must not be nil. Use myMock := NewMockBackend().")
+	}
+	params := []pegomock.Param{}
+	result := pegomock.GetGenericMockFrom(mock).Invoke("ListPullStatuses", params, []reflect.Type{reflect.TypeOf((*[]models.PullStatus)(nil)).Elem(), reflect.TypeOf((*error)(nil)).Elem()})
+	var ret0 []models.PullStatus
+	var ret1 error
+	if len(result) != 0 {
+		if result[0] != nil {
+			ret0 = result[0].([]models.PullStatus)
+		}
+		if result[1] != nil {
+			ret1 = result[1].(error)
+		}
+	}
+	return ret0, ret1
+}
+
+func (mock *MockBackend) ReleaseLease(name string, holderID string) error {
+	if mock == nil {
+		panic("mock must not be nil. Use myMock := NewMockBackend().")
+	}
+	params := []pegomock.Param{name, holderID}
+	result := pegomock.GetGenericMockFrom(mock).Invoke("ReleaseLease", params, []reflect.Type{reflect.TypeOf((*error)(nil)).Elem()})
+	var ret0 error
+	if len(result) != 0 {
+		if result[0] != nil {
+			ret0 = result[0].(error)
+		}
+	}
+	return ret0
+}
+
+func (mock *MockBackend) Restore(locks []models.ProjectLock, pullStatuses []models.PullStatus, commandLocks []command.Lock, tokens []models.APIToken, auditEvents []models.AuditEvent) error {
+	if mock == nil {
+		panic("mock must not be nil. Use myMock := NewMockBackend().")
+	}
+	params := []pegomock.Param{locks, pullStatuses, commandLocks, tokens, auditEvents}
+	result := pegomock.GetGenericMockFrom(mock).Invoke("Restore", params, []reflect.Type{reflect.TypeOf((*error)(nil)).Elem()})
+	var ret0 error
+	if len(result) != 0 {
+		if result[0] != nil {
+			ret0 = result[0].(error)
+		}
+	}
+	return ret0
+}
+
 func (mock *MockBackend) TryLock(lock models.ProjectLock) (bool, models.ProjectLock, error) {
 	if mock == nil {
 		panic("mock must not be nil. Use myMock := NewMockBackend().")
@@ -283,6 +461,64 @@ type VerifierMockBackend struct {
 	timeout                time.Duration
 }
 
+func (verifier *VerifierMockBackend) AcquireLease(lease models.Lease) *MockBackend_AcquireLease_OngoingVerification {
+	params := []pegomock.Param{lease}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "AcquireLease", params, verifier.timeout)
+	return &MockBackend_AcquireLease_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type MockBackend_AcquireLease_OngoingVerification struct {
+	mock              *MockBackend
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *MockBackend_AcquireLease_OngoingVerification) GetCapturedArguments() models.Lease {
+	lease := c.GetAllCapturedArguments()
+	return lease[len(lease)-1]
+}
+
+func (c *MockBackend_AcquireLease_OngoingVerification) GetAllCapturedArguments() (_param0 []models.Lease) {
+	params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(params) > 0 {
+		_param0 = make([]models.Lease, len(c.methodInvocations))
+		for u, param := range params[0] {
+			_param0[u] = param.(models.Lease)
+		}
+	}
+	return
+}
+
+func (verifier *VerifierMockBackend) AddAuditEvent(event models.AuditEvent, maxEvents int) *MockBackend_AddAuditEvent_OngoingVerification {
+	params := []pegomock.Param{event, maxEvents}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "AddAuditEvent", params, verifier.timeout)
+	return &MockBackend_AddAuditEvent_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type MockBackend_AddAuditEvent_OngoingVerification struct {
+	mock              *MockBackend
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *MockBackend_AddAuditEvent_OngoingVerification) GetCapturedArguments() (models.AuditEvent, int) {
+	event, maxEvents := c.GetAllCapturedArguments()
+	return event[len(event)-1], maxEvents[len(maxEvents)-1]
+}
+
+func (c *MockBackend_AddAuditEvent_OngoingVerification) GetAllCapturedArguments() (_param0 []models.AuditEvent, _param1 []int) {
+	params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(params) > 0 {
+		_param0 = make([]models.AuditEvent, len(c.methodInvocations))
+		for u, param := range params[0] {
+			_param0[u] = param.(models.AuditEvent)
+		}
+		_param1 = make([]int, len(c.methodInvocations))
+		for u, param := range params[1] {
+			_param1[u] = param.(int)
+		}
+	}
+	return
+}
+
 func (verifier *VerifierMockBackend) CheckCommandLock(cmdName command.Name) *MockBackend_CheckCommandLock_OngoingVerification {
 	params := []pegomock.Param{cmdName}
 	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "CheckCommandLock", params, verifier.timeout)
@@ -310,6 +546,60 @@ func (c *MockBackend_CheckCommandLock_OngoingVerification) GetAllCapturedArgumen
 	return
 }
 
+func (verifier *VerifierMockBackend) CreateAPIToken(token models.APIToken) *MockBackend_CreateAPIToken_OngoingVerification {
+	params := []pegomock.Param{token}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "CreateAPIToken", params, verifier.timeout)
+	return &MockBackend_CreateAPIToken_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type MockBackend_CreateAPIToken_OngoingVerification struct {
+	mock              *MockBackend
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *MockBackend_CreateAPIToken_OngoingVerification) GetCapturedArguments() models.APIToken {
+	token := c.GetAllCapturedArguments()
+	return token[len(token)-1]
+}
+
+func (c *MockBackend_CreateAPIToken_OngoingVerification) GetAllCapturedArguments() (_param0 []models.APIToken) {
+	params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(params) > 0 {
+		_param0 = make([]models.APIToken, len(c.methodInvocations))
+		for u, param := range params[0] {
+			_param0[u] = param.(models.APIToken)
+		}
+	}
+	return
+}
+
+func (verifier *VerifierMockBackend) DeleteAPIToken(name string) *MockBackend_DeleteAPIToken_OngoingVerification {
+	params := []pegomock.Param{name}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "DeleteAPIToken", params, verifier.timeout)
+	return &MockBackend_DeleteAPIToken_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type MockBackend_DeleteAPIToken_OngoingVerification struct {
+	mock              *MockBackend
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *MockBackend_DeleteAPIToken_OngoingVerification) GetCapturedArguments() string {
+	name := c.GetAllCapturedArguments()
+	return name[len(name)-1]
+}
+
+func (c *MockBackend_DeleteAPIToken_OngoingVerification) GetAllCapturedArguments() (_param0 []string) {
+	params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(params) > 0 {
+		_param0 = make([]string, len(c.methodInvocations))
+		for u, param := range params[0] {
+			_param0[u] = param.(string)
+		}
+	}
+	return
+}
+
 func (verifier *VerifierMockBackend) DeletePullStatus(pull models.PullRequest) *MockBackend_DeletePullStatus_OngoingVerification {
 	params := []pegomock.Param{pull}
 	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "DeletePullStatus", params, verifier.timeout)
@@ -412,6 +702,67 @@ func (c *MockBackend_List_OngoingVerification) GetCapturedArguments() {
 func (c *MockBackend_List_OngoingVerification) GetAllCapturedArguments() {
 }
 
+func (verifier *VerifierMockBackend) ListAPITokens() *MockBackend_ListAPITokens_OngoingVerification {
+	params := []pegomock.Param{}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "ListAPITokens", params, verifier.timeout)
+	return &MockBackend_ListAPITokens_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type MockBackend_ListAPITokens_OngoingVerification struct {
+	mock              *MockBackend
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *MockBackend_ListAPITokens_OngoingVerification) GetCapturedArguments() {
+}
+
+func (c *MockBackend_ListAPITokens_OngoingVerification) GetAllCapturedArguments() {
+}
+
+func (verifier *VerifierMockBackend) ListAuditEvents(query models.AuditQuery) *MockBackend_ListAuditEvents_OngoingVerification {
+	params := []pegomock.Param{query}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "ListAuditEvents", params, verifier.timeout)
+	return &MockBackend_ListAuditEvents_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type MockBackend_ListAuditEvents_OngoingVerification struct {
+	mock              *MockBackend
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *MockBackend_ListAuditEvents_OngoingVerification) GetCapturedArguments() models.AuditQuery {
+	query := c.GetAllCapturedArguments()
+	return query[len(query)-1]
+}
+
+func (c *MockBackend_ListAuditEvents_OngoingVerification) GetAllCapturedArguments() (_param0 []models.AuditQuery) {
+	params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(params) > 0 {
+		_param0 = make([]models.AuditQuery, len(c.methodInvocations))
+		for u, param := range params[0] {
+			_param0[u] = param.(models.AuditQuery)
+		}
+	}
+	return
+}
+
+func (verifier *VerifierMockBackend) ListLeases() *MockBackend_ListLeases_OngoingVerification {
+	params := []pegomock.Param{}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "ListLeases", params, verifier.timeout)
+	return &MockBackend_ListLeases_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type MockBackend_ListLeases_OngoingVerification struct {
+	mock              *MockBackend
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *MockBackend_ListLeases_OngoingVerification) GetCapturedArguments() {
+}
+
+func (c *MockBackend_ListLeases_OngoingVerification) GetAllCapturedArguments() {
+}
+
 func (verifier *VerifierMockBackend) LockCommand(cmdName command.Name, lockTime time.Time) *MockBackend_LockCommand_OngoingVerification {
 	params := []pegomock.Param{cmdName, lockTime}
 	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "LockCommand", params, verifier.timeout)
@@ -443,6 +794,81 @@ func (c *MockBackend_LockCommand_OngoingVerification) GetAllCapturedArguments()
 	return
 }
 
+func (verifier *VerifierMockBackend) ListPullStatuses() *MockBackend_ListPullStatuses_OngoingVerification {
+	params := []pegomock.Param{}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "ListPullStatuses", params, verifier.timeout)
+	return &MockBackend_ListPullStatuses_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type MockBackend_ListPullStatuses_OngoingVerification struct {
+	mock              *MockBackend
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *MockBackend_ListPullStatuses_OngoingVerification) GetCapturedArguments() {
+}
+
+func (c *MockBackend_ListPullStatuses_OngoingVerification) GetAllCapturedArguments() {
+}
+
+func (verifier *VerifierMockBackend) ReleaseLease(name string, holderID string) *MockBackend_ReleaseLease_OngoingVerification {
+	params := []pegomock.Param{name, holderID}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "ReleaseLease", params, verifier.timeout)
+	return &MockBackend_ReleaseLease_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type MockBackend_ReleaseLease_OngoingVerification struct {
+	mock              *MockBackend
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *MockBackend_ReleaseLease_OngoingVerification) GetCapturedArguments() (string, string) {
+	name, holderID := c.GetAllCapturedArguments()
+	return name[len(name)-1], holderID[len(holderID)-1]
+}
+
+func (c *MockBackend_ReleaseLease_OngoingVerification) GetAllCapturedArguments() (_param0 []string, _param1 []string) {
+	params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(params) > 0 {
+		_param0 = make([]string, len(c.methodInvocations))
+		for u, param := range params[0] {
+			_param0[u] = param.(string)
+		}
+		_param1 = make([]string, len(c.methodInvocations))
+		for u, param := range params[1] {
+			_param1[u] = param.(string)
+		}
+	}
+	return
+}
+
+func (verifier *VerifierMockBackend) RestorePullStatus(status models.PullStatus) *MockBackend_RestorePullStatus_OngoingVerification {
+	params := []pegomock.Param{status}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "RestorePullStatus", params, verifier.timeout)
+	return &MockBackend_RestorePullStatus_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type MockBackend_RestorePullStatus_OngoingVerification struct {
+	mock              *MockBackend
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *MockBackend_RestorePullStatus_OngoingVerification) GetCapturedArguments() models.PullStatus {
+	status := c.GetAllCapturedArguments()
+	return status[len(status)-1]
+}
+
+func (c *MockBackend_RestorePullStatus_OngoingVerification) GetAllCapturedArguments() (_param0 []models.PullStatus) {
+	params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(params) > 0 {
+		_param0 = make([]models.PullStatus, len(c.methodInvocations))
+		for u, param := range params[0] {
+			_param0[u] = param.(models.PullStatus)
+		}
+	}
+	return
+}
+
 func (verifier *VerifierMockBackend) TryLock(lock models.ProjectLock) *MockBackend_TryLock_OngoingVerification {
 	params := []pegomock.Param{lock}
 	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "TryLock", params, verifier.timeout)
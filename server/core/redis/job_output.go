@@ -0,0 +1,147 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// jobOutputTTL is how long the output of a job is kept after its last
+	// line, in case its pull request is never cleaned up.
+	jobOutputTTL = 24 * time.Hour
+	// jobOutputBlock is how long Follow waits for new lines before checking
+	// if it should stop.
+	jobOutputBlock = 2 * time.Second
+	// jobOutputCompleteField is the field of the stream entry that marks a
+	// job as complete.
+	jobOutputCompleteField = "complete"
+	jobOutputLineField     = "line"
+)
+
+// JobOutputStore stores the output of each job in a Redis stream so that any
+// Atlantis replica can stream it. It implements jobs.JobOutputStore.
+type JobOutputStore struct {
+	client *redis.Client
+}
+
+// JobOutputStore returns a store for job output in the same Redis database.
+func (r *RedisDB) JobOutputStore() *JobOutputStore {
+	return &JobOutputStore{client: r.client}
+}
+
+// Append adds lines to the output of the job with id jobID.
+func (s *JobOutputStore) Append(jobID string, lines ...string) error {
+	values := make([]map[string]interface{}, 0, len(lines))
+	for _, line := range lines {
+		values = append(values, map[string]interface{}{jobOutputLineField: line})
+	}
+	return s.add(jobID, values...)
+}
+
+// Complete marks the job with id jobID as complete.
+func (s *JobOutputStore) Complete(jobID string) error {
+	return s.add(jobID, map[string]interface{}{jobOutputCompleteField: "true"})
+}
+
+// Exists returns true if there's output for the job with id jobID.
+func (s *JobOutputStore) Exists(jobID string) (bool, error) {
+	n, err := s.client.Exists(ctx, s.key(jobID)).Result()
+	return n > 0, errors.Wrap(err, "db transaction failed")
+}
+
+// Follow sends every line of the job's output to receiver, waiting for new
+// lines until the job is complete or followCtx is done. It returns true if
+// the job is complete.
+func (s *JobOutputStore) Follow(followCtx context.Context, jobID string, receiver chan string) (bool, error) {
+	key := s.key(jobID)
+	lastID := "0"
+	for {
+		if followCtx.Err() != nil {
+			return false, nil
+		}
+		streams, err := s.client.XRead(followCtx, &redis.XReadArgs{
+			Streams: []string{key, lastID},
+			Count:   100,
+			Block:   jobOutputBlock,
+		}).Result()
+		if err == redis.Nil {
+			continue
+		}
+		if err != nil {
+			if followCtx.Err() != nil {
+				return false, nil
+			}
+			return false, errors.Wrap(err, "db transaction failed")
+		}
+		for _, stream := range streams {
+			for _, msg := range stream.Messages {
+				lastID = msg.ID
+				if _, ok := msg.Values[jobOutputCompleteField]; ok {
+					return true, nil
+				}
+				line, _ := msg.Values[jobOutputLineField].(string)
+				select {
+				case receiver <- line:
+				case <-followCtx.Done():
+					return false, nil
+				}
+			}
+		}
+	}
+}
+
+// SetRepoID sets the ID of the repo of the job with id jobID.
+func (s *JobOutputStore) SetRepoID(jobID string, repoID string) error {
+	return errors.Wrap(s.client.Set(ctx, s.repoKey(jobID), repoID, jobOutputTTL).Err(), "db transaction failed")
+}
+
+// RepoID returns the ID of the repo of the job with id jobID, or "" if it
+// isn't known.
+func (s *JobOutputStore) RepoID(jobID string) (string, error) {
+	repoID, err := s.client.Get(ctx, s.repoKey(jobID)).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+	return repoID, errors.Wrap(err, "db transaction failed")
+}
+
+// Delete deletes the output of the jobs with ids jobIDs.
+func (s *JobOutputStore) Delete(jobIDs ...string) error {
+	if len(jobIDs) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, 2*len(jobIDs))
+	for _, jobID := range jobIDs {
+		keys = append(keys, s.key(jobID), s.repoKey(jobID))
+	}
+	return errors.Wrap(s.client.Del(ctx, keys...).Err(), "db transaction failed")
+}
+
+// add adds an entry with each of entries to the job's stream, and renews
+// its TTL once.
+func (s *JobOutputStore) add(jobID string, entries ...map[string]interface{}) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	key := s.key(jobID)
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, values := range entries {
+			pipe.XAdd(ctx, &redis.XAddArgs{Stream: key, Values: values})
+		}
+		pipe.Expire(ctx, key, jobOutputTTL)
+		return nil
+	})
+	return errors.Wrap(err, "db transaction failed")
+}
+
+func (s *JobOutputStore) key(jobID string) string {
+	return fmt.Sprintf("jobs/%s/output", jobID)
+}
+
+func (s *JobOutputStore) repoKey(jobID string) string {
+	return fmt.Sprintf("jobs/%s/repo", jobID)
+}
@@ -62,6 +62,11 @@ func NewWithClient(client *redis.Client, _ string, _ string) (*RedisDB, error) {
 	}, nil
 }
 
+// Close closes the connection to Redis.
+func (r *RedisDB) Close() error {
+	return r.client.Close()
+}
+
 // TryLock attempts to create a new lock. If the lock is
 // acquired, it will return true and the lock returned will be newLock.
 // If the lock is not acquired, it will return false and the current
@@ -87,6 +92,16 @@ func (r *RedisDB) TryLock(newLock models.ProjectLock) (bool, models.ProjectLock,
 	if err := json.Unmarshal([]byte(val), &currLock); err != nil {
 		return false, currLock, errors.Wrap(err, "failed to deserialize current lock")
 	}
+	// If the same pull request is taking the lock again, renew it. Time is
+	// moved too so that ExpiresAt - Time stays the lock's TTL.
+	if currLock.Pull.Num == newLock.Pull.Num && !currLock.ExpiresAt.Equal(newLock.ExpiresAt) {
+		currLock.Time = newLock.Time
+		currLock.ExpiresAt = newLock.ExpiresAt
+		currLockSerialized, _ := json.Marshal(currLock)
+		if err := r.client.Set(ctx, key, currLockSerialized, 0).Err(); err != nil {
+			return false, currLock, errors.Wrap(err, "db transaction failed")
+		}
+	}
 	return false, currLock, nil
 }
 
@@ -265,6 +280,7 @@ func (r *RedisDB) UpdateProjectStatus(pull models.PullRequest, workspace string,
 			break
 		}
 	}
+	currStatus.UpdatedAt = time.Now()
 
 	err = r.writePull(key, currStatus)
 	return errors.Wrap(err, "db transaction failed")
@@ -281,6 +297,232 @@ func (r *RedisDB) GetPullStatus(pull models.PullRequest) (*models.PullStatus, er
 	return pullStatus, errors.Wrap(err, "db transaction failed")
 }
 
+// ListPullStatuses returns the statuses of all pull requests. Statuses are
+// deleted when their pull request is closed so these are the open pull
+// requests that Atlantis has run on.
+func (r *RedisDB) ListPullStatuses() ([]models.PullStatus, error) {
+	var statuses []models.PullStatus
+	iter := r.client.Scan(ctx, 0, "*"+pullKeySeparator+"*"+pullKeySeparator+"*", 0).Iterator()
+	for iter.Next(ctx) {
+		s, err := r.getPull(iter.Val())
+		if err != nil {
+			return nil, err
+		}
+		// The pull request may have been closed since we scanned its key.
+		if s != nil {
+			statuses = append(statuses, *s)
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return nil, errors.Wrap(err, "db transaction failed")
+	}
+	return statuses, nil
+}
+
+// CreateAPIToken stores token. It returns an error if a token with the same
+// name already exists.
+func (r *RedisDB) CreateAPIToken(token models.APIToken) error {
+	serialized, err := json.Marshal(token)
+	if err != nil {
+		return errors.Wrap(err, "serializing")
+	}
+	created, err := r.client.SetNX(ctx, r.apiTokenKey(token.Name), serialized, 0).Result()
+	if err != nil {
+		return errors.Wrap(err, "db transaction failed")
+	}
+	if !created {
+		return fmt.Errorf("token %q already exists", token.Name)
+	}
+	return nil
+}
+
+// ListAPITokens returns all API tokens.
+func (r *RedisDB) ListAPITokens() ([]models.APIToken, error) {
+	var tokens []models.APIToken
+	iter := r.client.Scan(ctx, 0, r.apiTokenKey("*"), 0).Iterator()
+	for iter.Next(ctx) {
+		val, err := r.client.Get(ctx, iter.Val()).Result()
+		if err == redis.Nil {
+			// The token may have been deleted since we scanned its key.
+			continue
+		} else if err != nil {
+			return nil, errors.Wrap(err, "db transaction failed")
+		}
+		var t models.APIToken
+		if err := json.Unmarshal([]byte(val), &t); err != nil {
+			return nil, errors.Wrapf(err, "deserializing API token at %q", iter.Val())
+		}
+		tokens = append(tokens, t)
+	}
+	if err := iter.Err(); err != nil {
+		return nil, errors.Wrap(err, "db transaction failed")
+	}
+	return tokens, nil
+}
+
+// AcquireLease stores lease if there's no lease with its name, the lease has
+// expired or it's held by the same replica. It returns the current lease and
+// whether it was acquired. The lease's key expires with it.
+func (r *RedisDB) AcquireLease(lease models.Lease) (models.Lease, bool, error) {
+	key := r.leaseKey(lease.Name)
+	serialized, err := json.Marshal(lease)
+	if err != nil {
+		return lease, false, errors.Wrap(err, "serializing")
+	}
+	curr := lease
+	acquired := false
+	err = r.client.Watch(ctx, func(tx *redis.Tx) error {
+		existing, err := r.getLease(tx, key)
+		if err != nil {
+			return err
+		}
+		if existing != nil && existing.Holder.ID != lease.Holder.ID && !existing.Expired(time.Now()) {
+			curr = *existing
+			return nil
+		}
+		ttl := time.Until(lease.ExpiresAt)
+		if ttl < time.Millisecond {
+			ttl = time.Millisecond
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, key, serialized, ttl)
+			return nil
+		})
+		acquired = err == nil
+		return err
+	}, key)
+	if err == redis.TxFailedErr {
+		// Another replica changed the lease at the same time.
+		existing, err := r.getLease(r.client, key)
+		if err != nil || existing == nil {
+			return lease, false, err
+		}
+		return *existing, false, nil
+	}
+	return curr, acquired, errors.Wrap(err, "db transaction failed")
+}
+
+// ReleaseLease deletes the lease named name if it's held by the replica with
+// id holderID.
+func (r *RedisDB) ReleaseLease(name string, holderID string) error {
+	key := r.leaseKey(name)
+	err := r.client.Watch(ctx, func(tx *redis.Tx) error {
+		existing, err := r.getLease(tx, key)
+		if err != nil || existing == nil || existing.Holder.ID != holderID {
+			return err
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Del(ctx, key)
+			return nil
+		})
+		return err
+	}, key)
+	if err == redis.TxFailedErr {
+		// The lease was renewed or taken over so it's no longer ours to
+		// release.
+		return nil
+	}
+	return errors.Wrap(err, "db transaction failed")
+}
+
+// ListLeases returns all leases that haven't expired.
+func (r *RedisDB) ListLeases() ([]models.Lease, error) {
+	var leases []models.Lease
+	now := time.Now()
+	iter := r.client.Scan(ctx, 0, r.leaseKey("*"), 0).Iterator()
+	for iter.Next(ctx) {
+		lease, err := r.getLease(r.client, iter.Val())
+		if err != nil {
+			return nil, err
+		}
+		// The lease may have expired since we scanned its key.
+		if lease != nil && !lease.Expired(now) {
+			leases = append(leases, *lease)
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return nil, errors.Wrap(err, "db transaction failed")
+	}
+	return leases, nil
+}
+
+func (r *RedisDB) getLease(client redis.Cmdable, key string) (*models.Lease, error) {
+	val, err := client.Get(ctx, key).Result()
+	if err == redis.Nil {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.Wrap(err, "db transaction failed")
+	}
+	var lease models.Lease
+	if err := json.Unmarshal([]byte(val), &lease); err != nil {
+		return nil, errors.Wrapf(err, "deserializing lease at %q", key)
+	}
+	return &lease, nil
+}
+
+// DeleteAPIToken deletes the API token named name. It returns nil if there
+// was no such token.
+func (r *RedisDB) DeleteAPIToken(name string) (*models.APIToken, error) {
+	val, err := r.client.GetDel(ctx, r.apiTokenKey(name)).Result()
+	if err == redis.Nil {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.Wrap(err, "db transaction failed")
+	}
+	var t models.APIToken
+	if err := json.Unmarshal([]byte(val), &t); err != nil {
+		return nil, errors.Wrapf(err, "deserializing API token %q", name)
+	}
+	return &t, nil
+}
+
+// AddAuditEvent appends event to the audit list and trims it to the newest
+// maxEvents events.
+func (r *RedisDB) AddAuditEvent(event models.AuditEvent, maxEvents int) error {
+	serialized, err := json.Marshal(event)
+	if err != nil {
+		return errors.Wrap(err, "serializing")
+	}
+	_, err = r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.RPush(ctx, auditKey, serialized)
+		if maxEvents > 0 {
+			pipe.LTrim(ctx, auditKey, int64(-maxEvents), -1)
+		}
+		return nil
+	})
+	return errors.Wrap(err, "db transaction failed")
+}
+
+// ListAuditEvents returns the audit events matching query, newest first.
+// The audit list is read from the end in pages so that queries with a limit
+// don't read every event.
+func (r *RedisDB) ListAuditEvents(query models.AuditQuery) ([]models.AuditEvent, error) {
+	const pageSize = 100
+	var events []models.AuditEvent
+	for end := int64(-1); ; end -= pageSize {
+		page, err := r.client.LRange(ctx, auditKey, end-pageSize+1, end).Result()
+		if err != nil {
+			return nil, errors.Wrap(err, "db transaction failed")
+		}
+		for i := len(page) - 1; i >= 0; i-- {
+			var e models.AuditEvent
+			if err := json.Unmarshal([]byte(page[i]), &e); err != nil {
+				return nil, errors.Wrap(err, "deserializing audit event")
+			}
+			if !query.Matches(e) {
+				continue
+			}
+			events = append(events, e)
+			if query.Limit > 0 && len(events) == query.Limit {
+				return events, nil
+			}
+		}
+		if len(page) < pageSize {
+			return events, nil
+		}
+	}
+}
+
 func (r *RedisDB) DeletePullStatus(pull models.PullRequest) error {
 	key, err := r.pullKey(pull)
 	if err != nil {
@@ -289,12 +531,73 @@ func (r *RedisDB) DeletePullStatus(pull models.PullRequest) error {
 	return errors.Wrap(r.deletePull(key), "db transaction failed")
 }
 
+// Restore stores everything in one MULTI/EXEC transaction so that either
+// all of it is stored or none of it is. Audit events must be ordered oldest
+// first.
+func (r *RedisDB) Restore(locks []models.ProjectLock, pullStatuses []models.PullStatus, commandLocks []command.Lock, tokens []models.APIToken, auditEvents []models.AuditEvent) error {
+	// Everything is serialized up front so that nothing is sent if any of it
+	// can't be.
+	values := make(map[string][]byte)
+	for _, lock := range locks {
+		serialized, err := json.Marshal(lock)
+		if err != nil {
+			return errors.Wrap(err, "serializing")
+		}
+		values[r.lockKey(lock.Project, lock.Workspace)] = serialized
+	}
+	for _, status := range pullStatuses {
+		key, err := r.pullKey(status.Pull)
+		if err != nil {
+			return err
+		}
+		serialized, err := json.Marshal(status)
+		if err != nil {
+			return errors.Wrap(err, "serializing")
+		}
+		values[key] = serialized
+	}
+	for _, lock := range commandLocks {
+		serialized, err := json.Marshal(lock)
+		if err != nil {
+			return errors.Wrap(err, "serializing")
+		}
+		values[r.commandLockKey(lock.CommandName)] = serialized
+	}
+	for _, token := range tokens {
+		serialized, err := json.Marshal(token)
+		if err != nil {
+			return errors.Wrap(err, "serializing")
+		}
+		values[r.apiTokenKey(token.Name)] = serialized
+	}
+	var events []interface{}
+	for _, event := range auditEvents {
+		serialized, err := json.Marshal(event)
+		if err != nil {
+			return errors.Wrap(err, "serializing")
+		}
+		events = append(events, serialized)
+	}
+
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		for key, value := range values {
+			pipe.Set(ctx, key, value, 0)
+		}
+		if len(events) > 0 {
+			pipe.RPush(ctx, auditKey, events...)
+		}
+		return nil
+	})
+	return errors.Wrap(err, "db transaction failed")
+}
+
 func (r *RedisDB) UpdatePullWithResults(pull models.PullRequest, newResults []command.ProjectResult) (models.PullStatus, error) {
 	key, err := r.pullKey(pull)
 	if err != nil {
 		return models.PullStatus{}, err
 	}
 
+	now := time.Now()
 	var newStatus models.PullStatus
 	currStatus, err := r.getPull(key)
 	if err != nil {
@@ -309,8 +612,14 @@ func (r *RedisDB) UpdatePullWithResults(pull models.PullRequest, newResults []co
 			statuses = append(statuses, r.projectResultToProject(res))
 		}
 		newStatus = models.PullStatus{
-			Pull:     pull,
-			Projects: statuses,
+			Pull:      pull,
+			Projects:  statuses,
+			CreatedAt: now,
+		}
+		// Keep when we first saw the pull request even though its
+		// projects are reset by the new commit.
+		if currStatus != nil && !currStatus.CreatedAt.IsZero() {
+			newStatus.CreatedAt = currStatus.CreatedAt
 		}
 	} else {
 		// If there's an existing pull at the right commit then we have to
@@ -331,6 +640,9 @@ func (r *RedisDB) UpdatePullWithResults(pull models.PullRequest, newResults []co
 					res.ProjectName == proj.ProjectName {
 
 					proj.Status = res.PlanStatus()
+					if digest := res.PlanDigest(); digest != "" {
+						proj.PlanDigest = digest
+					}
 
 					// Updating only policy sets which are included in results; keeping the rest.
 					if len(proj.PolicyStatus) > 0 {
@@ -358,6 +670,11 @@ func (r *RedisDB) UpdatePullWithResults(pull models.PullRequest, newResults []co
 		}
 	}
 
+	if len(newResults) > 0 {
+		newStatus.LastCommand = newResults[0].Command.String()
+	}
+	newStatus.UpdatedAt = now
+
 	// Now, we overwrite the key with our new status.
 	return newStatus, errors.Wrap(r.writePull(key, newStatus), "db transaction failed")
 }
@@ -395,6 +712,17 @@ func (r *RedisDB) lockKey(p models.Project, workspace string) string {
 	return fmt.Sprintf("pr/%s/%s/%s", p.RepoFullName, p.Path, workspace)
 }
 
+// auditKey is the key of the list audit events are appended to.
+const auditKey = "audit"
+
+func (r *RedisDB) leaseKey(name string) string {
+	return fmt.Sprintf("lease/%s", name)
+}
+
+func (r *RedisDB) apiTokenKey(name string) string {
+	return fmt.Sprintf("apitoken/%s", name)
+}
+
 func (r *RedisDB) commandLockKey(cmdName command.Name) string {
 	return fmt.Sprintf("global/%s/lock", cmdName)
 }
@@ -419,5 +747,6 @@ func (r *RedisDB) projectResultToProject(p command.ProjectResult) models.Project
 		ProjectName:  p.ProjectName,
 		PolicyStatus: p.PolicyStatus(),
 		Status:       p.PlanStatus(),
+		PlanDigest:   p.PlanDigest(),
 	}
 }
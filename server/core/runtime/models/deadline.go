@@ -0,0 +1,102 @@
+package models
+
+import (
+	"bytes"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/core/runtime/sandbox"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/logging"
+)
+
+// TerminationGracePeriod is how long commands have to exit after they're
+// asked to because they ran past their deadline, before they're killed.
+var TerminationGracePeriod = 10 * time.Second
+
+// PrepareDeadline must be called before cmd is started for
+// EnforceDeadline to be able to kill the processes cmd starts.
+func PrepareDeadline(cmd *exec.Cmd) {
+	setProcessGroup(cmd)
+}
+
+// EnforceDeadline terminates cmd, which must have been started, and the
+// processes it started once deadline passes. If they don't exit within
+// TerminationGracePeriod they're killed.
+//
+// The returned function must be called once cmd has exited. It returns a
+// command.TimeoutError if cmd was terminated because of the deadline.
+func EnforceDeadline(log logging.SimpleLogging, cmd *exec.Cmd, deadline command.Deadline) func() error {
+	if deadline.At.IsZero() {
+		return func() error { return nil }
+	}
+	var mu sync.Mutex
+	exited, timedOut := false, false
+	var killTimer *time.Timer
+	timer := time.AfterFunc(time.Until(deadline.At), func() {
+		mu.Lock()
+		defer mu.Unlock()
+		if exited {
+			return
+		}
+		timedOut = true
+		log.Warn("terminating %q because it timed out after %s", cmd.String(), deadline.Timeout)
+		if err := terminateProcessGroup(cmd); err != nil {
+			log.Warn("failed terminating %q: %s", cmd.String(), err)
+		}
+		killTimer = time.AfterFunc(TerminationGracePeriod, func() {
+			mu.Lock()
+			defer mu.Unlock()
+			if exited {
+				return
+			}
+			log.Warn("killing %q because it didn't exit after being terminated", cmd.String())
+			if err := killProcessGroup(cmd); err != nil {
+				log.Warn("failed killing %q: %s", cmd.String(), err)
+			}
+		})
+	})
+	return func() error {
+		mu.Lock()
+		defer mu.Unlock()
+		exited = true
+		timer.Stop()
+		if killTimer != nil {
+			killTimer.Stop()
+		}
+		if timedOut {
+			return command.TimeoutError{Timeout: deadline.Timeout}
+		}
+		return nil
+	}
+}
+
+// CombinedOutput runs cmd and returns its combined stdout and stderr like
+// exec.Cmd.CombinedOutput, enforcing the deadline of ctx and running cmd in
+// its sandbox.
+func CombinedOutput(ctx command.ProjectContext, cmd *exec.Cmd) ([]byte, error) {
+	if ctx.Deadline.At.IsZero() && ctx.Sandbox == nil {
+		return cmd.CombinedOutput()
+	}
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	PrepareDeadline(cmd)
+	limitExceeded := func(err error) error { return err }
+	if ctx.Sandbox != nil {
+		var err error
+		if limitExceeded, err = sandbox.Prepare(cmd, ctx.Sandbox); err != nil {
+			return nil, err
+		}
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	deadlineExceeded := EnforceDeadline(ctx.Log, cmd, ctx.Deadline)
+	err := limitExceeded(cmd.Wait())
+	if timeoutErr := deadlineExceeded(); timeoutErr != nil {
+		err = timeoutErr
+	}
+	return out.Bytes(), err
+}
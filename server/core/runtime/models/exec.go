@@ -5,6 +5,8 @@ import (
 	"os"
 	"os/exec"
 	"strings"
+
+	"github.com/runatlantis/atlantis/server/core/config/valid"
 )
 
 //go:generate pegomock generate --package mocks -o mocks/mock_exec.go Exec
@@ -14,7 +16,11 @@ type Exec interface {
 	CombinedOutput(args []string, envs map[string]string, workdir string) (string, error)
 }
 
-type LocalExec struct{}
+type LocalExec struct {
+	// EnvPolicy decides which of Atlantis's environment variables commands
+	// inherit.
+	EnvPolicy valid.EnvPolicy
+}
 
 func (e LocalExec) LookPath(file string) (string, error) {
 	return exec.LookPath(file)
@@ -34,7 +40,7 @@ func (e LocalExec) CombinedOutput(args []string, envs map[string]string, workdir
 
 	// TODO: move this os.Environ call out to the server so this
 	// can happen once at the beginning
-	envVars = append(envVars, os.Environ()...)
+	envVars = append(envVars, e.EnvPolicy.Filter(os.Environ())...)
 
 	// honestly not entirely sure why we're using sh -c but it's used
 	// for the terraform binary so copying it for now
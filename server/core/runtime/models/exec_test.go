@@ -0,0 +1,27 @@
+package models_test
+
+import (
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/core/runtime/models"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestLocalExec_CombinedOutput_EnvPolicy(t *testing.T) {
+	t.Setenv("ATLANTIS_GH_TOKEN", "token")
+	t.Setenv("AWS_REGION", "us-east-1")
+	cmd := []string{"echo", "token=$ATLANTIS_GH_TOKEN", "region=$AWS_REGION", "env=$FROM_ENVS"}
+	envs := map[string]string{"FROM_ENVS": "value"}
+
+	t.Log("Atlantis's variables are stripped by default")
+	out, err := models.LocalExec{}.CombinedOutput(cmd, envs, t.TempDir())
+	Ok(t, err)
+	Equals(t, "token= region=us-east-1 env=value\n", out)
+
+	t.Log("the policy applies to inherited variables")
+	e := models.LocalExec{EnvPolicy: valid.EnvPolicy{Allow: []string{"ATLANTIS_GH_TOKEN", "PATH"}}}
+	out, err = e.CombinedOutput(cmd, envs, t.TempDir())
+	Ok(t, err)
+	Equals(t, "token=token region= env=value\n", out)
+}
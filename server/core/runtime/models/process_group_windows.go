@@ -0,0 +1,20 @@
+package models
+
+import (
+	"os/exec"
+)
+
+// setProcessGroup is a no-op on Windows where processes can't be signalled
+// as a group.
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// terminateProcessGroup kills cmd. Windows doesn't support asking processes
+// to exit.
+func terminateProcessGroup(cmd *exec.Cmd) error {
+	return cmd.Process.Kill()
+}
+
+// killProcessGroup kills cmd.
+func killProcessGroup(cmd *exec.Cmd) error {
+	return cmd.Process.Kill()
+}
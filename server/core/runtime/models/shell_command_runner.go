@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/core/runtime/sandbox"
 	"github.com/runatlantis/atlantis/server/events/command"
 	"github.com/runatlantis/atlantis/server/events/terraform/ansi"
 	"github.com/runatlantis/atlantis/server/jobs"
@@ -92,6 +93,19 @@ func (s *ShellCommandRunner) RunCommandAsync(ctx command.ProjectContext) (chan<-
 		stderr, _ := s.cmd.StderrPipe()
 		stdin, _ := s.cmd.StdinPipe()
 
+		if !ctx.Deadline.At.IsZero() {
+			PrepareDeadline(s.cmd)
+		}
+		limitExceeded := func(err error) error { return err }
+		if ctx.Sandbox != nil {
+			var err error
+			if limitExceeded, err = sandbox.Prepare(s.cmd, ctx.Sandbox); err != nil {
+				err = errors.Wrapf(err, "sandboxing %q", s.command)
+				ctx.Log.Err(err.Error())
+				outCh <- Line{Err: err}
+				return
+			}
+		}
 		ctx.Log.Debug("starting %q in %q", s.command, s.workingDir)
 		err := s.cmd.Start()
 		if err != nil {
@@ -100,6 +114,7 @@ func (s *ShellCommandRunner) RunCommandAsync(ctx command.ProjectContext) (chan<-
 			outCh <- Line{Err: err}
 			return
 		}
+		deadlineExceeded := EnforceDeadline(ctx.Log, s.cmd, ctx.Deadline)
 
 		// If we get anything on inCh, write it to stdin.
 		// This function will exit when inCh is closed which we do in our defer.
@@ -147,7 +162,13 @@ func (s *ShellCommandRunner) RunCommandAsync(ctx command.ProjectContext) (chan<-
 		wg.Wait()
 
 		// Wait for the command to complete.
-		err = s.cmd.Wait()
+		err = limitExceeded(s.cmd.Wait())
+		if timeoutErr := deadlineExceeded(); timeoutErr != nil {
+			err = timeoutErr
+			if s.streamOutput {
+				s.outputHandler.Send(ctx, timeoutErr.Error(), false)
+			}
+		}
 
 		dur := time.Since(start)
 		log := ctx.Log.With("duration", dur)
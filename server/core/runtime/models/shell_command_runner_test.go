@@ -1,10 +1,12 @@
 package models_test
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	. "github.com/petergtz/pegomock/v4"
 	"github.com/runatlantis/atlantis/server/core/runtime/models"
@@ -78,3 +80,48 @@ func TestShellCommandRunner_Run(t *testing.T) {
 		})
 	}
 }
+
+func TestShellCommandRunner_Run_Deadline(t *testing.T) {
+	gracePeriod := models.TerminationGracePeriod
+	models.TerminationGracePeriod = 200 * time.Millisecond
+	defer func() { models.TerminationGracePeriod = gracePeriod }()
+
+	cases := []struct {
+		description string
+		command     string
+		expOutput   string
+	}{
+		{
+			description: "terminated",
+			command:     "trap 'echo terminated; exit 1' TERM; echo started; sleep 30 & wait",
+			expOutput:   "started\nterminated\n",
+		},
+		{
+			description: "killed if it ignores being terminated",
+			command:     "trap '' TERM; echo started; sleep 30",
+			expOutput:   "started\n",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			RegisterMockTestingT(t)
+			log := logmocks.NewMockSimpleLogging()
+			When(log.With(Any[string](), Any[interface{}]())).ThenReturn(log)
+			ctx := command.ProjectContext{
+				Log:      log,
+				Deadline: command.Deadline{}.Within(500 * time.Millisecond),
+			}
+			projectCmdOutputHandler := mocks.NewMockProjectCommandOutputHandler()
+
+			start := time.Now()
+			runner := models.NewShellCommandRunner(c.command, nil, t.TempDir(), true, projectCmdOutputHandler)
+			output, err := runner.Run(ctx)
+			Assert(t, time.Since(start) < 5*time.Second, "exp command to be killed, ran for %s", time.Since(start))
+			ErrContains(t, "timed out after 500ms", err)
+			var timeoutErr command.TimeoutError
+			Assert(t, errors.As(err, &timeoutErr), "exp TimeoutError, got %T", err)
+			Equals(t, c.expOutput, output)
+			projectCmdOutputHandler.VerifyWasCalledOnce().Send(ctx, "timed out after 500ms", false)
+		})
+	}
+}
@@ -142,7 +142,7 @@ type ConfTestExecutorWorkflow struct {
 	Exec                   runtime_models.Exec
 }
 
-func NewConfTestExecutorWorkflow(log logging.SimpleLogging, versionRootDir string, conftestDownloder terraform.Downloader) *ConfTestExecutorWorkflow {
+func NewConfTestExecutorWorkflow(log logging.SimpleLogging, versionRootDir string, conftestDownloder terraform.Downloader, envPolicy valid.EnvPolicy) *ConfTestExecutorWorkflow {
 	downloader := ConfTestVersionDownloader{
 		downloader: conftestDownloder,
 	}
@@ -165,7 +165,7 @@ func NewConfTestExecutorWorkflow(log logging.SimpleLogging, versionRootDir strin
 		SourceResolver: &SourceResolverProxy{
 			localSourceResolver: &LocalSourceResolver{},
 		},
-		Exec: runtime_models.LocalExec{},
+		Exec: runtime_models.LocalExec{EnvPolicy: envPolicy},
 	}
 }
 
@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/runatlantis/atlantis/server/core/config/valid"
 	"github.com/runatlantis/atlantis/server/events/models"
 	"github.com/runatlantis/atlantis/server/jobs"
 )
@@ -18,6 +19,9 @@ type PostWorkflowHookRunner interface {
 
 type DefaultPostWorkflowHookRunner struct {
 	OutputHandler jobs.ProjectCommandOutputHandler
+	// EnvPolicy decides which of Atlantis's environment variables hooks
+	// inherit.
+	EnvPolicy valid.EnvPolicy
 }
 
 func (wh DefaultPostWorkflowHookRunner) Run(ctx models.WorkflowHookCommandContext, command string, shell string, shellArgs string, path string) (string, string, error) {
@@ -27,7 +31,7 @@ func (wh DefaultPostWorkflowHookRunner) Run(ctx models.WorkflowHookCommandContex
 	cmd := exec.Command(shell, shellArgsSlice...) // #nosec
 	cmd.Dir = path
 
-	baseEnvVars := os.Environ()
+	baseEnvVars := wh.EnvPolicy.Filter(os.Environ())
 	customEnvVars := map[string]string{
 		"BASE_BRANCH_NAME":   ctx.Pull.BaseBranch,
 		"BASE_REPO_NAME":     ctx.BaseRepo.Name,
@@ -20,6 +20,9 @@ type RunStepRunner struct {
 	// TerraformBinDir is the directory where Atlantis downloads Terraform binaries.
 	TerraformBinDir         string
 	ProjectCmdOutputHandler jobs.ProjectCommandOutputHandler
+	// EnvPolicy decides which of Atlantis's environment variables commands
+	// inherit.
+	EnvPolicy valid.EnvPolicy
 }
 
 func (r *RunStepRunner) Run(ctx command.ProjectContext, command string, path string, envs map[string]string, streamOutput bool, postProcessOutput valid.PostProcessRunOutputOption) (string, error) {
@@ -35,7 +38,7 @@ func (r *RunStepRunner) Run(ctx command.ProjectContext, command string, path str
 		return "", err
 	}
 
-	baseEnvVars := os.Environ()
+	baseEnvVars := r.EnvPolicy.Filter(os.Environ())
 	customEnvVars := map[string]string{
 		"ATLANTIS_TERRAFORM_VERSION": tfVersion.String(),
 		"BASE_BRANCH_NAME":           ctx.Pull.BaseBranch,
@@ -72,7 +75,7 @@ func (r *RunStepRunner) Run(ctx command.ProjectContext, command string, path str
 	output, err := runner.Run(ctx)
 
 	if err != nil {
-		err = fmt.Errorf("%s: running %q in %q: \n%s", err, command, path, output)
+		err = fmt.Errorf("%w: running %q in %q: \n%s", err, command, path, output)
 		if !ctx.CustomPolicyCheck {
 			ctx.Log.Debug("error: %s", err)
 			return "", err
@@ -163,3 +163,37 @@ func TestRunStepRunner_Run(t *testing.T) {
 		})
 	}
 }
+
+func TestRunStepRunner_Run_EnvPolicy(t *testing.T) {
+	t.Setenv("ATLANTIS_GH_TOKEN", "token")
+	t.Setenv("AWS_REGION", "us-east-1")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+
+	RegisterMockTestingT(t)
+	terraform := mocks.NewMockClient()
+	When(terraform.EnsureVersion(Any[logging.SimpleLogging](), Any[*version.Version]())).
+		ThenReturn(nil)
+	defaultVersion, _ := version.NewVersion("0.8")
+	ctx := command.ProjectContext{
+		Log:       logging.NewNoopLogger(t),
+		Workspace: "default",
+	}
+	cmd := "echo token=$ATLANTIS_GH_TOKEN region=$AWS_REGION secret=$AWS_SECRET_ACCESS_KEY env=$FROM_ENV_STEP"
+	envs := map[string]string{"FROM_ENV_STEP": "value"}
+
+	t.Log("Atlantis's variables are stripped by default")
+	r := runtime.RunStepRunner{
+		TerraformExecutor:       terraform,
+		DefaultTFVersion:        defaultVersion,
+		ProjectCmdOutputHandler: jobmocks.NewMockProjectCommandOutputHandler(),
+	}
+	out, err := r.Run(ctx, cmd, t.TempDir(), envs, false, valid.PostProcessRunOutputShow)
+	Ok(t, err)
+	Equals(t, "token= region=us-east-1 secret=secret env=value\n", out)
+
+	t.Log("variables set by steps aren't affected by the policy")
+	r.EnvPolicy = valid.EnvPolicy{Allow: []string{"ATLANTIS_GH_TOKEN", "AWS_*"}, Deny: []string{"AWS_SECRET_ACCESS_KEY", "FROM_ENV_STEP"}}
+	out, err = r.Run(ctx, cmd, t.TempDir(), envs, false, valid.PostProcessRunOutputShow)
+	Ok(t, err)
+	Equals(t, "token=token region=us-east-1 secret= env=value\n", out)
+}
@@ -0,0 +1,349 @@
+package sandbox
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/events/command"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// initArg0 is the name the Atlantis binary is re-executed with to set up
+	// a sandbox before running the sandboxed command.
+	initArg0 = "atlantis-sandbox-init"
+	// configEnvVar passes the sandbox to the re-executed binary.
+	configEnvVar = "ATLANTIS_SANDBOX_CONFIG"
+	// pluginCacheEnvVar is the terraform plugin cache the command uses.
+	pluginCacheEnvVar = "TF_PLUGIN_CACHE_DIR"
+	// initFailedExitCode is the exit code if the sandbox couldn't be set up.
+	initFailedExitCode = 126
+)
+
+func init() {
+	if len(os.Args) < 2 || os.Args[0] != initArg0 {
+		return
+	}
+	if err := runInit(); err != nil {
+		fmt.Fprintf(os.Stderr, "setting up sandbox: %s\n", err)
+		os.Exit(initFailedExitCode)
+	}
+}
+
+// Prepare changes cmd, which must not have been started, to run in sb. It
+// must be called after models.PrepareDeadline.
+//
+// The command is run by re-executing the Atlantis binary in a new mount
+// namespace. It hides the data dir, except for the clone, moves itself into
+// the job's cgroup, sets rlimits and switches to the job's user before
+// executing the command.
+//
+// The returned function must be called with the error of cmd.Wait. If the
+// command was killed because it exceeded a limit, it returns a
+// command.LimitError.
+func Prepare(cmd *exec.Cmd, sb *command.Sandbox) (func(error) error, error) {
+	if cmd.Err != nil {
+		return nil, cmd.Err
+	}
+	cfg, err := json.Marshal(sb)
+	if err != nil {
+		return nil, err
+	}
+	cmd.Args = append([]string{initArg0, cmd.Path}, cmd.Args...)
+	cmd.Path = "/proc/self/exe"
+	if cmd.Env == nil {
+		cmd.Env = os.Environ()
+	}
+	cmd.Env = append(cmd.Env,
+		"HOME="+sb.HomeDir,
+		"TMPDIR="+sb.HomeDir,
+		configEnvVar+"="+string(cfg),
+	)
+	// Commands that would use the shared plugin cache use the sandbox's own
+	// cache instead, and none if it doesn't have one.
+	for _, e := range cmd.Env {
+		if strings.HasPrefix(e, pluginCacheEnvVar+"=") {
+			cmd.Env = append(cmd.Env, pluginCacheEnvVar+"="+sb.PluginCacheDir)
+			break
+		}
+	}
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Cloneflags |= syscall.CLONE_NEWNS
+
+	before := readCgroupEvents(sb.CgroupDir)
+	return func(err error) error {
+		if err == nil {
+			return nil
+		}
+		after := readCgroupEvents(sb.CgroupDir)
+		if after.oomKills > before.oomKills {
+			return command.LimitError{Limit: "memory", Value: formatBytes(sb.Limits.Memory)}
+		}
+		if after.pidsMax > before.pidsMax {
+			return command.LimitError{Limit: "process", Value: strconv.Itoa(sb.Limits.Processes)}
+		}
+		var exitErr *exec.ExitError
+		if sb.Limits.CPUTime > 0 && errors.As(err, &exitErr) {
+			// The shell exits with 128 plus the signal number if the
+			// command it ran was killed by a signal.
+			status, ok := exitErr.Sys().(syscall.WaitStatus)
+			if ok && ((status.Signaled() && status.Signal() == syscall.SIGXCPU) || status.ExitStatus() == 128+int(syscall.SIGXCPU)) {
+				return command.LimitError{Limit: "CPU time", Value: sb.Limits.CPUTime.String()}
+			}
+		}
+		return err
+	}, nil
+}
+
+// runInit sets up the sandbox passed by Prepare and executes the sandboxed
+// command. It only returns if that fails.
+func runInit() error {
+	var sb command.Sandbox
+	if err := json.Unmarshal([]byte(os.Getenv(configEnvVar)), &sb); err != nil {
+		return fmt.Errorf("parsing $%s: %w", configEnvVar, err)
+	}
+	var env []string
+	for _, e := range os.Environ() {
+		if !strings.HasPrefix(e, configEnvVar+"=") {
+			env = append(env, e)
+		}
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	// Don't propagate our mounts to the mount namespace of Atlantis.
+	if err := unix.Mount("", "/", "", unix.MS_REC|unix.MS_PRIVATE, ""); err != nil {
+		return fmt.Errorf("making mounts private: %w", err)
+	}
+	if sb.HiddenDir != "" {
+		if err := hideDir(sb); err != nil {
+			return err
+		}
+	}
+	// Change to the working dir again since the one we started in is now
+	// hidden.
+	if err := os.Chdir(wd); err != nil {
+		return err
+	}
+
+	if sb.CgroupDir != "" {
+		if err := os.WriteFile(filepath.Join(sb.CgroupDir, "cgroup.procs"), []byte(strconv.Itoa(os.Getpid())), 0600); err != nil {
+			return fmt.Errorf("moving into cgroup: %w", err)
+		}
+	}
+	if err := setRlimits(sb); err != nil {
+		return err
+	}
+
+	if err := syscall.Setgroups(nil); err != nil {
+		return fmt.Errorf("dropping groups: %w", err)
+	}
+	if err := syscall.Setgid(sb.GID); err != nil {
+		return fmt.Errorf("changing group: %w", err)
+	}
+	if err := syscall.Setuid(sb.UID); err != nil {
+		return fmt.Errorf("changing user: %w", err)
+	}
+	return syscall.Exec(os.Args[1], os.Args[2:], env) // #nosec
+}
+
+// hideDir mounts an empty file system over sb.HiddenDir and mounts the
+// directories the sandbox can access back into it. Only the sandbox's plugin
+// cache is mounted from the plugin cache dir.
+func hideDir(sb command.Sandbox) error {
+	type visibleDir struct {
+		path     string
+		readOnly bool
+		fd       int
+	}
+	dirs := []visibleDir{{path: sb.CloneDir}}
+	for _, d := range sb.ReadOnlyDirs {
+		dirs = append(dirs, visibleDir{path: d, readOnly: true})
+	}
+	if sb.PluginCacheDir != "" {
+		dirs = append(dirs, visibleDir{path: sb.PluginCacheDir})
+	}
+
+	// Open the directories before they're hidden so that they can be mounted
+	// from their file descriptors afterwards.
+	var visible []visibleDir
+	for _, d := range dirs {
+		fd, err := unix.Open(d.path, unix.O_PATH|unix.O_DIRECTORY|unix.O_CLOEXEC, 0)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("opening %s: %w", d.path, err)
+		}
+		d.fd = fd
+		visible = append(visible, d)
+	}
+
+	if err := unix.Mount("tmpfs", sb.HiddenDir, "tmpfs", unix.MS_NOSUID|unix.MS_NODEV, "mode=0755"); err != nil {
+		return fmt.Errorf("hiding %s: %w", sb.HiddenDir, err)
+	}
+	for _, d := range visible {
+		if err := os.MkdirAll(d.path, 0755); err != nil {
+			return err
+		}
+		if err := unix.Mount(fmt.Sprintf("/proc/self/fd/%d", d.fd), d.path, "", unix.MS_BIND|unix.MS_REC, ""); err != nil {
+			return fmt.Errorf("mounting %s: %w", d.path, err)
+		}
+		if d.readOnly {
+			if err := unix.Mount("", d.path, "", unix.MS_BIND|unix.MS_REMOUNT|unix.MS_RDONLY|unix.MS_NOSUID|unix.MS_NODEV, ""); err != nil {
+				return fmt.Errorf("making %s read-only: %w", d.path, err)
+			}
+		}
+		unix.Close(d.fd) // nolint: errcheck
+	}
+	return nil
+}
+
+// setRlimits sets the limits that aren't enforced by the cgroup of sb.
+func setRlimits(sb command.Sandbox) error {
+	if sb.Limits.CPUTime > 0 {
+		// Processes get SIGXCPU when they reach the soft limit and are killed
+		// a few seconds later if they ignore it.
+		secs := uint64(sb.Limits.CPUTime.Seconds())
+		if err := unix.Setrlimit(unix.RLIMIT_CPU, &unix.Rlimit{Cur: secs, Max: secs + 5}); err != nil {
+			return fmt.Errorf("setting CPU time limit: %w", err)
+		}
+	}
+	if sb.CgroupDir != "" {
+		return nil
+	}
+	if sb.Limits.Memory > 0 {
+		limit := uint64(sb.Limits.Memory)
+		if err := unix.Setrlimit(unix.RLIMIT_DATA, &unix.Rlimit{Cur: limit, Max: limit}); err != nil {
+			return fmt.Errorf("setting memory limit: %w", err)
+		}
+	}
+	if sb.Limits.Processes > 0 {
+		// RLIMIT_NPROC counts the processes of the user, which only runs this
+		// job.
+		limit := uint64(sb.Limits.Processes)
+		if err := unix.Setrlimit(unix.RLIMIT_NPROC, &unix.Rlimit{Cur: limit, Max: limit}); err != nil {
+			return fmt.Errorf("setting process limit: %w", err)
+		}
+	}
+	return nil
+}
+
+// killUserProcesses kills every process running as uid. It's used to clean
+// up after sandboxes without a cgroup, whose processes can't be killed all at
+// once. Since they can fork while we kill them, it tries a few times.
+func killUserProcesses(uid int) error {
+	for i := 0; i < 10; i++ {
+		pids, err := userProcesses(uid)
+		if err != nil {
+			return err
+		}
+		if len(pids) == 0 {
+			return nil
+		}
+		for _, pid := range pids {
+			if err := syscall.Kill(pid, syscall.SIGKILL); err != nil && err != syscall.ESRCH {
+				return fmt.Errorf("killing process %d: %w", pid, err)
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return fmt.Errorf("processes of UID %d are still running", uid)
+}
+
+// userProcesses returns the IDs of the processes whose real, effective, saved
+// or file system UID is uid.
+func userProcesses(uid int) ([]int, error) {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil, err
+	}
+	var pids []int
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		status, err := os.ReadFile(filepath.Join("/proc", entry.Name(), "status")) // #nosec
+		if err != nil {
+			// The process exited.
+			continue
+		}
+		for _, line := range strings.Split(string(status), "\n") {
+			ids, found := strings.CutPrefix(line, "Uid:")
+			if !found {
+				continue
+			}
+			for _, id := range strings.Fields(ids) {
+				if id == strconv.Itoa(uid) {
+					pids = append(pids, pid)
+					break
+				}
+			}
+			break
+		}
+	}
+	return pids, nil
+}
+
+// cgroupEvents counts how often the limits of a cgroup were hit.
+type cgroupEvents struct {
+	oomKills int
+	pidsMax  int
+}
+
+// readCgroupEvents reads the events of the cgroup at dir. Events that can't
+// be read are 0.
+func readCgroupEvents(dir string) cgroupEvents {
+	var events cgroupEvents
+	if dir == "" {
+		return events
+	}
+	events.oomKills = readCgroupEvent(filepath.Join(dir, "memory.events"), "oom_kill")
+	events.pidsMax = readCgroupEvent(filepath.Join(dir, "pids.events"), "max")
+	return events
+}
+
+func readCgroupEvent(path string, name string) int {
+	f, err := os.Open(path) // #nosec
+	if err != nil {
+		return 0
+	}
+	defer f.Close() // nolint: errcheck
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		key, value, _ := strings.Cut(scanner.Text(), " ")
+		if key == name {
+			n, _ := strconv.Atoi(value)
+			return n
+		}
+	}
+	return 0
+}
+
+// formatBytes formats n bytes in the largest unit that divides it, ex.
+// 512MiB.
+func formatBytes(n int64) string {
+	for _, unit := range []struct {
+		name string
+		size int64
+	}{{"GiB", 1 << 30}, {"MiB", 1 << 20}, {"KiB", 1 << 10}} {
+		if n >= unit.size && n%unit.size == 0 {
+			return fmt.Sprintf("%d%s", n/unit.size, unit.name)
+		}
+	}
+	return fmt.Sprintf("%d bytes", n)
+}
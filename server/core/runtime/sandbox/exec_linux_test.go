@@ -0,0 +1,135 @@
+package sandbox_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/core/runtime/sandbox"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestPrepare(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("sandboxes can only be tested as root")
+	}
+	dataDir := t.TempDir()
+	// The sandbox's user must be able to get to the data dir.
+	Ok(t, os.Chmod(filepath.Dir(dataDir), 0755)) // nolint: gosec
+	cloneDir := filepath.Join(dataDir, "repos", "owner", "repo", "1", "default")
+	otherCloneDir := filepath.Join(dataDir, "repos", "owner", "repo", "2", "default")
+	binDir := filepath.Join(dataDir, "bin")
+	cacheDir := filepath.Join(dataDir, "plugin-cache")
+	for _, dir := range []string{cloneDir, otherCloneDir, binDir, cacheDir} {
+		Ok(t, os.MkdirAll(dir, 0755))
+	}
+	Ok(t, os.WriteFile(filepath.Join(otherCloneDir, "default.tfplan"), []byte("secret"), 0644))
+	Ok(t, os.WriteFile(filepath.Join(cacheDir, "provider"), nil, 0755))                                  // nolint: gosec
+	Ok(t, os.WriteFile(filepath.Join(binDir, "terraform"), []byte("#!/bin/sh\necho terraform\n"), 0755)) // nolint: gosec
+
+	pool, err := sandbox.NewPool(sandbox.Config{
+		FirstUID:       100000,
+		LastUID:        100000,
+		DataDir:        dataDir,
+		ReadOnlyDirs:   []string{binDir},
+		PluginCacheDir: cacheDir,
+		Limits:         command.SandboxLimits{CPUTime: time.Second},
+	})
+	Ok(t, err)
+	sb, release, err := pool.Acquire(logging.NewNoopLogger(t), cloneDir)
+	Ok(t, err)
+	defer release()
+
+	run := func(script string) (string, error) {
+		cmd := exec.Command("sh", "-c", script)
+		cmd.Dir = cloneDir
+		cmd.Env = []string{"PATH=" + os.Getenv("PATH"), "TF_PLUGIN_CACHE_DIR=" + cacheDir}
+		limitExceeded, err := sandbox.Prepare(cmd, sb)
+		Ok(t, err)
+		out, err := cmd.CombinedOutput()
+		return strings.TrimSpace(string(out)), limitExceeded(err)
+	}
+
+	t.Log("commands run as the sandbox's user with a private home dir")
+	out, err := run(`echo "$(id -u) $(id -g) $HOME $TMPDIR"`)
+	Ok(t, err)
+	Equals(t, strings.Join([]string{"100000", "100000", sb.HomeDir, sb.HomeDir}, " "), out)
+
+	t.Log("commands can write to their clone and run binaries from read-only dirs")
+	out, err = run(binDir + "/terraform > plan.out && cat plan.out && pwd")
+	Ok(t, err)
+	Equals(t, "terraform\n"+cloneDir, out)
+	_, err = run("touch " + binDir + "/provider")
+	Assert(t, err != nil, "exp bin dir to be read-only")
+
+	t.Log("commands use their own plugin cache instead of the shared one")
+	out, err = run(`touch "$TF_PLUGIN_CACHE_DIR/provider" && echo "$TF_PLUGIN_CACHE_DIR"`)
+	Ok(t, err)
+	Equals(t, filepath.Join(cacheDir, "100000"), out)
+	_, err = run("ls " + filepath.Join(cacheDir, "provider"))
+	Assert(t, err != nil, "exp shared plugin cache to be hidden")
+	_, err = run("touch " + filepath.Join(cacheDir, "planted"))
+	Assert(t, err != nil, "exp shared plugin cache not to be writable")
+
+	t.Log("other clones are hidden")
+	out, err = run("ls " + filepath.Join(dataDir, "repos", "owner", "repo") + "; cat " + filepath.Join(otherCloneDir, "default.tfplan"))
+	Assert(t, err != nil, "exp reading another clone to fail")
+	Equals(t, "1", strings.Split(out, "\n")[0])
+
+	t.Log("exceeding the CPU time limit is reported")
+	_, err = run("while :; do :; done")
+	ErrEquals(t, "exceeded the CPU time limit of 1s", err)
+}
+
+func TestPool_KillsLeftoverProcesses(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("sandboxes can only be tested as root")
+	}
+	dataDir := t.TempDir()
+	Ok(t, os.Chmod(filepath.Dir(dataDir), 0755)) // nolint: gosec
+	cloneDir := filepath.Join(dataDir, "repos", "owner", "repo", "1", "default")
+	Ok(t, os.MkdirAll(cloneDir, 0755))
+	pool, err := sandbox.NewPool(sandbox.Config{
+		FirstUID: 100000,
+		LastUID:  100000,
+		DataDir:  dataDir,
+	})
+	Ok(t, err)
+	sb, release, err := pool.Acquire(logging.NewNoopLogger(t), cloneDir)
+	Ok(t, err)
+
+	t.Log("without a cgroup, processes left by the job are killed when the sandbox is freed")
+	cmd := exec.Command("sleep", "60")
+	cmd.Dir = cloneDir
+	cmd.Env = []string{"PATH=" + os.Getenv("PATH")}
+	_, err = sandbox.Prepare(cmd, sb)
+	Ok(t, err)
+	Ok(t, cmd.Start())
+	// Wait for the command to switch to the sandbox's user.
+	for i := 0; i < 100; i++ {
+		if cmdUID(cmd.Process.Pid) == "100000" {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	Equals(t, "100000", cmdUID(cmd.Process.Pid))
+	release()
+	Assert(t, cmd.Wait() != nil, "exp leftover process to be killed")
+}
+
+// cmdUID returns the real UID of the process with id pid.
+func cmdUID(pid int) string {
+	status, _ := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "status"))
+	for _, line := range strings.Split(string(status), "\n") {
+		if ids, found := strings.CutPrefix(line, "Uid:"); found {
+			return strings.Fields(ids)[0]
+		}
+	}
+	return ""
+}
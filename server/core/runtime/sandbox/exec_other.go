@@ -0,0 +1,19 @@
+//go:build !linux
+
+package sandbox
+
+import (
+	"errors"
+	"os/exec"
+
+	"github.com/runatlantis/atlantis/server/events/command"
+)
+
+// Prepare returns an error since sandboxes are only supported on Linux.
+func Prepare(_ *exec.Cmd, _ *command.Sandbox) (func(error) error, error) {
+	return nil, errors.New("sandboxes are only supported on Linux")
+}
+
+func killUserProcesses(_ int) error {
+	return errors.New("sandboxes are only supported on Linux")
+}
@@ -0,0 +1,311 @@
+// Package sandbox runs the commands of a job as a dedicated user that can
+// only access the job's clone, with limits on the resources they can use.
+package sandbox
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/logging"
+)
+
+// Config configures the sandboxes of a Pool.
+type Config struct {
+	// FirstUID and LastUID are the range of UIDs jobs run as. Each job uses a
+	// UID that no other job is using. Its GID is the same as its UID.
+	FirstUID int
+	LastUID  int
+	// DataDir is the Atlantis data dir, which is hidden from jobs except for
+	// their clone, ReadOnlyDirs and their plugin cache.
+	DataDir      string
+	ReadOnlyDirs []string
+	// PluginCacheDir is the terraform plugin cache. Each UID gets its own
+	// cache in it instead of sharing a cache every job can write to, where
+	// one job could plant providers for all the others. If it's empty, jobs
+	// don't get a plugin cache.
+	PluginCacheDir string
+	// CgroupDir is a cgroup v2 delegated to Atlantis that jobs' cgroups are
+	// created in. If it's empty, limits are enforced with rlimits, which
+	// apply to each process instead of the whole job.
+	CgroupDir string
+	Limits    command.SandboxLimits
+}
+
+// ParseUIDRange parses a range of UIDs like 100000-100999.
+func ParseUIDRange(s string) (int, int, error) {
+	firstStr, lastStr, found := strings.Cut(s, "-")
+	if !found {
+		return 0, 0, fmt.Errorf("invalid UID range %q, must be FIRST-LAST", s)
+	}
+	first, err := strconv.Atoi(firstStr)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid UID range %q, must be FIRST-LAST", s)
+	}
+	last, err := strconv.Atoi(lastStr)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid UID range %q, must be FIRST-LAST", s)
+	}
+	if first <= 0 || last < first {
+		return 0, 0, fmt.Errorf("invalid UID range %q, UIDs must be positive and FIRST can't be greater than LAST", s)
+	}
+	return first, last, nil
+}
+
+// Pool hands out sandboxes to jobs.
+type Pool struct {
+	cfg    Config
+	mu     sync.Mutex
+	inUse  map[int]bool
+	clones map[string]*cloneSandbox
+}
+
+// cloneSandbox is the sandbox shared by the jobs running in the same clone at
+// once, ex. the projects of a pull request that are planned in parallel. The
+// clone can only have one owner so they run as the same user.
+type cloneSandbox struct {
+	// mu is held while the sandbox is created and freed.
+	mu sync.Mutex
+	// users is how many jobs are using the sandbox. It's guarded by Pool.mu.
+	users int
+	sb    *command.Sandbox
+}
+
+// NewPool returns a Pool for cfg. Atlantis must run as root to use
+// sandboxes.
+func NewPool(cfg Config) (*Pool, error) {
+	if runtime.GOOS != "linux" {
+		return nil, fmt.Errorf("sandboxes are only supported on Linux")
+	}
+	if os.Geteuid() != 0 {
+		return nil, fmt.Errorf("atlantis must run as root to use sandboxes")
+	}
+	if cfg.PluginCacheDir != "" {
+		// Only the caches in it are mounted into sandboxes, so no job needs
+		// to write to the directory itself.
+		if err := os.Chmod(cfg.PluginCacheDir, 0700); err != nil {
+			return nil, fmt.Errorf("changing mode of %s: %w", cfg.PluginCacheDir, err)
+		}
+	}
+	if cfg.CgroupDir != "" {
+		var controllers []string
+		if cfg.Limits.Memory > 0 {
+			controllers = append(controllers, "+memory")
+		}
+		if cfg.Limits.Processes > 0 {
+			controllers = append(controllers, "+pids")
+		}
+		if len(controllers) > 0 {
+			if err := os.WriteFile(filepath.Join(cfg.CgroupDir, "cgroup.subtree_control"), []byte(strings.Join(controllers, " ")), 0600); err != nil {
+				return nil, fmt.Errorf("enabling cgroup controllers in %s: %w", cfg.CgroupDir, err)
+			}
+		}
+	}
+	return &Pool{
+		cfg:    cfg,
+		inUse:  make(map[int]bool),
+		clones: make(map[string]*cloneSandbox),
+	}, nil
+}
+
+// Acquire returns a sandbox for a job that runs in cloneDir. The returned
+// function must be called once the job is done to free the sandbox.
+//
+// Jobs that run in the same clone at once share a sandbox. The clone is owned
+// by the sandbox's user until the last of them is done.
+func (p *Pool) Acquire(log logging.SimpleLogging, cloneDir string) (*command.Sandbox, func(), error) {
+	p.mu.Lock()
+	c, ok := p.clones[cloneDir]
+	if !ok {
+		c = &cloneSandbox{}
+		p.clones[cloneDir] = c
+	}
+	c.users++
+	p.mu.Unlock()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.sb == nil {
+		sb, err := p.create(log, cloneDir)
+		if err != nil {
+			p.leave(cloneDir, c)
+			return nil, nil, err
+		}
+		c.sb = sb
+	}
+	var once sync.Once
+	return c.sb, func() {
+		once.Do(func() {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			if p.leave(cloneDir, c) {
+				p.free(log, c.sb)
+				c.sb = nil
+			}
+		})
+	}, nil
+}
+
+// leave removes a user of c and returns whether it was the last one.
+func (p *Pool) leave(cloneDir string, c *cloneSandbox) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	c.users--
+	if c.users > 0 {
+		return false
+	}
+	delete(p.clones, cloneDir)
+	return true
+}
+
+// create creates a sandbox for cloneDir and makes its user the owner of the
+// clone.
+func (p *Pool) create(log logging.SimpleLogging, cloneDir string) (*command.Sandbox, error) {
+	uid, err := p.allocateUID()
+	if err != nil {
+		return nil, err
+	}
+	sb := &command.Sandbox{
+		UID:          uid,
+		GID:          uid,
+		HiddenDir:    p.cfg.DataDir,
+		CloneDir:     cloneDir,
+		ReadOnlyDirs: p.cfg.ReadOnlyDirs,
+		Limits:       p.cfg.Limits,
+	}
+	if sb.HomeDir, err = os.MkdirTemp("", fmt.Sprintf("atlantis-sandbox-%d-", uid)); err != nil {
+		p.free(log, sb)
+		return nil, fmt.Errorf("creating home dir of sandbox: %w", err)
+	}
+	if err := os.Chown(sb.HomeDir, uid, uid); err != nil {
+		p.free(log, sb)
+		return nil, fmt.Errorf("changing owner of home dir of sandbox: %w", err)
+	}
+	if err := chownAll(cloneDir, uid, uid); err != nil {
+		p.free(log, sb)
+		return nil, fmt.Errorf("changing owner of %s: %w", cloneDir, err)
+	}
+	if p.cfg.PluginCacheDir != "" {
+		if sb.PluginCacheDir, err = createPluginCache(p.cfg.PluginCacheDir, uid); err != nil {
+			p.free(log, sb)
+			return nil, err
+		}
+	}
+	if p.cfg.CgroupDir != "" {
+		if sb.CgroupDir, err = p.createCgroup(uid); err != nil {
+			p.free(log, sb)
+			return nil, err
+		}
+	}
+	return sb, nil
+}
+
+// free kills the processes left in sb, ex. daemons started by its jobs,
+// removes what was created for it and gives its clone back to Atlantis. Its
+// UID isn't reused if its processes couldn't be killed.
+func (p *Pool) free(log logging.SimpleLogging, sb *command.Sandbox) {
+	killed := true
+	if sb.CgroupDir != "" {
+		if err := removeCgroup(sb.CgroupDir); err != nil {
+			log.Warn("removing cgroup of sandbox: %s", err)
+			killed = false
+		}
+	} else if err := killUserProcesses(sb.UID); err != nil {
+		log.Warn("killing processes of sandbox: %s", err)
+		killed = false
+	}
+	if sb.HomeDir != "" {
+		if err := os.RemoveAll(sb.HomeDir); err != nil {
+			log.Warn("removing home dir of sandbox: %s", err)
+		}
+	}
+	if err := chownAll(sb.CloneDir, os.Getuid(), os.Getgid()); err != nil {
+		log.Warn("changing owner of %s back: %s", sb.CloneDir, err)
+	}
+	if !killed {
+		log.Warn("not reusing sandbox UID %d since its processes may still be running", sb.UID)
+		return
+	}
+	p.freeUID(sb.UID)
+}
+
+func (p *Pool) allocateUID() (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for uid := p.cfg.FirstUID; uid <= p.cfg.LastUID; uid++ {
+		if !p.inUse[uid] {
+			p.inUse[uid] = true
+			return uid, nil
+		}
+	}
+	return 0, fmt.Errorf("all %d sandbox UIDs are in use", p.cfg.LastUID-p.cfg.FirstUID+1)
+}
+
+func (p *Pool) freeUID(uid int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.inUse, uid)
+}
+
+// createCgroup creates the cgroup of the job running as uid.
+func (p *Pool) createCgroup(uid int) (string, error) {
+	dir := filepath.Join(p.cfg.CgroupDir, fmt.Sprintf("atlantis-%d", uid))
+	if err := os.Mkdir(dir, 0755); err != nil && !os.IsExist(err) {
+		return "", fmt.Errorf("creating cgroup: %w", err)
+	}
+	if p.cfg.Limits.Memory > 0 {
+		if err := os.WriteFile(filepath.Join(dir, "memory.max"), []byte(strconv.FormatInt(p.cfg.Limits.Memory, 10)), 0600); err != nil {
+			return dir, fmt.Errorf("setting memory limit of cgroup: %w", err)
+		}
+		// Don't let the job swap instead of hitting the limit.
+		if err := os.WriteFile(filepath.Join(dir, "memory.swap.max"), []byte("0"), 0600); err != nil && !os.IsNotExist(err) {
+			return dir, fmt.Errorf("setting swap limit of cgroup: %w", err)
+		}
+	}
+	if p.cfg.Limits.Processes > 0 {
+		if err := os.WriteFile(filepath.Join(dir, "pids.max"), []byte(strconv.Itoa(p.cfg.Limits.Processes)), 0600); err != nil {
+			return dir, fmt.Errorf("setting process limit of cgroup: %w", err)
+		}
+	}
+	return dir, nil
+}
+
+// createPluginCache creates the plugin cache of uid in dir if it doesn't
+// exist. It's kept once the sandbox is freed so later jobs with the same UID
+// don't download the providers again.
+func createPluginCache(dir string, uid int) (string, error) {
+	cache := filepath.Join(dir, strconv.Itoa(uid))
+	if err := os.Mkdir(cache, 0700); err != nil && !os.IsExist(err) {
+		return "", fmt.Errorf("creating plugin cache of sandbox: %w", err)
+	}
+	if err := os.Lchown(cache, uid, uid); err != nil {
+		return "", fmt.Errorf("changing owner of plugin cache of sandbox: %w", err)
+	}
+	return cache, nil
+}
+
+// removeCgroup kills the processes left in the cgroup at dir, ex. daemons
+// started by the job, and removes it.
+func removeCgroup(dir string) error {
+	// cgroup.kill only exists since Linux 5.14.
+	if err := os.WriteFile(filepath.Join(dir, "cgroup.kill"), []byte("1"), 0600); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return os.Remove(dir)
+}
+
+// chownAll changes the owner of dir and everything in it.
+func chownAll(dir string, uid int, gid int) error {
+	return filepath.WalkDir(dir, func(path string, _ fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		return os.Lchown(path, uid, gid)
+	})
+}
@@ -0,0 +1,105 @@
+package sandbox_test
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"syscall"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/core/runtime/sandbox"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestParseUIDRange(t *testing.T) {
+	cases := []struct {
+		input    string
+		expFirst int
+		expLast  int
+		expErr   string
+	}{
+		{input: "100000-100999", expFirst: 100000, expLast: 100999},
+		{input: "100000-100000", expFirst: 100000, expLast: 100000},
+		{input: "100000", expErr: `invalid UID range "100000", must be FIRST-LAST`},
+		{input: "a-b", expErr: `invalid UID range "a-b", must be FIRST-LAST`},
+		{input: "0-10", expErr: `invalid UID range "0-10", UIDs must be positive and FIRST can't be greater than LAST`},
+		{input: "10-1", expErr: `invalid UID range "10-1", UIDs must be positive and FIRST can't be greater than LAST`},
+	}
+	for _, c := range cases {
+		t.Run(c.input, func(t *testing.T) {
+			first, last, err := sandbox.ParseUIDRange(c.input)
+			if c.expErr != "" {
+				ErrEquals(t, c.expErr, err)
+				return
+			}
+			Ok(t, err)
+			Equals(t, c.expFirst, first)
+			Equals(t, c.expLast, last)
+		})
+	}
+}
+
+func TestPool_Acquire(t *testing.T) {
+	if runtime.GOOS != "linux" || os.Geteuid() != 0 {
+		t.Skip("sandboxes can only be tested on Linux as root")
+	}
+	logger := logging.NewNoopLogger(t)
+	dataDir := t.TempDir()
+	cloneDir := filepath.Join(dataDir, "repos", "owner", "repo", "1", "default")
+	otherCloneDir := filepath.Join(dataDir, "repos", "owner", "repo", "2", "default")
+	Ok(t, os.MkdirAll(cloneDir, 0700))
+	Ok(t, os.MkdirAll(otherCloneDir, 0700))
+	Ok(t, os.WriteFile(filepath.Join(cloneDir, "main.tf"), nil, 0600))
+	cacheDir := filepath.Join(dataDir, "plugin-cache")
+	Ok(t, os.Mkdir(cacheDir, 0755))
+
+	pool, err := sandbox.NewPool(sandbox.Config{
+		FirstUID:       100000,
+		LastUID:        100000,
+		DataDir:        dataDir,
+		PluginCacheDir: cacheDir,
+	})
+	Ok(t, err)
+
+	sb, release, err := pool.Acquire(logger, cloneDir)
+	Ok(t, err)
+	Equals(t, 100000, sb.UID)
+	Equals(t, 100000, sb.GID)
+	Equals(t, cloneDir, sb.CloneDir)
+	Equals(t, dataDir, sb.HiddenDir)
+	Equals(t, 100000, owner(t, filepath.Join(cloneDir, "main.tf")))
+	Equals(t, 100000, owner(t, sb.HomeDir))
+	Equals(t, filepath.Join(cacheDir, "100000"), sb.PluginCacheDir)
+	Equals(t, 100000, owner(t, sb.PluginCacheDir))
+
+	_, _, err = pool.Acquire(logger, otherCloneDir)
+	ErrEquals(t, "all 1 sandbox UIDs are in use", err)
+
+	t.Log("jobs in the same clone share its sandbox")
+	shared, releaseShared, err := pool.Acquire(logger, cloneDir)
+	Ok(t, err)
+	Assert(t, shared == sb, "exp jobs in the same clone to share a sandbox")
+	release()
+	Equals(t, 100000, owner(t, filepath.Join(cloneDir, "main.tf")))
+	_, err = os.Stat(sb.HomeDir)
+	Ok(t, err)
+
+	releaseShared()
+	Equals(t, os.Getuid(), owner(t, filepath.Join(cloneDir, "main.tf")))
+	_, err = os.Stat(sb.HomeDir)
+	Assert(t, os.IsNotExist(err), "exp home dir to be removed")
+	_, err = os.Stat(sb.PluginCacheDir)
+	Ok(t, err)
+
+	t.Log("the UID can be used again once it's released")
+	_, release, err = pool.Acquire(logger, cloneDir)
+	Ok(t, err)
+	release()
+}
+
+func owner(t *testing.T, path string) int {
+	info, err := os.Stat(path)
+	Ok(t, err)
+	return int(info.Sys().(*syscall.Stat_t).Uid)
+}
@@ -0,0 +1,76 @@
+package runtime
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// StepOutputsEnvVar is set to the path of the file that run steps can write
+// their outputs to.
+const StepOutputsEnvVar = "ATLANTIS_OUTPUTS"
+
+// stepOutputNameRegex matches the names of step outputs. They must be valid
+// environment variable names since they're passed to later steps as
+// environment variables.
+var stepOutputNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ReadStepOutputs returns the outputs a run step wrote to the file at path.
+// The file contains either a JSON object or KEY=VALUE lines, ex.
+//
+//	COST=42.10
+//	CRITICAL_FINDINGS=0
+//
+// Values in a JSON object that aren't strings are converted to JSON.
+func ReadStepOutputs(path string) (map[string]string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading $%s file: %w", StepOutputsEnvVar, err)
+	}
+	trimmed := strings.TrimSpace(string(content))
+	if trimmed == "" {
+		return nil, nil
+	}
+
+	outputs := make(map[string]string)
+	if strings.HasPrefix(trimmed, "{") {
+		var obj map[string]json.RawMessage
+		if err := json.Unmarshal([]byte(trimmed), &obj); err != nil {
+			return nil, fmt.Errorf("parsing $%s file as JSON: %w", StepOutputsEnvVar, err)
+		}
+		for name, raw := range obj {
+			var str string
+			if err := json.Unmarshal(raw, &str); err == nil {
+				outputs[name] = str
+			} else {
+				outputs[name] = string(raw)
+			}
+		}
+	} else {
+		scanner := bufio.NewScanner(strings.NewReader(trimmed))
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			name, value, found := strings.Cut(line, "=")
+			if !found {
+				return nil, fmt.Errorf("invalid line %q in $%s file, must be KEY=VALUE", line, StepOutputsEnvVar)
+			}
+			outputs[strings.TrimSpace(name)] = value
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("reading $%s file: %w", StepOutputsEnvVar, err)
+		}
+	}
+
+	for name := range outputs {
+		if !stepOutputNameRegex.MatchString(name) {
+			return nil, fmt.Errorf("invalid output name %q in $%s file, must only contain letters, digits and underscores", name, StepOutputsEnvVar)
+		}
+	}
+	return outputs, nil
+}
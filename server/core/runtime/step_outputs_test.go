@@ -0,0 +1,62 @@
+package runtime_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/core/runtime"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestReadStepOutputs(t *testing.T) {
+	cases := []struct {
+		description string
+		content     string
+		exp         map[string]string
+		expErr      string
+	}{
+		{
+			description: "empty",
+			content:     "\n",
+		},
+		{
+			description: "key value lines",
+			content:     "# estimate\nCOST=42.10\n\nSUMMARY=a=b\n",
+			exp:         map[string]string{"COST": "42.10", "SUMMARY": "a=b"},
+		},
+		{
+			description: "json",
+			content:     `{"COST": "42.10", "CRITICAL_FINDINGS": 0, "CHECKS": ["a", "b"]}`,
+			exp:         map[string]string{"COST": "42.10", "CRITICAL_FINDINGS": "0", "CHECKS": `["a", "b"]`},
+		},
+		{
+			description: "line without value",
+			content:     "COST",
+			expErr:      `invalid line "COST" in $ATLANTIS_OUTPUTS file, must be KEY=VALUE`,
+		},
+		{
+			description: "invalid name",
+			content:     "MONTHLY-COST=42",
+			expErr:      `invalid output name "MONTHLY-COST" in $ATLANTIS_OUTPUTS file, must only contain letters, digits and underscores`,
+		},
+		{
+			description: "invalid json",
+			content:     `{"COST": }`,
+			expErr:      "parsing $ATLANTIS_OUTPUTS file as JSON: invalid character '}' looking for beginning of value",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "outputs")
+			Ok(t, os.WriteFile(path, []byte(c.content), 0600))
+			outputs, err := runtime.ReadStepOutputs(path)
+			if c.expErr != "" {
+				ErrEquals(t, c.expErr, err)
+				return
+			}
+			Ok(t, err)
+			Equals(t, c.exp, outputs)
+		})
+	}
+}
@@ -37,6 +37,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/warrensbox/terraform-switcher/lib"
 
+	"github.com/runatlantis/atlantis/server/core/config/valid"
 	"github.com/runatlantis/atlantis/server/core/runtime/models"
 	"github.com/runatlantis/atlantis/server/events/command"
 	"github.com/runatlantis/atlantis/server/events/terraform/ansi"
@@ -91,6 +92,10 @@ type DefaultClient struct {
 	usePluginCache bool
 
 	projectCmdOutputHandler jobs.ProjectCommandOutputHandler
+
+	// envPolicy decides which of Atlantis's environment variables terraform
+	// inherits.
+	envPolicy valid.EnvPolicy
 }
 
 //go:generate pegomock generate --package mocks -o mocks/mock_downloader.go Downloader
@@ -267,6 +272,13 @@ func NewClient(
 	)
 }
 
+// SetEnvPolicy sets the policy that decides which of Atlantis's environment
+// variables terraform inherits. By default it inherits all of them except
+// Atlantis's own secrets.
+func (c *DefaultClient) SetEnvPolicy(policy valid.EnvPolicy) {
+	c.envPolicy = policy
+}
+
 // Version returns the default version of Terraform we use if no other version
 // is defined.
 func (c *DefaultClient) DefaultVersion() *version.Version {
@@ -415,7 +427,7 @@ func (c *DefaultClient) RunCommandWithVersion(ctx command.ProjectContext, path s
 	}
 	cmd.Env = envVars
 	start := time.Now()
-	out, err := cmd.CombinedOutput()
+	out, err := models.CombinedOutput(ctx, cmd)
 	dur := time.Since(start)
 	log := ctx.Log.With("duration", dur)
 	if err != nil {
@@ -478,7 +490,7 @@ func (c *DefaultClient) prepCmd(log logging.SimpleLogging, v *version.Version, w
 	}
 	// Append current Atlantis process's environment variables, ex.
 	// AWS_ACCESS_KEY.
-	envVars = append(envVars, os.Environ()...)
+	envVars = append(envVars, c.envPolicy.Filter(os.Environ())...)
 	tfCmd := fmt.Sprintf("%s %s", binPath, strings.Join(args, " "))
 	return tfCmd, envVars, nil
 }
@@ -0,0 +1,81 @@
+package events
+
+import (
+	"github.com/runatlantis/atlantis/server/audit"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+)
+
+// AuditedProjectCommandRunner is a decorator that records an audit event for
+// each command run on a project. Version is run for every plan so it isn't
+// audited.
+type AuditedProjectCommandRunner struct {
+	ProjectCommandRunner
+	Auditor *audit.Auditor
+}
+
+func (p *AuditedProjectCommandRunner) Plan(ctx command.ProjectContext) command.ProjectResult {
+	return p.run(ctx, p.ProjectCommandRunner.Plan)
+}
+
+func (p *AuditedProjectCommandRunner) PolicyCheck(ctx command.ProjectContext) command.ProjectResult {
+	return p.run(ctx, p.ProjectCommandRunner.PolicyCheck)
+}
+
+func (p *AuditedProjectCommandRunner) Apply(ctx command.ProjectContext) command.ProjectResult {
+	return p.run(ctx, p.ProjectCommandRunner.Apply)
+}
+
+func (p *AuditedProjectCommandRunner) ApprovePolicies(ctx command.ProjectContext) command.ProjectResult {
+	return p.run(ctx, p.ProjectCommandRunner.ApprovePolicies)
+}
+
+func (p *AuditedProjectCommandRunner) Import(ctx command.ProjectContext) command.ProjectResult {
+	return p.run(ctx, p.ProjectCommandRunner.Import)
+}
+
+func (p *AuditedProjectCommandRunner) StateRm(ctx command.ProjectContext) command.ProjectResult {
+	return p.run(ctx, p.ProjectCommandRunner.StateRm)
+}
+
+func (p *AuditedProjectCommandRunner) Custom(ctx command.ProjectContext) command.ProjectResult {
+	return p.run(ctx, p.ProjectCommandRunner.Custom)
+}
+
+func (p *AuditedProjectCommandRunner) run(ctx command.ProjectContext, execute func(ctx command.ProjectContext) command.ProjectResult) command.ProjectResult {
+	result := execute(ctx)
+	p.Auditor.Record(newProjectAuditEvent(ctx, result))
+	return result
+}
+
+// newProjectAuditEvent returns the audit event for result, the result of
+// running the command described by ctx.
+func newProjectAuditEvent(ctx command.ProjectContext, result command.ProjectResult) models.AuditEvent {
+	event := models.AuditEvent{
+		Type:      models.CommandAuditEvent,
+		Actor:     ctx.User.Username,
+		Repo:      ctx.BaseRepo.FullName,
+		Pull:      ctx.Pull.Num,
+		Project:   ctx.ProjectName,
+		Directory: ctx.RepoRelDir,
+		Workspace: ctx.Workspace,
+		Command:   ctx.CommandName.String(),
+		Outcome:   models.AuditSuccess,
+		JobID:     ctx.JobID,
+	}
+	if ctx.CommandName == command.ApprovePolicies {
+		event.Type = models.PolicyApprovalAuditEvent
+	}
+	switch {
+	case result.Error != nil:
+		event.Outcome = models.AuditError
+		event.Detail = result.Error.Error()
+	case result.Failure != "":
+		event.Outcome = models.AuditFailure
+		event.Detail = result.Failure
+	case result.PlanSuccess != nil:
+		stats := result.PlanSuccess.Stats()
+		event.PlanStats = &stats
+	}
+	return event
+}
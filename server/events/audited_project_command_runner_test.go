@@ -0,0 +1,81 @@
+package events_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/petergtz/pegomock/v4"
+	"github.com/runatlantis/atlantis/server/audit"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/mocks"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+type recordingAuditSink struct {
+	events []models.AuditEvent
+}
+
+func (r *recordingAuditSink) Write(event models.AuditEvent) error {
+	r.events = append(r.events, event)
+	return nil
+}
+
+func TestAuditedProjectCommandRunner(t *testing.T) {
+	RegisterMockTestingT(t)
+	mockRunner := mocks.NewMockProjectCommandRunner()
+	sink := &recordingAuditSink{}
+	runner := &events.AuditedProjectCommandRunner{
+		ProjectCommandRunner: mockRunner,
+		Auditor:              audit.NewAuditor(logging.NewNoopLogger(t), sink),
+	}
+	ctx := command.ProjectContext{
+		CommandName: command.Plan,
+		User:        models.User{Username: "alice"},
+		BaseRepo:    models.Repo{FullName: "owner/repo"},
+		Pull:        models.PullRequest{Num: 2},
+		ProjectName: "network",
+		RepoRelDir:  "network",
+		Workspace:   "prod",
+		JobID:       "job",
+	}
+
+	When(mockRunner.Plan(Any[command.ProjectContext]())).ThenReturn(command.ProjectResult{
+		PlanSuccess: &models.PlanSuccess{TerraformOutput: "Plan: 1 to add, 2 to change, 3 to destroy."},
+	})
+	runner.Plan(ctx)
+	Equals(t, 1, len(sink.events))
+	event := sink.events[0]
+	Assert(t, !event.Time.IsZero(), "exp time to be set")
+	event.Time = time.Time{}
+	Equals(t, models.AuditEvent{
+		Type:      models.CommandAuditEvent,
+		Actor:     "alice",
+		Repo:      "owner/repo",
+		Pull:      2,
+		Project:   "network",
+		Directory: "network",
+		Workspace: "prod",
+		Command:   "plan",
+		Outcome:   models.AuditSuccess,
+		PlanStats: &models.PlanSuccessStats{Add: 1, Change: 2, Destroy: 3, Changes: true},
+		JobID:     "job",
+	}, event)
+
+	t.Log("failed applies are recorded with their error")
+	ctx.CommandName = command.Apply
+	When(mockRunner.Apply(Any[command.ProjectContext]())).ThenReturn(command.ProjectResult{Error: errors.New("boom")})
+	runner.Apply(ctx)
+	Equals(t, models.AuditError, sink.events[1].Outcome)
+	Equals(t, "boom", sink.events[1].Detail)
+
+	t.Log("approving policies is recorded as a policy approval")
+	ctx.CommandName = command.ApprovePolicies
+	When(mockRunner.ApprovePolicies(Any[command.ProjectContext]())).ThenReturn(command.ProjectResult{Failure: "not an owner"})
+	runner.ApprovePolicies(ctx)
+	Equals(t, models.PolicyApprovalAuditEvent, sink.events[2].Type)
+	Equals(t, models.AuditFailure, sink.events[2].Outcome)
+}
@@ -1,18 +1,44 @@
 package events
 
 import (
+	"errors"
 	"fmt"
+	"sync"
+	"time"
 
 	"github.com/runatlantis/atlantis/server/events/command"
 	"github.com/runatlantis/atlantis/server/events/models"
 	"github.com/runatlantis/atlantis/server/events/vcs"
 )
 
+// defaultAutomergePollInterval is how often we check whether the pull request's
+// required statuses have passed when PollInterval isn't set.
+const defaultAutomergePollInterval = 30 * time.Second
+
 type AutoMerger struct {
 	VCSClient       vcs.Client
 	GlobalAutomerge bool
+	// MergeMethod is the method used to merge pull requests. If empty, each
+	// VCS client uses its default.
+	MergeMethod models.MergeMethod
+	// ChecksTimeout is how long to wait for the pull request's required
+	// statuses and checks to pass before abandoning the merge. If zero, we
+	// merge without waiting.
+	ChecksTimeout time.Duration
+	// PollInterval is how often we check the required statuses and checks.
+	PollInterval time.Duration
+	// VCSStatusName is the name Atlantis uses for its own commit statuses.
+	// They are ignored when checking whether the pull request can be merged.
+	VCSStatusName string
+	// Drainer, if set, is used to make shutdown wait for in-progress merges.
+	Drainer *Drainer
+
+	wg sync.WaitGroup
 }
 
+// automerge merges the pull request once all projects have been applied. If
+// ChecksTimeout is set, it merges in the background once the pull request's
+// required statuses and checks have passed.
 func (c *AutoMerger) automerge(ctx *command.Context, pullStatus models.PullStatus, deleteSourceBranchOnMerge bool) {
 	// We only automerge if all projects have been successfully applied.
 	for _, p := range pullStatus.Projects {
@@ -22,16 +48,53 @@ func (c *AutoMerger) automerge(ctx *command.Context, pullStatus models.PullStatu
 		}
 	}
 
+	if c.Drainer != nil && !c.Drainer.StartOp() {
+		c.abandon(ctx, "atlantis is shutting down")
+		return
+	}
+
 	// Comment that we're automerging the pull request.
 	if err := c.VCSClient.CreateComment(ctx.Pull.BaseRepo, ctx.Pull.Num, automergeComment, command.Apply.String()); err != nil {
 		ctx.Log.Err("failed to comment about automerge: %s", err)
 		// Commenting isn't required so continue.
 	}
 
+	pullOptions := models.PullRequestOptions{
+		DeleteSourceBranchOnMerge: deleteSourceBranchOnMerge,
+		MergeMethod:               c.MergeMethod,
+	}
+	merge := func() {
+		if c.Drainer != nil {
+			defer c.Drainer.OpDone()
+		}
+		c.waitAndMerge(ctx, pullOptions)
+	}
+	// There's nothing to wait for so merge right away, like before checks
+	// could be waited for.
+	if c.ChecksTimeout <= 0 {
+		merge()
+		return
+	}
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		merge()
+	}()
+}
+
+// Wait blocks until all automerges started so far have completed.
+func (c *AutoMerger) Wait() {
+	c.wg.Wait()
+}
+
+func (c *AutoMerger) waitAndMerge(ctx *command.Context, pullOptions models.PullRequestOptions) {
+	if err := c.waitForChecks(ctx); err != nil {
+		c.abandon(ctx, err.Error())
+		return
+	}
+
 	// Make the API call to perform the merge.
 	ctx.Log.Info("automerging pull request")
-	var pullOptions models.PullRequestOptions
-	pullOptions.DeleteSourceBranchOnMerge = deleteSourceBranchOnMerge
 	err := c.VCSClient.MergePull(ctx.Pull, pullOptions)
 
 	if err != nil {
@@ -44,6 +107,50 @@ func (c *AutoMerger) automerge(ctx *command.Context, pullStatus models.PullStatu
 	}
 }
 
+// waitForChecks polls until the pull request's required statuses and checks
+// have passed. It returns an error describing why we stopped waiting if they
+// didn't pass within ChecksTimeout.
+func (c *AutoMerger) waitForChecks(ctx *command.Context) error {
+	if c.ChecksTimeout <= 0 {
+		return nil
+	}
+	interval := c.PollInterval
+	if interval <= 0 {
+		interval = defaultAutomergePollInterval
+	}
+
+	deadline := time.Now().Add(c.ChecksTimeout)
+	for {
+		mergeable, err := c.VCSClient.PullIsMergeable(ctx.Pull.BaseRepo, ctx.Pull, c.VCSStatusName)
+		if err != nil {
+			// The error may be transient so we keep polling until we time out.
+			ctx.Log.Warn("checking if pull request is mergeable: %s", err)
+		} else if mergeable {
+			return nil
+		}
+
+		if c.Drainer != nil && c.Drainer.GetStatus().ShuttingDown {
+			return errors.New("atlantis is shutting down")
+		}
+		if time.Now().Add(interval).After(deadline) {
+			return fmt.Errorf("required statuses and checks did not pass within %s", c.ChecksTimeout)
+		}
+		ctx.Log.Debug("pull request is not mergeable yet, checking again in %s", interval)
+		time.Sleep(interval)
+	}
+}
+
+// abandon comments on the pull request with the reason automerge was
+// abandoned.
+func (c *AutoMerger) abandon(ctx *command.Context, reason string) {
+	ctx.Log.Warn("abandoning automerge: %s", reason)
+
+	comment := fmt.Sprintf("Automerging abandoned:\n```\n%s\n```", reason)
+	if err := c.VCSClient.CreateComment(ctx.Pull.BaseRepo, ctx.Pull.Num, comment, command.Apply.String()); err != nil {
+		ctx.Log.Err("failed to comment about automerge being abandoned: %s", err)
+	}
+}
+
 // automergeEnabled returns true if automerging is enabled in this context.
 func (c *AutoMerger) automergeEnabled(projectCmds []command.ProjectContext) bool {
 	// Use project automerge settings if projects exist; otherwise, use global automerge settings.
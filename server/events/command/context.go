@@ -1,6 +1,7 @@
 package command
 
 import (
+	"github.com/runatlantis/atlantis/server/core/config/valid"
 	"github.com/runatlantis/atlantis/server/events/models"
 	"github.com/runatlantis/atlantis/server/logging"
 	tally "github.com/uber-go/tally/v4"
@@ -15,6 +16,10 @@ const (
 
 	// Commands that are triggered by comments (ie. atlantis plan)
 	CommentTrigger
+
+	// Commands that are triggered by a pull request being merged (ie. apply
+	// for projects using apply_mode: after_merge)
+	MergeTrigger
 )
 
 // Context represents the context of a command that should be executed
@@ -43,4 +48,8 @@ type Context struct {
 	ClearPolicyApproval bool
 
 	Trigger Trigger
+
+	// CustomCommand is the custom command being run, if this is a custom
+	// command.
+	CustomCommand *valid.CustomCommand
 }
@@ -0,0 +1,57 @@
+package command
+
+import (
+	"fmt"
+	"time"
+)
+
+// Deadline limits how long the commands Atlantis runs for a project can run.
+type Deadline struct {
+	// At is when the commands are killed. If it's zero they're never killed.
+	At time.Time
+	// Timeout is how long the commands were allowed to run. It's used to tell
+	// users why they were killed.
+	Timeout time.Duration
+}
+
+// Within returns the earlier of d and a deadline timeout from now. If
+// timeout is 0, d is returned.
+func (d Deadline) Within(timeout time.Duration) Deadline {
+	if timeout <= 0 {
+		return d
+	}
+	at := time.Now().Add(timeout)
+	if !d.At.IsZero() && d.At.Before(at) {
+		return d
+	}
+	return Deadline{At: at, Timeout: timeout}
+}
+
+// Sleep waits for delay and returns true, unless d passes first, in which
+// case it returns false as soon as it does.
+func (d Deadline) Sleep(delay time.Duration) bool {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	if d.At.IsZero() {
+		<-timer.C
+		return true
+	}
+	deadline := time.NewTimer(time.Until(d.At))
+	defer deadline.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-deadline.C:
+		return false
+	}
+}
+
+// TimeoutError is returned when a command is killed because it ran past its
+// deadline.
+type TimeoutError struct {
+	Timeout time.Duration
+}
+
+func (e TimeoutError) Error() string {
+	return fmt.Sprintf("timed out after %s", e.Timeout)
+}
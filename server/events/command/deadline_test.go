@@ -0,0 +1,39 @@
+package command_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/events/command"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestDeadline_Within(t *testing.T) {
+	var none command.Deadline
+	Equals(t, none, none.Within(0))
+
+	hour := none.Within(time.Hour)
+	Equals(t, time.Hour, hour.Timeout)
+	Assert(t, time.Until(hour.At) > 59*time.Minute, "exp deadline in an hour, got %s", hour.At)
+
+	t.Log("the earlier deadline wins")
+	Equals(t, hour, hour.Within(2*time.Hour))
+	minute := hour.Within(time.Minute)
+	Equals(t, time.Minute, minute.Timeout)
+	Assert(t, minute.At.Before(hour.At), "exp deadline in a minute, got %s", minute.At)
+	Equals(t, hour, hour.Within(0))
+}
+
+func TestDeadline_Sleep(t *testing.T) {
+	var none command.Deadline
+	Assert(t, none.Sleep(time.Millisecond), "exp sleep without a deadline to finish")
+	Assert(t, none.Within(time.Hour).Sleep(time.Millisecond), "exp sleep before the deadline to finish")
+
+	start := time.Now()
+	Assert(t, !none.Within(10*time.Millisecond).Sleep(time.Hour), "exp sleep past the deadline to stop")
+	Assert(t, time.Since(start) < time.Minute, "exp sleep to stop at the deadline")
+}
+
+func TestTimeoutError(t *testing.T) {
+	ErrEquals(t, "timed out after 1h30m0s", command.TimeoutError{Timeout: 90 * time.Minute})
+}
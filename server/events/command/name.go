@@ -3,6 +3,7 @@ package command
 import (
 	"fmt"
 	"strings"
+	"sync"
 
 	"golang.org/x/text/cases"
 	"golang.org/x/text/language"
@@ -33,6 +34,49 @@ const (
 	// Adding more? Don't forget to update String() below
 )
 
+// firstCustom is the Name of the first custom command registered with
+// RegisterCustom. It leaves room for more built-in commands.
+const firstCustom Name = 1000
+
+var (
+	customMu sync.RWMutex
+	// customNames are the names of the custom commands. The Name of
+	// customNames[i] is firstCustom + i.
+	customNames []string
+)
+
+// RegisterCustom registers a custom command configured in the server-side
+// repo config and returns its Name. Registering the same name again returns
+// the same Name.
+func RegisterCustom(name string) Name {
+	customMu.Lock()
+	defer customMu.Unlock()
+	for i, n := range customNames {
+		if n == name {
+			return firstCustom + Name(i)
+		}
+	}
+	customNames = append(customNames, name)
+	return firstCustom + Name(len(customNames)-1)
+}
+
+// IsCustom returns true if c is a custom command registered with
+// RegisterCustom.
+func (c Name) IsCustom() bool {
+	_, ok := c.customName()
+	return ok
+}
+
+func (c Name) customName() (string, bool) {
+	customMu.RLock()
+	defer customMu.RUnlock()
+	i := int(c - firstCustom)
+	if i < 0 || i >= len(customNames) {
+		return "", false
+	}
+	return customNames[i], true
+}
+
 type ArgCount struct {
 	Min int
 	Max int
@@ -75,6 +119,9 @@ func (c Name) String() string {
 	case State:
 		return "state"
 	}
+	if name, ok := c.customName(); ok {
+		return name
+	}
 	return ""
 }
 
@@ -150,5 +197,12 @@ func ParseCommandName(name string) (Name, error) {
 	case "state":
 		return State, nil
 	}
+	customMu.RLock()
+	defer customMu.RUnlock()
+	for i, n := range customNames {
+		if n == name {
+			return firstCustom + Name(i), nil
+		}
+	}
 	return -1, fmt.Errorf("unknown command name: %s", name)
 }
@@ -192,3 +192,18 @@ func TestParseCommandName(t *testing.T) {
 		assert.ErrorContains(t, err, "unknown command name: unknown")
 	})
 }
+
+func TestRegisterCustom(t *testing.T) {
+	validate := command.RegisterCustom("validate")
+	assert.Equal(t, validate, command.RegisterCustom("validate"))
+	assert.NotEqual(t, validate, command.RegisterCustom("fmt-check"))
+	assert.True(t, validate.IsCustom())
+	assert.False(t, command.Plan.IsCustom())
+	assert.Equal(t, "validate", validate.String())
+	assert.Equal(t, "Validate", validate.TitleString())
+	assert.Equal(t, "validate", validate.DefaultUsage())
+
+	got, err := command.ParseCommandName("validate")
+	assert.NoError(t, err)
+	assert.Equal(t, validate, got)
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/hashicorp/go-version"
 	"github.com/runatlantis/atlantis/server/core/config/valid"
@@ -125,6 +126,32 @@ type ProjectContext struct {
 	AbortOnExcecutionOrderFail bool
 	// Allows custom policy check tools outside of Conftest to run in checks
 	CustomPolicyCheck bool
+	// ApplyMode controls whether this project is applied from the pull request
+	// or after the pull request has been merged.
+	ApplyMode valid.ApplyMode
+	// Trigger is how the command for this project was triggered.
+	Trigger Trigger
+	// PlanTimeout is how long plan can run for this project. If 0, the server
+	// default is used.
+	PlanTimeout time.Duration
+	// ApplyTimeout is how long apply can run for this project. If 0, the
+	// server default is used.
+	ApplyTimeout time.Duration
+	// Deadline is when the commands currently running for this project are
+	// killed.
+	Deadline Deadline
+	// Sandbox is the sandbox the commands for this project run in. If nil,
+	// they run as the Atlantis user.
+	Sandbox *Sandbox
+	// CustomCommand is the custom command being run, if this is a custom
+	// command. Steps are the steps of its stage.
+	CustomCommand *valid.CustomCommand
+	// Runner is the runner label of the workers this project runs on. If
+	// empty, it runs on the Atlantis server.
+	Runner string
+	// PullLabels are the labels of the pull request, if they've already been
+	// fetched. If nil, when expressions of steps fetch them from the VCS.
+	PullLabels []string
 }
 
 // SetProjectScopeTags adds ProjectContext tags to a new returned scope.
@@ -164,6 +191,16 @@ func (p ProjectContext) GetPolicyCheckResultFileName() string {
 	return fmt.Sprintf("%s-%s-policyout.json", projName, p.Workspace)
 }
 
+// GetStepOutputsFileName returns the filename (not the path) of the file run
+// steps write their outputs to.
+func (p ProjectContext) GetStepOutputsFileName() string {
+	if p.ProjectName == "" {
+		return fmt.Sprintf("%s-outputs", p.Workspace)
+	}
+	projName := strings.Replace(p.ProjectName, "/", planfileSlashReplace, -1)
+	return fmt.Sprintf("%s-%s-outputs", projName, p.Workspace)
+}
+
 // Gets a unique identifier for the current pull request as a single string
 func (p ProjectContext) PullInfo() string {
 	normalizedOwner := strings.ReplaceAll(p.BaseRepo.Owner, "/", "-")
@@ -6,19 +6,20 @@ import (
 
 // ProjectResult is the result of executing a plan/policy_check/apply for a specific project.
 type ProjectResult struct {
-	Command            Name
-	SubCommand         string
-	RepoRelDir         string
-	Workspace          string
-	Error              error
-	Failure            string
-	PlanSuccess        *models.PlanSuccess
-	PolicyCheckResults *models.PolicyCheckResults
-	ApplySuccess       string
-	VersionSuccess     string
-	ImportSuccess      *models.ImportSuccess
-	StateRmSuccess     *models.StateRmSuccess
-	ProjectName        string
+	Command              Name
+	SubCommand           string
+	RepoRelDir           string
+	Workspace            string
+	Error                error
+	Failure              string
+	PlanSuccess          *models.PlanSuccess
+	PolicyCheckResults   *models.PolicyCheckResults
+	ApplySuccess         string
+	VersionSuccess       string
+	ImportSuccess        *models.ImportSuccess
+	StateRmSuccess       *models.StateRmSuccess
+	CustomCommandSuccess *models.CustomCommandSuccess
+	ProjectName          string
 }
 
 // CommitStatus returns the vcs commit status of this project result.
@@ -48,6 +49,15 @@ func (p ProjectResult) PolicyStatus() []models.PolicySetStatus {
 	return policyStatuses
 }
 
+// PlanDigest returns the digest of the plan's changes, or "" if this isn't
+// the result of a successful plan.
+func (p ProjectResult) PlanDigest() string {
+	if p.PlanSuccess == nil {
+		return ""
+	}
+	return p.PlanSuccess.ChangesDigest()
+}
+
 // PlanStatus returns the plan status.
 func (p ProjectResult) PlanStatus() models.ProjectPlanStatus {
 	switch p.Command {
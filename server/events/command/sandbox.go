@@ -0,0 +1,57 @@
+package command
+
+import (
+	"fmt"
+	"time"
+)
+
+// Sandbox describes the sandbox the commands Atlantis runs for a project run
+// in. Sandboxes are only supported on Linux.
+type Sandbox struct {
+	// UID and GID are the user and group the commands run as. Each job gets
+	// its own UID so that jobs can't read each other's files.
+	UID int
+	GID int
+	// HomeDir is a private directory used as the commands' HOME and TMPDIR.
+	HomeDir string
+	// HiddenDir is hidden from the commands, except for CloneDir,
+	// ReadOnlyDirs and PluginCacheDir. It's the Atlantis data dir.
+	HiddenDir string
+	// CloneDir is the clone the commands run in.
+	CloneDir string
+	// ReadOnlyDirs are directories in HiddenDir the commands can read, ex.
+	// the directory terraform binaries are downloaded to.
+	ReadOnlyDirs []string
+	// PluginCacheDir is the terraform plugin cache of UID, which is used
+	// instead of the one shared by unsandboxed jobs. If it's empty, the
+	// commands don't get a plugin cache.
+	PluginCacheDir string
+	// CgroupDir is the cgroup v2 the commands are moved into. If it's empty,
+	// the limits are enforced with rlimits instead.
+	CgroupDir string
+	Limits    SandboxLimits
+}
+
+// SandboxLimits limits the resources the commands running in a sandbox can
+// use. Zero values mean no limit.
+type SandboxLimits struct {
+	// Memory is the maximum memory in bytes.
+	Memory int64
+	// CPUTime is the maximum CPU time of each process.
+	CPUTime time.Duration
+	// Processes is the maximum number of processes.
+	Processes int
+}
+
+// LimitError is returned when a command is killed because it exceeded a
+// limit of its sandbox.
+type LimitError struct {
+	// Limit is the name of the limit, ex. "memory".
+	Limit string
+	// Value is the limit that was exceeded, ex. "512MiB".
+	Value string
+}
+
+func (e LimitError) Error() string {
+	return fmt.Sprintf("exceeded the %s limit of %s", e.Limit, e.Value)
+}
@@ -15,6 +15,7 @@ type CommandRequirementHandler interface {
 	ValidatePlanProject(repoDir string, ctx command.ProjectContext) (string, error)
 	ValidateApplyProject(repoDir string, ctx command.ProjectContext) (string, error)
 	ValidateImportProject(repoDir string, ctx command.ProjectContext) (string, error)
+	ValidateCustomProject(repoDir string, ctx command.ProjectContext) (string, error)
 }
 
 type DefaultCommandRequirementHandler struct {
@@ -43,6 +44,26 @@ func (a *DefaultCommandRequirementHandler) ValidatePlanProject(repoDir string, c
 }
 
 func (a *DefaultCommandRequirementHandler) ValidateApplyProject(repoDir string, ctx command.ProjectContext) (failure string, err error) {
+	if ctx.ApplyMode == valid.AfterMergeApplyMode {
+		if ctx.Trigger != command.MergeTrigger {
+			return fmt.Sprintf("This project is configured with `%s: %s` and will be applied automatically once the pull request is merged.", valid.ApplyModeKey, valid.AfterMergeApplyMode), nil
+		}
+		// The pull request has already been merged so it can no longer be
+		// mergeable or diverged; only the remaining requirements apply.
+		for _, req := range ctx.ApplyRequirements {
+			switch req {
+			case raw.ApprovedRequirement:
+				if !ctx.PullReqStatus.ApprovalStatus.IsApproved {
+					return "Pull request must be approved according to the project's approval rules before running apply.", nil
+				}
+			case valid.PoliciesPassedCommandReq:
+				if !ctx.PolicyCleared() {
+					return "All policies must pass for project before running apply.", nil
+				}
+			}
+		}
+		return "", nil
+	}
 	for _, req := range ctx.ApplyRequirements {
 		switch req {
 		case raw.ApprovedRequirement:
@@ -103,3 +124,27 @@ func (a *DefaultCommandRequirementHandler) ValidateImportProject(repoDir string,
 	// Passed all import requirements configured.
 	return "", nil
 }
+
+func (a *DefaultCommandRequirementHandler) ValidateCustomProject(repoDir string, ctx command.ProjectContext) (failure string, err error) {
+	if ctx.CustomCommand == nil {
+		return "", nil
+	}
+	for _, req := range ctx.CustomCommand.Requirements {
+		switch req {
+		case raw.ApprovedRequirement:
+			if !ctx.PullReqStatus.ApprovalStatus.IsApproved {
+				return fmt.Sprintf("Pull request must be approved according to the project's approval rules before running %s.", ctx.CustomCommand.Name), nil
+			}
+		case raw.MergeableRequirement:
+			if !ctx.PullReqStatus.Mergeable {
+				return fmt.Sprintf("Pull request must be mergeable before running %s.", ctx.CustomCommand.Name), nil
+			}
+		case raw.UnDivergedRequirement:
+			if a.WorkingDir.HasDiverged(repoDir) {
+				return fmt.Sprintf("Default branch must be rebased onto pull request before running %s.", ctx.CustomCommand.Name), nil
+			}
+		}
+	}
+	// Passed all requirements of the custom command.
+	return "", nil
+}
@@ -189,6 +189,38 @@ func TestAggregateApplyRequirements_ValidateApplyProject(t *testing.T) {
 			wantFailure: "Default branch must be rebased onto pull request before running apply.",
 			wantErr:     assert.NoError,
 		},
+		{
+			name: "fail by after_merge apply mode on pull request",
+			ctx: command.ProjectContext{
+				ApplyMode: valid.AfterMergeApplyMode,
+				Trigger:   command.CommentTrigger,
+			},
+			wantFailure: "This project is configured with `apply_mode: after_merge` and will be applied automatically once the pull request is merged.",
+			wantErr:     assert.NoError,
+		},
+		{
+			name: "pass after_merge apply mode once merged",
+			ctx: command.ProjectContext{
+				ApplyMode:         valid.AfterMergeApplyMode,
+				Trigger:           command.MergeTrigger,
+				ApplyRequirements: []string{raw.ApprovedRequirement, raw.MergeableRequirement, raw.UnDivergedRequirement},
+				PullReqStatus: models.PullReqStatus{
+					ApprovalStatus: models.ApprovalStatus{IsApproved: true},
+					Mergeable:      false,
+				},
+			},
+			wantErr: assert.NoError,
+		},
+		{
+			name: "fail after_merge apply mode once merged by no approved",
+			ctx: command.ProjectContext{
+				ApplyMode:         valid.AfterMergeApplyMode,
+				Trigger:           command.MergeTrigger,
+				ApplyRequirements: []string{raw.ApprovedRequirement},
+			},
+			wantFailure: "Pull request must be approved according to the project's approval rules before running apply.",
+			wantErr:     assert.NoError,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -45,6 +45,9 @@ type CommandRunner interface {
 	// and then calling the appropriate services to finish executing the command.
 	RunCommentCommand(baseRepo models.Repo, maybeHeadRepo *models.Repo, maybePull *models.PullRequest, user models.User, pullNum int, cmd *CommentCommand)
 	RunAutoplanCommand(baseRepo models.Repo, headRepo models.Repo, pull models.PullRequest, user models.User)
+	// RunPostMergeCommand applies projects configured with apply_mode: after_merge
+	// once their pull request has been merged.
+	RunPostMergeCommand(baseRepo models.Repo, headRepo models.Repo, pull models.PullRequest, user models.User)
 }
 
 //go:generate pegomock generate --package mocks -o mocks/mock_github_pull_getter.go GithubPullGetter
@@ -129,6 +132,7 @@ type DefaultCommandRunner struct {
 	TeamAllowlistChecker           *TeamAllowlistChecker
 	VarFileAllowlistChecker        *VarFileAllowlistChecker
 	CommitStatusUpdater            CommitStatusUpdater
+	PostMergeApplyCommandRunner    *PostMergeApplyCommandRunner
 }
 
 // RunAutoplanCommand runs plan and policy_checks when a pull request is opened or updated.
@@ -227,6 +231,56 @@ func (c *DefaultCommandRunner) RunAutoplanCommand(baseRepo models.Repo, headRepo
 	}
 }
 
+// RunPostMergeCommand applies projects configured with apply_mode: after_merge
+// once their pull request has been merged. It must run before the pull request
+// is cleaned up since it relies on the plans recorded for the pull request.
+func (c *DefaultCommandRunner) RunPostMergeCommand(baseRepo models.Repo, headRepo models.Repo, pull models.PullRequest, user models.User) {
+	if !c.GlobalCfg.AfterMergeApplyModePossible(c.Logger, baseRepo.ID()) {
+		return
+	}
+	if opStarted := c.Drainer.StartOp(); !opStarted {
+		if commentErr := c.VCSClient.CreateComment(baseRepo, pull.Num, ShutdownComment, command.Apply.String()); commentErr != nil {
+			c.Logger.Log(logging.Error, "unable to comment that Atlantis is shutting down: %s", commentErr)
+		}
+		return
+	}
+	defer c.Drainer.OpDone()
+
+	log := c.buildLogger(baseRepo.FullName, pull.Num)
+	defer c.logPanics(baseRepo, pull.Num, log)
+	status, err := c.PullStatusFetcher.GetPullStatus(pull)
+	if err != nil {
+		log.Err("Unable to fetch pull status, this is likely a bug.", err)
+		return
+	}
+
+	scope := c.StatsScope.SubScope("post_merge")
+	timer := scope.Timer(metrics.ExecutionTimeMetric).Start()
+	defer timer.Stop()
+
+	// Check if the user who merged the pull request has permissions to run 'apply'.
+	ok, err := c.checkUserPermissions(baseRepo, user, command.Apply.String())
+	if err != nil {
+		c.Logger.Err("Unable to check user permissions: %s", err)
+		return
+	}
+	if !ok {
+		c.commentUserDoesNotHavePermissions(baseRepo, pull.Num, user, &CommentCommand{Name: command.Apply})
+		return
+	}
+
+	ctx := &command.Context{
+		User:       user,
+		Log:        log,
+		Scope:      scope,
+		Pull:       pull,
+		HeadRepo:   headRepo,
+		PullStatus: status,
+		Trigger:    command.MergeTrigger,
+	}
+	c.PostMergeApplyCommandRunner.Run(ctx)
+}
+
 // commentUserDoesNotHavePermissions comments on the pull request that the user
 // is not allowed to execute the command.
 func (c *DefaultCommandRunner) commentUserDoesNotHavePermissions(baseRepo models.Repo, pullNum int, user models.User, cmd *CommentCommand) {
@@ -19,6 +19,7 @@ import (
 	"regexp"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/runatlantis/atlantis/server/core/config/valid"
 	"github.com/runatlantis/atlantis/server/core/db"
@@ -64,6 +65,7 @@ var planCommandRunner *events.PlanCommandRunner
 var applyLockChecker *lockingmocks.MockApplyLockChecker
 var lockingLocker *lockingmocks.MockLocker
 var applyCommandRunner *events.ApplyCommandRunner
+var postMergeApplyCommandRunner *events.PostMergeApplyCommandRunner
 var unlockCommandRunner *events.UnlockCommandRunner
 var importCommandRunner *events.ImportCommandRunner
 var preWorkflowHooksCommandRunner events.PreWorkflowHooksCommandRunner
@@ -182,6 +184,16 @@ func setup(t *testing.T, options ...func(testConfig *TestConfig)) *vcsmocks.Mock
 		pullReqStatusFetcher,
 	)
 
+	postMergeApplyCommandRunner = events.NewPostMergeApplyCommandRunner(
+		applyLockChecker,
+		workingDir,
+		projectCommandBuilder,
+		projectCommandRunner,
+		projectCommandRunner,
+		pullUpdater,
+		pullReqStatusFetcher,
+	)
+
 	approvePoliciesCommandRunner = events.NewApprovePoliciesCommandRunner(
 		commitUpdater,
 		projectCommandBuilder,
@@ -198,6 +210,7 @@ func setup(t *testing.T, options ...func(testConfig *TestConfig)) *vcsmocks.Mock
 		vcsClient,
 		testConfig.SilenceNoProjects,
 		testConfig.DisableUnlockLabel,
+		nil,
 	)
 
 	versionCommandRunner := events.NewVersionCommandRunner(
@@ -253,6 +266,7 @@ func setup(t *testing.T, options ...func(testConfig *TestConfig)) *vcsmocks.Mock
 		PreWorkflowHooksCommandRunner:  preWorkflowHooksCommandRunner,
 		PostWorkflowHooksCommandRunner: postWorkflowHooksCommandRunner,
 		PullStatusFetcher:              testConfig.backend,
+		PostMergeApplyCommandRunner:    postMergeApplyCommandRunner,
 	}
 
 	return vcsClient
@@ -1135,9 +1149,71 @@ func TestApplyWithAutoMerge_VSCMerge(t *testing.T) {
 	}
 
 	ch.RunCommentCommand(testdata.GithubRepo, &testdata.GithubRepo, nil, testdata.User, testdata.Pull.Num, &events.CommentCommand{Name: command.Apply})
+	autoMerger.Wait()
 	vcsClient.VerifyWasCalledOnce().MergePull(modelPull, pullOptions)
 }
 
+func TestApplyWithAutoMerge_WaitsForChecks(t *testing.T) {
+	t.Log("if automerge is configured to wait for checks, the merge happens once the pull request is mergeable")
+
+	vcsClient := setup(t)
+	pull := &github.PullRequest{
+		State: github.String("open"),
+	}
+	modelPull := models.PullRequest{BaseRepo: testdata.GithubRepo, State: models.OpenPullState}
+	When(githubGetter.GetPullRequest(testdata.GithubRepo, testdata.Pull.Num)).ThenReturn(pull, nil)
+	When(eventParsing.ParseGithubPull(pull)).ThenReturn(modelPull, modelPull.BaseRepo, testdata.GithubRepo, nil)
+	When(vcsClient.PullIsMergeable(Any[models.Repo](), Any[models.PullRequest](), Any[string]())).
+		ThenReturn(false, nil).
+		ThenReturn(true, nil)
+	autoMerger.GlobalAutomerge = true
+	autoMerger.MergeMethod = models.SquashMergeMethod
+	autoMerger.ChecksTimeout = time.Minute
+	autoMerger.PollInterval = time.Millisecond
+	defer func() {
+		autoMerger.GlobalAutomerge = false
+		autoMerger.MergeMethod = ""
+		autoMerger.ChecksTimeout = 0
+		autoMerger.PollInterval = 0
+	}()
+
+	ch.RunCommentCommand(testdata.GithubRepo, &testdata.GithubRepo, nil, testdata.User, testdata.Pull.Num, &events.CommentCommand{Name: command.Apply})
+	autoMerger.Wait()
+	vcsClient.VerifyWasCalled(Times(2)).PullIsMergeable(Any[models.Repo](), Any[models.PullRequest](), Any[string]())
+	vcsClient.VerifyWasCalledOnce().MergePull(modelPull, models.PullRequestOptions{MergeMethod: models.SquashMergeMethod})
+}
+
+func TestApplyWithAutoMerge_AbandonedOnTimeout(t *testing.T) {
+	t.Log("if the pull request's checks don't pass before the timeout, automerge is abandoned with a comment")
+
+	vcsClient := setup(t)
+	pull := &github.PullRequest{
+		State: github.String("open"),
+	}
+	modelPull := models.PullRequest{BaseRepo: testdata.GithubRepo, State: models.OpenPullState}
+	When(githubGetter.GetPullRequest(testdata.GithubRepo, testdata.Pull.Num)).ThenReturn(pull, nil)
+	When(eventParsing.ParseGithubPull(pull)).ThenReturn(modelPull, modelPull.BaseRepo, testdata.GithubRepo, nil)
+	When(vcsClient.PullIsMergeable(Any[models.Repo](), Any[models.PullRequest](), Any[string]())).ThenReturn(false, nil)
+	autoMerger.GlobalAutomerge = true
+	autoMerger.ChecksTimeout = 5 * time.Millisecond
+	autoMerger.PollInterval = time.Millisecond
+	defer func() {
+		autoMerger.GlobalAutomerge = false
+		autoMerger.ChecksTimeout = 0
+		autoMerger.PollInterval = 0
+	}()
+
+	ch.RunCommentCommand(testdata.GithubRepo, &testdata.GithubRepo, nil, testdata.User, testdata.Pull.Num, &events.CommentCommand{Name: command.Apply})
+	autoMerger.Wait()
+	vcsClient.VerifyWasCalled(Never()).MergePull(Any[models.PullRequest](), Any[models.PullRequestOptions]())
+	vcsClient.VerifyWasCalledOnce().CreateComment(
+		Eq(testdata.GithubRepo),
+		Eq(modelPull.Num),
+		Eq("Automerging abandoned:\n```\nrequired statuses and checks did not pass within 5ms\n```"),
+		Eq("apply"),
+	)
+}
+
 func TestRunApply_DiscardedProjects(t *testing.T) {
 	t.Log("if \"atlantis apply\" is run with automerge and at least one project" +
 		" has a discarded plan, automerge should not take place")
@@ -24,6 +24,7 @@ import (
 	"text/template"
 
 	"github.com/google/shlex"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
 	"github.com/runatlantis/atlantis/server/events/command"
 	"github.com/runatlantis/atlantis/server/events/models"
 	"github.com/runatlantis/atlantis/server/utils"
@@ -83,6 +84,9 @@ type CommentParser struct {
 	AzureDevopsUser string
 	ExecutableName  string
 	AllowCommands   []command.Name
+	// CustomCommands are the custom commands configured in the server-side
+	// repo config. They're always allowed.
+	CustomCommands []valid.CustomCommand
 }
 
 // NewCommentParser returns a CommentParser
@@ -125,7 +129,7 @@ type CommentParseResult struct {
 //   - The initial "executable" name, 'run' or 'atlantis' or '@GithubUser'
 //     where GithubUser is the API user Atlantis is running as.
 //   - Then a command: 'plan', 'apply', 'unlock', 'version, 'approve_policies',
-//     or 'help'.
+//     'help' or a custom command.
 //   - Then optional flags, then an optional separator '--' followed by optional
 //     extra flags to be appended to the terraform plan/apply command.
 //
@@ -209,11 +213,15 @@ func (e *CommentParser) Parse(rawComment string, vcsHost models.VCSHostType) Com
 	}
 
 	// Need to have allow commands at this point.
-	if !e.isAllowedCommand(cmd) {
+	_, isCustom := e.customCommand(cmd)
+	if !e.isAllowedCommand(cmd) && !isCustom {
 		var allowCommandList []string
 		for _, allowCommand := range e.AllowCommands {
 			allowCommandList = append(allowCommandList, allowCommand.String())
 		}
+		for _, c := range e.CustomCommands {
+			allowCommandList = append(allowCommandList, c.Name)
+		}
 		return CommentParseResult{CommentResponse: fmt.Sprintf("```\nError: unknown command %q.\nRun '%s --help' for usage.\nAvailable commands(--allow-commands): %s\n```", cmd, e.ExecutableName, strings.Join(allowCommandList, ", "))}
 	}
 
@@ -283,7 +291,16 @@ func (e *CommentParser) Parse(rawComment string, vcsHost models.VCSHostType) Com
 		flagSet.StringVarP(&project, projectFlagLong, projectFlagShort, "", "Which project to run state command for. Refers to the name of the project configured in a repo config file. Cannot be used at same time as workspace or dir flags.")
 		flagSet.BoolVarP(&verbose, verboseFlagLong, verboseFlagShort, false, "Append Atlantis log to comment.")
 	default:
-		return CommentParseResult{CommentResponse: fmt.Sprintf("Error: unknown command %q – this is a bug", cmd)}
+		if !isCustom {
+			return CommentParseResult{CommentResponse: fmt.Sprintf("Error: unknown command %q – this is a bug", cmd)}
+		}
+		name = command.RegisterCustom(cmd)
+		flagSet = pflag.NewFlagSet(cmd, pflag.ContinueOnError)
+		flagSet.SetOutput(io.Discard)
+		flagSet.StringVarP(&workspace, workspaceFlagLong, workspaceFlagShort, "", fmt.Sprintf("Switch to this Terraform workspace before running %s.", cmd))
+		flagSet.StringVarP(&dir, dirFlagLong, dirFlagShort, "", fmt.Sprintf("Which directory to run %s in relative to root of repo, ex. 'child/dir'.", cmd))
+		flagSet.StringVarP(&project, projectFlagLong, projectFlagShort, "", fmt.Sprintf("Which project to run %s for. Refers to the name of the project configured in a repo config file. Cannot be used at same time as workspace or dir flags.", cmd))
+		flagSet.BoolVarP(&verbose, verboseFlagLong, verboseFlagShort, false, "Append Atlantis log to comment.")
 	}
 
 	subName, extraArgs, errResult := e.parseArgs(name, args, flagSet)
@@ -467,6 +484,16 @@ func (e *CommentParser) stringInSlice(a string, list []string) bool {
 	return false
 }
 
+// customCommand returns the custom command named cmd.
+func (e *CommentParser) customCommand(cmd string) (valid.CustomCommand, bool) {
+	for _, c := range e.CustomCommands {
+		if c.Name == cmd {
+			return c, true
+		}
+	}
+	return valid.CustomCommand{}, false
+}
+
 func (e *CommentParser) isAllowedCommand(cmd string) bool {
 	for _, allowed := range e.AllowCommands {
 		if allowed.String() == cmd {
@@ -492,6 +519,7 @@ func (e *CommentParser) HelpComment() string {
 		AllowApprovePolicies bool
 		AllowImport          bool
 		AllowState           bool
+		CustomCommands       []valid.CustomCommand
 	}{
 		ExecutableName:       e.ExecutableName,
 		AllowVersion:         e.isAllowedCommand(command.Version.String()),
@@ -501,6 +529,7 @@ func (e *CommentParser) HelpComment() string {
 		AllowApprovePolicies: e.isAllowedCommand(command.ApprovePolicies.String()),
 		AllowImport:          e.isAllowedCommand(command.Import.String()),
 		AllowState:           e.isAllowedCommand(command.State.String()),
+		CustomCommands:       e.CustomCommands,
 	}); err != nil {
 		return fmt.Sprintf("Failed to render template, this is a bug: %v", err)
 	}
@@ -560,6 +589,11 @@ Commands:
   state rm ADDRESS...
            Runs 'terraform state rm' for the passed address resource.
            To remove a specific project resource, use the -d, -w and -p flags.
+{{- end }}
+{{- range .CustomCommands }}
+  {{ if gt (len .Name) 8 }}{{ .Name }}
+          {{ else }}{{ printf "%-8s" .Name }}{{ end }} {{ if .Description }}{{ .Description }}{{ else }}Runs the '{{ .Stage }}' stage of the workflow.{{ end }}
+           To run it for a specific project, use the -d, -w and -p flags.
 {{- end }}
   help     View help.
 
@@ -18,6 +18,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/runatlantis/atlantis/server/core/config/valid"
 	"github.com/runatlantis/atlantis/server/events"
 	"github.com/runatlantis/atlantis/server/events/command"
 	"github.com/runatlantis/atlantis/server/events/models"
@@ -1060,3 +1061,37 @@ var ImportUsage = `Usage of import ADDRESS ID:
       --verbose            Append Atlantis log to comment.
   -w, --workspace string   Switch to this Terraform workspace before importing.
 `
+
+func TestParse_CustomCommand(t *testing.T) {
+	cp := events.CommentParser{
+		ExecutableName: "atlantis",
+		AllowCommands:  []command.Name{command.Plan},
+		CustomCommands: []valid.CustomCommand{
+			{Name: "validate", Description: "Runs 'terraform validate'.", Stage: "validate"},
+			{Name: "fmt-check", Stage: "fmt"},
+		},
+	}
+
+	r := cp.Parse("atlantis validate -d dir -w staging", models.Github)
+	Equals(t, "", r.CommentResponse)
+	Equals(t, "validate", r.Command.Name.String())
+	Assert(t, r.Command.Name.IsCustom(), "exp a custom command")
+	Equals(t, "dir", r.Command.RepoRelDir)
+	Equals(t, "staging", r.Command.Workspace)
+
+	r = cp.Parse("atlantis fmt-check -p network", models.Github)
+	Equals(t, "", r.CommentResponse)
+	Equals(t, "fmt-check", r.Command.Name.String())
+	Equals(t, "network", r.Command.ProjectName)
+
+	r = cp.Parse("atlantis refresh", models.Github)
+	Equals(t, "```\nError: unknown command \"refresh\".\nRun 'atlantis --help' for usage.\nAvailable commands(--allow-commands): plan, validate, fmt-check\n```", r.CommentResponse)
+
+	Assert(t, strings.Contains(cp.HelpComment(), `
+  validate Runs 'terraform validate'.
+           To run it for a specific project, use the -d, -w and -p flags.
+  fmt-check
+           Runs the 'fmt' stage of the workflow.
+           To run it for a specific project, use the -d, -w and -p flags.
+  help     View help.`), "exp custom commands in help, got %s", cp.HelpComment())
+}
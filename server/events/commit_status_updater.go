@@ -14,6 +14,7 @@
 package events
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/runatlantis/atlantis/server/core/runtime"
@@ -76,6 +77,10 @@ func (d *DefaultCommitStatusUpdater) UpdateCombinedCount(repo models.Repo, pull
 		cmdVerb = "policies checked"
 	case command.Apply:
 		cmdVerb = "applied"
+	default:
+		if cmdName.IsCustom() {
+			cmdVerb = fmt.Sprintf("ran %s", cmdName)
+		}
 	}
 
 	return d.Client.UpdateStatus(repo, pull, status, src, fmt.Sprintf("%d/%d projects %s successfully.", numSuccess, numTotal, cmdVerb), "")
@@ -93,6 +98,14 @@ func (d *DefaultCommitStatusUpdater) UpdateProject(ctx command.ProjectContext, c
 		descripWords = genProjectStatusDescription(cmdName.String(), "in progress...")
 	case models.FailedCommitStatus:
 		descripWords = genProjectStatusDescription(cmdName.String(), "failed.")
+		var timeoutErr command.TimeoutError
+		if result != nil && errors.As(result.Error, &timeoutErr) {
+			descripWords = genProjectStatusDescription(cmdName.String(), timeoutErr.Error()+".")
+		}
+		var limitErr command.LimitError
+		if result != nil && errors.As(result.Error, &limitErr) {
+			descripWords = genProjectStatusDescription(cmdName.String(), limitErr.Error()+".")
+		}
 	case models.SuccessCommitStatus:
 		if result != nil && result.PlanSuccess != nil {
 			descripWords = result.PlanSuccess.DiffSummary()
@@ -103,6 +116,18 @@ func (d *DefaultCommitStatusUpdater) UpdateProject(ctx command.ProjectContext, c
 	return d.Client.UpdateStatus(ctx.BaseRepo, ctx.Pull, status, src, descripWords, url)
 }
 
+// UpdateProjectQueued sets the status of the project described by ctx to
+// pending while it waits at position in the execution queue.
+func (d *DefaultCommitStatusUpdater) UpdateProjectQueued(ctx command.ProjectContext, cmdName command.Name, position int) error {
+	projectID := ctx.ProjectName
+	if projectID == "" {
+		projectID = fmt.Sprintf("%s/%s", ctx.RepoRelDir, ctx.Workspace)
+	}
+	src := fmt.Sprintf("%s/%s: %s", d.StatusName, cmdName.String(), projectID)
+	descripWords := genProjectStatusDescription(cmdName.String(), fmt.Sprintf("queued (position %d)...", position))
+	return d.Client.UpdateStatus(ctx.BaseRepo, ctx.Pull, models.PendingCommitStatus, src, descripWords, "")
+}
+
 func genProjectStatusDescription(cmdName, description string) string {
 	return fmt.Sprintf("%s %s", cases.Title(language.English).String(cmdName), description)
 }
@@ -16,6 +16,7 @@ package events_test
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	. "github.com/petergtz/pegomock/v4"
 	"github.com/runatlantis/atlantis/server/events"
@@ -221,6 +222,22 @@ func TestDefaultCommitStatusUpdater_UpdateProject(t *testing.T) {
 			cmd:        command.Apply,
 			expDescrip: "Apply failed.",
 		},
+		{
+			status: models.FailedCommitStatus,
+			cmd:    command.Apply,
+			result: &command.ProjectResult{
+				Error: fmt.Errorf("running apply: %w", command.TimeoutError{Timeout: 30 * time.Minute}),
+			},
+			expDescrip: "Apply timed out after 30m0s.",
+		},
+		{
+			status: models.FailedCommitStatus,
+			cmd:    command.Plan,
+			result: &command.ProjectResult{
+				Error: fmt.Errorf("running plan: %w", command.LimitError{Limit: "memory", Value: "512MiB"}),
+			},
+			expDescrip: "Plan exceeded the memory limit of 512MiB.",
+		},
 		{
 			status: models.SuccessCommitStatus,
 			cmd:    command.Apply,
@@ -258,3 +275,16 @@ func TestDefaultCommitStatusUpdater_UpdateProjectCustomStatusName(t *testing.T)
 	client.VerifyWasCalledOnce().UpdateStatus(models.Repo{}, models.PullRequest{},
 		models.SuccessCommitStatus, "custom/apply: ./default", "Apply succeeded.", "url")
 }
+
+func TestDefaultCommitStatusUpdater_UpdateProjectQueued(t *testing.T) {
+	RegisterMockTestingT(t)
+	client := mocks.NewMockClient()
+	s := events.DefaultCommitStatusUpdater{Client: client, StatusName: "atlantis"}
+	err := s.UpdateProjectQueued(command.ProjectContext{
+		RepoRelDir: ".",
+		Workspace:  "default",
+	}, command.Plan, 3)
+	Ok(t, err)
+	client.VerifyWasCalledOnce().UpdateStatus(models.Repo{}, models.PullRequest{},
+		models.PendingCommitStatus, "atlantis/plan: ./default", "Plan queued (position 3)...", "")
+}
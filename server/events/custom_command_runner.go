@@ -0,0 +1,143 @@
+package events
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/runatlantis/atlantis/server/auth"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs"
+)
+
+func NewCustomCommandRunner(
+	vcsClient vcs.Client,
+	globalCfg valid.GlobalCfg,
+	authorizer *auth.Authorizer,
+	commitStatusUpdater CommitStatusUpdater,
+	pullUpdater *PullUpdater,
+	pullReqStatusFetcher vcs.PullReqStatusFetcher,
+	prjCmdBuilder ProjectCustomCommandBuilder,
+	prjCmdRunner ProjectCustomCommandRunner,
+	SilenceNoProjects bool,
+) *CustomCommandRunner {
+	return &CustomCommandRunner{
+		vcsClient:            vcsClient,
+		globalCfg:            globalCfg,
+		authorizer:           authorizer,
+		commitStatusUpdater:  commitStatusUpdater,
+		pullUpdater:          pullUpdater,
+		pullReqStatusFetcher: pullReqStatusFetcher,
+		prjCmdBuilder:        prjCmdBuilder,
+		prjCmdRunner:         prjCmdRunner,
+		SilenceNoProjects:    SilenceNoProjects,
+	}
+}
+
+// CustomCommandRunner runs the custom commands defined in the server-side
+// repo config. Each custom command runs a stage of the workflow of every
+// project it applies to.
+type CustomCommandRunner struct {
+	vcsClient            vcs.Client
+	globalCfg            valid.GlobalCfg
+	authorizer           *auth.Authorizer
+	commitStatusUpdater  CommitStatusUpdater
+	pullUpdater          *PullUpdater
+	pullReqStatusFetcher vcs.PullReqStatusFetcher
+	prjCmdBuilder        ProjectCustomCommandBuilder
+	prjCmdRunner         ProjectCustomCommandRunner
+	// SilenceNoProjects is whether Atlantis should respond to PRs if no projects
+	// are found
+	SilenceNoProjects bool
+}
+
+func (c *CustomCommandRunner) Run(ctx *command.Context, cmd *CommentCommand) {
+	customCmd, ok := c.globalCfg.CustomCommand(cmd.Name.String())
+	if !ok {
+		c.pullUpdater.updatePull(ctx, cmd, command.Result{Error: fmt.Errorf("custom command %q is not defined", cmd.Name)})
+		return
+	}
+	ctx.CustomCommand = &customCmd
+
+	if allowed, err := c.isAllowed(ctx, customCmd); err != nil {
+		c.pullUpdater.updatePull(ctx, cmd, command.Result{Error: fmt.Errorf("checking roles of user: %w", err)})
+		return
+	} else if !allowed {
+		c.pullUpdater.updatePull(ctx, cmd, command.Result{
+			Failure: fmt.Sprintf("User @%s must have one of the roles %s to run %s.", ctx.User.Username, strings.Join(customCmd.Roles, ", "), customCmd.Name),
+		})
+		return
+	}
+
+	// Get the mergeable status before we set any build statuses of our own.
+	// We do this here because when we set a "Pending" status, if users have
+	// required the Atlantis status checks to pass, then we've now changed
+	// the mergeability status of the pull request.
+	// This sets the approved, mergeable, and sqlocked status in the context.
+	var err error
+	ctx.PullRequestStatus, err = c.pullReqStatusFetcher.FetchPullStatus(ctx.Pull)
+	if err != nil {
+		// On error we continue the request with mergeable assumed false.
+		// We want to continue because not all custom commands will need this
+		// status, only if they rely on the mergeability requirement.
+		ctx.Log.Warn("unable to get pull request status: %s. Continuing with mergeable and approved assumed false", err)
+	}
+
+	if err = c.commitStatusUpdater.UpdateCombined(ctx.Pull.BaseRepo, ctx.Pull, models.PendingCommitStatus, cmd.Name); err != nil {
+		ctx.Log.Warn("unable to update commit status: %s", err)
+	}
+
+	projectCmds, err := c.prjCmdBuilder.BuildCustomCommands(ctx, cmd)
+	if err != nil {
+		if statusErr := c.commitStatusUpdater.UpdateCombined(ctx.Pull.BaseRepo, ctx.Pull, models.FailedCommitStatus, cmd.Name); statusErr != nil {
+			ctx.Log.Warn("unable to update commit status: %s", statusErr)
+		}
+		c.pullUpdater.updatePull(ctx, cmd, command.Result{Error: err})
+		return
+	}
+
+	if len(projectCmds) == 0 && c.SilenceNoProjects {
+		ctx.Log.Info("determined there was no project to run %s in", cmd.Name)
+		if err := c.commitStatusUpdater.UpdateCombinedCount(ctx.Pull.BaseRepo, ctx.Pull, models.SuccessCommitStatus, cmd.Name, 0, 0); err != nil {
+			ctx.Log.Warn("unable to update commit status: %s", err)
+		}
+		return
+	}
+
+	result := runProjectCmds(projectCmds, c.prjCmdRunner.Custom)
+	c.pullUpdater.updatePull(ctx, cmd, result)
+
+	var numSuccess int
+	for _, r := range result.ProjectResults {
+		if r.Error == nil && r.Failure == "" {
+			numSuccess++
+		}
+	}
+	status := models.SuccessCommitStatus
+	if numSuccess < len(result.ProjectResults) {
+		status = models.FailedCommitStatus
+	}
+	if err := c.commitStatusUpdater.UpdateCombinedCount(ctx.Pull.BaseRepo, ctx.Pull, status, cmd.Name, numSuccess, len(result.ProjectResults)); err != nil {
+		ctx.Log.Warn("unable to update commit status: %s", err)
+	}
+}
+
+// isAllowed returns true if the user who commented has one of the roles the
+// custom command is restricted to, or if it isn't restricted to any role.
+func (c *CustomCommandRunner) isAllowed(ctx *command.Context, customCmd valid.CustomCommand) (bool, error) {
+	if len(customCmd.Roles) == 0 {
+		return true, nil
+	}
+	teams, err := c.vcsClient.GetTeamNamesForUser(ctx.Pull.BaseRepo, ctx.User)
+	if err != nil {
+		return false, err
+	}
+	id := auth.Identity{Username: ctx.User.Username, Groups: teams}
+	for _, r := range customCmd.Roles {
+		if c.authorizer.HasRepoRole(id, ctx.Pull.BaseRepo.ID(), auth.Role(r)) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
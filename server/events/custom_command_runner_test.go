@@ -0,0 +1,129 @@
+package events_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/petergtz/pegomock/v4"
+	"github.com/runatlantis/atlantis/server/auth"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/models/testdata"
+	"github.com/runatlantis/atlantis/server/logging"
+	"github.com/runatlantis/atlantis/server/metrics"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestCustomCommandRunner_Run(t *testing.T) {
+	logger := logging.NewNoopLogger(t)
+	validate := command.RegisterCustom("validate")
+
+	tests := []struct {
+		name           string
+		roles          []string
+		projectResults []command.ProjectResult
+		expComment     string
+		expStatus      models.CommitStatus
+		expNumSuccess  int
+		expBuilt       bool
+	}{
+		{
+			name: "success",
+			projectResults: []command.ProjectResult{{
+				Command:              validate,
+				RepoRelDir:           ".",
+				Workspace:            "default",
+				CustomCommandSuccess: &models.CustomCommandSuccess{Output: "Success! The configuration is valid."},
+			}},
+			expComment:    "Ran Validate for dir: `.` workspace: `default`",
+			expStatus:     models.SuccessCommitStatus,
+			expNumSuccess: 1,
+			expBuilt:      true,
+		},
+		{
+			name: "failure",
+			projectResults: []command.ProjectResult{{
+				Command:    validate,
+				RepoRelDir: ".",
+				Workspace:  "default",
+				Failure:    "locked",
+			}},
+			expComment: "**Validate Failed**: locked",
+			expStatus:  models.FailedCommitStatus,
+			expBuilt:   true,
+		},
+		{
+			name:       "user has a role",
+			roles:      []string{"operator"},
+			expComment: "Ran Validate for 0 projects:",
+			expStatus:  models.SuccessCommitStatus,
+			expBuilt:   true,
+		},
+		{
+			name:       "user doesn't have a role",
+			roles:      []string{"admin"},
+			expComment: "User @lkysow must have one of the roles admin to run validate.",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vcsClient := setup(t)
+			globalCfg := valid.NewGlobalCfgFromArgs(valid.GlobalCfgArgs{})
+			globalCfg.CustomCommands = []valid.CustomCommand{{Name: "validate", Stage: "validate", Roles: tt.roles}}
+			authorizer := auth.NewAuthorizer([]valid.RoleBinding{{Role: "operator", Users: []string{testdata.User.Username}}}, false)
+			runner := events.NewCustomCommandRunner(
+				vcsClient,
+				globalCfg,
+				authorizer,
+				commitUpdater,
+				pullUpdater,
+				pullReqStatusFetcher,
+				projectCommandBuilder,
+				projectCommandRunner,
+				false,
+			)
+
+			scopeNull, _, _ := metrics.NewLoggingScope(logger, "atlantis")
+			modelPull := models.PullRequest{BaseRepo: testdata.GithubRepo, State: models.OpenPullState, Num: testdata.Pull.Num}
+			ctx := &command.Context{
+				User:     testdata.User,
+				Log:      logging.NewNoopLogger(t),
+				Scope:    scopeNull,
+				Pull:     modelPull,
+				HeadRepo: testdata.GithubRepo,
+				Trigger:  command.CommentTrigger,
+			}
+			cmd := &events.CommentCommand{Name: validate}
+
+			var projectCmds []command.ProjectContext
+			for range tt.projectResults {
+				projectCmds = append(projectCmds, command.ProjectContext{CommandName: validate})
+			}
+			When(pullReqStatusFetcher.FetchPullStatus(modelPull)).ThenReturn(models.PullReqStatus{}, nil)
+			When(projectCommandBuilder.BuildCustomCommands(ctx, cmd)).ThenReturn(projectCmds, nil)
+			for _, r := range tt.projectResults {
+				When(projectCommandRunner.Custom(Any[command.ProjectContext]())).ThenReturn(r)
+			}
+
+			runner.Run(ctx, cmd)
+
+			_, _, comment, _ := vcsClient.VerifyWasCalledOnce().CreateComment(Any[models.Repo](), Any[int](), Any[string](), Any[string]()).GetCapturedArguments()
+			Assert(t, strings.Contains(comment, tt.expComment), "exp %q to contain %q", comment, tt.expComment)
+			if !tt.expBuilt {
+				projectCommandBuilder.VerifyWasCalled(Never()).BuildCustomCommands(Any[*command.Context](), Any[*events.CommentCommand]())
+				return
+			}
+			Equals(t, "validate", ctx.CustomCommand.Name)
+			commitUpdater.VerifyWasCalledOnce().UpdateCombinedCount(
+				Any[models.Repo](),
+				Any[models.PullRequest](),
+				Eq(tt.expStatus),
+				Eq(validate),
+				Eq(tt.expNumSuccess),
+				Eq(len(tt.projectResults)),
+			)
+		})
+	}
+}
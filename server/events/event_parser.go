@@ -18,6 +18,8 @@ import (
 	"fmt"
 	"net/url"
 	"path"
+	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/go-playground/validator/v10"
@@ -213,6 +215,22 @@ type EventParsing interface {
 	ParseGithubIssueCommentEvent(comment *github.IssueCommentEvent) (
 		baseRepo models.Repo, user models.User, pullNum int, err error)
 
+	// ParseGithubPullRequestReviewEvent parses GitHub pull request review
+	// events. The review's body is parsed like a comment.
+	// baseRepo is the repo that the pull request will be merged into.
+	// user is the review author.
+	// pullNum is the number of the pull request that was reviewed.
+	ParseGithubPullRequestReviewEvent(event *github.PullRequestReviewEvent) (
+		baseRepo models.Repo, user models.User, pullNum int, err error)
+
+	// ParseGithubPullRequestReviewCommentEvent parses GitHub pull request
+	// review comment events, i.e. comments on the pull request's diff.
+	// baseRepo is the repo that the pull request will be merged into.
+	// user is the comment author.
+	// pullNum is the number of the pull request that was commented on.
+	ParseGithubPullRequestReviewCommentEvent(event *github.PullRequestReviewCommentEvent) (
+		baseRepo models.Repo, user models.User, pullNum int, err error)
+
 	// ParseGithubPull parses the response from the GitHub API endpoint (not
 	// from a webhook) that returns a pull request.
 	// pull is the parsed pull request.
@@ -235,6 +253,14 @@ type EventParsing interface {
 	// returns a repo into the Atlantis model.
 	ParseGithubRepo(ghRepo *github.Repository) (models.Repo, error)
 
+	// ParseGithubMergeGroupEvent parses GitHub merge queue events.
+	// baseRepo is the repo the merge group will be merged into.
+	// pullNum is the number of the pull request the merge group was created for.
+	// baseSHA is the merge group's parent commit and headSHA is the merge
+	// group's commit that required statuses are reported on.
+	ParseGithubMergeGroupEvent(event *github.MergeGroupEvent) (
+		baseRepo models.Repo, pullNum int, baseSHA string, headSHA string, err error)
+
 	// ParseGitlabMergeRequestEvent parses GitLab merge request events.
 	// pull is the parsed merge request.
 	// pullEventType is the type of event, for example opened/closed.
@@ -377,7 +403,9 @@ func (e *EventParser) GetBitbucketCloudPullEventType(eventTypeHeader string, sha
 		}
 		lastBitbucketSha.Add(pr, sha)
 		return models.UpdatedPullEvent
-	case bitbucketcloud.PullFulfilledHeader, bitbucketcloud.PullRejectedHeader:
+	case bitbucketcloud.PullFulfilledHeader:
+		return models.MergedPullEvent
+	case bitbucketcloud.PullRejectedHeader:
 		return models.ClosedPullEvent
 	}
 	return models.OtherPullEvent
@@ -492,6 +520,51 @@ func (e *EventParser) ParseGithubIssueCommentEvent(comment *github.IssueCommentE
 	return
 }
 
+// ParseGithubPullRequestReviewEvent parses GitHub pull request review events.
+// See EventParsing for return value docs.
+func (e *EventParser) ParseGithubPullRequestReviewEvent(event *github.PullRequestReviewEvent) (baseRepo models.Repo, user models.User, pullNum int, err error) {
+	baseRepo, err = e.ParseGithubRepo(event.Repo)
+	if err != nil {
+		return
+	}
+	if event.Review == nil || event.Review.User.GetLogin() == "" {
+		err = errors.New("review.user.login is null")
+		return
+	}
+	user = models.User{
+		Username: event.Review.User.GetLogin(),
+	}
+	pullNum = event.GetPullRequest().GetNumber()
+	if pullNum == 0 {
+		err = errors.New("pull_request.number is null")
+		return
+	}
+	return
+}
+
+// ParseGithubPullRequestReviewCommentEvent parses GitHub pull request review
+// comment events.
+// See EventParsing for return value docs.
+func (e *EventParser) ParseGithubPullRequestReviewCommentEvent(event *github.PullRequestReviewCommentEvent) (baseRepo models.Repo, user models.User, pullNum int, err error) {
+	baseRepo, err = e.ParseGithubRepo(event.Repo)
+	if err != nil {
+		return
+	}
+	if event.Comment == nil || event.Comment.User.GetLogin() == "" {
+		err = errors.New("comment.user.login is null")
+		return
+	}
+	user = models.User{
+		Username: event.Comment.User.GetLogin(),
+	}
+	pullNum = event.GetPullRequest().GetNumber()
+	if pullNum == 0 {
+		err = errors.New("pull_request.number is null")
+		return
+	}
+	return
+}
+
 // ParseGithubPullEvent parses GitHub pull request events.
 // See EventParsing for return value docs.
 func (e *EventParser) ParseGithubPullEvent(pullEvent *github.PullRequestEvent) (pull models.PullRequest, pullEventType models.PullRequestEventType, baseRepo models.Repo, headRepo models.Repo, user models.User, err error) {
@@ -533,6 +606,9 @@ func (e *EventParser) ParseGithubPullEvent(pullEvent *github.PullRequestEvent) (
 		pullEventType = models.UpdatedPullEvent
 	case "closed":
 		pullEventType = models.ClosedPullEvent
+		if pullEvent.GetPullRequest().GetMerged() {
+			pullEventType = models.MergedPullEvent
+		}
 	default:
 		pullEventType = models.OtherPullEvent
 	}
@@ -540,6 +616,46 @@ func (e *EventParser) ParseGithubPullEvent(pullEvent *github.PullRequestEvent) (
 	return
 }
 
+// mergeGroupRefRegex matches the ref GitHub creates for a merge group, for
+// example refs/heads/gh-readonly-queue/main/pr-123-<sha>, and captures the
+// pull request number.
+var mergeGroupRefRegex = regexp.MustCompile(`/gh-readonly-queue/.+/pr-(\d+)-[0-9a-f]+$`)
+
+// ParseGithubMergeGroupEvent parses GitHub merge queue events.
+// See EventParsing for return value docs.
+func (e *EventParser) ParseGithubMergeGroupEvent(event *github.MergeGroupEvent) (baseRepo models.Repo, pullNum int, baseSHA string, headSHA string, err error) {
+	if event.MergeGroup == nil {
+		err = errors.New("merge_group is null")
+		return
+	}
+	if event.Repo == nil {
+		err = errors.New("repository is null")
+		return
+	}
+	baseRepo, err = e.ParseGithubRepo(event.Repo)
+	if err != nil {
+		return
+	}
+	headSHA = event.MergeGroup.GetHeadSHA()
+	if headSHA == "" {
+		err = errors.New("merge_group.head_sha is null")
+		return
+	}
+	baseSHA = event.MergeGroup.GetBaseSHA()
+	if baseSHA == "" {
+		err = errors.New("merge_group.base_sha is null")
+		return
+	}
+	headRef := event.MergeGroup.GetHeadRef()
+	matches := mergeGroupRefRegex.FindStringSubmatch(headRef)
+	if matches == nil {
+		err = fmt.Errorf("unable to determine pull request number from merge_group.head_ref %q", headRef)
+		return
+	}
+	pullNum, err = strconv.Atoi(matches[1])
+	return
+}
+
 // ParseGithubPull parses the response from the GitHub API endpoint (not
 // from a webhook) that returns a pull request.
 // See EventParsing for return value docs.
@@ -599,6 +715,7 @@ func (e *EventParser) ParseGithubPull(pull *github.PullRequest) (pullModel model
 		State:      pullState,
 		BaseRepo:   baseRepo,
 		BaseBranch: baseBranch,
+		Draft:      pull.GetDraft(),
 	}
 	return
 }
@@ -615,7 +732,8 @@ func (e *EventParser) ParseGitlabMergeRequestUpdateEvent(event gitlab.MergeEvent
 	// New commit to opened MR
 	if len(event.ObjectAttributes.OldRev) > 0 ||
 		// Check for MR that has been marked as ready
-		(strings.HasPrefix(event.Changes.Title.Previous, "Draft:") && !strings.HasPrefix(event.Changes.Title.Current, "Draft:")) {
+		(strings.HasPrefix(event.Changes.Title.Previous, "Draft:") && !strings.HasPrefix(event.Changes.Title.Current, "Draft:")) ||
+		(event.Changes.Draft.Previous && !event.Changes.Draft.Current) {
 		return models.UpdatedPullEvent
 	}
 	return models.OtherPullEvent
@@ -650,13 +768,14 @@ func (e *EventParser) ParseGitlabMergeRequestEvent(event gitlab.MergeEvent) (pul
 		BaseBranch: event.ObjectAttributes.TargetBranch,
 		State:      modelState,
 		BaseRepo:   baseRepo,
+		Draft:      event.ObjectAttributes.Draft || event.ObjectAttributes.WorkInProgress,
 	}
 
 	// If it's a draft PR we ignore it for auto-planning if configured to do so
 	// however it's still possible for users to run plan on it manually via a
 	// comment so if any draft PR is closed we still need to check if we need
 	// to delete its locks.
-	if event.ObjectAttributes.WorkInProgress && event.ObjectAttributes.Action != "close" && !e.AllowDraftPRs {
+	if pull.Draft && event.ObjectAttributes.Action != "close" && !e.AllowDraftPRs {
 		eventType = models.OtherPullEvent
 	} else {
 		switch event.ObjectAttributes.Action {
@@ -664,7 +783,9 @@ func (e *EventParser) ParseGitlabMergeRequestEvent(event gitlab.MergeEvent) (pul
 			eventType = models.OpenedPullEvent
 		case "update":
 			eventType = e.ParseGitlabMergeRequestUpdateEvent(event)
-		case "merge", "close":
+		case "merge":
+			eventType = models.MergedPullEvent
+		case "close":
 			eventType = models.ClosedPullEvent
 		default:
 			eventType = models.OtherPullEvent
@@ -723,6 +844,7 @@ func (e *EventParser) ParseGitlabMergeRequest(mr *gitlab.MergeRequest, baseRepo
 		BaseBranch: mr.TargetBranch,
 		State:      pullState,
 		BaseRepo:   baseRepo,
+		Draft:      mr.Draft || mr.WorkInProgress,
 	}
 }
 
@@ -734,7 +856,9 @@ func (e *EventParser) GetBitbucketServerPullEventType(eventTypeHeader string) mo
 	// so no additional checks are needed.
 	case bitbucketserver.PullCreatedHeader, bitbucketserver.PullFromRefUpdatedHeader:
 		return models.OpenedPullEvent
-	case bitbucketserver.PullMergedHeader, bitbucketserver.PullDeclinedHeader, bitbucketserver.PullDeletedHeader:
+	case bitbucketserver.PullMergedHeader:
+		return models.MergedPullEvent
+	case bitbucketserver.PullDeclinedHeader, bitbucketserver.PullDeletedHeader:
 		return models.ClosedPullEvent
 	}
 	return models.OtherPullEvent
@@ -853,13 +977,24 @@ func (e *EventParser) ParseAzureDevopsPullEvent(event azuredevops.Event) (pull m
 		err = errors.New("CreatedBy.UniqueName is null")
 		return
 	}
-	switch event.EventType {
+	eventType := event.EventType
+	// If it's a draft PR we ignore it for auto-planning if configured to do so
+	// however it's still possible for users to run plan on it manually via a
+	// comment. Publishing a draft PR triggers an update event so it will be
+	// auto-planned then. Closed draft PRs still need their locks deleted.
+	if pull.Draft && pull.State == models.OpenPullState && !e.AllowDraftPRs {
+		eventType = "other"
+	}
+	switch eventType {
 	case "git.pullrequest.created":
 		pullEventType = models.OpenedPullEvent
 	case "git.pullrequest.updated":
 		pullEventType = models.UpdatedPullEvent
 		if pull.State == models.ClosedPullState {
 			pullEventType = models.ClosedPullEvent
+			if pullResource.GetStatus() == azuredevops.PullCompleted.String() {
+				pullEventType = models.MergedPullEvent
+			}
 		}
 	default:
 		pullEventType = models.OtherPullEvent
@@ -931,6 +1066,7 @@ func (e *EventParser) ParseAzureDevopsPull(pull *azuredevops.GitPullRequest) (pu
 		State:      pullState,
 		BaseRepo:   baseRepo,
 		BaseBranch: strings.Replace(baseBranch, "refs/heads/", "", 1),
+		Draft:      pull.GetIsDraft(),
 	}
 	return
 }
@@ -61,6 +61,41 @@ func TestParseGithubRepo(t *testing.T) {
 	}, r)
 }
 
+func TestParseGithubMergeGroupEvent(t *testing.T) {
+	event := github.MergeGroupEvent{
+		Action: github.String("checks_requested"),
+		MergeGroup: &github.MergeGroup{
+			HeadSHA: github.String("headsha"),
+			HeadRef: github.String("refs/heads/gh-readonly-queue/main/pr-12-a1b2c3d4e5f6"),
+			BaseSHA: github.String("basesha"),
+			BaseRef: github.String("refs/heads/main"),
+		},
+		Repo: &Repo,
+	}
+
+	repo, pullNum, baseSHA, headSHA, err := parser.ParseGithubMergeGroupEvent(&event)
+	Ok(t, err)
+	Equals(t, "owner/repo", repo.FullName)
+	Equals(t, 12, pullNum)
+	Equals(t, "basesha", baseSHA)
+	Equals(t, "headsha", headSHA)
+
+	testEvent := deepcopy.Copy(event).(github.MergeGroupEvent)
+	testEvent.MergeGroup = nil
+	_, _, _, _, err = parser.ParseGithubMergeGroupEvent(&testEvent)
+	ErrEquals(t, "merge_group is null", err)
+
+	testEvent = deepcopy.Copy(event).(github.MergeGroupEvent)
+	testEvent.MergeGroup.HeadSHA = nil
+	_, _, _, _, err = parser.ParseGithubMergeGroupEvent(&testEvent)
+	ErrEquals(t, "merge_group.head_sha is null", err)
+
+	testEvent = deepcopy.Copy(event).(github.MergeGroupEvent)
+	testEvent.MergeGroup.HeadRef = github.String("refs/heads/main")
+	_, _, _, _, err = parser.ParseGithubMergeGroupEvent(&testEvent)
+	ErrEquals(t, "unable to determine pull request number from merge_group.head_ref \"refs/heads/main\"", err)
+}
+
 func TestParseGithubIssueCommentEvent(t *testing.T) {
 	comment := github.IssueCommentEvent{
 		Repo: &Repo,
@@ -162,6 +197,70 @@ func TestParseGithubPullEvent(t *testing.T) {
 	Equals(t, models.User{Username: "user"}, actUser)
 }
 
+func TestParseGithubPullRequestReviewEvent(t *testing.T) {
+	event := github.PullRequestReviewEvent{
+		Repo:        &Repo,
+		PullRequest: &github.PullRequest{Number: github.Int(1)},
+		Review: &github.PullRequestReview{
+			User: &github.User{Login: github.String("reviewer")},
+			Body: github.String("atlantis apply"),
+		},
+	}
+
+	testEvent := deepcopy.Copy(event).(github.PullRequestReviewEvent)
+	testEvent.Review = nil
+	_, _, _, err := parser.ParseGithubPullRequestReviewEvent(&testEvent)
+	ErrEquals(t, "review.user.login is null", err)
+
+	testEvent = deepcopy.Copy(event).(github.PullRequestReviewEvent)
+	testEvent.Review.User = nil
+	_, _, _, err = parser.ParseGithubPullRequestReviewEvent(&testEvent)
+	ErrEquals(t, "review.user.login is null", err)
+
+	testEvent = deepcopy.Copy(event).(github.PullRequestReviewEvent)
+	testEvent.PullRequest = nil
+	_, _, _, err = parser.ParseGithubPullRequestReviewEvent(&testEvent)
+	ErrEquals(t, "pull_request.number is null", err)
+
+	repo, user, pullNum, err := parser.ParseGithubPullRequestReviewEvent(&event)
+	Ok(t, err)
+	Equals(t, "owner/repo", repo.FullName)
+	Equals(t, models.User{Username: "reviewer"}, user)
+	Equals(t, 1, pullNum)
+}
+
+func TestParseGithubPullRequestReviewCommentEvent(t *testing.T) {
+	event := github.PullRequestReviewCommentEvent{
+		Repo:        &Repo,
+		PullRequest: &github.PullRequest{Number: github.Int(1)},
+		Comment: &github.PullRequestComment{
+			User: &github.User{Login: github.String("comment_user")},
+			Body: github.String("atlantis plan"),
+		},
+	}
+
+	testEvent := deepcopy.Copy(event).(github.PullRequestReviewCommentEvent)
+	testEvent.Comment = nil
+	_, _, _, err := parser.ParseGithubPullRequestReviewCommentEvent(&testEvent)
+	ErrEquals(t, "comment.user.login is null", err)
+
+	testEvent = deepcopy.Copy(event).(github.PullRequestReviewCommentEvent)
+	testEvent.Comment.User.Login = nil
+	_, _, _, err = parser.ParseGithubPullRequestReviewCommentEvent(&testEvent)
+	ErrEquals(t, "comment.user.login is null", err)
+
+	testEvent = deepcopy.Copy(event).(github.PullRequestReviewCommentEvent)
+	testEvent.PullRequest = nil
+	_, _, _, err = parser.ParseGithubPullRequestReviewCommentEvent(&testEvent)
+	ErrEquals(t, "pull_request.number is null", err)
+
+	repo, user, pullNum, err := parser.ParseGithubPullRequestReviewCommentEvent(&event)
+	Ok(t, err)
+	Equals(t, "owner/repo", repo.FullName)
+	Equals(t, models.User{Username: "comment_user"}, user)
+	Equals(t, 1, pullNum)
+}
+
 func TestParseGithubPullEventFromDraft(t *testing.T) {
 	// verify that close event treated as 'close' events by default
 	closeEvent := deepcopy.Copy(PullEvent).(github.PullRequestEvent)
@@ -172,12 +271,20 @@ func TestParseGithubPullEventFromDraft(t *testing.T) {
 	Ok(t, err)
 	Equals(t, models.ClosedPullEvent, evType)
 
+	// verify that a merged PR is treated as a 'merged' event
+	mergeEvent := deepcopy.Copy(closeEvent).(github.PullRequestEvent)
+	mergeEvent.PullRequest.Merged = github.Bool(true)
+	_, evType, _, _, _, err = parser.ParseGithubPullEvent(&mergeEvent)
+	Ok(t, err)
+	Equals(t, models.MergedPullEvent, evType)
+
 	// verify that draft PRs are treated as 'other' events by default
 	testEvent := deepcopy.Copy(PullEvent).(github.PullRequestEvent)
 	testEvent.PullRequest.Draft = github.Bool(true)
-	_, evType, _, _, _, err = parser.ParseGithubPullEvent(&testEvent)
+	pull, evType, _, _, _, err := parser.ParseGithubPullEvent(&testEvent)
 	Ok(t, err)
 	Equals(t, models.OtherPullEvent, evType)
+	Equals(t, true, pull.Draft)
 	// verify that drafts are planned if requested
 	parser.AllowDraftPRs = true
 	defer func() { parser.AllowDraftPRs = false }()
@@ -400,10 +507,28 @@ func TestParseGitlabMergeEventFromDraft(t *testing.T) {
 	testEvent := deepcopy.Copy(event).(gitlab.MergeEvent)
 	testEvent.ObjectAttributes.WorkInProgress = true
 
-	_, evType, _, _, _, err := parser.ParseGitlabMergeRequestEvent(testEvent)
+	pull, evType, _, _, _, err := parser.ParseGitlabMergeRequestEvent(testEvent)
+	Ok(t, err)
+	Equals(t, models.OtherPullEvent, evType)
+	Equals(t, true, pull.Draft)
+
+	draftEvent := deepcopy.Copy(event).(gitlab.MergeEvent)
+	draftEvent.ObjectAttributes.Draft = true
+	_, evType, _, _, _, err = parser.ParseGitlabMergeRequestEvent(draftEvent)
 	Ok(t, err)
 	Equals(t, models.OtherPullEvent, evType)
 
+	// Marking the MR as ready should trigger a plan.
+	readyEvent := deepcopy.Copy(event).(gitlab.MergeEvent)
+	readyEvent.ObjectAttributes.Action = "update"
+	readyEvent.ObjectAttributes.OldRev = ""
+	readyEvent.Changes.Draft.Previous = true
+	readyEvent.Changes.Draft.Current = false
+	pull, evType, _, _, _, err = parser.ParseGitlabMergeRequestEvent(readyEvent)
+	Ok(t, err)
+	Equals(t, models.UpdatedPullEvent, evType)
+	Equals(t, false, pull.Draft)
+
 	parser.AllowDraftPRs = true
 	defer func() { parser.AllowDraftPRs = false }()
 	_, evType, _, _, _, err = parser.ParseGitlabMergeRequestEvent(testEvent)
@@ -536,7 +661,7 @@ func TestParseGitlabMergeEvent_ActionType(t *testing.T) {
 		},
 		{
 			action: "merge",
-			exp:    models.ClosedPullEvent,
+			exp:    models.MergedPullEvent,
 		},
 		{
 			action: "close",
@@ -1050,7 +1175,7 @@ func TestGetBitbucketCloudEventType(t *testing.T) {
 		},
 		{
 			header: "pullrequest:fulfilled",
-			exp:    models.ClosedPullEvent,
+			exp:    models.MergedPullEvent,
 		},
 		{
 			header: "pullrequest:rejected",
@@ -1231,7 +1356,7 @@ func TestGetBitbucketServerEventType(t *testing.T) {
 		},
 		{
 			header: "pr:merged",
-			exp:    models.ClosedPullEvent,
+			exp:    models.MergedPullEvent,
 		},
 		{
 			header: "pr:declined",
@@ -1395,7 +1520,7 @@ func TestParseAzureDevopsPullEvent_EventType(t *testing.T) {
 		},
 		{
 			action: "git.pullrequest.updated",
-			exp:    models.ClosedPullEvent,
+			exp:    models.MergedPullEvent,
 		},
 		{
 			action: "anything_else",
@@ -1406,7 +1531,7 @@ func TestParseAzureDevopsPullEvent_EventType(t *testing.T) {
 	for _, c := range cases {
 		t.Run(c.action, func(t *testing.T) {
 			event := deepcopy.Copy(ADPullEvent).(azuredevops.Event)
-			if c.exp == models.ClosedPullEvent {
+			if c.exp == models.MergedPullEvent {
 				event = deepcopy.Copy(ADPullClosedEvent).(azuredevops.Event)
 			}
 			event.EventType = c.action
@@ -1417,6 +1542,30 @@ func TestParseAzureDevopsPullEvent_EventType(t *testing.T) {
 	}
 }
 
+func TestParseAzureDevopsPullEventFromDraft(t *testing.T) {
+	testEvent := deepcopy.Copy(ADPullEvent).(azuredevops.Event)
+	testEvent.Resource.(*azuredevops.GitPullRequest).IsDraft = azuredevops.Bool(true)
+
+	pull, evType, _, _, _, err := parser.ParseAzureDevopsPullEvent(testEvent)
+	Ok(t, err)
+	Equals(t, models.OtherPullEvent, evType)
+	Equals(t, true, pull.Draft)
+
+	// Closed drafts still need their locks deleted.
+	closeEvent := deepcopy.Copy(ADPullClosedEvent).(azuredevops.Event)
+	closeEvent.EventType = "git.pullrequest.updated"
+	closeEvent.Resource.(*azuredevops.GitPullRequest).IsDraft = azuredevops.Bool(true)
+	_, evType, _, _, _, err = parser.ParseAzureDevopsPullEvent(closeEvent)
+	Ok(t, err)
+	Equals(t, models.MergedPullEvent, evType)
+
+	parser.AllowDraftPRs = true
+	defer func() { parser.AllowDraftPRs = false }()
+	_, evType, _, _, _, err = parser.ParseAzureDevopsPullEvent(testEvent)
+	Ok(t, err)
+	Equals(t, models.OpenedPullEvent, evType)
+}
+
 func TestParseAzureDevopsPull(t *testing.T) {
 	testPull := deepcopy.Copy(ADPull).(azuredevops.GitPullRequest)
 	testPull.LastMergeSourceCommit.CommitID = nil
@@ -1571,7 +1720,7 @@ func TestParseAzureDevopsSelfHostedPullEvent_EventType(t *testing.T) {
 		},
 		{
 			action: "git.pullrequest.updated",
-			exp:    models.ClosedPullEvent,
+			exp:    models.MergedPullEvent,
 		},
 		{
 			action: "anything_else",
@@ -1582,7 +1731,7 @@ func TestParseAzureDevopsSelfHostedPullEvent_EventType(t *testing.T) {
 	for _, c := range cases {
 		t.Run(c.action, func(t *testing.T) {
 			event := deepcopy.Copy(ADSelfPullEvent).(azuredevops.Event)
-			if c.exp == models.ClosedPullEvent {
+			if c.exp == models.MergedPullEvent {
 				event = deepcopy.Copy(ADSelfPullClosedEvent).(azuredevops.Event)
 			}
 			event.EventType = c.action
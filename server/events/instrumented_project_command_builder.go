@@ -58,6 +58,15 @@ func (b *InstrumentedProjectCommandBuilder) BuildStateRmCommands(ctx *command.Co
 	)
 }
 
+func (b *InstrumentedProjectCommandBuilder) BuildCustomCommands(ctx *command.Context, comment *CommentCommand) ([]command.ProjectContext, error) {
+	return b.buildAndEmitStats(
+		comment.Name.String(),
+		func() ([]command.ProjectContext, error) {
+			return b.ProjectCommandBuilder.BuildCustomCommands(ctx, comment)
+		},
+	)
+}
+
 func (b *InstrumentedProjectCommandBuilder) buildAndEmitStats(
 	command string,
 	execute func() ([]command.ProjectContext, error),
@@ -0,0 +1,174 @@
+package events
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/audit"
+	"github.com/runatlantis/atlantis/server/core/locking"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs"
+	"github.com/runatlantis/atlantis/server/logging"
+)
+
+// reaperActor is the actor of audit events for locks released by the reaper.
+const reaperActor = "atlantis"
+
+// LockReaper is a scheduled job that releases stale locks. It releases locks
+// that have expired (see models.ProjectLock.ExpiresAt) and locks on pull
+// requests that have been closed or merged, which can happen if Atlantis
+// never received the webhook for the pull request being closed.
+type LockReaper struct {
+	Locker            locking.Locker
+	DeleteLockCommand DeleteLockCommand
+	Backend           locking.Backend
+	VCSClient         vcs.Client
+	// PullCleaner cleans up after pull requests that are closed, just like
+	// when the webhook for the pull request being closed is received.
+	PullCleaner              PullCleaner
+	EventParser              EventParsing
+	GithubPullGetter         GithubPullGetter
+	GitlabMergeRequestGetter GitlabMergeRequestGetter
+	AzureDevopsPullGetter    AzureDevopsPullGetter
+	Auditor                  *audit.Auditor
+	Logger                   logging.SimpleLogging
+	// Now returns the current time. It's set in tests.
+	Now func() time.Time
+}
+
+// Run releases stale locks. Errors are logged so that one bad lock doesn't
+// stop the others from being released.
+func (r *LockReaper) Run() {
+	locks, err := r.Locker.List()
+	if err != nil {
+		r.Logger.Err("lock reaper failed listing locks: %s", err)
+		return
+	}
+	now := time.Now()
+	if r.Now != nil {
+		now = r.Now()
+	}
+
+	// Pulls are only checked once per run since they usually hold several
+	// locks.
+	checked := make(map[string]bool)
+	for key, lock := range locks {
+		if lock.Expired(now) {
+			if err := r.releaseExpired(key, lock); err != nil {
+				r.Logger.Err("lock reaper failed releasing expired lock %q: %s", key, err)
+			}
+			continue
+		}
+
+		// Locks created before BaseRepo was added to PullRequest can't be
+		// looked up.
+		if lock.Pull.BaseRepo == (models.Repo{}) {
+			continue
+		}
+		pullKey := fmt.Sprintf("%s#%d", lock.Pull.BaseRepo.ID(), lock.Pull.Num)
+		if checked[pullKey] {
+			continue
+		}
+		checked[pullKey] = true
+		state, err := r.pullState(lock.Pull)
+		if err != nil {
+			r.Logger.Warn("lock reaper failed getting the state of pull %s: %s", pullKey, err)
+			continue
+		}
+		if state == models.ClosedPullState {
+			if err := r.releaseClosed(lock.Pull); err != nil {
+				r.Logger.Err("lock reaper failed releasing locks on closed pull %s: %s", pullKey, err)
+			}
+		}
+	}
+}
+
+// releaseExpired deletes the expired lock at key, discards its plan and
+// comments on its pull request.
+func (r *LockReaper) releaseExpired(key string, lock models.ProjectLock) error {
+	deleted, err := r.DeleteLockCommand.DeleteLock(key)
+	if err != nil {
+		return err
+	}
+	// The lock may have been deleted since we listed it.
+	if deleted == nil {
+		return nil
+	}
+	r.Logger.Info("lock reaper released lock %q which expired at %s", key, lock.ExpiresAt.Format(time.RFC3339))
+	r.Auditor.Record(models.AuditEvent{
+		Type:      models.UnlockAuditEvent,
+		Actor:     reaperActor,
+		Repo:      lock.Project.RepoFullName,
+		Pull:      lock.Pull.Num,
+		Directory: lock.Project.Path,
+		Workspace: lock.Workspace,
+		Outcome:   models.AuditSuccess,
+		Detail:    "lock expired",
+	})
+
+	if lock.Pull.BaseRepo == (models.Repo{}) {
+		return nil
+	}
+	if err := r.Backend.UpdateProjectStatus(lock.Pull, lock.Workspace, lock.Project.Path, models.DiscardedPlanStatus); err != nil {
+		r.Logger.Err("unable to update project status: %s", err)
+	}
+	comment := fmt.Sprintf("**Warning**: The lock for dir: `%s` workspace: `%s` expired after %s so it was released and its plan was **discarded**.\n\n"+
+		"To `apply` this plan you must run `plan` again.", lock.Project.Path, lock.Workspace, lock.ExpiresAt.Sub(lock.Time).Round(time.Second))
+	return r.VCSClient.CreateComment(lock.Pull.BaseRepo, lock.Pull.Num, comment, "")
+}
+
+// releaseClosed cleans up after pull, which has been closed. Cleaning up
+// releases its locks and comments on it.
+func (r *LockReaper) releaseClosed(pull models.PullRequest) error {
+	r.Logger.Info("lock reaper releasing locks on pull %s#%d since it's closed", pull.BaseRepo.FullName, pull.Num)
+	err := r.PullCleaner.CleanUpPull(pull.BaseRepo, pull)
+	r.Auditor.Record(models.AuditEvent{
+		Type:    models.UnlockAuditEvent,
+		Actor:   reaperActor,
+		Repo:    pull.BaseRepo.FullName,
+		Pull:    pull.Num,
+		Outcome: audit.Outcome(err),
+		Detail:  "pull request is closed",
+	})
+	return err
+}
+
+// pullState fetches the current state of pull from its VCS host. Bitbucket
+// pull requests can't be fetched so they're assumed to be open.
+func (r *LockReaper) pullState(pull models.PullRequest) (models.PullRequestState, error) {
+	repo := pull.BaseRepo
+	switch repo.VCSHost.Type {
+	case models.Github:
+		if r.GithubPullGetter == nil {
+			return 0, errors.New("Atlantis not configured to support GitHub")
+		}
+		ghPull, err := r.GithubPullGetter.GetPullRequest(repo, pull.Num)
+		if err != nil {
+			return 0, errors.Wrap(err, "making pull request API call to GitHub")
+		}
+		current, _, _, err := r.EventParser.ParseGithubPull(ghPull)
+		return current.State, err
+	case models.Gitlab:
+		if r.GitlabMergeRequestGetter == nil {
+			return 0, errors.New("Atlantis not configured to support GitLab")
+		}
+		mr, err := r.GitlabMergeRequestGetter.GetMergeRequest(repo.FullName, pull.Num)
+		if err != nil {
+			return 0, errors.Wrap(err, "making merge request API call to GitLab")
+		}
+		return r.EventParser.ParseGitlabMergeRequest(mr, repo).State, nil
+	case models.AzureDevops:
+		if r.AzureDevopsPullGetter == nil {
+			return 0, errors.New("Atlantis not configured to support Azure DevOps")
+		}
+		adPull, err := r.AzureDevopsPullGetter.GetPullRequest(repo, pull.Num)
+		if err != nil {
+			return 0, errors.Wrap(err, "making pull request API call to Azure DevOps")
+		}
+		current, _, _, err := r.EventParser.ParseAzureDevopsPull(adPull)
+		return current.State, err
+	default:
+		return models.OpenPullState, nil
+	}
+}
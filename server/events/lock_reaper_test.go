@@ -0,0 +1,93 @@
+package events_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v57/github"
+	. "github.com/petergtz/pegomock/v4"
+	"github.com/runatlantis/atlantis/server/audit"
+	lockingmocks "github.com/runatlantis/atlantis/server/core/locking/mocks"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/mocks"
+	"github.com/runatlantis/atlantis/server/events/models"
+	vcsmocks "github.com/runatlantis/atlantis/server/events/vcs/mocks"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestLockReaper_Run(t *testing.T) {
+	RegisterMockTestingT(t)
+	locker := lockingmocks.NewMockLocker()
+	deleteLockCommand := mocks.NewMockDeleteLockCommand()
+	backend := lockingmocks.NewMockBackend()
+	vcsClient := vcsmocks.NewMockClient()
+	pullCleaner := mocks.NewMockPullCleaner()
+	eventParser := mocks.NewMockEventParsing()
+	pullGetter := mocks.NewMockGithubPullGetter()
+	sink := &recordingAuditSink{}
+	now := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+	reaper := &events.LockReaper{
+		Locker:            locker,
+		DeleteLockCommand: deleteLockCommand,
+		Backend:           backend,
+		VCSClient:         vcsClient,
+		PullCleaner:       pullCleaner,
+		EventParser:       eventParser,
+		GithubPullGetter:  pullGetter,
+		Auditor:           audit.NewAuditor(logging.NewNoopLogger(t), sink),
+		Logger:            logging.NewNoopLogger(t),
+		Now:               func() time.Time { return now },
+	}
+
+	repo := models.Repo{FullName: "owner/repo", VCSHost: models.VCSHost{Type: models.Github, Hostname: "github.com"}}
+	expiredPull := models.PullRequest{Num: 1, BaseRepo: repo}
+	closedPull := models.PullRequest{Num: 2, BaseRepo: repo}
+	openPull := models.PullRequest{Num: 3, BaseRepo: repo}
+	expired := models.ProjectLock{
+		Project:   models.NewProject("owner/repo", "expired"),
+		Pull:      expiredPull,
+		Workspace: "default",
+		Time:      now.Add(-2 * time.Hour),
+		ExpiresAt: now.Add(-time.Hour),
+	}
+	closed := models.ProjectLock{Project: models.NewProject("owner/repo", "closed"), Pull: closedPull, Workspace: "default", Time: now}
+	open := models.ProjectLock{Project: models.NewProject("owner/repo", "open"), Pull: openPull, Workspace: "default", Time: now, ExpiresAt: now.Add(time.Hour)}
+	When(locker.List()).ThenReturn(map[string]models.ProjectLock{
+		"owner/repo/expired/default": expired,
+		"owner/repo/closed/default":  closed,
+		"owner/repo/open/default":    open,
+	}, nil)
+	When(deleteLockCommand.DeleteLock("owner/repo/expired/default")).ThenReturn(&expired, nil)
+
+	closedGHPull := &github.PullRequest{Number: github.Int(2)}
+	openGHPull := &github.PullRequest{Number: github.Int(3)}
+	When(pullGetter.GetPullRequest(repo, 2)).ThenReturn(closedGHPull, nil)
+	When(pullGetter.GetPullRequest(repo, 3)).ThenReturn(openGHPull, nil)
+	When(eventParser.ParseGithubPull(closedGHPull)).ThenReturn(models.PullRequest{Num: 2, State: models.ClosedPullState}, repo, repo, nil)
+	When(eventParser.ParseGithubPull(openGHPull)).ThenReturn(models.PullRequest{Num: 3, State: models.OpenPullState}, repo, repo, nil)
+
+	reaper.Run()
+
+	t.Log("the expired lock is released and its plan discarded")
+	deleteLockCommand.VerifyWasCalledOnce().DeleteLock("owner/repo/expired/default")
+	backend.VerifyWasCalledOnce().UpdateProjectStatus(expiredPull, "default", "expired", models.DiscardedPlanStatus)
+	vcsClient.VerifyWasCalledOnce().CreateComment(
+		repo,
+		1,
+		"**Warning**: The lock for dir: `expired` workspace: `default` expired after 1h0m0s so it was released and its plan was **discarded**.\n\n"+
+			"To `apply` this plan you must run `plan` again.",
+		"",
+	)
+
+	t.Log("the closed pull is cleaned up and the open one is left alone")
+	pullCleaner.VerifyWasCalledOnce().CleanUpPull(repo, closedPull)
+	pullCleaner.VerifyWasCalled(Never()).CleanUpPull(repo, openPull)
+	deleteLockCommand.VerifyWasCalled(Never()).DeleteLock("owner/repo/open/default")
+
+	Equals(t, 2, len(sink.events))
+	for _, event := range sink.events {
+		Equals(t, models.UnlockAuditEvent, event.Type)
+		Equals(t, "atlantis", event.Actor)
+	}
+}
@@ -72,6 +72,8 @@ type commonData struct {
 	EnableDiffMarkdownFormat  bool
 	ExecutableName            string
 	HideUnchangedPlanComments bool
+	// CustomCommand is true if Command is a custom command.
+	CustomCommand bool
 }
 
 // errData is data about an error response.
@@ -184,6 +186,7 @@ func (m *MarkdownRenderer) Render(res command.Result, cmdName command.Name, subC
 		EnableDiffMarkdownFormat:  m.enableDiffMarkdownFormat,
 		ExecutableName:            m.executableName,
 		HideUnchangedPlanComments: m.hideUnchangedPlanComments,
+		CustomCommand:             cmdName.IsCustom(),
 	}
 
 	templates := m.markdownTemplates
@@ -304,6 +307,13 @@ func (m *MarkdownRenderer) renderProjectResults(results []command.ProjectResult,
 			} else {
 				resultData.Rendered = m.renderTemplateTrimSpace(templates.Lookup("stateRmSuccessUnwrapped"), result.StateRmSuccess)
 			}
+		} else if result.CustomCommandSuccess != nil {
+			result.CustomCommandSuccess.Output = strings.TrimSpace(result.CustomCommandSuccess.Output)
+			if m.shouldUseWrappedTmpl(vcsHost, result.CustomCommandSuccess.Output) {
+				resultData.Rendered = m.renderTemplateTrimSpace(templates.Lookup("customCommandSuccessWrapped"), result.CustomCommandSuccess)
+			} else {
+				resultData.Rendered = m.renderTemplateTrimSpace(templates.Lookup("customCommandSuccessUnwrapped"), result.CustomCommandSuccess)
+			}
 			// Error out if no template was found, only if there are no errors or failures.
 			// This is because some errors and failures rely on additional context rendered by templtes, but not all errors or failures.
 		} else if !(result.Error != nil || result.Failure != "") {
@@ -330,6 +340,10 @@ func (m *MarkdownRenderer) renderProjectResults(results []command.ProjectResult,
 
 	var tmpl *template.Template
 	switch {
+	case len(resultsTmplData) == 1 && common.CustomCommand:
+		tmpl = templates.Lookup("singleProjectCustomCommand")
+	case common.CustomCommand:
+		tmpl = templates.Lookup("multiProjectCustomCommand")
 	case len(resultsTmplData) == 1 && common.Command == planCommandTitle && numPlanSuccesses > 0:
 		tmpl = templates.Lookup("singleProjectPlanSuccess")
 	case len(resultsTmplData) == 1 && common.Command == planCommandTitle && numPlanSuccesses == 0:
@@ -430,6 +430,65 @@ $$$
 
 * :repeat: To **plan** this project again, comment:
   * $atlantis plan -d path -w workspace$
+`,
+		},
+		{
+			"single successful custom command",
+			command.RegisterCustom("validate"),
+			"",
+			[]command.ProjectResult{
+				{
+					CustomCommandSuccess: &models.CustomCommandSuccess{
+						Output: "Success! The configuration is valid.",
+					},
+					Workspace:   "workspace",
+					RepoRelDir:  "path",
+					ProjectName: "projectname",
+				},
+			},
+			models.Github,
+			`Ran Validate for project: $projectname$ dir: $path$ workspace: $workspace$
+
+$$$
+Success! The configuration is valid.
+$$$
+`,
+		},
+		{
+			"multiple custom commands",
+			command.RegisterCustom("validate"),
+			"",
+			[]command.ProjectResult{
+				{
+					CustomCommandSuccess: &models.CustomCommandSuccess{
+						Output: "Success! The configuration is valid.",
+					},
+					Workspace:  "default",
+					RepoRelDir: "path",
+				},
+				{
+					Failure:     "The workflow of this project has no steps in its \"validate\" stage.",
+					Workspace:   "default",
+					RepoRelDir:  "path2",
+					ProjectName: "projectname",
+				},
+			},
+			models.Github,
+			`Ran Validate for 2 projects:
+
+1. dir: $path$ workspace: $default$
+1. project: $projectname$ dir: $path2$ workspace: $default$
+
+### 1. dir: $path$ workspace: $default$
+$$$
+Success! The configuration is valid.
+$$$
+
+---
+### 2. project: $projectname$ dir: $path2$ workspace: $default$
+**Validate Failed**: The workflow of this project has no steps in its "validate" stage.
+
+---
 `,
 		},
 		{
@@ -1379,6 +1438,39 @@ $$$
 	Equals(t, normalize(exp), normalize(rendered))
 }
 
+// Test that custom templates can render the outputs of run steps.
+func TestRenderCustomPlanTemplate_StepOutputs(t *testing.T) {
+	tmpDir := t.TempDir()
+	err := os.WriteFile(fmt.Sprintf("%s/templates.tmpl", tmpDir), []byte("{{ define \"planSuccessUnwrapped\" -}}Estimated cost: ${{ .StepOutputs.COST }}{{- end}}\n"), 0600)
+	Ok(t, err)
+	r := events.NewMarkdownRenderer(
+		false,      // gitlabSupportsCommonMark
+		false,      // disableApplyAll
+		false,      // disableApply
+		false,      // disableMarkdownFolding
+		false,      // disableRepoLocking
+		false,      // enableDiffMarkdownFormat
+		tmpDir,     // MarkdownTemplateOverridesDir
+		"atlantis", // executableName
+		false,      // hideUnchangedPlanComments
+	)
+
+	rendered := r.Render(command.Result{
+		ProjectResults: []command.ProjectResult{
+			{
+				Workspace:  "workspace",
+				RepoRelDir: "path",
+				PlanSuccess: &models.PlanSuccess{
+					TerraformOutput: "terraform-output",
+					LockURL:         "lock-url",
+					StepOutputs:     map[string]string{"COST": "42.10"},
+				},
+			},
+		},
+	}, command.Plan, "", "log", false, models.Github)
+	Assert(t, strings.Contains(rendered, "Estimated cost: $42.10"), "exp step output in %q", rendered)
+}
+
 // Test that if folding is disabled that it's not used.
 func TestRenderProjectResults_DisableFolding(t *testing.T) {
 	mr := events.NewMarkdownRenderer(
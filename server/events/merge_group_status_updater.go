@@ -0,0 +1,139 @@
+package events
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/core/locking"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs"
+	"github.com/runatlantis/atlantis/server/logging"
+)
+
+// MergeGroupStatusUpdater reports Atlantis' commit statuses on the commits
+// GitHub creates for merge queues. GitHub requires the same statuses on these
+// commits as on the pull request before it merges them, but Atlantis never
+// runs against them so we copy the outcome recorded for the pull request.
+type MergeGroupStatusUpdater struct {
+	Backend             locking.Backend
+	CommitStatusUpdater CommitStatusUpdater
+	VCSClient           vcs.Client
+	CommitComparer      vcs.GithubCommitComparer
+	// VerifyProjects is true if we should fail the merge group when it
+	// modifies files outside of the projects that were planned for the pull
+	// request. This can happen when the merge group contains changes that
+	// Atlantis never planned.
+	VerifyProjects bool
+}
+
+// UpdateStatuses sets the plan and apply statuses on the merge group's head
+// commit, headSHA, based on the recorded status of pull request pullNum.
+// baseSHA is the merge group's parent commit.
+func (m *MergeGroupStatusUpdater) UpdateStatuses(logger logging.SimpleLogging, repo models.Repo, pullNum int, baseSHA string, headSHA string) error {
+	pull := models.PullRequest{BaseRepo: repo, Num: pullNum}
+	pullStatus, err := m.Backend.GetPullStatus(pull)
+	if err != nil {
+		return errors.Wrapf(err, "getting status of pull request %d", pullNum)
+	}
+	// If Atlantis never ran for this pull request then no projects were
+	// modified, which we report as success like we do for pull requests.
+	if pullStatus == nil {
+		pullStatus = &models.PullStatus{Pull: pull}
+	}
+
+	mergeGroupPull := pull
+	mergeGroupPull.HeadCommit = headSHA
+
+	if m.VerifyProjects {
+		unplanned, err := m.unplannedFiles(repo, *pullStatus, baseSHA, headSHA)
+		if err != nil {
+			return err
+		}
+		if len(unplanned) > 0 {
+			logger.Warn("merge group %s modifies files outside of the projects planned for pull request %d: %s", headSHA, pullNum, strings.Join(unplanned, ", "))
+			comment := fmt.Sprintf(mergeGroupUnplannedComment, headSHA, strings.Join(unplanned, "\n"))
+			if err := m.VCSClient.CreateComment(repo, pullNum, comment, ""); err != nil {
+				logger.Warn("unable to comment about merge group: %s", err)
+			}
+			for _, cmdName := range []command.Name{command.Plan, command.Apply} {
+				if err := m.CommitStatusUpdater.UpdateCombined(repo, mergeGroupPull, models.FailedCommitStatus, cmdName); err != nil {
+					return errors.Wrapf(err, "updating %s status", cmdName)
+				}
+			}
+			return nil
+		}
+	}
+
+	numProjects := len(pullStatus.Projects)
+
+	// We consider anything that isn't a plan error as a plan success, like
+	// the plan command runner does.
+	numPlanErrored := pullStatus.StatusCount(models.ErroredPlanStatus)
+	planStatus := models.SuccessCommitStatus
+	if numPlanErrored > 0 {
+		planStatus = models.FailedCommitStatus
+	}
+	if err := m.CommitStatusUpdater.UpdateCombinedCount(repo, mergeGroupPull, planStatus, command.Plan, numProjects-numPlanErrored, numProjects); err != nil {
+		return errors.Wrap(err, "updating plan status")
+	}
+
+	numApplied := pullStatus.StatusCount(models.AppliedPlanStatus) + pullStatus.StatusCount(models.PlannedNoChangesPlanStatus)
+	applyStatus := models.SuccessCommitStatus
+	if pullStatus.StatusCount(models.ErroredApplyStatus) > 0 {
+		applyStatus = models.FailedCommitStatus
+	} else if numApplied < numProjects {
+		applyStatus = models.PendingCommitStatus
+	}
+	if err := m.CommitStatusUpdater.UpdateCombinedCount(repo, mergeGroupPull, applyStatus, command.Apply, numApplied, numProjects); err != nil {
+		return errors.Wrap(err, "updating apply status")
+	}
+	return nil
+}
+
+// unplannedFiles returns the files modified by the merge group that the pull
+// request didn't modify and that aren't in any of the pull request's projects.
+func (m *MergeGroupStatusUpdater) unplannedFiles(repo models.Repo, pullStatus models.PullStatus, baseSHA string, headSHA string) ([]string, error) {
+	mergeGroupFiles, err := m.CommitComparer.GetModifiedFilesBetween(repo, baseSHA, headSHA)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting files modified by merge group")
+	}
+	pullFiles, err := m.VCSClient.GetModifiedFiles(repo, pullStatus.Pull)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting files modified by pull request")
+	}
+	modifiedByPull := make(map[string]bool, len(pullFiles))
+	for _, f := range pullFiles {
+		modifiedByPull[f] = true
+	}
+
+	var unplanned []string
+	for _, f := range mergeGroupFiles {
+		if modifiedByPull[f] || inProject(f, pullStatus.Projects) {
+			continue
+		}
+		unplanned = append(unplanned, f)
+	}
+	return unplanned, nil
+}
+
+// inProject returns true if file is within the directory of any of projects.
+func inProject(file string, projects []models.ProjectStatus) bool {
+	for _, p := range projects {
+		dir := filepath.Clean(p.RepoRelDir)
+		if dir == "." {
+			return true
+		}
+		if rel, err := filepath.Rel(dir, file); err == nil && !strings.HasPrefix(rel, "..") {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeGroupUnplannedComment is posted when a merge group is rejected because
+// it modifies files that weren't planned for the pull request.
+var mergeGroupUnplannedComment = "**Error:** The merge queue commit %s modifies files outside of the projects that were planned for this pull request:\n```\n%s\n```\n" +
+	"Atlantis has failed its statuses so it won't be merged. Update the pull request and run `atlantis plan` again."
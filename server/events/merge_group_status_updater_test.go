@@ -0,0 +1,141 @@
+package events_test
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/petergtz/pegomock/v4"
+	"github.com/runatlantis/atlantis/server/core/db"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/mocks"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/models/testdata"
+	vcsmocks "github.com/runatlantis/atlantis/server/events/vcs/mocks"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func setupMergeGroupStatusUpdater(t *testing.T, verifyProjects bool) (*events.MergeGroupStatusUpdater, *db.BoltDB, *mocks.MockCommitStatusUpdater, *vcsmocks.MockClient, *vcsmocks.MockGithubCommitComparer) {
+	RegisterMockTestingT(t)
+	boltDB, err := db.New(t.TempDir())
+	Ok(t, err)
+	statusUpdater := mocks.NewMockCommitStatusUpdater()
+	vcsClient := vcsmocks.NewMockClient()
+	comparer := vcsmocks.NewMockGithubCommitComparer()
+	return &events.MergeGroupStatusUpdater{
+		Backend:             boltDB,
+		CommitStatusUpdater: statusUpdater,
+		VCSClient:           vcsClient,
+		CommitComparer:      comparer,
+		VerifyProjects:      verifyProjects,
+	}, boltDB, statusUpdater, vcsClient, comparer
+}
+
+func TestMergeGroupStatusUpdater_UpdateStatuses(t *testing.T) {
+	cases := map[string]struct {
+		results         []command.ProjectResult
+		expPlanStatus   models.CommitStatus
+		expPlanSuccess  int
+		expApplyStatus  models.CommitStatus
+		expApplySuccess int
+		expTotal        int
+	}{
+		"no projects": {
+			expPlanStatus:  models.SuccessCommitStatus,
+			expApplyStatus: models.SuccessCommitStatus,
+		},
+		"all applied": {
+			results: []command.ProjectResult{
+				{Command: command.Apply, RepoRelDir: "a", Workspace: "default", ApplySuccess: "success"},
+				{Command: command.Apply, RepoRelDir: "b", Workspace: "default", ApplySuccess: "success"},
+			},
+			expPlanStatus:   models.SuccessCommitStatus,
+			expPlanSuccess:  2,
+			expApplyStatus:  models.SuccessCommitStatus,
+			expApplySuccess: 2,
+			expTotal:        2,
+		},
+		"not applied": {
+			results: []command.ProjectResult{
+				{Command: command.Apply, RepoRelDir: "a", Workspace: "default", ApplySuccess: "success"},
+				{Command: command.Plan, RepoRelDir: "b", Workspace: "default", PlanSuccess: &models.PlanSuccess{}},
+			},
+			expPlanStatus:   models.SuccessCommitStatus,
+			expPlanSuccess:  2,
+			expApplyStatus:  models.PendingCommitStatus,
+			expApplySuccess: 1,
+			expTotal:        2,
+		},
+		"plan errored": {
+			results: []command.ProjectResult{
+				{Command: command.Plan, RepoRelDir: "a", Workspace: "default", Error: errors.New("err")},
+			},
+			expPlanStatus:  models.FailedCommitStatus,
+			expApplyStatus: models.PendingCommitStatus,
+			expTotal:       1,
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			updater, boltDB, statusUpdater, _, _ := setupMergeGroupStatusUpdater(t, false)
+			pull := testdata.Pull
+			pull.BaseRepo = testdata.GithubRepo
+			if c.results != nil {
+				_, err := boltDB.UpdatePullWithResults(pull, c.results)
+				Ok(t, err)
+			}
+
+			err := updater.UpdateStatuses(logging.NewNoopLogger(t), testdata.GithubRepo, pull.Num, "basesha", "headsha")
+			Ok(t, err)
+
+			expPull := models.PullRequest{BaseRepo: testdata.GithubRepo, Num: pull.Num, HeadCommit: "headsha"}
+			statusUpdater.VerifyWasCalledOnce().UpdateCombinedCount(testdata.GithubRepo, expPull, c.expPlanStatus, command.Plan, c.expPlanSuccess, c.expTotal)
+			statusUpdater.VerifyWasCalledOnce().UpdateCombinedCount(testdata.GithubRepo, expPull, c.expApplyStatus, command.Apply, c.expApplySuccess, c.expTotal)
+		})
+	}
+}
+
+func TestMergeGroupStatusUpdater_VerifyProjects(t *testing.T) {
+	cases := map[string]struct {
+		mergeGroupFiles []string
+		expFailed       bool
+	}{
+		"same files as pull request": {
+			mergeGroupFiles: []string{"project/main.tf", "README.md"},
+		},
+		"other files in planned project": {
+			mergeGroupFiles: []string{"project/variables.tf"},
+		},
+		"files outside of planned projects": {
+			mergeGroupFiles: []string{"project/main.tf", "other/main.tf"},
+			expFailed:       true,
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			updater, boltDB, statusUpdater, vcsClient, comparer := setupMergeGroupStatusUpdater(t, true)
+			pull := testdata.Pull
+			pull.BaseRepo = testdata.GithubRepo
+			_, err := boltDB.UpdatePullWithResults(pull, []command.ProjectResult{
+				{Command: command.Apply, RepoRelDir: "project", Workspace: "default", ApplySuccess: "success"},
+			})
+			Ok(t, err)
+			When(vcsClient.GetModifiedFiles(Any[models.Repo](), Any[models.PullRequest]())).ThenReturn([]string{"project/main.tf", "README.md"}, nil)
+			When(comparer.GetModifiedFilesBetween(testdata.GithubRepo, "basesha", "headsha")).ThenReturn(c.mergeGroupFiles, nil)
+
+			err = updater.UpdateStatuses(logging.NewNoopLogger(t), testdata.GithubRepo, pull.Num, "basesha", "headsha")
+			Ok(t, err)
+
+			expPull := models.PullRequest{BaseRepo: testdata.GithubRepo, Num: pull.Num, HeadCommit: "headsha"}
+			if c.expFailed {
+				statusUpdater.VerifyWasCalledOnce().UpdateCombined(testdata.GithubRepo, expPull, models.FailedCommitStatus, command.Plan)
+				statusUpdater.VerifyWasCalledOnce().UpdateCombined(testdata.GithubRepo, expPull, models.FailedCommitStatus, command.Apply)
+				vcsClient.VerifyWasCalledOnce().CreateComment(Eq(testdata.GithubRepo), Eq(pull.Num), Any[string](), Eq(""))
+			} else {
+				statusUpdater.VerifyWasCalledOnce().UpdateCombinedCount(testdata.GithubRepo, expPull, models.SuccessCommitStatus, command.Apply, 1, 1)
+				vcsClient.VerifyWasCalled(Never()).CreateComment(Any[models.Repo](), Any[int](), Any[string](), Any[string]())
+			}
+		})
+	}
+}
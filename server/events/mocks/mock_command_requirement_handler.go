@@ -200,6 +200,56 @@ func (c *MockCommandRequirementHandler_ValidateImportProject_OngoingVerification
 	return
 }
 
+func (mock *MockCommandRequirementHandler) ValidateCustomProject(repoDir string, ctx command.ProjectContext) (string, error) {
+	if mock == nil {
+		panic("mock must not be nil. Use myMock := NewMockCommandRequirementHandler().")
+	}
+	params := []pegomock.Param{repoDir, ctx}
+	result := pegomock.GetGenericMockFrom(mock).Invoke("ValidateCustomProject", params, []reflect.Type{reflect.TypeOf((*string)(nil)).Elem(), reflect.TypeOf((*error)(nil)).Elem()})
+	var ret0 string
+	var ret1 error
+	if len(result) != 0 {
+		if result[0] != nil {
+			ret0 = result[0].(string)
+		}
+		if result[1] != nil {
+			ret1 = result[1].(error)
+		}
+	}
+	return ret0, ret1
+}
+
+func (verifier *VerifierMockCommandRequirementHandler) ValidateCustomProject(repoDir string, ctx command.ProjectContext) *MockCommandRequirementHandler_ValidateCustomProject_OngoingVerification {
+	params := []pegomock.Param{repoDir, ctx}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "ValidateCustomProject", params, verifier.timeout)
+	return &MockCommandRequirementHandler_ValidateCustomProject_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type MockCommandRequirementHandler_ValidateCustomProject_OngoingVerification struct {
+	mock              *MockCommandRequirementHandler
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *MockCommandRequirementHandler_ValidateCustomProject_OngoingVerification) GetCapturedArguments() (string, command.ProjectContext) {
+	repoDir, ctx := c.GetAllCapturedArguments()
+	return repoDir[len(repoDir)-1], ctx[len(ctx)-1]
+}
+
+func (c *MockCommandRequirementHandler_ValidateCustomProject_OngoingVerification) GetAllCapturedArguments() (_param0 []string, _param1 []command.ProjectContext) {
+	params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(params) > 0 {
+		_param0 = make([]string, len(c.methodInvocations))
+		for u, param := range params[0] {
+			_param0[u] = param.(string)
+		}
+		_param1 = make([]command.ProjectContext, len(c.methodInvocations))
+		for u, param := range params[1] {
+			_param1[u] = param.(command.ProjectContext)
+		}
+	}
+	return
+}
+
 func (verifier *VerifierMockCommandRequirementHandler) ValidatePlanProject(repoDir string, ctx command.ProjectContext) *MockCommandRequirementHandler_ValidatePlanProject_OngoingVerification {
 	params := []pegomock.Param{repoDir, ctx}
 	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "ValidatePlanProject", params, verifier.timeout)
@@ -317,6 +317,37 @@ func (mock *MockEventParsing) ParseGithubIssueCommentEvent(comment *github.Issue
 	return ret0, ret1, ret2, ret3
 }
 
+func (mock *MockEventParsing) ParseGithubMergeGroupEvent(event *github.MergeGroupEvent) (models.Repo, int, string, string, error) {
+	if mock == nil {
+		panic("mock must not be nil. Use myMock := NewMockEventParsing().")
+	}
+	params := []pegomock.Param{event}
+	result := pegomock.GetGenericMockFrom(mock).Invoke("ParseGithubMergeGroupEvent", params, []reflect.Type{reflect.TypeOf((*models.Repo)(nil)).Elem(), reflect.TypeOf((*int)(nil)).Elem(), reflect.TypeOf((*string)(nil)).Elem(), reflect.TypeOf((*string)(nil)).Elem(), reflect.TypeOf((*error)(nil)).Elem()})
+	var ret0 models.Repo
+	var ret1 int
+	var ret2 string
+	var ret3 string
+	var ret4 error
+	if len(result) != 0 {
+		if result[0] != nil {
+			ret0 = result[0].(models.Repo)
+		}
+		if result[1] != nil {
+			ret1 = result[1].(int)
+		}
+		if result[2] != nil {
+			ret2 = result[2].(string)
+		}
+		if result[3] != nil {
+			ret3 = result[3].(string)
+		}
+		if result[4] != nil {
+			ret4 = result[4].(error)
+		}
+	}
+	return ret0, ret1, ret2, ret3, ret4
+}
+
 func (mock *MockEventParsing) ParseGithubPull(ghPull *github.PullRequest) (models.PullRequest, models.Repo, models.Repo, error) {
 	if mock == nil {
 		panic("mock must not be nil. Use myMock := NewMockEventParsing().")
@@ -379,6 +410,60 @@ func (mock *MockEventParsing) ParseGithubPullEvent(pullEvent *github.PullRequest
 	return ret0, ret1, ret2, ret3, ret4, ret5
 }
 
+func (mock *MockEventParsing) ParseGithubPullRequestReviewCommentEvent(event *github.PullRequestReviewCommentEvent) (models.Repo, models.User, int, error) {
+	if mock == nil {
+		panic("mock must not be nil. Use myMock := NewMockEventParsing().")
+	}
+	params := []pegomock.Param{event}
+	result := pegomock.GetGenericMockFrom(mock).Invoke("ParseGithubPullRequestReviewCommentEvent", params, []reflect.Type{reflect.TypeOf((*models.Repo)(nil)).Elem(), reflect.TypeOf((*models.User)(nil)).Elem(), reflect.TypeOf((*int)(nil)).Elem(), reflect.TypeOf((*error)(nil)).Elem()})
+	var ret0 models.Repo
+	var ret1 models.User
+	var ret2 int
+	var ret3 error
+	if len(result) != 0 {
+		if result[0] != nil {
+			ret0 = result[0].(models.Repo)
+		}
+		if result[1] != nil {
+			ret1 = result[1].(models.User)
+		}
+		if result[2] != nil {
+			ret2 = result[2].(int)
+		}
+		if result[3] != nil {
+			ret3 = result[3].(error)
+		}
+	}
+	return ret0, ret1, ret2, ret3
+}
+
+func (mock *MockEventParsing) ParseGithubPullRequestReviewEvent(event *github.PullRequestReviewEvent) (models.Repo, models.User, int, error) {
+	if mock == nil {
+		panic("mock must not be nil. Use myMock := NewMockEventParsing().")
+	}
+	params := []pegomock.Param{event}
+	result := pegomock.GetGenericMockFrom(mock).Invoke("ParseGithubPullRequestReviewEvent", params, []reflect.Type{reflect.TypeOf((*models.Repo)(nil)).Elem(), reflect.TypeOf((*models.User)(nil)).Elem(), reflect.TypeOf((*int)(nil)).Elem(), reflect.TypeOf((*error)(nil)).Elem()})
+	var ret0 models.Repo
+	var ret1 models.User
+	var ret2 int
+	var ret3 error
+	if len(result) != 0 {
+		if result[0] != nil {
+			ret0 = result[0].(models.Repo)
+		}
+		if result[1] != nil {
+			ret1 = result[1].(models.User)
+		}
+		if result[2] != nil {
+			ret2 = result[2].(int)
+		}
+		if result[3] != nil {
+			ret3 = result[3].(error)
+		}
+	}
+	return ret0, ret1, ret2, ret3
+}
+
 func (mock *MockEventParsing) ParseGithubRepo(ghRepo *github.Repository) (models.Repo, error) {
 	if mock == nil {
 		panic("mock must not be nil. Use myMock := NewMockEventParsing().")
@@ -844,6 +929,33 @@ func (c *MockEventParsing_ParseGithubIssueCommentEvent_OngoingVerification) GetA
 	return
 }
 
+func (verifier *VerifierMockEventParsing) ParseGithubMergeGroupEvent(event *github.MergeGroupEvent) *MockEventParsing_ParseGithubMergeGroupEvent_OngoingVerification {
+	params := []pegomock.Param{event}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "ParseGithubMergeGroupEvent", params, verifier.timeout)
+	return &MockEventParsing_ParseGithubMergeGroupEvent_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type MockEventParsing_ParseGithubMergeGroupEvent_OngoingVerification struct {
+	mock              *MockEventParsing
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *MockEventParsing_ParseGithubMergeGroupEvent_OngoingVerification) GetCapturedArguments() *github.MergeGroupEvent {
+	event := c.GetAllCapturedArguments()
+	return event[len(event)-1]
+}
+
+func (c *MockEventParsing_ParseGithubMergeGroupEvent_OngoingVerification) GetAllCapturedArguments() (_param0 []*github.MergeGroupEvent) {
+	params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(params) > 0 {
+		_param0 = make([]*github.MergeGroupEvent, len(c.methodInvocations))
+		for u, param := range params[0] {
+			_param0[u] = param.(*github.MergeGroupEvent)
+		}
+	}
+	return
+}
+
 func (verifier *VerifierMockEventParsing) ParseGithubPull(ghPull *github.PullRequest) *MockEventParsing_ParseGithubPull_OngoingVerification {
 	params := []pegomock.Param{ghPull}
 	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "ParseGithubPull", params, verifier.timeout)
@@ -898,6 +1010,60 @@ func (c *MockEventParsing_ParseGithubPullEvent_OngoingVerification) GetAllCaptur
 	return
 }
 
+func (verifier *VerifierMockEventParsing) ParseGithubPullRequestReviewCommentEvent(event *github.PullRequestReviewCommentEvent) *MockEventParsing_ParseGithubPullRequestReviewCommentEvent_OngoingVerification {
+	params := []pegomock.Param{event}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "ParseGithubPullRequestReviewCommentEvent", params, verifier.timeout)
+	return &MockEventParsing_ParseGithubPullRequestReviewCommentEvent_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type MockEventParsing_ParseGithubPullRequestReviewCommentEvent_OngoingVerification struct {
+	mock              *MockEventParsing
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *MockEventParsing_ParseGithubPullRequestReviewCommentEvent_OngoingVerification) GetCapturedArguments() *github.PullRequestReviewCommentEvent {
+	event := c.GetAllCapturedArguments()
+	return event[len(event)-1]
+}
+
+func (c *MockEventParsing_ParseGithubPullRequestReviewCommentEvent_OngoingVerification) GetAllCapturedArguments() (_param0 []*github.PullRequestReviewCommentEvent) {
+	params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(params) > 0 {
+		_param0 = make([]*github.PullRequestReviewCommentEvent, len(c.methodInvocations))
+		for u, param := range params[0] {
+			_param0[u] = param.(*github.PullRequestReviewCommentEvent)
+		}
+	}
+	return
+}
+
+func (verifier *VerifierMockEventParsing) ParseGithubPullRequestReviewEvent(event *github.PullRequestReviewEvent) *MockEventParsing_ParseGithubPullRequestReviewEvent_OngoingVerification {
+	params := []pegomock.Param{event}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "ParseGithubPullRequestReviewEvent", params, verifier.timeout)
+	return &MockEventParsing_ParseGithubPullRequestReviewEvent_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type MockEventParsing_ParseGithubPullRequestReviewEvent_OngoingVerification struct {
+	mock              *MockEventParsing
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *MockEventParsing_ParseGithubPullRequestReviewEvent_OngoingVerification) GetCapturedArguments() *github.PullRequestReviewEvent {
+	event := c.GetAllCapturedArguments()
+	return event[len(event)-1]
+}
+
+func (c *MockEventParsing_ParseGithubPullRequestReviewEvent_OngoingVerification) GetAllCapturedArguments() (_param0 []*github.PullRequestReviewEvent) {
+	params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(params) > 0 {
+		_param0 = make([]*github.PullRequestReviewEvent, len(c.methodInvocations))
+		for u, param := range params[0] {
+			_param0[u] = param.(*github.PullRequestReviewEvent)
+		}
+	}
+	return
+}
+
 func (verifier *VerifierMockEventParsing) ParseGithubRepo(ghRepo *github.Repository) *MockEventParsing_ParseGithubRepo_OngoingVerification {
 	params := []pegomock.Param{ghRepo}
 	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "ParseGithubRepo", params, verifier.timeout)
@@ -19,6 +19,8 @@
 package models
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"net/url"
 	paths "path"
@@ -178,6 +180,10 @@ type PullRequest struct {
 	State PullRequestState
 	// BaseRepo is the repository that the pull request will be merged into.
 	BaseRepo Repo
+	// Draft is true if the pull request is a draft (or work in progress) and
+	// isn't ready for review yet. Bitbucket doesn't support drafts so it's
+	// always false there.
+	Draft bool
 }
 
 // PullRequestOptions is used to set optional paralmeters for PullRequest
@@ -185,8 +191,23 @@ type PullRequestOptions struct {
 	// When DeleteSourceBranchOnMerge flag is set to true VCS deletes the source branch after the PR is merged
 	// Applied by GitLab & AzureDevops
 	DeleteSourceBranchOnMerge bool
+	// MergeMethod is the method used to merge the pull request. If empty, the
+	// VCS client falls back to its default method.
+	MergeMethod MergeMethod
 }
 
+// MergeMethod is how a pull request's commits are merged into its base branch.
+type MergeMethod string
+
+const (
+	// MergeCommitMergeMethod creates a merge commit.
+	MergeCommitMergeMethod MergeMethod = "merge"
+	// SquashMergeMethod squashes all commits into a single commit.
+	SquashMergeMethod MergeMethod = "squash"
+	// RebaseMergeMethod rebases the commits onto the base branch.
+	RebaseMergeMethod MergeMethod = "rebase"
+)
+
 type PullRequestState int
 
 const (
@@ -201,6 +222,9 @@ const (
 	UpdatedPullEvent
 	ClosedPullEvent
 	OtherPullEvent
+	// MergedPullEvent is a pull request that was closed because it was
+	// merged. Anything that handles ClosedPullEvent should also handle this.
+	MergedPullEvent
 )
 
 func (p PullRequestEventType) String() string {
@@ -213,6 +237,8 @@ func (p PullRequestEventType) String() string {
 		return "closed"
 	case OtherPullEvent:
 		return "other"
+	case MergedPullEvent:
+		return "merged"
 	}
 	return "<missing String() implementation>"
 }
@@ -238,6 +264,14 @@ type ProjectLock struct {
 	Workspace string
 	// Time is the time at which the lock was first created.
 	Time time.Time
+	// ExpiresAt is when the lock expires and can be released by the lock
+	// reaper. If zero, the lock doesn't expire.
+	ExpiresAt time.Time
+}
+
+// Expired returns true if the lock has expired at now.
+func (l ProjectLock) Expired(now time.Time) bool {
+	return !l.ExpiresAt.IsZero() && !now.Before(l.ExpiresAt)
 }
 
 // Project represents a Terraform project. Since there may be multiple
@@ -365,6 +399,9 @@ type PlanSuccess struct {
 	// branch we're merging into had been updated, and we had to merge again
 	// before planning
 	MergedAgain bool
+	// StepOutputs are the outputs that run steps wrote to their
+	// $ATLANTIS_OUTPUTS file.
+	StepOutputs map[string]string
 }
 
 type PolicySetResult struct {
@@ -406,6 +443,27 @@ func (p *PlanSuccess) DiffSummary() string {
 	return reNoChanges.FindString(p.TerraformOutput)
 }
 
+// planActionsStart is the line Terraform prints before the changes of a plan.
+const planActionsStart = "Terraform will perform the following actions:"
+
+// ChangesDigest returns a digest of the changes in TerraformOutput, from the
+// list of actions to the Plan: summary. Output that isn't part of the changes,
+// like warnings and paths, doesn't change it so two plans with the same
+// changes have the same digest.
+func (p *PlanSuccess) ChangesDigest() string {
+	changes := p.TerraformOutput
+	if start := strings.Index(changes, planActionsStart); start >= 0 {
+		changes = changes[start:]
+		if loc := rePlanChanges.FindStringIndex(changes); loc != nil {
+			changes = changes[:loc[1]]
+		}
+	} else {
+		changes = p.DiffSummary()
+	}
+	sum := sha256.Sum256([]byte(strings.TrimSpace(changes)))
+	return hex.EncodeToString(sum[:])
+}
+
 // NoChanges returns true if the plan has no changes.
 func (p *PlanSuccess) NoChanges() bool {
 	return reNoChanges.MatchString(p.TerraformOutput)
@@ -468,6 +526,12 @@ type StateRmSuccess struct {
 	RePlanCmd string
 }
 
+// CustomCommandSuccess is the result of a successful custom command run.
+type CustomCommandSuccess struct {
+	// Output is the output of the steps of the custom command's stage.
+	Output string
+}
+
 func (p *PolicyCheckResults) CombinedOutput() string {
 	combinedOutput := ""
 	for _, psResult := range p.PolicySetResults {
@@ -527,6 +591,14 @@ type PullStatus struct {
 	Projects []ProjectStatus
 	// Pull is the original pull request model.
 	Pull PullRequest
+	// LastCommand is the name of the last command that updated the projects'
+	// statuses, ex. "plan".
+	LastCommand string
+	// CreatedAt is when Atlantis first recorded a status for this pull
+	// request.
+	CreatedAt time.Time
+	// UpdatedAt is when any project's status was last updated.
+	UpdatedAt time.Time
 }
 
 // StatusCount returns the number of projects that have status.
@@ -549,6 +621,9 @@ type ProjectStatus struct {
 	PolicyStatus []PolicySetStatus
 	// Status is the status of where this project is at in the planning cycle.
 	Status ProjectPlanStatus
+	// PlanDigest is the PlanSuccess.ChangesDigest of the project's last
+	// successful plan, so that it can be compared with later plans.
+	PlanDigest string
 }
 
 // ProjectPlanStatus is the status of where this project is at in the planning
@@ -660,3 +735,183 @@ func NewPlanSuccessStats(output string) PlanSuccessStats {
 
 	return s
 }
+
+// APIToken is a named token for the Atlantis API that can only run some
+// commands on some repos. Only a hash of its secret is stored.
+type APIToken struct {
+	// Name identifies the token. It's recorded as the user for commands run
+	// with the token.
+	Name string
+	// Repos is a regex that the IDs of the repos the token can access must
+	// match in full, ex. "github.com/owner/.*". If empty, all repos can be
+	// accessed.
+	Repos string
+	// Commands are the names of the commands the token can run, ex. "plan".
+	Commands []string
+	// CreatedAt is when the token was minted.
+	CreatedAt time.Time
+	// ExpiresAt is when the token stops working. If zero, it never expires.
+	ExpiresAt time.Time
+	// SecretHash is the hex-encoded SHA-256 hash of the token's secret.
+	SecretHash string
+
+	// reposRegex is Repos compiled by CompileRepos.
+	reposRegex *regexp.Regexp
+}
+
+// CompileRepos compiles Repos so that it's matched against the whole repo ID.
+// It must be called before AllowsRepo.
+func (t *APIToken) CompileRepos() error {
+	if t.Repos == "" {
+		t.reposRegex = nil
+		return nil
+	}
+	re, err := regexp.Compile("^(?:" + t.Repos + ")$")
+	if err != nil {
+		return err
+	}
+	t.reposRegex = re
+	return nil
+}
+
+// Expired returns true if the token has expired at now.
+func (t APIToken) Expired(now time.Time) bool {
+	return !t.ExpiresAt.IsZero() && !now.Before(t.ExpiresAt)
+}
+
+// AllowsRepo returns true if the token can access the repo with ID repoID.
+// Tokens whose Repos weren't compiled with CompileRepos can't access any
+// repo unless Repos is empty.
+func (t APIToken) AllowsRepo(repoID string) bool {
+	if t.Repos == "" {
+		return true
+	}
+	return t.reposRegex != nil && t.reposRegex.MatchString(repoID)
+}
+
+// AllowsCommand returns true if the token can run the command named name.
+func (t APIToken) AllowsCommand(name string) bool {
+	for _, c := range t.Commands {
+		if c == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Replica is a running Atlantis server. Several replicas can share a Redis
+// database.
+type Replica struct {
+	// ID uniquely identifies the replica.
+	ID        string
+	Hostname  string
+	Version   string
+	StartedAt time.Time
+	// ShuttingDown is true if the replica is draining its in-progress
+	// operations before it exits.
+	ShuttingDown  bool
+	InProgressOps int
+}
+
+// Lease is held by one replica until it expires unless it's renewed. Leases
+// are used to elect a leader and to track which replicas are running.
+type Lease struct {
+	Name      string
+	Holder    Replica
+	RenewedAt time.Time
+	ExpiresAt time.Time
+}
+
+// Expired returns true if the lease has expired at now.
+func (l Lease) Expired(now time.Time) bool {
+	return !now.Before(l.ExpiresAt)
+}
+
+// AuditEventType is the kind of action an AuditEvent records.
+type AuditEventType string
+
+const (
+	// CommandAuditEvent records a command run on a project, ex. plan.
+	CommandAuditEvent AuditEventType = "command"
+	// PolicyApprovalAuditEvent records approving the failing policies of a
+	// project.
+	PolicyApprovalAuditEvent AuditEventType = "policy_approval"
+	// LockAuditEvent records a project lock being acquired.
+	LockAuditEvent AuditEventType = "lock"
+	// UnlockAuditEvent records project locks being deleted.
+	UnlockAuditEvent AuditEventType = "unlock"
+	// ApplyLockAuditEvent records the global apply lock being acquired.
+	ApplyLockAuditEvent AuditEventType = "apply_lock"
+	// ApplyUnlockAuditEvent records the global apply lock being released.
+	ApplyUnlockAuditEvent AuditEventType = "apply_unlock"
+	// APICallAuditEvent records a request to the Atlantis API.
+	APICallAuditEvent AuditEventType = "api_call"
+)
+
+// AuditOutcome is how the action an AuditEvent records turned out.
+type AuditOutcome string
+
+const (
+	// AuditSuccess means the action succeeded.
+	AuditSuccess AuditOutcome = "success"
+	// AuditFailure means the action was attempted but failed in an expected
+	// way, ex. a plan with invalid Terraform or a forbidden API call.
+	AuditFailure AuditOutcome = "failure"
+	// AuditError means the action failed because of an error in Atlantis.
+	AuditError AuditOutcome = "error"
+)
+
+// AuditEvent is a record of a privileged action, ex. an apply or deleting a
+// lock. Fields that don't apply to the action are empty.
+type AuditEvent struct {
+	Time time.Time      `json:"time"`
+	Type AuditEventType `json:"type"`
+	// Actor is the username of who performed the action. For API calls made
+	// with a scoped token it's the token's name.
+	Actor     string `json:"actor"`
+	Repo      string `json:"repo,omitempty"`
+	Pull      int    `json:"pull,omitempty"`
+	Project   string `json:"project,omitempty"`
+	Directory string `json:"directory,omitempty"`
+	Workspace string `json:"workspace,omitempty"`
+	// Command is the command that was run, ex. "apply". For API calls it's
+	// the method and path, ex. "POST /api/plan".
+	Command string       `json:"command,omitempty"`
+	Outcome AuditOutcome `json:"outcome"`
+	// Detail explains the outcome, ex. the error for failed commands or the
+	// status code for API calls.
+	Detail string `json:"detail,omitempty"`
+	// PlanStats are the stats of successful plans.
+	PlanStats *PlanSuccessStats `json:"plan_stats,omitempty"`
+	// JobID is the ID of the job whose output can be streamed from
+	// /jobs/{id} while it's kept in memory.
+	JobID string `json:"job_id,omitempty"`
+}
+
+// AuditQuery selects audit events. Fields that are empty match all events.
+type AuditQuery struct {
+	Type    AuditEventType
+	Actor   string
+	Repo    string
+	Pull    int
+	Command string
+	Outcome AuditOutcome
+	// Since and Until bound the time of the events, inclusively.
+	Since time.Time
+	Until time.Time
+	// Limit is the maximum number of events returned. If zero, all matching
+	// events are returned.
+	Limit int
+}
+
+// Matches returns true if e is selected by the query. Limit isn't checked.
+func (q AuditQuery) Matches(e AuditEvent) bool {
+	return (q.Type == "" || e.Type == q.Type) &&
+		(q.Actor == "" || e.Actor == q.Actor) &&
+		(q.Repo == "" || e.Repo == q.Repo) &&
+		(q.Pull == 0 || e.Pull == q.Pull) &&
+		(q.Command == "" || e.Command == q.Command) &&
+		(q.Outcome == "" || e.Outcome == q.Outcome) &&
+		(q.Since.IsZero() || !e.Time.Before(q.Since)) &&
+		(q.Until.IsZero() || !e.Time.After(q.Until))
+}
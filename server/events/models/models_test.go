@@ -15,6 +15,7 @@ package models_test
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/runatlantis/atlantis/server/events/models"
@@ -423,6 +424,24 @@ func TestPlanSuccess_DiffSummary(t *testing.T) {
 	}
 }
 
+func TestPlanSuccess_ChangesDigest(t *testing.T) {
+	changes := "Terraform will perform the following actions:\n\n  # null_resource.a will be created\n  + resource \"null_resource\" \"a\" {}\n\nPlan: 1 to add, 0 to change, 0 to destroy."
+	digest := (&models.PlanSuccess{TerraformOutput: changes}).ChangesDigest()
+
+	t.Log("output around the changes doesn't change the digest")
+	Equals(t, digest, (&models.PlanSuccess{
+		TerraformOutput: "Note: Objects have changed outside of Terraform\n\n" + changes + "\n\nSaved the plan to: /tmp/other/default.tfplan",
+	}).ChangesDigest())
+
+	t.Log("different changes have a different digest")
+	Assert(t, digest != (&models.PlanSuccess{
+		TerraformOutput: strings.Replace(changes, "null_resource.a", "null_resource.b", 1),
+	}).ChangesDigest(), "exp different changes to have a different digest")
+	Assert(t, digest != (&models.PlanSuccess{
+		TerraformOutput: "No changes. Your infrastructure matches the configuration.",
+	}).ChangesDigest(), "exp no changes to have a different digest")
+}
+
 func TestPolicyCheckResults_Summary(t *testing.T) {
 	cases := []struct {
 		description      string
@@ -0,0 +1,195 @@
+package events
+
+import (
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/core/locking"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs"
+)
+
+func NewPostMergeApplyCommandRunner(
+	applyCommandLocker locking.ApplyLockChecker,
+	workingDir WorkingDir,
+	prjCommandBuilder ProjectCommandBuilder,
+	prjPlanCmdRunner ProjectPlanCommandRunner,
+	prjApplyCmdRunner ProjectApplyCommandRunner,
+	pullUpdater *PullUpdater,
+	pullReqStatusFetcher vcs.PullReqStatusFetcher,
+) *PostMergeApplyCommandRunner {
+	return &PostMergeApplyCommandRunner{
+		locker:         applyCommandLocker,
+		workingDir:     workingDir,
+		prjCmdBuilder:  prjCommandBuilder,
+		prjPlanRunner:  prjPlanCmdRunner,
+		prjApplyRunner: prjApplyCmdRunner,
+		pullUpdater:    pullUpdater,
+
+		pullReqStatusFetcher: pullReqStatusFetcher,
+	}
+}
+
+// PostMergeApplyCommandRunner applies projects configured with
+// apply_mode: after_merge once their pull request has been merged.
+type PostMergeApplyCommandRunner struct {
+	locker         locking.ApplyLockChecker
+	workingDir     WorkingDir
+	prjCmdBuilder  ProjectCommandBuilder
+	prjPlanRunner  ProjectPlanCommandRunner
+	prjApplyRunner ProjectApplyCommandRunner
+	pullUpdater    *PullUpdater
+
+	pullReqStatusFetcher vcs.PullReqStatusFetcher
+}
+
+// Run re-plans every project that had an unapplied plan when the pull request
+// was merged against the merged base branch, and applies those that still
+// have the changes that were reviewed. Projects whose changes are different,
+// ex. because the base branch changed after the review, aren't applied. Only
+// projects using apply_mode: after_merge are considered.
+func (p *PostMergeApplyCommandRunner) Run(ctx *command.Context) {
+	reviewed := p.reviewedProjects(ctx.PullStatus)
+	if len(reviewed) == 0 {
+		ctx.Log.Debug("no unapplied plans on merged pull request, skipping post-merge apply")
+		return
+	}
+
+	// Fetch the approval status before re-planning since apply requirements
+	// are still checked once the pull request is merged.
+	var err error
+	ctx.PullRequestStatus, err = p.pullReqStatusFetcher.FetchPullStatus(ctx.Pull)
+	if err != nil {
+		ctx.Log.Warn("unable to get pull request status: %s. Continuing with mergeable and approved assumed false", err)
+	}
+
+	// The pull request has been merged so we re-plan against its base branch,
+	// which now contains the merge commit. The working directory still holds
+	// the pull request's checkout so it must be removed before we clone.
+	ctx.Pull.HeadBranch = ctx.Pull.BaseBranch
+	ctx.HeadRepo = ctx.Pull.BaseRepo
+	if err := p.workingDir.Delete(ctx.Pull.BaseRepo, ctx.Pull); err != nil {
+		ctx.Log.Err("unable to delete working dir before post-merge plan: %s", err)
+		return
+	}
+
+	var planCmds []command.ProjectContext
+	for _, proj := range reviewed {
+		cmds, err := p.prjCmdBuilder.BuildPlanCommands(ctx, &CommentCommand{
+			Name:        command.Plan,
+			RepoRelDir:  proj.RepoRelDir,
+			Workspace:   proj.Workspace,
+			ProjectName: proj.ProjectName,
+		})
+		if err != nil {
+			ctx.Log.Warn("unable to build post-merge plan for dir %q workspace %q: %s", proj.RepoRelDir, proj.Workspace, err)
+			continue
+		}
+		for _, cmd := range cmds {
+			if cmd.ApplyMode == valid.AfterMergeApplyMode {
+				planCmds = append(planCmds, cmd)
+			}
+		}
+	}
+	if len(planCmds) == 0 {
+		ctx.Log.Debug("no projects configured with %s: %s, skipping post-merge apply", valid.ApplyModeKey, valid.AfterMergeApplyMode)
+		return
+	}
+
+	lock, err := p.locker.CheckApplyLock()
+	if err != nil {
+		ctx.Log.Warn("checking global apply lock: %s", err)
+	}
+	if lock.Locked {
+		ctx.Log.Info("ignoring post-merge apply since apply disabled globally")
+		p.pullUpdater.updatePull(ctx, &CommentCommand{Name: command.Apply}, command.Result{Failure: postMergeApplyDisabledComment})
+		return
+	}
+	ctx.Log.Info("running post-merge apply for %d project(s)", len(planCmds))
+
+	var results []command.ProjectResult
+	for _, planCmd := range planCmds {
+		planResult := p.prjPlanRunner.Plan(planCmd)
+		if planResult.PlanStatus() != models.PlannedPlanStatus {
+			// Either the plan failed, in which case we report it, or the
+			// merged code no longer has any changes and there is nothing to
+			// apply.
+			if planResult.IsSuccessful() {
+				ctx.Log.Info("project at dir %q workspace %q has no changes after merge, skipping apply", planCmd.RepoRelDir, planCmd.Workspace)
+				continue
+			}
+			results = append(results, planResult)
+			continue
+		}
+		if !p.matchesReviewedPlan(reviewed, planCmd, planResult) {
+			ctx.Log.Warn("plan of project at dir %q workspace %q changed after it was reviewed, skipping apply", planCmd.RepoRelDir, planCmd.Workspace)
+			results = append(results, command.ProjectResult{
+				Command:     command.Apply,
+				RepoRelDir:  planCmd.RepoRelDir,
+				Workspace:   planCmd.Workspace,
+				ProjectName: planCmd.ProjectName,
+				Failure:     postMergePlanChangedComment,
+			})
+			continue
+		}
+
+		applyCmds, err := p.prjCmdBuilder.BuildApplyCommands(ctx, &CommentCommand{
+			Name:        command.Apply,
+			RepoRelDir:  planCmd.RepoRelDir,
+			Workspace:   planCmd.Workspace,
+			ProjectName: planCmd.ProjectName,
+		})
+		if err != nil {
+			results = append(results, command.ProjectResult{
+				Command:     command.Apply,
+				RepoRelDir:  planCmd.RepoRelDir,
+				Workspace:   planCmd.Workspace,
+				ProjectName: planCmd.ProjectName,
+				Error:       err,
+			})
+			continue
+		}
+		for _, applyCmd := range applyCmds {
+			results = append(results, p.prjApplyRunner.Apply(applyCmd))
+		}
+	}
+
+	if len(results) == 0 {
+		return
+	}
+	p.pullUpdater.updatePull(ctx, &CommentCommand{Name: command.Apply}, command.Result{ProjectResults: results})
+}
+
+// reviewedProjects returns the projects that had a successful plan that was
+// never applied at the time the pull request was merged.
+func (p *PostMergeApplyCommandRunner) reviewedProjects(pullStatus *models.PullStatus) []models.ProjectStatus {
+	if pullStatus == nil {
+		return nil
+	}
+	var reviewed []models.ProjectStatus
+	for _, proj := range pullStatus.Projects {
+		if proj.Status == models.PlannedPlanStatus || proj.Status == models.PassedPolicyCheckStatus {
+			reviewed = append(reviewed, proj)
+		}
+	}
+	return reviewed
+}
+
+// matchesReviewedPlan returns whether the changes of planResult are the ones
+// that were reviewed for the project of planCmd before the pull request was
+// merged. Projects without a reviewed plan never match.
+func (p *PostMergeApplyCommandRunner) matchesReviewedPlan(reviewed []models.ProjectStatus, planCmd command.ProjectContext, planResult command.ProjectResult) bool {
+	for _, proj := range reviewed {
+		if proj.RepoRelDir == planCmd.RepoRelDir && proj.Workspace == planCmd.Workspace && proj.ProjectName == planCmd.ProjectName {
+			return proj.PlanDigest != "" && proj.PlanDigest == planResult.PlanDigest()
+		}
+	}
+	return false
+}
+
+// postMergePlanChangedComment is posted for apply_mode: after_merge projects
+// whose plan after merge has different changes than the plan that was reviewed.
+var postMergePlanChangedComment = "The plan of this project after merge has different changes than the plan that was reviewed, ex. because the base branch changed, so it was not applied. Review and apply the new changes in another pull request."
+
+// postMergeApplyDisabledComment is posted when a pull request with
+// apply_mode: after_merge projects is merged while apply is disabled globally.
+var postMergeApplyDisabledComment = "Running `atlantis apply` is disabled so the projects in this pull request were not applied after merge."
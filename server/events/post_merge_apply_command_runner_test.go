@@ -0,0 +1,228 @@
+package events_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/petergtz/pegomock/v4"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/core/locking"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/mocks"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/models/testdata"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestRunPostMergeCommand_AppliesAfterMergeProjects(t *testing.T) {
+	vcsClient := setup(t)
+	afterMerge := valid.AfterMergeApplyMode
+	ch.GlobalCfg.Repos[0].ApplyMode = &afterMerge
+
+	pull := testdata.Pull
+	pull.BaseRepo = testdata.GithubRepo
+	pull.State = models.ClosedPullState
+	_, err := dbUpdater.Backend.UpdatePullWithResults(pull, []command.ProjectResult{
+		{
+			Command:     command.Plan,
+			RepoRelDir:  "after",
+			Workspace:   "default",
+			PlanSuccess: &models.PlanSuccess{},
+		},
+		{
+			Command:     command.Plan,
+			RepoRelDir:  "before",
+			Workspace:   "default",
+			PlanSuccess: &models.PlanSuccess{},
+		},
+		{
+			Command:      command.Apply,
+			RepoRelDir:   "applied",
+			Workspace:    "default",
+			ApplySuccess: "success",
+		},
+	})
+	Ok(t, err)
+
+	When(applyLockChecker.CheckApplyLock()).ThenReturn(locking.ApplyCommandLock{}, nil)
+	When(projectCommandBuilder.BuildPlanCommands(Any[*command.Context](), Eq(&events.CommentCommand{Name: command.Plan, RepoRelDir: "after", Workspace: "default"}))).
+		ThenReturn([]command.ProjectContext{
+			{
+				CommandName: command.Plan,
+				RepoRelDir:  "after",
+				Workspace:   "default",
+				ApplyMode:   valid.AfterMergeApplyMode,
+				Trigger:     command.MergeTrigger,
+			},
+		}, nil)
+	When(projectCommandBuilder.BuildPlanCommands(Any[*command.Context](), Eq(&events.CommentCommand{Name: command.Plan, RepoRelDir: "before", Workspace: "default"}))).
+		ThenReturn([]command.ProjectContext{
+			{
+				CommandName: command.Plan,
+				RepoRelDir:  "before",
+				Workspace:   "default",
+				Trigger:     command.MergeTrigger,
+			},
+		}, nil)
+	When(projectCommandRunner.Plan(Any[command.ProjectContext]())).
+		ThenReturn(command.ProjectResult{Command: command.Plan, RepoRelDir: "after", Workspace: "default", PlanSuccess: &models.PlanSuccess{}})
+	When(projectCommandBuilder.BuildApplyCommands(Any[*command.Context](), Eq(&events.CommentCommand{Name: command.Apply, RepoRelDir: "after", Workspace: "default"}))).
+		ThenReturn([]command.ProjectContext{
+			{
+				CommandName: command.Apply,
+				RepoRelDir:  "after",
+				Workspace:   "default",
+				ApplyMode:   valid.AfterMergeApplyMode,
+				Trigger:     command.MergeTrigger,
+			},
+		}, nil)
+	When(projectCommandRunner.Apply(Any[command.ProjectContext]())).
+		ThenReturn(command.ProjectResult{Command: command.Apply, RepoRelDir: "after", Workspace: "default", ApplySuccess: "success"})
+
+	ch.RunPostMergeCommand(testdata.GithubRepo, testdata.GithubRepo, pull, testdata.User)
+
+	workingDir.(*mocks.MockWorkingDir).VerifyWasCalledOnce().Delete(Any[models.Repo](), Any[models.PullRequest]())
+	projectCommandBuilder.VerifyWasCalled(Times(2)).BuildPlanCommands(Any[*command.Context](), Any[*events.CommentCommand]())
+	projectCommandRunner.VerifyWasCalledOnce().Plan(Any[command.ProjectContext]())
+	projectCommandRunner.VerifyWasCalledOnce().Apply(Any[command.ProjectContext]())
+	vcsClient.VerifyWasCalledOnce().CreateComment(Eq(testdata.GithubRepo), Eq(pull.Num), Any[string](), Eq("apply"))
+}
+
+func TestRunPostMergeCommand_NoChangesAfterMerge(t *testing.T) {
+	vcsClient := setup(t)
+	afterMerge := valid.AfterMergeApplyMode
+	ch.GlobalCfg.Repos[0].ApplyMode = &afterMerge
+
+	pull := testdata.Pull
+	pull.BaseRepo = testdata.GithubRepo
+	_, err := dbUpdater.Backend.UpdatePullWithResults(pull, []command.ProjectResult{
+		{
+			Command:     command.Plan,
+			RepoRelDir:  "after",
+			Workspace:   "default",
+			PlanSuccess: &models.PlanSuccess{},
+		},
+	})
+	Ok(t, err)
+
+	When(applyLockChecker.CheckApplyLock()).ThenReturn(locking.ApplyCommandLock{}, nil)
+	When(projectCommandBuilder.BuildPlanCommands(Any[*command.Context](), Any[*events.CommentCommand]())).
+		ThenReturn([]command.ProjectContext{
+			{
+				CommandName: command.Plan,
+				RepoRelDir:  "after",
+				Workspace:   "default",
+				ApplyMode:   valid.AfterMergeApplyMode,
+			},
+		}, nil)
+	When(projectCommandRunner.Plan(Any[command.ProjectContext]())).
+		ThenReturn(command.ProjectResult{
+			Command:     command.Plan,
+			RepoRelDir:  "after",
+			Workspace:   "default",
+			PlanSuccess: &models.PlanSuccess{TerraformOutput: "No changes. Your infrastructure matches the configuration."},
+		})
+
+	ch.RunPostMergeCommand(testdata.GithubRepo, testdata.GithubRepo, pull, testdata.User)
+
+	projectCommandRunner.VerifyWasCalled(Never()).Apply(Any[command.ProjectContext]())
+	vcsClient.VerifyWasCalled(Never()).CreateComment(Any[models.Repo](), Any[int](), Any[string](), Any[string]())
+}
+
+func TestRunPostMergeCommand_ApplyModeNotConfigured(t *testing.T) {
+	vcsClient := setup(t)
+
+	pull := testdata.Pull
+	pull.BaseRepo = testdata.GithubRepo
+	_, err := dbUpdater.Backend.UpdatePullWithResults(pull, []command.ProjectResult{
+		{
+			Command:     command.Plan,
+			RepoRelDir:  ".",
+			Workspace:   "default",
+			PlanSuccess: &models.PlanSuccess{},
+		},
+	})
+	Ok(t, err)
+
+	ch.RunPostMergeCommand(testdata.GithubRepo, testdata.GithubRepo, pull, testdata.User)
+
+	projectCommandBuilder.VerifyWasCalled(Never()).BuildPlanCommands(Any[*command.Context](), Any[*events.CommentCommand]())
+	vcsClient.VerifyWasCalled(Never()).CreateComment(Any[models.Repo](), Any[int](), Any[string](), Any[string]())
+}
+
+func TestRunPostMergeCommand_ApplyDisabled(t *testing.T) {
+	vcsClient := setup(t)
+	afterMerge := valid.AfterMergeApplyMode
+	ch.GlobalCfg.Repos[0].ApplyMode = &afterMerge
+
+	pull := testdata.Pull
+	pull.BaseRepo = testdata.GithubRepo
+	_, err := dbUpdater.Backend.UpdatePullWithResults(pull, []command.ProjectResult{
+		{
+			Command:     command.Plan,
+			RepoRelDir:  "after",
+			Workspace:   "default",
+			PlanSuccess: &models.PlanSuccess{},
+		},
+	})
+	Ok(t, err)
+
+	When(applyLockChecker.CheckApplyLock()).ThenReturn(locking.ApplyCommandLock{Locked: true}, nil)
+	When(projectCommandBuilder.BuildPlanCommands(Any[*command.Context](), Any[*events.CommentCommand]())).
+		ThenReturn([]command.ProjectContext{
+			{
+				CommandName: command.Plan,
+				RepoRelDir:  "after",
+				Workspace:   "default",
+				ApplyMode:   valid.AfterMergeApplyMode,
+			},
+		}, nil)
+
+	ch.RunPostMergeCommand(testdata.GithubRepo, testdata.GithubRepo, pull, testdata.User)
+
+	projectCommandRunner.VerifyWasCalled(Never()).Plan(Any[command.ProjectContext]())
+	vcsClient.VerifyWasCalledOnce().CreateComment(Eq(testdata.GithubRepo), Eq(pull.Num), Any[string](), Eq("apply"))
+}
+
+func TestRunPostMergeCommand_PlanChangedAfterReview(t *testing.T) {
+	vcsClient := setup(t)
+	afterMerge := valid.AfterMergeApplyMode
+	ch.GlobalCfg.Repos[0].ApplyMode = &afterMerge
+
+	pull := testdata.Pull
+	pull.BaseRepo = testdata.GithubRepo
+	_, err := dbUpdater.Backend.UpdatePullWithResults(pull, []command.ProjectResult{
+		{
+			Command:     command.Plan,
+			RepoRelDir:  "after",
+			Workspace:   "default",
+			PlanSuccess: &models.PlanSuccess{TerraformOutput: "Terraform will perform the following actions:\n  + null_resource.a\nPlan: 1 to add, 0 to change, 0 to destroy."},
+		},
+	})
+	Ok(t, err)
+
+	When(applyLockChecker.CheckApplyLock()).ThenReturn(locking.ApplyCommandLock{}, nil)
+	When(projectCommandBuilder.BuildPlanCommands(Any[*command.Context](), Any[*events.CommentCommand]())).
+		ThenReturn([]command.ProjectContext{
+			{
+				CommandName: command.Plan,
+				RepoRelDir:  "after",
+				Workspace:   "default",
+				ApplyMode:   valid.AfterMergeApplyMode,
+			},
+		}, nil)
+	When(projectCommandRunner.Plan(Any[command.ProjectContext]())).
+		ThenReturn(command.ProjectResult{
+			Command:     command.Plan,
+			RepoRelDir:  "after",
+			Workspace:   "default",
+			PlanSuccess: &models.PlanSuccess{TerraformOutput: "Terraform will perform the following actions:\n  - null_resource.b\nPlan: 0 to add, 0 to change, 1 to destroy."},
+		})
+
+	ch.RunPostMergeCommand(testdata.GithubRepo, testdata.GithubRepo, pull, testdata.User)
+
+	projectCommandRunner.VerifyWasCalled(Never()).Apply(Any[command.ProjectContext]())
+	_, _, comment, _ := vcsClient.VerifyWasCalledOnce().CreateComment(Eq(testdata.GithubRepo), Eq(pull.Num), Any[string](), Eq("apply")).GetCapturedArguments()
+	Assert(t, strings.Contains(comment, "different changes than the plan that was reviewed"), "exp comment to say the plan changed, got %q", comment)
+}
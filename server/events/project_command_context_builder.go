@@ -119,6 +119,13 @@ func (cb *DefaultProjectCommandContextBuilder) BuildProjectContext(
 			// if comes here, state_command_runner will respond on PR, so it's enough to do log only.
 			ctx.Log.Err("unknown state subcommand: %s", subName)
 		}
+	default:
+		if ctx.CustomCommand != nil {
+			// If the workflow doesn't have the stage there are no steps,
+			// which the project command runner reports.
+			stage, _ := prjCfg.Workflow.Stage(ctx.CustomCommand.Stage)
+			steps = stage.Steps
+		}
 	}
 
 	// If TerraformVersion not defined in config file look for a
@@ -301,11 +308,17 @@ func newProjectCommandContext(ctx *command.Context,
 		PolicySets:                 policySets,
 		PolicySetTarget:            ctx.PolicySet,
 		ClearPolicyApproval:        ctx.ClearPolicyApproval,
+		CustomCommand:              ctx.CustomCommand,
 		PullReqStatus:              pullReqStatus,
 		PullStatus:                 pullStatus,
 		JobID:                      uuid.New().String(),
 		ExecutionOrderGroup:        projCfg.ExecutionOrderGroup,
 		AbortOnExcecutionOrderFail: abortOnExcecutionOrderFail,
+		ApplyMode:                  projCfg.ApplyMode,
+		Trigger:                    ctx.Trigger,
+		PlanTimeout:                projCfg.PlanTimeout,
+		ApplyTimeout:               projCfg.ApplyTimeout,
+		Runner:                     projCfg.Runner,
 	}
 }
 
@@ -320,3 +333,16 @@ func escapeArgs(args []string) []string {
 	}
 	return escaped
 }
+
+// unescapeArgs reverses escapeArgs.
+func unescapeArgs(escaped []string) []string {
+	var args []string
+	for _, escapedArg := range escaped {
+		var arg []byte
+		for i := 1; i < len(escapedArg); i += 2 {
+			arg = append(arg, escapedArg[i])
+		}
+		args = append(args, string(arg))
+	}
+	return args
+}
@@ -19,11 +19,13 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/hashicorp/go-multierror"
 	"github.com/pkg/errors"
 	"github.com/runatlantis/atlantis/server/core/config/valid"
 	"github.com/runatlantis/atlantis/server/core/runtime"
+	"github.com/runatlantis/atlantis/server/core/runtime/sandbox"
 	"github.com/runatlantis/atlantis/server/events/command"
 	"github.com/runatlantis/atlantis/server/events/models"
 	"github.com/runatlantis/atlantis/server/events/vcs"
@@ -126,6 +128,12 @@ type ProjectStateCommandRunner interface {
 	StateRm(ctx command.ProjectContext) command.ProjectResult
 }
 
+type ProjectCustomCommandRunner interface {
+	// Custom runs the stage of the custom command for the project described
+	// by ctx.
+	Custom(ctx command.ProjectContext) command.ProjectResult
+}
+
 // ProjectCommandRunner runs project commands. A project command is a command
 // for a specific TF project.
 type ProjectCommandRunner interface {
@@ -136,6 +144,7 @@ type ProjectCommandRunner interface {
 	ProjectVersionCommandRunner
 	ProjectImportCommandRunner
 	ProjectStateCommandRunner
+	ProjectCustomCommandRunner
 }
 
 //go:generate pegomock generate --package mocks -o mocks/mock_job_url_setter.go JobURLSetter
@@ -219,6 +228,17 @@ type DefaultProjectCommandRunner struct {
 	Webhooks                  WebhooksSender
 	WorkingDirLocker          WorkingDirLocker
 	CommandRequirementHandler CommandRequirementHandler
+	// DefaultPlanTimeout is how long plan can run for projects that don't
+	// set plan_timeout. If 0, plans don't time out.
+	DefaultPlanTimeout time.Duration
+	// DefaultApplyTimeout is how long apply can run for projects that don't
+	// set apply_timeout. If 0, applies don't time out.
+	DefaultApplyTimeout time.Duration
+	// JobMessageSender, if set, is sent a message each time a step is
+	// retried so that it shows in the job's output.
+	JobMessageSender JobMessageSender
+	// Sandboxes, if set, is used to run the steps of each job in a sandbox.
+	Sandboxes *sandbox.Pool
 }
 
 // Plan runs terraform plan for the project described by ctx.
@@ -318,6 +338,21 @@ func (p *DefaultProjectCommandRunner) StateRm(ctx command.ProjectContext) comman
 	}
 }
 
+// Custom runs the stage of the custom command for the project described by
+// ctx.
+func (p *DefaultProjectCommandRunner) Custom(ctx command.ProjectContext) command.ProjectResult {
+	customSuccess, failure, err := p.doCustom(ctx)
+	return command.ProjectResult{
+		Command:              ctx.CommandName,
+		CustomCommandSuccess: customSuccess,
+		Error:                err,
+		Failure:              failure,
+		RepoRelDir:           ctx.RepoRelDir,
+		Workspace:            ctx.Workspace,
+		ProjectName:          ctx.ProjectName,
+	}
+}
+
 func (p *DefaultProjectCommandRunner) doApprovePolicies(ctx command.ProjectContext) (*models.PolicyCheckResults, string, error) {
 	// Acquire Atlantis lock for this repo/dir/workspace.
 	lockAttempt, err := p.Locker.TryLock(ctx.Log, ctx.Pull, ctx.User, ctx.Workspace, models.NewProject(ctx.Pull.BaseRepo.FullName, ctx.RepoRelDir), ctx.RepoLocking)
@@ -463,7 +498,7 @@ func (p *DefaultProjectCommandRunner) doPolicyCheck(ctx command.ProjectContext)
 	}
 
 	var failure string
-	outputs, err := p.runSteps(ctx.Steps, ctx, absPath)
+	outputs, _, err := p.runSteps(ctx.Steps, ctx, absPath)
 	var errs error
 	if err != nil {
 		for {
@@ -571,13 +606,18 @@ func (p *DefaultProjectCommandRunner) doPlan(ctx command.ProjectContext) (*model
 		return nil, failure, err
 	}
 
-	outputs, err := p.runSteps(ctx.Steps, ctx, projAbsPath)
+	planTimeout := ctx.PlanTimeout
+	if planTimeout == 0 {
+		planTimeout = p.DefaultPlanTimeout
+	}
+	ctx.Deadline = ctx.Deadline.Within(planTimeout)
+	outputs, stepOutputs, err := p.runSteps(ctx.Steps, ctx, projAbsPath)
 
 	if err != nil {
 		if unlockErr := lockAttempt.UnlockFn(); unlockErr != nil {
 			ctx.Log.Err("error unlocking state after plan error: %v", unlockErr)
 		}
-		return nil, "", fmt.Errorf("%s\n%s", err, strings.Join(outputs, "\n"))
+		return nil, "", fmt.Errorf("%w\n%s", err, strings.Join(outputs, "\n"))
 	}
 
 	return &models.PlanSuccess{
@@ -586,6 +626,7 @@ func (p *DefaultProjectCommandRunner) doPlan(ctx command.ProjectContext) (*model
 		RePlanCmd:       ctx.RePlanCmd,
 		ApplyCmd:        ctx.ApplyCmd,
 		MergedAgain:     mergedAgain,
+		StepOutputs:     stepOutputs,
 	}, "", nil
 }
 
@@ -619,7 +660,12 @@ func (p *DefaultProjectCommandRunner) doApply(ctx command.ProjectContext) (apply
 	}
 	defer unlockFn()
 
-	outputs, err := p.runSteps(ctx.Steps, ctx, absPath)
+	applyTimeout := ctx.ApplyTimeout
+	if applyTimeout == 0 {
+		applyTimeout = p.DefaultApplyTimeout
+	}
+	ctx.Deadline = ctx.Deadline.Within(applyTimeout)
+	outputs, _, err := p.runSteps(ctx.Steps, ctx, absPath)
 
 	p.Webhooks.Send(ctx.Log, webhooks.ApplyResult{ // nolint: errcheck
 		Workspace: ctx.Workspace,
@@ -631,7 +677,7 @@ func (p *DefaultProjectCommandRunner) doApply(ctx command.ProjectContext) (apply
 	})
 
 	if err != nil {
-		return "", "", fmt.Errorf("%s\n%s", err, strings.Join(outputs, "\n"))
+		return "", "", fmt.Errorf("%w\n%s", err, strings.Join(outputs, "\n"))
 	}
 
 	return strings.Join(outputs, "\n"), "", nil
@@ -657,7 +703,7 @@ func (p *DefaultProjectCommandRunner) doVersion(ctx command.ProjectContext) (ver
 	}
 	defer unlockFn()
 
-	outputs, err := p.runSteps(ctx.Steps, ctx, absPath)
+	outputs, _, err := p.runSteps(ctx.Steps, ctx, absPath)
 	if err != nil {
 		return "", "", fmt.Errorf("%s\n%s", err, strings.Join(outputs, "\n"))
 	}
@@ -698,7 +744,7 @@ func (p *DefaultProjectCommandRunner) doImport(ctx command.ProjectContext) (out
 	}
 	defer unlockFn()
 
-	outputs, err := p.runSteps(ctx.Steps, ctx, projAbsPath)
+	outputs, _, err := p.runSteps(ctx.Steps, ctx, projAbsPath)
 	if err != nil {
 		return nil, "", fmt.Errorf("%s\n%s", err, strings.Join(outputs, "\n"))
 	}
@@ -739,7 +785,7 @@ func (p *DefaultProjectCommandRunner) doStateRm(ctx command.ProjectContext) (out
 	}
 	defer unlockFn()
 
-	outputs, err := p.runSteps(ctx.Steps, ctx, projAbsPath)
+	outputs, _, err := p.runSteps(ctx.Steps, ctx, projAbsPath)
 	if err != nil {
 		return nil, "", fmt.Errorf("%s\n%s", err, strings.Join(outputs, "\n"))
 	}
@@ -752,48 +798,231 @@ func (p *DefaultProjectCommandRunner) doStateRm(ctx command.ProjectContext) (out
 	}, "", nil
 }
 
-func (p *DefaultProjectCommandRunner) runSteps(steps []valid.Step, ctx command.ProjectContext, absPath string) ([]string, error) {
+func (p *DefaultProjectCommandRunner) doCustom(ctx command.ProjectContext) (out *models.CustomCommandSuccess, failure string, err error) {
+	if ctx.CustomCommand == nil {
+		return nil, "", errors.New("no custom command set–this is a bug")
+	}
+	if len(ctx.Steps) == 0 {
+		return nil, fmt.Sprintf("The workflow of this project has no steps in its %q stage.", ctx.CustomCommand.Stage), nil
+	}
+
+	// Clone is idempotent so okay to run even if the repo was already cloned.
+	repoDir, _, cloneErr := p.WorkingDir.Clone(ctx.HeadRepo, ctx.Pull, ctx.Workspace)
+	if cloneErr != nil {
+		return nil, "", cloneErr
+	}
+	projAbsPath := filepath.Join(repoDir, ctx.RepoRelDir)
+	if _, err = os.Stat(projAbsPath); os.IsNotExist(err) {
+		return nil, "", DirNotExistErr{RepoRelDir: ctx.RepoRelDir}
+	}
+
+	failure, err = p.CommandRequirementHandler.ValidateCustomProject(repoDir, ctx)
+	if failure != "" || err != nil {
+		return nil, failure, err
+	}
+
+	var lockAttempt *TryLockResponse
+	if ctx.CustomCommand.RequireLock {
+		// Acquire Atlantis lock for this repo/dir/workspace.
+		lockAttempt, err = p.Locker.TryLock(ctx.Log, ctx.Pull, ctx.User, ctx.Workspace, models.NewProject(ctx.Pull.BaseRepo.FullName, ctx.RepoRelDir), ctx.RepoLocking)
+		if err != nil {
+			return nil, "", errors.Wrap(err, "acquiring lock")
+		}
+		if !lockAttempt.LockAcquired {
+			return nil, lockAttempt.LockFailureReason, nil
+		}
+		ctx.Log.Debug("acquired lock for project")
+	}
+
+	// Acquire internal lock for the directory we're going to operate in.
+	unlockFn, err := p.WorkingDirLocker.TryLock(ctx.Pull.BaseRepo.FullName, ctx.Pull.Num, ctx.Workspace, ctx.RepoRelDir)
+	if err != nil {
+		return nil, "", err
+	}
+	defer unlockFn()
+
+	outputs, _, err := p.runSteps(ctx.Steps, ctx, projAbsPath)
+	if err != nil {
+		// Only release a lock this command took, a lock the pull request
+		// already held protects an earlier plan.
+		if lockAttempt != nil && lockAttempt.LockCreated {
+			if unlockErr := lockAttempt.UnlockFn(); unlockErr != nil {
+				ctx.Log.Err("error unlocking state after %s error: %v", ctx.CustomCommand.Name, unlockErr)
+			}
+		}
+		return nil, "", fmt.Errorf("%w\n%s", err, strings.Join(outputs, "\n"))
+	}
+	return &models.CustomCommandSuccess{
+		Output: strings.Join(outputs, "\n"),
+	}, "", nil
+}
+
+// runSteps runs steps and returns what they printed and the outputs that run
+// steps wrote to their $ATLANTIS_OUTPUTS file.
+func (p *DefaultProjectCommandRunner) runSteps(steps []valid.Step, ctx command.ProjectContext, absPath string) ([]string, map[string]string, error) {
 	var outputs []string
+	var stepOutputs map[string]string
+
+	if p.Sandboxes != nil {
+		cloneDir, err := p.WorkingDir.GetWorkingDir(ctx.Pull.BaseRepo, ctx.Pull, ctx.Workspace)
+		if err != nil {
+			return nil, nil, err
+		}
+		sb, release, err := p.Sandboxes.Acquire(ctx.Log, cloneDir)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "creating sandbox")
+		}
+		defer release()
+		ctx.Sandbox = sb
+	}
 
 	envs := make(map[string]string)
+	projectDeadline := ctx.Deadline
+	conditionCtx := p.stepConditionContext(ctx)
 	for _, step := range steps {
+		if step.When != "" {
+			run, err := conditionCtx.Eval(step.When)
+			if err != nil {
+				return outputs, stepOutputs, errors.Wrapf(err, "evaluating when of step %q", step.StepName)
+			}
+			if !run {
+				ctx.Log.Info("skipped step %q because %q is false", step.StepName, step.When)
+				// Every output of a custom policy check is read as the result
+				// of a policy, so skipped steps are only logged there.
+				if ctx.CommandName != command.PolicyCheck || !ctx.CustomPolicyCheck {
+					outputs = append(outputs, fmt.Sprintf("skipped step %q (when: %s)", step.StepName, step.When))
+				}
+				conditionCtx.Steps = append(conditionCtx.Steps, valid.StepOutcome{Name: step.StepName, Outcome: valid.StepSkipped})
+				continue
+			}
+		}
+
 		var out string
+		var newStepOutputs map[string]string
 		var err error
-		switch step.StepName {
-		case "init":
-			out, err = p.InitStepRunner.Run(ctx, step.ExtraArgs, absPath, envs)
-		case "plan":
-			out, err = p.PlanStepRunner.Run(ctx, step.ExtraArgs, absPath, envs)
-		case "show":
-			_, err = p.ShowStepRunner.Run(ctx, step.ExtraArgs, absPath, envs)
-		case "policy_check":
-			out, err = p.PolicyCheckStepRunner.Run(ctx, step.ExtraArgs, absPath, envs)
-		case "apply":
-			out, err = p.ApplyStepRunner.Run(ctx, step.ExtraArgs, absPath, envs)
-		case "version":
-			out, err = p.VersionStepRunner.Run(ctx, step.ExtraArgs, absPath, envs)
-		case "import":
-			out, err = p.ImportStepRunner.Run(ctx, step.ExtraArgs, absPath, envs)
-		case "state_rm":
-			out, err = p.StateRmStepRunner.Run(ctx, step.ExtraArgs, absPath, envs)
-		case "run":
-			out, err = p.RunStepRunner.Run(ctx, step.RunCommand, absPath, envs, true, step.Output)
-		case "env":
-			out, err = p.EnvStepRunner.Run(ctx, step.RunCommand, step.EnvVarValue, absPath, envs)
-			envs[step.EnvVarName] = out
-			// We reset out to the empty string because we don't want it to
-			// be printed to the PR, it's solely to set the environment variable.
-			out = ""
-		case "multienv":
-			out, err = p.MultiEnvStepRunner.Run(ctx, step.RunCommand, absPath, envs)
+		for attempt := 1; ; attempt++ {
+			ctx.Deadline = projectDeadline.Within(step.Timeout)
+			out, newStepOutputs, err = p.runStep(ctx, step, absPath, envs)
+			if err == nil || !step.Retry.ShouldRetry(attempt, out+"\n"+err.Error()) {
+				break
+			}
+			// Steps that timed out would most likely time out again.
+			var timeoutErr command.TimeoutError
+			if errors.As(err, &timeoutErr) {
+				break
+			}
+			delay := step.Retry.DelayBefore(attempt + 1)
+			if !projectDeadline.At.IsZero() && time.Now().Add(delay).After(projectDeadline.At) {
+				ctx.Log.Warn("not retrying step %q because the %s would time out first", step.StepName, ctx.CommandName)
+				break
+			}
+			msg := fmt.Sprintf("step %q failed on attempt %d/%d, retrying in %s", step.StepName, attempt, step.Retry.Attempts, delay)
+			ctx.Log.Warn("%s: %s", msg, err)
+			if p.JobMessageSender != nil {
+				p.JobMessageSender.Send(ctx, fmt.Sprintf("Atlantis: %s", msg), false)
+			}
+			if !projectDeadline.Sleep(delay) {
+				break
+			}
 		}
 
 		if out != "" {
 			outputs = append(outputs, out)
 		}
 		if err != nil {
-			return outputs, err
+			return outputs, stepOutputs, err
+		}
+		// Outputs are passed to later steps as environment variables.
+		for name, value := range newStepOutputs {
+			if stepOutputs == nil {
+				stepOutputs = make(map[string]string)
+			}
+			stepOutputs[name] = value
+			envs[name] = value
+		}
+		conditionCtx.Steps = append(conditionCtx.Steps, valid.StepOutcome{Name: step.StepName, Outcome: valid.StepSucceeded, Output: out})
+	}
+	return outputs, stepOutputs, nil
+}
+
+// stepConditionContext returns what the when expressions of steps can read.
+func (p *DefaultProjectCommandRunner) stepConditionContext(ctx command.ProjectContext) valid.StepConditionContext {
+	return valid.StepConditionContext{
+		Workspace:   ctx.Workspace,
+		ProjectName: ctx.ProjectName,
+		RepoRelDir:  ctx.RepoRelDir,
+		BaseBranch:  ctx.Pull.BaseBranch,
+		HeadBranch:  ctx.Pull.HeadBranch,
+		CommentArgs: unescapeArgs(ctx.EscapedCommentArgs),
+		PullLabels: func() ([]string, error) {
+			if ctx.PullLabels != nil {
+				return ctx.PullLabels, nil
+			}
+			return p.VcsClient.GetPullLabels(ctx.Pull.BaseRepo, ctx.Pull)
+		},
+	}
+}
+
+// runStep runs a single attempt of step. It returns what the step printed
+// and, for run steps, the outputs it wrote to its $ATLANTIS_OUTPUTS file.
+func (p *DefaultProjectCommandRunner) runStep(ctx command.ProjectContext, step valid.Step, absPath string, envs map[string]string) (string, map[string]string, error) {
+	var out string
+	var err error
+	switch step.StepName {
+	case "init":
+		out, err = p.InitStepRunner.Run(ctx, step.ExtraArgs, absPath, envs)
+	case "plan":
+		out, err = p.PlanStepRunner.Run(ctx, step.ExtraArgs, absPath, envs)
+	case "show":
+		_, err = p.ShowStepRunner.Run(ctx, step.ExtraArgs, absPath, envs)
+	case "policy_check":
+		out, err = p.PolicyCheckStepRunner.Run(ctx, step.ExtraArgs, absPath, envs)
+	case "apply":
+		out, err = p.ApplyStepRunner.Run(ctx, step.ExtraArgs, absPath, envs)
+	case "version":
+		out, err = p.VersionStepRunner.Run(ctx, step.ExtraArgs, absPath, envs)
+	case "import":
+		out, err = p.ImportStepRunner.Run(ctx, step.ExtraArgs, absPath, envs)
+	case "state_rm":
+		out, err = p.StateRmStepRunner.Run(ctx, step.ExtraArgs, absPath, envs)
+	case "run":
+		return p.runRunStep(ctx, step, absPath, envs)
+	case "env":
+		out, err = p.EnvStepRunner.Run(ctx, step.RunCommand, step.EnvVarValue, absPath, envs)
+		envs[step.EnvVarName] = out
+		// We reset out to the empty string because we don't want it to
+		// be printed to the PR, it's solely to set the environment variable.
+		out = ""
+	case "multienv":
+		out, err = p.MultiEnvStepRunner.Run(ctx, step.RunCommand, absPath, envs)
+	}
+	return out, nil, err
+}
+
+// runRunStep runs a run step with $ATLANTIS_OUTPUTS set to a file it can
+// write outputs to.
+func (p *DefaultProjectCommandRunner) runRunStep(ctx command.ProjectContext, step valid.Step, absPath string, envs map[string]string) (string, map[string]string, error) {
+	outputsFile := filepath.Join(absPath, ctx.GetStepOutputsFileName())
+	if err := os.WriteFile(outputsFile, nil, 0600); err != nil {
+		return "", nil, errors.Wrapf(err, "creating $%s file", runtime.StepOutputsEnvVar)
+	}
+	defer os.Remove(outputsFile) // nolint: errcheck
+	if ctx.Sandbox != nil {
+		if err := os.Chown(outputsFile, ctx.Sandbox.UID, ctx.Sandbox.GID); err != nil {
+			return "", nil, errors.Wrapf(err, "changing owner of $%s file", runtime.StepOutputsEnvVar)
 		}
 	}
-	return outputs, nil
+
+	// Copy envs so that later steps don't get this step's outputs file.
+	runEnvs := make(map[string]string)
+	for k, v := range envs {
+		runEnvs[k] = v
+	}
+	runEnvs[runtime.StepOutputsEnvVar] = outputsFile
+	out, err := p.RunStepRunner.Run(ctx, step.RunCommand, absPath, runEnvs, true, step.Output)
+	if err != nil {
+		return out, nil, err
+	}
+	stepOutputs, err := runtime.ReadStepOutputs(outputsFile)
+	return out, stepOutputs, err
 }
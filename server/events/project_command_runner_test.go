@@ -17,7 +17,11 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/go-version"
 	. "github.com/petergtz/pegomock/v4"
@@ -83,6 +87,11 @@ func TestDefaultProjectCommandRunner_Plan(t *testing.T) {
 	expEnvs := map[string]string{
 		"name": "value",
 	}
+	// Run steps also get the path of the file to write their outputs to.
+	expRunEnvs := map[string]string{
+		"name":             "value",
+		"ATLANTIS_OUTPUTS": filepath.Join(repoDir, "default-outputs"),
+	}
 	ctx := command.ProjectContext{
 		Log: logging.NewNoopLogger(t),
 		Steps: []valid.Step{
@@ -111,7 +120,7 @@ func TestDefaultProjectCommandRunner_Plan(t *testing.T) {
 	When(mockInit.Run(ctx, nil, repoDir, expEnvs)).ThenReturn("init", nil)
 	When(mockPlan.Run(ctx, nil, repoDir, expEnvs)).ThenReturn("plan", nil)
 	When(mockApply.Run(ctx, nil, repoDir, expEnvs)).ThenReturn("apply", nil)
-	When(mockRun.Run(ctx, "", repoDir, expEnvs, true, "")).ThenReturn("run", nil)
+	When(mockRun.Run(ctx, "", repoDir, expRunEnvs, true, "")).ThenReturn("run", nil)
 	res := runner.Plan(ctx)
 
 	Assert(t, res.PlanSuccess != nil, "exp plan success")
@@ -127,7 +136,7 @@ func TestDefaultProjectCommandRunner_Plan(t *testing.T) {
 		case "apply":
 			mockApply.VerifyWasCalledOnce().Run(ctx, nil, repoDir, expEnvs)
 		case "run":
-			mockRun.VerifyWasCalledOnce().Run(ctx, "", repoDir, expEnvs, true, "")
+			mockRun.VerifyWasCalledOnce().Run(ctx, "", repoDir, expRunEnvs, true, "")
 		}
 	}
 }
@@ -453,10 +462,14 @@ func TestDefaultProjectCommandRunner_Apply(t *testing.T) {
 			expEnvs := map[string]string{
 				"key": "value",
 			}
+			expRunEnvs := map[string]string{
+				"key":              "value",
+				"ATLANTIS_OUTPUTS": filepath.Join(repoDir, "default-outputs"),
+			}
 			When(mockInit.Run(ctx, nil, repoDir, expEnvs)).ThenReturn("init", nil)
 			When(mockPlan.Run(ctx, nil, repoDir, expEnvs)).ThenReturn("plan", nil)
 			When(mockApply.Run(ctx, nil, repoDir, expEnvs)).ThenReturn("apply", nil)
-			When(mockRun.Run(ctx, "", repoDir, expEnvs, true, "")).ThenReturn("run", nil)
+			When(mockRun.Run(ctx, "", repoDir, expRunEnvs, true, "")).ThenReturn("run", nil)
 			When(mockEnv.Run(ctx, "", "value", repoDir, make(map[string]string))).ThenReturn("value", nil)
 
 			res := runner.Apply(ctx)
@@ -472,7 +485,7 @@ func TestDefaultProjectCommandRunner_Apply(t *testing.T) {
 				case "apply":
 					mockApply.VerifyWasCalledOnce().Run(ctx, nil, repoDir, expEnvs)
 				case "run":
-					mockRun.VerifyWasCalledOnce().Run(ctx, "", repoDir, expEnvs, true, "")
+					mockRun.VerifyWasCalledOnce().Run(ctx, "", repoDir, expRunEnvs, true, "")
 				case "env":
 					mockEnv.VerifyWasCalledOnce().Run(ctx, "", "value", repoDir, expEnvs)
 				}
@@ -623,6 +636,294 @@ func TestDefaultProjectCommandRunner_RunEnvSteps(t *testing.T) {
 }
 
 // Test that it runs the expected import steps.
+func TestDefaultProjectCommandRunner_PlanTimeout(t *testing.T) {
+	cases := []struct {
+		description string
+		stepTimeout time.Duration
+		planTimeout time.Duration
+		expErr      string
+	}{
+		{
+			description: "step timeout",
+			stepTimeout: 300 * time.Millisecond,
+			planTimeout: time.Hour,
+			expErr:      "timed out after 300ms",
+		},
+		{
+			description: "plan timeout",
+			planTimeout: 300 * time.Millisecond,
+			expErr:      "timed out after 300ms",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			RegisterMockTestingT(t)
+			tfClient := tmocks.NewMockClient()
+			tfVersion, err := version.NewVersion("0.12.0")
+			Ok(t, err)
+			run := runtime.RunStepRunner{
+				TerraformExecutor:       tfClient,
+				DefaultTFVersion:        tfVersion,
+				ProjectCmdOutputHandler: jobmocks.NewMockProjectCommandOutputHandler(),
+			}
+			mockWorkingDir := mocks.NewMockWorkingDir()
+			mockLocker := mocks.NewMockProjectLocker()
+			runner := events.DefaultProjectCommandRunner{
+				Locker:                    mockLocker,
+				LockURLGenerator:          mockURLGenerator{},
+				RunStepRunner:             &run,
+				WorkingDir:                mockWorkingDir,
+				WorkingDirLocker:          events.NewDefaultWorkingDirLocker(),
+				CommandRequirementHandler: mocks.NewMockCommandRequirementHandler(),
+				DefaultPlanTimeout:        c.planTimeout,
+			}
+			When(mockWorkingDir.Clone(Any[models.Repo](), Any[models.PullRequest](), Any[string]())).
+				ThenReturn(t.TempDir(), false, nil)
+			When(mockLocker.TryLock(Any[logging.SimpleLogging](), Any[models.PullRequest](), Any[models.User](), Any[string](), Any[models.Project](), AnyBool())).
+				ThenReturn(&events.TryLockResponse{
+					LockAcquired: true,
+					LockKey:      "lock-key",
+					UnlockFn:     func() error { return nil },
+				}, nil)
+
+			start := time.Now()
+			res := runner.Plan(command.ProjectContext{
+				Log: logging.NewNoopLogger(t),
+				Steps: []valid.Step{
+					{StepName: "run", RunCommand: "echo before"},
+					{StepName: "run", RunCommand: "sleep 30", Timeout: c.stepTimeout},
+				},
+				Workspace:  "default",
+				RepoRelDir: ".",
+			})
+			Assert(t, time.Since(start) < 10*time.Second, "exp step to be killed")
+			Assert(t, res.PlanSuccess == nil, "exp plan to fail")
+			ErrContains(t, c.expErr, res.Error)
+			ErrContains(t, "before", res.Error)
+		})
+	}
+}
+
+func TestDefaultProjectCommandRunner_StepOutputs(t *testing.T) {
+	RegisterMockTestingT(t)
+	tfVersion, err := version.NewVersion("0.12.0")
+	Ok(t, err)
+	run := runtime.RunStepRunner{
+		TerraformExecutor:       tmocks.NewMockClient(),
+		DefaultTFVersion:        tfVersion,
+		ProjectCmdOutputHandler: jobmocks.NewMockProjectCommandOutputHandler(),
+	}
+	mockWorkingDir := mocks.NewMockWorkingDir()
+	mockLocker := mocks.NewMockProjectLocker()
+	runner := events.DefaultProjectCommandRunner{
+		Locker:                    mockLocker,
+		LockURLGenerator:          mockURLGenerator{},
+		RunStepRunner:             &run,
+		WorkingDir:                mockWorkingDir,
+		WorkingDirLocker:          events.NewDefaultWorkingDirLocker(),
+		CommandRequirementHandler: mocks.NewMockCommandRequirementHandler(),
+	}
+	repoDir := t.TempDir()
+	When(mockWorkingDir.Clone(Any[models.Repo](), Any[models.PullRequest](), Any[string]())).
+		ThenReturn(repoDir, false, nil)
+	When(mockLocker.TryLock(Any[logging.SimpleLogging](), Any[models.PullRequest](), Any[models.User](), Any[string](), Any[models.Project](), AnyBool())).
+		ThenReturn(&events.TryLockResponse{
+			LockAcquired: true,
+			LockKey:      "lock-key",
+			UnlockFn:     func() error { return nil },
+		}, nil)
+
+	res := runner.Plan(command.ProjectContext{
+		Log: logging.NewNoopLogger(t),
+		Steps: []valid.Step{
+			{StepName: "run", RunCommand: `echo COST=42 >> "$ATLANTIS_OUTPUTS"`},
+			{StepName: "run", RunCommand: `echo '{"FINDINGS": 3}' > "$ATLANTIS_OUTPUTS"`},
+			{StepName: "run", RunCommand: `echo "cost $COST, findings $FINDINGS, outputs file '$ATLANTIS_OUTPUTS'"`},
+		},
+		Workspace:  "default",
+		RepoRelDir: ".",
+	})
+	Ok(t, res.Error)
+	Equals(t, "cost 42, findings 3, outputs file '"+filepath.Join(repoDir, "default-outputs")+"'", strings.TrimSpace(res.PlanSuccess.TerraformOutput))
+	Equals(t, map[string]string{"COST": "42", "FINDINGS": "3"}, res.PlanSuccess.StepOutputs)
+	_, err = os.Stat(filepath.Join(repoDir, "default-outputs"))
+	Assert(t, os.IsNotExist(err), "exp outputs file to be removed")
+}
+
+func TestDefaultProjectCommandRunner_Retry(t *testing.T) {
+	throttled := errors.New("exit status 1: Error: 429 Too Many Requests")
+	cases := []struct {
+		description string
+		retry       *valid.StepRetry
+		initErrs    []error
+		expCalls    int
+		expRetries  int
+		expErr      error
+	}{
+		{
+			description: "succeeds after retrying",
+			retry:       &valid.StepRetry{Attempts: 3, OnOutputRegex: regexp.MustCompile("429")},
+			initErrs:    []error{throttled, throttled, nil},
+			expCalls:    3,
+			expRetries:  2,
+		},
+		{
+			description: "fails once out of attempts",
+			retry:       &valid.StepRetry{Attempts: 2},
+			initErrs:    []error{throttled, throttled, nil},
+			expCalls:    2,
+			expRetries:  1,
+			expErr:      throttled,
+		},
+		{
+			description: "doesn't retry if output doesn't match",
+			retry:       &valid.StepRetry{Attempts: 3, OnOutputRegex: regexp.MustCompile("timeout")},
+			initErrs:    []error{throttled, nil},
+			expCalls:    1,
+			expErr:      throttled,
+		},
+		{
+			description: "doesn't retry steps that timed out",
+			retry:       &valid.StepRetry{Attempts: 3},
+			initErrs:    []error{fmt.Errorf("%w: running init", command.TimeoutError{Timeout: time.Minute}), nil},
+			expCalls:    1,
+			expErr:      errors.New("timed out after 1m0s"),
+		},
+		{
+			description: "doesn't retry without a retry policy",
+			initErrs:    []error{throttled, nil},
+			expCalls:    1,
+			expErr:      throttled,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			RegisterMockTestingT(t)
+			mockInit := mocks.NewMockStepRunner()
+			mockPlan := mocks.NewMockStepRunner()
+			mockSender := mocks.NewMockJobMessageSender()
+			mockWorkingDir := mocks.NewMockWorkingDir()
+			mockLocker := mocks.NewMockProjectLocker()
+			runner := events.DefaultProjectCommandRunner{
+				Locker:                    mockLocker,
+				LockURLGenerator:          mockURLGenerator{},
+				InitStepRunner:            mockInit,
+				PlanStepRunner:            mockPlan,
+				WorkingDir:                mockWorkingDir,
+				WorkingDirLocker:          events.NewDefaultWorkingDirLocker(),
+				CommandRequirementHandler: mocks.NewMockCommandRequirementHandler(),
+				JobMessageSender:          mockSender,
+			}
+			repoDir := t.TempDir()
+			When(mockWorkingDir.Clone(Any[models.Repo](), Any[models.PullRequest](), Any[string]())).
+				ThenReturn(repoDir, false, nil)
+			When(mockLocker.TryLock(Any[logging.SimpleLogging](), Any[models.PullRequest](), Any[models.User](), Any[string](), Any[models.Project](), AnyBool())).
+				ThenReturn(&events.TryLockResponse{
+					LockAcquired: true,
+					LockKey:      "lock-key",
+					UnlockFn:     func() error { return nil },
+				}, nil)
+			initStub := When(mockInit.Run(Any[command.ProjectContext](), Any[[]string](), Eq(repoDir), Any[map[string]string]()))
+			for _, err := range c.initErrs {
+				initStub = initStub.ThenReturn("", err)
+			}
+			When(mockPlan.Run(Any[command.ProjectContext](), Any[[]string](), Eq(repoDir), Any[map[string]string]())).
+				ThenReturn("plan", nil)
+
+			res := runner.Plan(command.ProjectContext{
+				Log: logging.NewNoopLogger(t),
+				Steps: []valid.Step{
+					{StepName: "init", Retry: c.retry},
+					{StepName: "plan"},
+				},
+				Workspace:  "default",
+				RepoRelDir: ".",
+			})
+			mockInit.VerifyWasCalled(Times(c.expCalls)).Run(Any[command.ProjectContext](), Any[[]string](), Eq(repoDir), Any[map[string]string]())
+			mockSender.VerifyWasCalled(Times(c.expRetries)).Send(Any[command.ProjectContext](), Any[string](), Eq(false))
+			if c.expErr != nil {
+				ErrContains(t, c.expErr.Error(), res.Error)
+				return
+			}
+			Ok(t, res.Error)
+			Equals(t, "plan", res.PlanSuccess.TerraformOutput)
+		})
+	}
+}
+
+func TestDefaultProjectCommandRunner_When(t *testing.T) {
+	RegisterMockTestingT(t)
+	mockInit := mocks.NewMockStepRunner()
+	mockPlan := mocks.NewMockStepRunner()
+	mockRun := mocks.NewMockCustomStepRunner()
+	mockWorkingDir := mocks.NewMockWorkingDir()
+	mockLocker := mocks.NewMockProjectLocker()
+	runner := events.DefaultProjectCommandRunner{
+		Locker:                    mockLocker,
+		LockURLGenerator:          mockURLGenerator{},
+		InitStepRunner:            mockInit,
+		PlanStepRunner:            mockPlan,
+		RunStepRunner:             mockRun,
+		WorkingDir:                mockWorkingDir,
+		WorkingDirLocker:          events.NewDefaultWorkingDirLocker(),
+		CommandRequirementHandler: mocks.NewMockCommandRequirementHandler(),
+	}
+	repoDir := t.TempDir()
+	When(mockWorkingDir.Clone(Any[models.Repo](), Any[models.PullRequest](), Any[string]())).
+		ThenReturn(repoDir, false, nil)
+	When(mockLocker.TryLock(Any[logging.SimpleLogging](), Any[models.PullRequest](), Any[models.User](), Any[string](), Any[models.Project](), AnyBool())).
+		ThenReturn(&events.TryLockResponse{
+			LockAcquired: true,
+			LockKey:      "lock-key",
+			UnlockFn:     func() error { return nil },
+		}, nil)
+	When(mockInit.Run(Any[command.ProjectContext](), Any[[]string](), Eq(repoDir), Any[map[string]string]())).
+		ThenReturn("init", nil)
+	When(mockPlan.Run(Any[command.ProjectContext](), Any[[]string](), Eq(repoDir), Any[map[string]string]())).
+		ThenReturn("plan", nil)
+	When(mockRun.Run(Any[command.ProjectContext](), Any[string](), Eq(repoDir), Any[map[string]string](), AnyBool(), Any[valid.PostProcessRunOutputOption]())).
+		ThenReturn("ran", nil)
+
+	log := logging.NewNoopLogger(t).WithHistory()
+	res := runner.Plan(command.ProjectContext{
+		Log: log,
+		Steps: []valid.Step{
+			{StepName: "init", When: `workspace == "prod"`},
+			{StepName: "plan", When: `contains(comment_args, "-lock=false")`},
+			{StepName: "run", RunCommand: "echo ran", When: `steps[0].outcome == "skipped" && steps[1].outcome == "success"`},
+		},
+		Workspace:          "default",
+		RepoRelDir:         ".",
+		EscapedCommentArgs: []string{"\\-\\l\\o\\c\\k\\=\\f\\a\\l\\s\\e"},
+	})
+	Ok(t, res.Error)
+	Equals(t, "skipped step \"init\" (when: workspace == \"prod\")\nplan\nran", res.PlanSuccess.TerraformOutput)
+	mockInit.VerifyWasCalled(Never()).Run(Any[command.ProjectContext](), Any[[]string](), Any[string](), Any[map[string]string]())
+	Assert(t, strings.Contains(log.GetHistory(), `skipped step "init" because "workspace == \"prod\"" is false`), "exp skipped step in history")
+
+	t.Log("skipped steps are shown in the pull request comment")
+	renderer := events.NewMarkdownRenderer(false, false, false, false, false, false, "", "atlantis", false)
+	rendered := renderer.Render(command.Result{ProjectResults: []command.ProjectResult{res}}, command.Plan, "", log.GetHistory(), false, models.Github)
+	Assert(t, strings.Contains(rendered, `skipped step "init" (when: workspace == "prod")`), "exp skipped step in %q", rendered)
+}
+
+func TestStepRetry_DelayBefore(t *testing.T) {
+	constant := valid.StepRetry{Attempts: 4, Delay: time.Second, Backoff: valid.ConstantBackoff}
+	Equals(t, time.Second, constant.DelayBefore(2))
+	Equals(t, time.Second, constant.DelayBefore(4))
+
+	exponential := valid.StepRetry{Attempts: 4, Delay: time.Second, Backoff: valid.ExponentialBackoff}
+	Equals(t, time.Second, exponential.DelayBefore(2))
+	Equals(t, 2*time.Second, exponential.DelayBefore(3))
+	Equals(t, 4*time.Second, exponential.DelayBefore(4))
+
+	t.Log("exponential delays are capped")
+	Equals(t, valid.MaxStepRetryDelay, exponential.DelayBefore(12))
+	Equals(t, valid.MaxStepRetryDelay, exponential.DelayBefore(1000))
+	long := valid.StepRetry{Attempts: 4, Delay: valid.MaxStepRetryDelay, Backoff: valid.ExponentialBackoff}
+	Equals(t, valid.MaxStepRetryDelay, long.DelayBefore(3))
+}
+
 func TestDefaultProjectCommandRunner_Import(t *testing.T) {
 	expEnvs := map[string]string{}
 	cases := []struct {
@@ -739,6 +1040,166 @@ func TestDefaultProjectCommandRunner_Import(t *testing.T) {
 	}
 }
 
+func TestDefaultProjectCommandRunner_Custom(t *testing.T) {
+	expEnvs := map[string]string{}
+	cases := []struct {
+		description   string
+		steps         []valid.Step
+		customCmd     valid.CustomCommand
+		lockAcquired  bool
+		lockCreated   bool
+		pullReqStatus models.PullReqStatus
+		initErr       error
+
+		expOut      *models.CustomCommandSuccess
+		expFailure  string
+		expErr      string
+		expLockUsed bool
+		expUnlocked bool
+		expTimeout  bool
+	}{
+		{
+			description: "runs the steps without a lock",
+			steps:       []valid.Step{{StepName: "init"}},
+			customCmd:   valid.CustomCommand{Name: "validate", Stage: "validate"},
+			expOut:      &models.CustomCommandSuccess{Output: "init"},
+		},
+		{
+			description:  "lock required",
+			steps:        []valid.Step{{StepName: "init"}},
+			customCmd:    valid.CustomCommand{Name: "validate", Stage: "validate", RequireLock: true},
+			lockAcquired: true,
+			expOut:       &models.CustomCommandSuccess{Output: "init"},
+			expLockUsed:  true,
+		},
+		{
+			description:  "lock released when a step fails",
+			steps:        []valid.Step{{StepName: "init"}},
+			customCmd:    valid.CustomCommand{Name: "validate", Stage: "validate", RequireLock: true},
+			lockAcquired: true,
+			lockCreated:  true,
+			initErr:      errors.New("init failed"),
+			expErr:       "init failed\ninit",
+			expLockUsed:  true,
+			expUnlocked:  true,
+		},
+		{
+			description:  "lock the pull request already held is kept when a step fails",
+			steps:        []valid.Step{{StepName: "init"}},
+			customCmd:    valid.CustomCommand{Name: "validate", Stage: "validate", RequireLock: true},
+			lockAcquired: true,
+			initErr:      errors.New("init failed"),
+			expErr:       "init failed\ninit",
+			expLockUsed:  true,
+		},
+		{
+			description: "timeouts are kept in the error",
+			steps:       []valid.Step{{StepName: "init"}},
+			customCmd:   valid.CustomCommand{Name: "validate", Stage: "validate"},
+			initErr:     command.TimeoutError{Timeout: time.Minute},
+			expErr:      "timed out after 1m0s\ninit",
+			expTimeout:  true,
+		},
+		{
+			description: "lock held by another pull request",
+			steps:       []valid.Step{{StepName: "init"}},
+			customCmd:   valid.CustomCommand{Name: "validate", Stage: "validate", RequireLock: true},
+			expFailure:  "locked",
+			expLockUsed: true,
+		},
+		{
+			description: "approval required",
+			steps:       []valid.Step{{StepName: "init"}},
+			customCmd:   valid.CustomCommand{Name: "validate", Stage: "validate", Requirements: []string{"approved"}},
+			expFailure:  "Pull request must be approved according to the project's approval rules before running validate.",
+		},
+		{
+			description: "stage without steps",
+			customCmd:   valid.CustomCommand{Name: "validate", Stage: "validate"},
+			expFailure:  `The workflow of this project has no steps in its "validate" stage.`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			RegisterMockTestingT(t)
+			mockInit := mocks.NewMockStepRunner()
+			mockWorkingDir := mocks.NewMockWorkingDir()
+			mockLocker := mocks.NewMockProjectLocker()
+
+			runner := events.DefaultProjectCommandRunner{
+				Locker:           mockLocker,
+				LockURLGenerator: mockURLGenerator{},
+				InitStepRunner:   mockInit,
+				WorkingDir:       mockWorkingDir,
+				WorkingDirLocker: events.NewDefaultWorkingDirLocker(),
+				CommandRequirementHandler: &events.DefaultCommandRequirementHandler{
+					WorkingDir: mockWorkingDir,
+				},
+			}
+			ctx := command.ProjectContext{
+				Log:           logging.NewNoopLogger(t),
+				CommandName:   command.RegisterCustom(c.customCmd.Name),
+				CustomCommand: &c.customCmd,
+				Steps:         c.steps,
+				Workspace:     "default",
+				RepoRelDir:    ".",
+				PullReqStatus: c.pullReqStatus,
+			}
+			repoDir := t.TempDir()
+			unlocked := false
+			When(mockWorkingDir.Clone(
+				Any[models.Repo](),
+				Any[models.PullRequest](),
+				Any[string](),
+			)).ThenReturn(repoDir, false, nil)
+			When(mockLocker.TryLock(
+				Any[logging.SimpleLogging](),
+				Any[models.PullRequest](),
+				Any[models.User](),
+				Any[string](),
+				Any[models.Project](),
+				AnyBool(),
+			)).ThenReturn(&events.TryLockResponse{
+				LockAcquired:      c.lockAcquired,
+				LockCreated:       c.lockCreated,
+				LockFailureReason: "locked",
+				UnlockFn: func() error {
+					unlocked = true
+					return nil
+				},
+			}, nil)
+			When(mockInit.Run(ctx, nil, repoDir, expEnvs)).ThenReturn("init", c.initErr)
+
+			res := runner.Custom(ctx)
+			Equals(t, c.expOut, res.CustomCommandSuccess)
+			Equals(t, c.expFailure, res.Failure)
+			if c.expErr != "" {
+				ErrEquals(t, c.expErr, res.Error)
+			} else {
+				Ok(t, res.Error)
+			}
+			Equals(t, c.expUnlocked, unlocked)
+			var timeoutErr command.TimeoutError
+			Equals(t, c.expTimeout, errors.As(res.Error, &timeoutErr))
+			Equals(t, "validate", res.Command.String())
+
+			expLockCalls := Never()
+			if c.expLockUsed {
+				expLockCalls = Once()
+			}
+			mockLocker.VerifyWasCalled(expLockCalls).TryLock(
+				Any[logging.SimpleLogging](),
+				Any[models.PullRequest](),
+				Any[models.User](),
+				Any[string](),
+				Any[models.Project](),
+				AnyBool(),
+			)
+		})
+	}
+}
+
 type mockURLGenerator struct{}
 
 func (m mockURLGenerator) GenerateLockURL(lockID string) string {
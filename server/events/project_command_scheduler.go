@@ -0,0 +1,259 @@
+package events
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/metrics"
+	tally "github.com/uber-go/tally/v4"
+)
+
+// ExecutionPriority is the priority of a project command waiting for an
+// execution slot. Higher priorities run first.
+type ExecutionPriority int
+
+const (
+	// AutoplanPriority is the priority of commands triggered automatically
+	// when a pull request is opened or updated.
+	AutoplanPriority ExecutionPriority = iota
+	// PlanPriority is the priority of commands users comment, like plan.
+	PlanPriority
+	// ApplyPriority is the priority of commands that change state, like
+	// apply, and of commands run when a pull request is merged.
+	ApplyPriority
+)
+
+func (p ExecutionPriority) String() string {
+	switch p {
+	case AutoplanPriority:
+		return "autoplan"
+	case PlanPriority:
+		return "plan"
+	case ApplyPriority:
+		return "apply"
+	}
+	return fmt.Sprintf("%d", p)
+}
+
+// executionPriority returns the priority of the command described by ctx.
+func executionPriority(ctx command.ProjectContext) ExecutionPriority {
+	switch {
+	case ctx.Trigger == command.MergeTrigger:
+		return ApplyPriority
+	case ctx.CommandName == command.Apply || ctx.CommandName == command.Import || ctx.CommandName == command.State:
+		return ApplyPriority
+	case ctx.Trigger == command.AutoTrigger:
+		return AutoplanPriority
+	}
+	return PlanPriority
+}
+
+// ExecutionScheduler limits how many project commands run at once, across all
+// pull requests, in total and per repo. Commands that can't run yet wait in a
+// queue ordered by priority and then by when they were queued.
+type ExecutionScheduler struct {
+	// maxConcurrent is how many commands can run at once. If 0, there's no
+	// limit.
+	maxConcurrent int
+	// repoLimit returns how many commands for a repo can run at once, or 0 if
+	// there's no limit.
+	repoLimit func(repoID string) int
+	scope     tally.Scope
+
+	mu            sync.Mutex
+	running       int
+	runningByRepo map[string]int
+	queue         []*queuedExecution
+	// seq orders commands with the same priority by when they were queued.
+	seq uint64
+}
+
+type queuedExecution struct {
+	repoID   string
+	priority ExecutionPriority
+	seq      uint64
+	// ready is closed once the command can run.
+	ready chan struct{}
+	// position is the 1-based position of the command in the queue, or 0
+	// once it can run.
+	position int
+	// moved is signaled when position changes.
+	moved chan struct{}
+}
+
+// NewExecutionScheduler returns a scheduler that runs at most maxConcurrent
+// commands at once, and at most repoLimit(repoID) commands for each repo.
+// A limit of 0 means there's no limit.
+func NewExecutionScheduler(maxConcurrent int, repoLimit func(repoID string) int, scope tally.Scope) *ExecutionScheduler {
+	scope = scope.SubScope("scheduler")
+	scope.Gauge(metrics.SchedulerQueueDepthMetric).Update(0)
+	scope.Gauge(metrics.SchedulerRunningMetric).Update(0)
+	return &ExecutionScheduler{
+		maxConcurrent: maxConcurrent,
+		repoLimit:     repoLimit,
+		scope:         scope,
+		runningByRepo: make(map[string]int),
+	}
+}
+
+// Acquire waits until a command for the repo with id repoID can run and
+// returns a function that must be called once it's done. If the command has to
+// wait, onQueued is called with its 1-based position in the queue, and again
+// each time the position changes while it waits. onQueued is called on the
+// goroutine that called Acquire.
+func (s *ExecutionScheduler) Acquire(repoID string, priority ExecutionPriority, onQueued func(position int)) func() {
+	start := time.Now()
+	s.mu.Lock()
+	s.seq++
+	e := &queuedExecution{repoID: repoID, priority: priority, seq: s.seq, ready: make(chan struct{}), moved: make(chan struct{}, 1)}
+	s.queue = append(s.queue, e)
+	sort.SliceStable(s.queue, func(i, j int) bool {
+		if s.queue[i].priority != s.queue[j].priority {
+			return s.queue[i].priority > s.queue[j].priority
+		}
+		return s.queue[i].seq < s.queue[j].seq
+	})
+	s.schedule()
+	s.mu.Unlock()
+
+	s.wait(e, onQueued)
+	s.scope.Tagged(map[string]string{"priority": priority.String()}).Timer(metrics.SchedulerWaitTimeMetric).Record(time.Since(start))
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { s.release(repoID) })
+	}
+}
+
+// wait blocks until e can run, calling onQueued with its position each time
+// the position changes in the meantime.
+func (s *ExecutionScheduler) wait(e *queuedExecution, onQueued func(position int)) {
+	reported := 0
+	for {
+		select {
+		case <-e.ready:
+			return
+		case <-e.moved:
+		}
+		s.mu.Lock()
+		position := e.position
+		s.mu.Unlock()
+		if position > 0 && position != reported && onQueued != nil {
+			onQueued(position)
+			reported = position
+		}
+	}
+}
+
+func (s *ExecutionScheduler) release(repoID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.running--
+	s.runningByRepo[repoID]--
+	if s.runningByRepo[repoID] == 0 {
+		delete(s.runningByRepo, repoID)
+	}
+	s.schedule()
+}
+
+// schedule starts the queued commands that can run, in order. A command that
+// can't run because its repo is at its limit doesn't hold up commands for
+// other repos. It must be called with mu locked.
+func (s *ExecutionScheduler) schedule() {
+	remaining := s.queue[:0]
+	for _, e := range s.queue {
+		if s.maxConcurrent > 0 && s.running >= s.maxConcurrent {
+			remaining = append(remaining, e)
+			continue
+		}
+		if limit := s.repoLimit(e.repoID); limit > 0 && s.runningByRepo[e.repoID] >= limit {
+			remaining = append(remaining, e)
+			continue
+		}
+		s.running++
+		s.runningByRepo[e.repoID]++
+		e.position = 0
+		close(e.ready)
+	}
+	for i := len(remaining); i < len(s.queue); i++ {
+		s.queue[i] = nil
+	}
+	s.queue = remaining
+	for i, e := range s.queue {
+		if e.position == i+1 {
+			continue
+		}
+		e.position = i + 1
+		// moved only needs to hold one signal since wait reads the latest
+		// position.
+		select {
+		case e.moved <- struct{}{}:
+		default:
+		}
+	}
+	s.scope.Gauge(metrics.SchedulerQueueDepthMetric).Update(float64(len(s.queue)))
+	s.scope.Gauge(metrics.SchedulerRunningMetric).Update(float64(s.running))
+}
+
+// QueuedStatusUpdater sets the commit status of projects that are waiting
+// for an execution slot.
+type QueuedStatusUpdater interface {
+	UpdateProjectQueued(ctx command.ProjectContext, cmdName command.Name, position int) error
+}
+
+// ScheduledProjectCommandRunner is a decorator that waits for an execution
+// slot from Scheduler before running each project command. Commands that run
+// on remote workers, and approve_policies, which doesn't run any steps, don't
+// need a slot.
+type ScheduledProjectCommandRunner struct {
+	ProjectCommandRunner
+	Scheduler     *ExecutionScheduler
+	StatusUpdater QueuedStatusUpdater
+}
+
+func (p *ScheduledProjectCommandRunner) Plan(ctx command.ProjectContext) command.ProjectResult {
+	return p.run(ctx, p.ProjectCommandRunner.Plan)
+}
+
+func (p *ScheduledProjectCommandRunner) PolicyCheck(ctx command.ProjectContext) command.ProjectResult {
+	return p.run(ctx, p.ProjectCommandRunner.PolicyCheck)
+}
+
+func (p *ScheduledProjectCommandRunner) Apply(ctx command.ProjectContext) command.ProjectResult {
+	return p.run(ctx, p.ProjectCommandRunner.Apply)
+}
+
+func (p *ScheduledProjectCommandRunner) Version(ctx command.ProjectContext) command.ProjectResult {
+	return p.run(ctx, p.ProjectCommandRunner.Version)
+}
+
+func (p *ScheduledProjectCommandRunner) Import(ctx command.ProjectContext) command.ProjectResult {
+	return p.run(ctx, p.ProjectCommandRunner.Import)
+}
+
+func (p *ScheduledProjectCommandRunner) StateRm(ctx command.ProjectContext) command.ProjectResult {
+	return p.run(ctx, p.ProjectCommandRunner.StateRm)
+}
+
+func (p *ScheduledProjectCommandRunner) Custom(ctx command.ProjectContext) command.ProjectResult {
+	return p.run(ctx, p.ProjectCommandRunner.Custom)
+}
+
+func (p *ScheduledProjectCommandRunner) run(ctx command.ProjectContext, execute func(ctx command.ProjectContext) command.ProjectResult) command.ProjectResult {
+	if ctx.Runner != "" {
+		return execute(ctx)
+	}
+	priority := executionPriority(ctx)
+	release := p.Scheduler.Acquire(ctx.Pull.BaseRepo.ID(), priority, func(position int) {
+		ctx.Log.Info("%s for project at dir %q workspace %q is at position %d in the queue with %s priority, waiting for an execution slot",
+			ctx.CommandName, ctx.RepoRelDir, ctx.Workspace, position, priority)
+		if err := p.StatusUpdater.UpdateProjectQueued(ctx, ctx.CommandName, position); err != nil {
+			ctx.Log.Warn("unable to update commit status: %s", err)
+		}
+	})
+	defer release()
+	return execute(ctx)
+}
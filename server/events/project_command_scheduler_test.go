@@ -0,0 +1,208 @@
+package events_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/petergtz/pegomock/v4"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/mocks"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+	"github.com/runatlantis/atlantis/server/metrics"
+	. "github.com/runatlantis/atlantis/testing"
+	tally "github.com/uber-go/tally/v4"
+)
+
+func noRepoLimit(string) int { return 0 }
+
+// acquireAsync acquires a slot from s in the background. It returns the
+// command's position once it's queued, a channel that receives its later
+// positions and a channel that receives its release function once it runs.
+func acquireAsync(t *testing.T, s *events.ExecutionScheduler, repoID string, priority events.ExecutionPriority) (int, chan int, chan func()) {
+	// The buffer is big enough that the command is never blocked on reporting
+	// a position nobody reads.
+	queued := make(chan int, 100)
+	running := make(chan func(), 1)
+	go func() {
+		running <- s.Acquire(repoID, priority, func(position int) { queued <- position })
+	}()
+	select {
+	case position := <-queued:
+		return position, queued, running
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for command to be queued")
+	}
+	return 0, nil, nil
+}
+
+func assertPosition(t *testing.T, positions chan int, exp int) {
+	t.Helper()
+	select {
+	case position := <-positions:
+		Equals(t, exp, position)
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for position %d", exp)
+	}
+}
+
+func assertWaiting(t *testing.T, running chan func()) {
+	t.Helper()
+	select {
+	case <-running:
+		t.Fatal("exp command to wait for a slot")
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+func assertRuns(t *testing.T, running chan func()) func() {
+	t.Helper()
+	select {
+	case release := <-running:
+		return release
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for command to run")
+	}
+	return nil
+}
+
+func TestExecutionScheduler_GlobalLimit(t *testing.T) {
+	scope := tally.NewTestScope("", nil)
+	s := events.NewExecutionScheduler(1, noRepoLimit, scope)
+	queued := false
+	release := s.Acquire("github.com/owner/repo", events.PlanPriority, func(int) { queued = true })
+	Assert(t, !queued, "exp first command not to be queued")
+
+	position, _, running := acquireAsync(t, s, "github.com/owner/other", events.PlanPriority)
+	Equals(t, 1, position)
+	assertWaiting(t, running)
+	Equals(t, 1.0, scope.Snapshot().Gauges()["scheduler."+metrics.SchedulerQueueDepthMetric+"+"].Value())
+
+	release()
+	release()
+	assertRuns(t, running)()
+	Equals(t, 0.0, scope.Snapshot().Gauges()["scheduler."+metrics.SchedulerQueueDepthMetric+"+"].Value())
+}
+
+func TestExecutionScheduler_RepoLimit(t *testing.T) {
+	s := events.NewExecutionScheduler(0, func(repoID string) int {
+		if repoID == "github.com/owner/repo" {
+			return 1
+		}
+		return 0
+	}, tally.NoopScope)
+	release := s.Acquire("github.com/owner/repo", events.PlanPriority, nil)
+
+	_, _, running := acquireAsync(t, s, "github.com/owner/repo", events.PlanPriority)
+	assertWaiting(t, running)
+
+	t.Log("other repos aren't held up by a repo at its limit")
+	s.Acquire("github.com/owner/other", events.PlanPriority, nil)()
+
+	release()
+	assertRuns(t, running)()
+}
+
+func TestExecutionScheduler_Priority(t *testing.T) {
+	s := events.NewExecutionScheduler(1, noRepoLimit, tally.NoopScope)
+	release := s.Acquire("github.com/owner/repo", events.PlanPriority, nil)
+
+	autoplanPosition, _, autoplan := acquireAsync(t, s, "github.com/owner/repo", events.AutoplanPriority)
+	planPosition, _, plan := acquireAsync(t, s, "github.com/owner/repo", events.PlanPriority)
+	secondPlanPosition, _, secondPlan := acquireAsync(t, s, "github.com/owner/repo", events.PlanPriority)
+	applyPosition, _, apply := acquireAsync(t, s, "github.com/owner/repo", events.ApplyPriority)
+	Equals(t, 1, autoplanPosition)
+	Equals(t, 1, planPosition)
+	Equals(t, 2, secondPlanPosition)
+	Equals(t, 1, applyPosition)
+
+	for _, next := range []chan func(){apply, plan, secondPlan, autoplan} {
+		release()
+		release = assertRuns(t, next)
+	}
+	release()
+}
+
+func TestExecutionScheduler_PositionUpdates(t *testing.T) {
+	s := events.NewExecutionScheduler(1, noRepoLimit, tally.NoopScope)
+	release := s.Acquire("github.com/owner/repo", events.PlanPriority, nil)
+
+	planPosition, planPositions, plan := acquireAsync(t, s, "github.com/owner/repo", events.PlanPriority)
+	Equals(t, 1, planPosition)
+
+	t.Log("commands queued ahead move others back")
+	applyPosition, applyPositions, apply := acquireAsync(t, s, "github.com/owner/repo", events.ApplyPriority)
+	Equals(t, 1, applyPosition)
+	assertPosition(t, planPositions, 2)
+
+	t.Log("commands that start running move others forward")
+	release()
+	release = assertRuns(t, apply)
+	assertPosition(t, planPositions, 1)
+
+	release()
+	assertRuns(t, plan)()
+	Equals(t, 0, len(planPositions))
+	Equals(t, 0, len(applyPositions))
+}
+
+func TestScheduledProjectCommandRunner(t *testing.T) {
+	RegisterMockTestingT(t)
+	mockRunner := mocks.NewMockProjectCommandRunner()
+	statusUpdater := &recordingQueuedStatusUpdater{}
+	s := events.NewExecutionScheduler(1, noRepoLimit, tally.NoopScope)
+	runner := &events.ScheduledProjectCommandRunner{
+		ProjectCommandRunner: mockRunner,
+		Scheduler:            s,
+		StatusUpdater:        statusUpdater,
+	}
+	ctx := command.ProjectContext{
+		Log:         logging.NewNoopLogger(t),
+		CommandName: command.Plan,
+		Trigger:     command.CommentTrigger,
+		Pull:        models.PullRequest{Num: 2, BaseRepo: models.Repo{FullName: "owner/repo", VCSHost: models.VCSHost{Hostname: "github.com"}}},
+		RepoRelDir:  ".",
+		Workspace:   "default",
+	}
+	When(mockRunner.Plan(Any[command.ProjectContext]())).ThenReturn(command.ProjectResult{PlanSuccess: &models.PlanSuccess{}})
+
+	t.Log("commands run once they get a slot")
+	release := s.Acquire(ctx.Pull.BaseRepo.ID(), events.ApplyPriority, nil)
+	done := make(chan command.ProjectResult, 1)
+	go func() { done <- runner.Plan(ctx) }()
+	for statusUpdater.position() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	Equals(t, 1, statusUpdater.position())
+	mockRunner.VerifyWasCalled(Never()).Plan(Any[command.ProjectContext]())
+	release()
+	Ok(t, (<-done).Error)
+	mockRunner.VerifyWasCalledOnce().Plan(Any[command.ProjectContext]())
+
+	t.Log("commands that run on remote workers don't need a slot")
+	release = s.Acquire(ctx.Pull.BaseRepo.ID(), events.ApplyPriority, nil)
+	defer release()
+	ctx.Runner = "prod"
+	Ok(t, runner.Plan(ctx).Error)
+	mockRunner.VerifyWasCalled(Times(2)).Plan(Any[command.ProjectContext]())
+}
+
+type recordingQueuedStatusUpdater struct {
+	mu   sync.Mutex
+	last int
+}
+
+func (r *recordingQueuedStatusUpdater) UpdateProjectQueued(_ command.ProjectContext, _ command.Name, position int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.last = position
+	return nil
+}
+
+func (r *recordingQueuedStatusUpdater) position() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.last
+}
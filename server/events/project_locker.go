@@ -16,6 +16,7 @@ package events
 import (
 	"fmt"
 
+	"github.com/runatlantis/atlantis/server/audit"
 	"github.com/runatlantis/atlantis/server/core/locking"
 	"github.com/runatlantis/atlantis/server/events/models"
 	"github.com/runatlantis/atlantis/server/events/vcs"
@@ -41,6 +42,11 @@ type DefaultProjectLocker struct {
 	Locker     locking.Locker
 	NoOpLocker locking.Locker
 	VCSClient  vcs.Client
+	// DisableDraftPRLocking is true if we should refuse to lock projects for
+	// draft pull requests.
+	DisableDraftPRLocking bool
+	// Auditor records an audit event when a project is locked.
+	Auditor *audit.Auditor
 }
 
 // TryLockResponse is the result of trying to lock a project.
@@ -56,6 +62,10 @@ type TryLockResponse struct {
 	UnlockFn func() error
 	// LockKey is the key for the lock if the lock was acquired.
 	LockKey string
+	// LockCreated is true if this call created the lock. It's false if the
+	// lock was acquired because the pull request already held it, ex. from an
+	// earlier plan.
+	LockCreated bool
 }
 
 // TryLock implements ProjectLocker.TryLock.
@@ -63,6 +73,11 @@ func (p *DefaultProjectLocker) TryLock(log logging.SimpleLogging, pull models.Pu
 	locker := p.Locker
 	if !repoLocking {
 		locker = p.NoOpLocker
+	} else if p.DisableDraftPRLocking && pull.Draft {
+		return &TryLockResponse{
+			LockAcquired:      false,
+			LockFailureReason: "This pull request is a draft so Atlantis won't lock its projects. Mark it as ready for review and comment `atlantis plan` to plan.",
+		}, nil
 	}
 
 	lockAttempt, err := locker.TryLock(project, workspace, pull, user)
@@ -84,12 +99,24 @@ func (p *DefaultProjectLocker) TryLock(log logging.SimpleLogging, pull models.Pu
 		}, nil
 	}
 	log.Info("acquired lock with id %q", lockAttempt.LockKey)
+	if repoLocking {
+		p.Auditor.Record(models.AuditEvent{
+			Type:      models.LockAuditEvent,
+			Actor:     user.Username,
+			Repo:      pull.BaseRepo.FullName,
+			Pull:      pull.Num,
+			Directory: project.Path,
+			Workspace: workspace,
+			Outcome:   models.AuditSuccess,
+		})
+	}
 	return &TryLockResponse{
 		LockAcquired: true,
 		UnlockFn: func() error {
 			_, err := p.Locker.Unlock(lockAttempt.LockKey)
 			return err
 		},
-		LockKey: lockAttempt.LockKey,
+		LockKey:     lockAttempt.LockKey,
+		LockCreated: lockAttempt.LockAcquired,
 	}, nil
 }
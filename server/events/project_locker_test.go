@@ -93,6 +93,7 @@ func TestDefaultProjectLocker_TryLockWhenLockedSamePull(t *testing.T) {
 	res, err := locker.TryLock(logging.NewNoopLogger(t), expPull, expUser, expWorkspace, expProject, true)
 	Ok(t, err)
 	Equals(t, true, res.LockAcquired)
+	Equals(t, false, res.LockCreated)
 
 	// UnlockFn should work.
 	mockLocker.VerifyWasCalled(Never()).Unlock(lockKey)
@@ -132,6 +133,7 @@ func TestDefaultProjectLocker_TryLockUnlocked(t *testing.T) {
 	res, err := locker.TryLock(logging.NewNoopLogger(t), expPull, expUser, expWorkspace, expProject, true)
 	Ok(t, err)
 	Equals(t, true, res.LockAcquired)
+	Equals(t, true, res.LockCreated)
 
 	// UnlockFn should work.
 	mockLocker.VerifyWasCalled(Never()).Unlock(lockKey)
@@ -210,3 +212,39 @@ func TestDefaultProjectLocker_RepoLocking(t *testing.T) {
 		})
 	}
 }
+
+func TestDefaultProjectLocker_DraftPRLocking(t *testing.T) {
+	RegisterMockTestingT(t)
+	var githubClient *vcs.GithubClient
+	mockClient := vcs.NewClientProxy(githubClient, nil, nil, nil, nil)
+	mockLocker := mocks.NewMockLocker()
+	locker := events.DefaultProjectLocker{
+		Locker:                mockLocker,
+		VCSClient:             mockClient,
+		DisableDraftPRLocking: true,
+	}
+	expProject := models.Project{}
+	expWorkspace := "default"
+	expUser := models.User{}
+
+	t.Log("draft pull requests shouldn't be locked")
+	draftPull := models.PullRequest{Num: 2, Draft: true}
+	res, err := locker.TryLock(logging.NewNoopLogger(t), draftPull, expUser, expWorkspace, expProject, true)
+	Ok(t, err)
+	Equals(t, false, res.LockAcquired)
+	Equals(t, "This pull request is a draft so Atlantis won't lock its projects. Mark it as ready for review and comment `atlantis plan` to plan.", res.LockFailureReason)
+	mockLocker.VerifyWasCalled(Never()).TryLock(Any[models.Project](), Any[string](), Any[models.PullRequest](), Any[models.User]())
+
+	t.Log("pull requests that are ready for review should be locked")
+	readyPull := models.PullRequest{Num: 2}
+	When(mockLocker.TryLock(expProject, expWorkspace, readyPull, expUser)).ThenReturn(
+		locking.TryLockResponse{
+			LockAcquired: true,
+			LockKey:      "key",
+		},
+		nil,
+	)
+	res, err = locker.TryLock(logging.NewNoopLogger(t), readyPull, expUser, expWorkspace, expProject, true)
+	Ok(t, err)
+	Equals(t, true, res.LockAcquired)
+}
@@ -55,6 +55,9 @@ type PullClosedExecutor struct {
 	Backend                  locking.Backend
 	PullClosedTemplate       PullCleanupTemplate
 	LogStreamResourceCleaner ResourceCleaner
+	// WorkerQueue, if set, is the queue of remote workers, which forgets
+	// which workers planned the pull request's projects.
+	WorkerQueue PullCleaner
 }
 
 type templatedProject struct {
@@ -97,6 +100,13 @@ func (p *PullClosedExecutor) CleanUpPull(repo models.Repo, pull models.PullReque
 		}
 	}
 
+	if p.WorkerQueue != nil {
+		if err := p.WorkerQueue.CleanUpPull(repo, pull); err != nil {
+			// Log and continue to clean up other resources.
+			p.Logger.Err("cleaning up worker queue: %s", err)
+		}
+	}
+
 	if err := p.WorkingDir.Delete(repo, pull); err != nil {
 		return errors.Wrap(err, "cleaning workspace")
 	}
@@ -95,6 +95,27 @@ func TestCleanUpPullNoLocks(t *testing.T) {
 	cp.VerifyWasCalled(Never()).CreateComment(Any[models.Repo](), Any[int](), Any[string](), Any[string]())
 }
 
+func TestCleanUpPullWorkerQueue(t *testing.T) {
+	t.Log("the worker queue forgets the pull request")
+	RegisterMockTestingT(t)
+	w := mocks.NewMockWorkingDir()
+	l := lockmocks.NewMockLocker()
+	queue := mocks.NewMockPullCleaner()
+	tmp := t.TempDir()
+	db, err := db.New(tmp)
+	Ok(t, err)
+	pce := events.PullClosedExecutor{
+		Locker:      l,
+		WorkingDir:  w,
+		Backend:     db,
+		WorkerQueue: queue,
+	}
+	When(l.UnlockByPull(testdata.GithubRepo.FullName, testdata.Pull.Num)).ThenReturn(nil, nil)
+	err = pce.CleanUpPull(testdata.GithubRepo, testdata.Pull)
+	Ok(t, err)
+	queue.VerifyWasCalledOnce().CleanUpPull(testdata.GithubRepo, testdata.Pull)
+}
+
 func TestCleanUpPullComments(t *testing.T) {
 	t.Log("should comment correctly")
 	RegisterMockTestingT(t)
@@ -3,7 +3,9 @@ package events
 import (
 	"slices"
 
+	"github.com/runatlantis/atlantis/server/audit"
 	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
 	"github.com/runatlantis/atlantis/server/events/vcs"
 )
 
@@ -12,12 +14,14 @@ func NewUnlockCommandRunner(
 	vcsClient vcs.Client,
 	SilenceNoProjects bool,
 	DisableUnlockLabel string,
+	auditor *audit.Auditor,
 ) *UnlockCommandRunner {
 	return &UnlockCommandRunner{
 		deleteLockCommand:  deleteLockCommand,
 		vcsClient:          vcsClient,
 		SilenceNoProjects:  SilenceNoProjects,
 		DisableUnlockLabel: DisableUnlockLabel,
+		auditor:            auditor,
 	}
 }
 
@@ -28,6 +32,7 @@ type UnlockCommandRunner struct {
 	// are found
 	SilenceNoProjects  bool
 	DisableUnlockLabel string
+	auditor            *audit.Auditor
 }
 
 func (u *UnlockCommandRunner) Run(ctx *command.Context, _ *CommentCommand) {
@@ -61,6 +66,15 @@ func (u *UnlockCommandRunner) Run(ctx *command.Context, _ *CommentCommand) {
 			vcsMessage = "Failed to delete PR locks"
 			ctx.Log.Err("failed to delete locks by pull %s", err.Error())
 		}
+		u.auditor.Record(models.AuditEvent{
+			Type:    models.UnlockAuditEvent,
+			Actor:   ctx.User.Username,
+			Repo:    baseRepo.FullName,
+			Pull:    pullNum,
+			Command: command.Unlock.String(),
+			Outcome: audit.Outcome(err),
+			Detail:  audit.ErrorDetail(err),
+		})
 	}
 
 	// if there are no locks to delete, no errors, and SilenceNoProjects is enabled, don't comment
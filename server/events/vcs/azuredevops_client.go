@@ -300,7 +300,8 @@ func (g *AzureDevopsClient) UpdateStatus(repo models.Repo, pull models.PullReque
 }
 
 // MergePull merges the merge request using the default no fast-forward strategy
-// If the user has set a branch policy that disallows no fast-forward, the merge will fail
+// unless a merge method is set in pullOptions.
+// If the user has set a branch policy that disallows the strategy, the merge will fail
 // until we handle branch policies
 // https://docs.microsoft.com/en-us/azure/devops/repos/git/branch-policies?view=azure-devops
 func (g *AzureDevopsClient) MergePull(pull models.PullRequest, pullOptions models.PullRequestOptions) error {
@@ -323,6 +324,12 @@ func (g *AzureDevopsClient) MergePull(pull models.PullRequest, pullOptions model
 	}
 	// Set default pull request completion options
 	mcm := azuredevops.NoFastForward.String()
+	switch pullOptions.MergeMethod {
+	case models.SquashMergeMethod:
+		mcm = azuredevops.Squash.String()
+	case models.RebaseMergeMethod:
+		mcm = azuredevops.Rebase.String()
+	}
 	twi := new(bool)
 	*twi = true
 	completionOpts := azuredevops.GitPullRequestCompletionOptions{
@@ -207,12 +207,31 @@ func (b *Client) UpdateStatus(repo models.Repo, pull models.PullRequest, status
 }
 
 // MergePull merges the pull request.
-func (b *Client) MergePull(pull models.PullRequest, _ models.PullRequestOptions) error {
+func (b *Client) MergePull(pull models.PullRequest, pullOptions models.PullRequestOptions) error {
 	path := fmt.Sprintf("%s/2.0/repositories/%s/pullrequests/%d/merge", b.BaseURL, pull.BaseRepo.FullName, pull.Num)
-	_, err := b.makeRequest("POST", path, nil)
+	var body io.Reader
+	if pullOptions.MergeMethod != "" {
+		strategy, ok := mergeStrategies[pullOptions.MergeMethod]
+		if !ok {
+			return fmt.Errorf("merge method %q is not supported by Bitbucket Cloud", pullOptions.MergeMethod)
+		}
+		bodyBytes, err := json.Marshal(map[string]string{"merge_strategy": strategy})
+		if err != nil {
+			return errors.Wrap(err, "json encoding")
+		}
+		body = bytes.NewBuffer(bodyBytes)
+	}
+	_, err := b.makeRequest("POST", path, body)
 	return err
 }
 
+// mergeStrategies maps merge methods to Bitbucket Cloud merge strategies.
+var mergeStrategies = map[models.MergeMethod]string{
+	models.MergeCommitMergeMethod: "merge_commit",
+	models.SquashMergeMethod:      "squash",
+	models.RebaseMergeMethod:      "rebase_fast_forward",
+}
+
 // MarkdownPullLink specifies the character used in a pull request comment.
 func (b *Client) MarkdownPullLink(pull models.PullRequest) (string, error) {
 	return fmt.Sprintf("#%d", pull.Num), nil
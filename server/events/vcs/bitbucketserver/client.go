@@ -278,8 +278,20 @@ func (b *Client) MergePull(pull models.PullRequest, pullOptions models.PullReque
 	if err := validator.New().Struct(pullResp); err != nil {
 		return errors.Wrapf(err, "API response %q was missing fields", string(resp))
 	}
+	var body io.Reader
+	if pullOptions.MergeMethod != "" {
+		strategy, ok := mergeStrategies[pullOptions.MergeMethod]
+		if !ok {
+			return fmt.Errorf("merge method %q is not supported by Bitbucket Server", pullOptions.MergeMethod)
+		}
+		bodyBytes, err := json.Marshal(map[string]string{"strategyId": strategy})
+		if err != nil {
+			return errors.Wrap(err, "json encoding")
+		}
+		body = bytes.NewBuffer(bodyBytes)
+	}
 	path = fmt.Sprintf("%s/rest/api/1.0/projects/%s/repos/%s/pull-requests/%d/merge?version=%d", b.BaseURL, projectKey, pull.BaseRepo.Name, pull.Num, *pullResp.Version)
-	_, err = b.makeRequest("POST", path, nil)
+	_, err = b.makeRequest("POST", path, body)
 	if err != nil {
 		return err
 	}
@@ -298,6 +310,13 @@ func (b *Client) MergePull(pull models.PullRequest, pullOptions models.PullReque
 	return err
 }
 
+// mergeStrategies maps merge methods to Bitbucket Server merge strategy IDs.
+var mergeStrategies = map[models.MergeMethod]string{
+	models.MergeCommitMergeMethod: "no-ff",
+	models.SquashMergeMethod:      "squash",
+	models.RebaseMergeMethod:      "rebase-no-ff",
+}
+
 // MarkdownPullLink specifies the character used in a pull request comment.
 func (b *Client) MarkdownPullLink(pull models.PullRequest) (string, error) {
 	return fmt.Sprintf("#%d", pull.Num), nil
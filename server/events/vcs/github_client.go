@@ -172,6 +172,39 @@ listloop:
 	return files, nil
 }
 
+// GetModifiedFilesBetween returns the names of files that were modified
+// between the base and head commits relative to the repo root, e.g.
+// parent/child/file.txt.
+func (g *GithubClient) GetModifiedFilesBetween(repo models.Repo, base string, head string) ([]string, error) {
+	var files []string
+	opts := github.ListOptions{
+		PerPage: 300,
+	}
+	for {
+		comparison, resp, err := g.client.Repositories.CompareCommits(g.ctx, repo.Owner, repo.Name, base, head, &opts)
+		if resp != nil {
+			g.logger.Debug("GET /repos/%v/%v/compare/%s...%s returned: %v", repo.Owner, repo.Name, base, head, resp.StatusCode)
+		}
+		if err != nil {
+			return files, errors.Wrap(err, "comparing commits")
+		}
+		for _, f := range comparison.Files {
+			files = append(files, f.GetFilename())
+
+			// If the file was renamed, the directory it was moved from
+			// was modified as well.
+			if f.GetStatus() == "renamed" {
+				files = append(files, f.GetPreviousFilename())
+			}
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return files, nil
+}
+
 // CreateComment creates a comment on the pull request.
 // If comment length is greater than the max comment length we split into
 // multiple comments.
@@ -211,6 +244,25 @@ func (g *GithubClient) ReactToComment(repo models.Repo, _ int, commentID int64,
 	return err
 }
 
+// ReactToReviewComment adds a reaction to a pull request review comment, i.e.
+// a comment on the pull request's diff.
+func (g *GithubClient) ReactToReviewComment(repo models.Repo, commentID int64, reaction string) error {
+	_, resp, err := g.client.Reactions.CreatePullRequestCommentReaction(g.ctx, repo.Owner, repo.Name, commentID, reaction)
+	if resp != nil {
+		g.logger.Debug("POST /repos/%v/%v/pulls/comments/%d/reactions returned: %v", repo.Owner, repo.Name, commentID, resp.StatusCode)
+	}
+	return err
+}
+
+// ReplyToReviewComment replies to a pull request review comment in its thread.
+func (g *GithubClient) ReplyToReviewComment(repo models.Repo, pullNum int, commentID int64, comment string) error {
+	_, resp, err := g.client.PullRequests.CreateCommentInReplyTo(g.ctx, repo.Owner, repo.Name, pullNum, comment, commentID)
+	if resp != nil {
+		g.logger.Debug("POST /repos/%v/%v/pulls/%d/comments returned: %v", repo.Owner, repo.Name, pullNum, resp.StatusCode)
+	}
+	return err
+}
+
 func (g *GithubClient) HidePrevCommandComments(repo models.Repo, pullNum int, command string, dir string) error {
 	var allComments []*github.IssueComment
 	nextPage := 0
@@ -614,7 +666,7 @@ func (g *GithubClient) UpdateStatus(repo models.Repo, pull models.PullRequest, s
 }
 
 // MergePull merges the pull request.
-func (g *GithubClient) MergePull(pull models.PullRequest, _ models.PullRequestOptions) error {
+func (g *GithubClient) MergePull(pull models.PullRequest, pullOptions models.PullRequestOptions) error {
 	// Users can set their repo to disallow certain types of merging.
 	// We detect which types aren't allowed and use the type that is.
 	repo, resp, err := g.client.Repositories.Get(g.ctx, pull.BaseRepo.Owner, pull.BaseRepo.Name)
@@ -630,7 +682,11 @@ func (g *GithubClient) MergePull(pull models.PullRequest, _ models.PullRequestOp
 		squashMergeMethod  = "squash"
 	)
 	method := defaultMergeMethod
-	if !repo.GetAllowMergeCommit() {
+	if pullOptions.MergeMethod != "" {
+		// The method was configured explicitly so we use it as is and let
+		// GitHub reject it if the repo doesn't allow it.
+		method = string(pullOptions.MergeMethod)
+	} else if !repo.GetAllowMergeCommit() {
 		if repo.GetAllowRebaseMerge() {
 			method = rebaseMergeMethod
 		} else if repo.GetAllowSquashMerge() {
@@ -977,6 +977,7 @@ func TestGithubClient_MergePullCorrectMethod(t *testing.T) {
 		allowMerge  bool
 		allowRebase bool
 		allowSquash bool
+		mergeMethod models.MergeMethod
 		expMethod   string
 	}{
 		"all true": {
@@ -1009,6 +1010,13 @@ func TestGithubClient_MergePullCorrectMethod(t *testing.T) {
 			allowSquash: false,
 			expMethod:   "rebase",
 		},
+		"configured method is used": {
+			allowMerge:  true,
+			allowRebase: true,
+			allowSquash: true,
+			mergeMethod: models.SquashMergeMethod,
+			expMethod:   "squash",
+		},
 	}
 
 	for name, c := range cases {
@@ -1082,6 +1090,7 @@ func TestGithubClient_MergePullCorrectMethod(t *testing.T) {
 					Num: 1,
 				}, models.PullRequestOptions{
 					DeleteSourceBranchOnMerge: false,
+					MergeMethod:               c.mergeMethod,
 				})
 
 			Ok(t, err)
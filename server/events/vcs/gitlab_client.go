@@ -488,13 +488,23 @@ func (g *GitlabClient) MergePull(pull models.PullRequest, pullOptions models.Pul
 		g.WaitForSuccessPipeline(context.Background(), pull)
 	}
 
+	acceptOpts := &gitlab.AcceptMergeRequestOptions{
+		MergeCommitMessage:       &commitMsg,
+		ShouldRemoveSourceBranch: &pullOptions.DeleteSourceBranchOnMerge,
+	}
+	switch pullOptions.MergeMethod {
+	case models.SquashMergeMethod:
+		acceptOpts.Squash = gitlab.Bool(true)
+	case models.RebaseMergeMethod:
+		// GitLab configures rebasing per project so it can't be requested
+		// when accepting a merge request.
+		return fmt.Errorf("unable to merge merge request, merge method %q is not supported by GitLab, set the project's merge method instead", pullOptions.MergeMethod)
+	}
+
 	_, resp, err = g.Client.MergeRequests.AcceptMergeRequest(
 		pull.BaseRepo.FullName,
 		pull.Num,
-		&gitlab.AcceptMergeRequestOptions{
-			MergeCommitMessage:       &commitMsg,
-			ShouldRemoveSourceBranch: &pullOptions.DeleteSourceBranchOnMerge,
-		})
+		acceptOpts)
 	if resp != nil {
 		g.logger.Debug("PUT /projects/%s/merge_requests/%d/merge returned: %d", pull.BaseRepo.FullName, pull.Num, resp.StatusCode)
 	}
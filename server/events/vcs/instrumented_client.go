@@ -24,6 +24,8 @@ func NewInstrumentedGithubClient(client *GithubClient, statsScope tally.Scope, l
 	return &InstrumentedGithubClient{
 		InstrumentedClient: instrumentedGHClient,
 		PullRequestGetter:  client,
+		CommitComparer:     client,
+		ReviewCommenter:    client,
 		StatsScope:         scope,
 		Logger:             logger,
 	}
@@ -35,11 +37,33 @@ type GithubPullRequestGetter interface {
 	GetPullRequest(repo models.Repo, pullNum int) (*github.PullRequest, error)
 }
 
+//go:generate pegomock generate --package mocks -o mocks/mock_github_commit_comparer.go GithubCommitComparer
+
+type GithubCommitComparer interface {
+	// GetModifiedFilesBetween returns the names of files that were modified
+	// between the base and head commits relative to the repo root.
+	GetModifiedFilesBetween(repo models.Repo, base string, head string) ([]string, error)
+}
+
+//go:generate pegomock generate --package mocks -o mocks/mock_github_review_commenter.go GithubReviewCommenter
+
+// GithubReviewCommenter reacts and replies to pull request review comments,
+// which GitHub treats differently from regular pull request comments.
+type GithubReviewCommenter interface {
+	// ReactToReviewComment adds a reaction to the review comment commentID.
+	ReactToReviewComment(repo models.Repo, commentID int64, reaction string) error
+	// ReplyToReviewComment replies to the review comment commentID in its
+	// thread.
+	ReplyToReviewComment(repo models.Repo, pullNum int, commentID int64, comment string) error
+}
+
 // IGithubClient exists to bridge the gap between GithubPullRequestGetter and Client interface to allow
 // for a single instrumented client
 type IGithubClient interface {
 	Client
 	GithubPullRequestGetter
+	GithubCommitComparer
+	GithubReviewCommenter
 }
 
 // InstrumentedGithubClient should delegate to the underlying InstrumentedClient for vcs provider-agnostic
@@ -47,6 +71,8 @@ type IGithubClient interface {
 type InstrumentedGithubClient struct {
 	*InstrumentedClient
 	PullRequestGetter GithubPullRequestGetter
+	CommitComparer    GithubCommitComparer
+	ReviewCommenter   GithubReviewCommenter
 	StatsScope        tally.Scope
 	Logger            logging.SimpleLogging
 }
@@ -78,6 +104,71 @@ func (c *InstrumentedGithubClient) GetPullRequest(repo models.Repo, pullNum int)
 
 }
 
+func (c *InstrumentedGithubClient) GetModifiedFilesBetween(repo models.Repo, base string, head string) ([]string, error) {
+	scope := c.StatsScope.SubScope("get_modified_files_between")
+	scope = scope.Tagged(map[string]string{"base_repo": repo.FullName})
+	logger := c.Logger.WithHistory([]interface{}{
+		"repository", fmt.Sprintf("%s/%s", repo.Owner, repo.Name),
+	}...)
+
+	executionTime := scope.Timer(metrics.ExecutionTimeMetric).Start()
+	defer executionTime.Stop()
+
+	executionSuccess := scope.Counter(metrics.ExecutionSuccessMetric)
+	executionError := scope.Counter(metrics.ExecutionErrorMetric)
+
+	files, err := c.CommitComparer.GetModifiedFilesBetween(repo, base, head)
+
+	if err != nil {
+		executionError.Inc(1)
+		logger.Err("Unable to get modified files between %s and %s, error: %s", base, head, err.Error())
+	} else {
+		executionSuccess.Inc(1)
+	}
+
+	return files, err
+}
+
+func (c *InstrumentedGithubClient) ReactToReviewComment(repo models.Repo, commentID int64, reaction string) error {
+	scope := c.StatsScope.SubScope("react_to_review_comment")
+
+	executionTime := scope.Timer(metrics.ExecutionTimeMetric).Start()
+	defer executionTime.Stop()
+
+	executionSuccess := scope.Counter(metrics.ExecutionSuccessMetric)
+	executionError := scope.Counter(metrics.ExecutionErrorMetric)
+
+	if err := c.ReviewCommenter.ReactToReviewComment(repo, commentID, reaction); err != nil {
+		executionError.Inc(1)
+		c.Logger.Err("Unable to react to review comment, error: %s", err.Error())
+		return err
+	}
+
+	executionSuccess.Inc(1)
+	return nil
+}
+
+func (c *InstrumentedGithubClient) ReplyToReviewComment(repo models.Repo, pullNum int, commentID int64, comment string) error {
+	scope := c.StatsScope.SubScope("reply_to_review_comment")
+	scope = SetGitScopeTags(scope, repo.FullName, pullNum)
+	logger := c.Logger.WithHistory(fmtLogSrc(repo, pullNum)...)
+
+	executionTime := scope.Timer(metrics.ExecutionTimeMetric).Start()
+	defer executionTime.Stop()
+
+	executionSuccess := scope.Counter(metrics.ExecutionSuccessMetric)
+	executionError := scope.Counter(metrics.ExecutionErrorMetric)
+
+	if err := c.ReviewCommenter.ReplyToReviewComment(repo, pullNum, commentID, comment); err != nil {
+		executionError.Inc(1)
+		logger.Err("Unable to reply to review comment, error: %s", err.Error())
+		return err
+	}
+
+	executionSuccess.Inc(1)
+	return nil
+}
+
 type InstrumentedClient struct {
 	Client
 	StatsScope tally.Scope
@@ -23,6 +23,7 @@ import (
 	"sync"
 
 	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
 	"github.com/runatlantis/atlantis/server/core/runtime"
 	"github.com/runatlantis/atlantis/server/events/models"
 	"github.com/runatlantis/atlantis/server/logging"
@@ -86,7 +87,10 @@ type FileWorkspace struct {
 	GpgNoSigningEnabled bool
 	// flag indicating if we have to merge with potential new changes upstream (directly after grabbing project lock)
 	CheckForUpstreamChanges bool
-	Logger                  logging.SimpleLogging
+	// EnvPolicy decides which of Atlantis's environment variables git
+	// inherits.
+	EnvPolicy valid.EnvPolicy
+	Logger    logging.SimpleLogging
 }
 
 // Clone git clones headRepo, checks out the branch and then returns the absolute
@@ -118,6 +122,7 @@ func (w *FileWorkspace) Clone(
 			pullHead = "HEAD^2"
 		}
 		revParseCmd := exec.Command("git", "rev-parse", pullHead) // #nosec
+		revParseCmd.Env = w.EnvPolicy.Filter(os.Environ())
 		revParseCmd.Dir = cloneDir
 		outputRevParseCmd, err := revParseCmd.CombinedOutput()
 		if err != nil {
@@ -180,6 +185,7 @@ func (w *FileWorkspace) recheckDiverged(p models.PullRequest, headRepo models.Re
 
 	for _, args := range cmds {
 		cmd := exec.Command(args[0], args[1:]...) // nolint: gosec
+		cmd.Env = w.EnvPolicy.Filter(os.Environ())
 		cmd.Dir = cloneDir
 
 		output, err := cmd.CombinedOutput()
@@ -201,6 +207,7 @@ func (w *FileWorkspace) HasDiverged(cloneDir string) bool {
 	}
 
 	statusFetchCmd := exec.Command("git", "fetch")
+	statusFetchCmd.Env = w.EnvPolicy.Filter(os.Environ())
 	statusFetchCmd.Dir = cloneDir
 	outputStatusFetch, err := statusFetchCmd.CombinedOutput()
 	if err != nil {
@@ -210,6 +217,7 @@ func (w *FileWorkspace) HasDiverged(cloneDir string) bool {
 
 	// Check if remote main branch has diverged.
 	statusUnoCmd := exec.Command("git", "status", "--untracked-files=no")
+	statusUnoCmd.Env = w.EnvPolicy.Filter(os.Environ())
 	statusUnoCmd.Dir = cloneDir
 	outputStatusUno, err := statusUnoCmd.CombinedOutput()
 	if err != nil {
@@ -315,7 +323,7 @@ func (w *FileWorkspace) wrappedGit(c wrappedGitContext, args ...string) error {
 	cmd := exec.Command("git", args...) // nolint: gosec
 	cmd.Dir = c.dir
 	// The git merge command requires these env vars are set.
-	cmd.Env = append(os.Environ(), []string{
+	cmd.Env = append(w.EnvPolicy.Filter(os.Environ()), []string{
 		"EMAIL=atlantis@runatlantis.io",
 		"GIT_AUTHOR_NAME=atlantis",
 		"GIT_COMMITTER_NAME=atlantis",
@@ -436,6 +444,7 @@ func (w *FileWorkspace) GetGitUntrackedFiles(r models.Repo, p models.PullRequest
 
 	w.Logger.Debug("Checking for Git untracked files in directory: '%s'", workingDir)
 	cmd := exec.Command("git", "ls-files", "--others", "--exclude-standard")
+	cmd.Env = w.EnvPolicy.Filter(os.Environ())
 	cmd.Dir = workingDir
 
 	output, err := cmd.CombinedOutput()
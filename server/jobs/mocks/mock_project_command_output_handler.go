@@ -4,6 +4,7 @@
 package mocks
 
 import (
+	context "context"
 	pegomock "github.com/petergtz/pegomock/v4"
 	command "github.com/runatlantis/atlantis/server/events/command"
 	models "github.com/runatlantis/atlantis/server/events/models"
@@ -81,11 +82,30 @@ func (mock *MockProjectCommandOutputHandler) IsKeyExists(key string) bool {
 	return ret0
 }
 
-func (mock *MockProjectCommandOutputHandler) Register(jobID string, receiver chan string) {
+func (mock *MockProjectCommandOutputHandler) JobRepoID(jobID string) (string, bool) {
 	if mock == nil {
 		panic("mock must not be nil. Use myMock := NewMockProjectCommandOutputHandler().")
 	}
-	params := []pegomock.Param{jobID, receiver}
+	params := []pegomock.Param{jobID}
+	result := pegomock.GetGenericMockFrom(mock).Invoke("JobRepoID", params, []reflect.Type{reflect.TypeOf((*string)(nil)).Elem(), reflect.TypeOf((*bool)(nil)).Elem()})
+	var ret0 string
+	var ret1 bool
+	if len(result) != 0 {
+		if result[0] != nil {
+			ret0 = result[0].(string)
+		}
+		if result[1] != nil {
+			ret1 = result[1].(bool)
+		}
+	}
+	return ret0, ret1
+}
+
+func (mock *MockProjectCommandOutputHandler) Register(ctx context.Context, jobID string, receiver chan string) {
+	if mock == nil {
+		panic("mock must not be nil. Use myMock := NewMockProjectCommandOutputHandler().")
+	}
+	params := []pegomock.Param{ctx, jobID, receiver}
 	pegomock.GetGenericMockFrom(mock).Invoke("Register", params, []reflect.Type{})
 }
 
@@ -261,8 +281,35 @@ func (c *MockProjectCommandOutputHandler_IsKeyExists_OngoingVerification) GetAll
 	return
 }
 
-func (verifier *VerifierMockProjectCommandOutputHandler) Register(jobID string, receiver chan string) *MockProjectCommandOutputHandler_Register_OngoingVerification {
-	params := []pegomock.Param{jobID, receiver}
+func (verifier *VerifierMockProjectCommandOutputHandler) JobRepoID(jobID string) *MockProjectCommandOutputHandler_JobRepoID_OngoingVerification {
+	params := []pegomock.Param{jobID}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "JobRepoID", params, verifier.timeout)
+	return &MockProjectCommandOutputHandler_JobRepoID_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type MockProjectCommandOutputHandler_JobRepoID_OngoingVerification struct {
+	mock              *MockProjectCommandOutputHandler
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *MockProjectCommandOutputHandler_JobRepoID_OngoingVerification) GetCapturedArguments() string {
+	jobID := c.GetAllCapturedArguments()
+	return jobID[len(jobID)-1]
+}
+
+func (c *MockProjectCommandOutputHandler_JobRepoID_OngoingVerification) GetAllCapturedArguments() (_param0 []string) {
+	params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(params) > 0 {
+		_param0 = make([]string, len(c.methodInvocations))
+		for u, param := range params[0] {
+			_param0[u] = param.(string)
+		}
+	}
+	return
+}
+
+func (verifier *VerifierMockProjectCommandOutputHandler) Register(ctx context.Context, jobID string, receiver chan string) *MockProjectCommandOutputHandler_Register_OngoingVerification {
+	params := []pegomock.Param{ctx, jobID, receiver}
 	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "Register", params, verifier.timeout)
 	return &MockProjectCommandOutputHandler_Register_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
 }
@@ -272,21 +319,25 @@ type MockProjectCommandOutputHandler_Register_OngoingVerification struct {
 	methodInvocations []pegomock.MethodInvocation
 }
 
-func (c *MockProjectCommandOutputHandler_Register_OngoingVerification) GetCapturedArguments() (string, chan string) {
-	jobID, receiver := c.GetAllCapturedArguments()
-	return jobID[len(jobID)-1], receiver[len(receiver)-1]
+func (c *MockProjectCommandOutputHandler_Register_OngoingVerification) GetCapturedArguments() (context.Context, string, chan string) {
+	ctx, jobID, receiver := c.GetAllCapturedArguments()
+	return ctx[len(ctx)-1], jobID[len(jobID)-1], receiver[len(receiver)-1]
 }
 
-func (c *MockProjectCommandOutputHandler_Register_OngoingVerification) GetAllCapturedArguments() (_param0 []string, _param1 []chan string) {
+func (c *MockProjectCommandOutputHandler_Register_OngoingVerification) GetAllCapturedArguments() (_param0 []context.Context, _param1 []string, _param2 []chan string) {
 	params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
 	if len(params) > 0 {
-		_param0 = make([]string, len(c.methodInvocations))
+		_param0 = make([]context.Context, len(c.methodInvocations))
 		for u, param := range params[0] {
-			_param0[u] = param.(string)
+			_param0[u] = param.(context.Context)
 		}
-		_param1 = make([]chan string, len(c.methodInvocations))
+		_param1 = make([]string, len(c.methodInvocations))
 		for u, param := range params[1] {
-			_param1[u] = param.(chan string)
+			_param1[u] = param.(string)
+		}
+		_param2 = make([]chan string, len(c.methodInvocations))
+		for u, param := range params[2] {
+			_param2[u] = param.(chan string)
 		}
 	}
 	return
@@ -0,0 +1,161 @@
+package jobs
+
+import (
+	"context"
+)
+
+// JobOutputStore stores the output of jobs outside of this process so that
+// it can be streamed by any Atlantis replica, not just the one running the
+// job.
+type JobOutputStore interface {
+	// Append adds lines to the output of the job with id jobID.
+	Append(jobID string, lines ...string) error
+	// Complete marks the job with id jobID as complete.
+	Complete(jobID string) error
+	// Exists returns true if there's output for the job with id jobID.
+	Exists(jobID string) (bool, error)
+	// Follow sends every line of the job's output to receiver, waiting for
+	// new lines until the job is complete or ctx is done. It returns true
+	// if the job is complete.
+	Follow(ctx context.Context, jobID string, receiver chan string) (bool, error)
+	// Delete deletes the output of the jobs with ids jobIDs.
+	Delete(jobIDs ...string) error
+	// SetRepoID sets the ID of the repo of the job with id jobID.
+	SetRepoID(jobID string, repoID string) error
+	// RepoID returns the ID of the repo of the job with id jobID, or "" if
+	// it isn't known.
+	RepoID(jobID string) (string, error)
+}
+
+const (
+	// storeWritesQueueSize is how many writes to the output store can wait
+	// to be published before Handle blocks.
+	storeWritesQueueSize = 10000
+	// storeWritesBatchSize is how many writes are published at most at once.
+	storeWritesBatchSize = 500
+)
+
+type storeWriteKind int
+
+const (
+	appendWrite storeWriteKind = iota
+	completeWrite
+	repoIDWrite
+	deleteWrite
+)
+
+// storeWrite is a write to the output store, which is published by publish.
+type storeWrite struct {
+	kind   storeWriteKind
+	jobIDs []string
+	// value is the line of appendWrite and the repo ID of repoIDWrite.
+	value string
+	// done, if set, is closed once the write is published.
+	done chan struct{}
+}
+
+// publish publishes the writes to the output store in the order they're
+// queued, so that Handle doesn't wait for the store. Lines queued at the same
+// time are appended together.
+func (p *AsyncProjectCommandOutputHandler) publish() {
+	for w := range p.storeWrites {
+		batch := []storeWrite{w}
+	drain:
+		for len(batch) < storeWritesBatchSize {
+			select {
+			case w := <-p.storeWrites:
+				batch = append(batch, w)
+			default:
+				break drain
+			}
+		}
+		p.publishBatch(batch)
+	}
+}
+
+func (p *AsyncProjectCommandOutputHandler) publishBatch(batch []storeWrite) {
+	var jobIDs []string
+	lines := make(map[string][]string)
+	flush := func(jobID string) {
+		if len(lines[jobID]) == 0 {
+			return
+		}
+		if err := p.outputStore.Append(jobID, lines[jobID]...); err != nil {
+			p.logger.Warn("failed publishing output of job %s: %s", jobID, err)
+		}
+		delete(lines, jobID)
+	}
+	for _, w := range batch {
+		switch w.kind {
+		case appendWrite:
+			jobID := w.jobIDs[0]
+			if _, ok := lines[jobID]; !ok {
+				jobIDs = append(jobIDs, jobID)
+			}
+			lines[jobID] = append(lines[jobID], w.value)
+		case completeWrite:
+			flush(w.jobIDs[0])
+			if err := p.outputStore.Complete(w.jobIDs[0]); err != nil {
+				p.logger.Warn("failed publishing completion of job %s: %s", w.jobIDs[0], err)
+			}
+		case repoIDWrite:
+			if err := p.outputStore.SetRepoID(w.jobIDs[0], w.value); err != nil {
+				p.logger.Warn("failed publishing repo of job %s: %s", w.jobIDs[0], err)
+			}
+		case deleteWrite:
+			for _, jobID := range w.jobIDs {
+				delete(lines, jobID)
+			}
+			if err := p.outputStore.Delete(w.jobIDs...); err != nil {
+				p.logger.Warn("failed deleting output of jobs %v: %s", w.jobIDs, err)
+			}
+		}
+		if w.done != nil {
+			close(w.done)
+		}
+	}
+	for _, jobID := range jobIDs {
+		flush(jobID)
+	}
+}
+
+// followRemote streams the output of a job that's running on another
+// replica into receiver until registerCtx is done or receiver is
+// deregistered. receiver is closed once the job is complete, like it is for
+// local jobs.
+func (p *AsyncProjectCommandOutputHandler) followRemote(registerCtx context.Context, jobID string, receiver chan string) {
+	ctx, cancel := context.WithCancel(registerCtx)
+	p.remoteReceiversLock.Lock()
+	p.remoteReceivers[receiver] = cancel
+	p.remoteReceiversLock.Unlock()
+	defer func() {
+		p.remoteReceiversLock.Lock()
+		delete(p.remoteReceivers, receiver)
+		p.remoteReceiversLock.Unlock()
+		cancel()
+	}()
+
+	complete, err := p.outputStore.Follow(ctx, jobID, receiver)
+	if ctx.Err() != nil {
+		// The receiver was deregistered or its request ended so nothing is
+		// reading it.
+		return
+	}
+	if err != nil {
+		p.logger.Warn("failed following output of job %s: %s", jobID, err)
+	}
+	if complete || err != nil {
+		close(receiver)
+	}
+}
+
+// stopRemote stops streaming output into receiver if it's following a job
+// on another replica.
+func (p *AsyncProjectCommandOutputHandler) stopRemote(receiver chan string) {
+	p.remoteReceiversLock.Lock()
+	defer p.remoteReceiversLock.Unlock()
+	if cancel, ok := p.remoteReceivers[receiver]; ok {
+		cancel()
+		delete(p.remoteReceivers, receiver)
+	}
+}
@@ -0,0 +1,226 @@
+package jobs_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/runatlantis/atlantis/server/core/redis"
+	"github.com/runatlantis/atlantis/server/jobs"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+	"github.com/stretchr/testify/assert"
+)
+
+func createReplicaOutputHandler(t *testing.T, store jobs.JobOutputStore) jobs.ProjectCommandOutputHandler {
+	handler := jobs.NewAsyncProjectCommandOutputHandlerWithStore(
+		make(chan *jobs.ProjectCmdOutputLine),
+		logging.NewNoopLogger(t),
+		store,
+	)
+	go handler.Handle()
+	return handler
+}
+
+func TestProjectCommandOutputHandler_RemoteJobs(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb, err := redis.New(mr.Host(), mr.Server().Addr().Port, "", false, false, 0)
+	Ok(t, err)
+	defer rdb.Close() // nolint: errcheck
+
+	// The job runs on the first replica and is streamed from the second.
+	running := createReplicaOutputHandler(t, rdb.JobOutputStore())
+	streaming := createReplicaOutputHandler(t, rdb.JobOutputStore())
+	ctx := createTestProjectCmdContext(t)
+
+	Assert(t, !streaming.IsKeyExists(ctx.JobID), "exp job not to exist before it has output")
+	running.Send(ctx, "line 1", false)
+	running.Send(ctx, "line 2", false)
+	assert.Eventually(t, func() bool { return streaming.IsKeyExists(ctx.JobID) }, time.Second, 10*time.Millisecond)
+
+	t.Log("the job's repo is known on every replica")
+	repoID, ok := streaming.JobRepoID(ctx.JobID)
+	Assert(t, ok, "exp repo of job to be known")
+	Equals(t, ctx.BaseRepo.ID(), repoID)
+
+	ch := make(chan string, 1000)
+	go streaming.Register(context.Background(), ctx.JobID, ch)
+
+	t.Log("buffered lines are replayed")
+	Equals(t, "line 1", <-ch)
+	Equals(t, "line 2", <-ch)
+
+	t.Log("new lines are followed until the job is complete")
+	running.Send(ctx, "line 3", false)
+	Equals(t, "line 3", <-ch)
+	running.Send(ctx, "", true)
+	select {
+	case _, ok := <-ch:
+		Assert(t, !ok, "exp channel to be closed once the job is complete")
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the channel to be closed")
+	}
+
+	t.Log("cleaning up the pull deletes its output")
+	running.CleanUp(jobs.PullInfo{
+		PullNum:      ctx.Pull.Num,
+		Repo:         ctx.BaseRepo.Name,
+		RepoFullName: ctx.BaseRepo.FullName,
+		ProjectName:  ctx.ProjectName,
+		Path:         ctx.RepoRelDir,
+		Workspace:    ctx.Workspace,
+	})
+	Assert(t, !streaming.IsKeyExists(ctx.JobID), "exp job output to be deleted")
+	_, ok = streaming.JobRepoID(ctx.JobID)
+	Assert(t, !ok, "exp repo of job to be deleted")
+}
+
+func TestProjectCommandOutputHandler_RemoteJobDeregistered(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb, err := redis.New(mr.Host(), mr.Server().Addr().Port, "", false, false, 0)
+	Ok(t, err)
+	defer rdb.Close() // nolint: errcheck
+	store := rdb.JobOutputStore()
+	Ok(t, store.Append("job", "line"))
+
+	handler := createReplicaOutputHandler(t, store)
+	ch := make(chan string, 1000)
+	done := make(chan struct{})
+	go func() {
+		handler.Register(context.Background(), "job", ch)
+		close(done)
+	}()
+	Equals(t, "line", <-ch)
+
+	handler.Deregister("job", ch)
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for the receiver to stop following the job")
+	}
+}
+
+func TestProjectCommandOutputHandler_RemoteJobRequestEnded(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb, err := redis.New(mr.Host(), mr.Server().Addr().Port, "", false, false, 0)
+	Ok(t, err)
+	defer rdb.Close() // nolint: errcheck
+	store := rdb.JobOutputStore()
+	Ok(t, store.Append("job", "line"))
+
+	t.Log("the receiver stops following the job even if it was deregistered before it was registered")
+	handler := createReplicaOutputHandler(t, store)
+	ch := make(chan string, 1000)
+	handler.Deregister("job", ch)
+	registerCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+	done := make(chan struct{})
+	go func() {
+		handler.Register(registerCtx, "job", ch)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for the receiver to stop following the job")
+	}
+}
+
+// blockingOutputStore records what's published to it. Its Append blocks
+// until unblock is closed.
+type blockingOutputStore struct {
+	unblock chan struct{}
+
+	mu        sync.Mutex
+	lines     []string
+	appends   int
+	completed bool
+}
+
+func (s *blockingOutputStore) Append(_ string, lines ...string) error {
+	<-s.unblock
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lines = append(s.lines, lines...)
+	s.appends++
+	return nil
+}
+
+func (s *blockingOutputStore) Complete(_ string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.completed = true
+	return nil
+}
+
+func (s *blockingOutputStore) Exists(_ string) (bool, error) { return false, nil }
+
+func (s *blockingOutputStore) Follow(_ context.Context, _ string, _ chan string) (bool, error) {
+	return true, nil
+}
+
+func (s *blockingOutputStore) Delete(_ ...string) error { return nil }
+
+func (s *blockingOutputStore) SetRepoID(_ string, _ string) error { return nil }
+
+func (s *blockingOutputStore) RepoID(_ string) (string, error) { return "", nil }
+
+func TestProjectCommandOutputHandler_PublishesInBatches(t *testing.T) {
+	store := &blockingOutputStore{unblock: make(chan struct{})}
+	handler := createReplicaOutputHandler(t, store)
+	ctx := createTestProjectCmdContext(t)
+
+	t.Log("output is handled while the store is slow")
+	var exp []string
+	for i := 0; i < 100; i++ {
+		exp = append(exp, fmt.Sprintf("line %d", i))
+		handler.Send(ctx, exp[i], false)
+	}
+	handler.Send(ctx, "", true)
+	ch := make(chan string, 1000)
+	handler.Register(context.Background(), ctx.JobID, ch)
+	var got []string
+	for line := range ch {
+		got = append(got, line)
+	}
+	Equals(t, exp, got)
+
+	t.Log("queued lines are published together and in order")
+	close(store.unblock)
+	assert.Eventually(t, func() bool {
+		store.mu.Lock()
+		defer store.mu.Unlock()
+		return store.completed
+	}, 5*time.Second, 10*time.Millisecond)
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	Equals(t, exp, store.lines)
+	Assert(t, store.appends < len(exp), "exp lines to be appended in batches, got %d appends", store.appends)
+}
+
+func TestRedisJobOutputStore_AppendBatch(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb, err := redis.New(mr.Host(), mr.Server().Addr().Port, "", false, false, 0)
+	Ok(t, err)
+	defer rdb.Close() // nolint: errcheck
+	store := rdb.JobOutputStore()
+
+	Ok(t, store.Append("job", "line 1", "line 2", "line 3"))
+	Ok(t, store.Append("job"))
+	Ok(t, store.Complete("job"))
+	entries, err := mr.Stream("jobs/job/output")
+	Ok(t, err)
+	Equals(t, 4, len(entries))
+	Assert(t, mr.TTL("jobs/job/output") > 0, "exp output to expire")
+
+	ch := make(chan string, 10)
+	complete, err := store.Follow(context.Background(), "job", ch)
+	Ok(t, err)
+	Assert(t, complete, "exp job to be complete")
+	Equals(t, "line 1", <-ch)
+	Equals(t, "line 2", <-ch)
+	Equals(t, "line 3", <-ch)
+}
@@ -1,6 +1,7 @@
 package jobs
 
 import (
+	"context"
 	"sync"
 	"time"
 
@@ -33,11 +34,16 @@ type JobIDInfo struct {
 type PullInfoWithJobIDs struct {
 	Pull       PullInfo
 	JobIDInfos []JobIDInfo
+	// RepoID is the ID of the pull request's repo, see models.Repo.ID.
+	RepoID string
 }
 
 type JobInfo struct {
 	PullInfo
 	HeadCommit string
+	// RepoID is the ID of the job's repo, see models.Repo.ID. It's used to
+	// check that users can view the job.
+	RepoID string
 }
 
 type ProjectCmdOutputLine struct {
@@ -62,6 +68,18 @@ type AsyncProjectCommandOutputHandler struct {
 
 	// Tracks all the jobs for a pull request which is used for clean up after a pull request is closed.
 	pullToJobMapping sync.Map
+	// jobRepoIDs maps the ID of each job to the ID of its repo.
+	jobRepoIDs sync.Map
+
+	// outputStore, if set, is where output is published so that jobs
+	// running on other replicas can be streamed.
+	outputStore JobOutputStore
+	// storeWrites are the writes waiting to be published to outputStore.
+	storeWrites chan storeWrite
+	// remoteReceivers are the receivers following jobs on other replicas,
+	// mapped to the function that stops them.
+	remoteReceivers     map[chan string]context.CancelFunc
+	remoteReceiversLock sync.Mutex
 }
 
 //go:generate pegomock generate --package mocks -o mocks/mock_project_command_output_handler.go ProjectCommandOutputHandler
@@ -73,8 +91,10 @@ type ProjectCommandOutputHandler interface {
 	SendWorkflowHook(ctx models.WorkflowHookCommandContext, msg string, operationComplete bool)
 
 	// Register registers a channel and blocks until it is caught up. Callers should call this asynchronously when attempting
-	// to read the channel in the same goroutine
-	Register(jobID string, receiver chan string)
+	// to read the channel in the same goroutine. Output of jobs running on
+	// other replicas is streamed until ctx is done or the channel is
+	// deregistered.
+	Register(ctx context.Context, jobID string, receiver chan string)
 
 	// Deregister removes a channel from successive updates and closes it.
 	Deregister(jobID string, receiver chan string)
@@ -89,19 +109,41 @@ type ProjectCommandOutputHandler interface {
 
 	// Returns a map from Pull Requests to Jobs
 	GetPullToJobMapping() []PullInfoWithJobIDs
+
+	// JobRepoID returns the ID of the repo of the job with id jobID, or false
+	// if the job doesn't exist or hasn't output anything yet.
+	JobRepoID(jobID string) (string, bool)
 }
 
 func NewAsyncProjectCommandOutputHandler(
 	projectCmdOutput chan *ProjectCmdOutputLine,
 	logger logging.SimpleLogging,
 ) ProjectCommandOutputHandler {
-	return &AsyncProjectCommandOutputHandler{
+	return NewAsyncProjectCommandOutputHandlerWithStore(projectCmdOutput, logger, nil)
+}
+
+// NewAsyncProjectCommandOutputHandlerWithStore returns a handler that also
+// publishes output to outputStore, and streams jobs running on other
+// replicas from it. If outputStore is nil, only local jobs can be streamed.
+func NewAsyncProjectCommandOutputHandlerWithStore(
+	projectCmdOutput chan *ProjectCmdOutputLine,
+	logger logging.SimpleLogging,
+	outputStore JobOutputStore,
+) ProjectCommandOutputHandler {
+	handler := &AsyncProjectCommandOutputHandler{
 		projectCmdOutput:     projectCmdOutput,
 		logger:               logger,
 		receiverBuffers:      map[string]map[chan string]bool{},
 		projectOutputBuffers: map[string]OutputBuffer{},
 		pullToJobMapping:     sync.Map{},
+		outputStore:          outputStore,
+		remoteReceivers:      map[chan string]context.CancelFunc{},
+	}
+	if outputStore != nil {
+		handler.storeWrites = make(chan storeWrite, storeWritesQueueSize)
+		go handler.publish()
 	}
+	return handler
 }
 
 func (p *AsyncProjectCommandOutputHandler) GetPullToJobMapping() []PullInfoWithJobIDs {
@@ -113,7 +155,7 @@ func (p *AsyncProjectCommandOutputHandler) GetPullToJobMapping() []PullInfoWithJ
 		pullInfo := key.(PullInfo)
 		jobIDMap := value.(map[string]time.Time)
 
-		p := PullInfoWithJobIDs{
+		pullJobs := PullInfoWithJobIDs{
 			Pull:       pullInfo,
 			JobIDInfos: make([]JobIDInfo, 0, len(jobIDMap)),
 		}
@@ -123,10 +165,13 @@ func (p *AsyncProjectCommandOutputHandler) GetPullToJobMapping() []PullInfoWithJ
 				JobID: jobID,
 				Time:  theTime,
 			}
-			p.JobIDInfos = append(p.JobIDInfos, jobIDInfo)
+			pullJobs.JobIDInfos = append(pullJobs.JobIDInfos, jobIDInfo)
+			if repoID, ok := p.jobRepoIDs.Load(jobID); ok {
+				pullJobs.RepoID = repoID.(string)
+			}
 		}
 
-		pullToJobMappings = append(pullToJobMappings, p)
+		pullToJobMappings = append(pullToJobMappings, pullJobs)
 		i++
 		return true
 	})
@@ -134,11 +179,32 @@ func (p *AsyncProjectCommandOutputHandler) GetPullToJobMapping() []PullInfoWithJ
 	return pullToJobMappings
 }
 
+func (p *AsyncProjectCommandOutputHandler) JobRepoID(jobID string) (string, bool) {
+	if repoID, ok := p.jobRepoIDs.Load(jobID); ok {
+		return repoID.(string), true
+	}
+	if p.outputStore == nil {
+		return "", false
+	}
+	repoID, err := p.outputStore.RepoID(jobID)
+	if err != nil {
+		p.logger.Warn("failed getting repo of job %s: %s", jobID, err)
+	}
+	return repoID, repoID != ""
+}
+
 func (p *AsyncProjectCommandOutputHandler) IsKeyExists(key string) bool {
 	p.projectOutputBuffersLock.RLock()
-	defer p.projectOutputBuffersLock.RUnlock()
 	_, ok := p.projectOutputBuffers[key]
-	return ok
+	p.projectOutputBuffersLock.RUnlock()
+	if ok || p.outputStore == nil {
+		return ok
+	}
+	exists, err := p.outputStore.Exists(key)
+	if err != nil {
+		p.logger.Warn("failed checking if job %s exists: %s", key, err)
+	}
+	return exists
 }
 
 func (p *AsyncProjectCommandOutputHandler) Send(ctx command.ProjectContext, msg string, operationComplete bool) {
@@ -146,6 +212,7 @@ func (p *AsyncProjectCommandOutputHandler) Send(ctx command.ProjectContext, msg
 		JobID: ctx.JobID,
 		JobInfo: JobInfo{
 			HeadCommit: ctx.Pull.HeadCommit,
+			RepoID:     ctx.BaseRepo.ID(),
 			PullInfo: PullInfo{
 				PullNum:      ctx.Pull.Num,
 				Repo:         ctx.BaseRepo.Name,
@@ -165,6 +232,7 @@ func (p *AsyncProjectCommandOutputHandler) SendWorkflowHook(ctx models.WorkflowH
 		JobID: ctx.HookID,
 		JobInfo: JobInfo{
 			HeadCommit: ctx.Pull.HeadCommit,
+			RepoID:     ctx.BaseRepo.ID(),
 			PullInfo: PullInfo{
 				PullNum: ctx.Pull.Num,
 				Repo:    ctx.BaseRepo.Name,
@@ -175,14 +243,30 @@ func (p *AsyncProjectCommandOutputHandler) SendWorkflowHook(ctx models.WorkflowH
 	}
 }
 
-func (p *AsyncProjectCommandOutputHandler) Register(jobID string, receiver chan string) {
+func (p *AsyncProjectCommandOutputHandler) Register(ctx context.Context, jobID string, receiver chan string) {
+	if p.outputStore != nil && !p.isLocalJob(jobID) {
+		p.followRemote(ctx, jobID, receiver)
+		return
+	}
 	p.addChan(receiver, jobID)
 }
 
+// isLocalJob returns true if the job with id jobID ran or is running on this
+// replica.
+func (p *AsyncProjectCommandOutputHandler) isLocalJob(jobID string) bool {
+	p.projectOutputBuffersLock.RLock()
+	defer p.projectOutputBuffersLock.RUnlock()
+	_, ok := p.projectOutputBuffers[jobID]
+	return ok
+}
+
 func (p *AsyncProjectCommandOutputHandler) Handle() {
 	for msg := range p.projectCmdOutput {
 		if msg.OperationComplete {
 			p.completeJob(msg.JobID)
+			if p.outputStore != nil {
+				p.storeWrites <- storeWrite{kind: completeWrite, jobIDs: []string{msg.JobID}}
+			}
 			continue
 		}
 
@@ -193,9 +277,15 @@ func (p *AsyncProjectCommandOutputHandler) Handle() {
 		value, _ := p.pullToJobMapping.Load(msg.JobInfo.PullInfo)
 		jobMapping := value.(map[string]time.Time)
 		jobMapping[msg.JobID] = time.Now()
+		if _, loaded := p.jobRepoIDs.LoadOrStore(msg.JobID, msg.JobInfo.RepoID); !loaded && p.outputStore != nil {
+			p.storeWrites <- storeWrite{kind: repoIDWrite, jobIDs: []string{msg.JobID}, value: msg.JobInfo.RepoID}
+		}
 
 		// Forward new message to all receiver channels and output buffer
 		p.writeLogLine(msg.JobID, msg.Line)
+		if p.outputStore != nil {
+			p.storeWrites <- storeWrite{kind: appendWrite, jobIDs: []string{msg.JobID}, value: msg.Line}
+		}
 	}
 }
 
@@ -279,6 +369,7 @@ func (p *AsyncProjectCommandOutputHandler) Deregister(jobID string, ch chan stri
 	p.receiverBuffersLock.Lock()
 	delete(p.receiverBuffers[jobID], ch)
 	p.receiverBuffersLock.Unlock()
+	p.stopRemote(ch)
 }
 
 func (p *AsyncProjectCommandOutputHandler) GetReceiverBufferForPull(jobID string) map[chan string]bool {
@@ -299,7 +390,9 @@ func (p *AsyncProjectCommandOutputHandler) GetJobIDMapForPull(pullInfo PullInfo)
 func (p *AsyncProjectCommandOutputHandler) CleanUp(pullInfo PullInfo) {
 	if value, ok := p.pullToJobMapping.Load(pullInfo); ok {
 		jobMapping := value.(map[string]time.Time)
+		jobIDs := make([]string, 0, len(jobMapping))
 		for jobID := range jobMapping {
+			jobIDs = append(jobIDs, jobID)
 			p.projectOutputBuffersLock.Lock()
 			delete(p.projectOutputBuffers, jobID)
 			p.projectOutputBuffersLock.Unlock()
@@ -307,6 +400,16 @@ func (p *AsyncProjectCommandOutputHandler) CleanUp(pullInfo PullInfo) {
 			p.receiverBuffersLock.Lock()
 			delete(p.receiverBuffers, jobID)
 			p.receiverBuffersLock.Unlock()
+
+			p.jobRepoIDs.Delete(jobID)
+		}
+
+		if p.outputStore != nil {
+			// Delete after the job's queued writes so they don't recreate
+			// its output.
+			done := make(chan struct{})
+			p.storeWrites <- storeWrite{kind: deleteWrite, jobIDs: jobIDs, done: done}
+			<-done
 		}
 
 		// Remove job mapping
@@ -323,7 +426,7 @@ func (p *NoopProjectOutputHandler) Send(_ command.ProjectContext, _ string, _ bo
 func (p *NoopProjectOutputHandler) SendWorkflowHook(_ models.WorkflowHookCommandContext, _ string, _ bool) {
 }
 
-func (p *NoopProjectOutputHandler) Register(_ string, _ chan string) {}
+func (p *NoopProjectOutputHandler) Register(_ context.Context, _ string, _ chan string) {}
 
 func (p *NoopProjectOutputHandler) Deregister(_ string, _ chan string) {}
 
@@ -340,3 +443,7 @@ func (p *NoopProjectOutputHandler) IsKeyExists(_ string) bool {
 func (p *NoopProjectOutputHandler) GetPullToJobMapping() []PullInfoWithJobIDs {
 	return []PullInfoWithJobIDs{}
 }
+
+func (p *NoopProjectOutputHandler) JobRepoID(_ string) (string, bool) {
+	return "", false
+}
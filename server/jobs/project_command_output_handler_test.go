@@ -1,6 +1,7 @@
 package jobs_test
 
 import (
+	"context"
 	"sync"
 	"testing"
 	"time"
@@ -72,7 +73,7 @@ func TestProjectCommandOutputHandler(t *testing.T) {
 		// Note: We call this synchronously because otherwise
 		// there could be a race where we are unable to register the channel
 		// before sending messages due to the way we lock our buffer memory cache
-		projectOutputHandler.Register(ctx.JobID, ch)
+		projectOutputHandler.Register(context.Background(), ctx.JobID, ch)
 
 		wg.Add(1)
 
@@ -121,7 +122,7 @@ func TestProjectCommandOutputHandler(t *testing.T) {
 		// Note: We call this synchronously because otherwise
 		// there could be a race where we are unable to register the channel
 		// before sending messages due to the way we lock our buffer memory cache
-		projectOutputHandler.Register(ctx.JobID, ch)
+		projectOutputHandler.Register(context.Background(), ctx.JobID, ch)
 
 		projectOutputHandler.Send(ctx, Msg, false)
 		wg.Wait()
@@ -144,7 +145,7 @@ func TestProjectCommandOutputHandler(t *testing.T) {
 		// Note: We call this synchronously because otherwise
 		// there could be a race where we are unable to register the channel
 		// before sending messages due to the way we lock our buffer memory cache
-		projectOutputHandler.Register(ctx.JobID, ch)
+		projectOutputHandler.Register(context.Background(), ctx.JobID, ch)
 
 		wg.Add(1)
 
@@ -169,6 +170,14 @@ func TestProjectCommandOutputHandler(t *testing.T) {
 			Workspace:    ctx.Workspace,
 		}
 		wg.Wait() // Must finish reading messages before cleaning up
+
+		repoID, ok := projectOutputHandler.JobRepoID(ctx.JobID)
+		assert.True(t, ok)
+		assert.Equal(t, ctx.BaseRepo.ID(), repoID)
+		mappings := projectOutputHandler.GetPullToJobMapping()
+		assert.Len(t, mappings, 1)
+		assert.Equal(t, ctx.BaseRepo.ID(), mappings[0].RepoID)
+
 		projectOutputHandler.CleanUp(pullContext)
 
 		// Check all the resources are cleaned up.
@@ -178,6 +187,8 @@ func TestProjectCommandOutputHandler(t *testing.T) {
 		assert.Empty(t, dfProjectOutputHandler.GetProjectOutputBuffer(ctx.JobID))
 		assert.Empty(t, dfProjectOutputHandler.GetReceiverBufferForPull(ctx.JobID))
 		assert.Empty(t, dfProjectOutputHandler.GetJobIDMapForPull(pullContext))
+		_, ok = projectOutputHandler.JobRepoID(ctx.JobID)
+		assert.False(t, ok)
 	})
 
 	t.Run("mark operation status complete and close conn buffers for the job", func(t *testing.T) {
@@ -189,7 +200,7 @@ func TestProjectCommandOutputHandler(t *testing.T) {
 		// Note: We call this synchronously because otherwise
 		// there could be a race where we are unable to register the channel
 		// before sending messages due to the way we lock our buffer memory cache
-		projectOutputHandler.Register(ctx.JobID, ch)
+		projectOutputHandler.Register(context.Background(), ctx.JobID, ch)
 
 		// read from channel
 		go func() {
@@ -223,7 +234,7 @@ func TestProjectCommandOutputHandler(t *testing.T) {
 		// Note: We call this synchronously because otherwise
 		// there could be a race where we are unable to register the channel
 		// before sending messages due to the way we lock our buffer memory cache
-		projectOutputHandler.Register(ctx.JobID, ch)
+		projectOutputHandler.Register(context.Background(), ctx.JobID, ch)
 
 		// read from channel
 		go func() {
@@ -247,7 +258,7 @@ func TestProjectCommandOutputHandler(t *testing.T) {
 			opComplete <- true
 		}()
 
-		projectOutputHandler.Register(ctx.JobID, ch2)
+		projectOutputHandler.Register(context.Background(), ctx.JobID, ch2)
 
 		assert.True(t, <-opComplete)
 	})
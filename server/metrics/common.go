@@ -5,4 +5,8 @@ const (
 	ExecutionSuccessMetric = "execution_success"
 	ExecutionErrorMetric   = "execution_error"
 	ExecutionFailureMetric = "execution_failure"
+
+	SchedulerQueueDepthMetric = "queue_depth"
+	SchedulerRunningMetric    = "running"
+	SchedulerWaitTimeMetric   = "wait_time"
 )
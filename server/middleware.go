@@ -17,6 +17,7 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/runatlantis/atlantis/server/auth"
 	"github.com/runatlantis/atlantis/server/logging"
 	"github.com/urfave/negroni/v3"
 )
@@ -24,10 +25,11 @@ import (
 // NewRequestLogger creates a RequestLogger.
 func NewRequestLogger(s *Server) *RequestLogger {
 	return &RequestLogger{
-		s.Logger,
-		s.WebAuthentication,
-		s.WebUsername,
-		s.WebPassword,
+		logger:            s.Logger,
+		WebAuthentication: s.WebAuthentication,
+		WebUsername:       s.WebUsername,
+		WebPassword:       s.WebPassword,
+		OIDC:              s.OIDCAuthenticator,
 	}
 }
 
@@ -38,36 +40,65 @@ type RequestLogger struct {
 	WebAuthentication bool
 	WebUsername       string
 	WebPassword       string
+	// OIDC logs users in with single sign-on. It's nil if single sign-on
+	// isn't enabled. If basic auth is also enabled, it's accepted as a
+	// fallback.
+	OIDC *auth.OIDCAuthenticator
 }
 
 // ServeHTTP implements the middleware function. It logs all requests at DEBUG level.
 func (l *RequestLogger) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
 	l.logger.Debug("%s %s – from %s", r.Method, r.URL.RequestURI(), r.RemoteAddr)
 	allowed := false
-	if !l.WebAuthentication ||
+	basicAuthAttempted := false
+	if (!l.WebAuthentication && l.OIDC == nil) ||
 		r.URL.Path == "/events" ||
 		r.URL.Path == "/healthz" ||
 		r.URL.Path == "/status" ||
-		strings.HasPrefix(r.URL.Path, "/api/") {
+		strings.HasPrefix(r.URL.Path, "/api/") ||
+		(l.OIDC != nil && isOIDCPath(r.URL.Path)) {
 		allowed = true
 	} else {
-		user, pass, ok := r.BasicAuth()
-		if ok {
-			r.SetBasicAuth(user, pass)
-			if user == l.WebUsername && pass == l.WebPassword {
-				l.logger.Debug("[VALID] log in: >> url: %s", r.URL.RequestURI())
+		if l.OIDC != nil {
+			if id, ok := l.OIDC.Authenticate(r); ok {
+				r = r.WithContext(auth.WithIdentity(r.Context(), id))
 				allowed = true
-			} else {
-				allowed = false
-				l.logger.Info("[INVALID] log in attempt: >> url: %s", r.URL.RequestURI())
+			}
+		}
+		if !allowed && l.WebAuthentication {
+			user, pass, ok := r.BasicAuth()
+			if ok {
+				basicAuthAttempted = true
+				r.SetBasicAuth(user, pass)
+				if user == l.WebUsername && pass == l.WebPassword {
+					l.logger.Debug("[VALID] log in: >> url: %s", r.URL.RequestURI())
+					r = r.WithContext(auth.WithIdentity(r.Context(), auth.Identity{Username: user, Method: auth.BasicAuthMethod}))
+					allowed = true
+				} else {
+					allowed = false
+					l.logger.Info("[INVALID] log in attempt: >> url: %s", r.URL.RequestURI())
+				}
 			}
 		}
 	}
 	if !allowed {
-		rw.Header().Set("WWW-Authenticate", `Basic realm="restricted", charset="UTF-8"`)
-		http.Error(rw, "Unauthorized", http.StatusUnauthorized)
+		// Browsers are sent to single sign-on unless they tried basic auth.
+		if l.OIDC != nil && r.Method == http.MethodGet && !basicAuthAttempted {
+			l.OIDC.RedirectToLogin(rw, r)
+		} else {
+			if l.WebAuthentication {
+				rw.Header().Set("WWW-Authenticate", `Basic realm="restricted", charset="UTF-8"`)
+			}
+			http.Error(rw, "Unauthorized", http.StatusUnauthorized)
+		}
 	} else {
 		next(rw, r)
 	}
 	l.logger.Debug("%s %s – respond HTTP %d", r.Method, r.URL.RequestURI(), rw.(negroni.ResponseWriter).Status())
 }
+
+// isOIDCPath returns true if path is one of the single sign-on routes, which
+// must be reachable without logging in.
+func isOIDCPath(path string) bool {
+	return path == auth.LoginPath || path == auth.CallbackPath || path == auth.LogoutPath
+}
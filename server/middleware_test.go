@@ -0,0 +1,117 @@
+package server_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server"
+	"github.com/runatlantis/atlantis/server/auth"
+	"github.com/runatlantis/atlantis/server/auth/authtest"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+	"github.com/urfave/negroni/v3"
+)
+
+func TestRequestLogger_BasicAuth(t *testing.T) {
+	l := server.NewRequestLogger(&server.Server{
+		Logger:            logging.NewNoopLogger(t),
+		WebAuthentication: true,
+		WebUsername:       "user",
+		WebPassword:       "pass",
+	})
+
+	cases := []struct {
+		description string
+		path        string
+		user, pass  string
+		expCode     int
+		expUser     string
+	}{
+		{"no credentials", "/", "", "", http.StatusUnauthorized, ""},
+		{"invalid credentials", "/", "user", "wrong", http.StatusUnauthorized, ""},
+		{"valid credentials", "/", "user", "pass", http.StatusOK, "user"},
+		{"events", "/events", "", "", http.StatusOK, ""},
+		{"api", "/api/locks", "", "", http.StatusOK, ""},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			req := httptest.NewRequest("GET", c.path, nil)
+			if c.user != "" {
+				req.SetBasicAuth(c.user, c.pass)
+			}
+			w, user := serveRequestLogger(l, req)
+			Equals(t, c.expCode, w.Code)
+			Equals(t, c.expUser, user)
+		})
+	}
+}
+
+func TestRequestLogger_OIDC(t *testing.T) {
+	provider := authtest.NewProvider(t)
+	atlantisURL, _ := url.Parse("https://atlantis.example.com/basepath")
+	sessions, err := auth.NewSessionManager("secret", atlantisURL)
+	Ok(t, err)
+	authenticator, err := auth.NewOIDCAuthenticator(auth.OIDCConfig{
+		IssuerURL: provider.URL(),
+		ClientID:  authtest.ClientID,
+	}, sessions, atlantisURL, logging.NewNoopLogger(t), http.DefaultClient)
+	Ok(t, err)
+	l := server.NewRequestLogger(&server.Server{
+		Logger:            logging.NewNoopLogger(t),
+		WebAuthentication: true,
+		WebUsername:       "user",
+		WebPassword:       "pass",
+		OIDCAuthenticator: authenticator,
+	})
+
+	t.Log("browsers are redirected to log in")
+	w, _ := serveRequestLogger(l, httptest.NewRequest("GET", "/pulls?repo=a", nil))
+	Equals(t, http.StatusFound, w.Code)
+	Equals(t, "/basepath/login?return_to=%2Fpulls%3Frepo%3Da", w.Header().Get("Location"))
+
+	t.Log("other requests are unauthorized")
+	w, _ = serveRequestLogger(l, httptest.NewRequest("DELETE", "/locks?id=a", nil))
+	Equals(t, http.StatusUnauthorized, w.Code)
+
+	t.Log("the login routes, events and the API don't need a session")
+	for _, path := range []string{auth.LoginPath, auth.CallbackPath, auth.LogoutPath, "/events", "/api/locks"} {
+		w, _ = serveRequestLogger(l, httptest.NewRequest("GET", path, nil))
+		Equals(t, http.StatusOK, w.Code)
+	}
+
+	t.Log("basic auth is a fallback")
+	req := httptest.NewRequest("GET", "/", nil)
+	req.SetBasicAuth("user", "pass")
+	w, user := serveRequestLogger(l, req)
+	Equals(t, http.StatusOK, w.Code)
+	Equals(t, "user", user)
+	req.SetBasicAuth("user", "wrong")
+	w, _ = serveRequestLogger(l, req)
+	Equals(t, http.StatusUnauthorized, w.Code)
+
+	t.Log("sessions are accepted")
+	rec := httptest.NewRecorder()
+	Ok(t, sessions.Set(rec, auth.Identity{Username: "sso-user", Method: auth.OIDCMethod}))
+	req = httptest.NewRequest("GET", "/", nil)
+	for _, c := range rec.Result().Cookies() {
+		req.AddCookie(c)
+	}
+	w, user = serveRequestLogger(l, req)
+	Equals(t, http.StatusOK, w.Code)
+	Equals(t, "sso-user", user)
+}
+
+// serveRequestLogger serves req with l and returns the response and the name
+// of the user that the request was authenticated as.
+func serveRequestLogger(l *server.RequestLogger, req *http.Request) (*httptest.ResponseRecorder, string) {
+	rec := httptest.NewRecorder()
+	var user string
+	l.ServeHTTP(negroni.NewResponseWriter(rec), req, func(w http.ResponseWriter, r *http.Request) {
+		if id, ok := auth.IdentityFromContext(r.Context()); ok {
+			user = id.Username
+		}
+	})
+	return rec, user
+}
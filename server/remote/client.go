@@ -0,0 +1,146 @@
+package remote
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// SecretHeader is the header workers send the worker secret in.
+const SecretHeader = "X-Atlantis-Token"
+
+// ParseRunnerSecrets parses the secrets of runners, formatted as
+// {runner}:{secret},{runner}:{secret}. A runner can have several secrets.
+func ParseRunnerSecrets(s string) (map[string][]string, error) {
+	secrets := make(map[string][]string)
+	if s == "" {
+		return secrets, nil
+	}
+	for _, pair := range strings.Split(s, ",") {
+		runner, secret, found := strings.Cut(pair, ":")
+		if !found || runner == "" || secret == "" {
+			// Don't put the pair in the error since it's probably a secret.
+			return nil, errors.New("runner secrets must be formatted as {runner}:{secret},{runner}:{secret}")
+		}
+		secrets[runner] = append(secrets[runner], secret)
+	}
+	return secrets, nil
+}
+
+// The routes of the worker API on the Atlantis server.
+const (
+	ClaimRoute  = "/api/workers/claim"
+	OutputRoute = "/api/workers/jobs/{id}/output"
+	ResultRoute = "/api/workers/jobs/{id}/result"
+)
+
+// Client talks to the worker API of an Atlantis server.
+type Client struct {
+	// URL is the URL of the Atlantis server.
+	URL        string
+	Secret     string
+	HTTPClient *http.Client
+}
+
+// Claim claims a job for the worker described by req. It returns nil if
+// there was no job for it before the server stopped waiting for one.
+func (c *Client) Claim(ctx context.Context, req ClaimRequest) (*Job, error) {
+	var job Job
+	found, err := c.post(ctx, ClaimRoute, req, &job)
+	if err != nil || !found {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// SendOutput sends lines of the output of the job with id jobID. It returns
+// ErrUnknownJob if the server isn't waiting for the job anymore.
+func (c *Client) SendOutput(ctx context.Context, jobID string, req OutputRequest) error {
+	_, err := c.post(ctx, jobRoute(OutputRoute, jobID), req, nil)
+	return err
+}
+
+// Complete reports the result of the job with id jobID. It returns
+// ErrUnknownJob if the server isn't waiting for the job anymore.
+func (c *Client) Complete(ctx context.Context, jobID string, req ResultRequest) error {
+	_, err := c.post(ctx, jobRoute(ResultRoute, jobID), req, nil)
+	return err
+}
+
+// post posts body as JSON to route and decodes the response into resp. It
+// returns false if the response had no content.
+func (c *Client) post(ctx context.Context, route string, body interface{}, resp interface{}) (bool, error) {
+	reqBody, err := json.Marshal(body)
+	if err != nil {
+		return false, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(c.URL, "/")+route, bytes.NewReader(reqBody))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(SecretHeader, c.Secret)
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	httpResp, err := httpClient.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer httpResp.Body.Close() // nolint: errcheck
+
+	switch {
+	case httpResp.StatusCode == http.StatusNoContent:
+		return false, nil
+	case httpResp.StatusCode == http.StatusNotFound:
+		return false, ErrUnknownJob
+	case httpResp.StatusCode != http.StatusOK:
+		var errResp struct {
+			Error string `json:"error"`
+		}
+		respBody, _ := io.ReadAll(httpResp.Body)
+		msg := string(respBody)
+		if json.Unmarshal(respBody, &errResp) == nil && errResp.Error != "" {
+			msg = errResp.Error
+		}
+		return false, &StatusError{StatusCode: httpResp.StatusCode, Status: httpResp.Status, Message: msg}
+	}
+	if resp == nil {
+		return true, nil
+	}
+	return true, json.NewDecoder(httpResp.Body).Decode(resp)
+}
+
+// StatusError is returned when the Atlantis server responds with an
+// unexpected status.
+type StatusError struct {
+	StatusCode int
+	Status     string
+	Message    string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Status, e.Message)
+}
+
+// Permanent returns true if retrying the request won't help, ex. because
+// the worker secret is wrong.
+func (e *StatusError) Permanent() bool {
+	switch e.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusConflict:
+		return true
+	}
+	return false
+}
+
+func jobRoute(route string, jobID string) string {
+	return strings.Replace(route, "{id}", url.PathEscape(jobID), 1)
+}
@@ -0,0 +1,23 @@
+package remote_test
+
+import (
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/remote"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestParseRunnerSecrets(t *testing.T) {
+	secrets, err := remote.ParseRunnerSecrets("")
+	Ok(t, err)
+	Equals(t, map[string][]string{}, secrets)
+
+	secrets, err = remote.ParseRunnerSecrets("prod:a:b,staging:c,prod:d")
+	Ok(t, err)
+	Equals(t, map[string][]string{"prod": {"a:b", "d"}, "staging": {"c"}}, secrets)
+
+	for _, invalid := range []string{"secret", "prod:", ":secret", "prod:a,"} {
+		_, err = remote.ParseRunnerSecrets(invalid)
+		ErrEquals(t, "runner secrets must be formatted as {runner}:{secret},{runner}:{secret}", err)
+	}
+}
@@ -0,0 +1,121 @@
+// Package remote runs project commands on workers. Workers pull jobs from
+// the Atlantis server over HTTP, run them in their own clone, stream their
+// output back and report their result.
+package remote
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/events/command"
+)
+
+// The project commands a job can run. They're the methods of
+// events.ProjectCommandRunner that run steps.
+const (
+	PlanJob        = "plan"
+	PolicyCheckJob = "policy_check"
+	ApplyJob       = "apply"
+	VersionJob     = "version"
+	ImportJob      = "import"
+	StateRmJob     = "state_rm"
+	CustomJob      = "custom"
+)
+
+// Job is a project command for a worker to run.
+type Job struct {
+	ID string
+	// Type is the project command to run, ex. PlanJob.
+	Type string
+	// CommandName is the name of the command, ex. "plan". Command names are
+	// sent as strings because the numbers of custom commands are only
+	// meaningful within one process.
+	CommandName string
+	// Context is the context of the project. Its Log and Scope aren't sent.
+	Context command.ProjectContext
+	// EnvPolicy is the env_policy of the server's repo config, which
+	// decides which of the worker's environment variables the job's
+	// commands inherit.
+	EnvPolicy valid.EnvPolicy
+	// Files are written to the worker's clone before the job runs, ex. the
+	// planfile an apply applies.
+	Files []File
+}
+
+// File is a file in a clone, which is sent along with a job or its result.
+type File struct {
+	// Path is relative to the root of the clone.
+	Path    string
+	Content []byte
+	// Removed is true if the file was removed by the job.
+	Removed bool
+}
+
+// Result is the result of a job.
+type Result struct {
+	// ProjectResult is the result of the project command without its error,
+	// which is sent as Error instead.
+	ProjectResult command.ProjectResult
+	Error         string
+	// Files are the files the job created, changed or removed that the
+	// Atlantis server needs, ex. the planfile a plan created.
+	Files []File
+}
+
+// NewResult returns the Result of a job that returned res.
+func NewResult(res command.ProjectResult, files []File) Result {
+	r := Result{ProjectResult: res, Files: files}
+	if res.Error != nil {
+		r.Error = res.Error.Error()
+		r.ProjectResult.Error = nil
+	}
+	return r
+}
+
+// ToProjectResult returns the project result of r with its error.
+func (r Result) ToProjectResult() command.ProjectResult {
+	res := r.ProjectResult
+	if r.Error != "" {
+		res.Error = errors.New(r.Error)
+	}
+	return res
+}
+
+// ParseCommandName returns the command.Name of job, registering it if it's a
+// custom command.
+func (j Job) ParseCommandName() (command.Name, error) {
+	if j.Type == CustomJob {
+		if j.Context.CustomCommand == nil {
+			return 0, fmt.Errorf("custom job %s has no custom command", j.ID)
+		}
+		return command.RegisterCustom(j.Context.CustomCommand.Name), nil
+	}
+	return command.ParseCommandName(j.CommandName)
+}
+
+// ClaimRequest is the body of the request workers make to claim a job.
+type ClaimRequest struct {
+	// Worker is the name of the worker. It must be unique.
+	Worker string
+	// Runners are the runner labels of the projects the worker runs.
+	Runners []string
+	// Version is the Atlantis version of the worker. It must be the same as
+	// the server's.
+	Version string
+}
+
+// OutputRequest is the body of the request workers make to send the output
+// of a job. It also tells the server that the worker is still running the
+// job, so workers send it regularly even if there's no new output.
+type OutputRequest struct {
+	Worker string
+	Lines  []string
+}
+
+// ResultRequest is the body of the request workers make to report the
+// result of a job.
+type ResultRequest struct {
+	Worker string
+	Result Result
+}
@@ -0,0 +1,98 @@
+package remote_test
+
+import (
+	"encoding/json"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	version "github.com/hashicorp/go-version"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/remote"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+// Jobs are sent to workers as JSON so everything in the project context that
+// workers use must survive being encoded.
+func TestJob_JSON(t *testing.T) {
+	tfVersion := version.Must(version.NewVersion("1.5.7"))
+	job := remote.Job{
+		ID:          "id",
+		Type:        remote.PlanJob,
+		CommandName: command.Plan.String(),
+		Context: command.ProjectContext{
+			CommandName: command.Plan,
+			Pull: models.PullRequest{
+				Num:      1,
+				BaseRepo: models.Repo{FullName: "owner/repo", CloneURL: "https://github.com/owner/repo.git"},
+			},
+			RepoRelDir:       "dir",
+			Workspace:        "default",
+			TerraformVersion: tfVersion,
+			Steps: []valid.Step{
+				{StepName: "init"},
+				{
+					StepName:   "run",
+					RunCommand: "make plan",
+					Timeout:    time.Minute,
+					When:       `"deploy" in pull.labels`,
+					Retry: &valid.StepRetry{
+						Attempts:      3,
+						Delay:         time.Second,
+						Backoff:       valid.ExponentialBackoff,
+						OnOutputRegex: regexp.MustCompile("rate limit"),
+					},
+				},
+			},
+			PolicySets:  valid.PolicySets{Version: tfVersion, PolicySets: []valid.PolicySet{{Name: "policy", Path: "policies"}}},
+			PlanTimeout: time.Hour,
+			Runner:      "prod-account",
+			PullLabels:  []string{},
+		},
+		Files: []remote.File{{Path: "dir/default.tfplan", Content: []byte("plan")}},
+	}
+
+	encoded, err := json.Marshal(job)
+	Ok(t, err)
+	var decoded remote.Job
+	Ok(t, json.Unmarshal(encoded, &decoded))
+
+	Equals(t, job.Files, decoded.Files)
+	Equals(t, "1.5.7", decoded.Context.TerraformVersion.String())
+	Equals(t, "1.5.7", decoded.Context.PolicySets.Version.String())
+	Equals(t, "rate limit", decoded.Context.Steps[1].Retry.OnOutputRegex.String())
+	decoded.Context.TerraformVersion = tfVersion
+	decoded.Context.PolicySets.Version = tfVersion
+	decoded.Context.Steps[1].Retry.OnOutputRegex = job.Context.Steps[1].Retry.OnOutputRegex
+	Equals(t, job.Context, decoded.Context)
+}
+
+func TestJob_ParseCommandName(t *testing.T) {
+	cmdName, err := remote.Job{Type: remote.ApplyJob, CommandName: "apply"}.ParseCommandName()
+	Ok(t, err)
+	Equals(t, command.Apply, cmdName)
+
+	cmdName, err = remote.Job{
+		Type:    remote.CustomJob,
+		Context: command.ProjectContext{CustomCommand: &valid.CustomCommand{Name: "drift"}},
+	}.ParseCommandName()
+	Ok(t, err)
+	Equals(t, "drift", cmdName.String())
+}
+
+func TestResult_ToProjectResult(t *testing.T) {
+	res := remote.NewResult(command.ProjectResult{Error: errors.New("failed"), Workspace: "default"}, nil)
+	Equals(t, "failed", res.Error)
+	Assert(t, res.ProjectResult.Error == nil, "exp error to be sent as a string")
+
+	encoded, err := json.Marshal(res)
+	Ok(t, err)
+	var decoded remote.Result
+	Ok(t, json.Unmarshal(encoded, &decoded))
+	projectRes := decoded.ToProjectResult()
+	ErrEquals(t, "failed", projectRes.Error)
+	Equals(t, "default", projectRes.Workspace)
+}
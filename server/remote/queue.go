@@ -0,0 +1,277 @@
+package remote
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/utils"
+)
+
+const (
+	// DefaultClaimTimeout is how long jobs wait for a worker by default.
+	DefaultClaimTimeout = 10 * time.Minute
+	// DefaultLeaseTimeout is how long workers can go without sending
+	// output for a job by default.
+	DefaultLeaseTimeout = time.Minute
+)
+
+// ErrUnknownJob is returned when a worker reports on a job that it isn't
+// running, ex. because the job timed out.
+var ErrUnknownJob = errors.New("unknown job")
+
+// OutputSender is sent the output workers send for a job.
+type OutputSender interface {
+	Send(ctx command.ProjectContext, msg string, operationComplete bool)
+}
+
+// Queue holds the jobs waiting for workers and the jobs workers are
+// running. Jobs only exist in memory, so workers must pull them from the
+// Atlantis server that queued them.
+type Queue struct {
+	// ClaimTimeout is how long a job waits for a worker before it fails.
+	ClaimTimeout time.Duration
+	// LeaseTimeout is how long a worker can go without sending output for a
+	// job before the job fails.
+	LeaseTimeout time.Duration
+	// Output is sent the output of jobs.
+	Output OutputSender
+
+	mu      sync.Mutex
+	pending []*queuedJob
+	running map[string]*queuedJob
+	// planners are the workers that planned each project. Other commands
+	// for the project run on the same worker because they need the clone
+	// the plan ran in, ex. its .terraform directory.
+	planners map[projectKey]string
+	// wake is closed when a job is queued to wake up waiting workers.
+	wake chan struct{}
+}
+
+type queuedJob struct {
+	job Job
+	// ctx is the server's context of the project, which output is sent
+	// with.
+	ctx command.ProjectContext
+	// planner is the worker that must run the job, if any.
+	planner  string
+	queuedAt time.Time
+	worker   string
+	lastSeen time.Time
+	done     chan Result
+}
+
+// NewQueue returns a Queue that sends the output of jobs to output.
+func NewQueue(output OutputSender) *Queue {
+	return &Queue{
+		ClaimTimeout: DefaultClaimTimeout,
+		LeaseTimeout: DefaultLeaseTimeout,
+		Output:       output,
+		running:      make(map[string]*queuedJob),
+		planners:     make(map[projectKey]string),
+		wake:         make(chan struct{}),
+	}
+}
+
+// Run queues job for a worker with the runner label of ctx and waits for its
+// result. It returns an error if no worker claims the job within
+// ClaimTimeout or if the worker stops sending output for it.
+func (q *Queue) Run(ctx command.ProjectContext, job Job) (Result, error) {
+	job.ID = uuid.New().String()
+	// The logger and stats scope can't be sent to workers.
+	job.Context.Log = nil
+	job.Context.Scope = nil
+	qj := &queuedJob{
+		job:      job,
+		ctx:      ctx,
+		queuedAt: time.Now(),
+		done:     make(chan Result, 1),
+	}
+
+	q.mu.Lock()
+	if job.Type != PlanJob {
+		qj.planner = q.planners[newProjectKey(ctx)]
+	}
+	q.pending = append(q.pending, qj)
+	close(q.wake)
+	q.wake = make(chan struct{})
+	q.mu.Unlock()
+	ctx.Log.Info("queued %s job %s for a worker with runner %q", job.Type, job.ID, ctx.Runner)
+
+	ticker := time.NewTicker(q.checkInterval())
+	defer ticker.Stop()
+	for {
+		select {
+		case res := <-qj.done:
+			return res, nil
+		case <-ticker.C:
+			if err := q.expire(qj); err != nil {
+				return Result{}, err
+			}
+		}
+	}
+}
+
+// Claim returns the oldest job that the worker described by req can run. If
+// there's none, it waits for one until ctx is done and then returns nil.
+func (q *Queue) Claim(ctx context.Context, req ClaimRequest) *Job {
+	for {
+		q.mu.Lock()
+		for i, qj := range q.pending {
+			if !utils.SlicesContains(req.Runners, qj.ctx.Runner) || (qj.planner != "" && qj.planner != req.Worker) {
+				continue
+			}
+			q.pending = append(q.pending[:i], q.pending[i+1:]...)
+			qj.worker = req.Worker
+			qj.lastSeen = time.Now()
+			q.running[qj.job.ID] = qj
+			q.mu.Unlock()
+			qj.ctx.Log.Info("worker %q claimed %s job %s", req.Worker, qj.job.Type, qj.job.ID)
+			job := qj.job
+			return &job
+		}
+		wake := q.wake
+		q.mu.Unlock()
+
+		select {
+		case <-wake:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// JobRunner returns the runner label of the job with id jobID, which a
+// worker is running.
+func (q *Queue) JobRunner(jobID string) (string, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	qj, ok := q.running[jobID]
+	if !ok {
+		return "", ErrUnknownJob
+	}
+	return qj.ctx.Runner, nil
+}
+
+// SendOutput sends the output of the job with id jobID, which worker is
+// running.
+func (q *Queue) SendOutput(worker string, jobID string, lines []string) error {
+	q.mu.Lock()
+	qj, ok := q.running[jobID]
+	if !ok || qj.worker != worker {
+		q.mu.Unlock()
+		return ErrUnknownJob
+	}
+	qj.lastSeen = time.Now()
+	q.mu.Unlock()
+
+	for _, line := range lines {
+		q.Output.Send(qj.ctx, line, false)
+	}
+	return nil
+}
+
+// Complete reports the result of the job with id jobID, which worker was
+// running.
+func (q *Queue) Complete(worker string, jobID string, res Result) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	qj, ok := q.running[jobID]
+	if !ok || qj.worker != worker {
+		return ErrUnknownJob
+	}
+	delete(q.running, jobID)
+
+	key := newProjectKey(qj.ctx)
+	switch {
+	case qj.job.Type == PlanJob && res.ProjectResult.PlanSuccess != nil:
+		q.planners[key] = worker
+	case qj.job.Type == PlanJob:
+		// There's no plan to apply so the project can be planned again by
+		// any worker.
+		delete(q.planners, key)
+	case qj.job.Type == ApplyJob && res.Error == "" && res.ProjectResult.Failure == "":
+		// The project needs to be planned again before it can be applied
+		// again, possibly by another worker.
+		delete(q.planners, key)
+	}
+	qj.done <- res
+	return nil
+}
+
+// CleanUpPull forgets the workers that planned the projects of pull once
+// it's closed. It never returns an error.
+func (q *Queue) CleanUpPull(repo models.Repo, pull models.PullRequest) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for key := range q.planners {
+		if key.repo == repo.FullName && key.pull == pull.Num {
+			delete(q.planners, key)
+		}
+	}
+	return nil
+}
+
+// expire removes qj from the queue and returns an error if it waited for a
+// worker for longer than ClaimTimeout or if its worker stopped sending
+// output.
+func (q *Queue) expire(qj *queuedJob) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if qj.worker == "" {
+		if time.Since(qj.queuedAt) < q.ClaimTimeout {
+			return nil
+		}
+		for i, pending := range q.pending {
+			if pending == qj {
+				q.pending = append(q.pending[:i], q.pending[i+1:]...)
+				break
+			}
+		}
+		if qj.planner != "" {
+			return fmt.Errorf("worker %q, which planned this project, didn't pick up the job within %s, run plan again to plan it on another worker", qj.planner, q.ClaimTimeout)
+		}
+		return fmt.Errorf("no worker with runner %q picked up the job within %s", qj.ctx.Runner, q.ClaimTimeout)
+	}
+	if _, ok := q.running[qj.job.ID]; !ok || time.Since(qj.lastSeen) < q.LeaseTimeout {
+		// If the job isn't running anymore, its result is waiting in done.
+		return nil
+	}
+	delete(q.running, qj.job.ID)
+	return fmt.Errorf("worker %q stopped responding while running the job", qj.worker)
+}
+
+// checkInterval is how often jobs are checked for timeouts.
+func (q *Queue) checkInterval() time.Duration {
+	interval := q.LeaseTimeout / 4
+	if q.ClaimTimeout/4 < interval {
+		interval = q.ClaimTimeout / 4
+	}
+	if interval <= 0 {
+		interval = time.Second
+	}
+	return interval
+}
+
+// projectKey identifies a project in a pull request.
+type projectKey struct {
+	repo      string
+	pull      int
+	workspace string
+	dir       string
+}
+
+// newProjectKey returns the key of the project of ctx.
+func newProjectKey(ctx command.ProjectContext) projectKey {
+	return projectKey{
+		repo:      ctx.Pull.BaseRepo.FullName,
+		pull:      ctx.Pull.Num,
+		workspace: ctx.Workspace,
+		dir:       ctx.RepoRelDir,
+	}
+}
@@ -0,0 +1,168 @@
+package remote_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+	"github.com/runatlantis/atlantis/server/remote"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+type outputRecorder struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (o *outputRecorder) Send(_ command.ProjectContext, msg string, _ bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.lines = append(o.lines, msg)
+}
+
+func queueCtx(t *testing.T, runner string) command.ProjectContext {
+	return command.ProjectContext{
+		Log:        logging.NewNoopLogger(t),
+		Pull:       models.PullRequest{Num: 1, BaseRepo: models.Repo{FullName: "owner/repo"}},
+		RepoRelDir: ".",
+		Workspace:  "default",
+		Runner:     runner,
+	}
+}
+
+// runAsync runs job on q in the background and returns a channel that's sent
+// its result.
+func runAsync(q *remote.Queue, ctx command.ProjectContext, job remote.Job) chan error {
+	done := make(chan error, 1)
+	go func() {
+		_, err := q.Run(ctx, job)
+		done <- err
+	}()
+	return done
+}
+
+func claim(t *testing.T, q *remote.Queue, req remote.ClaimRequest, wait time.Duration) *remote.Job {
+	ctx, cancel := context.WithTimeout(context.Background(), wait)
+	defer cancel()
+	return q.Claim(ctx, req)
+}
+
+func TestQueue_RunsJobOnWorkerWithRunner(t *testing.T) {
+	output := &outputRecorder{}
+	q := remote.NewQueue(output)
+	ctx := queueCtx(t, "prod")
+
+	resCh := make(chan remote.Result, 1)
+	go func() {
+		res, err := q.Run(ctx, remote.Job{Type: remote.PlanJob})
+		Ok(t, err)
+		resCh <- res
+	}()
+
+	Assert(t, claim(t, q, remote.ClaimRequest{Worker: "staging-1", Runners: []string{"staging"}}, 100*time.Millisecond) == nil,
+		"exp worker with another runner not to get the job")
+	job := claim(t, q, remote.ClaimRequest{Worker: "prod-1", Runners: []string{"staging", "prod"}}, time.Second)
+	Assert(t, job != nil, "exp worker with the runner to get the job")
+	Equals(t, remote.PlanJob, job.Type)
+
+	runner, err := q.JobRunner(job.ID)
+	Ok(t, err)
+	Equals(t, "prod", runner)
+	Ok(t, q.SendOutput("prod-1", job.ID, []string{"line 1", "line 2"}))
+	Equals(t, remote.ErrUnknownJob, q.SendOutput("prod-2", job.ID, []string{"line 3"}))
+	Ok(t, q.Complete("prod-1", job.ID, remote.Result{ProjectResult: command.ProjectResult{Workspace: "default"}}))
+	Equals(t, remote.ErrUnknownJob, q.Complete("prod-1", job.ID, remote.Result{}))
+	_, err = q.JobRunner(job.ID)
+	Equals(t, remote.ErrUnknownJob, err)
+
+	res := <-resCh
+	Equals(t, "default", res.ProjectResult.Workspace)
+	Equals(t, []string{"line 1", "line 2"}, output.lines)
+}
+
+func TestQueue_RunsOtherCommandsOnPlanner(t *testing.T) {
+	q := remote.NewQueue(&outputRecorder{})
+	ctx := queueCtx(t, "prod")
+
+	done := runAsync(q, ctx, remote.Job{Type: remote.PlanJob})
+	job := claim(t, q, remote.ClaimRequest{Worker: "prod-1", Runners: []string{"prod"}}, time.Second)
+	Ok(t, q.Complete("prod-1", job.ID, remote.Result{ProjectResult: command.ProjectResult{PlanSuccess: &models.PlanSuccess{}}}))
+	Ok(t, <-done)
+
+	t.Log("the apply runs on the worker that planned the project")
+	done = runAsync(q, ctx, remote.Job{Type: remote.ApplyJob})
+	Assert(t, claim(t, q, remote.ClaimRequest{Worker: "prod-2", Runners: []string{"prod"}}, 100*time.Millisecond) == nil,
+		"exp another worker not to get the job")
+	job = claim(t, q, remote.ClaimRequest{Worker: "prod-1", Runners: []string{"prod"}}, time.Second)
+	Assert(t, job != nil, "exp the planner to get the job")
+	Ok(t, q.Complete("prod-1", job.ID, remote.Result{}))
+	Ok(t, <-done)
+
+	t.Log("once applied, any worker can run the next job")
+	done = runAsync(q, ctx, remote.Job{Type: remote.VersionJob})
+	job = claim(t, q, remote.ClaimRequest{Worker: "prod-2", Runners: []string{"prod"}}, time.Second)
+	Assert(t, job != nil, "exp any worker to get the job")
+	Ok(t, q.Complete("prod-2", job.ID, remote.Result{}))
+	Ok(t, <-done)
+}
+
+func TestQueue_ForgetsPlanners(t *testing.T) {
+	q := remote.NewQueue(&outputRecorder{})
+	ctx := queueCtx(t, "prod")
+	plan := func(worker string, res remote.Result) {
+		done := runAsync(q, ctx, remote.Job{Type: remote.PlanJob})
+		job := claim(t, q, remote.ClaimRequest{Worker: worker, Runners: []string{"prod"}}, time.Second)
+		Ok(t, q.Complete(worker, job.ID, res))
+		Ok(t, <-done)
+	}
+	assertAnyWorker := func() {
+		done := runAsync(q, ctx, remote.Job{Type: remote.VersionJob})
+		job := claim(t, q, remote.ClaimRequest{Worker: "prod-2", Runners: []string{"prod"}}, time.Second)
+		Assert(t, job != nil, "exp any worker to get the job")
+		Ok(t, q.Complete("prod-2", job.ID, remote.Result{}))
+		Ok(t, <-done)
+	}
+
+	t.Log("a failed plan forgets the planner")
+	plan("prod-1", remote.Result{ProjectResult: command.ProjectResult{PlanSuccess: &models.PlanSuccess{}}})
+	plan("prod-1", remote.Result{Error: "failed"})
+	assertAnyWorker()
+
+	t.Log("closing the pull request forgets the planner")
+	plan("prod-1", remote.Result{ProjectResult: command.ProjectResult{PlanSuccess: &models.PlanSuccess{}}})
+	Ok(t, q.CleanUpPull(ctx.Pull.BaseRepo, models.PullRequest{Num: 2, BaseRepo: ctx.Pull.BaseRepo}))
+	done := runAsync(q, ctx, remote.Job{Type: remote.VersionJob})
+	Assert(t, claim(t, q, remote.ClaimRequest{Worker: "prod-2", Runners: []string{"prod"}}, 100*time.Millisecond) == nil,
+		"exp the planner of another pull request to be kept")
+	job := claim(t, q, remote.ClaimRequest{Worker: "prod-1", Runners: []string{"prod"}}, time.Second)
+	Ok(t, q.Complete("prod-1", job.ID, remote.Result{}))
+	Ok(t, <-done)
+	Ok(t, q.CleanUpPull(ctx.Pull.BaseRepo, ctx.Pull))
+	assertAnyWorker()
+}
+
+func TestQueue_ClaimTimeout(t *testing.T) {
+	q := remote.NewQueue(&outputRecorder{})
+	q.ClaimTimeout = 50 * time.Millisecond
+
+	err := <-runAsync(q, queueCtx(t, "prod"), remote.Job{Type: remote.PlanJob})
+	ErrContains(t, `no worker with runner "prod" picked up the job`, err)
+	Assert(t, claim(t, q, remote.ClaimRequest{Worker: "prod-1", Runners: []string{"prod"}}, 50*time.Millisecond) == nil,
+		"exp job to be removed from the queue")
+}
+
+func TestQueue_LeaseTimeout(t *testing.T) {
+	q := remote.NewQueue(&outputRecorder{})
+	q.LeaseTimeout = 50 * time.Millisecond
+
+	done := runAsync(q, queueCtx(t, "prod"), remote.Job{Type: remote.PlanJob})
+	job := claim(t, q, remote.ClaimRequest{Worker: "prod-1", Runners: []string{"prod"}}, time.Second)
+	Assert(t, job != nil, "exp worker to get the job")
+
+	ErrContains(t, `worker "prod-1" stopped responding while running the job`, <-done)
+	Equals(t, remote.ErrUnknownJob, q.Complete("prod-1", job.ID, remote.Result{}))
+}
@@ -0,0 +1,314 @@
+package remote
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/core/runtime"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs"
+	"github.com/runatlantis/atlantis/server/events/webhooks"
+)
+
+// ProjectCommandRunner runs the project commands of projects that have a
+// runner label on workers, and those of other projects with Local.
+//
+// Locks are taken, and apply webhooks are sent, by the Atlantis server
+// rather than by workers.
+type ProjectCommandRunner struct {
+	Local events.ProjectCommandRunner
+	// Queue is where jobs are queued for workers. If nil, workers aren't
+	// enabled and commands for projects with a runner label fail.
+	Queue            *Queue
+	Locker           events.ProjectLocker
+	LockURLGenerator events.LockURLGenerator
+	// WorkingDir is the Atlantis server's working dir, which the planfiles
+	// made by workers are copied into.
+	WorkingDir events.WorkingDir
+	VCSClient  vcs.Client
+	Webhooks   events.WebhooksSender
+	// GithubCredentials, if set, are used to give workers a token to clone
+	// with, since GitHub App clone URLs have no credentials.
+	GithubCredentials vcs.GithubCredentials
+	// DefaultPlanTimeout and DefaultApplyTimeout are sent to workers as the
+	// timeouts of projects that don't set them.
+	DefaultPlanTimeout  time.Duration
+	DefaultApplyTimeout time.Duration
+	// EnvPolicy is sent to workers with jobs so that commands inherit the
+	// worker's environment like they would the server's.
+	EnvPolicy valid.EnvPolicy
+}
+
+// Plan runs terraform plan for the project described by ctx.
+func (r *ProjectCommandRunner) Plan(ctx command.ProjectContext) command.ProjectResult {
+	if ctx.Runner == "" {
+		return r.Local.Plan(ctx)
+	}
+	lockAttempt, res := r.lock(ctx, command.Plan)
+	if lockAttempt == nil {
+		return res
+	}
+	res = r.run(ctx, PlanJob, command.Plan)
+	if res.PlanSuccess != nil {
+		res.PlanSuccess.LockURL = r.LockURLGenerator.GenerateLockURL(lockAttempt.LockKey)
+	} else if res.Error != nil {
+		if unlockErr := lockAttempt.UnlockFn(); unlockErr != nil {
+			ctx.Log.Err("error unlocking state after plan error: %v", unlockErr)
+		}
+	}
+	return res
+}
+
+// PolicyCheck runs the policy checks for the project described by ctx.
+func (r *ProjectCommandRunner) PolicyCheck(ctx command.ProjectContext) command.ProjectResult {
+	if ctx.Runner == "" {
+		return r.Local.PolicyCheck(ctx)
+	}
+	lockAttempt, res := r.lock(ctx, command.PolicyCheck)
+	if lockAttempt == nil {
+		return res
+	}
+	res = r.run(ctx, PolicyCheckJob, command.PolicyCheck)
+	if res.PolicyCheckResults != nil {
+		res.PolicyCheckResults.LockURL = r.LockURLGenerator.GenerateLockURL(lockAttempt.LockKey)
+	}
+	return res
+}
+
+// Apply runs terraform apply for the project described by ctx.
+func (r *ProjectCommandRunner) Apply(ctx command.ProjectContext) command.ProjectResult {
+	if ctx.Runner == "" {
+		return r.Local.Apply(ctx)
+	}
+	res := r.run(ctx, ApplyJob, command.Apply)
+	r.Webhooks.Send(ctx.Log, webhooks.ApplyResult{ // nolint: errcheck
+		Workspace: ctx.Workspace,
+		User:      ctx.User,
+		Repo:      ctx.Pull.BaseRepo,
+		Pull:      ctx.Pull,
+		Success:   res.Error == nil && res.Failure == "",
+		Directory: ctx.RepoRelDir,
+	})
+	return res
+}
+
+// ApprovePolicies approves the failing policies of the project described by
+// ctx. It doesn't run any steps so it always runs on the Atlantis server.
+func (r *ProjectCommandRunner) ApprovePolicies(ctx command.ProjectContext) command.ProjectResult {
+	return r.Local.ApprovePolicies(ctx)
+}
+
+// Version runs terraform version for the project described by ctx.
+func (r *ProjectCommandRunner) Version(ctx command.ProjectContext) command.ProjectResult {
+	if ctx.Runner == "" {
+		return r.Local.Version(ctx)
+	}
+	return r.run(ctx, VersionJob, command.Version)
+}
+
+// Import runs terraform import for the project described by ctx.
+func (r *ProjectCommandRunner) Import(ctx command.ProjectContext) command.ProjectResult {
+	if ctx.Runner == "" {
+		return r.Local.Import(ctx)
+	}
+	if lockAttempt, res := r.lock(ctx, command.Import); lockAttempt == nil {
+		return res
+	}
+	return r.run(ctx, ImportJob, command.Import)
+}
+
+// StateRm runs terraform state rm for the project described by ctx.
+func (r *ProjectCommandRunner) StateRm(ctx command.ProjectContext) command.ProjectResult {
+	if ctx.Runner == "" {
+		return r.Local.StateRm(ctx)
+	}
+	if lockAttempt, res := r.lock(ctx, command.State); lockAttempt == nil {
+		return res
+	}
+	return r.run(ctx, StateRmJob, command.State)
+}
+
+// Custom runs the stage of the custom command for the project described by
+// ctx.
+func (r *ProjectCommandRunner) Custom(ctx command.ProjectContext) command.ProjectResult {
+	if ctx.Runner == "" {
+		return r.Local.Custom(ctx)
+	}
+	if ctx.CustomCommand != nil && ctx.CustomCommand.RequireLock {
+		if lockAttempt, res := r.lock(ctx, ctx.CommandName); lockAttempt == nil {
+			return res
+		}
+	}
+	return r.run(ctx, CustomJob, ctx.CommandName)
+}
+
+// lock acquires the Atlantis lock of the project described by ctx. If it
+// can't, it returns a nil response and the result of cmdName.
+func (r *ProjectCommandRunner) lock(ctx command.ProjectContext, cmdName command.Name) (*events.TryLockResponse, command.ProjectResult) {
+	lockAttempt, err := r.Locker.TryLock(ctx.Log, ctx.Pull, ctx.User, ctx.Workspace, models.NewProject(ctx.Pull.BaseRepo.FullName, ctx.RepoRelDir), ctx.RepoLocking)
+	if err != nil {
+		return nil, newProjectResult(ctx, cmdName, errors.Wrap(err, "acquiring lock"))
+	}
+	if !lockAttempt.LockAcquired {
+		res := newProjectResult(ctx, cmdName, nil)
+		res.Failure = lockAttempt.LockFailureReason
+		return nil, res
+	}
+	ctx.Log.Debug("acquired lock for project")
+	return lockAttempt, command.ProjectResult{}
+}
+
+// run runs a job of type jobType for the project described by ctx on a
+// worker and returns its result.
+func (r *ProjectCommandRunner) run(ctx command.ProjectContext, jobType string, cmdName command.Name) command.ProjectResult {
+	if r.Queue == nil {
+		return newProjectResult(ctx, cmdName, errors.Errorf("project must run on a worker with runner %q but workers aren't enabled", ctx.Runner))
+	}
+	job, err := r.newJob(ctx, jobType, cmdName)
+	if err != nil {
+		return newProjectResult(ctx, cmdName, err)
+	}
+	result, err := r.Queue.Run(ctx, job)
+	if err != nil {
+		return newProjectResult(ctx, cmdName, err)
+	}
+	if err := r.writeFiles(ctx, result.Files); err != nil {
+		return newProjectResult(ctx, cmdName, errors.Wrap(err, "copying files from worker"))
+	}
+	res := result.ToProjectResult()
+	// Custom commands are numbered differently by each process.
+	res.Command = cmdName
+	return res
+}
+
+// newJob returns the job a worker runs to run cmdName for the project
+// described by ctx.
+func (r *ProjectCommandRunner) newJob(ctx command.ProjectContext, jobType string, cmdName command.Name) (Job, error) {
+	jobCtx := ctx
+	if jobCtx.PlanTimeout == 0 {
+		jobCtx.PlanTimeout = r.DefaultPlanTimeout
+	}
+	if jobCtx.ApplyTimeout == 0 {
+		jobCtx.ApplyTimeout = r.DefaultApplyTimeout
+	}
+
+	// Workers can't ask the VCS for the labels when expressions use, so
+	// they're sent along.
+	for _, step := range ctx.Steps {
+		if step.When == "" {
+			continue
+		}
+		labels, err := r.VCSClient.GetPullLabels(ctx.Pull.BaseRepo, ctx.Pull)
+		if err != nil {
+			return Job{}, errors.Wrap(err, "getting pull request labels")
+		}
+		jobCtx.PullLabels = append([]string{}, labels...)
+		break
+	}
+
+	if r.GithubCredentials != nil {
+		token, err := r.GithubCredentials.GetToken()
+		if err != nil {
+			return Job{}, errors.Wrap(err, "getting GitHub token for worker")
+		}
+		jobCtx.HeadRepo.CloneURL = withGithubToken(jobCtx.HeadRepo.CloneURL, token)
+		jobCtx.Pull.BaseRepo.CloneURL = withGithubToken(jobCtx.Pull.BaseRepo.CloneURL, token)
+		jobCtx.BaseRepo.CloneURL = withGithubToken(jobCtx.BaseRepo.CloneURL, token)
+	}
+
+	job := Job{
+		Type:        jobType,
+		CommandName: cmdName.String(),
+		Context:     jobCtx,
+		EnvPolicy:   r.EnvPolicy,
+	}
+	if jobType == ApplyJob || jobType == PolicyCheckJob {
+		planfile, err := r.readPlanfile(ctx)
+		if err != nil {
+			return Job{}, errors.Wrap(err, "reading planfile")
+		}
+		if planfile != nil {
+			job.Files = append(job.Files, *planfile)
+		}
+	}
+	return job, nil
+}
+
+// readPlanfile returns the planfile of the project described by ctx in the
+// Atlantis server's clone, or nil if there's none.
+func (r *ProjectCommandRunner) readPlanfile(ctx command.ProjectContext) (*File, error) {
+	repoDir, err := r.WorkingDir.GetWorkingDir(ctx.Pull.BaseRepo, ctx.Pull, ctx.Workspace)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(ctx.RepoRelDir, runtime.GetPlanFilename(ctx.Workspace, ctx.ProjectName))
+	content, err := os.ReadFile(filepath.Join(repoDir, path)) // #nosec
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &File{Path: filepath.ToSlash(path), Content: content}, nil
+}
+
+// writeFiles writes the files a worker sent into the Atlantis server's
+// clone so that, ex., pending plans are found there.
+func (r *ProjectCommandRunner) writeFiles(ctx command.ProjectContext, files []File) error {
+	if len(files) == 0 {
+		return nil
+	}
+	repoDir, err := r.WorkingDir.GetWorkingDir(ctx.Pull.BaseRepo, ctx.Pull, ctx.Workspace)
+	if err != nil {
+		return err
+	}
+	return writeFiles(repoDir, files)
+}
+
+// writeFiles writes files into the clone at repoDir.
+func writeFiles(repoDir string, files []File) error {
+	for _, f := range files {
+		if !filepath.IsLocal(filepath.FromSlash(f.Path)) {
+			return errors.Errorf("%q is not a path in the clone", f.Path)
+		}
+		path := filepath.Join(repoDir, filepath.FromSlash(f.Path))
+		if f.Removed {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, f.Content, 0600); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// withGithubToken adds token to cloneURL if it has no credentials, like the
+// clone URLs of GitHub Apps.
+func withGithubToken(cloneURL string, token string) string {
+	return strings.Replace(cloneURL, "://:@", "://x-access-token:"+token+"@", 1)
+}
+
+func newProjectResult(ctx command.ProjectContext, cmdName command.Name, err error) command.ProjectResult {
+	return command.ProjectResult{
+		Command:     cmdName,
+		Error:       err,
+		RepoRelDir:  ctx.RepoRelDir,
+		Workspace:   ctx.Workspace,
+		ProjectName: ctx.ProjectName,
+	}
+}
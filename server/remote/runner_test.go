@@ -0,0 +1,144 @@
+package remote_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/petergtz/pegomock/v4"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/mocks"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+	"github.com/runatlantis/atlantis/server/remote"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func newTestRunner(t *testing.T, queue *remote.Queue) (*remote.ProjectCommandRunner, *mocks.MockProjectCommandRunner, string) {
+	RegisterMockTestingT(t)
+	local := mocks.NewMockProjectCommandRunner()
+	locker := mocks.NewMockProjectLocker()
+	lockURLGenerator := mocks.NewMockLockURLGenerator()
+	workingDir := mocks.NewMockWorkingDir()
+	repoDir := t.TempDir()
+
+	When(locker.TryLock(
+		Any[logging.SimpleLogging](),
+		Any[models.PullRequest](),
+		Any[models.User](),
+		Any[string](),
+		Any[models.Project](),
+		AnyBool(),
+	)).ThenReturn(&events.TryLockResponse{
+		LockAcquired: true,
+		LockKey:      "lock-key",
+		UnlockFn:     func() error { return nil },
+	}, nil)
+	When(lockURLGenerator.GenerateLockURL("lock-key")).ThenReturn("https://atlantis/lock")
+	When(workingDir.GetWorkingDir(
+		Any[models.Repo](),
+		Any[models.PullRequest](),
+		Any[string](),
+	)).ThenReturn(repoDir, nil)
+
+	return &remote.ProjectCommandRunner{
+		Local:            local,
+		Queue:            queue,
+		Locker:           locker,
+		LockURLGenerator: lockURLGenerator,
+		WorkingDir:       workingDir,
+		Webhooks:         mocks.NewMockWebhooksSender(),
+	}, local, repoDir
+}
+
+// fakeWorker claims one job from q and completes it with res.
+func fakeWorker(t *testing.T, q *remote.Queue, res remote.Result) chan remote.Job {
+	jobs := make(chan remote.Job, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		job := q.Claim(ctx, remote.ClaimRequest{Worker: "prod-1", Runners: []string{"prod"}})
+		if job == nil {
+			close(jobs)
+			return
+		}
+		jobs <- *job
+		Ok(t, q.Complete("prod-1", job.ID, res))
+	}()
+	return jobs
+}
+
+func TestProjectCommandRunner_RunsProjectsWithoutRunnerLocally(t *testing.T) {
+	runner, local, _ := newTestRunner(t, remote.NewQueue(&outputRecorder{}))
+	ctx := queueCtx(t, "")
+	When(local.Plan(ctx)).ThenReturn(command.ProjectResult{Workspace: "local"})
+
+	res := runner.Plan(ctx)
+	Equals(t, "local", res.Workspace)
+}
+
+func TestProjectCommandRunner_WorkersDisabled(t *testing.T) {
+	runner, _, _ := newTestRunner(t, nil)
+
+	res := runner.Plan(queueCtx(t, "prod"))
+	ErrEquals(t, `project must run on a worker with runner "prod" but workers aren't enabled`, res.Error)
+}
+
+func TestProjectCommandRunner_Plan(t *testing.T) {
+	q := remote.NewQueue(&outputRecorder{})
+	runner, _, repoDir := newTestRunner(t, q)
+	runner.EnvPolicy = valid.EnvPolicy{Allow: []string{"AWS_*"}}
+	jobs := fakeWorker(t, q, remote.Result{
+		ProjectResult: command.ProjectResult{PlanSuccess: &models.PlanSuccess{TerraformOutput: "No changes."}},
+		Files:         []remote.File{{Path: "default.tfplan", Content: []byte("plan")}},
+	})
+
+	res := runner.Plan(queueCtx(t, "prod"))
+	Ok(t, res.Error)
+	Equals(t, command.Plan, res.Command)
+	Equals(t, "No changes.", res.PlanSuccess.TerraformOutput)
+	Equals(t, "https://atlantis/lock", res.PlanSuccess.LockURL)
+
+	job := <-jobs
+	Equals(t, remote.PlanJob, job.Type)
+	Equals(t, "plan", job.CommandName)
+	Assert(t, job.Context.Log == nil, "exp logger not to be sent")
+	Equals(t, valid.EnvPolicy{Allow: []string{"AWS_*"}}, job.EnvPolicy)
+
+	planfile, err := os.ReadFile(filepath.Join(repoDir, "default.tfplan"))
+	Ok(t, err)
+	Equals(t, "plan", string(planfile))
+}
+
+func TestProjectCommandRunner_Apply(t *testing.T) {
+	q := remote.NewQueue(&outputRecorder{})
+	runner, _, repoDir := newTestRunner(t, q)
+	Ok(t, os.WriteFile(filepath.Join(repoDir, "default.tfplan"), []byte("plan"), 0600))
+	jobs := fakeWorker(t, q, remote.Result{
+		ProjectResult: command.ProjectResult{ApplySuccess: "Apply complete!"},
+	})
+
+	res := runner.Apply(queueCtx(t, "prod"))
+	Ok(t, res.Error)
+	Equals(t, "Apply complete!", res.ApplySuccess)
+
+	job := <-jobs
+	Equals(t, remote.ApplyJob, job.Type)
+	Equals(t, []remote.File{{Path: "default.tfplan", Content: []byte("plan")}}, job.Files)
+}
+
+func TestProjectCommandRunner_RejectsFilesOutsideClone(t *testing.T) {
+	q := remote.NewQueue(&outputRecorder{})
+	runner, _, _ := newTestRunner(t, q)
+	fakeWorker(t, q, remote.Result{
+		ProjectResult: command.ProjectResult{PlanSuccess: &models.PlanSuccess{}},
+		Files:         []remote.File{{Path: "../outside", Content: []byte("oops")}},
+	})
+
+	res := runner.Plan(queueCtx(t, "prod"))
+	ErrContains(t, `"../outside" is not a path in the clone`, res.Error)
+}
@@ -0,0 +1,363 @@
+package remote
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/core/runtime"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/jobs"
+	"github.com/runatlantis/atlantis/server/logging"
+	tally "github.com/uber-go/tally/v4"
+)
+
+const (
+	// DefaultCloneTTL is how long workers keep clones that no job used by
+	// default.
+	DefaultCloneTTL = 7 * 24 * time.Hour
+	// outputInterval is how often workers send the output of a job.
+	outputInterval = time.Second
+	// heartbeatInterval is how often workers tell the server that they're
+	// still running a job that has no new output.
+	heartbeatInterval = 10 * time.Second
+	// retryDelay is how long workers wait before claiming a job again after
+	// they failed to.
+	retryDelay = 5 * time.Second
+	// cleanInterval is how often workers delete old clones.
+	cleanInterval = time.Hour
+)
+
+// Worker runs the jobs an Atlantis server queues for its runner labels.
+type Worker struct {
+	// Name identifies the worker. Other commands for a project run on the
+	// worker that planned it, so it must be unique and stay the same when
+	// the worker restarts.
+	Name    string
+	Runners []string
+	// Version is the Atlantis version of the worker.
+	Version string
+	Client  *Client
+	// ProjectCommandRunner runs jobs in the worker's clones. The output
+	// handler of its step runners must be Output.
+	ProjectCommandRunner events.ProjectCommandRunner
+	Output               *OutputForwarder
+	WorkingDir           events.WorkingDir
+	// DataDir is where WorkingDir keeps its clones.
+	DataDir string
+	// CloneTTL is how long clones that no job used are kept for. If 0,
+	// they're kept forever.
+	CloneTTL time.Duration
+	// SetEnvPolicy, if set, applies the env policy of a job to
+	// ProjectCommandRunner and WorkingDir before the job runs. Workers run
+	// one job at a time.
+	SetEnvPolicy func(valid.EnvPolicy)
+	Logger       logging.SimpleLogging
+
+	lastClean time.Time
+}
+
+// Run claims and runs jobs until ctx is done. A job that's running when ctx
+// is done is finished first. It returns an error if the server rejects the
+// worker, ex. because its secret or version is wrong.
+func (w *Worker) Run(ctx context.Context) error {
+	w.Logger.Info("worker %q running jobs for runners %v from %s", w.Name, w.Runners, w.Client.URL)
+	for ctx.Err() == nil {
+		job, err := w.Client.Claim(ctx, ClaimRequest{Worker: w.Name, Runners: w.Runners, Version: w.Version})
+		if ctx.Err() != nil {
+			return nil
+		}
+		var statusErr *StatusError
+		if errors.As(err, &statusErr) && statusErr.Permanent() {
+			return err
+		}
+		if err != nil {
+			w.Logger.Warn("claiming job: %s", err)
+			select {
+			case <-time.After(retryDelay):
+			case <-ctx.Done():
+			}
+			continue
+		}
+		if job != nil {
+			w.runJob(*job)
+		}
+		if w.CloneTTL > 0 && time.Since(w.lastClean) > cleanInterval {
+			w.cleanClones()
+			w.lastClean = time.Now()
+		}
+	}
+	return nil
+}
+
+// runJob runs job, streaming its output to the server, and reports its
+// result.
+func (w *Worker) runJob(job Job) {
+	ctx := job.Context
+	ctx.Log = w.Logger.With("repo", ctx.Pull.BaseRepo.FullName, "pull", ctx.Pull.Num, "job", job.ID)
+	ctx.Scope = tally.NoopScope
+	ctx.Log.Info("running %s job for project at dir %q workspace %q", job.Type, ctx.RepoRelDir, ctx.Workspace)
+
+	w.Output.start(ctx.JobID)
+	stopOutput := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		w.sendOutput(ctx, job.ID, stopOutput)
+	}()
+
+	res := w.run(ctx, job)
+
+	close(stopOutput)
+	wg.Wait()
+	if err := w.Client.Complete(context.Background(), job.ID, ResultRequest{Worker: w.Name, Result: res}); err != nil {
+		ctx.Log.Err("reporting result of job: %s", err)
+		return
+	}
+	ctx.Log.Info("finished %s job", job.Type)
+}
+
+// run runs job in the worker's clone and returns its result.
+func (w *Worker) run(ctx command.ProjectContext, job Job) Result {
+	cmdName, err := job.ParseCommandName()
+	if err != nil {
+		return NewResult(command.ProjectResult{Error: err}, nil)
+	}
+	ctx.CommandName = cmdName
+	if w.SetEnvPolicy != nil {
+		w.SetEnvPolicy(job.EnvPolicy)
+	}
+
+	if len(job.Files) > 0 {
+		// If the project hasn't been cloned, the command fails like it would
+		// on the server.
+		if repoDir, err := w.WorkingDir.GetWorkingDir(ctx.Pull.BaseRepo, ctx.Pull, ctx.Workspace); err == nil {
+			if err := writeFiles(repoDir, job.Files); err != nil {
+				return NewResult(newProjectResult(ctx, cmdName, fmt.Errorf("writing files from server: %w", err)), nil)
+			}
+		}
+	}
+
+	var res command.ProjectResult
+	switch job.Type {
+	case PlanJob:
+		res = w.ProjectCommandRunner.Plan(ctx)
+	case PolicyCheckJob:
+		res = w.ProjectCommandRunner.PolicyCheck(ctx)
+	case ApplyJob:
+		res = w.ProjectCommandRunner.Apply(ctx)
+	case VersionJob:
+		res = w.ProjectCommandRunner.Version(ctx)
+	case ImportJob:
+		res = w.ProjectCommandRunner.Import(ctx)
+	case StateRmJob:
+		res = w.ProjectCommandRunner.StateRm(ctx)
+	case CustomJob:
+		res = w.ProjectCommandRunner.Custom(ctx)
+	default:
+		return NewResult(newProjectResult(ctx, cmdName, fmt.Errorf("unknown job type %q", job.Type)), nil)
+	}
+
+	var files []File
+	switch job.Type {
+	case PlanJob, ImportJob, StateRmJob:
+		// These create or remove the planfile, which the server needs to
+		// know about to find pending plans.
+		planfile, err := w.planfile(ctx)
+		if err != nil {
+			return NewResult(newProjectResult(ctx, cmdName, fmt.Errorf("reading planfile: %w", err)), nil)
+		}
+		if planfile != nil {
+			files = append(files, *planfile)
+		}
+	}
+	w.touchClone(ctx)
+	return NewResult(res, files)
+}
+
+// planfile returns the planfile of the project described by ctx, which is
+// marked as removed if it doesn't exist, or nil if the project hasn't been
+// cloned.
+func (w *Worker) planfile(ctx command.ProjectContext) (*File, error) {
+	repoDir, err := w.WorkingDir.GetWorkingDir(ctx.Pull.BaseRepo, ctx.Pull, ctx.Workspace)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(ctx.RepoRelDir, runtime.GetPlanFilename(ctx.Workspace, ctx.ProjectName))
+	content, err := os.ReadFile(filepath.Join(repoDir, path)) // #nosec
+	if os.IsNotExist(err) {
+		return &File{Path: filepath.ToSlash(path), Removed: true}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &File{Path: filepath.ToSlash(path), Content: content}, nil
+}
+
+// sendOutput sends the output of the job with id jobID until stop is
+// closed. It sends it at least every heartbeatInterval so that the server
+// knows the job is still running.
+func (w *Worker) sendOutput(ctx command.ProjectContext, jobID string, stop chan struct{}) {
+	ticker := time.NewTicker(outputInterval)
+	defer ticker.Stop()
+	lastSent := time.Now()
+	for {
+		stopped := false
+		select {
+		case <-ticker.C:
+		case <-stop:
+			stopped = true
+		}
+		lines := w.Output.take(ctx.JobID, stopped)
+		if len(lines) > 0 || stopped || time.Since(lastSent) >= heartbeatInterval {
+			if err := w.Client.SendOutput(context.Background(), jobID, OutputRequest{Worker: w.Name, Lines: lines}); err != nil {
+				ctx.Log.Warn("sending output of job: %s", err)
+			}
+			lastSent = time.Now()
+		}
+		if stopped {
+			return
+		}
+	}
+}
+
+// touchClone marks the clone of the project described by ctx as used.
+func (w *Worker) touchClone(ctx command.ProjectContext) {
+	repoDir, err := w.WorkingDir.GetWorkingDir(ctx.Pull.BaseRepo, ctx.Pull, ctx.Workspace)
+	if err != nil {
+		return
+	}
+	now := time.Now()
+	if err := os.Chtimes(repoDir, now, now); err != nil {
+		ctx.Log.Warn("marking clone as used: %s", err)
+	}
+}
+
+// cleanClones deletes the clones that no job used within CloneTTL. Clones
+// are the directories with a .git directory under the repos directory of
+// DataDir.
+func (w *Worker) cleanClones() {
+	reposDir := filepath.Join(w.DataDir, "repos")
+	err := filepath.WalkDir(reposDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if _, err := os.Stat(filepath.Join(path, ".git")); err != nil {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if time.Since(info.ModTime()) > w.CloneTTL {
+			w.Logger.Info("deleting clone %s that wasn't used for %s", path, w.CloneTTL)
+			if err := os.RemoveAll(path); err != nil {
+				return err
+			}
+		}
+		return filepath.SkipDir
+	})
+	if err != nil {
+		w.Logger.Warn("deleting old clones: %s", err)
+	}
+}
+
+// OutputForwarder collects the output of the jobs a worker runs so that it
+// can be sent to the Atlantis server. It implements
+// jobs.ProjectCommandOutputHandler for the worker's step runners.
+type OutputForwarder struct {
+	mu sync.Mutex
+	// lines are the lines that haven't been sent yet by the JobID of the
+	// project context.
+	lines map[string][]string
+}
+
+// NewOutputForwarder returns an empty OutputForwarder.
+func NewOutputForwarder() *OutputForwarder {
+	return &OutputForwarder{lines: make(map[string][]string)}
+}
+
+func (f *OutputForwarder) start(jobID string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.lines[jobID] = nil
+}
+
+// take returns the lines of the job with id jobID that haven't been sent
+// yet. If done, the job is forgotten.
+func (f *OutputForwarder) take(jobID string, done bool) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	lines := f.lines[jobID]
+	if done {
+		delete(f.lines, jobID)
+	} else if _, ok := f.lines[jobID]; ok {
+		f.lines[jobID] = nil
+	}
+	return lines
+}
+
+// Send collects msg if it's output of a job the worker is running.
+func (f *OutputForwarder) Send(ctx command.ProjectContext, msg string, _ bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if lines, ok := f.lines[ctx.JobID]; ok {
+		f.lines[ctx.JobID] = append(lines, msg)
+	}
+}
+
+// SendWorkflowHook does nothing since workers don't run workflow hooks.
+func (f *OutputForwarder) SendWorkflowHook(_ models.WorkflowHookCommandContext, _ string, _ bool) {}
+
+// Register does nothing since output is streamed by the Atlantis server.
+func (f *OutputForwarder) Register(_ context.Context, _ string, _ chan string) {}
+
+// Deregister does nothing since output is streamed by the Atlantis server.
+func (f *OutputForwarder) Deregister(_ string, _ chan string) {}
+
+// IsKeyExists returns false since output is streamed by the Atlantis
+// server.
+func (f *OutputForwarder) IsKeyExists(_ string) bool { return false }
+
+// Handle does nothing since output is sent by the worker.
+func (f *OutputForwarder) Handle() {}
+
+// CleanUp does nothing since the output of each job is forgotten once it's
+// sent.
+func (f *OutputForwarder) CleanUp(_ jobs.PullInfo) {}
+
+// GetPullToJobMapping returns nothing since output is streamed by the
+// Atlantis server.
+func (f *OutputForwarder) GetPullToJobMapping() []jobs.PullInfoWithJobIDs { return nil }
+func (f *OutputForwarder) JobRepoID(_ string) (string, bool)              { return "", false }
+
+// NoopProjectLocker is the events.ProjectLocker of workers. Locks are taken by
+// the Atlantis server before it queues a job, so workers always acquire them.
+type NoopProjectLocker struct{}
+
+// TryLock acquires no lock and returns that it was acquired.
+func (NoopProjectLocker) TryLock(_ logging.SimpleLogging, _ models.PullRequest, _ models.User, _ string, project models.Project, _ bool) (*events.TryLockResponse, error) {
+	return &events.TryLockResponse{
+		LockAcquired: true,
+		UnlockFn:     func() error { return nil },
+		LockKey:      project.String(),
+	}, nil
+}
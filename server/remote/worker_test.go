@@ -0,0 +1,118 @@
+package remote_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	. "github.com/petergtz/pegomock/v4"
+	"github.com/runatlantis/atlantis/server/controllers"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/mocks"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+	"github.com/runatlantis/atlantis/server/remote"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+// newTestServer returns the URL of a server with the worker API for q.
+func newTestServer(t *testing.T, q *remote.Queue, version string) string {
+	c := &controllers.WorkersController{
+		Queue:           q,
+		Secret:          []byte("secret"),
+		AtlantisVersion: version,
+		Logger:          logging.NewNoopLogger(t),
+		ClaimWait:       100 * time.Millisecond,
+	}
+	router := mux.NewRouter()
+	router.HandleFunc(remote.ClaimRoute, c.Claim).Methods("POST")
+	router.HandleFunc(remote.OutputRoute, c.Output).Methods("POST")
+	router.HandleFunc(remote.ResultRoute, c.Result).Methods("POST")
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+func TestWorker_RunsJobs(t *testing.T) {
+	RegisterMockTestingT(t)
+	output := &outputRecorder{}
+	q := remote.NewQueue(output)
+	url := newTestServer(t, q, "1.0.0")
+
+	repoDir := t.TempDir()
+	workingDir := mocks.NewMockWorkingDir()
+	When(workingDir.GetWorkingDir(
+		Any[models.Repo](),
+		Any[models.PullRequest](),
+		Any[string](),
+	)).ThenReturn(repoDir, nil)
+	forwarder := remote.NewOutputForwarder()
+	projectCmdRunner := mocks.NewMockProjectCommandRunner()
+	When(projectCmdRunner.Plan(Any[command.ProjectContext]())).Then(func(params []Param) ReturnValues {
+		ctx := params[0].(command.ProjectContext)
+		forwarder.Send(ctx, "Terraform will perform the following actions:", false)
+		Ok(t, os.WriteFile(filepath.Join(repoDir, "default.tfplan"), []byte("plan"), 0600))
+		return ReturnValues{command.ProjectResult{Command: ctx.CommandName, PlanSuccess: &models.PlanSuccess{TerraformOutput: "1 to add"}}}
+	})
+
+	var envPolicy valid.EnvPolicy
+	worker := &remote.Worker{
+		Name:                 "prod-1",
+		Runners:              []string{"prod"},
+		Version:              "1.0.0",
+		Client:               &remote.Client{URL: url, Secret: "secret"},
+		ProjectCommandRunner: projectCmdRunner,
+		Output:               forwarder,
+		WorkingDir:           workingDir,
+		SetEnvPolicy:         func(p valid.EnvPolicy) { envPolicy = p },
+		Logger:               logging.NewNoopLogger(t),
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- worker.Run(ctx) }()
+
+	jobCtx := queueCtx(t, "prod")
+	jobCtx.JobID = "job-id"
+	res, err := q.Run(jobCtx, remote.Job{
+		Type:        remote.PlanJob,
+		CommandName: "plan",
+		Context:     jobCtx,
+		EnvPolicy:   valid.EnvPolicy{Deny: []string{"AWS_*"}},
+	})
+	Ok(t, err)
+	cancel()
+	Ok(t, <-done)
+
+	projectRes := res.ToProjectResult()
+	Ok(t, projectRes.Error)
+	Equals(t, command.Plan, projectRes.Command)
+	Equals(t, "1 to add", projectRes.PlanSuccess.TerraformOutput)
+	Equals(t, []remote.File{{Path: "default.tfplan", Content: []byte("plan")}}, res.Files)
+	Equals(t, []string{"Terraform will perform the following actions:"}, output.lines)
+	Equals(t, valid.EnvPolicy{Deny: []string{"AWS_*"}}, envPolicy)
+}
+
+func TestWorker_StopsWhenRejected(t *testing.T) {
+	url := newTestServer(t, remote.NewQueue(&outputRecorder{}), "1.0.0")
+	worker := &remote.Worker{
+		Name:    "prod-1",
+		Runners: []string{"prod"},
+		Logger:  logging.NewNoopLogger(t),
+	}
+
+	t.Log("the secret must match")
+	worker.Version = "1.0.0"
+	worker.Client = &remote.Client{URL: url, Secret: "wrong"}
+	ErrContains(t, "401 Unauthorized", worker.Run(context.Background()))
+
+	t.Log("the version must match")
+	worker.Version = "0.9.0"
+	worker.Client = &remote.Client{URL: url, Secret: "secret"}
+	ErrContains(t, `worker version "0.9.0" doesn't match server version "1.0.0"`, worker.Run(context.Background()))
+}
@@ -17,6 +17,16 @@ type ExecutorService struct {
 
 	// jobs
 	jobs []JobDefinition
+
+	// leader, if set, decides whether jobs that must only run on one
+	// replica run on this one.
+	leader Leader
+}
+
+// Leader knows if this replica is the leader. It's implemented by
+// LeaderElector.
+type Leader interface {
+	IsLeader() bool
 }
 
 func NewExecutorService(
@@ -42,9 +52,20 @@ func (s *ExecutorService) AddJob(jd JobDefinition) {
 	s.jobs = append(s.jobs, jd)
 }
 
+// SetLeader sets what decides if LeaderOnly jobs run on this replica. If it's
+// never set, they always run.
+func (s *ExecutorService) SetLeader(leader Leader) {
+	s.leader = leader
+}
+
 type JobDefinition struct {
 	Job    Job
 	Period time.Duration
+	// LeaderOnly jobs only run on the leader when several replicas share a
+	// database. Jobs that act on the shared database should be LeaderOnly,
+	// jobs that act on the replica itself, ex. its credentials or its
+	// runtime stats, shouldn't.
+	LeaderOnly bool
 }
 
 func (s *ExecutorService) Run() {
@@ -95,6 +116,9 @@ func (s *ExecutorService) runScheduledJob(ctx context.Context, wg *sync.WaitGrou
 				s.log.Warn("Received interrupt, cancelling job")
 				return
 			case <-ticker.C:
+				if jd.LeaderOnly && s.leader != nil && !s.leader.IsLeader() {
+					continue
+				}
 				jd.Job.Run()
 			}
 		}
@@ -46,3 +46,23 @@ func TestExecutorService_Run(t *testing.T) {
 		})
 	}
 }
+
+type fakeLeader bool
+
+func (l fakeLeader) IsLeader() bool {
+	return bool(l)
+}
+
+func TestExecutorService_LeaderOnly(t *testing.T) {
+	pegomock.RegisterMockTestingT(t)
+	leaderJob := mocks.NewMockJob()
+	replicaJob := mocks.NewMockJob()
+	s := &ExecutorService{log: logging.NewNoopLogger(t)}
+	s.SetLeader(fakeLeader(false))
+	s.AddJob(JobDefinition{Job: leaderJob, Period: 100 * time.Millisecond, LeaderOnly: true})
+	s.AddJob(JobDefinition{Job: replicaJob, Period: 100 * time.Millisecond})
+	go s.Run()
+	time.Sleep(250 * time.Millisecond)
+	leaderJob.VerifyWasCalled(pegomock.Never()).Run()
+	replicaJob.VerifyWasCalled(pegomock.AtLeast(1)).Run()
+}
@@ -0,0 +1,133 @@
+package scheduled
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+)
+
+const (
+	// LeaderLeaseName is the name of the lease held by the leader.
+	LeaderLeaseName = "leader"
+	// replicaLeasePrefix prefixes the names of the leases each replica holds
+	// to show it's running.
+	replicaLeasePrefix = "replica/"
+)
+
+// LeaseBackend stores leases. It's implemented by locking.Backend.
+type LeaseBackend interface {
+	AcquireLease(lease models.Lease) (models.Lease, bool, error)
+	ReleaseLease(name string, holderID string) error
+	ListLeases() ([]models.Lease, error)
+}
+
+// LeaderElector elects one of the replicas sharing a database as the leader.
+// Only the leader runs the jobs that must only run once across all replicas.
+//
+// It's a Job that must run more often than TTL so that the leases it holds
+// are renewed before they expire. If the leader stops renewing its lease,
+// another replica becomes the leader once the lease expires.
+type LeaderElector struct {
+	Backend LeaseBackend
+	// Replica returns the current state of this replica.
+	Replica func() models.Replica
+	// TTL is how long leases last unless they're renewed.
+	TTL    time.Duration
+	Logger logging.SimpleLogging
+
+	leader   atomic.Bool
+	resigned atomic.Bool
+}
+
+// NewReplicaID returns a unique ID for a replica running on hostname.
+func NewReplicaID(hostname string) string {
+	suffix := make([]byte, 4)
+	rand.Read(suffix) // nolint: errcheck
+	return fmt.Sprintf("%s-%s", hostname, hex.EncodeToString(suffix))
+}
+
+// Run renews this replica's lease and tries to become, or stay, the leader.
+func (e *LeaderElector) Run() {
+	now := time.Now()
+	lease := models.Lease{
+		Holder:    e.Replica(),
+		RenewedAt: now,
+		ExpiresAt: now.Add(e.TTL),
+	}
+	lease.Name = replicaLeasePrefix + lease.Holder.ID
+	if _, _, err := e.Backend.AcquireLease(lease); err != nil {
+		e.Logger.Warn("failed renewing lease of replica %s: %s", lease.Holder.ID, err)
+	}
+	if e.resigned.Load() {
+		return
+	}
+
+	lease.Name = LeaderLeaseName
+	curr, acquired, err := e.Backend.AcquireLease(lease)
+	if err != nil {
+		// Another replica will take over if our lease expires before we
+		// can renew it so we stop acting as the leader now.
+		e.Logger.Warn("failed acquiring leader lease: %s", err)
+		e.setLeader(false, "")
+		return
+	}
+	e.setLeader(acquired, curr.Holder.ID)
+}
+
+// IsLeader returns true if this replica is the leader.
+func (e *LeaderElector) IsLeader() bool {
+	return e.leader.Load()
+}
+
+// Resign stops this replica from being the leader so that another replica
+// can take over while this one shuts down.
+func (e *LeaderElector) Resign() {
+	e.resigned.Store(true)
+	e.leader.Store(false)
+	id := e.Replica().ID
+	if err := e.Backend.ReleaseLease(LeaderLeaseName, id); err != nil {
+		e.Logger.Warn("failed releasing leader lease: %s", err)
+	}
+	// Renew this replica's lease so that its peers see it's shutting down.
+	e.Run()
+}
+
+// Cluster returns the leader, or nil if there's no leader, and the replicas
+// that are running, sorted by ID.
+func (e *LeaderElector) Cluster() (*models.Replica, []models.Replica, error) {
+	leases, err := e.Backend.ListLeases()
+	if err != nil {
+		return nil, nil, err
+	}
+	var leader *models.Replica
+	var replicas []models.Replica
+	for _, lease := range leases {
+		holder := lease.Holder
+		switch {
+		case lease.Name == LeaderLeaseName:
+			leader = &holder
+		case strings.HasPrefix(lease.Name, replicaLeasePrefix):
+			replicas = append(replicas, holder)
+		}
+	}
+	sort.Slice(replicas, func(i, j int) bool { return replicas[i].ID < replicas[j].ID })
+	return leader, replicas, nil
+}
+
+func (e *LeaderElector) setLeader(leader bool, leaderID string) {
+	if e.leader.Swap(leader) == leader {
+		return
+	}
+	if leader {
+		e.Logger.Info("this replica is now the leader")
+	} else {
+		e.Logger.Info("this replica is no longer the leader, the leader is %q", leaderID)
+	}
+}
@@ -0,0 +1,64 @@
+package scheduled_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/runatlantis/atlantis/server/core/redis"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+	"github.com/runatlantis/atlantis/server/scheduled"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestLeaderElector(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb, err := redis.New(mr.Host(), mr.Server().Addr().Port, "", false, false, 0)
+	Ok(t, err)
+	defer rdb.Close() // nolint: errcheck
+
+	newElector := func(id string) *scheduled.LeaderElector {
+		return &scheduled.LeaderElector{
+			Backend: rdb,
+			Replica: func() models.Replica { return models.Replica{ID: id, Hostname: id} },
+			TTL:     time.Minute,
+			Logger:  logging.NewNoopLogger(t),
+		}
+	}
+	a, b := newElector("a"), newElector("b")
+
+	a.Run()
+	b.Run()
+	Assert(t, a.IsLeader(), "exp first replica to be the leader")
+	Assert(t, !b.IsLeader(), "exp second replica not to be the leader")
+
+	leader, replicas, err := b.Cluster()
+	Ok(t, err)
+	Equals(t, "a", leader.ID)
+	Equals(t, 2, len(replicas))
+	Equals(t, "a", replicas[0].ID)
+	Equals(t, "b", replicas[1].ID)
+
+	t.Log("the leader stays the leader when it renews its lease")
+	a.Run()
+	b.Run()
+	Assert(t, a.IsLeader(), "exp leader to renew its lease")
+	Assert(t, !b.IsLeader(), "exp second replica not to be the leader")
+
+	t.Log("another replica takes over when the leader resigns")
+	a.Resign()
+	Assert(t, !a.IsLeader(), "exp resigned replica not to be the leader")
+	b.Run()
+	Assert(t, b.IsLeader(), "exp second replica to take over")
+	a.Run()
+	Assert(t, !a.IsLeader(), "exp resigned replica not to become the leader again")
+
+	t.Log("another replica takes over when the leader's lease expires")
+	c := newElector("c")
+	c.Run()
+	Assert(t, !c.IsLeader(), "exp third replica not to be the leader")
+	mr.FastForward(2 * time.Minute)
+	c.Run()
+	Assert(t, c.IsLeader(), "exp third replica to take over")
+}
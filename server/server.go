@@ -48,6 +48,8 @@ import (
 
 	"github.com/gorilla/mux"
 	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/audit"
+	"github.com/runatlantis/atlantis/server/auth"
 	"github.com/runatlantis/atlantis/server/controllers"
 	events_controllers "github.com/runatlantis/atlantis/server/controllers/events"
 	"github.com/runatlantis/atlantis/server/controllers/templates"
@@ -55,6 +57,7 @@ import (
 	"github.com/runatlantis/atlantis/server/core/locking"
 	"github.com/runatlantis/atlantis/server/core/runtime"
 	"github.com/runatlantis/atlantis/server/core/runtime/policy"
+	"github.com/runatlantis/atlantis/server/core/runtime/sandbox"
 	"github.com/runatlantis/atlantis/server/core/terraform"
 	"github.com/runatlantis/atlantis/server/events"
 	"github.com/runatlantis/atlantis/server/events/command"
@@ -64,6 +67,7 @@ import (
 	"github.com/runatlantis/atlantis/server/events/vcs/bitbucketserver"
 	"github.com/runatlantis/atlantis/server/events/webhooks"
 	"github.com/runatlantis/atlantis/server/logging"
+	"github.com/runatlantis/atlantis/server/remote"
 )
 
 const (
@@ -83,6 +87,9 @@ const (
 	// terraformPluginCacheDir is the name of the dir inside our data dir
 	// where we tell terraform to cache plugins and modules.
 	TerraformPluginCacheDirName = "plugin-cache"
+	// leaderLeaseTTL is how long the leader stays the leader if it stops
+	// renewing its lease, ex. because it crashed.
+	leaderLeaseTTL = 30 * time.Second
 )
 
 // Server runs the Atlantis web server.
@@ -103,9 +110,11 @@ type Server struct {
 	VCSEventsController            *events_controllers.VCSEventsController
 	GithubAppController            *controllers.GithubAppController
 	LocksController                *controllers.LocksController
+	PullsController                *controllers.PullsController
 	StatusController               *controllers.StatusController
 	JobsController                 *controllers.JobsController
 	APIController                  *controllers.APIController
+	WorkersController              *controllers.WorkersController
 	IndexTemplate                  templates.TemplateWriter
 	LockDetailTemplate             templates.TemplateWriter
 	ProjectJobsTemplate            templates.TemplateWriter
@@ -119,8 +128,11 @@ type Server struct {
 	WebAuthentication              bool
 	WebUsername                    string
 	WebPassword                    string
+	OIDCAuthenticator              *auth.OIDCAuthenticator
+	Authorizer                     *auth.Authorizer
 	ProjectCmdOutputHandler        jobs.ProjectCommandOutputHandler
 	ScheduledExecutorService       *scheduled.ExecutorService
+	LeaderElector                  *scheduled.LeaderElector
 }
 
 // Config holds config for server that isn't passed in by the user.
@@ -370,12 +382,64 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		return nil, err
 	}
 
+	var sandboxes *sandbox.Pool
+	if userConfig.Sandbox {
+		firstUID, lastUID, err := sandbox.ParseUIDRange(userConfig.SandboxUIDs)
+		if err != nil {
+			return nil, err
+		}
+		sandboxes, err = sandbox.NewPool(sandbox.Config{
+			FirstUID:       firstUID,
+			LastUID:        lastUID,
+			DataDir:        userConfig.DataDir,
+			ReadOnlyDirs:   []string{binDir},
+			PluginCacheDir: cacheDir,
+			CgroupDir:      userConfig.SandboxCgroupDir,
+			Limits: command.SandboxLimits{
+				Memory:    int64(userConfig.SandboxMemoryLimit) << 20,
+				CPUTime:   time.Duration(userConfig.SandboxCPUTimeLimit) * time.Minute,
+				Processes: userConfig.SandboxMaxProcesses,
+			},
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "initializing sandboxes")
+		}
+	}
+
 	parsedURL, err := ParseAtlantisURL(userConfig.AtlantisURL)
 	if err != nil {
 		return nil, errors.Wrapf(err,
 			"parsing --%s flag %q", config.AtlantisURLFlag, userConfig.AtlantisURL)
 	}
 
+	var oidcAuthenticator *auth.OIDCAuthenticator
+	var oidcGroupRoles bool
+	if userConfig.WebOIDCIssuerURL != "" {
+		sessions, err := auth.NewSessionManager(userConfig.WebSessionSecret, parsedURL)
+		if err != nil {
+			return nil, err
+		}
+		groupRoles, err := userConfig.ToOIDCGroupRoles()
+		if err != nil {
+			return nil, err
+		}
+		oidcGroupRoles = len(groupRoles) > 0
+		oidcAuthenticator, err = auth.NewOIDCAuthenticator(auth.OIDCConfig{
+			IssuerURL:     userConfig.WebOIDCIssuerURL,
+			ClientID:      userConfig.WebOIDCClientID,
+			ClientSecret:  userConfig.WebOIDCClientSecret,
+			RedirectURL:   strings.TrimSuffix(parsedURL.String(), "/") + auth.CallbackPath,
+			Scopes:        strings.Split(userConfig.WebOIDCScopes, ","),
+			UsernameClaim: userConfig.WebOIDCUsernameClaim,
+			GroupsClaim:   userConfig.WebOIDCGroupsClaim,
+			GroupRoles:    groupRoles,
+		}, sessions, parsedURL, logger, &http.Client{Timeout: 30 * time.Second})
+		if err != nil {
+			return nil, errors.Wrap(err, "initializing single sign-on")
+		}
+	}
+	authorizer := auth.NewAuthorizer(globalCfg.Roles, oidcGroupRoles)
+
 	underlyingRouter := mux.NewRouter()
 	router := &Router{
 		AtlantisURL:               parsedURL,
@@ -385,6 +449,23 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		Underlying:                underlyingRouter,
 	}
 
+	var backend locking.Backend
+
+	switch dbtype := userConfig.LockingDBType; dbtype {
+	case "redis":
+		logger.Info("Utilizing Redis DB")
+		backend, err = redis.New(userConfig.RedisHost, userConfig.RedisPort, userConfig.RedisPassword, userConfig.RedisTLSEnabled, userConfig.RedisInsecureSkipVerify, userConfig.RedisDB)
+		if err != nil {
+			return nil, err
+		}
+	case "boltdb":
+		logger.Info("Utilizing BoltDB")
+		backend, err = db.New(userConfig.DataDir)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	var projectCmdOutputHandler jobs.ProjectCommandOutputHandler
 
 	if userConfig.TFEToken != "" && !userConfig.TFELocalExecutionMode {
@@ -392,9 +473,16 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		projectCmdOutputHandler = &jobs.NoopProjectOutputHandler{}
 	} else {
 		projectCmdOutput := make(chan *jobs.ProjectCmdOutputLine)
-		projectCmdOutputHandler = jobs.NewAsyncProjectCommandOutputHandler(
+		// With Redis, job output is published so that it can be streamed
+		// from any replica, not just the one running the job.
+		var outputStore jobs.JobOutputStore
+		if redisDB, ok := backend.(*redis.RedisDB); ok {
+			outputStore = redisDB.JobOutputStore()
+		}
+		projectCmdOutputHandler = jobs.NewAsyncProjectCommandOutputHandlerWithStore(
 			projectCmdOutput,
 			logger,
+			outputStore,
 		)
 	}
 
@@ -417,6 +505,7 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 	if err != nil && flag.Lookup("test.v") == nil {
 		return nil, errors.Wrap(err, "initializing terraform")
 	}
+	terraformClient.SetEnvPolicy(globalCfg.EnvPolicy)
 	markdownRenderer := events.NewMarkdownRenderer(
 		gitlabClient.SupportsCommonMark(),
 		userConfig.DisableApplyAll,
@@ -431,32 +520,34 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 
 	var lockingClient locking.Locker
 	var applyLockingClient locking.ApplyLocker
-	var backend locking.Backend
-
-	switch dbtype := userConfig.LockingDBType; dbtype {
-	case "redis":
-		logger.Info("Utilizing Redis DB")
-		backend, err = redis.New(userConfig.RedisHost, userConfig.RedisPort, userConfig.RedisPassword, userConfig.RedisTLSEnabled, userConfig.RedisInsecureSkipVerify, userConfig.RedisDB)
-		if err != nil {
-			return nil, err
-		}
-	case "boltdb":
-		logger.Info("Utilizing BoltDB")
-		backend, err = db.New(userConfig.DataDir)
-		if err != nil {
-			return nil, err
-		}
-	}
 
 	noOpLocker := locking.NewNoOpLocker()
 	if userConfig.DisableRepoLocking {
 		logger.Info("Repo Locking is disabled")
 		lockingClient = noOpLocker
 	} else {
-		lockingClient = locking.NewClient(backend)
+		lockingClient = locking.NewClientWithLockTTL(backend, globalCfg.LockTTL)
 	}
 
 	applyLockingClient = locking.NewApplyClient(backend, disableApply)
+
+	var auditSinks []audit.Sink
+	var auditDB audit.DBBackend
+	if userConfig.AuditLogFile != "" {
+		fileSink, err := audit.NewFileSink(userConfig.AuditLogFile)
+		if err != nil {
+			return nil, err
+		}
+		auditSinks = append(auditSinks, fileSink)
+	}
+	if userConfig.AuditWebhookURL != "" {
+		auditSinks = append(auditSinks, audit.NewWebhookSink(userConfig.AuditWebhookURL, logger))
+	}
+	if userConfig.AuditDB {
+		auditDB = backend
+		auditSinks = append(auditSinks, &audit.DBSink{Backend: backend, MaxEvents: userConfig.AuditDBMaxEvents})
+	}
+	auditor := audit.NewAuditor(logger, auditSinks...)
 	workingDirLocker := events.NewDefaultWorkingDirLocker()
 
 	var workingDir events.WorkingDir = &events.FileWorkspace{
@@ -464,6 +555,7 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		CheckoutMerge:    userConfig.CheckoutStrategy == "merge",
 		CheckoutDepth:    userConfig.CheckoutDepth,
 		GithubAppEnabled: githubAppEnabled,
+		EnvPolicy:        globalCfg.EnvPolicy,
 		Logger:           logger,
 	}
 
@@ -492,9 +584,11 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 	}
 
 	projectLocker := &events.DefaultProjectLocker{
-		Locker:     lockingClient,
-		NoOpLocker: noOpLocker,
-		VCSClient:  vcsClient,
+		Locker:                lockingClient,
+		NoOpLocker:            noOpLocker,
+		VCSClient:             vcsClient,
+		DisableDraftPRLocking: userConfig.DisableDraftPRLocking,
+		Auditor:               auditor,
 	}
 	deleteLockCommand := &events.DefaultDeleteLockCommand{
 		Locker:           lockingClient,
@@ -504,6 +598,21 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		Backend:          backend,
 	}
 
+	// Projects with a runner label run on remote workers, which pull their
+	// jobs from workerQueue.
+	workerRunnerSecrets, err := remote.ParseRunnerSecrets(userConfig.WorkerRunnerSecrets)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing --worker-runner-secrets")
+	}
+	var workerQueue *remote.Queue
+	if userConfig.WorkerSecret != "" || len(workerRunnerSecrets) > 0 {
+		workerQueue = remote.NewQueue(projectCmdOutputHandler)
+	}
+	var workerPullCleaner events.PullCleaner
+	if workerQueue != nil {
+		workerPullCleaner = workerQueue
+	}
+
 	pullClosedExecutor := events.NewInstrumentedPullClosedExecutor(
 		statsScope,
 		logger,
@@ -515,6 +624,7 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 			PullClosedTemplate:       &events.PullClosedEventTemplate{},
 			LogStreamResourceCleaner: projectCmdOutputHandler,
 			VCSClient:                vcsClient,
+			WorkerQueue:              workerPullCleaner,
 		},
 	)
 
@@ -538,6 +648,7 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		userConfig.ExecutableName,
 		allowCommands,
 	)
+	commentParser.CustomCommands = globalCfg.CustomCommands
 	defaultTfVersion := terraformClient.DefaultVersion()
 	pendingPlanFinder := &events.DefaultPendingPlanFinder{}
 	runStepRunner := &runtime.RunStepRunner{
@@ -545,12 +656,42 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		DefaultTFVersion:        defaultTfVersion,
 		TerraformBinDir:         terraformClient.TerraformBinDir(),
 		ProjectCmdOutputHandler: projectCmdOutputHandler,
+		EnvPolicy:               globalCfg.EnvPolicy,
 	}
 	drainer := &events.Drainer{}
+
+	// Replicas sharing a database elect a leader to run the scheduled jobs
+	// that must only run once.
+	hostname, _ := os.Hostname()
+	replica := models.Replica{
+		ID:        scheduled.NewReplicaID(hostname),
+		Hostname:  hostname,
+		Version:   config.AtlantisVersion,
+		StartedAt: time.Now().UTC(),
+	}
+	leaderElector := &scheduled.LeaderElector{
+		Backend: backend,
+		Replica: func() models.Replica {
+			status := drainer.GetStatus()
+			r := replica
+			r.ShuttingDown = status.ShuttingDown
+			r.InProgressOps = status.InProgressOps
+			return r
+		},
+		TTL:    leaderLeaseTTL,
+		Logger: logger,
+	}
+	scheduledExecutorService.SetLeader(leaderElector)
+	scheduledExecutorService.AddJob(scheduled.JobDefinition{
+		Job:    leaderElector,
+		Period: leaderLeaseTTL / 3,
+	})
+
 	statusController := &controllers.StatusController{
 		Logger:          logger,
 		Drainer:         drainer,
 		AtlantisVersion: config.AtlantisVersion,
+		LeaderElector:   leaderElector,
 	}
 	preWorkflowHooksCommandRunner := &events.DefaultPreWorkflowHooksCommandRunner{
 		VCSClient:        vcsClient,
@@ -559,6 +700,7 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		WorkingDir:       workingDir,
 		PreWorkflowHookRunner: runtime.DefaultPreWorkflowHookRunner{
 			OutputHandler: projectCmdOutputHandler,
+			EnvPolicy:     globalCfg.EnvPolicy,
 		},
 		CommitStatusUpdater: commitStatusUpdater,
 		Router:              router,
@@ -570,6 +712,7 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		WorkingDir:       workingDir,
 		PostWorkflowHookRunner: runtime.DefaultPostWorkflowHookRunner{
 			OutputHandler: projectCmdOutputHandler,
+			EnvPolicy:     globalCfg.EnvPolicy,
 		},
 		CommitStatusUpdater: commitStatusUpdater,
 		Router:              router,
@@ -608,7 +751,7 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 
 	policyCheckStepRunner, err := runtime.NewPolicyCheckStepRunner(
 		defaultTfVersion,
-		policy.NewConfTestExecutorWorkflow(logger, binDir, &terraform.DefaultDownloader{}),
+		policy.NewConfTestExecutorWorkflow(logger, binDir, &terraform.DefaultDownloader{}, globalCfg.EnvPolicy),
 	)
 
 	if err != nil {
@@ -653,6 +796,10 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		Webhooks:                  webhooksManager,
 		WorkingDirLocker:          workingDirLocker,
 		CommandRequirementHandler: applyRequirementHandler,
+		DefaultPlanTimeout:        time.Duration(userConfig.PlanTimeout) * time.Minute,
+		DefaultApplyTimeout:       time.Duration(userConfig.ApplyTimeout) * time.Minute,
+		JobMessageSender:          projectCmdOutputHandler,
+		Sandboxes:                 sandboxes,
 	}
 
 	dbUpdater := &events.DBUpdater{
@@ -668,16 +815,42 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 	autoMerger := &events.AutoMerger{
 		VCSClient:       vcsClient,
 		GlobalAutomerge: userConfig.Automerge,
+		MergeMethod:     models.MergeMethod(userConfig.AutomergeMethod),
+		ChecksTimeout:   time.Duration(userConfig.AutomergeChecksTimeout) * time.Minute,
+		VCSStatusName:   userConfig.VCSStatusName,
+		Drainer:         drainer,
+	}
+
+	remoteProjectCommandRunner := &remote.ProjectCommandRunner{
+		Local:               projectCommandRunner,
+		Queue:               workerQueue,
+		Locker:              projectLocker,
+		LockURLGenerator:    router,
+		WorkingDir:          workingDir,
+		VCSClient:           vcsClient,
+		Webhooks:            webhooksManager,
+		GithubCredentials:   githubCredentials,
+		DefaultPlanTimeout:  time.Duration(userConfig.PlanTimeout) * time.Minute,
+		DefaultApplyTimeout: time.Duration(userConfig.ApplyTimeout) * time.Minute,
+		EnvPolicy:           globalCfg.EnvPolicy,
 	}
 
 	projectOutputWrapper := &events.ProjectOutputWrapper{
 		JobMessageSender:     projectCmdOutputHandler,
-		ProjectCommandRunner: projectCommandRunner,
+		ProjectCommandRunner: remoteProjectCommandRunner,
 		JobURLSetter:         jobs.NewJobURLSetter(router, commitStatusUpdater),
 	}
+	scheduledProjectCmdRunner := &events.ScheduledProjectCommandRunner{
+		ProjectCommandRunner: projectOutputWrapper,
+		Scheduler:            events.NewExecutionScheduler(userConfig.MaxConcurrentProjects, globalCfg.MaxConcurrentProjects, statsScope),
+		StatusUpdater:        commitStatusUpdater,
+	}
 	instrumentedProjectCmdRunner := events.NewInstrumentedProjectCommandRunner(
 		statsScope,
-		projectOutputWrapper,
+		&events.AuditedProjectCommandRunner{
+			ProjectCommandRunner: scheduledProjectCmdRunner,
+			Auditor:              auditor,
+		},
 	)
 
 	policyCheckCommandRunner := events.NewPolicyCheckCommandRunner(
@@ -729,6 +902,16 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		pullReqStatusFetcher,
 	)
 
+	postMergeApplyCommandRunner := events.NewPostMergeApplyCommandRunner(
+		applyLockingClient,
+		workingDir,
+		projectCommandBuilder,
+		instrumentedProjectCmdRunner,
+		instrumentedProjectCmdRunner,
+		pullUpdater,
+		pullReqStatusFetcher,
+	)
+
 	approvePoliciesCommandRunner := events.NewApprovePoliciesCommandRunner(
 		commitStatusUpdater,
 		projectCommandBuilder,
@@ -745,12 +928,13 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		vcsClient,
 		userConfig.SilenceNoProjects,
 		userConfig.DisableUnlockLabel,
+		auditor,
 	)
 
 	versionCommandRunner := events.NewVersionCommandRunner(
 		pullUpdater,
 		projectCommandBuilder,
-		projectOutputWrapper,
+		scheduledProjectCmdRunner,
 		userConfig.ParallelPoolSize,
 		userConfig.SilenceNoProjects,
 	)
@@ -779,6 +963,21 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		command.State:           stateCommandRunner,
 	}
 
+	customCommandRunner := events.NewCustomCommandRunner(
+		vcsClient,
+		globalCfg,
+		authorizer,
+		commitStatusUpdater,
+		pullUpdater,
+		pullReqStatusFetcher,
+		projectCommandBuilder,
+		instrumentedProjectCmdRunner,
+		userConfig.SilenceNoProjects,
+	)
+	for _, c := range globalCfg.CustomCommands {
+		commentCommandRunnerByCmd[command.RegisterCustom(c.Name)] = customCommandRunner
+	}
+
 	githubTeamAllowlistChecker, err := events.NewTeamAllowlistChecker(userConfig.GithubTeamAllowlist)
 	if err != nil {
 		return nil, err
@@ -812,6 +1011,27 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		TeamAllowlistChecker:           githubTeamAllowlistChecker,
 		VarFileAllowlistChecker:        varFileAllowlistChecker,
 		CommitStatusUpdater:            commitStatusUpdater,
+		PostMergeApplyCommandRunner:    postMergeApplyCommandRunner,
+	}
+
+	if !userConfig.DisableLockReaper && !userConfig.DisableRepoLocking {
+		scheduledExecutorService.AddJob(scheduled.JobDefinition{
+			Job: &events.LockReaper{
+				Locker:                   lockingClient,
+				DeleteLockCommand:        deleteLockCommand,
+				Backend:                  backend,
+				VCSClient:                vcsClient,
+				PullCleaner:              pullClosedExecutor,
+				EventParser:              eventParser,
+				GithubPullGetter:         githubClient,
+				GitlabMergeRequestGetter: gitlabClient,
+				AzureDevopsPullGetter:    azuredevopsClient,
+				Auditor:                  auditor,
+				Logger:                   logger,
+			},
+			Period:     time.Duration(userConfig.LockReaperInterval) * time.Minute,
+			LeaderOnly: true,
+		})
 	}
 	repoAllowlist, err := events.NewRepoAllowlistChecker(userConfig.RepoAllowlist)
 	if err != nil {
@@ -829,6 +1049,8 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		WorkingDirLocker:   workingDirLocker,
 		Backend:            backend,
 		DeleteLockCommand:  deleteLockCommand,
+		Authorizer:         authorizer,
+		Auditor:            auditor,
 	}
 
 	wsMux := websocket.NewMultiplexor(
@@ -848,19 +1070,46 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		WsMux:                    wsMux,
 		KeyGenerator:             controllers.JobIDKeyGenerator{},
 		StatsScope:               statsScope.SubScope("api"),
+		OutputHandler:            projectCmdOutputHandler,
+		Authorizer:               authorizer,
+	}
+	pullsController := &controllers.PullsController{
+		AtlantisVersion:         config.AtlantisVersion,
+		AtlantisURL:             parsedURL,
+		Backend:                 backend,
+		Logger:                  logger,
+		PullsTemplate:           templates.PullsTemplate,
+		ProjectCmdOutputHandler: projectCmdOutputHandler,
+		JobURLGenerator:         router,
+		Authorizer:              authorizer,
 	}
 	apiController := &controllers.APIController{
 		APISecret:                 []byte(userConfig.APISecret),
+		APITokens:                 auth.NewAPITokens(backend),
+		AuditDB:                   auditDB,
+		Auditor:                   auditor,
+		Authorizer:                authorizer,
+		Backend:                   backend,
+		DeleteLockCommand:         deleteLockCommand,
+		JobURLGenerator:           router,
 		Locker:                    lockingClient,
 		Logger:                    logger,
 		Parser:                    eventParser,
 		ProjectCommandBuilder:     projectCommandBuilder,
 		ProjectPlanCommandRunner:  instrumentedProjectCmdRunner,
 		ProjectApplyCommandRunner: instrumentedProjectCmdRunner,
+		ProjectCmdOutputHandler:   projectCmdOutputHandler,
 		RepoAllowlistChecker:      repoAllowlist,
 		Scope:                     statsScope.SubScope("api"),
 		VCSClient:                 vcsClient,
 	}
+	workersController := &controllers.WorkersController{
+		Queue:           workerQueue,
+		Secret:          []byte(userConfig.WorkerSecret),
+		RunnerSecrets:   workerRunnerSecrets,
+		AtlantisVersion: config.AtlantisVersion,
+		Logger:          logger,
+	}
 
 	eventsController := &events_controllers.VCSEventsController{
 		CommandRunner:                   commandRunner,
@@ -884,6 +1133,14 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		AzureDevopsWebhookBasicUser:     []byte(userConfig.AzureDevopsWebhookUser),
 		AzureDevopsWebhookBasicPassword: []byte(userConfig.AzureDevopsWebhookPassword),
 		AzureDevopsRequestValidator:     &events_controllers.DefaultAzureDevopsRequestValidator{},
+		MergeGroupStatusUpdater: &events.MergeGroupStatusUpdater{
+			Backend:             backend,
+			CommitStatusUpdater: commitStatusUpdater,
+			VCSClient:           vcsClient,
+			CommitComparer:      githubClient,
+			VerifyProjects:      userConfig.GithubMergeQueueVerifyProjects,
+		},
+		GithubReviewCommenter: githubClient,
 	}
 	githubAppController := &controllers.GithubAppController{
 		AtlantisURL:         parsedURL,
@@ -891,6 +1148,7 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		GithubSetupComplete: githubAppEnabled,
 		GithubHostname:      userConfig.GithubHostname,
 		GithubOrg:           userConfig.GithubOrg,
+		Authorizer:          authorizer,
 	}
 
 	return &Server{
@@ -910,9 +1168,11 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		VCSEventsController:            eventsController,
 		GithubAppController:            githubAppController,
 		LocksController:                locksController,
+		PullsController:                pullsController,
 		JobsController:                 jobsController,
 		StatusController:               statusController,
 		APIController:                  apiController,
+		WorkersController:              workersController,
 		IndexTemplate:                  templates.IndexTemplate,
 		LockDetailTemplate:             templates.LockTemplate,
 		ProjectJobsTemplate:            templates.ProjectJobsTemplate,
@@ -924,7 +1184,10 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		WebAuthentication:              userConfig.WebBasicAuth,
 		WebUsername:                    userConfig.WebUsername,
 		WebPassword:                    userConfig.WebPassword,
+		OIDCAuthenticator:              oidcAuthenticator,
+		Authorizer:                     authorizer,
 		ScheduledExecutorService:       scheduledExecutorService,
+		LeaderElector:                  leaderElector,
 	}, nil
 }
 
@@ -937,12 +1200,30 @@ func (s *Server) Start() error {
 	s.Router.HandleFunc("/status", s.StatusController.Get).Methods("GET")
 	s.Router.PathPrefix("/static/").Handler(http.FileServer(http.FS(staticAssets)))
 	s.Router.HandleFunc("/events", s.VCSEventsController.Post).Methods("POST")
-	s.Router.HandleFunc("/api/plan", s.APIController.Plan).Methods("POST")
-	s.Router.HandleFunc("/api/apply", s.APIController.Apply).Methods("POST")
+	s.Router.HandleFunc("/api/plan", s.APIController.Audited(s.APIController.Plan)).Methods("POST")
+	s.Router.HandleFunc("/api/apply", s.APIController.Audited(s.APIController.Apply)).Methods("POST")
+	s.Router.HandleFunc("/api/locks", s.APIController.Audited(s.APIController.ListLocks)).Methods("GET")
+	s.Router.HandleFunc("/api/locks", s.APIController.Audited(s.APIController.DeleteLocks)).Methods("DELETE")
+	s.Router.HandleFunc("/api/locks/{id}", s.APIController.Audited(s.APIController.GetLock)).Methods("GET")
+	s.Router.HandleFunc("/api/locks/{id}", s.APIController.Audited(s.APIController.DeleteLock)).Methods("DELETE")
+	s.Router.HandleFunc("/api/pulls", s.APIController.Audited(s.APIController.ListPulls)).Methods("GET")
+	s.Router.HandleFunc("/api/tokens", s.APIController.Audited(s.APIController.ListTokens)).Methods("GET")
+	s.Router.HandleFunc("/api/tokens", s.APIController.Audited(s.APIController.CreateToken)).Methods("POST")
+	s.Router.HandleFunc("/api/tokens/{name}", s.APIController.Audited(s.APIController.DeleteToken)).Methods("DELETE")
+	s.Router.HandleFunc("/api/audit", s.APIController.Audited(s.APIController.ListAuditEvents)).Methods("GET")
+	s.Router.HandleFunc(remote.ClaimRoute, s.WorkersController.Claim).Methods("POST")
+	s.Router.HandleFunc(remote.OutputRoute, s.WorkersController.Output).Methods("POST")
+	s.Router.HandleFunc(remote.ResultRoute, s.WorkersController.Result).Methods("POST")
 	s.Router.HandleFunc("/github-app/exchange-code", s.GithubAppController.ExchangeCode).Methods("GET")
 	s.Router.HandleFunc("/github-app/setup", s.GithubAppController.New).Methods("GET")
 	s.Router.HandleFunc("/apply/lock", s.LocksController.LockApply).Methods("POST").Queries()
 	s.Router.HandleFunc("/apply/unlock", s.LocksController.UnlockApply).Methods("DELETE").Queries()
+	s.Router.HandleFunc("/pulls", s.PullsController.GetPulls).Methods("GET")
+	if s.OIDCAuthenticator != nil {
+		s.Router.HandleFunc(auth.LoginPath, s.OIDCAuthenticator.Login).Methods("GET")
+		s.Router.HandleFunc(auth.CallbackPath, s.OIDCAuthenticator.Callback).Methods("GET")
+		s.Router.HandleFunc(auth.LogoutPath, s.OIDCAuthenticator.Logout).Methods("GET")
+	}
 	s.Router.HandleFunc("/locks", s.LocksController.DeleteLock).Methods("DELETE").Queries("id", "{id:.*}")
 	s.Router.HandleFunc("/lock", s.LocksController.GetLock).Methods("GET").
 		Queries(LockViewRouteIDQueryParam, fmt.Sprintf("{%s}", LockViewRouteIDQueryParam)).Name(LockViewRouteName)
@@ -969,6 +1250,8 @@ func (s *Server) Start() error {
 	// Stop on SIGINTs and SIGTERMs.
 	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
 
+	// Elect a leader before the scheduled jobs start running.
+	s.LeaderElector.Run()
 	go s.ScheduledExecutorService.Run()
 
 	go func() {
@@ -995,6 +1278,9 @@ func (s *Server) Start() error {
 	<-stop
 
 	s.Logger.Warn("Received interrupt. Waiting for in-progress operations to complete")
+	// Another replica can take over as the leader while this one drains its
+	// own in-progress operations.
+	s.LeaderElector.Resign()
 	s.waitForDrain()
 
 	// flush stats before shutdown
@@ -1030,7 +1316,15 @@ func (s *Server) waitForDrain() {
 }
 
 // Index is the / route.
-func (s *Server) Index(w http.ResponseWriter, _ *http.Request) {
+func (s *Server) Index(w http.ResponseWriter, r *http.Request) {
+	id, _ := auth.IdentityFromContext(r.Context())
+	if err := s.Authorizer.AuthorizeAny(id, auth.ViewerRole); err != nil {
+		s.Logger.Warn("Forbidden: %s", err)
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprintf(w, "Forbidden: %s", err)
+		return
+	}
+
 	locks, err := s.Locker.List()
 	if err != nil {
 		w.WriteHeader(http.StatusServiceUnavailable)
@@ -1039,8 +1333,11 @@ func (s *Server) Index(w http.ResponseWriter, _ *http.Request) {
 	}
 
 	var lockResults []templates.LockIndexData
-	for id, v := range locks {
-		lockURL, _ := s.Router.Get(LockViewRouteName).URL("id", url.QueryEscape(id))
+	for key, v := range locks {
+		if !s.Authorizer.CanView(id, v.Pull.BaseRepo.ID()) {
+			continue
+		}
+		lockURL, _ := s.Router.Get(LockViewRouteName).URL("id", url.QueryEscape(key))
 		lockResults = append(lockResults, templates.LockIndexData{
 			// NOTE: must use .String() instead of .Path because we need the
 			// query params as part of the lock URL.
@@ -1071,9 +1368,16 @@ func (s *Server) Index(w http.ResponseWriter, _ *http.Request) {
 	//Sort by date - newest to oldest.
 	sort.SliceStable(lockResults, func(i, j int) bool { return lockResults[i].Time.After(lockResults[j].Time) })
 
+	pullToJobMappings := []jobs.PullInfoWithJobIDs{}
+	for _, mapping := range preparePullToJobMappings(s) {
+		if s.Authorizer.CanView(id, mapping.RepoID) {
+			pullToJobMappings = append(pullToJobMappings, mapping)
+		}
+	}
+
 	err = s.IndexTemplate.Execute(w, templates.IndexData{
 		Locks:            lockResults,
-		PullToJobMapping: preparePullToJobMappings(s),
+		PullToJobMapping: pullToJobMappings,
 		ApplyLock:        applyLockData,
 		AtlantisVersion:  s.AtlantisVersion,
 		CleanedBasePath:  s.AtlantisURL.Path,
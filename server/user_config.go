@@ -1,8 +1,10 @@
 package server
 
 import (
+	"fmt"
 	"strings"
 
+	"github.com/runatlantis/atlantis/server/auth"
 	"github.com/runatlantis/atlantis/server/events/command"
 	"github.com/runatlantis/atlantis/server/logging"
 )
@@ -13,9 +15,16 @@ import (
 type UserConfig struct {
 	AllowForkPRs                bool   `mapstructure:"allow-fork-prs"`
 	AllowCommands               string `mapstructure:"allow-commands"`
+	ApplyTimeout                int    `mapstructure:"apply-timeout"`
 	AtlantisURL                 string `mapstructure:"atlantis-url"`
+	AuditDB                     bool   `mapstructure:"audit-db"`
+	AuditDBMaxEvents            int    `mapstructure:"audit-db-max-events"`
+	AuditLogFile                string `mapstructure:"audit-log-file"`
+	AuditWebhookURL             string `mapstructure:"audit-webhook-url"`
 	AutoDiscoverModeFlag        string `mapstructure:"autodiscover-mode"`
 	Automerge                   bool   `mapstructure:"automerge"`
+	AutomergeChecksTimeout      int    `mapstructure:"automerge-checks-timeout"`
+	AutomergeMethod             string `mapstructure:"automerge-method"`
 	AutoplanFileList            string `mapstructure:"autoplan-file-list"`
 	AutoplanModules             bool   `mapstructure:"autoplan-modules"`
 	AutoplanModulesFromProjects string `mapstructure:"autoplan-modules-from-projects"`
@@ -34,7 +43,9 @@ type UserConfig struct {
 	DisableApplyAll             bool   `mapstructure:"disable-apply-all"`
 	DisableAutoplan             bool   `mapstructure:"disable-autoplan"`
 	DisableAutoplanLabel        string `mapstructure:"disable-autoplan-label"`
+	DisableDraftPRLocking       bool   `mapstructure:"disable-draft-pr-locking"`
 	DisableMarkdownFolding      bool   `mapstructure:"disable-markdown-folding"`
+	DisableLockReaper           bool   `mapstructure:"disable-lock-reaper"`
 	DisableRepoLocking          bool   `mapstructure:"disable-repo-locking"`
 	DisableUnlockLabel          string `mapstructure:"disable-unlock-label"`
 	DiscardApprovalOnPlanFlag   bool   `mapstructure:"discard-approval-on-plan"`
@@ -47,6 +58,7 @@ type UserConfig struct {
 	FailOnPreWorkflowHookError      bool   `mapstructure:"fail-on-pre-workflow-hook-error"`
 	HideUnchangedPlanComments       bool   `mapstructure:"hide-unchanged-plan-comments"`
 	GithubAllowMergeableBypassApply bool   `mapstructure:"gh-allow-mergeable-bypass-apply"`
+	GithubMergeQueueVerifyProjects  bool   `mapstructure:"gh-merge-queue-verify-projects"`
 	GithubHostname                  string `mapstructure:"gh-hostname"`
 	GithubToken                     string `mapstructure:"gh-token"`
 	GithubUser                      string `mapstructure:"gh-user"`
@@ -65,13 +77,16 @@ type UserConfig struct {
 	APISecret                       string `mapstructure:"api-secret"`
 	HidePrevPlanComments            bool   `mapstructure:"hide-prev-plan-comments"`
 	LockingDBType                   string `mapstructure:"locking-db-type"`
+	LockReaperInterval              int    `mapstructure:"lock-reaper-interval"`
 	LogLevel                        string `mapstructure:"log-level"`
 	MarkdownTemplateOverridesDir    string `mapstructure:"markdown-template-overrides-dir"`
+	MaxConcurrentProjects           int    `mapstructure:"max-concurrent-projects"`
 	ParallelPoolSize                int    `mapstructure:"parallel-pool-size"`
 	ParallelPlan                    bool   `mapstructure:"parallel-plan"`
 	ParallelApply                   bool   `mapstructure:"parallel-apply"`
 	StatsNamespace                  string `mapstructure:"stats-namespace"`
 	PlanDrafts                      bool   `mapstructure:"allow-draft-prs"`
+	PlanTimeout                     int    `mapstructure:"plan-timeout"`
 	Port                            int    `mapstructure:"port"`
 	QuietPolicyChecks               bool   `mapstructure:"quiet-policy-checks"`
 	RedisDB                         int    `mapstructure:"redis-db"`
@@ -83,6 +98,14 @@ type UserConfig struct {
 	RepoConfig                      string `mapstructure:"repo-config"`
 	RepoConfigJSON                  string `mapstructure:"repo-config-json"`
 	RepoAllowlist                   string `mapstructure:"repo-allowlist"`
+	// Sandbox is whether terraform and run steps run in a sandbox. The other
+	// Sandbox fields configure the sandboxes.
+	Sandbox             bool   `mapstructure:"sandbox"`
+	SandboxCgroupDir    string `mapstructure:"sandbox-cgroup-dir"`
+	SandboxCPUTimeLimit int    `mapstructure:"sandbox-cpu-time-limit"`
+	SandboxMaxProcesses int    `mapstructure:"sandbox-max-processes"`
+	SandboxMemoryLimit  int    `mapstructure:"sandbox-memory-limit"`
+	SandboxUIDs         string `mapstructure:"sandbox-uids"`
 
 	// SilenceNoProjects is whether Atlantis should respond to a PR if no projects are found.
 	SilenceNoProjects   bool `mapstructure:"silence-no-projects"`
@@ -111,6 +134,16 @@ type UserConfig struct {
 	WebBasicAuth               bool            `mapstructure:"web-basic-auth"`
 	WebUsername                string          `mapstructure:"web-username"`
 	WebPassword                string          `mapstructure:"web-password"`
+	WebOIDCClientID            string          `mapstructure:"web-oidc-client-id"`
+	WebOIDCClientSecret        string          `mapstructure:"web-oidc-client-secret"`
+	WebOIDCGroupRoles          string          `mapstructure:"web-oidc-group-roles"`
+	WebOIDCGroupsClaim         string          `mapstructure:"web-oidc-groups-claim"`
+	WebOIDCIssuerURL           string          `mapstructure:"web-oidc-issuer-url"`
+	WebOIDCScopes              string          `mapstructure:"web-oidc-scopes"`
+	WebOIDCUsernameClaim       string          `mapstructure:"web-oidc-username-claim"`
+	WebSessionSecret           string          `mapstructure:"web-session-secret"`
+	WorkerRunnerSecrets        string          `mapstructure:"worker-runner-secrets"`
+	WorkerSecret               string          `mapstructure:"worker-secret"`
 	WriteGitCreds              bool            `mapstructure:"write-git-creds"`
 	WebsocketCheckOrigin       bool            `mapstructure:"websocket-check-origin"`
 	UseTFPluginCache           bool            `mapstructure:"use-tf-plugin-cache"`
@@ -140,6 +173,27 @@ func (u UserConfig) ToAllowCommandNames() ([]command.Name, error) {
 	return allowCommands, nil
 }
 
+// ToOIDCGroupRoles parses WebOIDCGroupRoles, a comma-separated list of
+// group=role pairs, into a map of groups to the roles they grant.
+func (u UserConfig) ToOIDCGroupRoles() (map[string]auth.Role, error) {
+	groupRoles := map[string]auth.Role{}
+	for _, pair := range strings.Split(u.WebOIDCGroupRoles, ",") {
+		if pair == "" {
+			continue
+		}
+		i := strings.LastIndex(pair, "=")
+		if i < 1 {
+			return nil, fmt.Errorf("invalid group role %q, must be of the form group=role", pair)
+		}
+		role, err := auth.ParseRole(pair[i+1:])
+		if err != nil {
+			return nil, err
+		}
+		groupRoles[pair[:i]] = role
+	}
+	return groupRoles, nil
+}
+
 // ToLogLevel returns the LogLevel object corresponding to the user-passed
 // log level.
 func (u UserConfig) ToLogLevel() logging.LogLevel {
@@ -0,0 +1,191 @@
+package server
+
+import (
+	"flag"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/core/config/valid"
+	"github.com/runatlantis/atlantis/server/core/runtime"
+	runtime_models "github.com/runatlantis/atlantis/server/core/runtime/models"
+	"github.com/runatlantis/atlantis/server/core/runtime/policy"
+	"github.com/runatlantis/atlantis/server/core/terraform"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/command"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/webhooks"
+	"github.com/runatlantis/atlantis/server/logging"
+	"github.com/runatlantis/atlantis/server/remote"
+)
+
+// WorkerConfig is the configuration of a remote worker, which is set by the
+// flags of the worker command.
+type WorkerConfig struct {
+	AtlantisURL          string
+	AtlantisVersion      string
+	CheckoutDepth        int
+	CheckoutStrategy     string
+	CloneTTL             time.Duration
+	DataDir              string
+	DefaultTFVersion     string
+	DefaultTFVersionFlag string
+	LogLevel             logging.LogLevel
+	Name                 string
+	Runners              []string
+	TFDownload           bool
+	TFDownloadURL        string
+	UseTFPluginCache     bool
+	WorkerSecret         string
+}
+
+// NewWorker returns a remote worker that runs the jobs of the Atlantis server
+// at config.AtlantisURL. Like NewServer, it injects all the dependencies.
+func NewWorker(config WorkerConfig) (*remote.Worker, error) {
+	logging.SuppressDefaultLogging()
+	logger, err := logging.NewStructuredLoggerFromLevel(config.LogLevel)
+	if err != nil {
+		return nil, err
+	}
+
+	binDir, err := mkSubDir(config.DataDir, BinDirName)
+	if err != nil {
+		return nil, err
+	}
+	cacheDir, err := mkSubDir(config.DataDir, TerraformPluginCacheDirName)
+	if err != nil {
+		return nil, err
+	}
+
+	output := remote.NewOutputForwarder()
+	terraformClient, err := terraform.NewClient(
+		logger,
+		binDir,
+		cacheDir,
+		"",
+		"",
+		config.DefaultTFVersion,
+		config.DefaultTFVersionFlag,
+		config.TFDownloadURL,
+		&terraform.DefaultDownloader{},
+		config.TFDownload,
+		config.UseTFPluginCache,
+		output)
+	// See NewServer.
+	if err != nil && flag.Lookup("test.v") == nil {
+		return nil, errors.Wrap(err, "initializing terraform")
+	}
+	defaultTfVersion := terraformClient.DefaultVersion()
+
+	workingDir := &events.FileWorkspace{
+		DataDir:       config.DataDir,
+		CheckoutMerge: config.CheckoutStrategy == "merge",
+		CheckoutDepth: config.CheckoutDepth,
+		Logger:        logger,
+	}
+	// Commands inherit the worker's environment, ex. the cloud credentials
+	// of its account, as allowed by the env policy sent with each job.
+	runStepRunner := &runtime.RunStepRunner{
+		TerraformExecutor:       terraformClient,
+		DefaultTFVersion:        defaultTfVersion,
+		TerraformBinDir:         terraformClient.TerraformBinDir(),
+		ProjectCmdOutputHandler: output,
+	}
+	showStepRunner, err := runtime.NewShowStepRunner(terraformClient, defaultTfVersion)
+	if err != nil {
+		return nil, errors.Wrap(err, "initializing show step runner")
+	}
+	conftestExecutor := policy.NewConfTestExecutorWorkflow(logger, binDir, &terraform.DefaultDownloader{}, valid.EnvPolicy{})
+	policyCheckStepRunner, err := runtime.NewPolicyCheckStepRunner(defaultTfVersion, conftestExecutor)
+	if err != nil {
+		return nil, errors.Wrap(err, "initializing policy check step runner")
+	}
+
+	// Locks, commit statuses and webhooks are handled by the Atlantis server.
+	statusUpdater := noopStatusUpdater{}
+	projectCommandRunner := &events.DefaultProjectCommandRunner{
+		Locker:           remote.NoopProjectLocker{},
+		LockURLGenerator: noopLockURLGenerator{},
+		InitStepRunner: &runtime.InitStepRunner{
+			TerraformExecutor: terraformClient,
+			DefaultTFVersion:  defaultTfVersion,
+		},
+		PlanStepRunner:        runtime.NewPlanStepRunner(terraformClient, defaultTfVersion, statusUpdater, terraformClient),
+		ShowStepRunner:        showStepRunner,
+		PolicyCheckStepRunner: policyCheckStepRunner,
+		ApplyStepRunner: &runtime.ApplyStepRunner{
+			TerraformExecutor:   terraformClient,
+			DefaultTFVersion:    defaultTfVersion,
+			CommitStatusUpdater: statusUpdater,
+			AsyncTFExec:         terraformClient,
+		},
+		RunStepRunner: runStepRunner,
+		EnvStepRunner: &runtime.EnvStepRunner{
+			RunStepRunner: runStepRunner,
+		},
+		MultiEnvStepRunner: &runtime.MultiEnvStepRunner{
+			RunStepRunner: runStepRunner,
+		},
+		VersionStepRunner: &runtime.VersionStepRunner{
+			TerraformExecutor: terraformClient,
+			DefaultTFVersion:  defaultTfVersion,
+		},
+		ImportStepRunner:  runtime.NewImportStepRunner(terraformClient, defaultTfVersion),
+		StateRmStepRunner: runtime.NewStateRmStepRunner(terraformClient, defaultTfVersion),
+		WorkingDir:        workingDir,
+		Webhooks:          noopWebhooksSender{},
+		WorkingDirLocker:  events.NewDefaultWorkingDirLocker(),
+		CommandRequirementHandler: &events.DefaultCommandRequirementHandler{
+			WorkingDir: workingDir,
+		},
+		JobMessageSender: output,
+	}
+
+	return &remote.Worker{
+		Name:    config.Name,
+		Runners: config.Runners,
+		Version: config.AtlantisVersion,
+		Client: &remote.Client{
+			URL:    config.AtlantisURL,
+			Secret: config.WorkerSecret,
+			// Claims wait for a job for up to 30s on the server.
+			HTTPClient: &http.Client{Timeout: 2 * time.Minute},
+		},
+		ProjectCommandRunner: projectCommandRunner,
+		Output:               output,
+		WorkingDir:           workingDir,
+		DataDir:              config.DataDir,
+		CloneTTL:             config.CloneTTL,
+		SetEnvPolicy: func(envPolicy valid.EnvPolicy) {
+			terraformClient.SetEnvPolicy(envPolicy)
+			workingDir.EnvPolicy = envPolicy
+			runStepRunner.EnvPolicy = envPolicy
+			conftestExecutor.Exec = runtime_models.LocalExec{EnvPolicy: envPolicy}
+		},
+		Logger: logger,
+	}, nil
+}
+
+// noopStatusUpdater is the commit status updater of workers, which leave
+// commit statuses to the Atlantis server.
+type noopStatusUpdater struct{}
+
+func (noopStatusUpdater) UpdateProject(_ command.ProjectContext, _ command.Name, _ models.CommitStatus, _ string, _ *command.ProjectResult) error {
+	return nil
+}
+
+// noopLockURLGenerator is the lock URL generator of workers. The Atlantis
+// server sets the lock URLs of the results of jobs.
+type noopLockURLGenerator struct{}
+
+func (noopLockURLGenerator) GenerateLockURL(_ string) string {
+	return ""
+}
+
+// noopWebhooksSender is the webhooks sender of workers, which leave webhooks
+// to the Atlantis server.
+type noopWebhooksSender struct{}
+
+func (noopWebhooksSender) Send(_ logging.SimpleLogging, _ webhooks.ApplyResult) error {
+	return nil
+}